@@ -14,7 +14,7 @@ const (
 	MaxEncodedVersionLength = 100
 
 	// Version is the current version of siad.
-	Version = "1.5.4"
+	Version = "1.5.5"
 )
 
 // ReleaseTag contains the release tag, such as "rc3". It is supplied at build
@@ -41,12 +41,29 @@ var (
 	renterDownloadAsync       bool   // Downloads files asynchronously
 	renterDownloadRecursive   bool   // Downloads folders recursively.
 	renterDownloadRoot        bool   // Download path start from root instead of the UserFolder.
+	renterDownloadVerify      bool   // Verify downloaded files against their content hash.
 	renterFuseMountAllowOther bool   // Mount fuse with 'AllowOther' set to true.
+	renterFuseMountReadOnly   bool   // Mount fuse with 'ReadOnly' set to true.
 	renterListRecursive       bool   // List files of folder recursively.
 	renterListRoot            bool   // List path start from root instead of the UserFolder.
 	renterRenameRoot          bool   // Rename files relative to root instead of the UserFolder.
+	renterShareASCII          bool   // Create ASCII-armored shares.
 	renterShowHistory         bool   // Show download history in addition to download queue.
 
+	// Renter Backup Schedule Flags
+	renterBackupScheduleKeepDaily    uint64 // number of days for which the most recent scheduled backup is kept
+	renterBackupScheduleKeepLast     uint64 // number of most recent scheduled backups which are kept
+	renterBackupScheduleKeepWeekly   uint64 // number of weeks for which the most recent scheduled backup is kept
+	renterBackupScheduleNameTemplate string // template for the names of scheduled backups
+
+	// Renter Stream URL Flags
+	renterStreamURLExpiry       string // duration after which a signed stream URL expires
+	renterStreamURLMaxDownloads uint64 // number of downloads allowed for a signed stream URL
+
+	// Renter Upload Policy Flags
+	uploadPolicyCipherType     string // cipher type of the upload policy
+	uploadPolicyRepairPriority string // repair priority of the upload policy
+
 	// Renter Allowance Flags
 	allowanceFunds       string // amount of money to be used within a period
 	allowanceHosts       string // number of hosts to form contracts with
@@ -70,6 +87,7 @@ var (
 	// Skykey Flags
 	skykeyID              string // ID used to identify a Skykey.
 	skykeyName            string // Name used to identify a Skykey.
+	skykeyPublicHalf      bool   // Set to true to get the public half of a Skykey.
 	skykeyRenameAs        string // Optional parameter to rename a Skykey while adding it.
 	skykeyShowPrivateKeys bool   // Set to true to show private key data.
 	skykeyType            string // Type used to create a new Skykey.
@@ -81,12 +99,15 @@ var (
 	skynetLsRoot                   bool   // Use root as the base instead of the Skynet folder.
 	skynetPinPortal                string // Portal to use when trying to pin a skylink.
 	skynetUnpinRoot                bool   // Use root as the base instead of the Skynet folder.
+	skynetUploadCompression        string // Compress the file before it is uploaded.
 	skynetUploadDefaultPath        string // Specify the file to serve when no specific file is specified.
 	skynetUploadDisableDefaultPath bool   // This skyfile will not have a default path. The only way to use it is to download it.
 	skynetUploadDryRun             bool   // Perform a dry-run of the upload. This returns the skylink without actually uploading the file to the network.
+	skynetUploadErrorPages         string // JSON object mapping HTTP status codes to the files served for them.
 	skynetUploadRoot               bool   // Use root as the base instead of the Skynet folder.
 	skynetUploadSeparately         bool   // When uploading all files from a directory, upload each file separately, generating individual skylinks.
 	skynetUploadSilent             bool   // Don't report progress while uploading
+	skynetUploadTryFiles           string // Comma separated list of files to try when the requested path doesn't match a file.
 	skynetPortalPublic             bool   // Specify if a portal is public or not
 
 	// Utils Flags
@@ -331,10 +352,14 @@ func initCmds() *cobra.Command {
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
 		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
 		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
-		renterHealthSummaryCmd)
+		renterHealthSummaryCmd, renterUploadPolicyCmd, renterVersionsCmd,
+		renterChangeRedundancyCmd, renterFilesShareCmd, renterFilesImportCmd, renterDrainCmd,
+		renterDrainsCmd, renterStreamURLCmd, renterBackupCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd)
 
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
+	renterBackupCmd.AddCommand(renterBackupScheduleCmd)
+	renterBackupScheduleCmd.AddCommand(renterBackupScheduleDisableCmd, renterBackupScheduleSetCmd)
 	renterContractsCmd.AddCommand(renterContractsViewCmd)
 	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd)
 
@@ -344,12 +369,24 @@ func initCmds() *cobra.Command {
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadAsync, "async", "A", false, "Download file asynchronously")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadRecursive, "recursive", "R", false, "Download folder recursively")
 	renterFilesDownloadCmd.Flags().BoolVar(&renterDownloadRoot, "root", false, "Download files and folders from root instead of from the user home directory")
+	renterFilesDownloadCmd.Flags().BoolVar(&renterDownloadVerify, "verify", false, "Verify the downloaded files against their content hash")
+	renterFilesShareCmd.Flags().BoolVar(&renterShareASCII, "ascii", false, "Create an ASCII-armored share")
+	renterBackupScheduleSetCmd.Flags().StringVar(&renterBackupScheduleNameTemplate, "name-template", "", "Go template for the names of the backups, e.g. 'nightly-{{.Time.Format \"2006-01-02\"}}'")
+	renterBackupScheduleSetCmd.Flags().Uint64Var(&renterBackupScheduleKeepDaily, "keep-daily", 0, "Keep the most recent backup of this many days")
+	renterBackupScheduleSetCmd.Flags().Uint64Var(&renterBackupScheduleKeepLast, "keep-last", 0, "Keep this many of the most recent backups")
+	renterBackupScheduleSetCmd.Flags().Uint64Var(&renterBackupScheduleKeepWeekly, "keep-weekly", 0, "Keep the most recent backup of this many weeks")
+	renterStreamURLCmd.Flags().StringVar(&renterStreamURLExpiry, "expiry", "24h", "Duration after which the URL expires, e.g. 30m or 48h")
+	renterStreamURLCmd.Flags().Uint64Var(&renterStreamURLMaxDownloads, "max-downloads", 0, "Number of times the file can be downloaded, 0 means unlimited")
 	renterFilesListCmd.Flags().BoolVarP(&renterListRecursive, "recursive", "R", false, "Recursively list files and folders")
 	renterFilesListCmd.Flags().BoolVar(&renterListRoot, "root", false, "List files and folders from root instead of from the user home directory")
 	renterFilesUploadCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces a files should be uploaded with")
 	renterFilesUploadCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 	renterFilesRenameCmd.Flags().BoolVar(&renterRenameRoot, "root", false, "Rename files relative to root instead of the user homedir")
+	renterUploadPolicyCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces files in the folder should be uploaded with")
+	renterUploadPolicyCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces files in the folder should be uploaded with")
+	renterUploadPolicyCmd.Flags().StringVar(&uploadPolicyCipherType, "cipher-type", "", "the cipher type files in the folder should be encrypted with")
+	renterUploadPolicyCmd.Flags().StringVar(&uploadPolicyRepairPriority, "repair-priority", "", "the repair priority of files in the folder: low, normal or high")
 
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceFunds, "amount", "", "amount of money in allowance, specified in currency units")
 	renterSetAllowanceCmd.Flags().StringVar(&allowancePeriod, "period", "", "period of allowance in blocks (b), hours (h), days (d) or weeks (w)")
@@ -368,7 +405,9 @@ func initCmds() *cobra.Command {
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxUploadBandwidthPrice, "max-upload-bandwidth-price", "", "the maximum price that the renter will pay to upload data to a host")
 
 	renterFuseCmd.AddCommand(renterFuseMountCmd, renterFuseUnmountCmd)
+	renterVersionsCmd.AddCommand(renterVersionsRestoreCmd, renterVersionsSetCmd)
 	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountAllowOther, "allow-other", "", false, "Allow users other than the user that mounted the fuse directory to access and use the fuse directory")
+	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountReadOnly, "read-only", "", false, "Mount the fuse directory as read-only")
 
 	root.AddCommand(skynetCmd)
 	skynetCmd.AddCommand(skynetBlocklistCmd, skynetConvertCmd, skynetDownloadCmd, skynetLsCmd, skynetPinCmd, skynetPortalsCmd, skynetUnpinCmd, skynetUploadCmd)
@@ -377,9 +416,12 @@ func initCmds() *cobra.Command {
 	skynetUploadCmd.Flags().BoolVar(&skynetUploadRoot, "root", false, "Use the root folder as the base instead of the Skynet folder")
 	skynetUploadCmd.Flags().BoolVar(&skynetUploadDryRun, "dry-run", false, "Perform a dry-run of the upload, returning the skylink without actually uploading the file")
 	skynetUploadCmd.Flags().BoolVarP(&skynetUploadSeparately, "separately", "", false, "Upload each file separately, generating individual skylinks")
+	skynetUploadCmd.Flags().StringVar(&skynetUploadCompression, "compression", "", "Compress the file before it is uploaded, the only supported compression is 'gzip'")
 	skynetUploadCmd.Flags().StringVar(&skynetUploadDefaultPath, "defaultpath", "", "Specify the file to serve when no specific file is specified.")
 	skynetUploadCmd.Flags().BoolVarP(&skynetUploadDisableDefaultPath, "disabledefaultpath", "", false, "This skyfile will not have a default path. The only way to use it is to download it. Mutually exclusive with --defaultpath")
 	skynetUploadCmd.Flags().BoolVarP(&skynetUploadSilent, "silent", "s", false, "Don't report progress while uploading")
+	skynetUploadCmd.Flags().StringVar(&skynetUploadTryFiles, "tryfiles", "", "Comma separated list of files to serve when the requested path doesn't match a file, e.g. '$uri,/index.html'. Mutually exclusive with --defaultpath and --disabledefaultpath")
+	skynetUploadCmd.Flags().StringVar(&skynetUploadErrorPages, "errorpages", "", "JSON object mapping HTTP status codes to the files served for them, e.g. '{\"404\":\"/404.html\"}'")
 	skynetUploadCmd.Flags().StringVar(&skykeyID, "skykeyid", "", "Specify the skykey to be used by its key identifier.")
 	skynetUploadCmd.Flags().StringVar(&skykeyName, "skykeyname", "", "Specify the skykey to be used by name.")
 	skynetUnpinCmd.Flags().BoolVar(&skynetUnpinRoot, "root", false, "Use the root folder as the base instead of the Skynet folder")
@@ -400,6 +442,7 @@ func initCmds() *cobra.Command {
 	skykeyDeleteCmd.AddCommand(skykeyDeleteNameCmd, skykeyDeleteIDCmd)
 	skykeyGetCmd.Flags().StringVar(&skykeyName, "name", "", "The name of the skykey")
 	skykeyGetCmd.Flags().StringVar(&skykeyID, "id", "", "The base-64 encoded skykey ID")
+	skykeyGetCmd.Flags().BoolVar(&skykeyPublicHalf, "public", false, "Get the public half of an x25519-private skykey")
 	skykeyListCmd.Flags().BoolVar(&skykeyShowPrivateKeys, "show-priv-keys", false, "Show private key data.")
 
 	// Daemon Commands
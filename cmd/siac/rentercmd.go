@@ -7,6 +7,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -70,6 +71,44 @@ var (
 		Run:   wrap(renterbackuprestorecmd),
 	}
 
+	renterBackupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "Manage backups of the renter's siafiles",
+		Long:  "Manage backups of the renter's siafiles. Lists the backups stored on hosts.",
+		Run:   wrap(renterbackuplistcmd),
+	}
+
+	renterBackupScheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "View the backup schedule",
+		Long:  "View the schedule for automatic backups and the outcome of the most recent scheduled backup.",
+		Run:   wrap(renterbackupschedulecmd),
+	}
+
+	renterBackupScheduleDisableCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Disable scheduled backups",
+		Long:  "Disable scheduled backups. Existing backups are kept.",
+		Run:   wrap(renterbackupscheduledisablecmd),
+	}
+
+	renterBackupScheduleSetCmd = &cobra.Command{
+		Use:   "set [schedule]",
+		Short: "Set the backup schedule",
+		Long: `Set the schedule for automatic backups. The schedule is a cron expression with
+the fields minute, hour, day of month, month and day of week, e.g. '0 3 * * *'
+for every day at 3am, or one of @hourly, @daily, @weekly, @monthly and
+@every <duration>, e.g. '@every 6h'. After every scheduled backup, the
+scheduled backups which aren't kept by --keep-last, --keep-daily or
+--keep-weekly are deleted from the hosts. If none of them is set, all scheduled
+backups are kept. Backups which weren't created by the schedule are never
+deleted.
+
+Deleted backups are only removed from the hosts' snapshot tables. Their data
+stays in the contracts, and is paid for, until the contracts expire.`,
+		Run: wrap(renterbackupschedulesetcmd),
+	}
+
 	renterBackupListCmd = &cobra.Command{
 		Use:   "listbackups",
 		Short: "List backups stored on hosts",
@@ -171,10 +210,9 @@ the command 'siac renter lost' to see the renter's lost files.`,
 		Use:   "mount [path] [siapath]",
 		Short: "Mount a Sia folder to your disk",
 		Long: `Mount a Sia folder to your disk. Applications will be able to see this folder
-as though it is a normal part of your filesystem.  Currently experimental, and
-read-only. When Sia is ready to support read-write fuse mounting, siac will be
-updated to mount in read-write mode as the default. If you must guarantee that
-read-only mode is used, you must use the API.`,
+as though it is a normal part of your filesystem.  Currently experimental. The
+folder is mounted in read-write mode unless the --read-only flag is set. Writes
+are staged on disk and uploaded when a file is closed.`,
 		Run: wrap(renterfusemountcmd),
 	}
 
@@ -186,6 +224,99 @@ local path where the Sia folder is mounted.`,
 		Run: wrap(renterfuseunmountcmd),
 	}
 
+	renterUploadPolicyCmd = &cobra.Command{
+		Use:   "uploadpolicy [path]",
+		Short: "Set the upload policy of a folder",
+		Long: `Set the upload policy of a folder. Files uploaded into the folder or any of its
+subfolders use the policy's erasure coding settings and cipher type unless they
+are specified by the upload. Chunks of files with a higher repair priority are
+repaired before the other chunks queued for repair at the same time, but
+folders are still queued for repair by their health. Settings which aren't
+specified are inherited from the parent folder. The previous policy of the
+folder is replaced.`,
+		Run: wrap(renteruploadpolicycmd),
+	}
+
+	renterVersionsCmd = &cobra.Command{
+		Use:   "versions [path]",
+		Short: "List the versions of a file",
+		Long: `List the versions of a file which were kept when the file was overwritten.
+Versions are only kept if versioning is enabled for the file's folder.`,
+		Run: wrap(renterversionscmd),
+	}
+
+	renterVersionsRestoreCmd = &cobra.Command{
+		Use:   "restore [path] [version]",
+		Short: "Restore a version of a file",
+		Long: `Replace a file with one of its versions. The replaced file is kept as a new
+version.`,
+		Run: wrap(renterversionsrestorecmd),
+	}
+
+	renterVersionsSetCmd = &cobra.Command{
+		Use:   "set [path] [maxversions] [retentiondays]",
+		Short: "Set the versioning settings of a folder",
+		Long: `Set the versioning settings of a folder. If versioning is enabled, files within
+the folder which are overwritten are kept as hidden versions. 'maxversions' is
+the number of versions which are kept per file and 'retentiondays' is the number
+of days a version is kept. A value of 0 means that there is no limit. Setting
+both values to 0 disables versioning.`,
+		Run: wrap(renterversionssetcmd),
+	}
+
+	renterChangeRedundancyCmd = &cobra.Command{
+		Use:   "changeredundancy [path] [datapieces] [paritypieces]",
+		Short: "Re-encode a file with a new redundancy",
+		Long: `Re-encode the file at [path] with the provided number of data and parity pieces.
+The file keeps its path while it is re-encoded in the background. The progress is
+reported by the /renter/file API endpoint.`,
+		Run: wrap(renterchangeredundancycmd),
+	}
+
+	renterFilesShareCmd = &cobra.Command{
+		Use:   "share [destination] [path...]",
+		Short: "Export files into a share",
+		Long: `Export the files at the provided paths into a share file at [destination]. The
+share contains everything another renter needs to download the files from the
+hosts, including the encryption keys. Use the --ascii flag to create an
+ASCII-armored share.`,
+		Run: renterfilessharecmd,
+	}
+
+	renterFilesImportCmd = &cobra.Command{
+		Use:   "import [source] [path]",
+		Short: "Import the files of a share",
+		Long: `Import the files of the share file at [source] into the folder at [path]. Both
+raw and ASCII-armored shares are supported.`,
+		Run: wrap(renterfilesimportcmd),
+	}
+
+	renterDrainCmd = &cobra.Command{
+		Use:   "drain [hostpubkey]",
+		Short: "Move all data off a host",
+		Long: `Move all the data stored on the host with the provided public key to other hosts.
+The contract with the host is no longer used for uploads and is canceled once the
+data is stored on other hosts. Use 'siac renter drains' to view the progress.`,
+		Run: wrap(renterdraincmd),
+	}
+
+	renterStreamURLCmd = &cobra.Command{
+		Use:   "streamurl [path]",
+		Short: "Create a signed stream URL",
+		Long: `Create a signed URL which allows streaming the file at the provided path, or the
+files within the folder at the provided path, without the API password. The URL
+expires after the duration set with --expiry. If --max-downloads is set, the URL
+can only be used to download the file that many times.`,
+		Run: wrap(renterstreamurlcmd),
+	}
+
+	renterDrainsCmd = &cobra.Command{
+		Use:   "drains",
+		Short: "View the progress of drained hosts",
+		Long:  "View the progress of the hosts which are being drained or were drained.",
+		Run:   wrap(renterdrainscmd),
+	}
+
 	renterSetLocalPathCmd = &cobra.Command{
 		Use:   "setlocalpath [siapath] [newlocalpath]",
 		Short: "Changes the local path of the file",
@@ -1348,6 +1479,70 @@ func renterbackuplistcmd() {
 	}
 }
 
+// renterbackupschedulecmd is the handler for the command `siac renter backup
+// schedule`.
+func renterbackupschedulecmd() {
+	bss, err := httpClient.RenterBackupScheduleGet()
+	if err != nil {
+		die("Failed to retrieve backup schedule", err)
+	}
+	if bss.Schedule == "" {
+		fmt.Println("Scheduled backups are disabled.")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	if bss.Schedule != "" {
+		fmt.Fprintf(w, "Schedule:\t%v\n", bss.Schedule)
+		fmt.Fprintf(w, "Next Backup:\t%v\n", bss.NextRun.Format(time.RFC1123))
+	}
+	fmt.Fprintf(w, "Name Template:\t%v\n", bss.NameTemplate)
+	fmt.Fprintf(w, "Keep Last:\t%v\n", bss.KeepLast)
+	fmt.Fprintf(w, "Keep Daily:\t%v\n", bss.KeepDaily)
+	fmt.Fprintf(w, "Keep Weekly:\t%v\n", bss.KeepWeekly)
+	if !bss.LastRun.IsZero() {
+		fmt.Fprintf(w, "Last Run:\t%v\n", bss.LastRun.Format(time.RFC1123))
+	}
+	if bss.LastBackup != "" {
+		fmt.Fprintf(w, "Last Backup:\t%v\n", bss.LastBackup)
+	}
+	if bss.LastError != "" {
+		fmt.Fprintf(w, "Last Error:\t%v\n", bss.LastError)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterbackupscheduledisablecmd is the handler for the command `siac renter
+// backup schedule disable`.
+func renterbackupscheduledisablecmd() {
+	bss, err := httpClient.RenterBackupScheduleGet()
+	if err != nil {
+		die("Failed to retrieve backup schedule", err)
+	}
+	bs := bss.BackupSchedule
+	bs.Schedule = ""
+	if err := httpClient.RenterBackupSchedulePost(bs); err != nil {
+		die("Failed to disable scheduled backups", err)
+	}
+	fmt.Println("Scheduled backups disabled.")
+}
+
+// renterbackupschedulesetcmd is the handler for the command `siac renter
+// backup schedule set [schedule]`.
+func renterbackupschedulesetcmd(schedule string) {
+	bs := modules.BackupSchedule{
+		Schedule:     schedule,
+		NameTemplate: renterBackupScheduleNameTemplate,
+		KeepDaily:    renterBackupScheduleKeepDaily,
+		KeepLast:     renterBackupScheduleKeepLast,
+		KeepWeekly:   renterBackupScheduleKeepWeekly,
+	}
+	if err := httpClient.RenterBackupSchedulePost(bs); err != nil {
+		die("Failed to set backup schedule", err)
+	}
+	renterbackupschedulecmd()
+}
+
 // rentercontractscmd is the handler for the command `siac renter contracts`.
 // It lists the Renter's contracts.
 func rentercontractscmd() {
@@ -1841,10 +2036,6 @@ func renterfusecmd() {
 
 // renterfusemountcmd is the handler for the command `siac renter fuse mount [path] [siapath]`.
 func renterfusemountcmd(path, siaPathStr string) {
-	// TODO: Once read-write is supported on the backend, the 'true' flag can be
-	// set to 'false' - siac will support mounting read-write by default. Need
-	// to update the help string of the command to indicate that mounting will
-	// mount in read-write mode.
 	path = abs(path)
 	var siaPath modules.SiaPath
 	var err error
@@ -1857,7 +2048,7 @@ func renterfusemountcmd(path, siaPathStr string) {
 		}
 	}
 	opts := modules.MountOptions{
-		ReadOnly:   true,
+		ReadOnly:   renterFuseMountReadOnly,
 		AllowOther: renterFuseMountAllowOther,
 	}
 	err = httpClient.RenterFuseMount(path, siaPath, opts)
@@ -1878,6 +2069,221 @@ func renterfuseunmountcmd(path string) {
 	fmt.Printf("Unmounted %s successfully\n", path)
 }
 
+// renterversionscmd is the handler for the command `siac renter versions
+// [path]`. It lists the versions of a file.
+func renterversionscmd(path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	rfv, err := httpClient.RenterVersionsGet(siaPath)
+	if err != nil {
+		die("Could not fetch versions:", err)
+	}
+	if len(rfv.Versions) == 0 {
+		fmt.Println("No versions.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Version\tOverwritten\tSize\tRedundancy\n")
+	for _, v := range rfv.Versions {
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%.2f\n", v.ID, v.Time.Format("Jan 02 2006 03:04 PM"), modules.FilesizeUnits(v.Size), v.Redundancy)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterversionsrestorecmd is the handler for the command `siac renter
+// versions restore [path] [version]`. It restores a version of a file.
+func renterversionsrestorecmd(path, version string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	err = httpClient.RenterVersionsRestorePost(siaPath, version)
+	if err != nil {
+		die("Could not restore version:", err)
+	}
+	fmt.Printf("Restored version %v of %v\n", version, path)
+}
+
+// renterversionssetcmd is the handler for the command `siac renter versions
+// set [path] [maxversions] [retentiondays]`. It updates the versioning settings
+// of a folder.
+func renterversionssetcmd(path, maxVersionsStr, retentionDaysStr string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	maxVersions, err := strconv.ParseUint(maxVersionsStr, 10, 64)
+	if err != nil {
+		die("Couldn't parse maxversions:", err)
+	}
+	retentionDays, err := strconv.ParseUint(retentionDaysStr, 10, 64)
+	if err != nil {
+		die("Couldn't parse retentiondays:", err)
+	}
+	err = httpClient.RenterDirSetVersioningPost(siaPath, maxVersions, retentionDays)
+	if err != nil {
+		die("Could not set versioning:", err)
+	}
+	if maxVersions == 0 && retentionDays == 0 {
+		fmt.Printf("Disabled versioning for %v\n", path)
+		return
+	}
+	fmt.Printf("Updated versioning settings of %v\n", path)
+}
+
+// renteruploadpolicycmd is the handler for the command `siac renter
+// uploadpolicy [path]`. It updates the upload policy of a folder.
+func renteruploadpolicycmd(path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	var policy modules.UploadPolicy
+	if dataPieces != "" {
+		policy.DataPieces, err = strconv.Atoi(dataPieces)
+		if err != nil {
+			die("Couldn't parse data pieces:", err)
+		}
+	}
+	if parityPieces != "" {
+		policy.ParityPieces, err = strconv.Atoi(parityPieces)
+		if err != nil {
+			die("Couldn't parse parity pieces:", err)
+		}
+	}
+	policy.CipherType = uploadPolicyCipherType
+	err = policy.RepairPriority.FromString(uploadPolicyRepairPriority)
+	if err != nil {
+		die("Couldn't parse repair priority:", err)
+	}
+	err = httpClient.RenterDirSetUploadPolicyPost(siaPath, policy)
+	if err != nil {
+		die("Could not set upload policy:", err)
+	}
+	fmt.Printf("Updated upload policy of %v\n", path)
+}
+
+// renterchangeredundancycmd is the handler for the command `siac renter
+// changeredundancy [path] [datapieces] [paritypieces]`. Re-encodes a file with
+// a new redundancy.
+func renterchangeredundancycmd(path, datapieces, paritypieces string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	dataPieces, parityPieces, err := api.ParseDataAndParityPieces(datapieces, paritypieces)
+	if err != nil {
+		die("Couldn't parse erasure coding parameters:", err)
+	}
+	err = httpClient.RenterChangeRedundancyPost(siaPath, uint64(dataPieces), uint64(parityPieces))
+	if err != nil {
+		die("Could not change redundancy:", err)
+	}
+	fmt.Printf("Started re-encoding %v with %v data pieces and %v parity pieces\n", path, dataPieces, parityPieces)
+}
+
+// renterfilessharecmd is the handler for the command `siac renter share
+// [destination] [path...]`. Exports files into a share.
+func renterfilessharecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	destination := abs(args[0])
+	var siaPaths []modules.SiaPath
+	for _, path := range args[1:] {
+		siaPath, err := modules.NewSiaPath(path)
+		if err != nil {
+			die("Couldn't parse SiaPath:", err)
+		}
+		siaPaths = append(siaPaths, siaPath)
+	}
+	var data []byte
+	if renterShareASCII {
+		rsa, err := httpClient.RenterShareASCIIGet(siaPaths)
+		if err != nil {
+			die("Could not export files:", err)
+		}
+		data = []byte(rsa.ASCIIsia)
+	} else {
+		var err error
+		data, err = httpClient.RenterShareGet(siaPaths)
+		if err != nil {
+			die("Could not export files:", err)
+		}
+	}
+	err := ioutil.WriteFile(destination, data, modules.DefaultFilePerm)
+	if err != nil {
+		die("Could not write share:", err)
+	}
+	fmt.Printf("Exported %v files to %v\n", len(siaPaths), destination)
+}
+
+// renterfilesimportcmd is the handler for the command `siac renter import
+// [source] [path]`. Imports the files of a share.
+func renterfilesimportcmd(source, path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	data, err := ioutil.ReadFile(abs(source))
+	if err != nil {
+		die("Could not read share:", err)
+	}
+	err = httpClient.RenterSharePost(siaPath, data)
+	if err != nil {
+		die("Could not import files:", err)
+	}
+	fmt.Printf("Imported the files of %v into %v\n", source, path)
+}
+
+// renterdraincmd is the handler for the command `siac renter drain
+// [hostpubkey]`. Moves all data off a host.
+func renterdraincmd(hostpubkey string) {
+	var hostKey types.SiaPublicKey
+	if err := hostKey.LoadString(hostpubkey); err != nil {
+		die("Couldn't parse host public key:", err)
+	}
+	err := httpClient.RenterDrainPost(hostKey)
+	if err != nil {
+		die("Could not drain host:", err)
+	}
+	fmt.Printf("Started draining host %v\n", hostpubkey)
+}
+
+// renterdrainscmd is the handler for the command `siac renter drains`. Lists
+// the progress of the hosts which are being drained.
+func renterdrainscmd() {
+	rdg, err := httpClient.RenterDrainGet()
+	if err != nil {
+		die("Could not get drain status:", err)
+	}
+	if len(rdg.Drains) == 0 {
+		fmt.Println("No hosts are being drained.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Host\tStarted\tMoved\tChunks Remaining\tStatus")
+	for _, drain := range rdg.Drains {
+		status := "draining"
+		if drain.Done {
+			status = "done"
+		}
+		if drain.Error != "" {
+			status += " (" + drain.Error + ")"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", drain.HostPublicKey, drain.StartTime.Format(time.RFC822),
+			modules.FilesizeUnits(drain.BytesMoved), drain.ChunksRemaining, status)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
 //rentersetlocalpathcmd is the handler for the command `siac renter setlocalpath [siapath] [newlocalpath]`
 //Changes the trackingpath of the file
 //through API Endpoint
@@ -2505,3 +2911,22 @@ func writeWorkers(workers []modules.WorkerStatus) {
 		die("failed to flush writer:", err)
 	}
 }
+
+// renterstreamurlcmd is the handler for the command `siac renter streamurl
+// [path]`. Creates a signed stream URL for a file or folder.
+func renterstreamurlcmd(path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	expiry, err := time.ParseDuration(renterStreamURLExpiry)
+	if err != nil {
+		die("Couldn't parse expiry:", err)
+	}
+	rsup, err := httpClient.RenterStreamURLPost(siaPath, expiry, renterStreamURLMaxDownloads)
+	if err != nil {
+		die("Could not create stream URL:", err)
+	}
+	fmt.Printf("http://%v%v\n", httpClient.Address, rsup.URL)
+	fmt.Printf("Expires: %v\n", time.Unix(rsup.StreamSignature.Expires, 0).Format(time.RFC1123))
+}
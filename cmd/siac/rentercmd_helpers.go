@@ -96,6 +96,16 @@ func contractStats(contracts []api.RenterContract) (size uint64, spent, remainin
 	return
 }
 
+// startFullDownload starts an async download of the full file at the given
+// root siapath. If the verify flag is set, the download is verified against
+// the file's content hash.
+func startFullDownload(siaPath modules.SiaPath, destination string) (modules.DownloadID, error) {
+	if renterDownloadVerify {
+		return httpClient.RenterDownloadVerifiedGet(siaPath, destination, true, true)
+	}
+	return httpClient.RenterDownloadFullGet(siaPath, destination, true, true)
+}
+
 // downloadDir downloads the dir at the specified siaPath to the specified
 // location. It returns all the files for which a download was initialized as
 // tracked files and the ones which were ignored as skipped. Errors are composed
@@ -125,7 +135,7 @@ func downloadDir(siaPath modules.SiaPath, destination string) (tfs []trackedFile
 		}
 		// Download file.
 		totalSize += file.Filesize
-		_, err = httpClient.RenterDownloadFullGet(file.SiaPath, dst, true, true)
+		_, err = startFullDownload(file.SiaPath, dst)
 		if err != nil {
 			err = errors.AddContext(err, "Failed to start download")
 			return
@@ -526,7 +536,7 @@ func renterFilesDownload(path, destination string) {
 	// the call will return before the download has completed. The call is made
 	// as an async call.
 	start := time.Now()
-	cancelID, err := httpClient.RenterDownloadFullGet(siaPath, destination, true, true)
+	cancelID, err := startFullDownload(siaPath, destination)
 	if err != nil {
 		die("Download could not be started:", err)
 	}
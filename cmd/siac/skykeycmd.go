@@ -41,7 +41,8 @@ var (
 		Use:   "create [name]",
 		Short: "Create a skykey with the given name.",
 		Long: `Create a skykey  with the given name. The --type flag can be
-		used to specify the skykey type. Its default is private-id.`,
+		used to specify the skykey type. Its default is private-id. Valid types
+		are public-id, private-id and x25519-private.`,
 		Run: wrap(skykeycreatecmd),
 	}
 
@@ -68,8 +69,10 @@ var (
 	skykeyGetCmd = &cobra.Command{
 		Use:   "get",
 		Short: "Get the skykey by its name or id",
-		Long:  `Get the base64-encoded skykey using either its name with --name or id with --id`,
-		Run:   wrap(skykeygetcmd),
+		Long: `Get the base64-encoded skykey using either its name with --name or id with --id.
+Use --public to get the public half of an x25519-private skykey, which can be
+shared with others to let them upload skyfiles only you can decrypt.`,
+		Run: wrap(skykeygetcmd),
 	}
 
 	skykeyGetIDCmd = &cobra.Command{
@@ -184,7 +187,7 @@ func skykeydeletenamecmd(name string) {
 
 // skykeygetcmd is a wrapper for skykeyGet that handles skykey get commands.
 func skykeygetcmd() {
-	skykeyStr, err := skykeyGet(httpClient, skykeyName, skykeyID)
+	skykeyStr, err := skykeyGet(httpClient, skykeyName, skykeyID, skykeyPublicHalf)
 	if err != nil {
 		die(err)
 	}
@@ -192,15 +195,18 @@ func skykeygetcmd() {
 	fmt.Printf("Found skykey: %v\n", skykeyStr)
 }
 
-// skykeyGet retrieves the skykey using a name or id flag.
-func skykeyGet(c client.Client, name, id string) (string, error) {
+// skykeyGet retrieves the skykey using a name or id flag. If publicHalf is set,
+// the public half of the skykey is retrieved instead.
+func skykeyGet(c client.Client, name, id string, publicHalf bool) (string, error) {
 	err := validateSkyKeyNameAndIDUsage(name, id)
 	if err != nil {
 		return "", errors.AddContext(err, "cannot validate skykey name and ID usage to get skykey")
 	}
 
 	var sk skykey.Skykey
-	if name != "" {
+	if name != "" && publicHalf {
+		sk, err = c.SkykeyGetPublicHalfByName(name)
+	} else if name != "" {
 		sk, err = c.SkykeyGetByName(name)
 	} else {
 		var skykeyID skykey.SkykeyID
@@ -208,7 +214,11 @@ func skykeyGet(c client.Client, name, id string) (string, error) {
 		if err != nil {
 			return "", errors.AddContext(err, "Could not decode skykey ID")
 		}
-		sk, err = c.SkykeyGetByID(skykeyID)
+		if publicHalf {
+			sk, err = c.SkykeyGetPublicHalfByID(skykeyID)
+		} else {
+			sk, err = c.SkykeyGetByID(skykeyID)
+		}
 	}
 
 	if err != nil {
@@ -194,7 +194,7 @@ func testSkykeyGet(t *testing.T, c client.Client) {
 
 	// Test skykeyGet
 	// known key should have no errors.
-	getKeyStr, err := skykeyGet(c, keyName, "")
+	getKeyStr, err := skykeyGet(c, keyName, "", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -206,7 +206,7 @@ func testSkykeyGet(t *testing.T, c client.Client) {
 // testSkykeyGetUsingNameAndID tests using both name and id params should return an
 // error.
 func testSkykeyGetUsingNameAndID(t *testing.T, c client.Client) {
-	_, err := skykeyGet(c, "name", "id")
+	_, err := skykeyGet(c, "name", "id", false)
 	if err == nil {
 		t.Fatal("Expected error when using both name and id")
 	}
@@ -215,7 +215,7 @@ func testSkykeyGetUsingNameAndID(t *testing.T, c client.Client) {
 // testSkykeyGetUsingNoNameAndNoID test using neither name or id param should return an
 // error.
 func testSkykeyGetUsingNoNameAndNoID(t *testing.T, c client.Client) {
-	_, err := skykeyGet(c, "", "")
+	_, err := skykeyGet(c, "", "", false)
 	if err == nil {
 		t.Fatal("Expected error when using neither name or id params")
 	}
@@ -381,7 +381,7 @@ func testSkykeyListKeysAdditionalKeysDoesntShowPrivateKeys(t *testing.T, c clien
 	for i := 0; i < nExtraKeys; i++ {
 		nextName := fmt.Sprintf("extrakey-%d", i)
 		keyNames = append(keyNames, nextName)
-		nextSkStr, err := skykeyGet(c, nextName, "")
+		nextSkStr, err := skykeyGet(c, nextName, "", false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -424,12 +424,12 @@ func initSkykeyData(t *testing.T, c client.Client, keyStrings, keyNames, keyIDs
 	keyName1 := "createkey1"
 	keyName2 := "createkey testSkykeyGet"
 
-	getKeyStr1, err := skykeyGet(c, keyName1, "")
+	getKeyStr1, err := skykeyGet(c, keyName1, "", false)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	getKeyStr2, err := skykeyGet(c, keyName2, "")
+	getKeyStr2, err := skykeyGet(c, keyName2, "", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -71,7 +71,8 @@ on top of Sia.`,
 		Short: "Download a skylink from skynet.",
 		Long: `Download a file from skynet using a skylink. The download may fail unless this
 node is configured as a skynet portal. Use the --portal flag to fetch a skylink
-file from a chosen skynet portal.`,
+file from a chosen skynet portal. v2 skylinks are resolved through the registry
+to the v1 skylink they currently point to.`,
 		Run: skynetdownloadcmd,
 	}
 
@@ -116,10 +117,12 @@ by --public if you want it to be publicly available.`,
 	}
 
 	skynetUnpinCmd = &cobra.Command{
-		Use:   "unpin [siapath]",
+		Use:   "unpin [siapath | skylink]",
 		Short: "Unpin pinned skyfiles or directories.",
 		Long: `Unpin one or more pinned skyfiles or directories at the given siapaths. The
-files and directories will continue to be available on Skynet if other nodes have pinned them.`,
+files and directories will continue to be available on Skynet if other nodes have pinned them.
+
+If a skylink is provided instead of a siapath, all siafiles that store the skylink will be unpinned.`,
 		Run: skynetunpincmd,
 	}
 
@@ -259,10 +262,16 @@ func skynetdownloadcmd(cmd *cobra.Command, args []string) {
 		}()
 	} else {
 		// Try to perform a download using the client package.
-		reader, err = httpClient.SkynetSkylinkReaderGet(skylink)
+		var header http.Header
+		header, reader, err = httpClient.SkynetSkylinkReaderGetWithHeader(skylink)
 		if err != nil {
 			die("Unable to fetch skylink:", err)
 		}
+		// Let the user know what a v2 skylink was resolved to.
+		resolved := header.Get("Skynet-Resolved-Skylink")
+		if resolved != "" && !strings.HasPrefix(skylink, resolved) {
+			fmt.Printf("Skylink %v resolved to %v\n", skylink, resolved)
+		}
 		defer func() {
 			err = reader.Close()
 			if err != nil {
@@ -529,6 +538,23 @@ func skynetunpincmd(cmd *cobra.Command, skyPathStrs []string) {
 	}
 
 	for _, skyPathStr := range skyPathStrs {
+		// Check whether the argument is a skylink.
+		skylinkStr := strings.TrimPrefix(skyPathStr, "sia://")
+		var skylink modules.Skylink
+		if err := skylink.LoadString(skylinkStr); err == nil {
+			ssup, err := httpClient.SkynetSkylinkUnpinPost(skylinkStr)
+			if err != nil {
+				die(fmt.Sprintf("Failed to unpin skylink %v: %v", skylinkStr, err))
+			}
+			for _, siaPath := range ssup.SiaPaths {
+				fmt.Printf("Unpinned skyfile '%v'\n", siaPath)
+			}
+			for _, siaPath := range ssup.UpdatedSiaPaths {
+				fmt.Printf("Removed skylink from shared skyfile '%v'\n", siaPath)
+			}
+			continue
+		}
+
 		// Create the skypath.
 		skyPath, err := modules.NewSiaPath(skyPathStr)
 		if err != nil {
@@ -812,6 +838,25 @@ func skynetUploadDirectory(sourcePath, destSiaPath string) {
 		fmt.Println("Illegal combination of parameters: --defaultpath and --disabledefaultpath are mutually exclusive.")
 		die()
 	}
+	if skynetUploadCompression != "" {
+		fmt.Println("--compression is not supported when uploading a directory as a single skyfile.")
+		die()
+	}
+	var tryFiles []string
+	if skynetUploadTryFiles != "" {
+		tryFiles = strings.Split(skynetUploadTryFiles, ",")
+	}
+	if len(tryFiles) > 0 && (skynetUploadDisableDefaultPath || skynetUploadDefaultPath != "") {
+		fmt.Println("Illegal combination of parameters: --tryfiles can't be combined with --defaultpath or --disabledefaultpath.")
+		die()
+	}
+	var errorPages map[int]string
+	if skynetUploadErrorPages != "" {
+		err = json.Unmarshal([]byte(skynetUploadErrorPages), &errorPages)
+		if err != nil {
+			die("Failed to parse --errorpages:", err)
+		}
+	}
 	pr, pw := io.Pipe()
 	defer pr.Close()
 	writer := multipart.NewWriter(pw)
@@ -857,6 +902,8 @@ func skynetUploadDirectory(sourcePath, destSiaPath string) {
 		Filename:            skyfilePath.Name(),
 		DefaultPath:         skynetUploadDefaultPath,
 		DisableDefaultPath:  skynetUploadDisableDefaultPath,
+		TryFiles:            tryFiles,
+		ErrorPages:          errorPages,
 		ContentType:         writer.FormDataContentType(),
 	}
 	skylink, _, err := httpClient.SkynetSkyfileMultiPartPost(sup)
@@ -877,6 +924,8 @@ func skynetUploadFileFromReader(source io.Reader, filename string, siaPath modul
 		Filename: filename,
 		Mode:     mode,
 
+		Compression: skynetUploadCompression,
+
 		DryRun: skynetUploadDryRun,
 		Reader: source,
 	}
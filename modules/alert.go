@@ -53,8 +53,24 @@ const (
 	// registered if the host has insufficient collateral budget left to form or
 	// renew a contract
 	AlertIDHostInsufficientCollateral = "host-insufficient-collateral"
+	// AlertIDRenterScheduledBackupFailed is the id of the alert that is
+	// registered if a scheduled backup failed and unregistered once a
+	// scheduled backup succeeds.
+	AlertIDRenterScheduledBackupFailed = "scheduled-backup-failed"
 )
 
+// AlertIDSiafileContentHashMismatch uses a Siafile's UID to create a unique
+// AlertID for a content hash mismatch alert.
+func AlertIDSiafileContentHashMismatch(uid string) AlertID {
+	return AlertID(fmt.Sprintf("content-hash-mismatch:%v", uid))
+}
+
+// AlertIDRenterFuseUploadFailed uses the name of a fuse spool to create a
+// unique AlertID for an alert about a spool which couldn't be uploaded.
+func AlertIDRenterFuseUploadFailed(spool string) AlertID {
+	return AlertID(fmt.Sprintf("fuse-upload-failed:%v", spool))
+}
+
 // AlertIDSiafileLowRedundancy uses a Siafile's UID to create a unique AlertID
 // for a low redundancy alert.
 func AlertIDSiafileLowRedundancy(uid string) AlertID {
@@ -74,6 +74,15 @@ var (
 	// prevent the host from having too much money at risk.
 	defaultMaxEphemeralAccountRisk = types.SiacoinPrecision.Mul64(5)
 
+	// ephemeralStorageMaxDuration is the maximum number of blocks the host is
+	// willing to store a sector for which is paid for from an ephemeral
+	// account instead of a file contract.
+	ephemeralStorageMaxDuration = build.Select(build.Var{
+		Dev:      types.BlockHeight(144),
+		Standard: types.BlocksPerWeek,
+		Testing:  types.BlockHeight(20),
+	}).(types.BlockHeight)
+
 	// logAllLimit is the number of errors of each type that the host will log
 	// before switching to probabilistic logging. If there are not many errors,
 	// it is reasonable that all errors get logged. If there are lots of
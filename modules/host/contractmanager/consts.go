@@ -4,7 +4,9 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/persist"
+	"gitlab.com/NebulousLabs/Sia/types"
 )
 
 // Constants related to the host's alerts.
@@ -15,6 +17,15 @@ const (
 )
 
 const (
+	// ephemeralSectorsFile is the name of the file that is used to persist the
+	// expiry heights of the ephemeral sectors stored by the contract manager.
+	ephemeralSectorsFile = "ephemeralsectors.dat"
+
+	// ephemeralSectorsFileTmp is the name of the file that is used to compact
+	// the ephemeral sectors file. Once it is complete, it replaces the
+	// ephemeral sectors file.
+	ephemeralSectorsFileTmp = "ephemeralsectors.dat_temp"
+
 	// logFile is the name of the file that is used for logging in the contract
 	// manager.
 	logFile = "contractmanager.log"
@@ -48,6 +59,14 @@ const (
 )
 
 const (
+	// ephemeralSectorPersistSize is the size of a persisted ephemeral sector
+	// update. It is the length of the sector root plus the expiry height.
+	ephemeralSectorPersistSize = crypto.HashSize + 8
+
+	// ephemeralStorageDivisor determines the fraction of the host's storage
+	// capacity that can be used for ephemeral sectors.
+	ephemeralStorageDivisor = 10
+
 	// folderAllocationStepSize is the amount of data that gets allocated at a
 	// time when writing out the sparse sector file during a storageFolderAdd or
 	// a storageFolderGrow.
@@ -81,6 +100,14 @@ var (
 		Version: "1.2.0",
 	}
 
+	// ephemeralSectorsMetadataHeader is the header of the file that is used to
+	// persist the expiry heights of the ephemeral sectors.
+	ephemeralSectorsMetadataHeader = types.NewSpecifier("EphemeralSectors")
+
+	// ephemeralSectorsMetadataVersion is the version of the file that is used
+	// to persist the expiry heights of the ephemeral sectors.
+	ephemeralSectorsMetadataVersion = types.NewSpecifier("v1.5.4\n")
+
 	// walMetadata is the header that is used when writing the write ahead log
 	// to disk, so that it may be identified at startup.
 	walMetadata = persist.Metadata{
@@ -90,6 +117,15 @@ var (
 )
 
 var (
+	// ephemeralSectorsCompactThreshold is the number of stale updates the
+	// ephemeral sectors file may contain in addition to twice the number of
+	// ephemeral sectors before it is compacted at startup.
+	ephemeralSectorsCompactThreshold = build.Select(build.Var{
+		Dev:      100,
+		Standard: 1000,
+		Testing:  5,
+	}).(int)
+
 	// MaximumSectorsPerStorageFolder sets an upper bound on how large storage
 	// folders in the host are allowed to be. There is a hard limit at 4
 	// billion sectors because the sector location map only uses 4 bytes to
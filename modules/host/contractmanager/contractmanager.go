@@ -27,12 +27,14 @@ package contractmanager
 
 import (
 	"path/filepath"
+	"sync"
 	"sync/atomic"
 
 	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/persist"
 	siasync "gitlab.com/NebulousLabs/Sia/sync"
+	"gitlab.com/NebulousLabs/Sia/types"
 	"gitlab.com/NebulousLabs/errors"
 )
 
@@ -92,6 +94,15 @@ type ContractManager struct {
 	// or modified.
 	lockedSectors map[sectorID]*sectorLock
 
+	// ephemeralSectors maps the roots of sectors which were stored without a
+	// file contract to the height at which they expire. The expiry heights
+	// are tracked separately from the host's storage obligations and
+	// protected by their own mutex. Updates are appended to the
+	// ephemeralSectorsPersist file.
+	ephemeralSectors        map[crypto.Hash]types.BlockHeight
+	ephemeralSectorsMu      sync.Mutex
+	ephemeralSectorsPersist *persist.AppendOnlyPersist
+
 	// Utilities.
 	dependencies  modules.Dependencies
 	staticAlerter *modules.GenericAlerter
@@ -115,6 +126,8 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 
 		lockedSectors: make(map[sectorID]*sectorLock),
 
+		ephemeralSectors: make(map[crypto.Hash]types.BlockHeight),
+
 		dependencies: dependencies,
 		persistDir:   persistDir,
 
@@ -159,6 +172,13 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 		return nil, errors.AddContext(err, "error while loading contract manager atomic data")
 	}
 
+	// Load the expiry heights of the ephemeral sectors.
+	err = cm.loadEphemeralSectors()
+	if err != nil {
+		cm.log.Println("ERROR: Unable to load the contract manager ephemeral sectors:", err)
+		return nil, errors.AddContext(err, "error while loading the ephemeral sectors")
+	}
+
 	// Load the WAL, repairing any corruption caused by unclean shutdown.
 	err = cm.wal.load()
 	if err != nil {
@@ -0,0 +1,245 @@
+package contractmanager
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/persist"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// ErrEphemeralStorageFull is returned when an ephemeral sector is added
+	// while the ephemeral sectors already take up the maximum amount of
+	// storage.
+	ErrEphemeralStorageFull = errors.New("ephemeral storage of the host is full")
+)
+
+type (
+	// savedEphemeralSector is the persisted version of an ephemeral sector. An
+	// expiry of 0 marks the removal of the sector.
+	savedEphemeralSector struct {
+		Root   crypto.Hash
+		Expiry types.BlockHeight
+	}
+)
+
+// marshalEphemeralSectors marshals the provided ephemeral sectors into a byte
+// slice which can be appended to the persist file.
+func marshalEphemeralSectors(sectors []savedEphemeralSector) []byte {
+	var buf bytes.Buffer
+	for _, es := range sectors {
+		buf.Write(encoding.Marshal(es))
+	}
+	return buf.Bytes()
+}
+
+// unmarshalEphemeralSectors replays the ephemeral sector updates of a persist
+// file. It returns the expiry heights of the sectors which weren't removed and
+// the number of updates.
+func unmarshalEphemeralSectors(b []byte) (map[crypto.Hash]types.BlockHeight, int, error) {
+	if uint64(len(b))%ephemeralSectorPersistSize != 0 {
+		return nil, 0, errors.New("persisted ephemeral sectors have an invalid length")
+	}
+	sectors := make(map[crypto.Hash]types.BlockHeight)
+	var updates int
+	for ; len(b) > 0; b = b[ephemeralSectorPersistSize:] {
+		var es savedEphemeralSector
+		err := encoding.Unmarshal(b[:ephemeralSectorPersistSize], &es)
+		if err != nil {
+			return nil, 0, errors.AddContext(err, "unable to unmarshal ephemeral sector")
+		}
+		if es.Expiry == 0 {
+			delete(sectors, es.Root)
+		} else {
+			sectors[es.Root] = es.Expiry
+		}
+		updates++
+	}
+	return sectors, updates, nil
+}
+
+// loadEphemeralSectors loads the expiry heights of the ephemeral sectors from
+// disk. If the persist file contains a lot more updates than sectors, it is
+// compacted.
+func (cm *ContractManager) loadEphemeralSectors() error {
+	aop, reader, err := persist.NewAppendOnlyPersist(cm.persistDir, ephemeralSectorsFile, ephemeralSectorsMetadataHeader, ephemeralSectorsMetadataVersion)
+	if err != nil {
+		return errors.AddContext(err, "unable to open the ephemeral sectors file")
+	}
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to read the ephemeral sectors file"), aop.Close())
+	}
+	sectors, updates, err := unmarshalEphemeralSectors(b)
+	if err != nil {
+		return errors.Compose(err, aop.Close())
+	}
+	cm.ephemeralSectors = sectors
+	cm.ephemeralSectorsPersist = aop
+	cm.tg.AfterStop(func() {
+		cm.ephemeralSectorsMu.Lock()
+		defer cm.ephemeralSectorsMu.Unlock()
+		err := cm.ephemeralSectorsPersist.Close()
+		if err != nil {
+			cm.log.Println("Error closing the ephemeral sectors file", err)
+		}
+	})
+	if updates <= 2*len(sectors)+ephemeralSectorsCompactThreshold {
+		return nil
+	}
+	return errors.AddContext(cm.compactEphemeralSectors(), "unable to compact the ephemeral sectors file")
+}
+
+// compactEphemeralSectors rewrites the persist file of the ephemeral sectors to
+// only contain the sectors which weren't removed. The new file is written to a
+// temporary location first and then renamed to replace the old one.
+func (cm *ContractManager) compactEphemeralSectors() error {
+	tmpPath := filepath.Join(cm.persistDir, ephemeralSectorsFileTmp)
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return errors.AddContext(err, "unable to remove old temporary file")
+	}
+	tmp, _, err := persist.NewAppendOnlyPersist(cm.persistDir, ephemeralSectorsFileTmp, ephemeralSectorsMetadataHeader, ephemeralSectorsMetadataVersion)
+	if err != nil {
+		return errors.AddContext(err, "unable to create temporary file")
+	}
+	sectors := make([]savedEphemeralSector, 0, len(cm.ephemeralSectors))
+	for root, expiry := range cm.ephemeralSectors {
+		sectors = append(sectors, savedEphemeralSector{
+			Root:   root,
+			Expiry: expiry,
+		})
+	}
+	_, err = tmp.Write(marshalEphemeralSectors(sectors))
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to write temporary file"), tmp.Close())
+	}
+	err = errors.Compose(tmp.Close(), cm.ephemeralSectorsPersist.Close())
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, cm.ephemeralSectorsPersist.FilePath())
+	if err != nil {
+		return errors.AddContext(err, "unable to replace the ephemeral sectors file")
+	}
+	cm.ephemeralSectorsPersist, _, err = persist.NewAppendOnlyPersist(cm.persistDir, ephemeralSectorsFile, ephemeralSectorsMetadataHeader, ephemeralSectorsMetadataVersion)
+	return errors.AddContext(err, "unable to reopen the ephemeral sectors file")
+}
+
+// saveEphemeralSectors appends updates of the ephemeral sectors to the persist
+// file. The caller needs to hold the ephemeralSectorsMu.
+func (cm *ContractManager) saveEphemeralSectors(sectors ...savedEphemeralSector) error {
+	_, err := cm.ephemeralSectorsPersist.Write(marshalEphemeralSectors(sectors))
+	return err
+}
+
+// managedMaxEphemeralSectors returns the maximum number of ephemeral sectors
+// the contract manager stores. It is a fraction of the capacity of all storage
+// folders.
+func (cm *ContractManager) managedMaxEphemeralSectors() int {
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	var sectors uint64
+	for _, sf := range cm.storageFolders {
+		sectors += 64 * uint64(len(sf.usage))
+	}
+	return int(sectors / ephemeralStorageDivisor)
+}
+
+// AddEphemeralSector adds a sector to the contract manager which is not
+// covered by a file contract and will be removed once the provided expiry
+// height is reached. If the sector is already stored as an ephemeral sector,
+// its expiry height is extended instead.
+func (cm *ContractManager) AddEphemeralSector(root crypto.Hash, sectorData []byte, expiry types.BlockHeight) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	maxSectors := cm.managedMaxEphemeralSectors()
+
+	cm.ephemeralSectorsMu.Lock()
+	defer cm.ephemeralSectorsMu.Unlock()
+
+	// If the sector is already tracked, only extend its expiry.
+	oldExpiry, exists := cm.ephemeralSectors[root]
+	if exists {
+		if expiry <= oldExpiry {
+			return nil
+		}
+		err = cm.saveEphemeralSectors(savedEphemeralSector{Root: root, Expiry: expiry})
+		if err != nil {
+			return errors.AddContext(err, "unable to extend ephemeral sector expiry")
+		}
+		cm.ephemeralSectors[root] = expiry
+		return nil
+	}
+
+	// Check that there is enough ephemeral storage left.
+	if len(cm.ephemeralSectors) >= maxSectors {
+		return ErrEphemeralStorageFull
+	}
+
+	// Persist the expiry before adding the sector. That way an unclean
+	// shutdown can't cause the sector to be stored forever.
+	err = cm.saveEphemeralSectors(savedEphemeralSector{Root: root, Expiry: expiry})
+	if err != nil {
+		return errors.AddContext(err, "unable to persist ephemeral sector expiry")
+	}
+	cm.ephemeralSectors[root] = expiry
+	err = cm.AddSector(root, sectorData)
+	if err != nil {
+		delete(cm.ephemeralSectors, root)
+		return errors.Compose(err, cm.saveEphemeralSectors(savedEphemeralSector{Root: root}))
+	}
+	return nil
+}
+
+// EphemeralSectorExpiry returns the height at which the ephemeral sector with
+// the provided root expires and whether it exists.
+func (cm *ContractManager) EphemeralSectorExpiry(root crypto.Hash) (types.BlockHeight, bool) {
+	cm.ephemeralSectorsMu.Lock()
+	defer cm.ephemeralSectorsMu.Unlock()
+	expiry, exists := cm.ephemeralSectors[root]
+	return expiry, exists
+}
+
+// RemoveExpiredEphemeralSectors removes all ephemeral sectors with an expiry
+// height smaller than or equal to the provided height from the contract
+// manager.
+func (cm *ContractManager) RemoveExpiredEphemeralSectors(height types.BlockHeight) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	cm.ephemeralSectorsMu.Lock()
+	defer cm.ephemeralSectorsMu.Unlock()
+
+	var removed []savedEphemeralSector
+	for root, expiry := range cm.ephemeralSectors {
+		if expiry > height {
+			continue
+		}
+		err = cm.RemoveSector(root)
+		if err != nil && !errors.Contains(err, ErrSectorNotFound) {
+			// Keep the sector around to try again at the next height.
+			cm.log.Printf("WARN: unable to remove expired ephemeral sector %v: %v", root, err)
+			continue
+		}
+		delete(cm.ephemeralSectors, root)
+		removed = append(removed, savedEphemeralSector{Root: root})
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	return errors.AddContext(cm.saveEphemeralSectors(removed...), "unable to persist ephemeral sectors after removing expired ones")
+}
@@ -0,0 +1,153 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/persist"
+)
+
+// TestEphemeralSectors tests adding ephemeral sectors to the contract manager
+// and removing them once they expire.
+func TestEphemeralSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add two ephemeral sectors with different expiry heights.
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	err = cmt.cm.AddEphemeralSector(root1, data1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddEphemeralSector(root2, data2, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmt.cm.HasSector(root1) || !cmt.cm.HasSector(root2) {
+		t.Fatal("ephemeral sectors should exist")
+	}
+
+	// Adding the first sector again with a lower expiry shouldn't change its
+	// expiry. Adding it with a higher expiry should extend it.
+	err = cmt.cm.AddEphemeralSector(root1, data1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiry, exists := cmt.cm.EphemeralSectorExpiry(root1); !exists || expiry != 10 {
+		t.Fatal("unexpected expiry", expiry, exists)
+	}
+	err = cmt.cm.AddEphemeralSector(root1, data1, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiry, exists := cmt.cm.EphemeralSectorExpiry(root1); !exists || expiry != 15 {
+		t.Fatal("unexpected expiry", expiry, exists)
+	}
+
+	// Reload the contract manager and check that the expiries were persisted.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiry, exists := cmt.cm.EphemeralSectorExpiry(root1); !exists || expiry != 15 {
+		t.Fatal("unexpected expiry after reload", expiry, exists)
+	}
+	if expiry, exists := cmt.cm.EphemeralSectorExpiry(root2); !exists || expiry != 20 {
+		t.Fatal("unexpected expiry after reload", expiry, exists)
+	}
+
+	// Remove the expired sectors at height 15. Only the first one should be
+	// removed.
+	err = cmt.cm.RemoveExpiredEphemeralSectors(15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := cmt.cm.EphemeralSectorExpiry(root1); exists || cmt.cm.HasSector(root1) {
+		t.Fatal("first sector should have been removed")
+	}
+	if _, exists := cmt.cm.EphemeralSectorExpiry(root2); !exists || !cmt.cm.HasSector(root2) {
+		t.Fatal("second sector shouldn't have been removed")
+	}
+
+	// Remove the second one.
+	err = cmt.cm.RemoveExpiredEphemeralSectors(25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := cmt.cm.EphemeralSectorExpiry(root2); exists || cmt.cm.HasSector(root2) {
+		t.Fatal("second sector should have been removed")
+	}
+
+	// Ephemeral sectors can only use a fraction of the storage capacity.
+	maxSectors := cmt.cm.managedMaxEphemeralSectors()
+	if maxSectors != 64/ephemeralStorageDivisor {
+		t.Fatal("unexpected max number of ephemeral sectors", maxSectors)
+	}
+	for i := 0; i < maxSectors; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddEphemeralSector(root, data, 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, data := randSector()
+	err = cmt.cm.AddEphemeralSector(root, data, 30)
+	if !errors.Contains(err, ErrEphemeralStorageFull) {
+		t.Fatal("expected ErrEphemeralStorageFull", err)
+	}
+	if cmt.cm.HasSector(root) {
+		t.Fatal("sector shouldn't have been added")
+	}
+
+	// Remove all sectors. Since the persist file only contains stale updates
+	// now, it should be compacted when the contract manager is reloaded.
+	err = cmt.cm.RemoveExpiredEphemeralSectors(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmt.cm.ephemeralSectors) != 0 {
+		t.Fatal("no ephemeral sectors should be left", len(cmt.cm.ephemeralSectors))
+	}
+	fi, err := os.Stat(cmt.cm.ephemeralSectorsPersist.FilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(fi.Size()) != persist.MetadataPageSize {
+		t.Fatal("ephemeral sectors file wasn't compacted", fi.Size())
+	}
+}
@@ -387,6 +387,10 @@ func (h *Host) managedUpdatePriceTable() {
 		SubscriptionMemoryCost:           types.NewCurrency64(1),
 		SubscriptionNotificationBaseCost: hes.DownloadBandwidthPrice.Mul64(registry.PersistedEntrySize),
 
+		// Ephemeral storage related fields.
+		EphemeralStorageCost:        hes.StoragePrice,
+		EphemeralStorageMaxDuration: ephemeralStorageMaxDuration,
+
 		// TxnFee related fields.
 		TxnFeeMinRecommended: minRecommended,
 		TxnFeeMaxRecommended: maxRecommended,
@@ -760,6 +764,30 @@ func (h *Host) BlockHeight() types.BlockHeight {
 	return h.blockHeight
 }
 
+// ContractSectorRoot returns the public key of the renter and the sector root
+// at the given index of the storage obligation with the given id. The MDM uses
+// it to copy sectors between contracts of the same renter.
+func (h *Host) ContractSectorRoot(fcid types.FileContractID, sectorIdx uint64) (types.SiaPublicKey, crypto.Hash, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return types.SiaPublicKey{}, crypto.Hash{}, err
+	}
+	defer h.tg.Done()
+	sos, err := h.managedGetStorageObligationSnapshot(fcid)
+	if err != nil {
+		return types.SiaPublicKey{}, crypto.Hash{}, errors.AddContext(err, "failed to get storage obligation snapshot")
+	}
+	roots := sos.SectorRoots()
+	if sectorIdx >= uint64(len(roots)) {
+		return types.SiaPublicKey{}, crypto.Hash{}, fmt.Errorf("sector index out-of-bounds: %v >= %v", sectorIdx, len(roots))
+	}
+	uc := sos.RecentRevision().UnlockConditions
+	if len(uc.PublicKeys) == 0 {
+		return types.SiaPublicKey{}, crypto.Hash{}, errors.New("storage obligation doesn't contain a renter key")
+	}
+	return uc.PublicKeys[0], roots[sectorIdx], nil
+}
+
 // managedExternalSettings returns the host's external settings. These values
 // cannot be set by the user (host is configured through InternalSettings), and
 // are the values that get displayed to other hosts on the network.
@@ -780,6 +808,16 @@ func (h *Host) RegistryGet(pubKey types.SiaPublicKey, tweak crypto.Hash) (module
 	return h.staticRegistry.Get(pubKey, tweak)
 }
 
+// RegistryGetRID retrieves a value from the registry by its entry ID.
+func (h *Host) RegistryGetRID(rid crypto.Hash) (types.SiaPublicKey, modules.SignedRegistryValue, bool) {
+	err := h.tg.Add()
+	if err != nil {
+		return types.SiaPublicKey{}, modules.SignedRegistryValue{}, false
+	}
+	defer h.tg.Done()
+	return h.staticRegistry.GetByRID(rid)
+}
+
 // RegistryUpdate updates a value in the registry.
 func (h *Host) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error) {
 	err := h.tg.Add()
@@ -69,6 +69,13 @@ func (tb *testProgramBuilder) AddAppendInstruction(data []byte, merkleProof bool
 	tb.staticValues.AddAppendInstruction(data)
 }
 
+// AddCopySectorInstruction adds a copysector instruction to the builder,
+// keeping track of running values.
+func (tb *testProgramBuilder) AddCopySectorInstruction(fcid types.FileContractID, sectorIdx uint64, merkleProof bool) {
+	tb.staticPB.AddCopySectorInstruction(fcid, sectorIdx, merkleProof)
+	tb.staticValues.AddCopySectorInstruction()
+}
+
 // AddDropSectorsInstruction adds a dropsectors instruction to the builder,
 // keeping track of running values.
 func (tb *testProgramBuilder) AddDropSectorsInstruction(numSectors uint64, merkleProof bool) {
@@ -103,6 +110,16 @@ func (tb *testProgramBuilder) AddRevisionInstruction() {
 	tb.staticValues.AddRevisionInstruction()
 }
 
+// AddStoreSectorInstruction adds a StoreSector instruction to the builder,
+// keeping track of running values.
+func (tb *testProgramBuilder) AddStoreSectorInstruction(data []byte, duration types.BlockHeight) {
+	err := tb.staticPB.AddStoreSectorInstruction(data, duration)
+	if err != nil {
+		panic(err)
+	}
+	tb.staticValues.AddStoreSectorInstruction(duration)
+}
+
 // AddSwapSectorInstruction adds a SwapSector instruction to the builder,
 // keeping track of running values.
 func (tb *testProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx uint64, merkleProof bool) {
@@ -110,6 +127,23 @@ func (tb *testProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx ui
 	tb.staticValues.AddSwapSectorInstruction()
 }
 
+// AddTruncateInstruction adds a truncate instruction to the builder, keeping
+// track of running values.
+func (tb *testProgramBuilder) AddTruncateInstruction(numSectors uint64, merkleProof bool) {
+	tb.staticPB.AddTruncateInstruction(numSectors, merkleProof)
+	tb.staticValues.AddTruncateInstruction(numSectors)
+}
+
+// AddUpdateSectorInstruction adds an updatesector instruction to the builder,
+// keeping track of running values.
+func (tb *testProgramBuilder) AddUpdateSectorInstruction(offset uint64, data []byte, merkleProof bool) {
+	err := tb.staticPB.AddUpdateSectorInstruction(offset, data, merkleProof)
+	if err != nil {
+		panic(err)
+	}
+	tb.staticValues.AddUpdateSectorInstruction(data)
+}
+
 // AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the
 // builder, keeping track of running values.
 func (tb *testProgramBuilder) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv modules.SignedRegistryValue) {
@@ -130,6 +164,16 @@ func (tb *testProgramBuilder) AddReadRegistryInstruction(spk types.SiaPublicKey,
 	tb.staticValues.AddReadRegistryInstruction(spk)
 }
 
+// AddReadRegistryEIDInstruction adds an ReadRegistryEID instruction to the
+// builder, keeping track of running values.
+func (tb *testProgramBuilder) AddReadRegistryEIDInstruction(rid crypto.Hash) {
+	err := tb.staticPB.AddReadRegistryEIDInstruction(rid)
+	if err != nil {
+		panic(err)
+	}
+	tb.staticValues.AddReadRegistryEIDInstruction()
+}
+
 // Program returns the built program.
 func (tb *testProgramBuilder) Program() (modules.Program, modules.ProgramData) {
 	return tb.staticPB.Program()
@@ -0,0 +1,150 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// errCopySectorWrongRenter is returned if the contract a sector is copied
+	// from doesn't belong to the renter of the program's contract.
+	errCopySectorWrongRenter = errors.New("can't copy sector from a contract of another renter")
+
+	// errCopySectorNoRenterKey is returned if the renter's key can't be
+	// determined from the program's contract.
+	errCopySectorNoRenterKey = errors.New("program's contract doesn't contain a renter key")
+)
+
+// instructionCopySector is an instruction that appends a sector from another
+// contract of the same renter to a file contract.
+type instructionCopySector struct {
+	commonInstruction
+
+	fcidOffset      uint64
+	sectorIdxOffset uint64
+}
+
+// staticDecodeCopySectorInstruction creates a new 'CopySector' instruction from
+// the provided generic instruction.
+func (p *program) staticDecodeCopySectorInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierCopySector {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierCopySector, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCICopySectorLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCICopySectorLen, len(instruction.Args))
+	}
+	// Read args.
+	fcidOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	sectorIdxOffset := binary.LittleEndian.Uint64(instruction.Args[8:16])
+	return &instructionCopySector{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: instruction.Args[16] == 1,
+			staticState:       p.staticProgramState,
+		},
+		fcidOffset:      fcidOffset,
+		sectorIdxOffset: sectorIdxOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionCopySector) Batch() bool {
+	return false
+}
+
+// Execute executes the 'CopySector' instruction.
+func (i *instructionCopySector) Execute(prevOutput output) output {
+	// Fetch the data.
+	fcidHash, err := i.staticData.Hash(i.fcidOffset)
+	if err != nil {
+		return errOutput(err)
+	}
+	fcid := types.FileContractID(fcidHash)
+	sectorIdx, err := i.staticData.Uint64(i.sectorIdxOffset)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	// Look up the sector and make sure the other contract belongs to the same
+	// renter.
+	ps := i.staticState
+	renterKey, err := programRenterKey(ps)
+	if err != nil {
+		return errOutput(err)
+	}
+	otherRenterKey, sectorRoot, err := ps.host.ContractSectorRoot(fcid, sectorIdx)
+	if err != nil {
+		return errOutput(errors.AddContext(err, "failed to fetch sector root of other contract"))
+	}
+	if !renterKey.Equals(otherRenterKey) {
+		return errOutput(errCopySectorWrongRenter)
+	}
+	sectorData, err := ps.sectors.readSector(ps.host, sectorRoot)
+	if err != nil {
+		return errOutput(err)
+	}
+	newFileSize := prevOutput.NewSize + modules.SectorSize
+
+	oldSectors := ps.sectors.merkleRoots
+	newMerkleRoot, err := ps.sectors.copySector(sectorRoot, sectorData)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	// Construct proof if necessary. The copied sector root is returned as the
+	// output since the renter might not know it yet.
+	var proof []crypto.Hash
+	if i.staticMerkleProof {
+		proof = crypto.MerkleDiffProof(nil, uint64(len(oldSectors)), nil, oldSectors)
+	}
+
+	return output{
+		NewSize:       newFileSize,
+		NewMerkleRoot: newMerkleRoot,
+		Output:        sectorRoot[:],
+		Proof:         proof,
+	}
+}
+
+// programRenterKey returns the renter's public key of the contract the program
+// is executed on.
+func programRenterKey(ps *programState) (types.SiaPublicKey, error) {
+	revs := ps.staticRevisionTxn.FileContractRevisions
+	if len(revs) == 0 || len(revs[0].UnlockConditions.PublicKeys) == 0 {
+		return types.SiaPublicKey{}, errCopySectorNoRenterKey
+	}
+	return revs[0].UnlockConditions.PublicKeys[0], nil
+}
+
+// Collateral returns the collateral cost of adding one full sector.
+func (i *instructionCopySector) Collateral() types.Currency {
+	return modules.MDMCopySectorCollateral(i.staticState.priceTable)
+}
+
+// Cost returns the Cost of this `CopySector` instruction.
+func (i *instructionCopySector) Cost() (executionCost, storage types.Currency, err error) {
+	duration := i.staticState.staticRemainingDuration
+	executionCost, storage = modules.MDMCopyCost(i.staticState.priceTable, duration)
+	return
+}
+
+// Memory returns the memory allocated by the 'CopySector' instruction beyond
+// the lifetime of the instruction.
+func (i *instructionCopySector) Memory() uint64 {
+	return modules.MDMCopySectorMemory()
+}
+
+// Time returns the execution time of a 'CopySector' instruction.
+func (i *instructionCopySector) Time() (uint64, error) {
+	return modules.MDMTimeCopySector, nil
+}
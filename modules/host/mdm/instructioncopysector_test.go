@@ -0,0 +1,79 @@
+package mdm
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestInstructionCopySector tests executing a program with a single CopySector
+// instruction.
+func TestInstructionCopySector(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Create a storage obligation with some random sectors and another one
+	// with a single sector for the same renter.
+	src := host.newTestStorageObligation(true)
+	src.AddRandomSectors(3)
+	so := host.newTestStorageObligation(true)
+	so.sk = src.sk
+	so.AddRandomSector()
+
+	// Prepare a priceTable and duration.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+
+	ics := so.ContractSize()
+	oldRoots := append([]crypto.Hash{}, so.sectorRoots...)
+	copiedRoot := src.sectorRoots[1]
+
+	// Use a builder to build the program.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddCopySectorInstruction(src.id, 1, true)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert the output.
+	newRoots := append(oldRoots, copiedRoot)
+	expectedProof := crypto.MerkleDiffProof(nil, uint64(len(oldRoots)), nil, oldRoots)
+	err = outputs[0].assert(ics+modules.SectorSize, cachedMerkleRoot(newRoots), expectedProof, copiedRoot[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The storage obligation should contain the copied sector.
+	if len(so.sectorRoots) != 2 || so.sectorRoots[1] != copiedRoot {
+		t.Fatal("copied sector wasn't added to the storage obligation")
+	}
+	if _, exists := so.sectorMap[copiedRoot]; !exists {
+		t.Fatal("copied sector wasn't added to the sector map")
+	}
+
+	// Copying from a contract of another renter should fail.
+	other := host.newTestStorageObligation(true)
+	other.AddRandomSector()
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddCopySectorInstruction(other.id, 0, true)
+	_, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err == nil || !strings.Contains(err.Error(), errCopySectorWrongRenter.Error()) {
+		t.Fatal("expected copying from another renter's contract to fail", err)
+	}
+
+	// Copying a sector which is out of bounds should fail.
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddCopySectorInstruction(src.id, 3, true)
+	_, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err == nil || !strings.Contains(err.Error(), "out-of-bounds") {
+		t.Fatal("expected copying an out-of-bounds sector to fail", err)
+	}
+}
@@ -0,0 +1,105 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/encoding"
+)
+
+// instructionReadRegistryEID defines an instruction to read an entry from the
+// registry by its entry ID.
+type instructionReadRegistryEID struct {
+	commonInstruction
+
+	ridOffset uint64
+}
+
+// staticDecodeReadRegistryEIDInstruction creates a new 'ReadRegistryEID'
+// instruction from the provided generic instruction.
+func (p *program) staticDecodeReadRegistryEIDInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierReadRegistryEID {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierReadRegistryEID, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIReadRegistryEIDLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIReadRegistryEIDLen, len(instruction.Args))
+	}
+	// Read args.
+	ridOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	return &instructionReadRegistryEID{
+		commonInstruction: commonInstruction{
+			staticData:  p.staticData,
+			staticState: p.staticProgramState,
+		},
+		ridOffset: ridOffset,
+	}, nil
+}
+
+// Execute executes the 'ReadRegistryEID' instruction.
+func (i *instructionReadRegistryEID) Execute(prevOutput output) output {
+	// Fetch the args.
+	rid, err := i.staticData.Hash(i.ridOffset)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	// Prepare the output. An empty output.Output means the data wasn't found.
+	out := output{
+		NewSize:       prevOutput.NewSize,
+		NewMerkleRoot: prevOutput.NewMerkleRoot,
+		Output:        nil,
+	}
+
+	// Get the value. If this fails we are done.
+	spk, rv, found := i.staticState.host.RegistryGetRID(rid)
+	if !found {
+		return out
+	}
+
+	// Return the signature followed by the revision, tweak, public key and
+	// data. The caller needs the public key and tweak to verify the signature
+	// and to make sure that the entry actually belongs to the entry ID.
+	rev := make([]byte, 8)
+	binary.LittleEndian.PutUint64(rev, rv.Revision)
+	out.Output = append(out.Output, rv.Signature[:]...)
+	out.Output = append(out.Output, rev...)
+	out.Output = append(out.Output, rv.Tweak[:]...)
+	out.Output = append(out.Output, encoding.Marshal(spk)...)
+	out.Output = append(out.Output, rv.Data...)
+	return out
+}
+
+// Registry reads by entry ID can be batched for the same reasons as regular
+// registry reads.
+func (i *instructionReadRegistryEID) Batch() bool {
+	return true
+}
+
+// Collateral returns the collateral the host has to put up for this
+// instruction.
+func (i *instructionReadRegistryEID) Collateral() types.Currency {
+	return modules.MDMReadRegistryCollateral()
+}
+
+// Cost returns the Cost of this `ReadRegistryEID` instruction.
+func (i *instructionReadRegistryEID) Cost() (executionCost, _ types.Currency, err error) {
+	executionCost = modules.MDMReadRegistryCost(i.staticState.priceTable)
+	return
+}
+
+// Memory returns the memory allocated by the 'ReadRegistryEID' instruction
+// beyond the lifetime of the instruction.
+func (i *instructionReadRegistryEID) Memory() uint64 {
+	return modules.MDMReadRegistryMemory()
+}
+
+// Time returns the execution time of an 'ReadRegistryEID' instruction.
+func (i *instructionReadRegistryEID) Time() (uint64, error) {
+	return modules.MDMTimeReadRegistry, nil
+}
@@ -0,0 +1,102 @@
+package mdm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestInstructionReadRegistryEID tests the ReadRegistryEID instruction.
+func TestInstructionReadRegistryEID(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Add a registry value for a given random key/tweak pair.
+	sk, pk := crypto.GenerateKeyPair()
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	data := fastrand.Bytes(modules.RegistryDataSize)
+	rev := fastrand.Uint64n(1000)
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	rv := modules.NewRegistryValue(tweak, data, rev).Sign(sk)
+	_, err := host.RegistryUpdate(rv, spk, types.BlockHeight(fastrand.Uint64n(1000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	so := host.newTestStorageObligation(true)
+	pt := newTestPriceTable()
+	tb := newTestProgramBuilder(pt, 0)
+	tb.AddReadRegistryEIDInstruction(modules.DeriveRegistryEntryID(spk, tweak))
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert output.
+	output := outputs[0]
+	revBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(revBytes, rev)
+	var expectedOutput []byte
+	expectedOutput = append(expectedOutput, rv.Signature[:]...)
+	expectedOutput = append(expectedOutput, revBytes...)
+	expectedOutput = append(expectedOutput, tweak[:]...)
+	expectedOutput = append(expectedOutput, encoding.Marshal(spk)...)
+	expectedOutput = append(expectedOutput, rv.Data...)
+	err = output.assert(0, crypto.Hash{}, []crypto.Hash{}, expectedOutput, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parse the output and verify the signature.
+	spk2, rv2, err := modules.ParseReadRegistryEIDResponse(output.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !spk2.Equals(spk) {
+		t.Fatal("wrong public key")
+	}
+	if rv2.Tweak != tweak || rv2.Revision != rev || !bytes.Equal(rv2.Data, data) {
+		t.Fatal("wrong value")
+	}
+	if rv2.Verify(pk) != nil {
+		t.Fatal("verification failed", err)
+	}
+}
+
+// TestInstructionReadRegistryEIDNotFound tests the ReadRegistryEID instruction
+// for when an entry isn't found.
+func TestInstructionReadRegistryEIDNotFound(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	so := host.newTestStorageObligation(true)
+	pt := newTestPriceTable()
+	tb := newTestProgramBuilder(pt, 0)
+	tb.AddReadRegistryEIDInstruction(crypto.Hash{})
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs[0].Error != nil {
+		t.Fatal("error returned", outputs[0].Error)
+	}
+	if len(outputs[0].Output) != 0 {
+		t.Fatal("expected empty output")
+	}
+}
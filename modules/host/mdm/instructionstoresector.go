@@ -0,0 +1,134 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// ephemeralSector is a sector added by a StoreSector instruction. It is only
+// stored on the host once the program is committed.
+type ephemeralSector struct {
+	root   crypto.Hash
+	data   []byte
+	expiry types.BlockHeight
+}
+
+// instructionStoreSector is an instruction that stores a sector on the host for
+// a fixed number of blocks without adding it to a file contract. The storage is
+// paid for from an ephemeral account.
+type instructionStoreSector struct {
+	commonInstruction
+
+	dataOffset     uint64
+	durationOffset uint64
+}
+
+// staticDecodeStoreSectorInstruction creates a new 'StoreSector' instruction
+// from the provided generic instruction.
+func (p *program) staticDecodeStoreSectorInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierStoreSector {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierStoreSector, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIStoreSectorLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIStoreSectorLen, len(instruction.Args))
+	}
+	// Read args.
+	dataOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	durationOffset := binary.LittleEndian.Uint64(instruction.Args[8:16])
+	return &instructionStoreSector{
+		commonInstruction: commonInstruction{
+			staticData:  p.staticData,
+			staticState: p.staticProgramState,
+		},
+		dataOffset:     dataOffset,
+		durationOffset: durationOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionStoreSector) Batch() bool {
+	return false
+}
+
+// Execute executes the 'StoreSector' instruction.
+func (i *instructionStoreSector) Execute(prevOutput output) output {
+	// Fetch the operands.
+	data, err := i.staticData.Bytes(i.dataOffset, modules.SectorSize)
+	if err != nil {
+		return errOutput(err)
+	}
+	duration, err := i.staticData.Uint64(i.durationOffset)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	// Verify input.
+	err = storeSectorVerify(types.BlockHeight(duration), i.staticState.priceTable.EphemeralStorageMaxDuration)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	// Add the sector to the program's ephemeral sectors. It is stored once
+	// the program is committed.
+	ps := i.staticState
+	root := crypto.MerkleRoot(data)
+	ps.ephemeralSectors = append(ps.ephemeralSectors, ephemeralSector{
+		root:   root,
+		data:   data,
+		expiry: ps.host.BlockHeight() + types.BlockHeight(duration),
+	})
+
+	return output{
+		NewSize:       prevOutput.NewSize,
+		NewMerkleRoot: prevOutput.NewMerkleRoot,
+		Output:        root[:],
+	}
+}
+
+// storeSectorVerify verifies the input to a StoreSector instruction.
+func storeSectorVerify(duration, maxDuration types.BlockHeight) error {
+	if duration == 0 {
+		return fmt.Errorf("bad input: duration can't be 0")
+	}
+	if duration > maxDuration {
+		return fmt.Errorf("bad input: duration %v exceeds the host's max duration of %v", duration, maxDuration)
+	}
+	return nil
+}
+
+// Collateral is zero for the StoreSector instruction since the sector isn't
+// added to a file contract.
+func (i *instructionStoreSector) Collateral() types.Currency {
+	return modules.MDMStoreSectorCollateral()
+}
+
+// Cost returns the Cost of this `StoreSector` instruction.
+func (i *instructionStoreSector) Cost() (executionCost, storeCost types.Currency, err error) {
+	duration, err := i.staticData.Uint64(i.durationOffset)
+	if err != nil {
+		err = fmt.Errorf("bad input: durationOffset: %v", err)
+		return
+	}
+	executionCost, storeCost = modules.MDMStoreSectorCost(i.staticState.priceTable, types.BlockHeight(duration))
+	return
+}
+
+// Memory returns the memory allocated by the 'StoreSector' instruction beyond
+// the lifetime of the instruction.
+func (i *instructionStoreSector) Memory() uint64 {
+	return modules.MDMStoreSectorMemory()
+}
+
+// Time returns the execution time of a 'StoreSector' instruction.
+func (i *instructionStoreSector) Time() (uint64, error) {
+	return modules.MDMTimeStoreSector, nil
+}
@@ -0,0 +1,132 @@
+package mdm
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestStoreSectorVerify tests verification of StoreSector input.
+func TestStoreSectorVerify(t *testing.T) {
+	tests := []struct {
+		duration, maxDuration types.BlockHeight
+		valid                 bool
+	}{
+		{1, 10, true},
+		{10, 10, true},
+		{0, 10, false},
+		{11, 10, false},
+	}
+	for _, test := range tests {
+		err := storeSectorVerify(test.duration, test.maxDuration)
+		if test.valid && err != nil {
+			t.Errorf("storeSectorVerify(%v, %v): expected success but got '%v'", test.duration, test.maxDuration, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("storeSectorVerify(%v, %v): expected failure", test.duration, test.maxDuration)
+		}
+	}
+}
+
+// TestInstructionStoreSector tests executing a program with a single
+// StoreSector instruction.
+func TestInstructionStoreSector(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Prepare a priceTable and the sector.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(5)
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(data)
+
+	// Use a builder to build the program.
+	tb := newTestProgramBuilder(pt, 0)
+	tb.AddStoreSectorInstruction(data, duration)
+
+	// Execute it. The program doesn't modify the storage obligation.
+	so := host.newTestStorageObligation(true)
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert the output.
+	err = outputs[0].assert(0, crypto.Hash{}, []crypto.Hash{}, root[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The host should store the sector with the right expiry.
+	host.mu.Lock()
+	expiry, exists := host.ephemeral[root]
+	height := host.blockHeight
+	host.mu.Unlock()
+	if !exists {
+		t.Fatal("sector wasn't stored as an ephemeral sector")
+	}
+	if expiry != height+duration {
+		t.Fatalf("wrong expiry %v != %v", expiry, height+duration)
+	}
+	if !host.HasSector(root) {
+		t.Fatal("host should have the sector")
+	}
+
+	// Storing a sector for longer than the max duration should fail.
+	tb = newTestProgramBuilder(pt, 0)
+	tb.AddStoreSectorInstruction(data, pt.EphemeralStorageMaxDuration+1)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || outputs[0].Error == nil || !strings.Contains(outputs[0].Error.Error(), "exceeds the host's max duration") {
+		t.Fatal("expected storing a sector for too long to fail", outputs)
+	}
+
+	// If a later instruction of the program fails, the sector of an earlier
+	// StoreSector instruction shouldn't be stored.
+	data = fastrand.Bytes(int(modules.SectorSize))
+	root = crypto.MerkleRoot(data)
+	tb = newTestProgramBuilder(pt, 0)
+	tb.AddStoreSectorInstruction(data, duration)
+	tb.AddStoreSectorInstruction(data, pt.EphemeralStorageMaxDuration+1)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 || outputs[0].Error != nil || outputs[1].Error == nil {
+		t.Fatal("expected the second instruction to fail", outputs)
+	}
+	host.mu.Lock()
+	_, exists = host.ephemeral[root]
+	host.mu.Unlock()
+	if exists || host.HasSector(root) {
+		t.Fatal("sector of a failed program shouldn't be stored")
+	}
+
+	// In a write program, the sector is only stored once the program is
+	// finalized.
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddAppendInstruction(randomSectorData(), false)
+	tb.AddStoreSectorInstruction(data, duration)
+	finalizeFn, _, outputs, err := mdm.ExecuteProgramWithBuilderManualFinalize(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 || outputs[1].Error != nil {
+		t.Fatal("unexpected outputs", outputs)
+	}
+	if host.HasSector(root) {
+		t.Fatal("sector shouldn't be stored before the program is finalized")
+	}
+	if err := finalizeFn(so); err != nil {
+		t.Fatal(err)
+	}
+	if !host.HasSector(root) {
+		t.Fatal("sector should be stored after the program is finalized")
+	}
+}
@@ -0,0 +1,125 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/encoding"
+)
+
+// instructionTruncate is an instruction that drops the given number of sectors
+// from the end of the contract. Unlike 'DropSectors' it returns the roots of
+// the dropped sectors, which allows the renter to verify the proof without
+// knowing which sectors are stored at the end of the contract.
+type instructionTruncate struct {
+	commonInstruction
+
+	numSectorsOffset uint64
+}
+
+// staticDecodeTruncateInstruction creates a new 'Truncate' instruction from the
+// provided generic instruction.
+func (p *program) staticDecodeTruncateInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierTruncate {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierTruncate, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCITruncateLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCITruncateLen, len(instruction.Args))
+	}
+	// Read args.
+	numSectorsOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	return &instructionTruncate{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: instruction.Args[8] == 1,
+			staticState:       p.staticProgramState,
+		},
+		numSectorsOffset: numSectorsOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionTruncate) Batch() bool {
+	return false
+}
+
+// Execute executes the 'Truncate' instruction.
+//
+// If the number of sectors is 0 this instruction is a noop.
+func (i *instructionTruncate) Execute(prevOutput output) output {
+	// Fetch the data.
+	numSectorsTruncated, err := i.staticData.Uint64(i.numSectorsOffset)
+	if err != nil {
+		return errOutput(fmt.Errorf("bad input: numSectorsOffset: %v", err))
+	}
+
+	// Verify input.
+	oldNumSectors := prevOutput.NewSize / modules.SectorSize
+	err = dropSectorsVerify(numSectorsTruncated, oldNumSectors)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	newNumSectors := oldNumSectors - numSectorsTruncated
+	ps := i.staticState
+
+	// Remember the truncated roots and construct the proof, if necessary,
+	// before updating the roots.
+	truncatedRoots := append([]crypto.Hash{}, ps.sectors.merkleRoots[newNumSectors:]...)
+	var proof []crypto.Hash
+	if i.staticMerkleProof && numSectorsTruncated > 0 && newNumSectors > 0 {
+		// Create proof with range covering the truncated sectors.
+		proof = crypto.MerkleSectorRangeProof(ps.sectors.merkleRoots, int(newNumSectors), int(oldNumSectors))
+	}
+
+	newMerkleRoot, err := ps.sectors.dropSectors(numSectorsTruncated)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	return output{
+		NewSize:       newNumSectors * modules.SectorSize,
+		NewMerkleRoot: newMerkleRoot,
+		Output:        encoding.Marshal(truncatedRoots),
+		Proof:         proof,
+	}
+}
+
+// Collateral is zero for the Truncate instruction.
+func (i *instructionTruncate) Collateral() types.Currency {
+	return modules.MDMTruncateCollateral()
+}
+
+// Cost returns the Cost of the Truncate instruction.
+func (i *instructionTruncate) Cost() (executionCost, _ types.Currency, err error) {
+	numSectorsTruncated, err := i.staticData.Uint64(i.numSectorsOffset)
+	if err != nil {
+		err = fmt.Errorf("bad input: numSectorsOffset: %v", err)
+		return
+	}
+	executionCost = modules.MDMTruncateCost(i.staticState.priceTable, numSectorsTruncated)
+	return
+}
+
+// Memory returns the memory allocated by the 'Truncate' instruction beyond the
+// lifetime of the instruction.
+func (i *instructionTruncate) Memory() uint64 {
+	return modules.MDMTruncateMemory()
+}
+
+// Time returns the execution time of the 'Truncate' instruction.
+func (i *instructionTruncate) Time() (uint64, error) {
+	numTruncated, err := i.staticData.Uint64(i.numSectorsOffset)
+	if err != nil {
+		return 0, err
+	}
+	return modules.MDMTruncateTime(numTruncated), nil
+}
@@ -0,0 +1,93 @@
+package mdm
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestInstructionTruncate tests executing a program with multiple Truncate
+// instructions.
+func TestInstructionTruncate(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Create a storage obligation with some random sectors.
+	so := host.newTestStorageObligation(true)
+	so.AddRandomSectors(3)
+	roots := append([]crypto.Hash{}, so.sectorRoots...)
+	imr := so.MerkleRoot()
+
+	// Construct the program.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+	tb := newTestProgramBuilder(pt, duration)
+
+	// Don't truncate any sectors.
+	tb.AddTruncateInstruction(0, true)
+	// Truncate one sector.
+	tb.AddTruncateInstruction(1, true)
+	// Truncate the two remaining sectors.
+	tb.AddTruncateInstruction(2, true)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert the outputs.
+	expectedOutputs := []output{
+		{
+			NewSize:       3 * modules.SectorSize,
+			NewMerkleRoot: imr,
+			Output:        encoding.Marshal([]crypto.Hash{}),
+			Proof:         []crypto.Hash{},
+		},
+		{
+			NewSize:       2 * modules.SectorSize,
+			NewMerkleRoot: cachedMerkleRoot(roots[:2]),
+			Output:        encoding.Marshal(roots[2:]),
+			Proof:         crypto.MerkleSectorRangeProof(roots, 2, 3),
+		},
+		{
+			NewSize:       0,
+			NewMerkleRoot: cachedMerkleRoot([]crypto.Hash{}),
+			Output:        encoding.Marshal(roots[:2]),
+			Proof:         []crypto.Hash{},
+		},
+	}
+	for i, output := range outputs {
+		expected := expectedOutputs[i]
+		if err := output.assert(expected.NewSize, expected.NewMerkleRoot, expected.Proof, expected.Output, nil); err != nil {
+			t.Fatal(i, err)
+		}
+	}
+
+	// Verify the proof of the second instruction using the returned roots.
+	var truncated []crypto.Hash
+	err = encoding.Unmarshal(outputs[1].Output, &truncated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !crypto.VerifySectorRangeProof(truncated, outputs[1].Proof, 2, 3, imr) {
+		t.Fatal("failed to verify proof")
+	}
+
+	// Truncating more sectors than the contract contains should fail.
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddTruncateInstruction(4, true)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || outputs[0].Error == nil || !strings.Contains(outputs[0].Error.Error(), "greater than the number of sectors") {
+		t.Fatal("expected truncating too many sectors to fail", outputs)
+	}
+}
@@ -0,0 +1,160 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// instructionUpdateSector is an instruction that overwrites a range of data
+// within an existing sector of a file contract.
+type instructionUpdateSector struct {
+	commonInstruction
+
+	offsetOffset uint64
+	lengthOffset uint64
+	dataOffset   uint64
+}
+
+// staticDecodeUpdateSectorInstruction creates a new 'UpdateSector' instruction
+// from the provided generic instruction.
+func (p *program) staticDecodeUpdateSectorInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierUpdateSector {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierUpdateSector, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIUpdateSectorLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIUpdateSectorLen, len(instruction.Args))
+	}
+	// Read args.
+	offsetOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	lengthOffset := binary.LittleEndian.Uint64(instruction.Args[8:16])
+	dataOffset := binary.LittleEndian.Uint64(instruction.Args[16:24])
+	return &instructionUpdateSector{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: instruction.Args[24] == 1,
+			staticState:       p.staticProgramState,
+		},
+		offsetOffset: offsetOffset,
+		lengthOffset: lengthOffset,
+		dataOffset:   dataOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionUpdateSector) Batch() bool {
+	return false
+}
+
+// Execute executes the 'UpdateSector' instruction.
+//
+// The proof is a range proof for the updated segments against the contract
+// before the update and the output contains the overwritten data. That allows
+// the renter to verify the proof against the old merkle root using the old data
+// and against the new merkle root using the new data since the hashes outside
+// of the updated range don't change.
+func (i *instructionUpdateSector) Execute(prevOutput output) output {
+	// Fetch the operands.
+	offset, err := i.staticData.Uint64(i.offsetOffset)
+	if err != nil {
+		return errOutput(err)
+	}
+	length, err := i.staticData.Uint64(i.lengthOffset)
+	if err != nil {
+		return errOutput(err)
+	}
+	data, err := i.staticData.Bytes(i.dataOffset, length)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	// Verify input.
+	ps := i.staticState
+	relOffset, secIdx, err := ps.sectors.translateOffset(offset)
+	if err != nil {
+		return errOutput(err)
+	}
+	err = updateSectorVerify(relOffset, length)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	// Fetch the sector and apply the update to a copy of it.
+	oldSector, err := ps.sectors.readSector(ps.host, ps.sectors.merkleRoots[secIdx])
+	if err != nil {
+		return errOutput(err)
+	}
+	newSector := make([]byte, len(oldSector))
+	copy(newSector, oldSector)
+	copy(newSector[relOffset:], data)
+
+	// Construct the proof, if necessary, before updating the roots.
+	var proof []crypto.Hash
+	if i.staticMerkleProof {
+		proofStart := int(offset) / crypto.SegmentSize
+		proofEnd := int(offset+length) / crypto.SegmentSize
+		roots := ps.sectors.merkleRoots
+		otherRoots := make([]crypto.Hash, 0, len(roots)-1)
+		otherRoots = append(otherRoots, roots[:secIdx]...)
+		otherRoots = append(otherRoots, roots[secIdx+1:]...)
+		proof = crypto.MerkleMixedRangeProof(otherRoots, oldSector, int(modules.SectorSize), proofStart, proofEnd)
+	}
+	oldData := append([]byte{}, oldSector[relOffset:][:length]...)
+
+	newMerkleRoot, err := ps.sectors.updateSector(secIdx, newSector)
+	if err != nil {
+		return errOutput(err)
+	}
+
+	return output{
+		NewSize:       prevOutput.NewSize,
+		NewMerkleRoot: newMerkleRoot,
+		Output:        oldData,
+		Proof:         proof,
+	}
+}
+
+// updateSectorVerify verifies the input to an UpdateSector instruction.
+func updateSectorVerify(relOffset, length uint64) error {
+	if length == 0 {
+		return fmt.Errorf("bad input: length can't be 0")
+	}
+	if relOffset%crypto.SegmentSize != 0 || length%crypto.SegmentSize != 0 {
+		return fmt.Errorf("bad input: offset (%v) and length (%v) need to be segment aligned", relOffset, length)
+	}
+	if relOffset+length > modules.SectorSize {
+		return fmt.Errorf("bad input: update of length %v at offset %v exceeds the sector", length, relOffset)
+	}
+	return nil
+}
+
+// Collateral is zero for the UpdateSector instruction since the size of the
+// contract doesn't change.
+func (i *instructionUpdateSector) Collateral() types.Currency {
+	return modules.MDMUpdateSectorCollateral()
+}
+
+// Cost returns the Cost of this `UpdateSector` instruction.
+func (i *instructionUpdateSector) Cost() (executionCost, storage types.Currency, err error) {
+	executionCost = modules.MDMUpdateSectorCost(i.staticState.priceTable)
+	return
+}
+
+// Memory returns the memory allocated by the 'UpdateSector' instruction beyond
+// the lifetime of the instruction.
+func (i *instructionUpdateSector) Memory() uint64 {
+	return modules.MDMUpdateSectorMemory()
+}
+
+// Time returns the execution time of an 'UpdateSector' instruction.
+func (i *instructionUpdateSector) Time() (uint64, error) {
+	return modules.MDMTimeUpdateSector, nil
+}
@@ -0,0 +1,114 @@
+package mdm
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestUpdateSectorVerify tests verification of UpdateSector input.
+func TestUpdateSectorVerify(t *testing.T) {
+	tests := []struct {
+		relOffset, length uint64
+		valid             bool
+	}{
+		{0, crypto.SegmentSize, true},
+		{0, modules.SectorSize, true},
+		{modules.SectorSize - crypto.SegmentSize, crypto.SegmentSize, true},
+		{0, 0, false},
+		{1, crypto.SegmentSize, false},
+		{0, crypto.SegmentSize + 1, false},
+		{crypto.SegmentSize, modules.SectorSize, false},
+	}
+	for _, test := range tests {
+		err := updateSectorVerify(test.relOffset, test.length)
+		if test.valid && err != nil {
+			t.Errorf("updateSectorVerify(%v, %v): expected success but got '%v'", test.relOffset, test.length, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("updateSectorVerify(%v, %v): expected failure", test.relOffset, test.length)
+		}
+	}
+}
+
+// TestInstructionUpdateSector tests executing a program with a single
+// UpdateSector instruction.
+func TestInstructionUpdateSector(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Create a storage obligation with some random sectors.
+	so := host.newTestStorageObligation(true)
+	so.AddRandomSectors(3)
+	ics := so.ContractSize()
+	imr := so.MerkleRoot()
+	oldRoots := append([]crypto.Hash{}, so.sectorRoots...)
+
+	// Prepare a priceTable and duration.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+
+	// Update a few segments in the middle sector.
+	relOffset := uint64(2 * crypto.SegmentSize)
+	offset := modules.SectorSize + relOffset
+	data := fastrand.Bytes(4 * crypto.SegmentSize)
+	oldSector, err := host.ReadSector(oldRoots[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldData := append([]byte{}, oldSector[relOffset:][:len(data)]...)
+
+	// Use a builder to build the program.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddUpdateSectorInstruction(offset, data, true)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compute the expected root and proof.
+	newSector := append([]byte{}, oldSector...)
+	copy(newSector[relOffset:], data)
+	newRoots := append([]crypto.Hash{}, oldRoots...)
+	newRoots[1] = crypto.MerkleRoot(newSector)
+	nmr := cachedMerkleRoot(newRoots)
+	if nmr == imr {
+		t.Fatal("nmr shouldn't match imr")
+	}
+	proofStart := int(offset) / crypto.SegmentSize
+	proofEnd := int(offset+uint64(len(data))) / crypto.SegmentSize
+	otherRoots := []crypto.Hash{oldRoots[0], oldRoots[2]}
+	expectedProof := crypto.MerkleMixedRangeProof(otherRoots, oldSector, int(modules.SectorSize), proofStart, proofEnd)
+
+	// Assert the output.
+	err = outputs[0].assert(ics, nmr, expectedProof, oldData, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The proof should be valid for the old data against the old root and for
+	// the new data against the new root.
+	if !crypto.VerifyMixedRangeProof(oldData, outputs[0].Proof, imr, proofStart, proofEnd) {
+		t.Fatal("failed to verify proof against old root")
+	}
+	if !crypto.VerifyMixedRangeProof(data, outputs[0].Proof, nmr, proofStart, proofEnd) {
+		t.Fatal("failed to verify proof against new root")
+	}
+
+	// Updating a sector which is out of bounds should fail.
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddUpdateSectorInstruction(ics, data, true)
+	outputs, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 1 || outputs[0].Error == nil || !strings.Contains(outputs[0].Error.Error(), "out of bounds") {
+		t.Fatal("expected out-of-bounds update to fail", outputs)
+	}
+}
@@ -32,11 +32,14 @@ type StorageObligationSnapshot interface {
 // Host defines the minimal interface a Host needs to
 // implement to be used by the mdm.
 type Host interface {
+	AddEphemeralSector(sectorRoot crypto.Hash, sectorData []byte, expiry types.BlockHeight) error
 	BlockHeight() types.BlockHeight
+	ContractSectorRoot(fcid types.FileContractID, sectorIdx uint64) (types.SiaPublicKey, crypto.Hash, error)
 	HasSector(crypto.Hash) bool
 	ReadSector(sectorRoot crypto.Hash) ([]byte, error)
 	RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error)
 	RegistryGet(pubKey types.SiaPublicKey, tweak crypto.Hash) (modules.SignedRegistryValue, bool)
+	RegistryGetRID(rid crypto.Hash) (types.SiaPublicKey, modules.SignedRegistryValue, bool)
 }
 
 // MDM (Merklized Data Machine) is a virtual machine that executes instructions
@@ -25,8 +25,11 @@ type (
 	TestHost struct {
 		generateSectors bool
 		blockHeight     types.BlockHeight
+		contracts       map[types.FileContractID]*TestStorageObligation
+		ephemeral       map[crypto.Hash]types.BlockHeight
 		sectors         map[crypto.Hash][]byte
 		registry        map[crypto.Hash]modules.SignedRegistryValue
+		registryKeys    map[crypto.Hash]types.SiaPublicKey
 		mu              sync.Mutex
 	}
 	// TestStorageObligation is a dummy storage obligation for testing which
@@ -37,6 +40,7 @@ type (
 		sectorRoots []crypto.Hash
 
 		// contract related fields.
+		id types.FileContractID
 		sk crypto.SecretKey
 	}
 )
@@ -48,18 +52,38 @@ func newTestHost() *TestHost {
 func newCustomTestHost(generateSectors bool) *TestHost {
 	return &TestHost{
 		generateSectors: generateSectors,
+		contracts:       make(map[types.FileContractID]*TestStorageObligation),
+		ephemeral:       make(map[crypto.Hash]types.BlockHeight),
 		registry:        make(map[crypto.Hash]modules.SignedRegistryValue),
+		registryKeys:    make(map[crypto.Hash]types.SiaPublicKey),
 		sectors:         make(map[crypto.Hash][]byte),
 	}
 }
 
 func (h *TestHost) newTestStorageObligation(locked bool) *TestStorageObligation {
 	sk, _ := crypto.GenerateKeyPair()
-	return &TestStorageObligation{
+	so := &TestStorageObligation{
 		host:      h,
 		sectorMap: make(map[crypto.Hash][]byte),
 		sk:        sk,
 	}
+	fastrand.Read(so.id[:])
+	h.mu.Lock()
+	h.contracts[so.id] = so
+	h.mu.Unlock()
+	return so
+}
+
+// AddEphemeralSector adds a sector to the host which isn't covered by a
+// contract.
+func (h *TestHost) AddEphemeralSector(sectorRoot crypto.Hash, sectorData []byte, expiry types.BlockHeight) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if expiry > h.ephemeral[sectorRoot] {
+		h.ephemeral[sectorRoot] = expiry
+	}
+	h.sectors[sectorRoot] = sectorData
+	return nil
 }
 
 // BlockHeight returns an incremented blockheight.
@@ -70,6 +94,21 @@ func (h *TestHost) BlockHeight() types.BlockHeight {
 	return h.blockHeight
 }
 
+// ContractSectorRoot returns the renter's public key and the sector root at the
+// given index of the storage obligation with the given id.
+func (h *TestHost) ContractSectorRoot(fcid types.FileContractID, sectorIdx uint64) (types.SiaPublicKey, crypto.Hash, error) {
+	h.mu.Lock()
+	so, exists := h.contracts[fcid]
+	h.mu.Unlock()
+	if !exists {
+		return types.SiaPublicKey{}, crypto.Hash{}, errors.New("storage obligation not found")
+	}
+	if sectorIdx >= uint64(len(so.sectorRoots)) {
+		return types.SiaPublicKey{}, crypto.Hash{}, fmt.Errorf("sector index out-of-bounds: %v >= %v", sectorIdx, len(so.sectorRoots))
+	}
+	return so.RecentRevision().UnlockConditions.PublicKeys[0], so.sectorRoots[sectorIdx], nil
+}
+
 // HasSector indicates whether the host stores a sector with a given root or
 // not.
 func (h *TestHost) HasSector(sectorRoot crypto.Hash) bool {
@@ -105,9 +144,21 @@ func (h *TestHost) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.S
 	}
 
 	h.registry[key] = rv
+	h.registryKeys[key] = pubKey
 	return oldRV, nil
 }
 
+// RegistryGetRID retrieves a value from the registry by its entry ID.
+func (h *TestHost) RegistryGetRID(rid crypto.Hash) (types.SiaPublicKey, modules.SignedRegistryValue, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, exists := h.registry[rid]
+	if !exists {
+		return types.SiaPublicKey{}, modules.SignedRegistryValue{}, false
+	}
+	return h.registryKeys[rid], v, true
+}
+
 // ReadSector implements the Host interface by returning a random sector for
 // each root. Calling ReadSector multiple times on the same root will result in
 // the same data.
@@ -157,6 +208,11 @@ func (so *TestStorageObligation) MerkleRoot() crypto.Hash {
 // RecentRevision implements the StorageObligation interface.
 func (so *TestStorageObligation) RecentRevision() types.FileContractRevision {
 	return types.FileContractRevision{
+		UnlockConditions: types.UnlockConditions{
+			PublicKeys: []types.SiaPublicKey{
+				types.Ed25519PublicKey(so.sk.PublicKey()),
+			},
+		},
 		NewFileMerkleRoot: so.MerkleRoot(),
 		NewFileSize:       so.ContractSize(),
 	}
@@ -233,6 +289,10 @@ func newTestPriceTable() *modules.RPCPriceTable {
 		WriteLengthCost:     types.NewCurrency64(1),
 		WriteStoreCost:      types.NewCurrency64(1),
 
+		// Ephemeral storage costs
+		EphemeralStorageCost:        types.NewCurrency64(1),
+		EphemeralStorageMaxDuration: 10,
+
 		// Bandwidth costs
 		DownloadBandwidthCost: types.NewCurrency64(1),
 		UploadBandwidthCost:   types.NewCurrency64(1),
@@ -37,7 +37,8 @@ type programState struct {
 	staticRemainingDuration types.BlockHeight
 
 	// program cache
-	sectors sectors
+	sectors          sectors
+	ephemeralSectors []ephemeralSector
 
 	// statistic related fields
 	potentialStorageRevenue types.Currency
@@ -56,6 +57,7 @@ type program struct {
 	instructions       []instruction
 	staticData         *programData
 	staticProgramState *programState
+	staticReadOnly     bool
 
 	staticBudget           *modules.RPCBudget
 	staticCollateralBudget types.Currency
@@ -89,6 +91,8 @@ func decodeInstruction(p *program, i modules.Instruction) (instruction, error) {
 	switch i.Specifier {
 	case modules.SpecifierAppend:
 		return p.staticDecodeAppendInstruction(i)
+	case modules.SpecifierCopySector:
+		return p.staticDecodeCopySectorInstruction(i)
 	case modules.SpecifierDropSectors:
 		return p.staticDecodeDropSectorsInstruction(i)
 	case modules.SpecifierHasSector:
@@ -99,12 +103,20 @@ func decodeInstruction(p *program, i modules.Instruction) (instruction, error) {
 		return p.staticDecodeReadOffsetInstruction(i)
 	case modules.SpecifierRevision:
 		return p.staticDecodeRevisionInstruction(i)
+	case modules.SpecifierStoreSector:
+		return p.staticDecodeStoreSectorInstruction(i)
 	case modules.SpecifierSwapSector:
 		return p.staticDecodeSwapSectorInstruction(i)
+	case modules.SpecifierTruncate:
+		return p.staticDecodeTruncateInstruction(i)
+	case modules.SpecifierUpdateSector:
+		return p.staticDecodeUpdateSectorInstruction(i)
 	case modules.SpecifierUpdateRegistry:
 		return p.staticDecodeUpdateRegistryInstruction(i)
 	case modules.SpecifierReadRegistry:
 		return p.staticDecodeReadRegistryInstruction(i)
+	case modules.SpecifierReadRegistryEID:
+		return p.staticDecodeReadRegistryEIDInstruction(i)
 	default:
 		return nil, fmt.Errorf("unknown instruction specifier: %v", i.Specifier)
 	}
@@ -138,6 +150,7 @@ func (mdm *MDM) ExecuteProgram(ctx context.Context, pt *modules.RPCPriceTable, p
 		usedMemory:             modules.MDMInitMemory(),
 		staticCollateralBudget: collateralBudget,
 		staticData:             openProgramData(data, programDataLen),
+		staticReadOnly:         p.ReadOnly(),
 		tg:                     &mdm.tg,
 	}
 	// Convert the instructions.
@@ -252,6 +265,13 @@ func (p *program) executeInstructions(ctx context.Context, fcSize uint64, fcRoot
 		batch := idx < len(p.instructions)-1 && p.instructions[idx+1].Batch()
 		// Execute next instruction.
 		output = i.Execute(output)
+		// Readonly programs are not finalized. Their changes are committed
+		// after the last instruction was executed successfully.
+		if p.staticReadOnly && output.Error == nil && idx == len(p.instructions)-1 {
+			if err := p.commitEphemeralSectors(); err != nil {
+				output = errOutput(err)
+			}
+		}
 		p.outputChan <- Output{
 			output:                output,
 			Batch:                 batch,
@@ -280,6 +300,11 @@ func (p *program) managedFinalize(so StorageObligation) error {
 	if err != nil {
 		return err
 	}
+	// Store the ephemeral sectors.
+	err = p.commitEphemeralSectors()
+	if err != nil {
+		return err
+	}
 	// Commit the changes to the storage obligation.
 	s := p.staticProgramState.sectors
 	err = so.Update(s.merkleRoots, s.sectorsRemoved, s.sectorsGained)
@@ -288,3 +313,16 @@ func (p *program) managedFinalize(so StorageObligation) error {
 	}
 	return nil
 }
+
+// commitEphemeralSectors stores the ephemeral sectors added by the program's
+// StoreSector instructions on the host.
+func (p *program) commitEphemeralSectors() error {
+	ps := p.staticProgramState
+	for _, es := range ps.ephemeralSectors {
+		err := ps.host.AddEphemeralSector(es.root, es.data, es.expiry)
+		if err != nil {
+			return errors.AddContext(err, "failed to store ephemeral sector")
+		}
+	}
+	return nil
+}
@@ -33,14 +33,7 @@ func (s *sectors) appendSector(sectorData []byte) (crypto.Hash, error) {
 	newRoot := crypto.MerkleRoot(sectorData)
 
 	// Update the program cache.
-	_, removed := s.sectorsRemoved[newRoot]
-	if removed {
-		// If the sector has been marked as removed, unmark it.
-		delete(s.sectorsRemoved, newRoot)
-	} else {
-		// Add the sector to the cache.
-		s.sectorsGained[newRoot] = sectorData
-	}
+	s.gainSector(newRoot, sectorData)
 
 	// Update the roots.
 	s.merkleRoots = append(s.merkleRoots, newRoot)
@@ -49,6 +42,23 @@ func (s *sectors) appendSector(sectorData []byte) (crypto.Hash, error) {
 	return cachedMerkleRoot(s.merkleRoots), nil
 }
 
+// copySector appends the root of a sector, which already exists on the host,
+// to the program cache and returns the new merkle root.
+func (s *sectors) copySector(sectorRoot crypto.Hash, sectorData []byte) (crypto.Hash, error) {
+	if uint64(len(sectorData)) != modules.SectorSize {
+		return crypto.Hash{}, fmt.Errorf("trying to copy data of length %v", len(sectorData))
+	}
+
+	// Update the program cache.
+	s.gainSector(sectorRoot, sectorData)
+
+	// Update the roots.
+	s.merkleRoots = append(s.merkleRoots, sectorRoot)
+
+	// Return the new merkle root of the contract.
+	return cachedMerkleRoot(s.merkleRoots), nil
+}
+
 // dropSectors drops the specified number of sectors and returns the new merkle
 // root.
 func (s *sectors) dropSectors(numSectorsDropped uint64) (crypto.Hash, error) {
@@ -64,20 +74,39 @@ func (s *sectors) dropSectors(numSectorsDropped uint64) (crypto.Hash, error) {
 
 	// Update the program cache.
 	for _, droppedRoot := range droppedRoots {
-		_, gained := s.sectorsGained[droppedRoot]
-		if gained {
-			// Remove the sectors from the cache.
-			delete(s.sectorsGained, droppedRoot)
-		} else {
-			// Mark the sectors as removed in the cache.
-			s.sectorsRemoved[droppedRoot] = struct{}{}
-		}
+		s.removeSector(droppedRoot)
 	}
 
 	// Compute the new merkle root of the contract.
 	return cachedMerkleRoot(s.merkleRoots), nil
 }
 
+// gainSector adds a sector to the program cache. If the sector has been marked
+// as removed before, it is unmarked instead.
+func (s *sectors) gainSector(sectorRoot crypto.Hash, sectorData []byte) {
+	_, removed := s.sectorsRemoved[sectorRoot]
+	if removed {
+		// If the sector has been marked as removed, unmark it.
+		delete(s.sectorsRemoved, sectorRoot)
+	} else {
+		// Add the sector to the cache.
+		s.sectorsGained[sectorRoot] = sectorData
+	}
+}
+
+// removeSector removes a sector from the program cache. If the sector wasn't
+// gained by the program, it is marked as removed instead.
+func (s *sectors) removeSector(sectorRoot crypto.Hash) {
+	_, gained := s.sectorsGained[sectorRoot]
+	if gained {
+		// Remove the sector from the cache.
+		delete(s.sectorsGained, sectorRoot)
+	} else {
+		// Mark the sector as removed in the cache.
+		s.sectorsRemoved[sectorRoot] = struct{}{}
+	}
+}
+
 // hasSector checks if the given root exists, first checking the program cache
 // and then querying the host.
 func (s *sectors) hasSector(sectorRoot crypto.Hash) bool {
@@ -102,6 +131,28 @@ func (s *sectors) swapSectors(idx1, idx2 uint64) (crypto.Hash, error) {
 	return cachedMerkleRoot(s.merkleRoots), nil
 }
 
+// updateSector replaces the sector at idx with the provided data and returns
+// the new merkle root.
+func (s *sectors) updateSector(idx uint64, sectorData []byte) (crypto.Hash, error) {
+	if idx >= uint64(len(s.merkleRoots)) {
+		return crypto.Hash{}, fmt.Errorf("idx out-of-bounds: %v >= %v", idx, len(s.merkleRoots))
+	}
+	if uint64(len(sectorData)) != modules.SectorSize {
+		return crypto.Hash{}, fmt.Errorf("trying to update sector with data of length %v", len(sectorData))
+	}
+	newRoot := crypto.MerkleRoot(sectorData)
+
+	// Update the program cache.
+	s.removeSector(s.merkleRoots[idx])
+	s.gainSector(newRoot, sectorData)
+
+	// Update the roots.
+	s.merkleRoots[idx] = newRoot
+
+	// Return the new merkle root of the contract.
+	return cachedMerkleRoot(s.merkleRoots), nil
+}
+
 // translateOffset translates an offset within a filecontract into a relative
 // offset within a sector and the sector's index within the contract.
 func (s *sectors) translateOffset(offset uint64) (uint64, uint64, error) {
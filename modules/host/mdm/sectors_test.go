@@ -244,6 +244,56 @@ func TestDropSectors(t *testing.T) {
 	}
 }
 
+// TestUpdateSector tests updating sectors in the cache.
+func TestUpdateSector(t *testing.T) {
+	// Initialize the sectors.
+	sectorRoots := randomSectorRoots(initialContractSectors)
+	oldRoot := sectorRoots[0]
+	s := newSectors(append([]crypto.Hash{}, sectorRoots...))
+
+	// Update the first sector.
+	data := randomSectorData()
+	newRoot := crypto.MerkleRoot(data)
+	root, err := s.updateSector(0, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectorRoots[0] = newRoot
+	if root != cachedMerkleRoot(sectorRoots) {
+		t.Fatalf("unexpected merkle root")
+	}
+	if _, exists := s.sectorsRemoved[oldRoot]; !exists {
+		t.Fatal("old sector wasn't marked as removed")
+	}
+	if !bytes.Equal(s.sectorsGained[newRoot], data) {
+		t.Fatal("new sector wasn't added to the cache")
+	}
+
+	// Update the same sector again. The previously gained sector should be
+	// removed from the cache.
+	data2 := randomSectorData()
+	newRoot2 := crypto.MerkleRoot(data2)
+	_, err = s.updateSector(0, data2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := s.sectorsGained[newRoot]; exists {
+		t.Fatal("intermediate sector should have been removed from the cache")
+	}
+	if _, exists := s.sectorsGained[newRoot2]; !exists {
+		t.Fatal("new sector wasn't added to the cache")
+	}
+	if len(s.sectorsRemoved) != 1 || len(s.sectorsGained) != 1 {
+		t.Fatalf("unexpected cache sizes %v %v", len(s.sectorsRemoved), len(s.sectorsGained))
+	}
+
+	// Updating an out-of-bounds sector should fail.
+	_, err = s.updateSector(initialContractSectors, data)
+	if err == nil {
+		t.Fatal("expected error when updating out-of-bounds sector")
+	}
+}
+
 // TestHasSector tests checking if a sector exists in the cache or host.
 func TestHasSector(t *testing.T) {
 	// Initialize the sectors.
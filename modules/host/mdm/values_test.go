@@ -60,6 +60,19 @@ func (v *TestValues) AddAppendInstruction(data []byte) {
 	v.addInstruction(collateral, cost, refund, memory, time, newData, readonly, batch)
 }
 
+// AddCopySectorInstruction adds the cost of a copy sector instruction to the
+// object.
+func (v *TestValues) AddCopySectorInstruction() {
+	memory := modules.MDMCopySectorMemory()
+	collateral := modules.MDMCopySectorCollateral(v.staticPT)
+	cost, refund := modules.MDMCopyCost(v.staticPT, v.staticDuration)
+	time := uint64(modules.MDMTimeCopySector)
+	newData := crypto.HashSize + 8
+	readonly := false
+	batch := false
+	v.addInstruction(collateral, cost, refund, memory, time, newData, readonly, batch)
+}
+
 // AddDropSectorsInstruction adds the cost of a drop sectors instruction to the
 // object.
 func (v *TestValues) AddDropSectorsInstruction(numSectors uint64) {
@@ -124,6 +137,19 @@ func (v *TestValues) AddRevisionInstruction() {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, memory, time, 0, readonly, batch)
 }
 
+// AddStoreSectorInstruction adds the cost of a store sector instruction to the
+// object.
+func (v *TestValues) AddStoreSectorInstruction(duration types.BlockHeight) {
+	collateral := modules.MDMStoreSectorCollateral()
+	cost, refund := modules.MDMStoreSectorCost(v.staticPT, duration)
+	memory := modules.MDMStoreSectorMemory()
+	time := uint64(modules.MDMTimeStoreSector)
+	newData := int(modules.SectorSize) + 8
+	readonly := true
+	batch := false
+	v.addInstruction(collateral, cost, refund, memory, time, newData, readonly, batch)
+}
+
 // AddSwapSectorInstruction adds a revision instruction to the builder, keeping
 // track of running values.
 func (v *TestValues) AddSwapSectorInstruction() {
@@ -137,6 +163,32 @@ func (v *TestValues) AddSwapSectorInstruction() {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, memory, time, newData, readonly, batch)
 }
 
+// AddTruncateInstruction adds the cost of a truncate instruction to the
+// object.
+func (v *TestValues) AddTruncateInstruction(numSectors uint64) {
+	collateral := modules.MDMTruncateCollateral()
+	cost := modules.MDMTruncateCost(v.staticPT, numSectors)
+	memory := modules.MDMTruncateMemory()
+	time := modules.MDMTruncateTime(numSectors)
+	newData := 8
+	readonly := false
+	batch := false
+	v.addInstruction(collateral, cost, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
+// AddUpdateSectorInstruction adds the cost of an update sector instruction to
+// the object.
+func (v *TestValues) AddUpdateSectorInstruction(data []byte) {
+	collateral := modules.MDMUpdateSectorCollateral()
+	cost := modules.MDMUpdateSectorCost(v.staticPT)
+	memory := modules.MDMUpdateSectorMemory()
+	time := uint64(modules.MDMTimeUpdateSector)
+	newData := 8 + 8 + len(data)
+	readonly := false
+	batch := false
+	v.addInstruction(collateral, cost, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
 // AddUpdateRegistryInstruction adds a revision instruction to the builder, keeping
 // track of running values.
 func (v *TestValues) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv modules.SignedRegistryValue) {
@@ -164,6 +216,20 @@ func (v *TestValues) AddReadRegistryInstruction(spk types.SiaPublicKey) {
 	v.addInstruction(collateral, cost, refund, memory, time, newData, readonly, batch)
 }
 
+// AddReadRegistryEIDInstruction adds a ReadRegistryEID instruction to the
+// builder, keeping track of running values.
+func (v *TestValues) AddReadRegistryEIDInstruction() {
+	memory := modules.MDMReadRegistryMemory()
+	collateral := modules.MDMReadRegistryCollateral()
+	cost := modules.MDMReadRegistryCost(v.staticPT)
+	refund := types.ZeroCurrency
+	time := uint64(modules.MDMTimeReadRegistry)
+	newData := crypto.HashSize
+	readonly := true
+	batch := true
+	v.addInstruction(collateral, cost, refund, memory, time, newData, readonly, batch)
+}
+
 // Cost returns the current cost of the program which would result . If
 // 'finalized' is 'true', the memory cost of finalizing the program is included.
 func (v TestValues) Cost() (cost, refund, collateral types.Currency) {
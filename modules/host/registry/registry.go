@@ -87,7 +87,7 @@ type (
 // valueMapKey creates a key usable in in-memory maps from a value's key and
 // tweak.
 func valueMapKey(key types.SiaPublicKey, tweak crypto.Hash) crypto.Hash {
-	return crypto.HashAll(key, tweak)
+	return modules.DeriveRegistryEntryID(key, tweak)
 }
 
 // mapKey creates a key usable in in-memory maps from the value.
@@ -148,6 +148,20 @@ func (r *Registry) Get(pubKey types.SiaPublicKey, tweak crypto.Hash) (modules.Si
 	return modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature), true
 }
 
+// GetByRID fetches the data associated with a registry entry ID from the
+// registry. On top of the value it also returns the entry's public key.
+func (r *Registry) GetByRID(rid crypto.Hash) (types.SiaPublicKey, modules.SignedRegistryValue, bool) {
+	r.mu.Lock()
+	v, ok := r.entries[rid]
+	r.mu.Unlock()
+	if !ok {
+		return types.SiaPublicKey{}, modules.SignedRegistryValue{}, false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.key, modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature), true
+}
+
 // Len returns the length of the registry.
 func (r *Registry) Len() uint64 {
 	r.mu.Lock()
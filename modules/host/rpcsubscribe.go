@@ -67,8 +67,7 @@ func newRegistrySubscriptions() *registrySubscriptions {
 // subscriptionMemoryCost is the cost of storing the given number of
 // subscriptions in memory.
 func subscriptionMemoryCost(pt *modules.RPCPriceTable, newSubscriptions uint64) types.Currency {
-	memory := newSubscriptions * modules.SubscriptionEntrySize
-	return pt.SubscriptionMemoryCost.Mul64(memory)
+	return modules.SubscriptionMemoryCost(pt, newSubscriptions)
 }
 
 // newSubscriptionInfo creates a new subscriptionInfo object.
@@ -319,4 +319,22 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 	}
 
 	newHeight = h.blockHeight
+
+	// Remove the ephemeral sectors which expired.
+	go h.threadedRemoveExpiredEphemeralSectors(newHeight)
+}
+
+// threadedRemoveExpiredEphemeralSectors removes all the ephemeral sectors from
+// the storage manager which expire at or before the provided height.
+func (h *Host) threadedRemoveExpiredEphemeralSectors(height types.BlockHeight) {
+	err := h.tg.Add()
+	if err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	err = h.RemoveExpiredEphemeralSectors(height)
+	if err != nil {
+		h.log.Println("WARN: failed to remove expired ephemeral sectors:", err)
+	}
 }
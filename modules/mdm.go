@@ -49,6 +49,10 @@ const (
 	// TODO: This should scale with the number of added + removed sectors.
 	MDMTimeCommit = 50e3
 
+	// MDMTimeCopySector is the time for executing a 'CopySector' instruction.
+	// The sector already exists on the host so only a read is required.
+	MDMTimeCopySector = 1000
+
 	// MDMTimeDropSectorsBase is the base time for executing a 'DropSectors'
 	// instruction.
 	MDMTimeDropSectorsBase = 1
@@ -76,9 +80,25 @@ const (
 	// MDMTimeRevision is the time for executing a 'Revision' instruction.
 	MDMTimeRevision = 1
 
+	// MDMTimeStoreSector is the time for executing a 'StoreSector'
+	// instruction.
+	MDMTimeStoreSector = MDMTimeWriteSector
+
 	// MDMTimeSwapSector is the time for executing an 'SwapSector' instruction.
 	MDMTimeSwapSector = 1
 
+	// MDMTimeTruncateBase is the base time for executing a 'Truncate'
+	// instruction.
+	MDMTimeTruncateBase = 1
+
+	// MDMTimeTruncateSingleSector is the time for truncating a single sector.
+	MDMTimeTruncateSingleSector = 1
+
+	// MDMTimeUpdateSector is the time for executing an 'UpdateSector'
+	// instruction. Sectors can't be updated in place so the host needs to read
+	// the old sector and write a new one.
+	MDMTimeUpdateSector = MDMTimeReadSector + MDMTimeWriteSector
+
 	// MDMTimeWriteSector is the time for executing a 'WriteSector' instruction.
 	MDMTimeWriteSector = 10000
 
@@ -94,6 +114,10 @@ const (
 	// instructon.
 	RPCIAppendLen = 9
 
+	// RPCICopySectorLen is the expected length of the 'Args' of a CopySector
+	// instruction.
+	RPCICopySectorLen = 17 // 2 uint64 offsets + merkle proof flag
+
 	// RPCIDropSectorsLen is the expected length of the 'Args' of a DropSectors
 	// Instruction.
 	RPCIDropSectorsLen = 9
@@ -114,10 +138,22 @@ const (
 	// instruction.
 	RPCIRevisionLen = 0
 
+	// RPCIStoreSectorLen is the expected length of the 'Args' of a
+	// StoreSector instruction.
+	RPCIStoreSectorLen = 16 // 2 uint64 offsets
+
 	// RPCISwapSectorLen is the expected length of the 'Args' of an SwapSector
 	// instructon.
 	RPCISwapSectorLen = 17 // 2 uint64 offsets + merkle proof flag
 
+	// RPCITruncateLen is the expected length of the 'Args' of a Truncate
+	// instruction.
+	RPCITruncateLen = 9
+
+	// RPCIUpdateSectorLen is the expected length of the 'Args' of an
+	// UpdateSector instruction.
+	RPCIUpdateSectorLen = 25 // 3 uint64 offsets + merkle proof flag
+
 	// RPCIUpdateRegistryLen is the expected length of the 'Args' of an
 	// UpdateRegistry instruction.
 	// tweakOffset + revisionOffset + signatureOffset + pubKeyOffset +
@@ -128,6 +164,11 @@ const (
 	// ReadRegistry instruction.
 	// tweakOffset + pubKeyOffset + pubKeyLength = 3 * 8 bytes = 24 byte
 	RPCIReadRegistryLen = 24
+
+	// RPCIReadRegistryEIDLen is the expected length of the 'Args' of an
+	// ReadRegistryEID instruction.
+	// entryIDOffset = 8 bytes
+	RPCIReadRegistryEIDLen = 8
 )
 
 var (
@@ -144,6 +185,9 @@ var (
 	// SpecifierAppend is the specifier for the Append instruction.
 	SpecifierAppend = InstructionSpecifier{'A', 'p', 'p', 'e', 'n', 'd'}
 
+	// SpecifierCopySector is the specifier for the CopySector instruction.
+	SpecifierCopySector = InstructionSpecifier{'C', 'o', 'p', 'y', 'S', 'e', 'c', 't', 'o', 'r'}
+
 	// SpecifierDropSectors is the specifier for the DropSectors instruction.
 	SpecifierDropSectors = InstructionSpecifier{'D', 'r', 'o', 'p', 'S', 'e', 'c', 't', 'o', 'r', 's'}
 
@@ -159,9 +203,18 @@ var (
 	// SpecifierRevision is the specifier for the Revision instruction.
 	SpecifierRevision = InstructionSpecifier{'R', 'e', 'v', 'i', 's', 'i', 'o', 'n'}
 
+	// SpecifierStoreSector is the specifier for the StoreSector instruction.
+	SpecifierStoreSector = InstructionSpecifier{'S', 't', 'o', 'r', 'e', 'S', 'e', 'c', 't', 'o', 'r'}
+
 	// SpecifierSwapSector is the specifier for the SwapSector instruction.
 	SpecifierSwapSector = InstructionSpecifier{'S', 'w', 'a', 'p', 'S', 'e', 'c', 't', 'o', 'r'}
 
+	// SpecifierTruncate is the specifier for the Truncate instruction.
+	SpecifierTruncate = InstructionSpecifier{'T', 'r', 'u', 'n', 'c', 'a', 't', 'e'}
+
+	// SpecifierUpdateSector is the specifier for the UpdateSector instruction.
+	SpecifierUpdateSector = InstructionSpecifier{'U', 'p', 'd', 'a', 't', 'e', 'S', 'e', 'c', 't', 'o', 'r'}
+
 	// SpecifierUpdateRegistry is the specifier for the UpdateRegistry
 	// instruction.
 	SpecifierUpdateRegistry = InstructionSpecifier{'U', 'p', 'd', 'a', 't', 'e', 'R', 'e', 'g', 'i', 's', 't', 'r', 'y'}
@@ -170,6 +223,10 @@ var (
 	// instruction.
 	SpecifierReadRegistry = InstructionSpecifier{'R', 'e', 'a', 'd', 'R', 'e', 'g', 'i', 's', 't', 'r', 'y'}
 
+	// SpecifierReadRegistryEID is the specifier for the ReadRegistryEID
+	// instruction.
+	SpecifierReadRegistryEID = InstructionSpecifier{'R', 'e', 'a', 'd', 'R', 'e', 'g', 'i', 's', 't', 'r', 'y', 'E', 'I', 'D'}
+
 	// ErrInsufficientBandwidthBudget is returned when bandwidth can no longer
 	// be paid for with the provided budget.
 	ErrInsufficientBandwidthBudget = errors.New("insufficient budget for bandwidth")
@@ -226,9 +283,15 @@ func MDMAppendCost(pt *RPCPriceTable, duration types.BlockHeight) (types.Currenc
 	return writeCost.Add(storeCost), storeCost
 }
 
-// MDMCopyCost is the cost of executing a 'Copy' instruction.
-func MDMCopyCost(pt RPCPriceTable, contractSize uint64) types.Currency {
-	return types.SiacoinPrecision // TODO: figure out good cost
+// MDMCopyCost is the cost of executing a 'CopySector' instruction. The sector
+// doesn't need to be uploaded or written again, but the host needs to read it
+// and store it for the duration of the contract.
+func MDMCopyCost(pt *RPCPriceTable, duration types.BlockHeight) (types.Currency, types.Currency) {
+	// Cost for reading the sector.
+	readCost := MDMReadCost(pt, SectorSize)
+	// Cost of storing for the duration.
+	storeCost := pt.WriteStoreCost.Mul64(SectorSize).Mul64(uint64(duration))
+	return readCost.Add(storeCost), storeCost
 }
 
 // MDMDropSectorsCost is the cost of executing a 'DropSectors' instruction for a
@@ -263,6 +326,17 @@ func MDMRevisionCost(pt *RPCPriceTable) types.Currency {
 	return cost
 }
 
+// MDMStoreSectorCost is the cost of executing a 'StoreSector' instruction.
+// The sector is stored for the given duration without a file contract, which
+// is why the host's ephemeral storage price is used.
+func MDMStoreSectorCost(pt *RPCPriceTable, duration types.BlockHeight) (types.Currency, types.Currency) {
+	// Cost for writing the Data.
+	writeCost := MDMWriteCost(pt, SectorSize)
+	// Cost of storing for the duration.
+	storeCost := pt.EphemeralStorageCost.Mul64(SectorSize).Mul64(uint64(duration))
+	return writeCost.Add(storeCost), storeCost
+}
+
 // MDMSwapSectorCost is the cost of executing a 'SwapSector' instruction.
 func MDMSwapSectorCost(pt *RPCPriceTable) types.Currency {
 	return pt.SwapSectorCost
@@ -299,9 +373,17 @@ func MDMSwapCost(pt *RPCPriceTable, contractSize uint64) types.Currency {
 	return types.SiacoinPrecision // TODO: figure out good cost
 }
 
-// MDMTruncateCost is the cost of executing a 'Truncate' instruction.
-func MDMTruncateCost(pt *RPCPriceTable, contractSize uint64) types.Currency {
-	return types.SiacoinPrecision // TODO: figure out good cost
+// MDMTruncateCost is the cost of executing a 'Truncate' instruction for a
+// certain number of truncated sectors.
+func MDMTruncateCost(pt *RPCPriceTable, numSectorsTruncated uint64) types.Currency {
+	return MDMDropSectorsCost(pt, numSectorsTruncated)
+}
+
+// MDMUpdateSectorCost is the cost of executing an 'UpdateSector' instruction.
+// Since sectors can't be updated in place, the host needs to read the whole
+// sector and write a new one, independent of the length of the update.
+func MDMUpdateSectorCost(pt *RPCPriceTable) types.Currency {
+	return MDMReadCost(pt, SectorSize).Add(MDMWriteCost(pt, SectorSize))
 }
 
 // MDMAppendMemory returns the additional memory consumption of a 'Append'
@@ -310,6 +392,12 @@ func MDMAppendMemory() uint64 {
 	return SectorSize // A full sector is added to the program's memory until the program is finalized.
 }
 
+// MDMCopySectorMemory returns the additional memory consumption of a
+// 'CopySector' instruction.
+func MDMCopySectorMemory() uint64 {
+	return SectorSize // A full sector is added to the program's memory until the program is finalized.
+}
+
 // MDMDropSectorsMemory returns the additional memory consumption of a
 // `DropSectors` instruction
 func MDMDropSectorsMemory() uint64 {
@@ -339,12 +427,30 @@ func MDMRevisionMemory() uint64 {
 	return 0 // 'Revision' doesn't hold on to any memory beyond the lifetime of the instruction.
 }
 
+// MDMStoreSectorMemory returns the additional memory consumption of a
+// 'StoreSector' instruction.
+func MDMStoreSectorMemory() uint64 {
+	return SectorSize // A full sector is added to the program's memory until the program is committed.
+}
+
 // MDMSwapSectorMemory returns the additional memory consumption of a
 // 'SwapSector' instruction.
 func MDMSwapSectorMemory() uint64 {
 	return 0 // 'SwapSector' doesn't hold on to any memory beyond the lifetime of the instruction.
 }
 
+// MDMTruncateMemory returns the additional memory consumption of a 'Truncate'
+// instruction.
+func MDMTruncateMemory() uint64 {
+	return 0 // 'Truncate' doesn't hold on to any memory beyond the lifetime of the instruction.
+}
+
+// MDMUpdateSectorMemory returns the additional memory consumption of an
+// 'UpdateSector' instruction.
+func MDMUpdateSectorMemory() uint64 {
+	return SectorSize // The updated sector is added to the program's memory until the program is finalized.
+}
+
 // MDMUpdateRegistryMemory returns the additional memory consumption of a
 // 'UpdateRegistry' instruction.
 func MDMUpdateRegistryMemory() uint64 {
@@ -377,18 +483,37 @@ func SubscriptionNotificationsCost(pt *RPCPriceTable, numNotifications uint64) t
 	return singleCost.Mul64(numNotifications)
 }
 
+// SubscriptionMemoryCost is a helper to compute the cost of storing
+// numSubscriptions subscriptions in memory for a SubscriptionPeriod.
+func SubscriptionMemoryCost(pt *RPCPriceTable, numSubscriptions uint64) types.Currency {
+	memory := numSubscriptions * SubscriptionEntrySize
+	return pt.SubscriptionMemoryCost.Mul64(memory)
+}
+
 // MDMDropSectorsTime returns the time for a `DropSectors` instruction given
 // `numSectorsDropped`.
 func MDMDropSectorsTime(numSectorsDropped uint64) uint64 {
 	return MDMTimeDropSectorsBase + MDMTimeDropSingleSector*numSectorsDropped
 }
 
+// MDMTruncateTime returns the time for a `Truncate` instruction given
+// `numSectorsTruncated`.
+func MDMTruncateTime(numSectorsTruncated uint64) uint64 {
+	return MDMTimeTruncateBase + MDMTimeTruncateSingleSector*numSectorsTruncated
+}
+
 // MDMAppendCollateral returns the additional collateral a 'Append' instruction
 // requires the host to put up.
 func MDMAppendCollateral(pt *RPCPriceTable) types.Currency {
 	return pt.CollateralCost.Mul64(SectorSize)
 }
 
+// MDMCopySectorCollateral returns the additional collateral a 'CopySector'
+// instruction requires the host to put up.
+func MDMCopySectorCollateral(pt *RPCPriceTable) types.Currency {
+	return pt.CollateralCost.Mul64(SectorSize)
+}
+
 // MDMDropSectorsCollateral returns the additional collateral a 'DropSectors'
 // instruction requires the host to put up.
 func MDMDropSectorsCollateral() types.Currency {
@@ -413,12 +538,30 @@ func MDMRevisionCollateral() types.Currency {
 	return types.ZeroCurrency
 }
 
+// MDMStoreSectorCollateral returns the additional collateral a 'StoreSector'
+// instruction requires the host to put up.
+func MDMStoreSectorCollateral() types.Currency {
+	return types.ZeroCurrency
+}
+
 // MDMSwapSectorCollateral returns the additional collateral a 'SwapSector'
 // instruction requires the host to put up.
 func MDMSwapSectorCollateral() types.Currency {
 	return types.ZeroCurrency
 }
 
+// MDMTruncateCollateral returns the additional collateral a 'Truncate'
+// instruction requires the host to put up.
+func MDMTruncateCollateral() types.Currency {
+	return types.ZeroCurrency
+}
+
+// MDMUpdateSectorCollateral returns the additional collateral an
+// 'UpdateSector' instruction requires the host to put up.
+func MDMUpdateSectorCollateral() types.Currency {
+	return types.ZeroCurrency
+}
+
 // MDMUpdateRegistryCollateral returns the additional collateral a
 // 'UpdateRegistry' instruction requires the host to put up.
 func MDMUpdateRegistryCollateral() types.Currency {
@@ -437,17 +580,26 @@ func (p Program) ReadOnly() bool {
 		switch instruction.Specifier {
 		case SpecifierAppend:
 			return false
+		case SpecifierCopySector:
+			return false
 		case SpecifierDropSectors:
 			return false
 		case SpecifierHasSector:
 		case SpecifierReadOffset:
 		case SpecifierReadSector:
 		case SpecifierRevision:
+		case SpecifierStoreSector:
+			// considered read-only cause it doesn't update a contract
 		case SpecifierSwapSector:
 			return false
+		case SpecifierTruncate:
+			return false
+		case SpecifierUpdateSector:
+			return false
 		case SpecifierUpdateRegistry:
 			// considered read-only cause it doesn't update a contract
 		case SpecifierReadRegistry:
+		case SpecifierReadRegistryEID:
 		default:
 			build.Critical("ReadOnly: unknown instruction")
 		}
@@ -464,6 +616,8 @@ func (p Program) RequiresSnapshot() bool {
 		switch instruction.Specifier {
 		case SpecifierAppend:
 			return true
+		case SpecifierCopySector:
+			return true
 		case SpecifierDropSectors:
 			return true
 		case SpecifierHasSector:
@@ -472,10 +626,16 @@ func (p Program) RequiresSnapshot() bool {
 		case SpecifierReadSector:
 		case SpecifierRevision:
 			return true
+		case SpecifierStoreSector:
 		case SpecifierSwapSector:
 			return true
+		case SpecifierTruncate:
+			return true
+		case SpecifierUpdateSector:
+			return true
 		case SpecifierUpdateRegistry:
 		case SpecifierReadRegistry:
+		case SpecifierReadRegistryEID:
 		default:
 			build.Critical("RequiresSnapshot: unknown instruction")
 		}
@@ -165,6 +165,11 @@ func TestRequiresReadonlyAndSnapshot(t *testing.T) {
 			false,
 			true,
 		},
+		{
+			SpecifierCopySector,
+			false,
+			true,
+		},
 		{
 			SpecifierDropSectors,
 			false,
@@ -190,11 +195,26 @@ func TestRequiresReadonlyAndSnapshot(t *testing.T) {
 			true,
 			true,
 		},
+		{
+			SpecifierStoreSector,
+			true,
+			false,
+		},
 		{
 			SpecifierSwapSector,
 			false,
 			true,
 		},
+		{
+			SpecifierTruncate,
+			false,
+			true,
+		},
+		{
+			SpecifierUpdateSector,
+			false,
+			true,
+		},
 	}
 
 	for i, test := range tests {
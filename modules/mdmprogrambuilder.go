@@ -65,6 +65,30 @@ func (pb *ProgramBuilder) AddAppendInstruction(data []byte, merkleProof bool) er
 	return nil
 }
 
+// AddCopySectorInstruction adds a CopySector instruction to the program. The
+// sector at sectorIdx of the contract with the given id is appended to the
+// contract the program is executed on. Both contracts need to belong to the
+// same renter.
+func (pb *ProgramBuilder) AddCopySectorInstruction(fcid types.FileContractID, sectorIdx uint64, merkleProof bool) {
+	// Compute the argument offsets.
+	fcidOffset := uint64(pb.programData.Len())
+	sectorIdxOffset := fcidOffset + crypto.HashSize
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, fcid[:])
+	binary.Write(pb.programData, binary.LittleEndian, sectorIdx)
+	// Create the instruction.
+	i := NewCopySectorInstruction(fcidOffset, sectorIdxOffset, merkleProof)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMCopySectorCollateral(pb.staticPT)
+	cost, refund := MDMCopyCost(pb.staticPT, pb.staticDuration)
+	memory := MDMCopySectorMemory()
+	time := uint64(MDMTimeCopySector)
+	pb.addInstruction(collateral, cost, refund, memory, time)
+	pb.readonly = false
+}
+
 // AddDropSectorsInstruction adds a DropSectors instruction to the program.
 func (pb *ProgramBuilder) AddDropSectorsInstruction(numSectors uint64, merkleProof bool) {
 	// Compute the argument offsets.
@@ -160,6 +184,32 @@ func (pb *ProgramBuilder) AddRevisionInstruction() {
 	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
 }
 
+// AddStoreSectorInstruction adds a StoreSector instruction to the program. The
+// sector is stored by the host for the given duration without being added to a
+// file contract.
+func (pb *ProgramBuilder) AddStoreSectorInstruction(data []byte, duration types.BlockHeight) error {
+	if uint64(len(data)) != SectorSize {
+		return fmt.Errorf("expected stored data to have size %v but was %v", SectorSize, len(data))
+	}
+	// Compute the argument offsets.
+	dataOffset := uint64(pb.programData.Len())
+	durationOffset := dataOffset + SectorSize
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, data)
+	binary.Write(pb.programData, binary.LittleEndian, duration)
+	// Create the instruction.
+	i := NewStoreSectorInstruction(dataOffset, durationOffset)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMStoreSectorCollateral()
+	cost, refund := MDMStoreSectorCost(pb.staticPT, duration)
+	memory := MDMStoreSectorMemory()
+	time := uint64(MDMTimeStoreSector)
+	pb.addInstruction(collateral, cost, refund, memory, time)
+	return nil
+}
+
 // AddSwapSectorInstruction adds a SwapSector instruction to the program.
 func (pb *ProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx uint64, merkleProof bool) {
 	// Compute the argument offsets.
@@ -181,6 +231,59 @@ func (pb *ProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx uint64
 	pb.readonly = false
 }
 
+// AddTruncateInstruction adds a Truncate instruction to the program.
+func (pb *ProgramBuilder) AddTruncateInstruction(numSectors uint64, merkleProof bool) {
+	// Compute the argument offsets.
+	numSectorsOffset := uint64(pb.programData.Len())
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, numSectors)
+	// Create the instruction.
+	i := NewTruncateInstruction(numSectorsOffset, merkleProof)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMTruncateCollateral()
+	cost := MDMTruncateCost(pb.staticPT, numSectors)
+	memory := MDMTruncateMemory()
+	time := MDMTruncateTime(numSectors)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+	pb.readonly = false
+}
+
+// AddUpdateSectorInstruction adds an UpdateSector instruction to the program.
+// The data overwrites the contract's data starting at offset. Both the offset
+// and the length of the data need to be segment aligned and the update can't
+// span multiple sectors.
+func (pb *ProgramBuilder) AddUpdateSectorInstruction(offset uint64, data []byte, merkleProof bool) error {
+	length := uint64(len(data))
+	if length == 0 || length%crypto.SegmentSize != 0 || offset%crypto.SegmentSize != 0 {
+		return fmt.Errorf("offset %v and length %v of the update need to be segment aligned", offset, length)
+	}
+	if offset%SectorSize+length > SectorSize {
+		return fmt.Errorf("update of length %v at offset %v spans multiple sectors", length, offset)
+	}
+	// Compute the argument offsets.
+	offsetOffset := uint64(pb.programData.Len())
+	lengthOffset := offsetOffset + 8
+	dataOffset := lengthOffset + 8
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, offset)
+	binary.Write(pb.programData, binary.LittleEndian, length)
+	binary.Write(pb.programData, binary.LittleEndian, data)
+	// Create the instruction.
+	i := NewUpdateSectorInstruction(offsetOffset, lengthOffset, dataOffset, merkleProof)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMUpdateSectorCollateral()
+	cost := MDMUpdateSectorCost(pb.staticPT)
+	memory := MDMUpdateSectorMemory()
+	time := uint64(MDMTimeUpdateSector)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+	pb.readonly = false
+	return nil
+}
+
 // AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the program.
 func (pb *ProgramBuilder) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv SignedRegistryValue) error {
 	// Marshal pubKey.
@@ -243,6 +346,30 @@ func (pb *ProgramBuilder) AddReadRegistryInstruction(spk types.SiaPublicKey, twe
 	return nil
 }
 
+// AddReadRegistryEIDInstruction adds an ReadRegistryEID instruction to the
+// program.
+func (pb *ProgramBuilder) AddReadRegistryEIDInstruction(rid crypto.Hash) error {
+	// Compute the argument offsets.
+	ridOff := uint64(pb.programData.Len())
+	// Extend the programData.
+	_, err := pb.programData.Write(rid[:])
+	if err != nil {
+		return errors.AddContext(err, "AddReadRegistryEIDInstruction: failed to extend programData")
+	}
+	// Create the instruction.
+	i := NewReadRegistryEIDInstruction(ridOff)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Read cost, collateral and memory usage.
+	collateral := MDMReadRegistryCollateral()
+	cost := MDMReadRegistryCost(pb.staticPT)
+	refund := types.ZeroCurrency
+	memory := MDMReadRegistryMemory()
+	time := uint64(MDMTimeReadRegistry)
+	pb.addInstruction(collateral, cost, refund, memory, time)
+	return nil
+}
+
 // Cost returns the current cost of the program being built by the builder. If
 // 'finalized' is 'true', the memory cost of finalizing the program is included.
 func (pb *ProgramBuilder) Cost(finalized bool) (cost, storage, collateral types.Currency) {
@@ -291,6 +418,20 @@ func NewAppendInstruction(dataOffset uint64, merkleProof bool) Instruction {
 	return i
 }
 
+// NewCopySectorInstruction creates an Instruction from arguments.
+func NewCopySectorInstruction(fcidOffset, sectorIdxOffset uint64, merkleProof bool) Instruction {
+	i := Instruction{
+		Specifier: SpecifierCopySector,
+		Args:      make([]byte, RPCICopySectorLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], fcidOffset)
+	binary.LittleEndian.PutUint64(i.Args[8:16], sectorIdxOffset)
+	if merkleProof {
+		i.Args[16] = 1
+	}
+	return i
+}
+
 // NewUpdateRegistryInstruction creates an Instruction from arguments.
 func NewUpdateRegistryInstruction(tweakOff, revisionOff, signatureOff, pubKeyOff, pubKeyLen, dataOff, dataLen uint64) Instruction {
 	i := Instruction{
@@ -319,6 +460,16 @@ func NewReadRegistryInstruction(pubKeyOff, pubKeyLen, tweakOff uint64) Instructi
 	return i
 }
 
+// NewReadRegistryEIDInstruction creates an Instruction from arguments.
+func NewReadRegistryEIDInstruction(ridOff uint64) Instruction {
+	i := Instruction{
+		Specifier: SpecifierReadRegistryEID,
+		Args:      make([]byte, RPCIReadRegistryEIDLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], ridOff)
+	return i
+}
+
 // NewDropSectorsInstruction creates an Instruction from arguments.
 func NewDropSectorsInstruction(numSectorsOffset uint64, merkleProof bool) Instruction {
 	i := Instruction{
@@ -371,6 +522,17 @@ func NewReadSectorInstruction(lengthOffset, offsetOffset, merkleRootOffset uint6
 	return i
 }
 
+// NewStoreSectorInstruction creates an Instruction from arguments.
+func NewStoreSectorInstruction(dataOffset, durationOffset uint64) Instruction {
+	i := Instruction{
+		Specifier: SpecifierStoreSector,
+		Args:      make([]byte, RPCIStoreSectorLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], dataOffset)
+	binary.LittleEndian.PutUint64(i.Args[8:16], durationOffset)
+	return i
+}
+
 // NewSwapSectorInstruction creates a modules.Instruction from arguments.
 func NewSwapSectorInstruction(sector1Offset, sector2Offset uint64, merkleProof bool) Instruction {
 	i := Instruction{
@@ -385,6 +547,34 @@ func NewSwapSectorInstruction(sector1Offset, sector2Offset uint64, merkleProof b
 	return i
 }
 
+// NewTruncateInstruction creates a modules.Instruction from arguments.
+func NewTruncateInstruction(numSectorsOffset uint64, merkleProof bool) Instruction {
+	i := Instruction{
+		Specifier: SpecifierTruncate,
+		Args:      make([]byte, RPCITruncateLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], numSectorsOffset)
+	if merkleProof {
+		i.Args[8] = 1
+	}
+	return i
+}
+
+// NewUpdateSectorInstruction creates a modules.Instruction from arguments.
+func NewUpdateSectorInstruction(offsetOffset, lengthOffset, dataOffset uint64, merkleProof bool) Instruction {
+	i := Instruction{
+		Specifier: SpecifierUpdateSector,
+		Args:      make([]byte, RPCIUpdateSectorLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], offsetOffset)
+	binary.LittleEndian.PutUint64(i.Args[8:16], lengthOffset)
+	binary.LittleEndian.PutUint64(i.Args[16:24], dataOffset)
+	if merkleProof {
+		i.Args[24] = 1
+	}
+	return i
+}
+
 // NewRevisionInstruction creates a modules.Instruction from arguments.
 func NewRevisionInstruction(merkleRootOffset uint64) Instruction {
 	return Instruction{
@@ -26,6 +26,12 @@ var (
 		Testing:  uint64(1 << (alignmentScalingStandard - (SectorSizeScalingStandard - SectorSizeScalingTesting))),
 	}).(uint64)
 	alignmentScalingStandard = 10
+
+	// skylinkAlignmentScaling is the alignment scaling used when packing
+	// skyfiles. The offsets of v1 skylinks use the alignments of Standard
+	// builds, independent of the SectorSize, so the alignments can't be
+	// scaled.
+	skylinkAlignmentScaling = uint64(1 << alignmentScalingStandard)
 )
 
 type (
@@ -95,6 +101,18 @@ type (
 //
 // 4. Return the array of file IDs in the order that they are packed.
 func PackFiles(files map[string]uint64) ([]FilePlacement, uint64, error) {
+	return packFiles(files, alignmentScaling)
+}
+
+// PackSkyfiles packs files the same way as PackFiles, but the files are aligned
+// in a way that allows for every placement to be addressed by a v1 skylink in
+// all builds.
+func PackSkyfiles(files map[string]uint64) ([]FilePlacement, uint64, error) {
+	return packFiles(files, skylinkAlignmentScaling)
+}
+
+// packFiles packs the files into sectors using the given alignment scaling.
+func packFiles(files map[string]uint64, scaling uint64) ([]FilePlacement, uint64, error) {
 	filesSorted := sortByFileSizeDescending(files)
 
 	// We can end up with a maximum of 2 buckets created for every file packed,
@@ -113,7 +131,7 @@ func PackFiles(files map[string]uint64) ([]FilePlacement, uint64, error) {
 			return nil, 0, ErrZeroSize
 		}
 
-		bucketIndex, err := findBucket(file.size, buckets)
+		bucketIndex, err := findBucket(file.size, buckets, scaling)
 		if errors.Contains(err, errBucketNotFound) {
 			// Create a new sector and bucket. We have already ensured above
 			// that the file will fit into this new sector-bucket.
@@ -124,7 +142,7 @@ func PackFiles(files map[string]uint64) ([]FilePlacement, uint64, error) {
 		}
 
 		var filePlacement FilePlacement
-		filePlacement, buckets, err = packBucket(file, bucketIndex, buckets)
+		filePlacement, buckets, err = packBucket(file, bucketIndex, buckets, scaling)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -138,7 +156,7 @@ func PackFiles(files map[string]uint64) ([]FilePlacement, uint64, error) {
 // index of the bucket.
 //
 // Return an error if no valid bucket was found.
-func findBucket(fileSize uint64, buckets bucketList) (int, error) {
+func findBucket(fileSize uint64, buckets bucketList, scaling uint64) (int, error) {
 	var currentBucket *bucket = nil
 	currentBucketIndex := -1
 
@@ -154,7 +172,7 @@ func findBucket(fileSize uint64, buckets bucketList) (int, error) {
 		}
 
 		// Try to find an alignment for the file in the bucket.
-		alignment, err := alignFileInBucket(fileSize, bucket.sectorOffset)
+		alignment, err := alignFileInBucket(fileSize, bucket.sectorOffset, scaling)
 		if err != nil {
 			return 0, err
 		}
@@ -185,14 +203,15 @@ func extendSectors(buckets bucketList, numSectors uint64) (bucketList, uint64) {
 }
 
 // requiredAlignment returns the byte alignment from the start of a sector that
-// the file must start at, based on the size of the file.
-func requiredAlignment(fileSize uint64) (uint64, error) {
-	// NOTE: We need to scale the required alignments the same way we scale the
+// the file must start at, based on the size of the file and the alignment
+// scaling.
+func requiredAlignment(fileSize, scaling uint64) (uint64, error) {
+	// NOTE: PackFiles scales the required alignments the same way we scale the
 	// SectorSize, so that the alignments actually fit inside sectors in Dev and
 	// Testing builds.
 	for n := 0; n < 8; n++ {
-		if fileSize <= 32*(1<<n)*alignmentScaling {
-			return 4 * (1 << n) * alignmentScaling, nil
+		if fileSize <= 32*(1<<n)*scaling {
+			return 4 * (1 << n) * scaling, nil
 		}
 	}
 
@@ -200,8 +219,8 @@ func requiredAlignment(fileSize uint64) (uint64, error) {
 }
 
 // alignFileInBucket returns the offset in the bucket that the file aligns to.
-func alignFileInBucket(fileSize uint64, sectorOffset uint64) (uint64, error) {
-	requiredAlignment, err := requiredAlignment(fileSize)
+func alignFileInBucket(fileSize, sectorOffset, scaling uint64) (uint64, error) {
+	requiredAlignment, err := requiredAlignment(fileSize, scaling)
 	if err != nil {
 		return 0, err
 	}
@@ -216,7 +235,7 @@ func alignFileInBucket(fileSize uint64, sectorOffset uint64) (uint64, error) {
 
 // packBucket packs the file into the bucket at the correct alignment, replacing
 // it with up to 2 new buckets.
-func packBucket(file packingFile, bucketIndex int, buckets bucketList) (FilePlacement, bucketList, error) {
+func packBucket(file packingFile, bucketIndex int, buckets bucketList, scaling uint64) (FilePlacement, bucketList, error) {
 	oldBucket := buckets[bucketIndex]
 	sectorIndex := oldBucket.sectorIndex
 	sectorOffset := oldBucket.sectorOffset
@@ -226,7 +245,7 @@ func packBucket(file packingFile, bucketIndex int, buckets bucketList) (FilePlac
 
 	// bucketAlignment is the alignment of the file from the start of the old
 	// bucket.
-	bucketAlignment, err := alignFileInBucket(file.size, sectorOffset)
+	bucketAlignment, err := alignFileInBucket(file.size, sectorOffset, scaling)
 	if err != nil {
 		return FilePlacement{}, buckets, err
 	}
@@ -234,13 +253,13 @@ func packBucket(file packingFile, bucketIndex int, buckets bucketList) (FilePlac
 	// bucketBeforeLength is the space from the start of the old bucket to the
 	// start of the file.
 	bucketBeforeLength := bucketAlignment
-	bucketIndex, buckets = createNewBucket(sectorIndex, sectorOffset, bucketBeforeLength, bucketIndex, buckets)
+	bucketIndex, buckets = createNewBucket(sectorIndex, sectorOffset, bucketBeforeLength, bucketIndex, buckets, scaling)
 
 	// bucketAfterLength is the space still available in the old bucket once the
 	// file and its alignment are subtracted away.
 	bucketAfterLength := oldBucket.length - file.size - bucketAlignment
 	bucketAfterSectorOffset := sectorOffset + bucketAlignment + file.size
-	_, buckets = createNewBucket(sectorIndex, bucketAfterSectorOffset, bucketAfterLength, bucketIndex, buckets)
+	_, buckets = createNewBucket(sectorIndex, bucketAfterSectorOffset, bucketAfterLength, bucketIndex, buckets, scaling)
 
 	filePlacement := FilePlacement{
 		FileID:       file.id,
@@ -253,7 +272,7 @@ func packBucket(file packingFile, bucketIndex int, buckets bucketList) (FilePlac
 
 // createNewBucket will actually create a new bucket and add it to the bucket
 // list.
-func createNewBucket(sectorIndex, sectorOffset, length uint64, bucketIndex int, buckets bucketList) (int, bucketList) {
+func createNewBucket(sectorIndex, sectorOffset, length uint64, bucketIndex int, buckets bucketList, scaling uint64) (int, bucketList) {
 	if length == 0 {
 		return bucketIndex, buckets
 	}
@@ -262,7 +281,7 @@ func createNewBucket(sectorIndex, sectorOffset, length uint64, bucketIndex int,
 	// minimum alignment from the start of the bucket landing outside the
 	// bucket, do not bother adding the bucket. This will result in less buckets
 	// to search through later.
-	minimumAlignment, _ := alignFileInBucket(1, sectorOffset)
+	minimumAlignment, _ := alignFileInBucket(1, sectorOffset, scaling)
 	if minimumAlignment >= length {
 		return bucketIndex, buckets
 	}
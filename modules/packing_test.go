@@ -8,6 +8,7 @@ import (
 	"text/tabwriter"
 
 	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/fastrand"
 )
 
@@ -157,7 +158,7 @@ func TestPackFilesRandom(t *testing.T) {
 	// Check that all alignments are correct.
 	for _, p := range placements {
 		size := p.Size
-		requiredAlignment, err := requiredAlignment(size)
+		requiredAlignment, err := requiredAlignment(size, alignmentScaling)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -188,6 +189,35 @@ func TestPackFilesRandom(t *testing.T) {
 	}
 }
 
+// TestPackSkyfiles tests that every placement returned by PackSkyfiles can be
+// addressed by a v1 skylink, even if the sectors are smaller than in Standard
+// builds.
+func TestPackSkyfiles(t *testing.T) {
+	// Test using the dev sector size.
+	defer func(sectorSize uint64) {
+		SectorSize = sectorSize
+	}(SectorSize)
+	SectorSize = SectorSizeDev
+
+	files := randomFileMap(1e3)
+	placements, _, err := PackSkyfiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placements) != len(files) {
+		t.Fatalf("expected %v placements, got %v", len(files), len(placements))
+	}
+	for _, p := range placements {
+		if p.SectorOffset+p.Size > SectorSize {
+			t.Fatalf("placement outside sector: (%v, %v)", p.SectorOffset, p.Size)
+		}
+		_, err := NewSkylinkV1(crypto.Hash{}, p.SectorOffset, p.Size)
+		if err != nil {
+			t.Fatalf("placement (%v, %v) can't be addressed by a skylink: %v", p.SectorOffset, p.Size, err)
+		}
+	}
+}
+
 func overlaps(i1, i2, j1, j2 uint64) bool {
 	return i1 <= j2 && j1 <= i2
 }
@@ -334,7 +364,7 @@ func TestFindBucket(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		res, err := findBucket(test.fileSize, test.buckets)
+		res, err := findBucket(test.fileSize, test.buckets, alignmentScaling)
 		if res != test.out || err != test.err {
 			t.Errorf("findBucket(%v, %v, %v): expected %v %v, got %v %v", test.fileSize, test.buckets, test.numSectors, test.out, test.err, res, err)
 		}
@@ -359,7 +389,7 @@ func TestRequiredAlignment(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		res, err := requiredAlignment(test.fileSize)
+		res, err := requiredAlignment(test.fileSize, alignmentScaling)
 		if res != test.out || err != test.err {
 			t.Errorf("requiredAlignment(%v): expected %v %v, got %v %v", test.fileSize, test.out, test.err, res, err)
 		}
@@ -385,7 +415,7 @@ func TestAlignFileInBucket(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		res, err := alignFileInBucket(test.fileSize, test.sectorOffset)
+		res, err := alignFileInBucket(test.fileSize, test.sectorOffset, alignmentScaling)
 		if res != test.out || err != test.err {
 			t.Errorf("AlignFileInBucket(%v, %v): expected %v %v, got %v %v", test.fileSize, test.sectorOffset, test.out, test.err, res, err)
 		}
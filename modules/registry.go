@@ -1,7 +1,14 @@
 package modules
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+
 	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
 )
 
 const (
@@ -35,6 +42,43 @@ func RoundRegistrySize(size uint64) uint64 {
 	return nUnits * smallestRegUnit
 }
 
+// DeriveRegistryEntryID derives the unique identifier of a registry entry from
+// its public key and tweak. Hosts use the same identifier to look up entries
+// which allows for looking up an entry without knowing its key and tweak.
+func DeriveRegistryEntryID(spk types.SiaPublicKey, tweak crypto.Hash) crypto.Hash {
+	return crypto.HashAll(spk, tweak)
+}
+
+// ParseReadRegistryEIDResponse parses the output of a ReadRegistryEID
+// instruction. The output consists of the signature, revision, tweak, public
+// key and data of the entry in that order. The returned value is not verified.
+func ParseReadRegistryEIDResponse(resp []byte) (types.SiaPublicKey, SignedRegistryValue, error) {
+	if len(resp) < crypto.SignatureSize+8+crypto.HashSize {
+		return types.SiaPublicKey{}, SignedRegistryValue{}, errors.New("failed to parse response due to invalid size")
+	}
+	var sig crypto.Signature
+	copy(sig[:], resp[:crypto.SignatureSize])
+	resp = resp[crypto.SignatureSize:]
+	rev := binary.LittleEndian.Uint64(resp)
+	resp = resp[8:]
+	var tweak crypto.Hash
+	copy(tweak[:], resp[:crypto.HashSize])
+	resp = resp[crypto.HashSize:]
+
+	// Decode the public key. Everything after the public key is data.
+	r := bytes.NewReader(resp)
+	var spk types.SiaPublicKey
+	err := encoding.NewDecoder(r, len(resp)).Decode(&spk)
+	if err != nil {
+		return types.SiaPublicKey{}, SignedRegistryValue{}, errors.AddContext(err, "failed to decode public key")
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return types.SiaPublicKey{}, SignedRegistryValue{}, errors.AddContext(err, "failed to read data")
+	}
+	return spk, NewSignedRegistryValue(tweak, data, rev, sig), nil
+}
+
 // RegistryValue is a value that can be registered on a host's registry.
 type RegistryValue struct {
 	Tweak    crypto.Hash
@@ -54,6 +54,10 @@ var (
 	// BackupKeySpecifier is a specifier that is hashed with the wallet seed to
 	// create a key for encrypting backups.
 	BackupKeySpecifier = types.NewSpecifier("backupkey")
+
+	// DefaultBackupNameTemplate is the name template of a backup schedule
+	// which doesn't specify one.
+	DefaultBackupNameTemplate = `scheduled-{{.Time.Format "2006-01-02-150405"}}`
 )
 
 // DataSourceID is an identifier to uniquely identify a data source, such as for
@@ -74,6 +78,10 @@ type FileListFunc func(FileInfo)
 // over the filesystem.
 type DirListFunc func(DirectoryInfo)
 
+// RegistryNotifyFunc is a type that's passed in to the renter when creating a
+// RegistrySubscriber. It is called whenever a subscribed to entry is updated.
+type RegistryNotifyFunc func(spk types.SiaPublicKey, srv SignedRegistryValue)
+
 // HostDBFilterError HostDBDisableFilter HostDBActivateBlacklist and
 // HostDBActiveWhitelist are the constants used to enable and disable the filter
 // mode of the renter's hostdb
@@ -323,6 +331,14 @@ type DirectoryInfo struct {
 	DirSize             uint64      `json:"size,siamismatch"` // Stays as 'size' in json for compatibility
 	StuckHealth         float64     `json:"stuckhealth"`
 	UID                 uint64      `json:"uid"`
+
+	// The following fields are the versioning settings of the siadir.
+	MaxVersions          uint64 `json:"maxversions"`
+	VersionRetentionDays uint64 `json:"versionretentiondays"`
+
+	// UploadPolicy is the upload policy which is set for the siadir itself.
+	// It doesn't include the settings inherited from parent directories.
+	UploadPolicy UploadPolicy `json:"uploadpolicy"`
 }
 
 // Name implements os.FileInfo.
@@ -346,21 +362,54 @@ func (d DirectoryInfo) Sys() interface{} { return nil }
 // DownloadInfo provides information about a file that has been requested for
 // download.
 type DownloadInfo struct {
-	Destination     string  `json:"destination"`     // The destination of the download.
-	DestinationType string  `json:"destinationtype"` // Can be "file", "memory buffer", or "http stream".
-	Length          uint64  `json:"length"`          // The length requested for the download.
-	Offset          uint64  `json:"offset"`          // The offset within the siafile requested for the download.
-	SiaPath         SiaPath `json:"siapath"`         // The siapath of the file used for the download.
+	Destination     string     `json:"destination"`     // The destination of the download.
+	DestinationType string     `json:"destinationtype"` // Can be "file", "memory buffer", or "http stream".
+	Length          uint64     `json:"length"`          // The length requested for the download.
+	Offset          uint64     `json:"offset"`          // The offset within the siafile requested for the download.
+	SiaPath         SiaPath    `json:"siapath"`         // The siapath of the file used for the download.
+	UID             DownloadID `json:"uid"`             // The unique identifier of the download.
 
 	Completed            bool      `json:"completed"`            // Whether or not the download has completed.
 	EndTime              time.Time `json:"endtime"`              // The time when the download fully completed.
 	Error                string    `json:"error"`                // Will be the empty string unless there was an error.
 	Received             uint64    `json:"received"`             // Amount of data confirmed and decoded.
+	Resumable            bool      `json:"resumable"`            // Whether or not the download can be resumed.
 	StartTime            time.Time `json:"starttime"`            // The time when the download was started.
 	StartTimeUnix        int64     `json:"starttimeunix"`        // The time when the download was started in unix format.
 	TotalDataTransferred uint64    `json:"totaldatatransferred"` // Total amount of data transferred, including negotiation, etc.
 }
 
+// FileVersion describes a previous version of a siafile which was kept when
+// the siafile was overwritten.
+type FileVersion struct {
+	ID         string    `json:"id"`         // The identifier of the version.
+	Redundancy float64   `json:"redundancy"` // The redundancy of the version.
+	SiaPath    SiaPath   `json:"siapath"`    // The siapath of the hidden siafile of the version.
+	Size       uint64    `json:"size"`       // The size of the version.
+	Time       time.Time `json:"time"`       // The time when the version was overwritten.
+}
+
+// HostDrainStatus contains the progress of moving the data of a host to other
+// hosts.
+type HostDrainStatus struct {
+	BytesMoved      uint64             `json:"bytesmoved"`      // The amount of data which was moved to other hosts.
+	ChunksRemaining uint64             `json:"chunksremaining"` // The number of chunks which still rely on the host.
+	Done            bool               `json:"done"`            // Whether the data was moved and the contract canceled.
+	Error           string             `json:"error"`           // The most recent error of the drain.
+	HostPublicKey   types.SiaPublicKey `json:"hostpublickey"`   // The public key of the host.
+	StartTime       time.Time          `json:"starttime"`       // The time when the drain was started.
+}
+
+// StreamSignature contains the parameters of a signed stream URL. They are
+// passed to /renter/stream in the URL's query string.
+type StreamSignature struct {
+	Expires      int64   `json:"expires"`      // The unix timestamp after which the URL is invalid.
+	ID           string  `json:"id"`           // The random id of the URL.
+	MaxDownloads uint64  `json:"maxdownloads"` // The number of downloads allowed, 0 means unlimited.
+	Scope        SiaPath `json:"scope"`        // The file or folder the URL grants access to.
+	Signature    string  `json:"signature"`    // The hex encoded HMAC of the other fields.
+}
+
 // FileUploadParams contains the information used by the Renter to upload a
 // file.
 type FileUploadParams struct {
@@ -379,6 +428,116 @@ type FileUploadParams struct {
 	// to create a CipherKey with the given CipherType. This value override
 	// CipherType if it is set.
 	CipherKey crypto.CipherKey
+
+	// Mode is the file mode of a streamed upload. If it is left blank, the
+	// renter will use the default file mode. Uploads from a source file always
+	// use the mode of the source file.
+	Mode os.FileMode
+}
+
+// RepairPriority determines the order of the chunks in the upload heap. Chunks
+// of siafiles with a higher priority are repaired before the other chunks in
+// the heap. The directories which are added to the heap are still selected by
+// their health alone.
+type RepairPriority uint8
+
+const (
+	// RepairPriorityInherit indicates that the repair priority is inherited
+	// from the parent directory.
+	RepairPriorityInherit RepairPriority = iota
+
+	// RepairPriorityLow is the priority of bulk data like archives.
+	RepairPriorityLow
+
+	// RepairPriorityNormal is the priority of siafiles which don't have a
+	// priority set in any of their directories.
+	RepairPriorityNormal
+
+	// RepairPriorityHigh is the priority of critical data.
+	RepairPriorityHigh
+)
+
+// ErrInvalidRepairPriority is returned when a repair priority is unknown.
+var ErrInvalidRepairPriority = errors.New("invalid repair priority")
+
+// String returns the string representation of a RepairPriority.
+func (rp RepairPriority) String() string {
+	switch rp {
+	case RepairPriorityInherit:
+		return ""
+	case RepairPriorityLow:
+		return "low"
+	case RepairPriorityNormal:
+		return "normal"
+	case RepairPriorityHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// FromString reads a RepairPriority from a string. An empty string is read as
+// RepairPriorityInherit.
+func (rp *RepairPriority) FromString(s string) error {
+	switch s {
+	case "":
+		*rp = RepairPriorityInherit
+	case "low":
+		*rp = RepairPriorityLow
+	case "normal":
+		*rp = RepairPriorityNormal
+	case "high":
+		*rp = RepairPriorityHigh
+	default:
+		return ErrInvalidRepairPriority
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (rp RepairPriority) MarshalText() ([]byte, error) {
+	if rp > RepairPriorityHigh {
+		return nil, ErrInvalidRepairPriority
+	}
+	return []byte(rp.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (rp *RepairPriority) UnmarshalText(b []byte) error {
+	return rp.FromString(string(b))
+}
+
+// UploadPolicy contains the upload settings of a directory. Uploads into the
+// directory or any of its subdirectories use the policy for any setting which
+// isn't specified by the upload itself. Fields which are left blank are
+// inherited from the parent directory.
+type UploadPolicy struct {
+	DataPieces     int            `json:"datapieces"`
+	ParityPieces   int            `json:"paritypieces"`
+	CipherType     string         `json:"ciphertype"`
+	RepairPriority RepairPriority `json:"repairpriority"`
+}
+
+// Validate checks that the upload policy is valid.
+func (up UploadPolicy) Validate() error {
+	if (up.DataPieces == 0) != (up.ParityPieces == 0) {
+		return errors.New("either both or none of the data and parity pieces need to be set")
+	}
+	if up.DataPieces != 0 {
+		if _, err := NewRSSubCode(up.DataPieces, up.ParityPieces, crypto.SegmentSize); err != nil {
+			return errors.AddContext(err, "invalid erasure code settings")
+		}
+	}
+	if up.CipherType != "" {
+		var ct crypto.CipherType
+		if err := ct.FromString(up.CipherType); err != nil {
+			return err
+		}
+	}
+	if up.RepairPriority > RepairPriorityHigh {
+		return ErrInvalidRepairPriority
+	}
+	return nil
 }
 
 // FileInfo provides information about a file.
@@ -387,6 +546,7 @@ type FileInfo struct {
 	Available        bool              `json:"available"`
 	ChangeTime       time.Time         `json:"changetime"`
 	CipherType       string            `json:"ciphertype"`
+	ContentHash      crypto.Hash       `json:"contenthash"`
 	CreateTime       time.Time         `json:"createtime"`
 	Expiration       types.BlockHeight `json:"expiration"`
 	Filesize         uint64            `json:"filesize"`
@@ -400,6 +560,9 @@ type FileInfo struct {
 	OnDisk           bool              `json:"ondisk"`
 	Recoverable      bool              `json:"recoverable"`
 	Redundancy       float64           `json:"redundancy"`
+	ReencodeError    string            `json:"reencodeerror"`
+	ReencodeProgress float64           `json:"reencodeprogress"`
+	Reencoding       bool              `json:"reencoding"`
 	Renewing         bool              `json:"renewing"`
 	Skylinks         []string          `json:"skylinks"`
 	SiaPath          SiaPath           `json:"siapath"`
@@ -537,7 +700,12 @@ type RenterSettings struct {
 	IPViolationCheck bool          `json:"ipviolationcheck"`
 	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
 	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
+	SkynetCacheSize  uint64        `json:"skynetcachesize"`
 	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
+
+	// RequireStreamSignature indicates whether /renter/stream requires either
+	// a signed URL or the API password.
+	RequireStreamSignature bool `json:"requirestreamsignature"`
 }
 
 // UploadsStatus contains information about the Renter's Uploads
@@ -722,6 +890,41 @@ type UploadedBackup struct {
 	UploadProgress float64
 }
 
+// BackupSchedule describes when the renter automatically creates backups and
+// which of the scheduled backups it keeps. A backup is kept if any of the
+// retention rules keeps it. If none of them is set, all backups are kept.
+type BackupSchedule struct {
+	// Schedule is a cron expression with the fields minute, hour, day of
+	// month, month and day of week or one of @hourly, @daily, @weekly,
+	// @monthly and @every <duration>. An empty schedule disables scheduled
+	// backups.
+	Schedule string `json:"schedule"`
+
+	// NameTemplate is a text/template which is executed with a BackupNameData
+	// to create the name of a backup.
+	NameTemplate string `json:"nametemplate"`
+
+	KeepDaily  uint64 `json:"keepdaily"`  // The number of days for which the most recent backup is kept.
+	KeepLast   uint64 `json:"keeplast"`   // The number of most recent backups which are kept.
+	KeepWeekly uint64 `json:"keepweekly"` // The number of weeks for which the most recent backup is kept.
+}
+
+// BackupNameData is the data a backup schedule's name template is executed
+// with.
+type BackupNameData struct {
+	Time time.Time // The time when the backup was started.
+}
+
+// BackupScheduleStatus contains a backup schedule and the outcome of its most
+// recent run.
+type BackupScheduleStatus struct {
+	BackupSchedule
+	LastBackup string    `json:"lastbackup"` // The name of the most recent successful scheduled backup.
+	LastError  string    `json:"lasterror"`  // The error of the most recent run if it failed.
+	LastRun    time.Time `json:"lastrun"`    // The time of the most recent run.
+	NextRun    time.Time `json:"nextrun"`    // The time of the next run if the schedule is enabled.
+}
+
 type (
 	// WorkerPoolStatus contains information about the status of the workerPool
 	// and the workers
@@ -929,6 +1132,15 @@ type Renter interface {
 	// BackupsOnHost returns the backups stored on the specified host.
 	BackupsOnHost(hostKey types.SiaPublicKey) ([]UploadedBackup, error)
 
+	// BackupSchedule returns the renter's backup schedule and the outcome of
+	// its most recent run.
+	BackupSchedule() (BackupScheduleStatus, error)
+
+	// SetBackupSchedule replaces the renter's backup schedule. Scheduled
+	// backups which aren't kept by the retention rules are deleted from the
+	// hosts after every scheduled backup.
+	SetBackupSchedule(schedule BackupSchedule) error
+
 	// DeleteFile deletes a file entry from the renter.
 	DeleteFile(siaPath SiaPath) error
 
@@ -954,6 +1166,12 @@ type Renter interface {
 	// DownloadHistory lists all the files that have been scheduled for download.
 	DownloadHistory() []DownloadInfo
 
+	// ResumeDownload resumes a checkpointed download which didn't complete
+	// successfully. The download needs to be started using the returned
+	// method. The optional onComplete function is called when the download is
+	// finished.
+	ResumeDownload(uid DownloadID, onComplete func(error) error) (start func() error, cancel func(), err error)
+
 	// File returns information on specific file queried by user
 	File(siaPath SiaPath) (FileInfo, error)
 
@@ -982,9 +1200,48 @@ type Renter interface {
 	// RenameFile changes the path of a file.
 	RenameFile(siaPath, newSiaPath SiaPath) error
 
+	// OverwriteFile moves the file at srcSiaPath to dstSiaPath. An existing
+	// file at dstSiaPath is replaced, or kept as a version if versioning is
+	// enabled for its directory.
+	OverwriteFile(srcSiaPath, dstSiaPath SiaPath) error
+
 	// RenameDir changes the path of a dir.
 	RenameDir(oldPath, newPath SiaPath) error
 
+	// FileVersions returns the hidden versions of the siafile at the given
+	// siapath which were kept when the siafile was overwritten.
+	FileVersions(siaPath SiaPath) ([]FileVersion, error)
+
+	// RestoreFileVersion replaces the siafile at the given siapath with one
+	// of its versions. The replaced siafile is kept as a version.
+	RestoreFileVersion(siaPath SiaPath, id string) error
+
+	// SetDirVersioning updates the versioning settings of a dir.
+	SetDirVersioning(siaPath SiaPath, maxVersions, retentionDays uint64) error
+
+	// SetDirUploadPolicy updates the upload policy of a dir.
+	SetDirUploadPolicy(siaPath SiaPath, policy UploadPolicy) error
+
+	// ChangeRedundancy re-encodes the siafile at the given siapath using the
+	// provided erasure coder. The re-encoding happens in the background.
+	ChangeRedundancy(siaPath SiaPath, ec ErasureCoder) error
+
+	// DrainHost moves the data stored on the host with the given public key
+	// to other hosts and cancels the contract with the host afterwards.
+	DrainHost(hostPubKey types.SiaPublicKey) error
+
+	// DrainStatus returns the progress of the hosts which are being drained.
+	DrainStatus() []HostDrainStatus
+
+	// ExportSiaFile creates a share of the siafiles at the given siapaths
+	// which can be imported by another renter. If ascii is set, the share is
+	// ASCII-armored.
+	ExportSiaFile(siaPaths []SiaPath, ascii bool) ([]byte, error)
+
+	// ImportSiaFile adds the siafiles of a share created by ExportSiaFile to
+	// the dir at the given siapath.
+	ImportSiaFile(data []byte, dirSiaPath SiaPath) error
+
 	// EstimateHostScore will return the score for a host with the provided
 	// settings, assuming perfect age and uptime adjustments
 	EstimateHostScore(entry HostDBEntry, allowance Allowance) (HostScoreBreakdown, error)
@@ -995,6 +1252,16 @@ type Renter interface {
 	// used.
 	ReadRegistry(spk types.SiaPublicKey, tweak crypto.Hash, timeout time.Duration) (SignedRegistryValue, error)
 
+	// NewRegistrySubscriber creates a new RegistrySubscriber which calls
+	// notify whenever one of the entries it is subscribed to is updated.
+	NewRegistrySubscriber(notify RegistryNotifyFunc) (RegistrySubscriber, error)
+
+	// ReadRegistryRID starts a registry lookup by entry ID on all available
+	// workers. The jobs have 'timeout' amount of time to finish their jobs and
+	// return a response. Otherwise the response with the highest revision
+	// number will be used.
+	ReadRegistryRID(rid crypto.Hash, timeout time.Duration) (SignedRegistryValue, error)
+
 	// ScoreBreakdown will return the score for a host db entry using the
 	// hostdb's weighting algorithm.
 	ScoreBreakdown(entry HostDBEntry) (HostScoreBreakdown, error)
@@ -1009,6 +1276,18 @@ type Renter interface {
 	// new value. Useful if files need to be moved on disk.
 	SetFileTrackingPath(siaPath SiaPath, newPath string) error
 
+	// SignStreamURL signs the parameters of a stream URL which grants access
+	// to the file or folder at the siapath until it expires.
+	SignStreamURL(scope SiaPath, expires time.Time, maxDownloads uint64) (StreamSignature, error)
+
+	// VerifyStreamURL checks that the signature grants access to the file at
+	// the siapath and that the URL's download limit wasn't reached.
+	VerifyStreamURL(siaPath SiaPath, sig StreamSignature) error
+
+	// ChargeStreamURL charges the bytes which were served for the file at the
+	// siapath to the download limit of the signed URL.
+	ChargeStreamURL(siaPath SiaPath, sig StreamSignature, served uint64) error
+
 	// UpdateRegistry updates the registries on all workers with the given
 	// registry value.
 	UpdateRegistry(spk types.SiaPublicKey, srv SignedRegistryValue, timeout time.Duration) error
@@ -1081,12 +1360,25 @@ type Renter interface {
 	DownloadByRoot(root crypto.Hash, offset, length uint64, timeout time.Duration) ([]byte, error)
 
 	// DownloadSkylink will fetch a file from the Sia network using the skylink.
+	// v2 skylinks are resolved to the v1 skylink they point to first.
 	DownloadSkylink(Skylink, time.Duration) (SkyfileMetadata, Streamer, error)
 
 	// DownloadSkylinkBaseSector will take a link and turn it into the data of a download
 	// without any decoding of the metadata, fanout, or decryption.
 	DownloadSkylinkBaseSector(Skylink, time.Duration) (Streamer, error)
 
+	// SkylinkHealth queries the hosts for the sectors of the skylink and
+	// reports how well the skyfile is available on the network.
+	SkylinkHealth(Skylink, time.Duration) (SkylinkHealth, error)
+
+	// SkynetCacheStats returns the hit and miss statistics of the on-disk
+	// skynet cache.
+	SkynetCacheStats() (SkynetCacheStats, error)
+
+	// ResolveSkylinkV2 resolves a v2 skylink to the v1 skylink it points to
+	// by looking up the registry. v1 skylinks are returned unchanged.
+	ResolveSkylinkV2(Skylink, time.Duration) (Skylink, error)
+
 	// UploadSkyfile will upload data to the Sia network from a reader and
 	// create a skyfile, returning the skylink that can be used to access the
 	// file.
@@ -1097,6 +1389,16 @@ type Renter interface {
 	// file.
 	UploadSkyfile(SkyfileUploadParameters, SkyfileUploadReader) (Skylink, error)
 
+	// TemporaryUpload uploads a small skyfile which is stored by the hosts
+	// for the given number of blocks without being tracked by a siafile or
+	// covered by file contracts.
+	TemporaryUpload(SkyfileUploadParameters, SkyfileUploadReader, types.BlockHeight) (Skylink, error)
+
+	// UploadPackedSkyfiles uploads a batch of small files as individual
+	// skyfiles that are packed into as few sectors as possible. The returned
+	// skylinks are in the same order as the files.
+	UploadPackedSkyfiles(SkyfileUploadParameters, []SkyfilePackedFile) ([]Skylink, error)
+
 	// Blocklist returns the merkleroots that are blocked
 	Blocklist() ([]crypto.Hash, error)
 
@@ -1107,6 +1409,11 @@ type Renter interface {
 	// the given parameters.
 	PinSkylink(Skylink, SkyfileUploadParameters, time.Duration) error
 
+	// UnpinSkylink deletes all siafiles that only store the skylink and
+	// removes the skylink from siafiles that are shared with other skylinks.
+	// The siapaths of the deleted and the updated siafiles are returned.
+	UnpinSkylink(Skylink) (deleted, updated []SiaPath, err error)
+
 	// Portals returns the list of known skynet portals.
 	Portals() ([]SkynetPortal, error)
 
@@ -1117,6 +1424,24 @@ type Renter interface {
 	WorkerPoolStatus() (WorkerPoolStatus, error)
 }
 
+// RegistrySubscriber is the interface implemented by the Renter's registry
+// subscriber type which allows for being notified about updates to registry
+// entries without polling the registry.
+type RegistrySubscriber interface {
+	// Subscribe subscribes to the entry with the given public key and tweak.
+	// If the renter already knows about a value for the entry, the notify
+	// function is called with it right away.
+	Subscribe(spk types.SiaPublicKey, tweak crypto.Hash) error
+
+	// Unsubscribe unsubscribes from the entry with the given public key and
+	// tweak.
+	Unsubscribe(spk types.SiaPublicKey, tweak crypto.Hash)
+
+	// Close unsubscribes from all entries. No more notifications will be
+	// delivered after Close returns.
+	Close()
+}
+
 // Streamer is the interface implemented by the Renter's streamer type which
 // allows for streaming files uploaded to the Sia network.
 type Streamer interface {
@@ -1134,6 +1459,11 @@ type RenterDownloadParameters struct {
 	SiaPath          SiaPath
 	Destination      string
 	DisableDiskFetch bool
+
+	// VerifyContentHash indicates whether the downloaded data should be
+	// verified against the content hash of the file. Only full downloads of
+	// files with a content hash can be verified.
+	VerifyContentHash bool
 }
 
 // HealthPercentage returns the health in a more human understandable format out
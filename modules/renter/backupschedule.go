@@ -0,0 +1,515 @@
+package renter
+
+// The backup scheduler creates backups of the renter according to a cron-like
+// schedule and uploads them to the hosts like backups created through the API.
+// After every scheduled backup, the scheduled backups which aren't kept by the
+// retention rules of the schedule are deleted. Backups which weren't created by
+// the scheduler are never deleted. If a scheduled backup fails, an alert is
+// registered until the next scheduled backup succeeds.
+//
+// NOTE: Deleting a backup only removes it from the snapshot tables of the
+// hosts. The sectors of the backup stay in the contracts, and are paid for,
+// until the contracts expire, because the renter doesn't track the contract's
+// sector roots when swapping and trimming sectors. Retention rules therefore
+// limit the backups that can be restored, not the storage used by them.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/proto"
+)
+
+var (
+	// errBackupScheduleNeverRuns is returned when a backup schedule doesn't
+	// match any time in the foreseeable future, e.g. the 30th of February.
+	errBackupScheduleNeverRuns = errors.New("schedule never runs")
+
+	// backupScheduleShorthands maps the supported shorthands of backup
+	// schedules to the equivalent cron expressions.
+	backupScheduleShorthands = map[string]string{
+		"@hourly":  "0 * * * *",
+		"@daily":   "0 0 * * *",
+		"@weekly":  "0 0 * * 0",
+		"@monthly": "0 0 1 * *",
+	}
+
+	// minBackupScheduleInterval is the minimum interval of @every schedules.
+	minBackupScheduleInterval = time.Minute
+)
+
+type (
+	// backupScheduleSpec is a parsed backup schedule.
+	backupScheduleSpec struct {
+		// every is the interval of @every schedules.
+		every time.Duration
+
+		// The fields of a cron expression as bitsets of the values they
+		// match. domAny and dowAny are set if the day of month or day of week
+		// field is a wildcard.
+		minute, hour, dom, month, dow uint64
+		domAny, dowAny                bool
+	}
+
+	// backupScheduleState is the persisted state of the backup scheduler.
+	backupScheduleState struct {
+		// Backups contains the names of the existing backups which were
+		// created by the scheduler.
+		Backups []string
+
+		LastBackup string
+		LastError  string
+		LastRun    time.Time
+		NextRun    time.Time
+	}
+
+	// deletedBackup is a backup which was deleted. Deleted backups are
+	// remembered to remove them from the snapshot tables of hosts which were
+	// unavailable at the time of the deletion.
+	deletedBackup struct {
+		UID  [16]byte
+		Time time.Time
+	}
+)
+
+// parseBackupSchedule parses a cron expression with the fields minute, hour,
+// day of month, month and day of week or one of the supported shorthands.
+func parseBackupSchedule(schedule string) (spec backupScheduleSpec, err error) {
+	schedule = strings.TrimSpace(schedule)
+	if strings.HasPrefix(schedule, "@every ") {
+		spec.every, err = time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(schedule, "@every ")))
+		if err != nil {
+			return backupScheduleSpec{}, errors.AddContext(err, "invalid interval")
+		}
+		if spec.every < minBackupScheduleInterval {
+			return backupScheduleSpec{}, fmt.Errorf("interval needs to be at least %v", minBackupScheduleInterval)
+		}
+		return spec, nil
+	}
+	if expr, ok := backupScheduleShorthands[schedule]; ok {
+		schedule = expr
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return backupScheduleSpec{}, errors.New("a cron expression needs the 5 fields minute, hour, day of month, month and day of week")
+	}
+	cronFields := []struct {
+		name     string
+		min, max uint64
+		bits     *uint64
+	}{
+		{"minute", 0, 59, &spec.minute},
+		{"hour", 0, 23, &spec.hour},
+		{"day of month", 1, 31, &spec.dom},
+		{"month", 1, 12, &spec.month},
+		{"day of week", 0, 7, &spec.dow},
+	}
+	for i, f := range cronFields {
+		*f.bits, err = parseCronField(fields[i], f.min, f.max)
+		if err != nil {
+			return backupScheduleSpec{}, errors.AddContext(err, "invalid "+f.name)
+		}
+	}
+	// Sunday can be specified as 0 or 7.
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1
+	}
+	spec.domAny = strings.HasPrefix(fields[2], "*")
+	spec.dowAny = strings.HasPrefix(fields[4], "*")
+	if spec.next(time.Now()).IsZero() {
+		return backupScheduleSpec{}, errBackupScheduleNeverRuns
+	}
+	return spec, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and
+// wildcards, each with an optional step, into a bitset of the values it
+// matches.
+func parseCronField(field string, min, max uint64) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		valueRange, step := part, uint64(1)
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.ParseUint(part[i+1:], 10, 64)
+			if err != nil || s == 0 {
+				return 0, fmt.Errorf("invalid step in '%v'", part)
+			}
+			valueRange, step = part[:i], s
+		}
+		lo, hi := min, max
+		if valueRange != "*" {
+			bounds := strings.SplitN(valueRange, "-", 2)
+			var err error
+			lo, err = strconv.ParseUint(bounds[0], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in '%v'", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				hi, err = strconv.ParseUint(bounds[1], 10, 64)
+				if err != nil {
+					return 0, fmt.Errorf("invalid value in '%v'", part)
+				}
+			} else if step > 1 {
+				// A single value with a step starts at that value.
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("'%v' is out of the range %v-%v", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// matchesDay returns whether the schedule runs on the day of the provided
+// time. Like cron, a day matches if it matches either the day of month or the
+// day of week if both of them are restricted.
+func (spec backupScheduleSpec) matchesDay(t time.Time) bool {
+	dom := spec.dom&(1<<uint(t.Day())) != 0
+	dow := spec.dow&(1<<uint(t.Weekday())) != 0
+	if !spec.domAny && !spec.dowAny {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// next returns the first time after t at which the schedule runs. The zero
+// time is returned if the schedule doesn't run within the next 5 years.
+func (spec backupScheduleSpec) next(t time.Time) time.Time {
+	if spec.every > 0 {
+		return t.Add(spec.every)
+	}
+	// Start at the next full minute.
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if spec.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !spec.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if spec.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if spec.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// backupName creates the name of a scheduled backup from a name template.
+func backupName(nameTemplate string, t time.Time) (string, error) {
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, modules.BackupNameData{Time: t}); err != nil {
+		return "", err
+	}
+	name := sb.String()
+	if name == "" {
+		return "", errors.New("name is empty")
+	}
+	if len(name) > 96 {
+		return "", errors.New("name is too long")
+	}
+	if _, err := modules.BackupFolder.Join(name); err != nil {
+		return "", errors.AddContext(err, "name is not a valid siapath")
+	}
+	return name, nil
+}
+
+// backupsToDelete returns the backups which aren't kept by the retention rules
+// of the schedule. The days and weeks of the backups are determined in the
+// provided location.
+func backupsToDelete(backups []modules.UploadedBackup, bs modules.BackupSchedule, loc *time.Location) []modules.UploadedBackup {
+	if bs.KeepLast == 0 && bs.KeepDaily == 0 && bs.KeepWeekly == 0 {
+		return nil
+	}
+	// Sort the backups from newest to oldest. That way the first backup of a
+	// day or week is the most recent one.
+	backups = append([]modules.UploadedBackup(nil), backups...)
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].CreationDate > backups[j].CreationDate
+	})
+	var toDelete []modules.UploadedBackup
+	var lastDay, lastWeek string
+	var days, weeks uint64
+	for i, ub := range backups {
+		t := time.Unix(int64(ub.CreationDate), 0).In(loc)
+		year, week := t.ISOWeek()
+		day, weekOfYear := t.Format("2006-01-02"), fmt.Sprintf("%v-%v", year, week)
+
+		keep := uint64(i) < bs.KeepLast
+		if day != lastDay {
+			lastDay = day
+			if days < bs.KeepDaily {
+				days++
+				keep = true
+			}
+		}
+		if weekOfYear != lastWeek {
+			lastWeek = weekOfYear
+			if weeks < bs.KeepWeekly {
+				weeks++
+				keep = true
+			}
+		}
+		if !keep {
+			toDelete = append(toDelete, ub)
+		}
+	}
+	return toDelete
+}
+
+// BackupSchedule returns the renter's backup schedule and the outcome of its
+// most recent run.
+func (r *Renter) BackupSchedule() (modules.BackupScheduleStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.BackupScheduleStatus{}, err
+	}
+	defer r.tg.Done()
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	state := r.persist.BackupScheduleState
+	status := modules.BackupScheduleStatus{
+		BackupSchedule: r.persist.BackupSchedule,
+		LastBackup:     state.LastBackup,
+		LastError:      state.LastError,
+		LastRun:        state.LastRun,
+	}
+	if status.Schedule != "" {
+		status.NextRun = state.NextRun
+	}
+	return status, nil
+}
+
+// SetBackupSchedule replaces the renter's backup schedule. Scheduled backups
+// which aren't kept by the retention rules are deleted from the hosts after
+// every scheduled backup.
+func (r *Renter) SetBackupSchedule(bs modules.BackupSchedule) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Validate the schedule.
+	if bs.NameTemplate == "" {
+		bs.NameTemplate = modules.DefaultBackupNameTemplate
+	}
+	if _, err := backupName(bs.NameTemplate, time.Now()); err != nil {
+		return errors.AddContext(err, "invalid name template")
+	}
+	var nextRun time.Time
+	if bs.Schedule != "" {
+		spec, err := parseBackupSchedule(bs.Schedule)
+		if err != nil {
+			return errors.AddContext(err, "invalid schedule")
+		}
+		nextRun = spec.next(time.Now())
+	}
+
+	id := r.mu.Lock()
+	r.persist.BackupSchedule = bs
+	r.persist.BackupScheduleState.NextRun = nextRun
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "unable to persist backup schedule")
+	}
+
+	// Notify the scheduler.
+	select {
+	case r.backupScheduleUpdate <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// threadedScheduleBackups creates backups according to the renter's backup
+// schedule. Backups which were missed while the renter was offline are
+// created right away.
+func (r *Renter) threadedScheduleBackups() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		id := r.mu.RLock()
+		enabled := r.persist.BackupSchedule.Schedule != ""
+		nextRun := r.persist.BackupScheduleState.NextRun
+		r.mu.RUnlock(id)
+
+		// Wait for the next run or a change of the schedule.
+		var timer *time.Timer
+		var due <-chan time.Time
+		if enabled {
+			timer = time.NewTimer(time.Until(nextRun))
+			due = timer.C
+		}
+		select {
+		case <-r.tg.StopChan():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-r.backupScheduleUpdate:
+			if timer != nil {
+				timer.Stop()
+			}
+			continue
+		case <-due:
+		}
+		r.managedScheduledBackup()
+	}
+}
+
+// managedScheduledBackup creates a scheduled backup, deletes the scheduled
+// backups which aren't kept anymore and updates the state of the schedule.
+func (r *Renter) managedScheduledBackup() {
+	id := r.mu.RLock()
+	bs := r.persist.BackupSchedule
+	r.mu.RUnlock(id)
+
+	start := time.Now()
+	name, err := r.managedCreateScheduledBackup(bs, start)
+	if err == nil {
+		err = errors.AddContext(r.managedPruneScheduledBackups(bs), "unable to delete old backups")
+	}
+
+	id = r.mu.Lock()
+	state := &r.persist.BackupScheduleState
+	state.LastRun = start
+	state.LastError = ""
+	if err != nil {
+		state.LastError = err.Error()
+	}
+	if name != "" {
+		state.LastBackup = name
+	}
+	// Unless the schedule was changed in the meantime, the next run is
+	// computed from the current time to skip the runs which were missed while
+	// creating the backup.
+	if r.persist.BackupSchedule == bs {
+		spec, parseErr := parseBackupSchedule(bs.Schedule)
+		if parseErr != nil {
+			r.log.Println("Disabling invalid backup schedule:", parseErr)
+			r.persist.BackupSchedule.Schedule = ""
+		}
+		state.NextRun = spec.next(time.Now())
+	}
+	saveErr := r.saveSync()
+	r.mu.Unlock(id)
+	if saveErr != nil {
+		r.log.Println("Failed to persist the backup schedule:", saveErr)
+	}
+
+	if err != nil {
+		r.log.Println("Scheduled backup failed:", err)
+		r.staticAlerter.RegisterAlert(modules.AlertIDRenterScheduledBackupFailed, AlertMSGScheduledBackupFailed, err.Error(), modules.SeverityError)
+		return
+	}
+	r.log.Printf("Created scheduled backup %q", name)
+	r.staticAlerter.UnregisterAlert(modules.AlertIDRenterScheduledBackupFailed)
+}
+
+// managedCreateScheduledBackup creates a backup of the renter and uploads it to
+// the hosts using a name created from the schedule's name template.
+func (r *Renter) managedCreateScheduledBackup(bs modules.BackupSchedule, start time.Time) (_ string, err error) {
+	name, err := backupName(bs.NameTemplate, start)
+	if err != nil {
+		return "", errors.AddContext(err, "unable to create backup name")
+	}
+	if unlocked, _ := r.w.Unlocked(); !unlocked {
+		return "", errors.New("wallet is locked")
+	}
+
+	// Get the wallet seed.
+	ws, _, err := r.w.PrimarySeed()
+	if err != nil {
+		return "", errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := proto.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	// Derive the secret and wipe it afterwards.
+	secret := crypto.HashAll(rs, modules.BackupKeySpecifier)
+	defer fastrand.Read(secret[:])
+
+	// Write the backup to a temporary file and delete it after uploading.
+	tmpDir, err := ioutil.TempDir("", "sia-backup")
+	if err != nil {
+		return "", errors.AddContext(err, "unable to create temporary directory")
+	}
+	defer func() {
+		err = errors.Compose(err, os.RemoveAll(tmpDir))
+	}()
+	backupPath := filepath.Join(tmpDir, "backup.bak")
+	if err := r.managedCreateBackup(backupPath, secret[:32]); err != nil {
+		return "", errors.AddContext(err, "failed to create backup")
+	}
+	if err := r.managedUploadBackup(backupPath, name); err != nil {
+		return "", errors.AddContext(err, "failed to upload backup")
+	}
+
+	// Remember that the backup was created by the scheduler.
+	id := r.mu.Lock()
+	r.persist.BackupScheduleState.Backups = append(r.persist.BackupScheduleState.Backups, name)
+	err = r.saveSync()
+	r.mu.Unlock(id)
+	return name, errors.AddContext(err, "unable to persist scheduled backup")
+}
+
+// managedPruneScheduledBackups deletes the scheduled backups which aren't kept
+// by the retention rules of the schedule.
+func (r *Renter) managedPruneScheduledBackups(bs modules.BackupSchedule) error {
+	// Collect the scheduled backups and forget about the ones which no longer
+	// exist, e.g. because they were evicted from the snapshot table.
+	id := r.mu.Lock()
+	scheduled := make(map[string]struct{})
+	for _, name := range r.persist.BackupScheduleState.Backups {
+		scheduled[name] = struct{}{}
+	}
+	var backups []modules.UploadedBackup
+	var names []string
+	for _, ub := range r.persist.UploadedBackups {
+		if _, ok := scheduled[ub.Name]; ok {
+			backups = append(backups, ub)
+			names = append(names, ub.Name)
+		}
+	}
+	r.persist.BackupScheduleState.Backups = names
+	r.mu.Unlock(id)
+
+	var err error
+	for _, ub := range backupsToDelete(backups, bs, time.Local) {
+		deleteErr := r.managedDeleteBackup(ub)
+		err = errors.Compose(err, errors.AddContext(deleteErr, fmt.Sprintf("unable to delete backup '%v'", ub.Name)))
+	}
+	return err
+}
@@ -0,0 +1,270 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestParseBackupSchedule tests parsing valid and invalid backup schedules.
+func TestParseBackupSchedule(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{
+		"* * * * *",
+		"0 3 * * *",
+		"*/15 1-5,22 1,15 */2 1-5",
+		"5/10 * * * 7",
+		"0 0 29 2 *",
+		"@hourly",
+		"@daily",
+		"@weekly",
+		"@monthly",
+		"@every 6h",
+	}
+	for _, schedule := range valid {
+		if _, err := parseBackupSchedule(schedule); err != nil {
+			t.Errorf("schedule '%v' should be valid: %v", schedule, err)
+		}
+	}
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@yearly",
+		"@every 1s",
+		"@every forever",
+	}
+	for _, schedule := range invalid {
+		if _, err := parseBackupSchedule(schedule); err == nil {
+			t.Errorf("schedule '%v' should be invalid", schedule)
+		}
+	}
+	if _, err := parseBackupSchedule("0 0 30 2 *"); !errors.Contains(err, errBackupScheduleNeverRuns) {
+		t.Fatal("expected errBackupScheduleNeverRuns", err)
+	}
+}
+
+// TestBackupScheduleNext tests computing the next run of a backup schedule.
+func TestBackupScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	// Friday, March 12th 2021.
+	now := time.Date(2021, 3, 12, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		next     time.Time
+	}{
+		{"* * * * *", time.Date(2021, 3, 12, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 3, 12, 10, 15, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2021, 3, 13, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2021, 3, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both the day of month and day of week are restricted, so either
+		// of them needs to match.
+		{"0 0 20 * 1", time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", now.Add(90 * time.Minute)},
+	}
+	for _, test := range tests {
+		spec, err := parseBackupSchedule(test.schedule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next := spec.next(now); !next.Equal(test.next) {
+			t.Errorf("next run of '%v' should be %v but was %v", test.schedule, test.next, next)
+		}
+	}
+}
+
+// TestBackupName tests creating backup names from name templates.
+func TestBackupName(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 3, 12, 10, 7, 30, 0, time.UTC)
+	name, err := backupName(modules.DefaultBackupNameTemplate, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "scheduled-2021-03-12-100730" {
+		t.Fatal("unexpected name", name)
+	}
+	name, err = backupName("nightly/{{.Time.Unix}}", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "nightly/1615543650" {
+		t.Fatal("unexpected name", name)
+	}
+	invalid := []string{
+		"",
+		"{{.Time",
+		"{{.Missing}}",
+		"{{.Time.Format \"\"}}",
+		"../{{.Time.Unix}}",
+		string(fastrand.Bytes(97)),
+	}
+	for _, nameTemplate := range invalid {
+		if _, err := backupName(nameTemplate, now); err == nil {
+			t.Errorf("name template '%v' should be invalid", nameTemplate)
+		}
+	}
+}
+
+// TestBackupsToDelete tests the retention rules of backup schedules.
+func TestBackupsToDelete(t *testing.T) {
+	t.Parallel()
+
+	// Create two backups a day for 3 weeks starting on Monday, March 1st
+	// 2021.
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	var backups []modules.UploadedBackup
+	for day := 0; day < 21; day++ {
+		for _, hour := range []int{6, 18} {
+			creation := start.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+			backups = append(backups, modules.UploadedBackup{
+				Name:         creation.Format(time.RFC3339),
+				CreationDate: types.Timestamp(creation.Unix()),
+			})
+		}
+	}
+	// kept returns the names of the backups which aren't deleted.
+	kept := func(bs modules.BackupSchedule) map[string]bool {
+		keep := make(map[string]bool)
+		for _, ub := range backups {
+			keep[ub.Name] = true
+		}
+		for _, ub := range backupsToDelete(backups, bs, time.UTC) {
+			delete(keep, ub.Name)
+		}
+		return keep
+	}
+
+	// Without retention rules, all backups are kept.
+	if k := kept(modules.BackupSchedule{}); len(k) != len(backups) {
+		t.Fatal("all backups should be kept", len(k))
+	}
+	// Keep the last 3 backups.
+	k := kept(modules.BackupSchedule{KeepLast: 3})
+	if len(k) != 3 || !k["2021-03-21T18:00:00Z"] || !k["2021-03-21T06:00:00Z"] || !k["2021-03-20T18:00:00Z"] {
+		t.Fatal("wrong backups kept", k)
+	}
+	// Keep the most recent backup of the last 2 days.
+	k = kept(modules.BackupSchedule{KeepDaily: 2})
+	if len(k) != 2 || !k["2021-03-21T18:00:00Z"] || !k["2021-03-20T18:00:00Z"] {
+		t.Fatal("wrong backups kept", k)
+	}
+	// Keep the most recent backup of the last 3 weeks.
+	k = kept(modules.BackupSchedule{KeepWeekly: 3})
+	if len(k) != 3 || !k["2021-03-21T18:00:00Z"] || !k["2021-03-14T18:00:00Z"] || !k["2021-03-07T18:00:00Z"] {
+		t.Fatal("wrong backups kept", k)
+	}
+	// The rules are combined.
+	k = kept(modules.BackupSchedule{KeepLast: 2, KeepDaily: 3, KeepWeekly: 2})
+	if len(k) != 5 || !k["2021-03-21T18:00:00Z"] || !k["2021-03-21T06:00:00Z"] || !k["2021-03-20T18:00:00Z"] || !k["2021-03-19T18:00:00Z"] || !k["2021-03-14T18:00:00Z"] {
+		t.Fatal("wrong backups kept", k)
+	}
+	k = kept(modules.BackupSchedule{KeepWeekly: 5})
+	if len(k) != 3 {
+		t.Fatal("wrong backups kept", k)
+	}
+}
+
+// TestBackupSchedule tests setting the backup schedule of a renter and
+// deleting backups.
+func TestBackupSchedule(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Invalid schedules are rejected.
+	if err := r.SetBackupSchedule(modules.BackupSchedule{Schedule: "0 0 30 2 *"}); err == nil {
+		t.Fatal("expected invalid schedule to be rejected")
+	}
+	if err := r.SetBackupSchedule(modules.BackupSchedule{Schedule: "@daily", NameTemplate: "{{.Time"}); err == nil {
+		t.Fatal("expected invalid name template to be rejected")
+	}
+
+	// Set a schedule without a name template.
+	bs := modules.BackupSchedule{
+		Schedule: "@daily",
+		KeepLast: 7,
+	}
+	if err := r.SetBackupSchedule(bs); err != nil {
+		t.Fatal(err)
+	}
+	status, err := r.BackupSchedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs.NameTemplate = modules.DefaultBackupNameTemplate
+	if status.BackupSchedule != bs {
+		t.Fatal("wrong schedule", status.BackupSchedule)
+	}
+	if !status.NextRun.After(time.Now()) || status.NextRun.After(time.Now().Add(24*time.Hour)) {
+		t.Fatal("wrong next run", status.NextRun)
+	}
+
+	// Disable the schedule.
+	bs.Schedule = ""
+	if err := r.SetBackupSchedule(bs); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = r.BackupSchedule(); err != nil {
+		t.Fatal(err)
+	}
+	if status.Schedule != "" || !status.NextRun.IsZero() {
+		t.Fatal("schedule should be disabled", status)
+	}
+
+	// Deleted backups can't be added back.
+	ub := modules.UploadedBackup{
+		Name:           "backup",
+		CreationDate:   types.CurrentTimestamp(),
+		UploadProgress: 100,
+	}
+	fastrand.Read(ub.UID[:])
+	if err := r.managedSaveSnapshot(ub); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedDeleteBackup(ub); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedSaveSnapshot(ub); err != nil {
+		t.Fatal(err)
+	}
+	backups, _, err := r.UploadedBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 0 {
+		t.Fatal("deleted backup shouldn't exist", backups)
+	}
+}
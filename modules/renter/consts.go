@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
 )
 
@@ -21,8 +22,33 @@ const (
 	// AlertSiafileLowRedundancyThreshold is the health threshold at which we start
 	// registering the LowRedundancy alert for a Siafile.
 	AlertSiafileLowRedundancyThreshold = 0.75
+
+	// AlertMSGSiafileContentHashMismatch indicates that the data of a full
+	// download of a SiaFile didn't match the SiaFile's content hash.
+	AlertMSGSiafileContentHashMismatch = "The data downloaded for the SiaFile mentioned in the 'Cause' doesn't match its content hash"
+
+	// AlertMSGScheduledBackupFailed indicates that the most recent scheduled
+	// backup failed.
+	AlertMSGScheduledBackupFailed = "The most recent scheduled backup failed"
+
+	// AlertMSGFuseUploadFailed indicates that the writes to a file of a fuse
+	// filesystem couldn't be uploaded.
+	AlertMSGFuseUploadFailed = "The writes to the fuse file mentioned in the 'Cause' couldn't be uploaded"
 )
 
+// AlertCauseFuseUploadFailed creates a customized "cause" for a fuse file with
+// a certain path whose writes couldn't be uploaded.
+func AlertCauseFuseUploadFailed(siaPath modules.SiaPath, err error) string {
+	return fmt.Sprintf("Uploading the writes to '%v' failed and will be retried: %v", siaPath.String(), err)
+}
+
+// AlertCauseSiafileContentHashMismatch creates a customized "cause" for a
+// siafile with a certain path whose downloaded data didn't match its content
+// hash.
+func AlertCauseSiafileContentHashMismatch(siaPath modules.SiaPath, expected, actual crypto.Hash) string {
+	return fmt.Sprintf("Siafile '%v' has a content hash of %v but the downloaded data has a hash of %v", siaPath.String(), expected, actual)
+}
+
 // AlertCauseSiafileLowRedundancy creates a customized "cause" for a siafile
 // with a certain path and health.
 func AlertCauseSiafileLowRedundancy(siaPath modules.SiaPath, health, redundancy float64) string {
@@ -58,6 +84,22 @@ var (
 		Testing:  time.Second * 3,
 	}).(time.Duration)
 
+	// fuseSpoolRetryInterval is how long the renter waits between attempts to
+	// upload the fuse spools which were left behind by failed uploads.
+	fuseSpoolRetryInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// streamURLDownloadsPersistInterval is how often the renter persists the
+	// bytes which were served by signed stream URLs.
+	streamURLDownloadsPersistInterval = build.Select(build.Var{
+		Dev:      time.Second * 10,
+		Standard: time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
 	// maxWaitForCompleteUpload is the maximum amount of time we wait for an
 	// upload chunk to be completely uploaded after it has become available in
 	// the upload process.
@@ -133,6 +175,10 @@ const (
 	// from the /renter/stream endpoint.
 	destinationTypeSeekStream = "httpseekstream"
 
+	// destinationTypeFile is the destination type used for downloads to a
+	// file on disk.
+	destinationTypeFile = "file"
+
 	// memoryPriorityLow is used to request low priority memory
 	memoryPriorityLow = false
 
@@ -290,6 +336,41 @@ var (
 		Standard: 5 * time.Minute,
 		Testing:  5 * time.Second,
 	}).(time.Duration)
+
+	// downloadCheckpointInterval defines how often the checkpoint of a
+	// download to a file on disk is persisted while the download is running.
+	// Chunks completed since the last checkpoint are downloaded again when the
+	// download is resumed.
+	downloadCheckpointInterval = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// deletedBackupExpiry defines how long the renter remembers that a backup
+	// was deleted. Until then, hosts which still store the backup have it
+	// removed from their snapshot table instead of it being restored.
+	deletedBackupExpiry = build.Select(build.Var{
+		Dev:      24 * time.Hour,
+		Standard: 180 * 24 * time.Hour,
+		Testing:  time.Hour,
+	}).(time.Duration)
+
+	// reencodeCheckInterval defines how often the renter checks whether the
+	// upload of a re-encoded siafile is done.
+	reencodeCheckInterval = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// drainCheckInterval defines how often the renter checks which chunks
+	// still rely on a host that is being drained.
+	drainCheckInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
 )
 
 // Constants that tune the worker swarm.
@@ -0,0 +1,153 @@
+package renter
+
+// The renter computes a hash of the plaintext content of every uploaded file
+// and stores it in the file's metadata. Uploads from a reader hash the data
+// while it is read from the reader. Uploads from disk hash the local file in
+// the background since the upload code reads the chunks of the file out of
+// order. Full downloads can optionally be verified against the content hash.
+// If the verification fails, the download fails and an alert is registered.
+
+import (
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+)
+
+var (
+	// errContentHashMismatch is returned when the downloaded data doesn't
+	// match the content hash of the file.
+	errContentHashMismatch = errors.New("downloaded data doesn't match the content hash of the file")
+
+	// errNoContentHash is returned when a download should be verified but
+	// the file doesn't have a content hash.
+	errNoContentHash = errors.New("file doesn't have a content hash")
+
+	// errPartialContentHashVerification is returned when a download which
+	// isn't a full download should be verified.
+	errPartialContentHashVerification = errors.New("only full downloads can be verified against the content hash")
+)
+
+type (
+	// contentHashReader is a helper type which hashes all the data that is
+	// read from the wrapped reader.
+	contentHashReader struct {
+		r io.Reader
+		h hash.Hash
+	}
+
+	// contentHashWriter is a helper type which hashes all the data that is
+	// written to the wrapped writer.
+	contentHashWriter struct {
+		w io.Writer
+		h hash.Hash
+	}
+)
+
+// newContentHashReader creates a new contentHashReader.
+func newContentHashReader(r io.Reader) *contentHashReader {
+	return &contentHashReader{
+		r: r,
+		h: crypto.NewHash(),
+	}
+}
+
+// newContentHashWriter creates a new contentHashWriter.
+func newContentHashWriter(w io.Writer) *contentHashWriter {
+	return &contentHashWriter{
+		w: w,
+		h: crypto.NewHash(),
+	}
+}
+
+// Read implements the io.Reader interface.
+func (chr *contentHashReader) Read(b []byte) (int, error) {
+	n, err := chr.r.Read(b)
+	_, _ = chr.h.Write(b[:n])
+	return n, err
+}
+
+// Sum returns the hash of the data read so far.
+func (chr *contentHashReader) Sum() (h crypto.Hash) {
+	copy(h[:], chr.h.Sum(nil))
+	return
+}
+
+// Write implements the io.Writer interface.
+func (chw *contentHashWriter) Write(b []byte) (int, error) {
+	n, err := chw.w.Write(b)
+	_, _ = chw.h.Write(b[:n])
+	return n, err
+}
+
+// Sum returns the hash of the data written so far.
+func (chw *contentHashWriter) Sum() (h crypto.Hash) {
+	copy(h[:], chw.h.Sum(nil))
+	return
+}
+
+// hashLocalFile returns the content hash of the first length bytes of the
+// file at the given path.
+func hashLocalFile(path string, length uint64) (_ crypto.Hash, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	hr := newContentHashReader(io.LimitReader(f, int64(length)))
+	n, err := io.Copy(ioutil.Discard, hr)
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	if uint64(n) != length {
+		return crypto.Hash{}, io.ErrUnexpectedEOF
+	}
+	return hr.Sum(), nil
+}
+
+// threadedSetContentHashFromDisk computes the content hash of the local file
+// at the given path and stores it in the provided siafile. The siafile is
+// closed afterwards.
+func (r *Renter) threadedSetContentHashFromDisk(entry *filesystem.FileNode, path string) {
+	err := r.tg.Add()
+	if err != nil {
+		err = errors.Compose(err, entry.Close())
+		r.log.Debugln("unable to compute content hash:", err)
+		return
+	}
+	defer r.tg.Done()
+	defer func() {
+		if err := entry.Close(); err != nil {
+			r.log.Println("unable to close siafile after computing content hash:", err)
+		}
+	}()
+	contentHash, err := hashLocalFile(path, entry.Size())
+	if err != nil {
+		r.log.Printf("unable to compute content hash of %v: %v", path, err)
+		return
+	}
+	err = entry.SetContentHash(contentHash)
+	if err != nil {
+		r.log.Printf("unable to set content hash of %v: %v", path, err)
+	}
+}
+
+// managedVerifyContentHash compares the content hash of the siafile at the
+// given siapath with the hash of the downloaded data. If they don't match, an
+// alert is registered.
+func (r *Renter) managedVerifyContentHash(siaPath modules.SiaPath, uid string, expected, actual crypto.Hash) error {
+	if expected == actual {
+		return nil
+	}
+	r.staticAlerter.RegisterAlert(modules.AlertIDSiafileContentHashMismatch(uid), AlertMSGSiafileContentHashMismatch,
+		AlertCauseSiafileContentHashMismatch(siaPath, expected, actual), modules.SeverityError)
+	return errContentHashMismatch
+}
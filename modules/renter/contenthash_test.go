@@ -0,0 +1,149 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+// TestContentHashHelpers tests that the content hash helpers compute the hash
+// of the data they process.
+func TestContentHashHelpers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	data := fastrand.Bytes(int(fastrand.Intn(1000)) + 1000)
+	expected := crypto.HashBytes(data)
+
+	// Check the reader.
+	hr := newContentHashReader(bytes.NewReader(data))
+	read, err := ioutil.ReadAll(hr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) || hr.Sum() != expected {
+		t.Fatal("reader computed the wrong hash")
+	}
+
+	// Check the writer.
+	var buf bytes.Buffer
+	hw := newContentHashWriter(&buf)
+	if _, err := hw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) || hw.Sum() != expected {
+		t.Fatal("writer computed the wrong hash")
+	}
+
+	// Check hashing a local file which is longer than the data.
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(path, append(data, fastrand.Bytes(10)...), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	h, err := hashLocalFile(path, uint64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h != expected {
+		t.Fatal("local file has the wrong hash")
+	}
+	if _, err := hashLocalFile(path, uint64(len(data)+11)); err == nil {
+		t.Fatal("expected hashing beyond the end of the file to fail")
+	}
+}
+
+// TestVerifyContentHash tests the validation of downloads which should be
+// verified and that mismatching content hashes register an alert.
+func TestVerifyContentHash(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a file without a content hash.
+	entry, err := r.newRenterTestFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath := r.staticFileSystem.FileSiaPath(entry)
+	destination := filepath.Join(rt.dir, "download")
+	params := modules.RenterDownloadParameters{
+		Destination:       destination,
+		SiaPath:           siaPath,
+		VerifyContentHash: true,
+	}
+	if _, err := r.managedDownload(params); !errors.Contains(err, errNoContentHash) {
+		t.Fatal("expected errNoContentHash", err)
+	}
+
+	// Set the content hash. Partial downloads can't be verified.
+	contentHash := crypto.HashBytes(fastrand.Bytes(100))
+	if err := entry.SetContentHash(contentHash); err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := r.File(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.ContentHash != contentHash {
+		t.Fatal("FileInfo has the wrong content hash")
+	}
+	params.Length = fi.Filesize - 1
+	if _, err := r.managedDownload(params); !errors.Contains(err, errPartialContentHashVerification) {
+		t.Fatal("expected errPartialContentHashVerification", err)
+	}
+
+	// Matching hashes shouldn't register an alert.
+	numAlerts := func() (n int) {
+		_, errs, _ := r.staticAlerter.Alerts()
+		for _, alert := range errs {
+			if alert.Msg == AlertMSGSiafileContentHashMismatch {
+				n++
+			}
+		}
+		return
+	}
+	if err := r.managedVerifyContentHash(siaPath, "uid", contentHash, contentHash); err != nil {
+		t.Fatal(err)
+	}
+	if n := numAlerts(); n != 0 {
+		t.Fatal("unexpected number of alerts", n)
+	}
+	// Mismatching hashes should.
+	err = r.managedVerifyContentHash(siaPath, "uid", contentHash, crypto.Hash{})
+	if !errors.Contains(err, errContentHashMismatch) {
+		t.Fatal("expected errContentHashMismatch", err)
+	}
+	if n := numAlerts(); n != 1 {
+		t.Fatal("unexpected number of alerts", n)
+	}
+}
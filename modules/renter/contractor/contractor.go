@@ -81,6 +81,10 @@ type Contractor struct {
 	numFailedRenews map[types.FileContractID]types.BlockHeight
 	renewing        map[types.FileContractID]bool // prevent revising during renewal
 
+	// drainingContracts contains the contracts which are being drained by
+	// the renter. They are neither good for upload nor good for renew.
+	drainingContracts map[types.FileContractID]struct{}
+
 	// pubKeysToContractID is a map of host pubkeys to the latest contract ID
 	// that is formed with the host. The contract also has to have an end height
 	// in the future
@@ -312,6 +316,21 @@ func (c *Contractor) ProvidePayment(stream io.ReadWriter, host types.SiaPublicKe
 	return nil
 }
 
+// FinalizeAppendProgram finalizes a write program, consisting of Append
+// instructions for the given sector roots, which was executed on the host
+// using the provided stream.
+func (c *Contractor) FinalizeAppendProgram(stream io.ReadWriter, host types.SiaPublicKey, roots []crypto.Hash, lastOutput modules.RPCExecuteProgramResponse, maxTransfer, storageCost, bandwidthCost types.Currency, blockHeight types.BlockHeight) error {
+	contract, exists := c.ContractByPublicKey(host)
+	if !exists {
+		return errContractNotFound
+	}
+	err := c.staticContracts.FinalizeAppendProgram(stream, contract.ID, roots, lastOutput, maxTransfer, storageCost, bandwidthCost, blockHeight)
+	if err != nil {
+		return errors.AddContext(err, "FinalizeAppendProgram: failed to finalize program")
+	}
+	return nil
+}
+
 // RecoveryScanStatus returns a bool indicating if a scan for recoverable
 // contracts is in progress and if it is, the current progress of the scan.
 func (c *Contractor) RecoveryScanStatus() (bool, types.BlockHeight) {
@@ -450,6 +469,7 @@ func contractorBlockingStartup(cs modules.ConsensusSet, w modules.Wallet, tp mod
 		doubleSpentContracts: make(map[types.FileContractID]types.BlockHeight),
 		recoverableContracts: make(map[types.FileContractID]modules.RecoverableContract),
 		renewing:             make(map[types.FileContractID]bool),
+		drainingContracts:    make(map[types.FileContractID]struct{}),
 		renewedFrom:          make(map[types.FileContractID]types.FileContractID),
 		renewedTo:            make(map[types.FileContractID]types.FileContractID),
 	}
@@ -148,6 +148,31 @@ func (c *Contractor) ContractUtility(pk types.SiaPublicKey) (modules.ContractUti
 	return c.managedContractUtility(id)
 }
 
+// DrainContract marks a specific contract as being drained. A drained contract
+// is neither good for upload nor good for renew which causes the renter to
+// move its data to other hosts. Unlike a canceled contract, its utility isn't
+// locked.
+func (c *Contractor) DrainContract(id types.FileContractID) error {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+
+	sc, exists := c.staticContracts.Acquire(id)
+	if !exists {
+		return errors.New("contract not found")
+	}
+	defer c.staticContracts.Return(sc)
+	c.mu.Lock()
+	c.drainingContracts[id] = struct{}{}
+	c.mu.Unlock()
+	u := sc.Utility()
+	u.GoodForUpload = false
+	u.GoodForRenew = false
+	err := c.callUpdateUtility(sc, u, false)
+	return errors.AddContext(err, "unable to mark contract as drained")
+}
+
 // MarkContractBad will mark a specific contract as bad.
 func (c *Contractor) MarkContractBad(id types.FileContractID) error {
 	if err := c.tg.Add(); err != nil {
@@ -30,6 +30,17 @@ func (c *Contractor) badContractCheck(u modules.ContractUtility) (modules.Contra
 	return u, false
 }
 
+// drainingCheck will return a contract with no utility and a required update
+// if the contract is being drained, no changes otherwise.
+func (c *Contractor) drainingCheck(u modules.ContractUtility, draining bool) (modules.ContractUtility, bool) {
+	if draining {
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, true
+	}
+	return u, false
+}
+
 // maxRevisionCheck will return a locked utility if the contract has reached its
 // max revision.
 func (c *Contractor) maxRevisionCheck(u modules.ContractUtility, revisionNumber uint64) (modules.ContractUtility, bool) {
@@ -148,6 +159,7 @@ func (c *Contractor) managedCriticalUtilityChecks(sc *proto.SafeContract, host m
 	renewWindow := c.allowance.RenewWindow
 	period := c.allowance.Period
 	_, renewed := c.renewedTo[contract.ID]
+	_, draining := c.drainingContracts[contract.ID]
 	c.mu.RUnlock()
 
 	// A contract that has been renewed should be set to !GFU and !GFR.
@@ -166,6 +178,11 @@ func (c *Contractor) managedCriticalUtilityChecks(sc *proto.SafeContract, host m
 		return u, needsUpdate
 	}
 
+	u, needsUpdate = c.drainingCheck(contract.Utility, draining)
+	if needsUpdate {
+		return u, needsUpdate
+	}
+
 	u, needsUpdate = c.offlineCheck(contract, host)
 	if needsUpdate {
 		return u, needsUpdate
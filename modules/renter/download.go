@@ -1,7 +1,6 @@
 package renter
 
 import (
-	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,11 +11,10 @@ import (
 	"sync/atomic"
 	"time"
 
-	"gitlab.com/NebulousLabs/fastrand"
-
 	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem/siafile"
 	"gitlab.com/NebulousLabs/Sia/types"
@@ -33,6 +31,7 @@ type (
 		chunksRemaining uint64        // Number of chunks whose downloads are incomplete.
 		completeChan    chan struct{} // Closed once the download is complete.
 		err             error         // Only set if there was an error which prevented the download from completing.
+		unverified      bool          // Set if the downloaded data didn't pass the content hash verification.
 
 		// downloadCompleteFunc is a slice of functions which are called when
 		// completeChan is closed.
@@ -51,6 +50,10 @@ type (
 		staticSiaPath         modules.SiaPath    // The path of the siafile at the time the download started.
 		staticUID             modules.DownloadID // unique identifier for the download
 
+		// staticCheckpoint is used to resume the download. It is only set for
+		// downloads to a file on disk.
+		staticCheckpoint *downloadCheckpoint
+
 		staticParams downloadParams
 
 		// Retrieval settings for the file.
@@ -65,6 +68,7 @@ type (
 
 	// downloadParams is the set of parameters to use when downloading a file.
 	downloadParams struct {
+		checkpoint        *downloadCheckpoint // The checkpoint of the download. Optional.
 		destination       downloadDestination // The place to write the downloaded data.
 		destinationType   string              // "file", "buffer", "http stream", etc.
 		destinationString string              // The string to report to the user for the destination.
@@ -182,6 +186,27 @@ func (d *download) OnComplete(f func(error) error) {
 	d.onComplete(f)
 }
 
+// verifyContentHashOnComplete registers a function which verifies the hash of
+// the downloaded data against the expected content hash once the download
+// completed successfully. If the verification fails, the download fails. Such
+// a download can't be resumed since all of its chunks were written already.
+func (d *download) verifyContentHashOnComplete(siaPath modules.SiaPath, uid string, expected crypto.Hash, sum func() (crypto.Hash, error)) {
+	d.OnComplete(func(downloadErr error) error {
+		if downloadErr != nil {
+			return nil
+		}
+		actual, err := sum()
+		if err == nil {
+			err = d.r.managedVerifyContentHash(siaPath, uid, expected, actual)
+		}
+		// The downloadCompleteFuncs are executed while the download is
+		// locked which allows for failing the download here.
+		d.err = err
+		d.unverified = err != nil
+		return err
+	})
+}
+
 // UID returns the unique identifier of the download.
 func (d *download) UID() modules.DownloadID {
 	return d.staticUID
@@ -278,12 +303,36 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	if p.Offset < 0 || p.Offset+p.Length > entry.Size() {
 		return nil, fmt.Errorf("offset and length combination invalid, max byte is at index %d", entry.Size()-1)
 	}
+	// Check whether the download can be verified against the content hash.
+	contentHash := entry.ContentHash()
+	if p.VerifyContentHash && contentHash == (crypto.Hash{}) {
+		return nil, errNoContentHash
+	}
+	if p.VerifyContentHash && (p.Offset != 0 || p.Length != entry.Size()) {
+		return nil, errPartialContentHashVerification
+	}
+
+	// Prepare snapshot.
+	snap, err := entry.SnapshotRange(p.SiaPath, p.Offset, p.Length)
+	if err != nil {
+		return nil, err
+	}
 
-	// Instantiate the correct downloadWriter implementation.
+	// Instantiate the correct downloadWriter implementation. Downloads to a
+	// file are checkpointed to allow for resuming them.
 	var dw downloadDestination
 	var destinationType string
+	var cp *downloadCheckpoint
+	var hashWriter *contentHashWriter
 	if isHTTPResp {
-		dw = newDownloadDestinationWriter(p.Httpwriter)
+		w := p.Httpwriter
+		if p.VerifyContentHash {
+			// The writer receives the data in order which allows for
+			// hashing it on the fly.
+			hashWriter = newContentHashWriter(w)
+			w = hashWriter
+		}
+		dw = newDownloadDestinationWriter(w)
 		destinationType = "http stream"
 	} else {
 		osFile, err := os.OpenFile(p.Destination, os.O_CREATE|os.O_WRONLY, entry.Mode())
@@ -295,7 +344,8 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 			f:               osFile,
 			staticChunkSize: int64(entry.ChunkSize()),
 		}
-		destinationType = "file"
+		destinationType = destinationTypeFile
+		cp = newDownloadCheckpoint(snap, p.Destination, p.Offset, p.Length, p.DisableDiskFetch)
 	}
 
 	// If the destination is a httpWriter, we set the Content-Length in the
@@ -307,13 +357,9 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		}
 	}
 
-	// Prepare snapshot.
-	snap, err := entry.SnapshotRange(p.SiaPath, p.Offset, p.Length)
-	if err != nil {
-		return nil, err
-	}
 	// Create the download object.
 	d, err := r.managedNewDownload(downloadParams{
+		checkpoint:        cp,
 		destination:       dw,
 		destinationType:   destinationType,
 		destinationString: p.Destination,
@@ -327,6 +373,9 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		overdrive:     3, // TODO: moderate default until full overdrive support is added.
 		priority:      5, // TODO: moderate default until full priority support is added.
 	})
+	if err == nil && cp != nil {
+		err = r.managedTrackDownloadCheckpoint(d)
+	}
 	if closer, ok := dw.(io.Closer); err != nil && ok {
 		// If the destination can be closed we do so.
 		return nil, errors.Compose(err, closer.Close())
@@ -341,12 +390,22 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 			return closer.Close()
 		}
 		// sanity check that we close files.
-		if destinationType == "file" {
+		if destinationType == destinationTypeFile {
 			build.Critical("file wasn't closed after download")
 		}
 		return nil
 	})
 
+	// Verify the downloaded data after the destination was closed.
+	if p.VerifyContentHash {
+		d.verifyContentHashOnComplete(p.SiaPath, string(entry.UID()), contentHash, func() (crypto.Hash, error) {
+			if hashWriter != nil {
+				return hashWriter.Sum(), nil
+			}
+			return hashLocalFile(p.Destination, p.Length)
+		})
+	}
+
 	// Add the download object to the download history if it's not a stream.
 	if destinationType != destinationTypeSeekStream {
 		r.downloadHistoryMu.Lock()
@@ -375,6 +434,12 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 		return nil, errors.New("download is requesting data past the boundary of the file")
 	}
 
+	// Resumed downloads keep the uid of the download they resume.
+	uid := newDownloadUID()
+	if params.checkpoint != nil {
+		uid = params.checkpoint.UID
+	}
+
 	// Create the download object.
 	d := &download{
 		completeChan: make(chan struct{}),
@@ -384,7 +449,8 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 		destination:           params.destination,
 		destinationString:     params.destinationString,
 		staticDestinationType: params.destinationType,
-		staticUID:             modules.DownloadID(hex.EncodeToString(fastrand.Bytes(16))),
+		staticCheckpoint:      params.checkpoint,
+		staticUID:             uid,
 		staticLatencyTarget:   params.latencyTarget,
 		staticLength:          params.length,
 		staticOffset:          params.offset,
@@ -460,9 +526,18 @@ func (d *download) Start() error {
 		}
 	}
 
-	// Queue the downloads for each chunk.
+	// Queue the downloads for each chunk. Chunks which were already written
+	// to the destination before the download was resumed are skipped.
 	writeOffset := int64(0) // where to write a chunk within the download destination.
-	d.chunksRemaining += maxChunk - minChunk + 1
+	chunksRemaining := maxChunk - minChunk + 1
+	if d.staticCheckpoint != nil {
+		for i := minChunk; i <= maxChunk; i++ {
+			if d.staticCheckpoint.managedChunkComplete(i) {
+				chunksRemaining--
+			}
+		}
+	}
+	d.chunksRemaining += chunksRemaining
 	for i := minChunk; i <= maxChunk; i++ {
 		udc := &unfinishedDownloadChunk{
 			destination: params.destination,
@@ -516,6 +591,12 @@ func (d *download) Start() error {
 		udc.staticWriteOffset = writeOffset
 		writeOffset += int64(udc.staticFetchLength)
 
+		// Skip the chunk if it was already written to the destination.
+		if d.staticCheckpoint != nil && d.staticCheckpoint.managedChunkComplete(i) {
+			atomic.AddUint64(&d.atomicDataReceived, udc.staticFetchLength)
+			continue
+		}
+
 		// TODO: Currently all chunks are given overdrive. This should probably
 		// be changed once the hostdb knows how to measure host speed/latency
 		// and once we can assign overdrive dynamically.
@@ -529,6 +610,13 @@ func (d *download) Start() error {
 		default:
 		}
 	}
+
+	// If all the chunks were already written, the download is complete.
+	if chunksRemaining == 0 {
+		d.mu.Lock()
+		d.markComplete()
+		d.mu.Unlock()
+	}
 	return nil
 }
 
@@ -541,21 +629,29 @@ func (r *Renter) DownloadByUID(uid modules.DownloadID) (modules.DownloadInfo, bo
 		return modules.DownloadInfo{}, false
 	}
 	d.mu.Lock()
-	defer d.mu.Unlock()
-	return modules.DownloadInfo{
+	di := modules.DownloadInfo{
 		Destination:     d.destinationString,
 		DestinationType: d.staticDestinationType,
 		Length:          d.staticLength,
 		Offset:          d.staticOffset,
 		SiaPath:         d.staticSiaPath,
+		UID:             d.staticUID,
 
 		Completed:            d.staticComplete(),
 		EndTime:              d.endTime,
 		Received:             atomic.LoadUint64(&d.atomicDataReceived),
 		StartTime:            d.staticStartTime,
+		Resumable:            d.resumable(),
 		StartTimeUnix:        d.staticStartTime.UnixNano(),
 		TotalDataTransferred: atomic.LoadUint64(&d.atomicTotalDataTransferred),
-	}, true
+	}
+	// Release download lock before calling d.Err(), which will acquire the
+	// lock.
+	d.mu.Unlock()
+	if err := d.Err(); err != nil {
+		di.Error = err.Error()
+	}
+	return di, true
 }
 
 // DownloadHistory returns the list of downloads that have been performed. Will
@@ -592,11 +688,13 @@ func (r *Renter) DownloadHistory() []modules.DownloadInfo {
 			Length:          d.staticLength,
 			Offset:          d.staticOffset,
 			SiaPath:         d.staticSiaPath,
+			UID:             d.staticUID,
 
 			Completed:            d.staticComplete(),
 			EndTime:              d.endTime,
 			Received:             atomic.LoadUint64(&d.atomicDataReceived),
 			StartTime:            d.staticStartTime,
+			Resumable:            d.resumable(),
 			StartTimeUnix:        d.staticStartTime.UnixNano(),
 			TotalDataTransferred: atomic.LoadUint64(&d.atomicTotalDataTransferred),
 		}
@@ -614,7 +712,8 @@ func (r *Renter) DownloadHistory() []modules.DownloadInfo {
 }
 
 // ClearDownloadHistory clears the renter's download history inclusive of the
-// provided before and after timestamps
+// provided before and after timestamps. Cleared downloads which failed can no
+// longer be resumed and their checkpoints are removed.
 //
 // TODO: This function can be improved by implementing a binary search, the
 // trick will be making the binary search be just as readable while handling
@@ -624,6 +723,28 @@ func (r *Renter) ClearDownloadHistory(after, before time.Time) error {
 		return err
 	}
 	defer r.tg.Done()
+
+	// Timestamp validation
+	if before.Before(after) {
+		return errors.New("before timestamp can not be newer then after timestamp")
+	}
+
+	// Clear the history and discard the resumable downloads among the cleared
+	// ones. The history lock needs to be released first since loading the
+	// resumable downloads acquires the locks in the opposite order.
+	cleared := r.managedClearDownloadHistory(after, before)
+	var uids []modules.DownloadID
+	for _, d := range cleared {
+		if d.staticComplete() {
+			uids = append(uids, d.UID())
+		}
+	}
+	return r.managedDiscardResumableDownloads(uids)
+}
+
+// managedClearDownloadHistory removes the downloads within the given timespan
+// from the renter's download history and returns them.
+func (r *Renter) managedClearDownloadHistory(after, before time.Time) []*download {
 	r.downloadHistoryMu.Lock()
 	defer r.downloadHistoryMu.Unlock()
 
@@ -632,15 +753,14 @@ func (r *Renter) ClearDownloadHistory(after, before time.Time) error {
 		return nil
 	}
 
-	// Timestamp validation
-	if before.Before(after) {
-		return errors.New("before timestamp can not be newer then after timestamp")
-	}
-
+	var cleared []*download
 	// Clear download history if both before and after timestamps are zero values
 	if before.Equal(types.EndOfTime) && after.IsZero() {
+		for _, d := range r.downloadHistory {
+			cleared = append(cleared, d)
+		}
 		r.downloadHistory = make(map[modules.DownloadID]*download)
-		return nil
+		return cleared
 	}
 
 	// Find and return downloads that are not within the given range
@@ -651,8 +771,10 @@ func (r *Renter) ClearDownloadHistory(after, before time.Time) error {
 	for _, d := range r.downloadHistory {
 		if !withinTimespan(d.staticStartTime) {
 			filtered[d.UID()] = d
+		} else {
+			cleared = append(cleared, d)
 		}
 	}
 	r.downloadHistory = filtered
-	return nil
+	return cleared
 }
@@ -0,0 +1,497 @@
+package renter
+
+// Downloads to a file on disk are checkpointed to allow for resuming them after
+// they were cancelled, failed or interrupted by a restart of the renter. Next to
+// the partially downloaded file, the renter persists a checkpoint which
+// contains a bitmap of the chunks that were already written to disk. The
+// renter keeps track of all the checkpoints of downloads which didn't complete
+// successfully yet. When a download is resumed, only the chunks which haven't
+// been marked as complete in the checkpoint are downloaded again.
+//
+// To avoid syncing the destination and rewriting the checkpoint for every
+// chunk, completed chunks are only marked in memory and the checkpoint is
+// persisted at most once per downloadCheckpointInterval and when the download
+// fails. Resuming a download that was interrupted by a crash downloads the
+// chunks which were completed since the last checkpoint again. Clearing a
+// download from the download history discards its checkpoint.
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem/siafile"
+	"gitlab.com/NebulousLabs/Sia/persist"
+)
+
+const (
+	// downloadCheckpointExtension is the extension appended to the
+	// destination of a download to get the path of its checkpoint.
+	downloadCheckpointExtension = ".siadownload"
+
+	// resumableDownloadsFile is the name of the file within the renter's
+	// persist dir which contains the downloads that can be resumed.
+	resumableDownloadsFile = "resumabledownloads.json"
+)
+
+var (
+	// downloadCheckpointMetadata is the metadata of a download checkpoint.
+	downloadCheckpointMetadata = persist.Metadata{
+		Header:  "Sia Download Checkpoint",
+		Version: "1.5.4",
+	}
+
+	// resumableDownloadsMetadata is the metadata of the file which contains
+	// the resumable downloads.
+	resumableDownloadsMetadata = persist.Metadata{
+		Header:  "Renter Resumable Downloads",
+		Version: "1.5.4",
+	}
+)
+
+var (
+	// ErrDownloadNotResumable is returned when trying to resume a download
+	// which doesn't have a checkpoint.
+	ErrDownloadNotResumable = errors.New("download can't be resumed")
+
+	// errDownloadInProgress is returned when trying to resume a download
+	// which hasn't completed yet.
+	errDownloadInProgress = errors.New("download is still in progress")
+
+	// errDownloadFileChanged is returned when trying to resume a download of
+	// a siafile which was replaced since the download was started.
+	errDownloadFileChanged = errors.New("siafile changed since the download was started")
+
+	// errDownloadInterrupted is the error of a download which was interrupted
+	// by a restart of the renter.
+	errDownloadInterrupted = errors.New("download was interrupted by a restart of the renter")
+)
+
+type (
+	// downloadCheckpoint contains the information required to resume a
+	// download to a file on disk.
+	downloadCheckpoint struct {
+		UID               modules.DownloadID `json:"uid"`
+		Destination       string             `json:"destination"`
+		DisableLocalFetch bool               `json:"disablelocalfetch"`
+		FileUID           siafile.SiafileUID `json:"fileuid"`
+		Length            uint64             `json:"length"`
+		NumChunks         uint64             `json:"numchunks"`
+		Offset            uint64             `json:"offset"`
+		SiaPath           modules.SiaPath    `json:"siapath"`
+		StartTime         time.Time          `json:"starttime"`
+
+		// Completed is a bitmap of the chunks of the siafile which have been
+		// written to the destination. Received is the amount of data within
+		// those chunks that is part of the download.
+		Completed []byte `json:"completed"`
+		Received  uint64 `json:"received"`
+
+		// lastSave is the time the checkpoint was last persisted. saveMu
+		// serializes persisting the checkpoint.
+		lastSave time.Time
+		saveMu   sync.Mutex
+
+		mu sync.Mutex
+	}
+
+	// resumableDownload is the persisted form of an entry of the renter's
+	// resumable downloads.
+	resumableDownload struct {
+		UID         modules.DownloadID `json:"uid"`
+		Destination string             `json:"destination"`
+	}
+)
+
+// newDownloadUID creates a new, random identifier for a download.
+func newDownloadUID() modules.DownloadID {
+	return modules.DownloadID(hex.EncodeToString(fastrand.Bytes(16)))
+}
+
+// downloadCheckpointPath returns the path of the checkpoint of a download to
+// the given destination.
+func downloadCheckpointPath(destination string) string {
+	return destination + downloadCheckpointExtension
+}
+
+// newDownloadCheckpoint creates a checkpoint for a new download of the given
+// range of the snapshot to the destination.
+func newDownloadCheckpoint(snap *siafile.Snapshot, destination string, offset, length uint64, disableLocalFetch bool) *downloadCheckpoint {
+	return &downloadCheckpoint{
+		UID:               newDownloadUID(),
+		Destination:       destination,
+		DisableLocalFetch: disableLocalFetch,
+		FileUID:           snap.UID(),
+		Length:            length,
+		NumChunks:         snap.NumChunks(),
+		Offset:            offset,
+		SiaPath:           snap.SiaPath(),
+		StartTime:         time.Now(),
+
+		Completed: make([]byte, (snap.NumChunks()+7)/8),
+	}
+}
+
+// loadDownloadCheckpoint loads the checkpoint at the given path from disk.
+func loadDownloadCheckpoint(path string) (*downloadCheckpoint, error) {
+	cp := new(downloadCheckpoint)
+	err := persist.LoadJSON(downloadCheckpointMetadata, cp, path)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(cp.Completed)) != (cp.NumChunks+7)/8 {
+		return nil, errors.New("checkpoint bitmap doesn't match the number of chunks")
+	}
+	return cp, nil
+}
+
+// save persists the checkpoint next to the destination of the download.
+func (cp *downloadCheckpoint) save() error {
+	return persist.SaveJSON(downloadCheckpointMetadata, cp, downloadCheckpointPath(cp.Destination))
+}
+
+// managedChunkComplete returns whether the chunk with the given index was
+// already written to the destination.
+func (cp *downloadCheckpoint) managedChunkComplete(chunkIndex uint64) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if chunkIndex >= cp.NumChunks {
+		return false
+	}
+	return cp.Completed[chunkIndex/8]&(1<<(chunkIndex%8)) != 0
+}
+
+// managedMarkChunkComplete marks the chunk with the given index as written to
+// the destination. The chunk is only marked in memory, the returned bool
+// indicates whether the checkpoint is due to be persisted.
+func (cp *downloadCheckpoint) managedMarkChunkComplete(chunkIndex, fetchLength uint64) (bool, error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if chunkIndex >= cp.NumChunks {
+		return false, errors.New("chunk index out of bounds")
+	}
+	if cp.Completed[chunkIndex/8]&(1<<(chunkIndex%8)) != 0 {
+		return false, nil
+	}
+	cp.Completed[chunkIndex/8] |= 1 << (chunkIndex % 8)
+	cp.Received += fetchLength
+	return time.Since(cp.lastSave) >= downloadCheckpointInterval, nil
+}
+
+// managedSave persists the checkpoint.
+func (cp *downloadCheckpoint) managedSave() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.lastSave = time.Now()
+	return cp.save()
+}
+
+// managedFlush persists the chunks marked as complete in the checkpoint. The
+// destination is synced first to make sure the data of the chunks is on disk
+// before they are persisted as complete. Only the chunks which were marked as
+// complete before syncing are persisted.
+func (cp *downloadCheckpoint) managedFlush(dst downloadDestination) error {
+	cp.saveMu.Lock()
+	defer cp.saveMu.Unlock()
+
+	// Copy the checkpoint before syncing the destination.
+	cp.mu.Lock()
+	cp.lastSave = time.Now()
+	saved := &downloadCheckpoint{
+		UID:               cp.UID,
+		Destination:       cp.Destination,
+		DisableLocalFetch: cp.DisableLocalFetch,
+		FileUID:           cp.FileUID,
+		Length:            cp.Length,
+		NumChunks:         cp.NumChunks,
+		Offset:            cp.Offset,
+		SiaPath:           cp.SiaPath,
+		StartTime:         cp.StartTime,
+
+		Completed: append([]byte{}, cp.Completed...),
+		Received:  cp.Received,
+	}
+	cp.mu.Unlock()
+
+	if syncer, ok := dst.(interface{ Sync() error }); ok {
+		if err := syncer.Sync(); err != nil {
+			return errors.AddContext(err, "failed to sync destination")
+		}
+	}
+	return saved.save()
+}
+
+// managedCheckpointChunk marks a chunk of the download as complete in the
+// download's checkpoint and persists the checkpoint if it is due. Failing to
+// checkpoint a chunk doesn't fail the download, it only means that the chunk
+// needs to be downloaded again when the download is resumed.
+func (d *download) managedCheckpointChunk(chunkIndex, fetchLength uint64, dst downloadDestination) {
+	cp := d.staticCheckpoint
+	if cp == nil {
+		return
+	}
+	due, err := cp.managedMarkChunkComplete(chunkIndex, fetchLength)
+	if err != nil {
+		d.r.log.Printf("WARN: failed to checkpoint chunk %v of download %v: %v", chunkIndex, d.staticUID, err)
+		return
+	}
+	if !due {
+		return
+	}
+	if err := cp.managedFlush(dst); err != nil {
+		d.r.log.Printf("WARN: failed to save checkpoint of download %v: %v", d.staticUID, err)
+	}
+}
+
+// resumable returns whether the download can be resumed. Only
+// checkpointed downloads which didn't complete successfully can be resumed.
+// Downloads which failed the content hash verification can't be resumed since
+// their checkpoint is removed once all chunks were written.
+// The download's lock needs to be held when calling this method.
+func (d *download) resumable() bool {
+	return d.staticCheckpoint != nil && d.staticComplete() && d.err != nil && !d.unverified
+}
+
+// newInterruptedDownload creates a download from a checkpoint which was loaded
+// from disk. The download is marked as complete with an error, which allows
+// for it to be resumed.
+func (r *Renter) newInterruptedDownload(cp *downloadCheckpoint) *download {
+	d := &download{
+		atomicDataReceived: cp.Received,
+
+		completeChan: make(chan struct{}),
+		err:          errDownloadInterrupted,
+
+		staticStartTime: cp.StartTime,
+
+		destinationString:     cp.Destination,
+		staticCheckpoint:      cp,
+		staticDestinationType: destinationTypeFile,
+		staticLength:          cp.Length,
+		staticOffset:          cp.Offset,
+		staticSiaPath:         cp.SiaPath,
+		staticUID:             cp.UID,
+
+		r: r,
+	}
+	close(d.completeChan)
+	return d
+}
+
+// saveResumableDownloads persists the renter's resumable downloads. The
+// resumableDownloadsMu needs to be held when calling this method.
+func (r *Renter) saveResumableDownloads() error {
+	downloads := make([]resumableDownload, 0, len(r.resumableDownloads))
+	for uid, destination := range r.resumableDownloads {
+		downloads = append(downloads, resumableDownload{
+			UID:         uid,
+			Destination: destination,
+		})
+	}
+	return persist.SaveJSON(resumableDownloadsMetadata, downloads, filepath.Join(r.persistDir, resumableDownloadsFile))
+}
+
+// managedLoadResumableDownloads loads the renter's resumable downloads from
+// disk and adds them to the download history as interrupted downloads.
+// Downloads without a valid checkpoint are dropped.
+func (r *Renter) managedLoadResumableDownloads() error {
+	var downloads []resumableDownload
+	err := persist.LoadJSON(resumableDownloadsMetadata, &downloads, filepath.Join(r.persistDir, resumableDownloadsFile))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.AddContext(err, "failed to load resumable downloads")
+	}
+
+	r.resumableDownloadsMu.Lock()
+	defer r.resumableDownloadsMu.Unlock()
+	r.resumableDownloads = make(map[modules.DownloadID]string)
+	var dropped bool
+	for _, rd := range downloads {
+		cp, err := loadDownloadCheckpoint(downloadCheckpointPath(rd.Destination))
+		if err == nil && cp.UID != rd.UID {
+			err = errors.New("checkpoint belongs to a different download")
+		}
+		if err != nil {
+			r.log.Printf("WARN: dropping resumable download %v: %v", rd.UID, err)
+			dropped = true
+			continue
+		}
+		r.resumableDownloads[rd.UID] = rd.Destination
+		r.downloadHistoryMu.Lock()
+		r.downloadHistory[rd.UID] = r.newInterruptedDownload(cp)
+		r.downloadHistoryMu.Unlock()
+	}
+	if !dropped {
+		return nil
+	}
+	return r.saveResumableDownloads()
+}
+
+// managedDiscardResumableDownloads removes the downloads with the given uids
+// from the renter's resumable downloads and deletes their checkpoints. The
+// partially downloaded files are kept.
+func (r *Renter) managedDiscardResumableDownloads(uids []modules.DownloadID) error {
+	r.resumableDownloadsMu.Lock()
+	defer r.resumableDownloadsMu.Unlock()
+	var err error
+	var discarded bool
+	for _, uid := range uids {
+		destination, exists := r.resumableDownloads[uid]
+		if !exists {
+			continue
+		}
+		err = errors.Compose(err, persist.RemoveFile(downloadCheckpointPath(destination)))
+		delete(r.resumableDownloads, uid)
+		discarded = true
+	}
+	if !discarded {
+		return err
+	}
+	return errors.Compose(err, r.saveResumableDownloads())
+}
+
+// managedTrackDownloadCheckpoint persists the checkpoint of the download and
+// adds the download to the renter's resumable downloads. Once the download
+// completes successfully, the checkpoint is removed again.
+func (r *Renter) managedTrackDownloadCheckpoint(d *download) error {
+	cp := d.staticCheckpoint
+	if err := cp.managedSave(); err != nil {
+		return errors.AddContext(err, "failed to save download checkpoint")
+	}
+	r.resumableDownloadsMu.Lock()
+	r.resumableDownloads[d.staticUID] = cp.Destination
+	err := r.saveResumableDownloads()
+	r.resumableDownloadsMu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "failed to save resumable downloads")
+	}
+
+	d.OnComplete(func(err error) error {
+		if err != nil {
+			// Persist the chunks which completed since the last checkpoint.
+			return errors.AddContext(cp.managedFlush(d.destination), "failed to save download checkpoint")
+		}
+		r.resumableDownloadsMu.Lock()
+		delete(r.resumableDownloads, d.staticUID)
+		err = r.saveResumableDownloads()
+		r.resumableDownloadsMu.Unlock()
+		return errors.Compose(err, persist.RemoveFile(downloadCheckpointPath(cp.Destination)))
+	})
+	return nil
+}
+
+// ResumeDownload resumes a download to a file on disk which was cancelled,
+// failed or interrupted by a restart of the renter. Only the chunks which
+// haven't been written to the destination yet are downloaded. The download
+// needs to be started using the returned method. ResumeDownload also accepts an
+// optional input function which will be registered to be called when the
+// download is finished.
+func (r *Renter) ResumeDownload(uid modules.DownloadID, f func(error) error) (start func() error, cancel func(), err error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, nil, err
+	}
+	defer r.tg.Done()
+	d, err := r.managedResumeDownload(uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if f != nil {
+		d.OnComplete(f)
+	}
+	return d.Start, d.managedCancel, nil
+}
+
+// managedResumeDownload creates a download from the checkpoint of the download
+// with the given uid and replaces the download in the download history.
+func (r *Renter) managedResumeDownload(uid modules.DownloadID) (_ *download, err error) {
+	// Make sure the download isn't running anymore.
+	r.downloadHistoryMu.Lock()
+	old, exists := r.downloadHistory[uid]
+	r.downloadHistoryMu.Unlock()
+	if exists && !old.staticComplete() {
+		return nil, errDownloadInProgress
+	}
+
+	// Load the checkpoint.
+	r.resumableDownloadsMu.Lock()
+	destination, resumable := r.resumableDownloads[uid]
+	r.resumableDownloadsMu.Unlock()
+	if !resumable {
+		return nil, ErrDownloadNotResumable
+	}
+	cp, err := loadDownloadCheckpoint(downloadCheckpointPath(destination))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to load download checkpoint")
+	}
+	if cp.UID != uid {
+		return nil, errors.New("checkpoint belongs to a different download")
+	}
+
+	// Make sure the siafile is still the one that was downloaded.
+	entry, err := r.staticFileSystem.OpenSiaFile(cp.SiaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	if cp.Offset+cp.Length > entry.Size() {
+		return nil, errDownloadFileChanged
+	}
+	snap, err := entry.SnapshotRange(cp.SiaPath, cp.Offset, cp.Length)
+	if err != nil {
+		return nil, err
+	}
+	if snap.UID() != cp.FileUID || snap.NumChunks() != cp.NumChunks {
+		return nil, errDownloadFileChanged
+	}
+
+	// Open the partially downloaded file without truncating it.
+	osFile, err := os.OpenFile(cp.Destination, os.O_WRONLY, entry.Mode())
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to open partially downloaded file")
+	}
+	dw := &downloadDestinationFile{
+		deps:            r.deps,
+		f:               osFile,
+		staticChunkSize: int64(entry.ChunkSize()),
+	}
+	d, err := r.managedNewDownload(downloadParams{
+		checkpoint:        cp,
+		destination:       dw,
+		destinationType:   destinationTypeFile,
+		destinationString: cp.Destination,
+		disableLocalFetch: cp.DisableLocalFetch,
+		file:              snap,
+
+		latencyTarget: 25e3 * time.Millisecond,
+		length:        cp.Length,
+		needsMemory:   true,
+		offset:        cp.Offset,
+		overdrive:     3,
+		priority:      5,
+	})
+	if err == nil {
+		err = r.managedTrackDownloadCheckpoint(d)
+	}
+	if err != nil {
+		return nil, errors.Compose(err, dw.Close())
+	}
+	d.OnComplete(func(_ error) error {
+		return dw.Close()
+	})
+
+	// Replace the download in the history unless it was resumed concurrently.
+	r.downloadHistoryMu.Lock()
+	defer r.downloadHistoryMu.Unlock()
+	if old, exists := r.downloadHistory[uid]; exists && !old.staticComplete() {
+		return nil, errors.Compose(errDownloadInProgress, dw.Close())
+	}
+	r.downloadHistory[uid] = d
+	return d, nil
+}
@@ -0,0 +1,265 @@
+package renter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// newTestDownloadCheckpoint creates a checkpoint for a download with the given
+// number of chunks to a destination within dir.
+func newTestDownloadCheckpoint(dir string, numChunks uint64) *downloadCheckpoint {
+	return &downloadCheckpoint{
+		UID:         newDownloadUID(),
+		Destination: filepath.Join(dir, "file"),
+		Length:      numChunks * modules.SectorSize,
+		NumChunks:   numChunks,
+		SiaPath:     modules.RandomSiaPath(),
+		Completed:   make([]byte, (numChunks+7)/8),
+	}
+}
+
+// TestDownloadCheckpoint tests marking chunks of a download checkpoint as
+// complete and persisting the checkpoint.
+func TestDownloadCheckpoint(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	cp := newTestDownloadCheckpoint(dir, 10)
+
+	// Mark a few chunks as complete. Only the first one should be due to be
+	// persisted since the checkpoint was never saved before.
+	for i, chunkIndex := range []uint64{0, 3, 9} {
+		due, err := cp.managedMarkChunkComplete(chunkIndex, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if due != (i == 0) {
+			t.Fatalf("chunk %v: expected due to be %v", chunkIndex, i == 0)
+		}
+		if due {
+			if err := cp.managedFlush(nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Marking a chunk twice shouldn't increase the received data.
+	if _, err := cp.managedMarkChunkComplete(3, 100); err != nil {
+		t.Fatal(err)
+	}
+	if cp.Received != 300 {
+		t.Fatal("wrong amount of received data", cp.Received)
+	}
+	// Marking an out-of-bounds chunk should fail.
+	if _, err := cp.managedMarkChunkComplete(10, 100); err == nil {
+		t.Fatal("expected marking an out-of-bounds chunk to fail")
+	}
+	for i := uint64(0); i < 11; i++ {
+		expected := i == 0 || i == 3 || i == 9
+		if cp.managedChunkComplete(i) != expected {
+			t.Fatalf("chunk %v: expected complete to be %v", i, expected)
+		}
+	}
+
+	// Only the first chunk should have been persisted.
+	loaded, err := loadDownloadCheckpoint(downloadCheckpointPath(cp.Destination))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Received != 100 || loaded.Completed[0] != 1 {
+		t.Fatal("unflushed chunks shouldn't be persisted", loaded.Received, loaded.Completed)
+	}
+
+	// Flush the checkpoint, load it from disk and compare it.
+	if err := cp.managedFlush(nil); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = loadDownloadCheckpoint(downloadCheckpointPath(cp.Destination))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.UID != cp.UID || loaded.Received != cp.Received || !reflect.DeepEqual(loaded.Completed, cp.Completed) {
+		t.Fatal("loaded checkpoint doesn't match saved checkpoint")
+	}
+
+	// A checkpoint with a bitmap of the wrong size can't be loaded.
+	cp.Completed = cp.Completed[:1]
+	if err := cp.managedSave(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadDownloadCheckpoint(downloadCheckpointPath(cp.Destination)); err == nil {
+		t.Fatal("expected loading a corrupted checkpoint to fail")
+	}
+}
+
+// TestResumableDownloadsPersist tests that checkpointed downloads are added to
+// the download history as resumable downloads after a restart.
+func TestResumableDownloadsPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Persist a checkpoint and track it together with a download which doesn't
+	// have a checkpoint.
+	cp := newTestDownloadCheckpoint(rt.dir, 3)
+	if _, err := cp.managedMarkChunkComplete(1, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.managedFlush(nil); err != nil {
+		t.Fatal(err)
+	}
+	missing := newDownloadUID()
+	r.resumableDownloadsMu.Lock()
+	r.resumableDownloads[cp.UID] = cp.Destination
+	r.resumableDownloads[missing] = filepath.Join(rt.dir, "missing")
+	err = r.saveResumableDownloads()
+	r.resumableDownloadsMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the renter.
+	r, err = rt.reloadRenter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The checkpointed download should be resumable.
+	di, exists := r.DownloadByUID(cp.UID)
+	if !exists {
+		t.Fatal("checkpointed download isn't in the download history")
+	}
+	if !di.Resumable || !di.Completed || di.Error != errDownloadInterrupted.Error() {
+		t.Fatal("checkpointed download should be resumable", di)
+	}
+	if di.UID != cp.UID || di.Received != 100 || di.Destination != cp.Destination {
+		t.Fatal("wrong download info", di)
+	}
+
+	// The download without a checkpoint should be dropped.
+	if _, exists := r.DownloadByUID(missing); exists {
+		t.Fatal("download without checkpoint shouldn't be in the download history")
+	}
+	r.resumableDownloadsMu.Lock()
+	_, exists = r.resumableDownloads[missing]
+	r.resumableDownloadsMu.Unlock()
+	if exists {
+		t.Fatal("download without checkpoint should have been dropped")
+	}
+
+	// Resuming the download without a checkpoint should fail.
+	if _, _, err := r.ResumeDownload(missing, nil); err != ErrDownloadNotResumable {
+		t.Fatal("expected ErrDownloadNotResumable", err)
+	}
+
+	// Clearing the download history should discard the resumable download and
+	// its checkpoint.
+	if err := r.ClearDownloadHistory(time.Time{}, types.EndOfTime); err != nil {
+		t.Fatal(err)
+	}
+	r.resumableDownloadsMu.Lock()
+	numResumable := len(r.resumableDownloads)
+	r.resumableDownloadsMu.Unlock()
+	if numResumable != 0 {
+		t.Fatal("cleared download should have been discarded", numResumable)
+	}
+	if _, err := os.Stat(downloadCheckpointPath(cp.Destination)); !os.IsNotExist(err) {
+		t.Fatal("checkpoint of cleared download should have been removed", err)
+	}
+	if _, _, err := r.ResumeDownload(cp.UID, nil); err != ErrDownloadNotResumable {
+		t.Fatal("expected ErrDownloadNotResumable", err)
+	}
+
+	// The discarded download shouldn't be loaded after a restart.
+	r, err = rt.reloadRenter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := r.DownloadByUID(cp.UID); exists {
+		t.Fatal("discarded download shouldn't be in the download history")
+	}
+}
+
+// TestUnverifiedDownloadNotResumable tests that a checkpointed download which
+// fails the content hash verification isn't reported as resumable.
+func TestUnverifiedDownloadNotResumable(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Track a checkpointed download which verifies a mismatching hash.
+	cp := newTestDownloadCheckpoint(rt.dir, 1)
+	d := &download{
+		completeChan:     make(chan struct{}),
+		staticCheckpoint: cp,
+		staticSiaPath:    cp.SiaPath,
+		staticUID:        cp.UID,
+		r:                r,
+	}
+	if err := r.managedTrackDownloadCheckpoint(d); err != nil {
+		t.Fatal(err)
+	}
+	d.verifyContentHashOnComplete(cp.SiaPath, "uid", crypto.HashBytes([]byte("expected")), func() (crypto.Hash, error) {
+		return crypto.Hash{}, nil
+	})
+	r.downloadHistoryMu.Lock()
+	r.downloadHistory[d.UID()] = d
+	r.downloadHistoryMu.Unlock()
+
+	// Complete the download without an error.
+	d.mu.Lock()
+	d.markComplete()
+	d.mu.Unlock()
+
+	// The download should have failed and shouldn't be resumable.
+	di, exists := r.DownloadByUID(cp.UID)
+	if !exists {
+		t.Fatal("download isn't in the download history")
+	}
+	if di.Resumable || !di.Completed || di.Error != errContentHashMismatch.Error() {
+		t.Fatal("unverified download shouldn't be resumable", di)
+	}
+	if _, _, err := r.ResumeDownload(cp.UID, nil); err != ErrDownloadNotResumable {
+		t.Fatal("expected ErrDownloadNotResumable", err)
+	}
+	if _, err := os.Stat(downloadCheckpointPath(cp.Destination)); !os.IsNotExist(err) {
+		t.Fatal("checkpoint of unverified download should have been removed", err)
+	}
+}
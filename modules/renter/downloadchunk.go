@@ -229,6 +229,9 @@ func (udc *unfinishedDownloadChunk) threadedRecoverLogicalData() error {
 		udc.mu.Unlock()
 		return errors.AddContext(err, "unable to write to download destination")
 	}
+	// Checkpoint the chunk before finalizing it to allow for resuming the
+	// download.
+	udc.download.managedCheckpointChunk(udc.staticChunkIndex, udc.staticFetchLength, udc.destination)
 	// finalize the chunk.
 	udc.managedFinalizeRecovery()
 	return nil
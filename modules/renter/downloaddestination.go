@@ -125,6 +125,11 @@ func (ddf *downloadDestinationFile) Close() error {
 	return ddf.f.Close()
 }
 
+// Sync commits the written data of the downloadDestinationFile to disk.
+func (ddf *downloadDestinationFile) Sync() error {
+	return ddf.f.Sync()
+}
+
 // WritePieces will decode the pieces and write them to a file at the provided
 // offset, using the provided length.
 func (ddf *downloadDestinationFile) WritePieces(ec modules.ErasureCoder, pieces [][]byte, dataOffset uint64, offset int64, length uint64) error {
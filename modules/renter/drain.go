@@ -0,0 +1,381 @@
+package renter
+
+// Draining a host moves the data stored on the host to other hosts before the
+// contract with the host is canceled. The contract is marked as being drained
+// which makes it neither good for upload nor good for renew. The data can still
+// be downloaded from the host though. The renter periodically scans all
+// siafiles for chunks which have pieces on the host that aren't stored on any
+// other good host and pushes them into the upload heap with a high repair
+// priority. Once no chunk relies on the host anymore, the contract is
+// canceled. Drains are persisted and resumed on startup.
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem/siafile"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errDrainInProgress is returned when a host is already being drained.
+	errDrainInProgress = errors.New("host is already being drained")
+
+	// errNoContractWithHost is returned when a host without a contract is
+	// drained.
+	errNoContractWithHost = errors.New("no contract with host")
+)
+
+// drainStatus tracks the progress of draining a host.
+type drainStatus struct {
+	staticHostPubKey types.SiaPublicKey
+	staticStartTime  time.Time
+
+	bytesInitial    uint64
+	bytesRemaining  uint64
+	chunksRemaining uint64
+	done            bool
+	err             error
+	scanned         bool
+	mu              sync.Mutex
+}
+
+// newDrainStatus creates a new drainStatus for the host with the given public
+// key.
+func newDrainStatus(hostPubKey types.SiaPublicKey) *drainStatus {
+	return &drainStatus{
+		staticHostPubKey: hostPubKey,
+		staticStartTime:  time.Now(),
+	}
+}
+
+// drainPiecesRemaining returns the number of pieces of a chunk which are
+// stored on the host with the given public key but not on any other host which
+// is online and good for renew.
+func drainPiecesRemaining(pieces [][]siafile.Piece, hostPubKey string, offline, goodForRenew map[string]bool) (remaining uint64) {
+	for _, pieceSet := range pieces {
+		onHost, elsewhere := false, false
+		for _, piece := range pieceSet {
+			hpk := piece.HostPubKey.String()
+			if hpk == hostPubKey {
+				onHost = true
+				continue
+			}
+			gfr, exists := goodForRenew[hpk]
+			off, exists2 := offline[hpk]
+			if exists && gfr && exists2 && !off {
+				elsewhere = true
+			}
+		}
+		if onHost && !elsewhere {
+			remaining++
+		}
+	}
+	return
+}
+
+// managedFinish marks the drain as done.
+func (ds *drainStatus) managedFinish(err error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.done = true
+	ds.err = err
+}
+
+// managedInProgress returns whether the drain is still in progress.
+func (ds *drainStatus) managedInProgress() bool {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return !ds.done
+}
+
+// managedSetError sets the most recent error of the drain.
+func (ds *drainStatus) managedSetError(err error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.err = err
+}
+
+// managedStatus returns the status of the drain.
+func (ds *drainStatus) managedStatus() modules.HostDrainStatus {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	status := modules.HostDrainStatus{
+		ChunksRemaining: ds.chunksRemaining,
+		Done:            ds.done,
+		HostPublicKey:   ds.staticHostPubKey,
+		StartTime:       ds.staticStartTime,
+	}
+	if ds.bytesInitial > ds.bytesRemaining {
+		status.BytesMoved = ds.bytesInitial - ds.bytesRemaining
+	}
+	if ds.err != nil {
+		status.Error = ds.err.Error()
+	}
+	return status
+}
+
+// managedUpdate updates the progress of the drain with the results of a scan.
+// The results of the first scan are used as a baseline.
+func (ds *drainStatus) managedUpdate(chunksRemaining, bytesRemaining uint64) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if !ds.scanned {
+		ds.bytesInitial = bytesRemaining
+		ds.scanned = true
+	}
+	ds.bytesRemaining = bytesRemaining
+	ds.chunksRemaining = chunksRemaining
+}
+
+// managedDrainFile pushes the chunks of the siafile at the given siapath which
+// rely on the host with the given public key into the upload heap. It returns
+// the number of chunks which rely on the host and the amount of data which is
+// only stored on the host.
+func (r *Renter) managedDrainFile(siaPath modules.SiaPath, hostPubKey string, hosts map[string]struct{}, offline, goodForRenew map[string]bool) (chunksRemaining, bytesRemaining uint64, err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+
+	// Build a map of host public keys.
+	pks := make(map[string]types.SiaPublicKey)
+	for _, pk := range entry.HostPublicKeys() {
+		pks[string(pk.Key)] = pk
+	}
+
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		pieces, err := entry.Pieces(chunkIndex)
+		if err != nil {
+			return 0, 0, errors.AddContext(err, "unable to get pieces of chunk")
+		}
+		remaining := drainPiecesRemaining(pieces, hostPubKey, offline, goodForRenew)
+		if remaining == 0 {
+			continue
+		}
+		chunksRemaining++
+		bytesRemaining += remaining * entry.PieceSize()
+
+		// Push the chunk into the upload heap with a high priority if it
+		// can be repaired.
+		chunk, err := r.managedBuildUnfinishedChunk(entry, chunkIndex, hosts, pks, memoryPriorityLow, offline, goodForRenew)
+		if err != nil {
+			return 0, 0, errors.AddContext(err, "unable to build chunk")
+		}
+		chunk.repairPriority = modules.RepairPriorityHigh
+		if chunk.health > 1 && !chunk.onDisk {
+			r.log.Printf("WARN: chunk %v of %v can't be drained since it isn't repairable", chunkIndex, siaPath)
+			err = chunk.fileEntry.Close()
+		} else if pushed, pushErr := r.managedPushChunkForRepair(chunk, chunkTypeLocalChunk); pushErr != nil || !pushed {
+			err = errors.Compose(pushErr, chunk.fileEntry.Close())
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return chunksRemaining, bytesRemaining, nil
+}
+
+// managedDrainScan pushes all the chunks which rely on the drained host into
+// the upload heap and updates the progress of the drain. It returns the number
+// of chunks which rely on the host.
+func (r *Renter) managedDrainScan(ds *drainStatus) (uint64, error) {
+	hostPubKey := ds.staticHostPubKey.String()
+	hosts := r.managedRefreshHostsAndWorkers()
+	offline, goodForRenew, _ := r.managedContractUtilityMaps()
+
+	// Get all the siafiles.
+	var mu sync.Mutex
+	var siaPaths []modules.SiaPath
+	err := r.staticFileSystem.CachedList(modules.RootSiaPath(), true, func(fi modules.FileInfo) {
+		mu.Lock()
+		siaPaths = append(siaPaths, fi.SiaPath)
+		mu.Unlock()
+	}, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to list files")
+	}
+
+	// Drain the siafiles. A single siafile which can't be drained shouldn't
+	// block the others.
+	var chunksRemaining, bytesRemaining uint64
+	var scanErr error
+	for _, siaPath := range siaPaths {
+		select {
+		case <-r.tg.StopChan():
+			return 0, errors.New("interrupted by shutdown")
+		default:
+		}
+		chunks, bytes, err := r.managedDrainFile(siaPath, hostPubKey, hosts, offline, goodForRenew)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			continue // file was deleted in the meantime
+		}
+		if err != nil {
+			scanErr = errors.Compose(scanErr, errors.AddContext(err, fmt.Sprintf("unable to drain file %v", siaPath)))
+		}
+		chunksRemaining += chunks
+		bytesRemaining += bytes
+	}
+	ds.managedUpdate(chunksRemaining, bytesRemaining)
+
+	// Signal the repair loop that chunks were added to the upload heap.
+	if chunksRemaining > 0 {
+		select {
+		case r.uploadHeap.repairNeeded <- struct{}{}:
+		default:
+		}
+	}
+	return chunksRemaining, scanErr
+}
+
+// managedDrainIteration marks the contract with the drained host as drained
+// and pushes the chunks which rely on the host into the upload heap. Once no
+// chunk relies on the host anymore, the contract is canceled and the drain is
+// finished.
+func (r *Renter) managedDrainIteration(ds *drainStatus) error {
+	contract, exists := r.hostContractor.ContractByPublicKey(ds.staticHostPubKey)
+	if !exists {
+		// The contract expired in the meantime. There is nothing left to
+		// drain.
+		ds.managedFinish(errNoContractWithHost)
+		return r.managedPersistDrains()
+	}
+	err := r.hostContractor.DrainContract(contract.ID)
+	if err != nil {
+		return errors.AddContext(err, "unable to mark contract as drained")
+	}
+	chunksRemaining, err := r.managedDrainScan(ds)
+	if err != nil || chunksRemaining > 0 {
+		return err
+	}
+	err = r.hostContractor.CancelContract(contract.ID)
+	if err != nil {
+		return errors.AddContext(err, "unable to cancel contract")
+	}
+	ds.managedFinish(nil)
+	return r.managedPersistDrains()
+}
+
+// threadedDrainHost drains a host until no chunk relies on it anymore.
+func (r *Renter) threadedDrainHost(ds *drainStatus) {
+	err := r.tg.Add()
+	if err != nil {
+		return
+	}
+	defer r.tg.Done()
+	for ds.managedInProgress() {
+		err := r.managedDrainIteration(ds)
+		if err != nil {
+			r.log.Printf("WARN: failed to drain host %v: %v", ds.staticHostPubKey, err)
+		}
+		if !ds.managedInProgress() {
+			return
+		}
+		ds.managedSetError(err)
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(drainCheckInterval):
+		}
+	}
+}
+
+// managedPersistDrains stores the public keys of the hosts which are being
+// drained in the renter's persistence.
+func (r *Renter) managedPersistDrains() error {
+	r.drainsMu.Lock()
+	var hosts []types.SiaPublicKey
+	for _, ds := range r.drains {
+		if ds.managedInProgress() {
+			hosts = append(hosts, ds.staticHostPubKey)
+		}
+	}
+	r.drainsMu.Unlock()
+
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	r.persist.DrainingHosts = hosts
+	return r.saveSync()
+}
+
+// managedResumeDrains resumes the drains which were in progress when the
+// renter was shut down.
+func (r *Renter) managedResumeDrains() {
+	id := r.mu.RLock()
+	hosts := append([]types.SiaPublicKey(nil), r.persist.DrainingHosts...)
+	r.mu.RUnlock(id)
+
+	r.drainsMu.Lock()
+	defer r.drainsMu.Unlock()
+	for _, hostPubKey := range hosts {
+		ds := newDrainStatus(hostPubKey)
+		r.drains[hostPubKey.String()] = ds
+		go r.threadedDrainHost(ds)
+	}
+}
+
+// DrainHost moves the data stored on the host with the given public key to
+// other hosts. The contract with the host is canceled once the data is stored
+// on other hosts. The drain happens in the background and its progress is
+// reported by DrainStatus.
+func (r *Renter) DrainHost(hostPubKey types.SiaPublicKey) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	contract, exists := r.hostContractor.ContractByPublicKey(hostPubKey)
+	if !exists {
+		return errNoContractWithHost
+	}
+
+	// Register the drain.
+	ds := newDrainStatus(hostPubKey)
+	r.drainsMu.Lock()
+	if oldStatus, exists := r.drains[hostPubKey.String()]; exists && oldStatus.managedInProgress() {
+		r.drainsMu.Unlock()
+		return errDrainInProgress
+	}
+	r.drains[hostPubKey.String()] = ds
+	r.drainsMu.Unlock()
+
+	// Stop using the contract for uploads right away and persist the drain.
+	err := r.hostContractor.DrainContract(contract.ID)
+	if err != nil {
+		err = errors.AddContext(err, "unable to mark contract as drained")
+		ds.managedFinish(err)
+		return err
+	}
+	err = r.managedPersistDrains()
+	if err != nil {
+		err = errors.AddContext(err, "unable to persist drain")
+		ds.managedFinish(err)
+		return err
+	}
+
+	go r.threadedDrainHost(ds)
+	return nil
+}
+
+// DrainStatus returns the progress of the hosts which are being drained or
+// were drained since the renter was started, sorted by their start time.
+func (r *Renter) DrainStatus() []modules.HostDrainStatus {
+	r.drainsMu.Lock()
+	statuses := make([]modules.HostDrainStatus, 0, len(r.drains))
+	for _, ds := range r.drains {
+		statuses = append(statuses, ds.managedStatus())
+	}
+	r.drainsMu.Unlock()
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].StartTime.Before(statuses[j].StartTime)
+	})
+	return statuses
+}
@@ -0,0 +1,104 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem/siafile"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestDrainPiecesRemaining tests that only the pieces which aren't stored on
+// any other good host are counted as remaining.
+func TestDrainPiecesRemaining(t *testing.T) {
+	t.Parallel()
+
+	randomKey := func() types.SiaPublicKey {
+		return types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	}
+	drained, good, bad := randomKey(), randomKey(), randomKey()
+	offline := map[string]bool{
+		drained.String(): false,
+		good.String():    false,
+		bad.String():     true,
+	}
+	goodForRenew := map[string]bool{
+		drained.String(): false,
+		good.String():    true,
+		bad.String():     true,
+	}
+	pieces := [][]siafile.Piece{
+		// Only on the drained host.
+		{{HostPubKey: drained}},
+		// Also on a good host.
+		{{HostPubKey: drained}, {HostPubKey: good}},
+		// Also on an offline host.
+		{{HostPubKey: drained}, {HostPubKey: bad}},
+		// Not on the drained host.
+		{{HostPubKey: good}},
+		// Missing.
+		{},
+	}
+	remaining := drainPiecesRemaining(pieces, drained.String(), offline, goodForRenew)
+	if remaining != 2 {
+		t.Fatal("wrong number of remaining pieces", remaining)
+	}
+}
+
+// TestDrainStatus tests that the progress of a drain is computed relative to
+// the first scan.
+func TestDrainStatus(t *testing.T) {
+	t.Parallel()
+
+	ds := newDrainStatus(types.SiaPublicKey{})
+	ds.managedUpdate(10, 1000)
+	status := ds.managedStatus()
+	if status.BytesMoved != 0 || status.ChunksRemaining != 10 || status.Done {
+		t.Fatal("unexpected status", status)
+	}
+	ds.managedUpdate(2, 200)
+	status = ds.managedStatus()
+	if status.BytesMoved != 800 || status.ChunksRemaining != 2 {
+		t.Fatal("unexpected status", status)
+	}
+	// More remaining data than initially shouldn't underflow.
+	ds.managedUpdate(20, 2000)
+	if status = ds.managedStatus(); status.BytesMoved != 0 {
+		t.Fatal("unexpected status", status)
+	}
+	ds.managedFinish(errNoContractWithHost)
+	status = ds.managedStatus()
+	if !status.Done || status.Error != errNoContractWithHost.Error() || ds.managedInProgress() {
+		t.Fatal("unexpected status", status)
+	}
+}
+
+// TestDrainHostNoContract tests that hosts without a contract can't be
+// drained.
+func TestDrainHostNoContract(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	if err := r.DrainHost(hostKey); !errors.Contains(err, errNoContractWithHost) {
+		t.Fatal("expected errNoContractWithHost", err)
+	}
+	if drains := r.DrainStatus(); len(drains) != 0 {
+		t.Fatal("unexpected drains", drains)
+	}
+}
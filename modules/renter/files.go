@@ -2,6 +2,7 @@ package renter
 
 import (
 	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
 
 	"gitlab.com/NebulousLabs/errors"
 )
@@ -69,6 +70,10 @@ func (r *Renter) File(siaPath modules.SiaPath) (modules.FileInfo, error) {
 	if err != nil {
 		return modules.FileInfo{}, errors.AddContext(err, "unable to get the fileinfo from the filesystem")
 	}
+	err = r.managedAddReencodeInfo(siaPath, &fi)
+	if err != nil {
+		return modules.FileInfo{}, errors.AddContext(err, "unable to get the re-encoding status")
+	}
 	return fi, nil
 }
 
@@ -120,6 +125,25 @@ func (r *Renter) RenameFile(currentName, newName modules.SiaPath) error {
 	return nil
 }
 
+// OverwriteFile moves the file at srcSiaPath to dstSiaPath. An existing file at
+// dstSiaPath is replaced, or kept as a version if versioning is enabled for its
+// directory.
+func (r *Renter) OverwriteFile(srcSiaPath, dstSiaPath modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	err := r.RenameFile(srcSiaPath, dstSiaPath)
+	if !errors.Contains(err, filesystem.ErrExists) {
+		return err
+	}
+	err = r.managedOverwriteFile(dstSiaPath)
+	if err != nil {
+		return err
+	}
+	return r.RenameFile(srcSiaPath, dstSiaPath)
+}
+
 // SetFileStuck sets the Stuck field of the whole siafile to stuck.
 func (r *Renter) SetFileStuck(siaPath modules.SiaPath, stuck bool) (err error) {
 	if err := r.tg.Add(); err != nil {
@@ -156,6 +156,28 @@ func (n *DirNode) UpdateLastHealthCheckTime(aggregateLastHealthCheckTime, lastHe
 	return sd.UpdateLastHealthCheckTime(aggregateLastHealthCheckTime, lastHealthCheckTime)
 }
 
+// UpdateVersioning is a wrapper for SiaDir.UpdateVersioning.
+func (n *DirNode) UpdateVersioning(maxVersions, retentionDays uint64) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.UpdateVersioning(maxVersions, retentionDays)
+}
+
+// UpdateUploadPolicy is a wrapper for SiaDir.UpdateUploadPolicy.
+func (n *DirNode) UpdateUploadPolicy(policy modules.UploadPolicy) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.UpdateUploadPolicy(policy)
+}
+
 // UpdateMetadata is a wrapper for SiaDir.UpdateMetadata.
 func (n *DirNode) UpdateMetadata(md siadir.Metadata) error {
 	n.mu.Lock()
@@ -603,6 +625,13 @@ func (n *DirNode) managedInfo(siaPath modules.SiaPath) (modules.DirectoryInfo, e
 		StuckHealth:         metadata.StuckHealth,
 		SiaPath:             siaPath,
 		UID:                 n.staticUID,
+
+		// Versioning Fields
+		MaxVersions:          metadata.MaxVersions,
+		VersionRetentionDays: metadata.VersionRetentionDays,
+
+		// Upload Policy
+		UploadPolicy: metadata.UploadPolicy,
 	}, nil
 }
 
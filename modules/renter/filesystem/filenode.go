@@ -98,6 +98,7 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		Available:        redundancy >= 1,
 		ChangeTime:       n.ChangeTime(),
 		CipherType:       n.MasterKey().Type().String(),
+		ContentHash:      n.ContentHash(),
 		CreateTime:       n.CreateTime(),
 		Expiration:       n.Expiration(contracts),
 		Filesize:         n.Size(),
@@ -162,6 +163,42 @@ func (n *FileNode) managedRename(newName string, oldParent, newParent *DirNode)
 	return err
 }
 
+// managedReplace replaces the underlying file of dst with the fNode's
+// underlying file. Afterwards the fNode takes the place of dst and dst is
+// deleted.
+func (n *FileNode) managedReplace(dst *FileNode, oldParent, newParent *DirNode) error {
+	// Lock the parents. If they are the same, only lock one.
+	if oldParent.staticUID == newParent.staticUID {
+		oldParent.node.mu.Lock()
+		defer oldParent.node.mu.Unlock()
+	} else {
+		oldParent.node.mu.Lock()
+		defer oldParent.node.mu.Unlock()
+		newParent.node.mu.Lock()
+		defer newParent.node.mu.Unlock()
+	}
+	n.node.mu.Lock()
+	defer n.node.mu.Unlock()
+	dst.node.mu.Lock()
+	defer dst.node.mu.Unlock()
+	// Replace the file.
+	err := n.SiaFile.Replace(dst.SiaFile)
+	if err != nil {
+		return err
+	}
+	// Remove both files from their parents and add the fNode to the new
+	// parent.
+	oldParent.removeFile(n)
+	newParent.removeFile(dst)
+	// Update parent and name.
+	n.parent = newParent
+	*n.name = *dst.name
+	*n.path = *dst.path
+	// Add file to new parent.
+	n.parent.files[*n.name] = n
+	return nil
+}
+
 // cachedFileInfo returns information on a siafile. As a performance
 // optimization, the fileInfo takes the maps returned by
 // renter.managedContractUtilityMaps for many files at once.
@@ -181,6 +218,7 @@ func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo,
 		Available:        md.CachedUserRedundancy >= 1,
 		ChangeTime:       md.ChangeTime,
 		CipherType:       md.StaticMasterKeyType.String(),
+		ContentHash:      md.ContentHash,
 		CreateTime:       md.CreateTime,
 		Expiration:       md.CachedExpiration,
 		Filesize:         uint64(md.FileSize),
@@ -171,13 +171,29 @@ func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem,
 // already exists with a different UID, the UID will be updated and a unique
 // path will be chosen. If no file exists, the UID will be updated but the path
 // remains the same.
-func (fs *FileSystem) AddSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath) (err error) {
+func (fs *FileSystem) AddSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath) error {
+	return fs.managedAddSiaFileFromReader(rs, siaPath, false)
+}
+
+// ImportSiaFileFromReader adds a SiaFile which was shared by another renter to
+// the set and stores it on disk. Unlike AddSiaFileFromReader, the local path of
+// the SiaFile is removed since it refers to the other renter's machine.
+func (fs *FileSystem) ImportSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath) error {
+	return fs.managedAddSiaFileFromReader(rs, siaPath, true)
+}
+
+// managedAddSiaFileFromReader adds an existing SiaFile to the set and stores it
+// on disk. If resetLocalPath is set, the local path of the SiaFile is removed.
+func (fs *FileSystem) managedAddSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath, resetLocalPath bool) (err error) {
 	// Load the file.
 	path := fs.FilePath(siaPath)
 	sf, chunks, err := siafile.LoadSiaFileFromReaderWithChunks(rs, path, fs.staticWal)
 	if err != nil {
 		return err
 	}
+	if resetLocalPath {
+		sf.ResetLocalPath()
+	}
 	// Create dir with same Mode as file if it doesn't exist already and open
 	// it.
 	dirSiaPath, err := siaPath.Dir()
@@ -519,6 +535,55 @@ func (fs *FileSystem) RenameFile(oldSiaPath, newSiaPath modules.SiaPath) (err er
 	return sf.managedRename(newSiaPath.Name(), oldDir, newDir)
 }
 
+// ReplaceFile atomically replaces the siafile at dstSiaPath with the siafile at
+// srcSiaPath. The data of the source is kept while the metadata of the
+// destination which is unrelated to the erasure coding and encryption is
+// preserved. Afterwards the siafile at srcSiaPath no longer exists.
+func (fs *FileSystem) ReplaceFile(srcSiaPath, dstSiaPath modules.SiaPath) (err error) {
+	// Open SiaDir and file at the source location.
+	srcDirSiaPath, err := srcSiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	srcDir, err := fs.managedOpenSiaDir(srcDirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, srcDir.Close())
+	}()
+	src, err := srcDir.managedOpenFile(srcSiaPath.Name())
+	if err != nil {
+		return errors.AddContext(err, "failed to open source file")
+	}
+	defer func() {
+		err = errors.Compose(err, src.Close())
+	}()
+
+	// Open SiaDir and file at the destination location.
+	dstDirSiaPath, err := dstSiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	dstDir, err := fs.managedOpenSiaDir(dstDirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dstDir.Close())
+	}()
+	dst, err := dstDir.managedOpenFile(dstSiaPath.Name())
+	if err != nil {
+		return errors.AddContext(err, "failed to open destination file")
+	}
+	defer func() {
+		err = errors.Compose(err, dst.Close())
+	}()
+
+	// Replace the file.
+	return src.managedReplace(dst, srcDir, dstDir)
+}
+
 // RenameDir takes an existing directory and changes the path. The original
 // directory must exist, and there must not be any directory that already has
 // the replacement path.  All sia files within directory will also be renamed
@@ -315,6 +315,9 @@ func (sd *SiaDir) UpdateBubbledMetadata(metadata Metadata) error {
 	sd.mu.Lock()
 	defer sd.mu.Unlock()
 	metadata.Mode = sd.metadata.Mode
+	metadata.MaxVersions = sd.metadata.MaxVersions
+	metadata.VersionRetentionDays = sd.metadata.VersionRetentionDays
+	metadata.UploadPolicy = sd.metadata.UploadPolicy
 	metadata.Version = sd.metadata.Version
 	return sd.updateMetadata(metadata)
 }
@@ -330,6 +333,27 @@ func (sd *SiaDir) UpdateLastHealthCheckTime(aggregateLastHealthCheckTime, lastHe
 	return sd.updateMetadata(md)
 }
 
+// UpdateVersioning updates the versioning settings of the SiaDir and saves the
+// changes to disk.
+func (sd *SiaDir) UpdateVersioning(maxVersions, retentionDays uint64) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.MaxVersions = maxVersions
+	md.VersionRetentionDays = retentionDays
+	return sd.updateMetadata(md)
+}
+
+// UpdateUploadPolicy updates the upload policy of the SiaDir and saves the
+// changes to disk.
+func (sd *SiaDir) UpdateUploadPolicy(policy modules.UploadPolicy) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.UploadPolicy = policy
+	return sd.updateMetadata(md)
+}
+
 // UpdateMetadata updates the SiaDir metadata on disk
 func (sd *SiaDir) UpdateMetadata(metadata Metadata) error {
 	sd.mu.Lock()
@@ -368,6 +392,10 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.Size = metadata.Size
 	sd.metadata.StuckHealth = metadata.StuckHealth
 
+	sd.metadata.MaxVersions = metadata.MaxVersions
+	sd.metadata.VersionRetentionDays = metadata.VersionRetentionDays
+	sd.metadata.UploadPolicy = metadata.UploadPolicy
+
 	sd.metadata.Version = metadata.Version
 
 	// Testing check to ensure new fields aren't missed
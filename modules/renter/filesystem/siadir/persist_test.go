@@ -82,6 +82,19 @@ func equalMetadatas(md, md2 Metadata) error {
 		return fmt.Errorf("StuckHealths not equal, %v and %v", md.StuckHealth, md2.StuckHealth)
 	}
 
+	// Check Versioning Fields
+	if md.MaxVersions != md2.MaxVersions {
+		return fmt.Errorf("MaxVersions not equal, %v and %v", md.MaxVersions, md2.MaxVersions)
+	}
+	if md.VersionRetentionDays != md2.VersionRetentionDays {
+		return fmt.Errorf("VersionRetentionDays not equal, %v and %v", md.VersionRetentionDays, md2.VersionRetentionDays)
+	}
+
+	// Check Upload Policy
+	if md.UploadPolicy != md2.UploadPolicy {
+		return fmt.Errorf("UploadPolicies not equal, %v and %v", md.UploadPolicy, md2.UploadPolicy)
+	}
+
 	return nil
 }
 
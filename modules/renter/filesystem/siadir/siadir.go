@@ -86,6 +86,20 @@ type (
 		Size                uint64      `json:"size"`
 		StuckHealth         float64     `json:"stuckhealth"`
 
+		// The following fields configure the versioning of the siafiles
+		// within the siadir. If either of them is set, overwritten siafiles
+		// are kept as hidden versions instead of being deleted. MaxVersions
+		// is the number of versions that are kept per siafile and
+		// VersionRetentionDays is the number of days a version is kept. A
+		// value of 0 means that there is no limit.
+		MaxVersions          uint64 `json:"maxversions"`
+		VersionRetentionDays uint64 `json:"versionretentiondays"`
+
+		// UploadPolicy contains the upload settings which are used for
+		// uploads into the siadir and its sub siadirs. Unset fields are
+		// inherited from the parent siadir.
+		UploadPolicy modules.UploadPolicy `json:"uploadpolicy"`
+
 		// Version is the used version of the header file.
 		Version string `json:"version"`
 	}
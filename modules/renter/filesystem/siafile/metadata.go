@@ -166,6 +166,12 @@ type (
 		// skyfiles, those skyfiles will be listed here. It should be noted that
 		// a single siafile can be responsible for tracking many skyfiles.
 		Skylinks []string `json:"skylinks"`
+
+		// ContentHash is the hash of the file's plaintext content. It is
+		// computed while the file is uploaded and allows for verifying full
+		// downloads of the file. Files which were uploaded before content
+		// hashes were introduced have an empty ContentHash.
+		ContentHash crypto.Hash `json:"contenthash"`
 	}
 
 	// BubbledMetadata is the metadata of a siafile that gets bubbled
@@ -210,6 +216,33 @@ func (sf *SiaFile) AddSkylink(s modules.Skylink) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// RemoveSkylink will remove a skylink from the SiaFile.
+func (sf *SiaFile) RemoveSkylink(s modules.Skylink) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	skylinks := make([]string, 0, len(sf.staticMetadata.Skylinks))
+	for _, skylink := range sf.staticMetadata.Skylinks {
+		if skylink != s.String() {
+			skylinks = append(skylinks, skylink)
+		}
+	}
+	sf.staticMetadata.Skylinks = skylinks
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // ChangeTime returns the ChangeTime timestamp of the file.
 func (sf *SiaFile) ChangeTime() time.Time {
 	sf.mu.RLock()
@@ -217,6 +250,14 @@ func (sf *SiaFile) ChangeTime() time.Time {
 	return sf.staticMetadata.ChangeTime
 }
 
+// ContentHash returns the hash of the file's plaintext content. An empty hash
+// indicates that the content hash of the file is unknown.
+func (sf *SiaFile) ContentHash() crypto.Hash {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.ContentHash
+}
+
 // PartialChunks returns the partial chunk infos of the siafile.
 func (sf *SiaFile) PartialChunks() []PartialChunkInfo {
 	sf.mu.RLock()
@@ -353,6 +394,7 @@ func (md Metadata) backup() (b Metadata) {
 	b.GroupID = md.GroupID
 	b.ChunkOffset = md.ChunkOffset
 	b.PubKeyTableOffset = md.PubKeyTableOffset
+	b.ContentHash = md.ContentHash
 	// Special handling for slice since reflect.DeepEqual is false when
 	// comparing empty slice to nil.
 	if md.PartialChunks == nil {
@@ -408,6 +450,7 @@ func (md *Metadata) restore(b Metadata) {
 	md.ChunkOffset = b.ChunkOffset
 	md.PubKeyTableOffset = b.PubKeyTableOffset
 	md.Skylinks = b.Skylinks
+	md.ContentHash = b.ContentHash
 	// If the backup was successful it should match the backup.
 	if build.Release == "testing" && !md.equals(b) {
 		fmt.Println("md:\n", md)
@@ -526,6 +569,28 @@ func (sf *SiaFile) SetLastHealthCheckTime() {
 	sf.staticMetadata.LastHealthCheckTime = time.Now()
 }
 
+// SetContentHash sets the hash of the file's plaintext content.
+func (sf *SiaFile) SetContentHash(h crypto.Hash) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.ContentHash = h
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // SetLocalPath changes the local path of the file which is used to repair
 // the file from disk.
 func (sf *SiaFile) SetLocalPath(path string) (err error) {
@@ -561,6 +626,13 @@ func (sf *SiaFile) UpdateUniqueID() {
 	sf.staticMetadata.UniqueID = uniqueID()
 }
 
+// ResetLocalPath removes the local path of the SiaFile without persisting the
+// change. It is meant for siafiles which were created by another renter and
+// haven't been saved to disk yet.
+func (sf *SiaFile) ResetLocalPath() {
+	sf.staticMetadata.LocalPath = ""
+}
+
 // UpdateAccessTime updates the AccessTime timestamp to the current time.
 func (sf *SiaFile) UpdateAccessTime() (err error) {
 	sf.mu.Lock()
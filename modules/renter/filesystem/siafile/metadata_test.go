@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/types"
 	"gitlab.com/NebulousLabs/errors"
@@ -146,6 +147,7 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		if fastrand.Intn(2) == 0 { // 50% chance to be not nil
 			sf.staticMetadata.Skylinks = make([]string, fastrand.Intn(10))
 		}
+		fastrand.Read(sf.staticMetadata.ContentHash[:])
 
 		// Error occurred after changing the fields.
 		return errors.New("")
@@ -155,3 +157,32 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		t.Fatalf("metadata wasn't restored successfully %v %v", mdBefore, sf.staticMetadata)
 	}
 }
+
+// TestSetContentHash tests that setting the content hash of a SiaFile is
+// persisted.
+func TestSetContentHash(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf, wal, _ := newBlankTestFileAndWAL(1)
+	if sf.ContentHash() != (crypto.Hash{}) {
+		t.Fatal("new file shouldn't have a content hash")
+	}
+	h := crypto.HashBytes(fastrand.Bytes(100))
+	if err := sf.SetContentHash(h); err != nil {
+		t.Fatal(err)
+	}
+	if sf.ContentHash() != h {
+		t.Fatal("content hash wasn't set")
+	}
+	// Reload the file and check the content hash.
+	sf, err := LoadSiaFile(sf.SiaFilePath(), wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.ContentHash() != h {
+		t.Fatal("content hash wasn't persisted")
+	}
+}
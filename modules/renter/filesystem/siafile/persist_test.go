@@ -476,6 +476,88 @@ func TestRename(t *testing.T) {
 	}
 }
 
+// TestReplace tests replacing a SiaFile with a SiaFile of the same size but
+// with a different erasure code.
+func TestReplace(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create the file which is going to be replaced.
+	dst, wal, _ := newBlankTestFileAndWAL(1)
+	dstPath := dst.SiaFilePath()
+
+	// Create a file of the same size with a different erasure code.
+	rc, err := modules.NewRSSubCode(4, 8, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcPath := strings.TrimSuffix(dstPath, modules.SiaFileExtension) + "_reencoded" + modules.SiaFileExtension
+	src, err := New(srcPath, "", wal, rc, crypto.GenerateSiaKey(crypto.TypeDefaultRenter), dst.Size(), dst.Mode(), nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for chunkIndex := uint64(0); chunkIndex < src.NumChunks(); chunkIndex++ {
+		pk := types.SiaPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+		if err := src.AddPiece(pk, chunkIndex, 0, crypto.Hash{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srcUID := src.UID()
+
+	// Replacing a file of a different size should fail.
+	other := newBlankTestFile()
+	if other.Size() != dst.Size() {
+		if err := other.Replace(dst); err == nil {
+			t.Fatal("expected replacing a file of a different size to fail")
+		}
+	}
+
+	// Replace the file.
+	if err := src.Replace(dst); err != nil {
+		t.Fatal(err)
+	}
+	if !dst.Deleted() || src.Deleted() {
+		t.Fatal("expected only the replaced file to be deleted")
+	}
+	if src.SiaFilePath() != dstPath {
+		t.Fatal("file wasn't moved to the path of the replaced file", src.SiaFilePath())
+	}
+	if _, err := os.Stat(srcPath); !os.IsNotExist(err) {
+		t.Fatal("expected the file to be removed from its old path", err)
+	}
+
+	// Load the file from disk and compare it.
+	loaded, err := LoadSiaFile(dstPath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.UID() != srcUID {
+		t.Fatal("loaded file has the wrong uid")
+	}
+	if loaded.ErasureCode().Identifier() != rc.Identifier() {
+		t.Fatal("loaded file has the wrong erasure code")
+	}
+	if loaded.NumChunks() != src.NumChunks() {
+		t.Fatal("loaded file has the wrong number of chunks", loaded.NumChunks(), src.NumChunks())
+	}
+	for chunkIndex := uint64(0); chunkIndex < loaded.NumChunks(); chunkIndex++ {
+		pieces, err := loaded.Pieces(chunkIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pieces[0]) != 1 {
+			t.Fatal("loaded chunk is missing its piece", chunkIndex)
+		}
+	}
+
+	// A deleted file can't be replaced.
+	if err := src.Replace(dst); !errors.Contains(err, ErrDeleted) {
+		t.Fatal("expected ErrDeleted", err)
+	}
+}
+
 // TestApplyUpdates tests a variety of functions that are used to apply
 // updates.
 func TestApplyUpdates(t *testing.T) {
@@ -484,6 +484,72 @@ func (sf *SiaFile) Delete() (err error) {
 	return err
 }
 
+// Replace atomically replaces the file dst with sf. The data of sf is kept
+// while the metadata of dst which is unrelated to the erasure coding and
+// encryption of the data is copied over. This allows for changing the
+// redundancy of a file by uploading its data to a new file first. Afterwards
+// sf is located at the path of dst and dst is marked as deleted. Both files
+// need to be of the same size.
+func (sf *SiaFile) Replace(dst *SiaFile) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	// Can't replace or be replaced by a deleted file.
+	if sf.deleted || dst.deleted {
+		return errors.AddContext(ErrDeleted, "can't replace deleted file")
+	}
+	if sf.staticMetadata.FileSize != dst.staticMetadata.FileSize {
+		return fmt.Errorf("can't replace file of size %v with file of size %v", dst.staticMetadata.FileSize, sf.staticMetadata.FileSize)
+	}
+	// Backup the changed metadata before changing it. Revert the change on
+	// error.
+	oldPath := sf.siaFilePath
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+			sf.siaFilePath = oldPath
+		}
+	}(sf.staticMetadata.backup())
+	// Load all the chunks.
+	chunks := make([]chunk, 0, sf.numChunks)
+	err = sf.iterateChunksReadonly(func(chunk chunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Copy over the metadata of dst.
+	dstMD := dst.staticMetadata.backup()
+	sf.staticMetadata.LocalPath = dstMD.LocalPath
+	sf.staticMetadata.ModTime = dstMD.ModTime
+	sf.staticMetadata.AccessTime = dstMD.AccessTime
+	sf.staticMetadata.CreateTime = dstMD.CreateTime
+	sf.staticMetadata.ChangeTime = time.Now()
+	sf.staticMetadata.Mode = dstMD.Mode
+	sf.staticMetadata.UserID = dstMD.UserID
+	sf.staticMetadata.GroupID = dstMD.GroupID
+	sf.staticMetadata.Skylinks = dstMD.Skylinks
+	// Delete both files and write sf to the location of dst.
+	updates := []writeaheadlog.Update{dst.createDeleteUpdate(), sf.createDeleteUpdate()}
+	sf.siaFilePath = dst.siaFilePath
+	headerUpdates, err := sf.saveHeaderUpdates()
+	if err != nil {
+		return err
+	}
+	updates = append(updates, headerUpdates...)
+	for _, chunk := range chunks {
+		updates = append(updates, sf.saveChunkUpdate(chunk))
+	}
+	err = createAndApplyTransaction(sf.wal, updates...)
+	if err != nil {
+		return err
+	}
+	dst.deleted = true
+	return nil
+}
+
 // Deleted indicates if this file has been deleted by the user.
 func (sf *SiaFile) Deleted() bool {
 	sf.mu.RLock()
@@ -3,9 +3,13 @@
 package renter
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"io/ioutil"
 	"math"
+	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -17,6 +21,17 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 )
 
+const (
+	// fuseSpoolDir is the name of the directory within the renter's persist
+	// dir which contains the spools of the fuse files which are being written.
+	fuseSpoolDir = "fusespool"
+
+	// fuseSpoolSiaPathSuffix is the suffix of the file next to a spool which
+	// contains the siapath of the spool's file. It is written when uploading
+	// the spool failed so that the upload can be retried after a restart.
+	fuseSpoolSiaPathSuffix = ".siapath"
+)
+
 // fuseDirnode is a fuse node for the fs package that covers a siadir.
 //
 // NOTE: The fuseDirnode is _very_hot_ in that it gets hit rapidly and
@@ -38,6 +53,8 @@ type fuseDirnode struct {
 // NodeAccesser is necessary for telling certain programs that it is okay to
 // access the file.
 //
+// NodeCreater is necessary for creating new files in the directory.
+//
 // NodeFlusher is necessary for cleaning up resources such as the filesystem
 // node.
 //
@@ -46,29 +63,57 @@ type fuseDirnode struct {
 //
 // NodeLookuper is necessary to have files added to the filesystem tree.
 //
+// NodeMkdirer is necessary for creating subdirectories.
+//
 // NodeReaddirer is necessary to list the files in a directory.
 //
+// NodeRenamer is necessary for moving files and directories.
+//
+// NodeRmdirer is necessary for deleting empty subdirectories.
+//
 // NodeStatfser is necessary to provide information about the filesystem that
 // contains the directory.
+//
+// NodeUnlinker is necessary for deleting files.
 var _ = (fs.NodeAccesser)((*fuseDirnode)(nil))
+var _ = (fs.NodeCreater)((*fuseDirnode)(nil))
 var _ = (fs.NodeFlusher)((*fuseDirnode)(nil))
 var _ = (fs.NodeGetattrer)((*fuseDirnode)(nil))
 var _ = (fs.NodeLookuper)((*fuseDirnode)(nil))
+var _ = (fs.NodeMkdirer)((*fuseDirnode)(nil))
 var _ = (fs.NodeReaddirer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRenamer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRmdirer)((*fuseDirnode)(nil))
 var _ = (fs.NodeStatfser)((*fuseDirnode)(nil))
+var _ = (fs.NodeUnlinker)((*fuseDirnode)(nil))
 
 // fuseFilenode is a fuse node for the fs package that covers a siafile.
 //
 // Data is fetched using a download streamer. This download streamer needs to be
 // closed when the filehandle is released.
+//
+// Writes are staged in a local spool file which is uploaded when the file is
+// flushed or released. Uploading the spool replaces the siafile, which is why
+// the fileNode isn't static. Creating, writing and uploading the spool is
+// serialized by ioMu, which is held while data is transferred over the
+// network and which also protects dirty. The spool and the fileNode are only
+// changed while both ioMu and spoolMu are held, which means that they can be
+// read while holding either of them. spoolMu is never held during network
+// I/O, so that Getattr isn't blocked by uploads. The locks need to be acquired
+// in the order mu, ioMu, spoolMu.
 type fuseFilenode struct {
 	atomicClosed uint32
 
 	fs.Inode
 	staticFilesystem *fuseFS
-	staticFileNode   *filesystem.FileNode
 	stream           modules.Streamer
 	mu               sync.Mutex
+
+	fileNode *filesystem.FileNode
+	spool    *os.File
+	dirty    bool
+	ioMu     sync.Mutex
+	spoolMu  sync.Mutex
 }
 
 // Ensure the file nodes satisfy the required interfaces.
@@ -85,14 +130,24 @@ type fuseFilenode struct {
 //
 // NodeReader is necessary for reading files.
 //
+// NodeReleaser is necessary for uploading the staged writes and removing the
+// spool once the file handle is released.
+//
+// NodeSetattrer is necessary for truncating files.
+//
 // NodeStatfser is necessary to provide information about the filesystem that
 // contains the file.
+//
+// NodeWriter is necessary for writing files.
 var _ = (fs.NodeAccesser)((*fuseFilenode)(nil))
 var _ = (fs.NodeFlusher)((*fuseFilenode)(nil))
 var _ = (fs.NodeGetattrer)((*fuseFilenode)(nil))
 var _ = (fs.NodeOpener)((*fuseFilenode)(nil))
 var _ = (fs.NodeReader)((*fuseFilenode)(nil))
+var _ = (fs.NodeReleaser)((*fuseFilenode)(nil))
+var _ = (fs.NodeSetattrer)((*fuseFilenode)(nil))
 var _ = (fs.NodeStatfser)((*fuseFilenode)(nil))
+var _ = (fs.NodeWriter)((*fuseFilenode)(nil))
 
 // fuseRoot is the root directory for a mounted fuse filesystem.
 type fuseFS struct {
@@ -107,12 +162,194 @@ type fuseFS struct {
 func errToStatus(err error) syscall.Errno {
 	if err == nil {
 		return syscall.F_OK
-	} else if errors.IsOSNotExist(err) {
+	} else if errors.IsOSNotExist(err) || errors.Contains(err, filesystem.ErrNotExist) {
 		return syscall.ENOENT
+	} else if errors.Contains(err, filesystem.ErrExists) {
+		return syscall.EEXIST
 	}
 	return syscall.EIO
 }
 
+// managedFileNode returns the current file node of the file.
+func (ffn *fuseFilenode) managedFileNode() *filesystem.FileNode {
+	ffn.spoolMu.Lock()
+	defer ffn.spoolMu.Unlock()
+	return ffn.fileNode
+}
+
+// managedSiaPath returns the current siapath of the file.
+func (ffn *fuseFilenode) managedSiaPath() modules.SiaPath {
+	return ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.managedFileNode())
+}
+
+// childSiaPath returns the siapath of the child of the directory with the
+// given name.
+func (fdn *fuseDirnode) childSiaPath(name string) (modules.SiaPath, error) {
+	return fdn.staticFilesystem.renter.staticFileSystem.DirSiaPath(fdn.staticDirNode).Join(name)
+}
+
+// prepareSpool creates the spool of the file if it doesn't exist yet. Unless
+// truncate is set, the current content of the file is downloaded into the new
+// spool. An existing spool is emptied if truncate is set. The ioMu needs to be
+// held.
+func (ffn *fuseFilenode) prepareSpool(truncate bool) (err error) {
+	if ffn.spool != nil {
+		if truncate {
+			return ffn.spool.Truncate(0)
+		}
+		return nil
+	}
+	r := ffn.staticFilesystem.renter
+	spool, err := ioutil.TempFile(filepath.Join(r.persistDir, fuseSpoolDir), "")
+	if err != nil {
+		return errors.AddContext(err, "unable to create spool")
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, spool.Close(), os.Remove(spool.Name()))
+		}
+	}()
+	// The spool has the mode of the file but always remains accessible to the
+	// renter since it might need to be uploaded after a restart.
+	err = spool.Chmod(ffn.fileNode.Mode().Perm() | 0600)
+	if err != nil {
+		return errors.AddContext(err, "unable to set mode of spool")
+	}
+	if !truncate {
+		stream, err := r.StreamerByNode(ffn.fileNode, false)
+		if err != nil {
+			return errors.AddContext(err, "unable to get stream for file")
+		}
+		_, err = io.Copy(spool, stream)
+		err = errors.Compose(err, stream.Close())
+		if err != nil {
+			return errors.AddContext(err, "unable to download file into spool")
+		}
+	}
+	ffn.spoolMu.Lock()
+	ffn.spool = spool
+	ffn.spoolMu.Unlock()
+	return nil
+}
+
+// commitSpool uploads the spool of the file if it contains writes which
+// weren't uploaded yet. The uploaded spool replaces the siafile and the file
+// node is updated to the node of the new siafile. If the upload fails, the
+// siapath of the file is stored next to the spool and an alert is registered
+// until the spool is uploaded. The ioMu needs to be held.
+func (ffn *fuseFilenode) commitSpool() error {
+	if ffn.spool == nil || !ffn.dirty {
+		return nil
+	}
+	r := ffn.staticFilesystem.renter
+	siaPath := r.staticFileSystem.FileSiaPath(ffn.fileNode)
+	_, err := ffn.spool.Seek(0, io.SeekStart)
+	if err != nil {
+		return errors.AddContext(err, "unable to seek to start of spool")
+	}
+	up := modules.FileUploadParams{
+		SiaPath:     siaPath,
+		ErasureCode: ffn.fileNode.ErasureCode(),
+		Force:       true,
+		Mode:        ffn.fileNode.Mode(),
+	}
+	fileNode, err := r.callUploadStreamFromReader(up, ffn.spool)
+	if err != nil {
+		err = errors.AddContext(err, "unable to upload spool")
+		return errors.Compose(err, r.managedFailedFuseSpool(ffn.spool.Name(), siaPath, err))
+	}
+
+	// A previous upload of the spool might have failed.
+	err = r.managedClearFailedFuseSpool(ffn.spool.Name())
+	if err != nil {
+		err = errors.Compose(err, fileNode.Close())
+		return errors.AddContext(err, "unable to clear failed upload of spool")
+	}
+
+	// Swap the nodes. If the file was flushed already, nothing else is going
+	// to close the new node so it is closed right away. Closed nodes can
+	// still be used to fetch information about the file.
+	ffn.spoolMu.Lock()
+	oldNode := ffn.fileNode
+	ffn.fileNode = fileNode
+	ffn.dirty = false
+	ffn.spoolMu.Unlock()
+	if atomic.LoadUint32(&ffn.atomicClosed) == 1 {
+		return fileNode.Close()
+	}
+	return oldNode.Close()
+}
+
+// removeSpool closes and deletes the spool of the file. The ioMu needs to be
+// held.
+func (ffn *fuseFilenode) removeSpool() error {
+	if ffn.spool == nil {
+		return nil
+	}
+	ffn.spoolMu.Lock()
+	spool := ffn.spool
+	ffn.spool = nil
+	ffn.dirty = false
+	ffn.spoolMu.Unlock()
+	return errors.Compose(spool.Close(), ffn.staticFilesystem.renter.managedRemoveFuseSpool(spool.Name()))
+}
+
+// managedFailedFuseSpool stores the siapath next to a spool which couldn't be
+// uploaded and registers an alert for it.
+func (r *Renter) managedFailedFuseSpool(spool string, siaPath modules.SiaPath, uploadErr error) error {
+	r.staticAlerter.RegisterAlert(modules.AlertIDRenterFuseUploadFailed(filepath.Base(spool)), AlertMSGFuseUploadFailed,
+		AlertCauseFuseUploadFailed(siaPath, uploadErr), modules.SeverityError)
+	err := ioutil.WriteFile(spool+fuseSpoolSiaPathSuffix, []byte(siaPath.String()), modules.DefaultFilePerm)
+	return errors.AddContext(err, "unable to store the siapath of the spool")
+}
+
+// managedClearFailedFuseSpool deletes the siapath stored next to a spool by a
+// failed upload and unregisters its alert.
+func (r *Renter) managedClearFailedFuseSpool(spool string) error {
+	r.staticAlerter.UnregisterAlert(modules.AlertIDRenterFuseUploadFailed(filepath.Base(spool)))
+	err := os.Remove(spool + fuseSpoolSiaPathSuffix)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// managedRemoveFuseSpool deletes a spool together with its siapath and
+// unregisters its alert.
+func (r *Renter) managedRemoveFuseSpool(spool string) error {
+	return errors.Compose(r.managedClearFailedFuseSpool(spool), os.Remove(spool))
+}
+
+// managedUploadFuseSpool uploads a spool which was left behind after its
+// upload failed and deletes it afterwards. The spool keeps the erasure code and
+// mode of the siafile it replaces.
+func (r *Renter) managedUploadFuseSpool(spool string, siaPath modules.SiaPath) (err error) {
+	f, err := os.Open(spool)
+	if err != nil {
+		return errors.AddContext(err, "unable to open spool")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	up := modules.FileUploadParams{
+		SiaPath: siaPath,
+		Force:   true,
+	}
+	if oldNode, err := r.staticFileSystem.OpenSiaFile(siaPath); err == nil {
+		up.ErasureCode = oldNode.ErasureCode()
+		up.Mode = oldNode.Mode()
+		if err := oldNode.Close(); err != nil {
+			return err
+		}
+	}
+	fileNode, err := r.callUploadStreamFromReader(up, f)
+	if err != nil {
+		err = errors.AddContext(err, "unable to upload spool")
+		return errors.Compose(err, r.managedFailedFuseSpool(spool, siaPath, err))
+	}
+	return errors.Compose(fileNode.Close(), r.managedRemoveFuseSpool(spool))
+}
+
 // Access reports whether a directory can be accessed by the caller.
 func (fdn *fuseDirnode) Access(ctx context.Context, mask uint32) syscall.Errno {
 	// TODO: parse the mask and return a more correct value instead of always
@@ -137,14 +374,24 @@ func (fdn *fuseDirnode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Err
 	return errToStatus(err)
 }
 
-// Flush is called when a file is being closed.
+// Flush is called when a file is being closed. Writes which weren't uploaded
+// yet are uploaded.
 func (ffn *fuseFilenode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Errno {
+	ffn.mu.Lock()
+	defer ffn.mu.Unlock()
+	ffn.ioMu.Lock()
+	defer ffn.ioMu.Unlock()
+
+	// Upload the spool.
+	commitErr := ffn.commitSpool()
 	swapped := atomic.CompareAndSwapUint32(&ffn.atomicClosed, 0, 1)
 	if !swapped {
-		return errToStatus(nil)
+		if commitErr != nil {
+			siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.fileNode)
+			ffn.staticFilesystem.renter.log.Printf("error when flushing fuse file %v: %v", siaPath, commitErr)
+		}
+		return errToStatus(commitErr)
 	}
-	ffn.mu.Lock()
-	defer ffn.mu.Unlock()
 
 	// If a stream was opened for the file, the stream must now be closed.
 	var streamErr error
@@ -155,10 +402,10 @@ func (ffn *fuseFilenode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Er
 	}
 
 	// Check all of the errors.
-	closeErr := ffn.staticFileNode.Close()
-	err := errors.Compose(streamErr, closeErr)
+	closeErr := ffn.fileNode.Close()
+	err := errors.Compose(commitErr, streamErr, closeErr)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.fileNode)
 		ffn.staticFilesystem.renter.log.Printf("error when flushing fuse file %v: %v", siaPath, err)
 		return errToStatus(err)
 	}
@@ -181,7 +428,7 @@ func (fdn *fuseDirnode) Lookup(ctx context.Context, name string, out *fuse.Entry
 		// Convert the file to an inode.
 		filenode := &fuseFilenode{
 			staticFilesystem: fdn.staticFilesystem,
-			staticFileNode:   fileNode,
+			fileNode:         fileNode,
 		}
 		attrs := fs.StableAttr{
 			Ino:  fileInfo.UID,
@@ -244,10 +491,22 @@ func (fdn *fuseDirnode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse
 // Getattr should try to minimize lock contention and should run very quickly if
 // possible.
 func (ffn *fuseFilenode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
-	fileInfo, err := ffn.staticFilesystem.renter.staticFileSystem.FileNodeInfo(ffn.staticFileNode)
+	ffn.spoolMu.Lock()
+	fileInfo, err := ffn.staticFilesystem.renter.staticFileSystem.FileNodeInfo(ffn.fileNode)
 	if err != nil {
 		ffn.staticFilesystem.renter.log.Printf("Unable to fetch info from file: %v", err)
 	}
+	// If the file is being written, the size of the spool is the size of the
+	// file.
+	if ffn.spool != nil {
+		stat, err := ffn.spool.Stat()
+		if err != nil {
+			ffn.staticFilesystem.renter.log.Printf("Unable to fetch info from spool: %v", err)
+		} else {
+			fileInfo.Filesize = uint64(stat.Size())
+		}
+	}
+	ffn.spoolMu.Unlock()
 
 	out.Size = fileInfo.Filesize
 	out.Mode = uint32(fileInfo.Mode()) | syscall.S_IFREG
@@ -264,10 +523,27 @@ func (ffn *fuseFilenode) Open(ctx context.Context, flags uint32) (fs.FileHandle,
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
 
-	stream, err := ffn.staticFilesystem.renter.StreamerByNode(ffn.staticFileNode, false)
+	// Prepare the spool if the file is opened for writing.
+	if flags&(syscall.O_WRONLY|syscall.O_RDWR) != 0 {
+		if ffn.staticFilesystem.options.ReadOnly {
+			return nil, 0, syscall.EROFS
+		}
+		truncate := flags&syscall.O_TRUNC != 0
+		ffn.ioMu.Lock()
+		err := ffn.prepareSpool(truncate)
+		if err == nil && truncate {
+			ffn.dirty = true
+		}
+		ffn.ioMu.Unlock()
+		if err != nil {
+			ffn.staticFilesystem.renter.log.Printf("Unable to prepare spool for file %v: %v", ffn.managedSiaPath(), err)
+			return nil, 0, errToStatus(err)
+		}
+	}
+
+	stream, err := ffn.staticFilesystem.renter.StreamerByNode(ffn.managedFileNode(), false)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
-		ffn.staticFilesystem.renter.log.Printf("Unable to get stream for file %v: %v", siaPath, err)
+		ffn.staticFilesystem.renter.log.Printf("Unable to get stream for file %v: %v", ffn.managedSiaPath(), err)
 		return nil, 0, errToStatus(err)
 	}
 	ffn.stream = stream
@@ -284,9 +560,32 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
 
+	// If the file is being written, the data is read from the spool.
+	ffn.spoolMu.Lock()
+	if ffn.spool != nil {
+		n, err := ffn.spool.ReadAt(dest, offset)
+		ffn.spoolMu.Unlock()
+		if err != nil && !errors.Contains(err, io.EOF) {
+			ffn.staticFilesystem.renter.log.Printf("Error reading spool from offset %v during call to Read in file %s: %v", offset, ffn.managedSiaPath(), err)
+			return nil, errToStatus(err)
+		}
+		return fuse.ReadResultData(dest[:n]), errToStatus(nil)
+	}
+	ffn.spoolMu.Unlock()
+
+	// Files which were created through fuse don't have a stream yet.
+	if ffn.stream == nil {
+		stream, err := ffn.staticFilesystem.renter.StreamerByNode(ffn.managedFileNode(), false)
+		if err != nil {
+			ffn.staticFilesystem.renter.log.Printf("Unable to get stream for file %v: %v", ffn.managedSiaPath(), err)
+			return nil, errToStatus(err)
+		}
+		ffn.stream = stream
+	}
+
 	_, err := ffn.stream.Seek(offset, io.SeekStart)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+		siaPath := ffn.managedSiaPath()
 		ffn.staticFilesystem.renter.log.Printf("Error seeking to offset %v during call to Read in file %s: %v", offset, siaPath.String(), err)
 		return nil, errToStatus(err)
 	}
@@ -297,7 +596,7 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 	// often dropping parts of the tail of the file.
 	n, err := io.ReadFull(ffn.stream, dest)
 	if err != nil && !errors.Contains(err, io.EOF) && err != io.ErrUnexpectedEOF {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+		siaPath := ffn.managedSiaPath()
 		ffn.staticFilesystem.renter.log.Printf("Error reading from offset %v during call to Read in file %s: %v", offset, siaPath.String(), err)
 		return nil, errToStatus(err)
 	}
@@ -338,6 +637,248 @@ func (fdn *fuseDirnode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errn
 	return fs.NewListDirStream(dirEntries), errToStatus(nil)
 }
 
+// Create creates a new file in the directory and opens it for writing. An
+// empty siafile is uploaded right away to make the file visible to other
+// programs.
+func (fdn *fuseDirnode) Create(ctx context.Context, name string, flags uint32, mode uint32, out *fuse.EntryOut) (*fs.Inode, fs.FileHandle, uint32, syscall.Errno) {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return nil, nil, 0, syscall.EROFS
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return nil, nil, 0, syscall.EINVAL
+	}
+	up := modules.FileUploadParams{
+		SiaPath: siaPath,
+		Mode:    os.FileMode(mode).Perm(),
+	}
+	fileNode, err := r.callUploadStreamFromReader(up, bytes.NewReader(nil))
+	if err != nil {
+		r.log.Printf("Unable to create fuse file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(err)
+	}
+	fileInfo, err := r.staticFileSystem.FileNodeInfo(fileNode)
+	if err != nil {
+		r.log.Printf("Unable to fetch fileinfo on new file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(errors.Compose(err, fileNode.Close()))
+	}
+
+	// The new file is empty so its spool starts out empty as well.
+	filenode := &fuseFilenode{
+		staticFilesystem: fdn.staticFilesystem,
+		fileNode:         fileNode,
+	}
+	err = filenode.prepareSpool(true)
+	if err != nil {
+		r.log.Printf("Unable to prepare spool for new file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(errors.Compose(err, fileNode.Close()))
+	}
+	attrs := fs.StableAttr{
+		Ino:  fileInfo.UID,
+		Mode: fuse.S_IFREG,
+	}
+	out.Ino = fileInfo.UID
+	out.Size = fileInfo.Filesize
+	out.Mode = uint32(fileInfo.Mode())
+	inode := fdn.NewInode(ctx, filenode, attrs)
+	return inode, filenode, 0, errToStatus(nil)
+}
+
+// Mkdir creates a new subdirectory in the directory.
+func (fdn *fuseDirnode) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return nil, syscall.EROFS
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return nil, syscall.EINVAL
+	}
+	err = r.CreateDir(siaPath, os.FileMode(mode).Perm())
+	if err != nil {
+		r.log.Printf("Unable to create fuse dir %v: %v", siaPath, err)
+		return nil, errToStatus(err)
+	}
+	childDir, err := fdn.staticDirNode.Dir(name)
+	if err != nil {
+		r.log.Printf("Unable to open new fuse dir %v: %v", siaPath, err)
+		return nil, errToStatus(err)
+	}
+	dirInfo, err := r.staticFileSystem.DirNodeInfo(childDir)
+	if err != nil {
+		r.log.Printf("Unable to fetch info from new dir %v: %v", siaPath, err)
+		return nil, errToStatus(errors.Compose(err, childDir.Close()))
+	}
+	dirnode := &fuseDirnode{
+		staticDirNode:    childDir,
+		staticFilesystem: fdn.staticFilesystem,
+	}
+	attrs := fs.StableAttr{
+		Ino:  dirInfo.UID,
+		Mode: fuse.S_IFDIR,
+	}
+	out.Ino = dirInfo.UID
+	out.Mode = uint32(dirInfo.Mode())
+	inode := fdn.NewInode(ctx, dirnode, attrs)
+	return inode, errToStatus(nil)
+}
+
+// Release is called when a file handle is released. Writes which weren't
+// uploaded yet are uploaded and the spool is removed. If the upload fails,
+// the spool is kept to retry the upload the next time the file is flushed or
+// when the renter is restarted.
+func (ffn *fuseFilenode) Release(ctx context.Context, f fs.FileHandle) syscall.Errno {
+	ffn.ioMu.Lock()
+	defer ffn.ioMu.Unlock()
+	err := ffn.commitSpool()
+	if err == nil {
+		err = ffn.removeSpool()
+	}
+	if err != nil {
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.fileNode)
+		ffn.staticFilesystem.renter.log.Printf("error when releasing fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	return errToStatus(nil)
+}
+
+// Rename moves a file or subdirectory of the directory to the provided
+// directory. An existing file at the destination is overwritten.
+func (fdn *fuseDirnode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+	newDir, ok := newParent.(*fuseDirnode)
+	if !ok {
+		return syscall.EXDEV
+	}
+	r := fdn.staticFilesystem.renter
+	oldSiaPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	newSiaPath, err := newDir.childSiaPath(newName)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	err = r.OverwriteFile(oldSiaPath, newSiaPath)
+	if errors.IsOSNotExist(err) || errors.Contains(err, filesystem.ErrNotExist) {
+		err = r.RenameDir(oldSiaPath, newSiaPath)
+	}
+	if err != nil {
+		r.log.Printf("Unable to rename %v to %v: %v", oldSiaPath, newSiaPath, err)
+		return errToStatus(err)
+	}
+	return errToStatus(nil)
+}
+
+// Rmdir deletes an empty subdirectory of the directory.
+func (fdn *fuseDirnode) Rmdir(ctx context.Context, name string) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	childDir, err := fdn.staticDirNode.Dir(name)
+	if err != nil {
+		return errToStatus(err)
+	}
+	fileinfos, dirinfos, err := r.staticFileSystem.CachedListOnNode(childDir)
+	err = errors.Compose(err, childDir.Close())
+	if err != nil {
+		r.log.Printf("Unable to get file and directory list for fuse directory %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	// The first directory is always the directory itself.
+	if len(fileinfos) > 0 || len(dirinfos) > 1 {
+		return syscall.ENOTEMPTY
+	}
+	err = r.DeleteDir(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to delete fuse dir %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	return errToStatus(nil)
+}
+
+// Setattr changes the attributes of a file. Only changing the size of the
+// file is supported. The spool is truncated and uploaded when the file is
+// flushed or, if the file isn't open, right away.
+func (ffn *fuseFilenode) Setattr(ctx context.Context, f fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	if size, ok := in.GetSize(); ok {
+		if ffn.staticFilesystem.options.ReadOnly {
+			return syscall.EROFS
+		}
+		ffn.ioMu.Lock()
+		err := ffn.prepareSpool(size == 0)
+		if err == nil {
+			err = ffn.spool.Truncate(int64(size))
+		}
+		if err == nil {
+			ffn.dirty = true
+		}
+		if err == nil && f == nil {
+			err = ffn.commitSpool()
+			if err == nil {
+				err = ffn.removeSpool()
+			}
+		}
+		ffn.ioMu.Unlock()
+		if err != nil {
+			ffn.staticFilesystem.renter.log.Printf("Unable to truncate fuse file %v: %v", ffn.managedSiaPath(), err)
+			return errToStatus(err)
+		}
+	}
+	return ffn.Getattr(ctx, f, out)
+}
+
+// Unlink deletes a file of the directory.
+func (fdn *fuseDirnode) Unlink(ctx context.Context, name string) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+	r := fdn.staticFilesystem.renter
+	siaPath, err := fdn.childSiaPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	err = r.DeleteFile(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to delete fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	return errToStatus(nil)
+}
+
+// Write writes data to the spool of the file.
+func (ffn *fuseFilenode) Write(ctx context.Context, f fs.FileHandle, data []byte, offset int64) (uint32, syscall.Errno) {
+	if ffn.staticFilesystem.options.ReadOnly {
+		return 0, syscall.EROFS
+	}
+	ffn.ioMu.Lock()
+	defer ffn.ioMu.Unlock()
+	err := ffn.prepareSpool(false)
+	if err != nil {
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.fileNode)
+		ffn.staticFilesystem.renter.log.Printf("Unable to prepare spool for file %v: %v", siaPath, err)
+		return 0, errToStatus(err)
+	}
+	n, err := ffn.spool.WriteAt(data, offset)
+	if n > 0 {
+		ffn.dirty = true
+	}
+	if err != nil {
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.fileNode)
+		ffn.staticFilesystem.renter.log.Printf("Error writing to offset %v during call to Write in file %s: %v", offset, siaPath, err)
+		return uint32(n), errToStatus(err)
+	}
+	return uint32(n), errToStatus(nil)
+}
+
 // setStatfsOut is a method that will set the StatfsOut fields which are
 // consistent across the fuse filesystem.
 func (ffs *fuseFS) setStatfsOut(out *fuse.StatfsOut) error {
@@ -401,7 +942,7 @@ func (fdn *fuseDirnode) Statfs(ctx context.Context, out *fuse.StatfsOut) syscall
 func (ffn *fuseFilenode) Statfs(ctx context.Context, out *fuse.StatfsOut) syscall.Errno {
 	err := ffn.staticFilesystem.setStatfsOut(out)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+		siaPath := ffn.managedSiaPath()
 		ffn.staticFilesystem.renter.log.Printf("Error fetching statfs for fuse file %v: %v", siaPath, err)
 		return errToStatus(err)
 	}
@@ -4,7 +4,12 @@ package renter
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/hanwen/go-fuse/v2/fs"
 	"github.com/hanwen/go-fuse/v2/fuse"
@@ -30,6 +35,16 @@ func newFuseManager(r *Renter) renterFuseManager {
 		renter:      r,
 	}
 
+	// Remove the spools which were left behind by an unclean shutdown and
+	// retry the uploads of the spools which failed to upload.
+	spools, err := r.managedLoadFuseSpools()
+	if err != nil {
+		r.log.Println("Unable to load fuse spools:", err)
+	}
+	if len(spools) > 0 {
+		go r.threadedUploadFuseSpools(spools)
+	}
+
 	// Close the fuse manager on shutdown.
 	r.tg.OnStop(func() error {
 		return fm.managedCloseFuseManager()
@@ -38,6 +53,76 @@ func newFuseManager(r *Renter) renterFuseManager {
 	return fm
 }
 
+// managedLoadFuseSpools removes the spools in the fuse spool dir which weren't
+// written to a siapath and returns the siapaths of the remaining spools. These
+// spools contain writes which couldn't be uploaded before the renter shut
+// down.
+func (r *Renter) managedLoadFuseSpools() (map[string]modules.SiaPath, error) {
+	dir := filepath.Join(r.persistDir, fuseSpoolDir)
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read fuse spool dir")
+	}
+	// Collect the siapaths of the failed spools first.
+	spools := make(map[string]modules.SiaPath)
+	for _, fi := range fis {
+		name := fi.Name()
+		if !strings.HasSuffix(name, fuseSpoolSiaPathSuffix) {
+			continue
+		}
+		spool := filepath.Join(dir, strings.TrimSuffix(name, fuseSpoolSiaPathSuffix))
+		b, readErr := ioutil.ReadFile(filepath.Join(dir, name))
+		var siaPath modules.SiaPath
+		if readErr == nil {
+			readErr = siaPath.LoadString(string(b))
+		}
+		if readErr != nil {
+			err = errors.Compose(err, readErr)
+			continue
+		}
+		spools[spool] = siaPath
+		r.staticAlerter.RegisterAlert(modules.AlertIDRenterFuseUploadFailed(filepath.Base(spool)), AlertMSGFuseUploadFailed,
+			AlertCauseFuseUploadFailed(siaPath, errors.New("renter was shut down")), modules.SeverityError)
+	}
+	// Remove all the other files.
+	for _, fi := range fis {
+		name := fi.Name()
+		if _, exists := spools[filepath.Join(dir, strings.TrimSuffix(name, fuseSpoolSiaPathSuffix))]; exists {
+			continue
+		}
+		err = errors.Compose(err, os.Remove(filepath.Join(dir, name)))
+	}
+	return spools, err
+}
+
+// threadedUploadFuseSpools periodically tries to upload the spools which were
+// left behind by failed uploads until all of them are uploaded.
+func (r *Renter) threadedUploadFuseSpools(spools map[string]modules.SiaPath) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for len(spools) > 0 {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(fuseSpoolRetryInterval):
+		}
+		for spool, siaPath := range spools {
+			err := r.managedUploadFuseSpool(spool, siaPath)
+			if err != nil {
+				r.log.Printf("Unable to upload fuse spool of %v: %v", siaPath, err)
+				continue
+			}
+			delete(spools, spool)
+		}
+	}
+}
+
 // managedCloseFuseManager unmounts all currently-mounted filesystems.
 func (fm *fuseManager) managedCloseFuseManager() error {
 	// The concurreny here is a little bit annoying because the callto Unmount
@@ -98,9 +183,12 @@ func (fm *fuseManager) Mount(mountPoint string, sp modules.SiaPath, opts modules
 		}
 	}()
 
-	// Enforce read-only requirements for now.
+	// Writable mounts stage writes in the spool dir.
 	if !opts.ReadOnly {
-		return errors.New("fuse must be mounted as read-only")
+		err = os.MkdirAll(filepath.Join(fm.renter.persistDir, fuseSpoolDir), modules.DefaultDirPerm)
+		if err != nil {
+			return errors.AddContext(err, "unable to create the fuse spool dir")
+		}
 	}
 
 	// Get the mountpoint's root from the filesystem.
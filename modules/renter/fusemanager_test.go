@@ -0,0 +1,100 @@
+// +build linux darwin
+
+package renter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+// TestLoadFuseSpools checks that the spools which failed to upload are kept
+// when the renter starts and that all other spools are removed.
+func TestLoadFuseSpools(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	numAlerts := func() (n int) {
+		_, errs, _ := r.staticAlerter.Alerts()
+		for _, alert := range errs {
+			if alert.Msg == AlertMSGFuseUploadFailed {
+				n++
+			}
+		}
+		return
+	}
+
+	// Without a spool dir there are no spools.
+	spools, err := r.managedLoadFuseSpools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spools) != 0 {
+		t.Fatal("expected no spools", spools)
+	}
+
+	// Create a spool which wasn't uploaded yet and a spool which failed to
+	// upload.
+	dir := filepath.Join(r.persistDir, fuseSpoolDir)
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	unfinished := filepath.Join(dir, "unfinished")
+	failed := filepath.Join(dir, "failed")
+	siaPath := modules.RandomSiaPath()
+	if err := ioutil.WriteFile(unfinished, []byte("data"), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(failed, []byte("data"), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedFailedFuseSpool(failed, siaPath, os.ErrClosed); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the failed spool should be returned and kept.
+	spools, err = r.managedLoadFuseSpools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spools) != 1 || !spools[failed].Equals(siaPath) {
+		t.Fatal("wrong spools", spools)
+	}
+	if _, err := os.Stat(unfinished); !os.IsNotExist(err) {
+		t.Fatal("unfinished spool wasn't removed", err)
+	}
+	if _, err := os.Stat(failed + fuseSpoolSiaPathSuffix); err != nil {
+		t.Fatal(err)
+	}
+	if n := numAlerts(); n != 1 {
+		t.Fatal("unexpected number of alerts", n)
+	}
+
+	// Removing the spool should remove its siapath and alert.
+	if err := r.managedRemoveFuseSpool(failed); err != nil {
+		t.Fatal(err)
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 0 {
+		t.Fatal("spool dir should be empty", len(fis))
+	}
+	if n := numAlerts(); n != 0 {
+		t.Fatal("unexpected number of alerts", n)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/writeaheadlog"
 
+	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
 	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem/siadir"
@@ -59,8 +60,20 @@ type (
 	persistence struct {
 		MaxDownloadSpeed int64
 		MaxUploadSpeed   int64
+		SkynetCacheSize  uint64
 		UploadedBackups  []modules.UploadedBackup
 		SyncedContracts  []types.FileContractID
+		DrainingHosts    []types.SiaPublicKey
+
+		// Signed stream URL related fields.
+		RequireStreamSignature bool
+		StreamURLKey           crypto.Hash
+		StreamURLDownloads     map[string]streamURLDownloads
+
+		// Scheduled backup related fields.
+		BackupSchedule      modules.BackupSchedule
+		BackupScheduleState backupScheduleState
+		DeletedBackups      []deletedBackup
 	}
 )
 
@@ -458,6 +458,12 @@ func (c *SafeContract) applySetRoot(root crypto.Hash, index int) error {
 // managedRecordAppendIntent creates a WAL update that adds a new sector to the
 // contract and queues this update for application.
 func (c *SafeContract) managedRecordAppendIntent(rev types.FileContractRevision, root crypto.Hash, storageCost, bandwidthCost types.Currency) (*unappliedWalTxn, error) {
+	return c.managedRecordAppendRootsIntent(rev, []crypto.Hash{root}, storageCost, bandwidthCost)
+}
+
+// managedRecordAppendRootsIntent creates a WAL update that adds multiple new
+// sectors to the contract and queues this update for application.
+func (c *SafeContract) managedRecordAppendRootsIntent(rev types.FileContractRevision, roots []crypto.Hash, storageCost, bandwidthCost types.Currency) (*unappliedWalTxn, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	// construct new header
@@ -468,16 +474,18 @@ func (c *SafeContract) managedRecordAppendIntent(rev types.FileContractRevision,
 	newHeader.StorageSpending = newHeader.StorageSpending.Add(storageCost)
 	newHeader.UploadSpending = newHeader.UploadSpending.Add(bandwidthCost)
 
-	updates := []writeaheadlog.Update{
-		c.makeUpdateSetHeader(newHeader),
-		c.makeUpdateSetRoot(root, c.merkleRoots.len()),
+	updates := []writeaheadlog.Update{c.makeUpdateSetHeader(newHeader)}
+	for i, root := range roots {
+		updates = append(updates, c.makeUpdateSetRoot(root, c.merkleRoots.len()+i))
 	}
 	if build.Release == "testing" {
-		rcUpdate, err := c.makeUpdateRefCounterAppend()
-		if err != nil {
-			return nil, errors.AddContext(err, "failed to create a refcounter update")
+		for range roots {
+			rcUpdate, err := c.makeUpdateRefCounterAppend()
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to create a refcounter update")
+			}
+			updates = append(updates, rcUpdate)
 		}
-		updates = append(updates, rcUpdate)
 	}
 	t, err := c.newWalTxn(updates)
 	if err != nil {
@@ -613,6 +613,91 @@ func TestContractRefCounter(t *testing.T) {
 	}
 }
 
+// TestContractRecordCommitAppendRootsIntent tests recording and committing
+// the append of multiple sectors at once.
+func TestContractRecordCommitAppendRootsIntent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create a contract set
+	dir := build.TempDir(filepath.Join("proto", t.Name()))
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := NewContractSet(dir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// add a contract
+	initialHeader := contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewRevisionNumber:    1,
+				NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{{}, {}},
+				},
+			}},
+		},
+	}
+	initialRoots := []crypto.Hash{{1}}
+	c, err := cs.managedInsertContract(initialHeader, initialRoots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := cs.managedMustAcquire(t, c.ID)
+	defer cs.Return(sc)
+
+	// compute the expected root after appending the new roots
+	newRoots := []crypto.Hash{{2}, {3}, {4}}
+	expectedRoot := cachedMerkleRoot(append(initialRoots, newRoots...))
+	if root := sc.merkleRoots.checkNewRoot(newRoots...); root != expectedRoot {
+		t.Fatal("checkNewRoot returned wrong root")
+	}
+	if sc.merkleRoots.len() != len(initialRoots) {
+		t.Fatal("checkNewRoot shouldn't append roots")
+	}
+
+	// record the intent
+	rev := initialHeader.Transaction.FileContractRevisions[0]
+	rev.NewRevisionNumber++
+	rev.NewFileMerkleRoot = expectedRoot
+	storageCost := types.NewCurrency64(7)
+	bandwidthCost := types.NewCurrency64(17)
+	walTxn, err := sc.managedRecordAppendRootsIntent(rev, newRoots, storageCost, bandwidthCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// commit the change
+	txn := rev.ToTransaction()
+	err = sc.managedCommitAppend(walTxn, txn, storageCost, bandwidthCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// verify the contract
+	if sc.merkleRoots.len() != len(initialRoots)+len(newRoots) {
+		t.Fatal("wrong number of roots", sc.merkleRoots.len())
+	}
+	roots, err := sc.merkleRoots.merkleRoots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(roots, append(initialRoots, newRoots...)) {
+		t.Fatal("wrong roots")
+	}
+	if sc.LastRevision().NewRevisionNumber != rev.NewRevisionNumber {
+		t.Fatal("wrong revision number")
+	}
+	if !sc.header.StorageSpending.Equals(storageCost) || !sc.header.UploadSpending.Equals(bandwidthCost) {
+		t.Fatal("wrong spending", sc.header.StorageSpending, sc.header.UploadSpending)
+	}
+	if sc.staticRC.numSectors != uint64(sc.merkleRoots.numMerkleRoots) {
+		t.Fatalf("refCounter has wrong number of sectors. Expected %d, found %d", uint64(sc.merkleRoots.numMerkleRoots), sc.staticRC.numSectors)
+	}
+}
+
 // TestContractRecordCommitDownloadIntent tests recording and committing
 // downloads and makes sure they use the wal correctly.
 func TestContractRecordCommitDownloadIntent(t *testing.T) {
@@ -0,0 +1,124 @@
+package proto
+
+import (
+	"io"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// errAppendProgramBadRoot is returned if the merkle root of a contract
+	// after executing an append program doesn't match the expected root.
+	errAppendProgramBadRoot = errors.New("host returned unexpected merkle root after appending sectors")
+
+	// errAppendProgramBadSize is returned if the size of a contract after
+	// executing an append program doesn't match the expected size.
+	errAppendProgramBadSize = errors.New("host returned unexpected contract size after appending sectors")
+
+	// errAppendProgramHighTransfer is returned if the host expects the renter
+	// to move more money to the void than the program justifies.
+	errAppendProgramHighTransfer = errors.New("host requested a transfer of collateral and storage cost which is too high")
+)
+
+// FinalizeAppendProgram finalizes a write program, consisting of Append
+// instructions for the given sector roots, which was executed on the provided
+// stream. It verifies the last output of the program against the contract's
+// merkle roots, exchanges signatures for the resulting revision with the host
+// and then updates both the contract and its merkle roots atomically.
+//
+// maxTransfer is the amount of collateral and storage cost the renter expects
+// to be moved from the host's missed output to the void. storageCost and
+// bandwidthCost are only used to update the contract's spending.
+func (cs *ContractSet) FinalizeAppendProgram(stream io.ReadWriter, fcid types.FileContractID, roots []crypto.Hash, lastOutput modules.RPCExecuteProgramResponse, maxTransfer, storageCost, bandwidthCost types.Currency, blockHeight types.BlockHeight) error {
+	// Acquire the contract.
+	sc, haveContract := cs.Acquire(fcid)
+	if !haveContract {
+		return errors.New("contract not present in contract set")
+	}
+	defer cs.Return(sc)
+	current := sc.LastRevision()
+
+	// Verify the output of the program.
+	newSize := current.NewFileSize + uint64(len(roots))*modules.SectorSize
+	if lastOutput.NewSize != newSize {
+		return errors.AddContext(errAppendProgramBadSize, "FinalizeAppendProgram")
+	}
+	sc.mu.Lock()
+	newRoot := sc.merkleRoots.checkNewRoot(roots...)
+	sc.mu.Unlock()
+	if lastOutput.NewMerkleRoot != newRoot {
+		return errors.AddContext(errAppendProgramBadRoot, "FinalizeAppendProgram")
+	}
+	transfer := lastOutput.AdditionalCollateral.Add(lastOutput.StorageCost)
+	if transfer.Cmp(maxTransfer) > 0 {
+		return errors.AddContext(errAppendProgramHighTransfer, "FinalizeAppendProgram")
+	}
+
+	// Create the new revision.
+	rev, err := current.ExecuteProgramRevision(current.NewRevisionNumber+1, transfer, newRoot, newSize)
+	if err != nil {
+		return errors.AddContext(err, "failed to create execute program revision")
+	}
+	signedTxn := rev.ToTransaction()
+	renterSig := sc.Sign(signedTxn.SigHash(0, blockHeight))
+	signedTxn.TransactionSignatures[0].Signature = renterSig[:]
+
+	// Record the change we are about to make to the contract. If we lose power
+	// mid-revision, this allows us to restore either the pre-revision or
+	// post-revision contract.
+	walTxn, err := sc.managedRecordAppendRootsIntent(rev, roots, storageCost, bandwidthCost)
+	if err != nil {
+		return errors.AddContext(err, "failed to record append intent")
+	}
+
+	// Disrupt here before sending the signed revision to the host.
+	if cs.staticDeps.Disrupt("InterruptUploadBeforeSendingRevision") {
+		return errors.New("InterruptUploadBeforeSendingRevision disrupt")
+	}
+
+	// Send the signing request.
+	req := modules.RPCExecuteProgramRevisionSigningRequest{
+		Signature:            renterSig[:],
+		NewRevisionNumber:    rev.NewRevisionNumber,
+		NewValidProofValues:  make([]types.Currency, len(rev.NewValidProofOutputs)),
+		NewMissedProofValues: make([]types.Currency, len(rev.NewMissedProofOutputs)),
+	}
+	for i, o := range rev.NewValidProofOutputs {
+		req.NewValidProofValues[i] = o.Value
+	}
+	for i, o := range rev.NewMissedProofOutputs {
+		req.NewMissedProofValues[i] = o.Value
+	}
+	err = modules.RPCWrite(stream, req)
+	if err != nil {
+		return errors.AddContext(err, "failed to send revision signing request")
+	}
+
+	// Receive the host's signature.
+	var resp modules.RPCExecuteProgramRevisionSigningResponse
+	err = modules.RPCRead(stream, &resp)
+	if err != nil {
+		return errors.AddContext(err, "failed to read revision signing response")
+	}
+	signedTxn.TransactionSignatures = append(signedTxn.TransactionSignatures, types.TransactionSignature{
+		ParentID:       crypto.Hash(rev.ParentID),
+		CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+		PublicKeyIndex: 1,
+		Signature:      resp.Signature,
+	})
+	err = modules.VerifyFileContractRevisionTransactionSignatures(rev, signedTxn.TransactionSignatures, blockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to verify host signature")
+	}
+
+	// Disrupt here before updating the contract.
+	if cs.staticDeps.Disrupt("InterruptUploadAfterSendingRevision") {
+		return errors.New("InterruptUploadAfterSendingRevision disrupt")
+	}
+
+	// Update the contract.
+	return errors.AddContext(sc.managedCommitAppend(walTxn, signedTxn, storageCost, bandwidthCost), "failed to commit append")
+}
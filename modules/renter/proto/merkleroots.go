@@ -325,9 +325,9 @@ func (mr *merkleRoots) root() crypto.Hash {
 	return tree.Root()
 }
 
-// checkNewRoot returns the root of the merkleTree after appending the checkNewRoot
-// without actually appending it.
-func (mr *merkleRoots) checkNewRoot(newRoot crypto.Hash) crypto.Hash {
+// checkNewRoot returns the root of the merkleTree after appending the new roots
+// without actually appending them.
+func (mr *merkleRoots) checkNewRoot(newRoots ...crypto.Hash) crypto.Hash {
 	tree := crypto.NewCachedTree(sectorHeight)
 	for _, st := range mr.cachedSubTrees {
 		if err := tree.PushSubTree(st.height, st.sum); err != nil {
@@ -338,8 +338,10 @@ func (mr *merkleRoots) checkNewRoot(newRoot crypto.Hash) crypto.Hash {
 	for _, root := range mr.uncachedRoots {
 		tree.Push(root)
 	}
-	// Push the new root.
-	tree.Push(newRoot)
+	// Push the new roots.
+	for _, root := range newRoots {
+		tree.Push(root)
+	}
 	return tree.Root()
 }
 
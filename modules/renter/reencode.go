@@ -0,0 +1,309 @@
+package renter
+
+// Re-encoding a siafile changes its erasure coding parameters without changing
+// its siapath. The data of the siafile is streamed through the upload code
+// into a temporary siafile within the ReencodeFolder which uses the new
+// erasure coding parameters. Once the temporary siafile is fully uploaded and
+// every chunk reached the redundancy of the new erasure code, the chunk table of the original siafile is atomically replaced with the one of
+// the temporary siafile using the siafile's WAL. The old pieces are dropped
+// together with the original chunk table. If any chunk of the temporary
+// siafile falls short of the new redundancy, the re-encoding fails and the
+// original siafile is kept.
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem/siafile"
+)
+
+var (
+	// errReencodeInProgress is returned when a siafile is already being
+	// re-encoded.
+	errReencodeInProgress = errors.New("file is already being re-encoded")
+
+	// errReencodeIncomplete is returned when the re-encoded siafile didn't
+	// reach the redundancy of its erasure code.
+	errReencodeIncomplete = errors.New("re-encoded file didn't reach full redundancy")
+
+	// errReencodeSameRedundancy is returned when a siafile is re-encoded with
+	// the erasure coding parameters it already uses.
+	errReencodeSameRedundancy = errors.New("file already uses the provided erasure coding parameters")
+
+	// errReencodeSkyfile is returned when a skyfile is re-encoded. Skylinks
+	// point to specific sectors which would be dropped by the re-encoding.
+	errReencodeSkyfile = errors.New("can't re-encode a file which has skylinks")
+)
+
+type (
+	// reencodeStatus tracks the progress of the re-encoding of a siafile.
+	reencodeStatus struct {
+		staticSize uint64
+
+		done bool
+		err  error
+		read uint64
+		mu   sync.Mutex
+	}
+
+	// reencodeReader is a helper type which counts the bytes that are read
+	// from the original siafile during a re-encoding.
+	reencodeReader struct {
+		r      io.Reader
+		status *reencodeStatus
+	}
+)
+
+// Read implements the io.Reader interface.
+func (rr *reencodeReader) Read(b []byte) (int, error) {
+	n, err := rr.r.Read(b)
+	rr.status.mu.Lock()
+	rr.status.read += uint64(n)
+	rr.status.mu.Unlock()
+	return n, err
+}
+
+// managedFinish marks the re-encoding as done.
+func (rs *reencodeStatus) managedFinish(err error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.done = true
+	rs.err = err
+}
+
+// managedInProgress returns whether the re-encoding is still in progress.
+func (rs *reencodeStatus) managedInProgress() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return !rs.done
+}
+
+// managedUpdateFileInfo adds the status of the re-encoding to the provided
+// FileInfo.
+func (rs *reencodeStatus) managedUpdateFileInfo(fi *modules.FileInfo) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	fi.Reencoding = !rs.done
+	if rs.err != nil {
+		fi.ReencodeError = rs.err.Error()
+	}
+	if rs.staticSize == 0 || rs.read >= rs.staticSize {
+		fi.ReencodeProgress = 100
+		return
+	}
+	fi.ReencodeProgress = 100 * float64(rs.read) / float64(rs.staticSize)
+}
+
+// managedReencodeStatus returns the status of the most recent re-encoding of
+// the siafile with the given uid.
+func (r *Renter) managedReencodeStatus(uid siafile.SiafileUID) (*reencodeStatus, bool) {
+	r.reencodesMu.Lock()
+	defer r.reencodesMu.Unlock()
+	status, exists := r.reencodes[uid]
+	return status, exists
+}
+
+// managedAddReencodeInfo adds the status of the re-encoding of the siafile at
+// the given siapath to the provided FileInfo.
+func (r *Renter) managedAddReencodeInfo(siaPath modules.SiaPath, fi *modules.FileInfo) (err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	status, exists := r.managedReencodeStatus(entry.UID())
+	if !exists {
+		return nil
+	}
+	status.managedUpdateFileInfo(fi)
+	return nil
+}
+
+// managedReencodeUploadDone returns whether there are no more chunks of the
+// provided siafile in the upload heap.
+func (r *Renter) managedReencodeUploadDone(entry *filesystem.FileNode) bool {
+	uid := entry.UID()
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		if r.uploadHeap.managedExists(uploadChunkID{fileUID: uid, index: chunkIndex}) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkReencodeRedundancy returns an error if not every chunk of the provided
+// re-encoded siafile reached the redundancy of its erasure code.
+func checkReencodeRedundancy(entry *filesystem.FileNode, offline, goodForRenew map[string]bool) error {
+	ec := entry.ErasureCode()
+	target := float64(ec.NumPieces()) / float64(ec.MinPieces())
+	redundancy, _, err := entry.Redundancy(offline, goodForRenew)
+	if err != nil {
+		return errors.AddContext(err, "unable to compute redundancy of re-encoded file")
+	}
+	if redundancy < target {
+		return errors.AddContext(errReencodeIncomplete, fmt.Sprintf("redundancy %.2f is lower than %.2f", redundancy, target))
+	}
+	return nil
+}
+
+// managedReencode re-encodes the provided siafile using the provided erasure
+// coder.
+func (r *Renter) managedReencode(entry *filesystem.FileNode, ec modules.ErasureCoder, status *reencodeStatus) (err error) {
+	// Create a streamer for the siafile.
+	snap, err := entry.Snapshot(r.staticFileSystem.FileSiaPath(entry))
+	if err != nil {
+		return errors.AddContext(err, "unable to create snapshot of file")
+	}
+	streamer := r.managedStreamer(snap, false)
+	defer func() {
+		err = errors.Compose(err, streamer.Close())
+	}()
+
+	// Upload the data to a temporary siafile using the new erasure code.
+	tmpSiaPath, err := modules.ReencodeFolder.Join(string(entry.UID()))
+	if err != nil {
+		return err
+	}
+	up := modules.FileUploadParams{
+		SiaPath:             tmpSiaPath,
+		ErasureCode:         ec,
+		Force:               true,
+		CipherType:          entry.MasterKey().Type(),
+		DisablePartialChunk: true,
+	}
+	defer func() {
+		// Clean up the temporary siafile if the re-encoding failed.
+		if err == nil {
+			return
+		}
+		deleteErr := r.staticFileSystem.DeleteFile(tmpSiaPath)
+		if deleteErr != nil && !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			err = errors.Compose(err, deleteErr)
+		}
+	}()
+	tmpEntry, err := r.callUploadStreamFromReader(up, &reencodeReader{r: streamer, status: status})
+	if err != nil {
+		return errors.AddContext(err, "unable to upload re-encoded file")
+	}
+	defer func() {
+		err = errors.Compose(err, tmpEntry.Close())
+	}()
+
+	// Wait for the upload of the temporary siafile to finish.
+	for !r.managedReencodeUploadDone(tmpEntry) {
+		select {
+		case <-r.tg.StopChan():
+			return errors.New("interrupted by shutdown")
+		case <-time.After(reencodeCheckInterval):
+		}
+	}
+
+	// Make sure the upload didn't leave any chunks behind. Chunks which failed
+	// to upload are no longer in the upload heap but lack pieces.
+	offline, goodForRenew, _ := r.managedContractUtilityMaps()
+	err = checkReencodeRedundancy(tmpEntry, offline, goodForRenew)
+	if err != nil {
+		return err
+	}
+
+	// Replace the original siafile with the temporary one. The siapath is
+	// fetched again since the siafile might have been renamed in the
+	// meantime.
+	siaPath := r.staticFileSystem.FileSiaPath(entry)
+	err = r.staticFileSystem.ReplaceFile(tmpSiaPath, siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to replace original file")
+	}
+
+	// Update the metadata of the affected directories.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	bubblePaths := r.newUniqueRefreshPaths()
+	err = bubblePaths.callAdd(dirSiaPath)
+	if err != nil {
+		r.log.Printf("failed to add directory '%v' to bubble paths: %v", dirSiaPath, err)
+	}
+	err = bubblePaths.callAdd(modules.ReencodeFolder)
+	if err != nil {
+		r.log.Printf("failed to add directory '%v' to bubble paths: %v", modules.ReencodeFolder, err)
+	}
+	bubblePaths.callRefreshAll()
+	return nil
+}
+
+// threadedReencode re-encodes the provided siafile and closes it afterwards.
+func (r *Renter) threadedReencode(entry *filesystem.FileNode, ec modules.ErasureCoder, status *reencodeStatus) {
+	uid := entry.UID()
+	err := r.tg.Add()
+	if err != nil {
+		status.managedFinish(errors.Compose(err, entry.Close()))
+		return
+	}
+	defer r.tg.Done()
+
+	err = r.managedReencode(entry, ec, status)
+	err = errors.Compose(err, entry.Close())
+	status.managedFinish(err)
+	if err != nil {
+		// Keep the status around to report the error.
+		r.log.Printf("WARN: failed to re-encode file %v: %v", uid, err)
+		return
+	}
+	r.reencodesMu.Lock()
+	delete(r.reencodes, uid)
+	r.reencodesMu.Unlock()
+}
+
+// ChangeRedundancy re-encodes the siafile at the given siapath using the
+// provided erasure coder. The re-encoding happens in the background and its
+// progress is reported by File.
+func (r *Renter) ChangeRedundancy(siaPath modules.SiaPath, ec modules.ErasureCoder) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if ec == nil {
+		return errors.New("erasure coder can't be nil")
+	}
+
+	// Open the file. It is closed by the re-encoding thread.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, entry.Close())
+		}
+	}()
+	if entry.ErasureCode().Identifier() == ec.Identifier() {
+		return errReencodeSameRedundancy
+	}
+	if len(entry.Metadata().Skylinks) > 0 {
+		return errReencodeSkyfile
+	}
+
+	// Register the re-encoding.
+	uid := entry.UID()
+	status := &reencodeStatus{staticSize: entry.Size()}
+	r.reencodesMu.Lock()
+	if oldStatus, exists := r.reencodes[uid]; exists && oldStatus.managedInProgress() {
+		r.reencodesMu.Unlock()
+		return errReencodeInProgress
+	}
+	r.reencodes[uid] = status
+	r.reencodesMu.Unlock()
+
+	go r.threadedReencode(entry, ec, status)
+	return nil
+}
@@ -0,0 +1,203 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestChangeRedundancy tests the validation and status reporting of
+// re-encodings as well as replacing a siafile with a re-encoded one.
+func TestChangeRedundancy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a file.
+	entry, err := r.newRenterTestFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath := r.staticFileSystem.FileSiaPath(entry)
+	uid := entry.UID()
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-encoding without an erasure coder or with the current one should
+	// fail.
+	if err := r.ChangeRedundancy(siaPath, nil); err == nil {
+		t.Fatal("expected re-encoding without erasure coder to fail")
+	}
+	if err := r.ChangeRedundancy(siaPath, entry.ErasureCode()); !errors.Contains(err, errReencodeSameRedundancy) {
+		t.Fatal("expected errReencodeSameRedundancy", err)
+	}
+
+	// Register a re-encoding which is in progress. Another re-encoding
+	// shouldn't be possible.
+	status := &reencodeStatus{staticSize: 1000, read: 250}
+	r.reencodesMu.Lock()
+	r.reencodes[uid] = status
+	r.reencodesMu.Unlock()
+	ec, err := modules.NewRSSubCode(4, 8, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ChangeRedundancy(siaPath, ec); !errors.Contains(err, errReencodeInProgress) {
+		t.Fatal("expected errReencodeInProgress", err)
+	}
+
+	// The progress should be reported by File.
+	fi, err := r.File(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.Reencoding || fi.ReencodeProgress != 25 || fi.ReencodeError != "" {
+		t.Fatal("wrong re-encoding status", fi.Reencoding, fi.ReencodeProgress, fi.ReencodeError)
+	}
+
+	// A failed re-encoding should be reported as well.
+	status.managedFinish(errors.New("failed"))
+	fi, err = r.File(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Reencoding || fi.ReencodeError != "failed" {
+		t.Fatal("wrong re-encoding status", fi.Reencoding, fi.ReencodeError)
+	}
+
+	// Create a re-encoded copy of the file and replace the file with it.
+	tmpSiaPath, err := modules.ReencodeFolder.Join(string(uid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpEntry, err := r.createRenterTestFileWithParams(tmpSiaPath, ec, crypto.RandomCipherType())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpUID := tmpEntry.UID()
+	if err := tmpEntry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.staticFileSystem.ReplaceFile(tmpSiaPath, siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.staticFileSystem.OpenSiaFile(tmpSiaPath); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("expected re-encoded file to be moved", err)
+	}
+	entry, err = r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if entry.UID() != tmpUID {
+		t.Fatal("file wasn't replaced")
+	}
+	if entry.ErasureCode().Identifier() != ec.Identifier() {
+		t.Fatal("file has the wrong erasure code")
+	}
+}
+
+// TestCheckReencodeRedundancy tests that a re-encoded siafile is only accepted
+// once every chunk reached the redundancy of its erasure code.
+func TestCheckReencodeRedundancy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a re-encoded file without any pieces.
+	ec, err := modules.NewRSSubCode(4, 8, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpSiaPath, err := modules.ReencodeFolder.Join(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := r.createRenterTestFileWithParams(tmpSiaPath, ec, crypto.RandomCipherType())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	offline := make(map[string]bool)
+	goodForRenew := make(map[string]bool)
+	if err := checkReencodeRedundancy(entry, offline, goodForRenew); !errors.Contains(err, errReencodeIncomplete) {
+		t.Fatal("expected errReencodeIncomplete", err)
+	}
+
+	// Upload all but one piece of every chunk. The file is still incomplete.
+	hosts := make([]types.SiaPublicKey, ec.NumPieces())
+	for i := range hosts {
+		hosts[i] = types.SiaPublicKey{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(crypto.PublicKeySize),
+		}
+		offline[hosts[i].String()] = false
+		goodForRenew[hosts[i].String()] = true
+	}
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		for pieceIndex := 0; pieceIndex < ec.NumPieces()-1; pieceIndex++ {
+			if err := entry.AddPiece(hosts[pieceIndex], chunkIndex, uint64(pieceIndex), crypto.Hash{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := checkReencodeRedundancy(entry, offline, goodForRenew); !errors.Contains(err, errReencodeIncomplete) {
+		t.Fatal("expected errReencodeIncomplete", err)
+	}
+
+	// Upload the missing pieces. The file should be complete.
+	lastPiece := ec.NumPieces() - 1
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		if err := entry.AddPiece(hosts[lastPiece], chunkIndex, uint64(lastPiece), crypto.Hash{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := checkReencodeRedundancy(entry, offline, goodForRenew); err != nil {
+		t.Fatal(err)
+	}
+
+	// If a host goes offline, the file is incomplete again.
+	offline[hosts[0].String()] = true
+	if err := checkReencodeRedundancy(entry, offline, goodForRenew); !errors.Contains(err, errReencodeIncomplete) {
+		t.Fatal("expected errReencodeIncomplete", err)
+	}
+}
@@ -104,6 +104,35 @@ func (r *Renter) ReadRegistry(spk types.SiaPublicKey, tweak crypto.Hash, timeout
 	return srv, err
 }
 
+// ReadRegistryRID starts a registry lookup by entry ID on all available
+// workers. The jobs have 'timeout' amount of time to finish their jobs and
+// return a response. Otherwise the response with the highest revision number
+// will be used.
+func (r *Renter) ReadRegistryRID(rid crypto.Hash, timeout time.Duration) (modules.SignedRegistryValue, error) {
+	// Block until there is memory available, and then ensure the memory gets
+	// returned.
+	if !r.memoryManager.Request(readRegistryMemory, memoryPriorityHigh) {
+		return modules.SignedRegistryValue{}, errors.New("renter shut down before memory could be allocated for the project")
+	}
+	defer r.memoryManager.Return(readRegistryMemory)
+
+	// Create a context. If the timeout is greater than zero, have the context
+	// expire when the timeout triggers.
+	ctx := r.tg.StopCtx()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(r.tg.StopCtx(), timeout)
+		defer cancel()
+	}
+
+	// Start the ReadRegistryEID jobs.
+	srv, err := r.managedReadRegistryRID(ctx, rid)
+	if errors.Contains(err, ErrRegistryLookupTimeout) {
+		err = errors.AddContext(err, fmt.Sprintf("timed out after %vs", timeout.Seconds()))
+	}
+	return srv, err
+}
+
 // UpdateRegistry updates the registries on all workers with the given
 // registry value.
 func (r *Renter) UpdateRegistry(spk types.SiaPublicKey, srv modules.SignedRegistryValue, timeout time.Duration) error {
@@ -252,6 +281,113 @@ LOOP:
 	return *srv, nil
 }
 
+// managedReadRegistryRID starts a registry lookup by entry ID on all available
+// workers. Apart from the jobs it launches it behaves like managedReadRegistry.
+func (r *Renter) managedReadRegistryRID(ctx context.Context, rid crypto.Hash) (modules.SignedRegistryValue, error) {
+	// Create a context that dies when the function ends, this will cancel all
+	// of the worker jobs that get created by this function.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Get the full list of workers and create a channel to receive all of the
+	// results from the workers.
+	workers := r.staticWorkerPool.callWorkers()
+	staticResponseChan := make(chan *jobReadRegistryEIDResponse, len(workers))
+
+	// Filter out hosts that don't support looking up entries by ID.
+	numRegistryWorkers := 0
+	for _, worker := range workers {
+		cache := worker.staticCache()
+		if !worker.staticSupportsRegistryEID() {
+			continue
+		}
+
+		// check for price gouging
+		pt := worker.staticPriceTable().staticPriceTable
+		err := checkPDBRGouging(pt, cache.staticRenterAllowance)
+		if err != nil {
+			r.log.Debugf("price gouging detected in worker %v, err: %v\n", worker.staticHostPubKeyStr, err)
+			continue
+		}
+
+		jrr := worker.newJobReadRegistryEID(ctx, staticResponseChan, rid)
+		if !worker.staticJobReadRegistryEIDQueue.callAdd(jrr) {
+			// This will filter out any workers that are on cooldown or
+			// otherwise can't participate in the project.
+			continue
+		}
+		workers[numRegistryWorkers] = worker
+		numRegistryWorkers++
+	}
+	workers = workers[:numRegistryWorkers]
+	// If there are no workers remaining, fail early.
+	if len(workers) == 0 {
+		return modules.SignedRegistryValue{}, errors.AddContext(modules.ErrNotEnoughWorkersInWorkerPool, "cannot perform ReadRegistryRID")
+	}
+
+	// Prepare a context which will be overwritten by a child context with a
+	// timeout when we receive the first response.
+	var useHighestRevCtx context.Context
+
+	var srv *modules.SignedRegistryValue
+	responses := 0
+
+LOOP:
+	for responses < len(workers) {
+		// Check cancel condition and block for more responses.
+		var resp *jobReadRegistryEIDResponse
+		if srv != nil {
+			select {
+			case <-useHighestRevCtx.Done():
+				break LOOP // using best
+			case <-ctx.Done():
+				break LOOP // timeout reached
+			case resp = <-staticResponseChan:
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				break LOOP // timeout reached
+			case resp = <-staticResponseChan:
+			}
+		}
+
+		// When we get the first response, we initialize the highest rev
+		// timeout.
+		if responses == 0 {
+			c, cancel := context.WithTimeout(ctx, useHighestRevDefaultTimeout)
+			defer cancel()
+			useHighestRevCtx = c
+		}
+
+		// Increment responses.
+		responses++
+
+		// Ignore error responses and responses that returned no entry.
+		if resp.staticErr != nil || resp.staticSignedRegistryValue == nil {
+			continue
+		}
+
+		// Remember the response with the highest revision number.
+		if srv == nil || resp.staticSignedRegistryValue.Revision >= srv.Revision {
+			srv = resp.staticSignedRegistryValue
+		}
+	}
+
+	// If we don't have a successful response and also not a response for every
+	// worker, we timed out.
+	if srv == nil && responses < len(workers) {
+		return modules.SignedRegistryValue{}, ErrRegistryLookupTimeout
+	}
+
+	// If we don't have a successful response but received a response from every
+	// worker, we were unable to look up the entry.
+	if srv == nil {
+		return modules.SignedRegistryValue{}, ErrRegistryEntryNotFound
+	}
+	return *srv, nil
+}
+
 // managedUpdateRegistry updates the registries on all workers with the given
 // registry value.
 // NOTE: the input ctx only unblocks the call if it fails to hit the threshold
@@ -0,0 +1,299 @@
+package renter
+
+// registrysubscriptions.go contains the renter-wide bookkeeping for registry
+// subscriptions. Subscribers register their interest in registry entries with
+// the registrySubscriptionManager. The workers periodically sync the set of
+// entries they are subscribed to on their hosts with the set of entries the
+// manager tracks and forward any notifications they receive to the manager.
+// Since the same entry is usually subscribed to on multiple hosts, the manager
+// deduplicates the notifications by revision number before passing them on to
+// the subscribers.
+
+import (
+	"sync"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+var (
+	// errRegistrySubscriberClosed is returned when trying to subscribe to an
+	// entry using a closed subscriber.
+	errRegistrySubscriberClosed = errors.New("registry subscriber was closed")
+)
+
+type (
+	// registrySubscriptionManager keeps track of all the registry entries
+	// that the renter's subscribers are subscribed to.
+	registrySubscriptionManager struct {
+		// entries maps the entry ids of all entries that at least one
+		// subscriber is subscribed to to the state of the entry.
+		entries map[crypto.Hash]*registrySubscriptionEntry
+
+		staticRenter *Renter
+		mu           sync.Mutex
+	}
+
+	// registrySubscriptionEntry is the state of a single entry that is
+	// subscribed to.
+	registrySubscriptionEntry struct {
+		staticRequest modules.RPCRegistrySubscriptionRequest
+
+		// latest is the registry value with the highest revision number that
+		// any of the workers received for the entry. It is nil if no worker
+		// received a value yet.
+		latest *modules.SignedRegistryValue
+
+		// subscribers are the subscribers that are subscribed to the entry.
+		subscribers map[registrySubscriberID]*registrySubscriber
+	}
+
+	// registrySubscriber is the renter's implementation of the
+	// modules.RegistrySubscriber.
+	registrySubscriber struct {
+		staticID      registrySubscriberID
+		staticManager *registrySubscriptionManager
+		staticNotify  modules.RegistryNotifyFunc
+
+		// entries contains the ids of the entries the subscriber is
+		// subscribed to.
+		entries map[crypto.Hash]struct{}
+
+		// notified contains the revision number of the latest value the
+		// subscriber was notified about for every entry. It is used to make
+		// sure that notifications are never delivered out of order.
+		notified map[crypto.Hash]uint64
+
+		closed bool
+		mu     sync.Mutex
+	}
+
+	// registrySubscriberID is a unique identifier for a registrySubscriber.
+	registrySubscriberID types.Specifier
+)
+
+// newRegistrySubscriptionManager creates a new registrySubscriptionManager.
+func newRegistrySubscriptionManager(r *Renter) *registrySubscriptionManager {
+	return &registrySubscriptionManager{
+		entries:      make(map[crypto.Hash]*registrySubscriptionEntry),
+		staticRenter: r,
+	}
+}
+
+// NewRegistrySubscriber creates a new registry subscriber which calls notify
+// whenever one of the entries it is subscribed to receives an update with a
+// higher revision number.
+func (r *Renter) NewRegistrySubscriber(notify modules.RegistryNotifyFunc) (modules.RegistrySubscriber, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticRegistrySubscriptions.staticNewSubscriber(notify), nil
+}
+
+// staticNewSubscriber creates a new subscriber.
+func (rsm *registrySubscriptionManager) staticNewSubscriber(notify modules.RegistryNotifyFunc) *registrySubscriber {
+	sub := &registrySubscriber{
+		staticManager: rsm,
+		staticNotify:  notify,
+		entries:       make(map[crypto.Hash]struct{}),
+		notified:      make(map[crypto.Hash]uint64),
+	}
+	fastrand.Read(sub.staticID[:])
+	return sub
+}
+
+// callSubscriptions returns the requests for all entries that are currently
+// subscribed to.
+func (rsm *registrySubscriptionManager) callSubscriptions() map[crypto.Hash]modules.RPCRegistrySubscriptionRequest {
+	rsm.mu.Lock()
+	defer rsm.mu.Unlock()
+	requests := make(map[crypto.Hash]modules.RPCRegistrySubscriptionRequest, len(rsm.entries))
+	for eid, entry := range rsm.entries {
+		requests[eid] = entry.staticRequest
+	}
+	return requests
+}
+
+// callNumSubscriptions returns the number of entries that are currently
+// subscribed to.
+func (rsm *registrySubscriptionManager) callNumSubscriptions() int {
+	rsm.mu.Lock()
+	defer rsm.mu.Unlock()
+	return len(rsm.entries)
+}
+
+// managedNotify is called by the workers whenever they receive a verified
+// registry value from their host. If the value has a higher revision number
+// than any previously received value for the same entry, all the subscribers
+// of the entry are notified.
+func (rsm *registrySubscriptionManager) managedNotify(spk types.SiaPublicKey, srv modules.SignedRegistryValue) {
+	eid := modules.DeriveRegistryEntryID(spk, srv.Tweak)
+
+	rsm.mu.Lock()
+	entry, exists := rsm.entries[eid]
+	if !exists || (entry.latest != nil && srv.Revision <= entry.latest.Revision) {
+		rsm.mu.Unlock()
+		return
+	}
+	entry.latest = &srv
+	subscribers := make([]*registrySubscriber, 0, len(entry.subscribers))
+	for _, sub := range entry.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	rsm.mu.Unlock()
+
+	for _, sub := range subscribers {
+		rsm.staticNotifySubscriber(sub, spk, srv)
+	}
+}
+
+// managedWakeWorkers wakes the subscription loops of all workers to update
+// the set of entries they are subscribed to.
+func (rsm *registrySubscriptionManager) managedWakeWorkers() {
+	for _, w := range rsm.staticRenter.staticWorkerPool.callWorkers() {
+		w.staticSubscriptionInfo.staticWake()
+	}
+}
+
+// staticNotifySubscriber notifies a subscriber about a registry value in a
+// separate goroutine to avoid blocking the worker that received it.
+func (rsm *registrySubscriptionManager) staticNotifySubscriber(sub *registrySubscriber, spk types.SiaPublicKey, srv modules.SignedRegistryValue) {
+	err := rsm.staticRenter.tg.Launch(func() {
+		sub.managedNotify(spk, srv)
+	})
+	if err != nil {
+		rsm.staticRenter.log.Debugln("failed to notify registry subscriber", err)
+	}
+}
+
+// managedNotify calls the subscriber's notify function unless the subscriber
+// was closed, unsubscribed from the entry in the meantime or already notified
+// about a value with an equal or higher revision number.
+func (sub *registrySubscriber) managedNotify(spk types.SiaPublicKey, srv modules.SignedRegistryValue) {
+	eid := modules.DeriveRegistryEntryID(spk, srv.Tweak)
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if sub.closed {
+		return
+	}
+	if _, subscribed := sub.entries[eid]; !subscribed {
+		return
+	}
+	if rev, notified := sub.notified[eid]; notified && srv.Revision <= rev {
+		return
+	}
+	sub.notified[eid] = srv.Revision
+	sub.staticNotify(spk, srv)
+}
+
+// Subscribe subscribes to the entry with the given public key and tweak. If a
+// value for the entry is already known, the subscriber is notified about it
+// right away.
+func (sub *registrySubscriber) Subscribe(spk types.SiaPublicKey, tweak crypto.Hash) error {
+	eid := modules.DeriveRegistryEntryID(spk, tweak)
+	rsm := sub.staticManager
+
+	sub.mu.Lock()
+	if sub.closed {
+		sub.mu.Unlock()
+		return errRegistrySubscriberClosed
+	}
+	if _, subscribed := sub.entries[eid]; subscribed {
+		sub.mu.Unlock()
+		return nil
+	}
+	sub.entries[eid] = struct{}{}
+	sub.mu.Unlock()
+
+	rsm.mu.Lock()
+	entry, exists := rsm.entries[eid]
+	if !exists {
+		entry = &registrySubscriptionEntry{
+			staticRequest: modules.RPCRegistrySubscriptionRequest{
+				PubKey: spk,
+				Tweak:  tweak,
+			},
+			subscribers: make(map[registrySubscriberID]*registrySubscriber),
+		}
+		rsm.entries[eid] = entry
+	}
+	entry.subscribers[sub.staticID] = sub
+	var latest *modules.SignedRegistryValue
+	if entry.latest != nil {
+		srv := *entry.latest
+		latest = &srv
+	}
+	rsm.mu.Unlock()
+
+	// If this is a new entry, the workers need to subscribe to it. Otherwise
+	// the subscriber is notified about the latest known value.
+	if !exists {
+		rsm.managedWakeWorkers()
+	} else if latest != nil {
+		rsm.staticNotifySubscriber(sub, spk, *latest)
+	}
+	return nil
+}
+
+// Unsubscribe unsubscribes from the entry with the given public key and tweak.
+func (sub *registrySubscriber) Unsubscribe(spk types.SiaPublicKey, tweak crypto.Hash) {
+	eid := modules.DeriveRegistryEntryID(spk, tweak)
+
+	sub.mu.Lock()
+	_, subscribed := sub.entries[eid]
+	delete(sub.entries, eid)
+	delete(sub.notified, eid)
+	sub.mu.Unlock()
+	if !subscribed {
+		return
+	}
+	if sub.staticManager.managedRemoveSubscriber(sub.staticID, eid) {
+		sub.staticManager.managedWakeWorkers()
+	}
+}
+
+// Close unsubscribes the subscriber from all of its entries.
+func (sub *registrySubscriber) Close() {
+	sub.mu.Lock()
+	if sub.closed {
+		sub.mu.Unlock()
+		return
+	}
+	sub.closed = true
+	entries := sub.entries
+	sub.entries = make(map[crypto.Hash]struct{})
+	sub.notified = make(map[crypto.Hash]uint64)
+	sub.mu.Unlock()
+
+	rsm := sub.staticManager
+	var removed bool
+	for eid := range entries {
+		removed = rsm.managedRemoveSubscriber(sub.staticID, eid) || removed
+	}
+	if removed {
+		rsm.managedWakeWorkers()
+	}
+}
+
+// managedRemoveSubscriber removes a subscriber from the entry with the given
+// id. If the entry has no subscribers left, it is removed from the manager and
+// true is returned.
+func (rsm *registrySubscriptionManager) managedRemoveSubscriber(id registrySubscriberID, eid crypto.Hash) bool {
+	rsm.mu.Lock()
+	defer rsm.mu.Unlock()
+	entry, exists := rsm.entries[eid]
+	if !exists {
+		return false
+	}
+	delete(entry.subscribers, id)
+	if len(entry.subscribers) > 0 {
+		return false
+	}
+	delete(rsm.entries, eid)
+	return true
+}
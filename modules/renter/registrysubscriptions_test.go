@@ -0,0 +1,145 @@
+package renter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestRegistrySubscriptionManager tests that the registry subscription manager
+// deduplicates notifications and correctly tracks the subscribed to entries.
+func TestRegistrySubscriptionManager(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	rsm := rt.renter.staticRegistrySubscriptions
+
+	// Create a registry value.
+	sk, pk := crypto.GenerateKeyPair()
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	rv := modules.NewRegistryValue(tweak, fastrand.Bytes(10), 1).Sign(sk)
+
+	// Create two subscribers.
+	notifications1 := make(chan modules.SignedRegistryValue, 10)
+	sub1, err := rt.renter.NewRegistrySubscriber(func(_ types.SiaPublicKey, srv modules.SignedRegistryValue) {
+		notifications1 <- srv
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifications2 := make(chan modules.SignedRegistryValue, 10)
+	sub2, err := rt.renter.NewRegistrySubscriber(func(_ types.SiaPublicKey, srv modules.SignedRegistryValue) {
+		notifications2 <- srv
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Helper to wait for a notification.
+	expectNotification := func(c chan modules.SignedRegistryValue, expected modules.SignedRegistryValue) {
+		t.Helper()
+		select {
+		case srv := <-c:
+			if !reflect.DeepEqual(srv, expected) {
+				t.Fatal("wrong notification", srv, expected)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no notification received")
+		}
+	}
+	// Helper to make sure there is no notification.
+	expectNoNotification := func(c chan modules.SignedRegistryValue) {
+		t.Helper()
+		select {
+		case srv := <-c:
+			t.Fatal("unexpected notification", srv)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// Notifying about an entry that nobody is subscribed to is a no-op.
+	rsm.managedNotify(spk, rv)
+	expectNoNotification(notifications1)
+
+	// Subscribe with the first subscriber.
+	err = sub1.Subscribe(spk, tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := rsm.callNumSubscriptions(); n != 1 {
+		t.Fatal("wrong number of subscriptions", n)
+	}
+
+	// Notify. The subscriber should be notified once, even if the same value
+	// is received multiple times.
+	rsm.managedNotify(spk, rv)
+	rsm.managedNotify(spk, rv)
+	expectNotification(notifications1, rv)
+	expectNoNotification(notifications1)
+
+	// Subscribe with the second subscriber. It should be notified about the
+	// latest known value right away. The number of subscriptions shouldn't
+	// change.
+	err = sub2.Subscribe(spk, tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectNotification(notifications2, rv)
+	if n := rsm.callNumSubscriptions(); n != 1 {
+		t.Fatal("wrong number of subscriptions", n)
+	}
+
+	// Notify about an older and a newer revision. Only the newer one should
+	// be passed on.
+	rvOld := modules.NewRegistryValue(tweak, fastrand.Bytes(10), 0).Sign(sk)
+	rvNew := modules.NewRegistryValue(tweak, fastrand.Bytes(10), 2).Sign(sk)
+	rsm.managedNotify(spk, rvOld)
+	rsm.managedNotify(spk, rvNew)
+	expectNotification(notifications1, rvNew)
+	expectNotification(notifications2, rvNew)
+	expectNoNotification(notifications1)
+	expectNoNotification(notifications2)
+
+	// Unsubscribe the first subscriber. It shouldn't receive notifications
+	// anymore while the second one still does.
+	sub1.Unsubscribe(spk, tweak)
+	if n := rsm.callNumSubscriptions(); n != 1 {
+		t.Fatal("wrong number of subscriptions", n)
+	}
+	rvNew = modules.NewRegistryValue(tweak, fastrand.Bytes(10), 3).Sign(sk)
+	rsm.managedNotify(spk, rvNew)
+	expectNotification(notifications2, rvNew)
+	expectNoNotification(notifications1)
+
+	// Close the second subscriber. There should be no subscriptions left and
+	// subscribing again should fail.
+	sub2.Close()
+	if n := rsm.callNumSubscriptions(); n != 0 {
+		t.Fatal("wrong number of subscriptions", n)
+	}
+	if err := sub2.Subscribe(spk, tweak); err != errRegistrySubscriberClosed {
+		t.Fatal("expected errRegistrySubscriberClosed but got", err)
+	}
+	sub1.Close()
+}
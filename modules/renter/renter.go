@@ -25,6 +25,7 @@ package renter
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
@@ -44,6 +45,7 @@ import (
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/modules/renter/contractor"
 	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem/siafile"
 	"gitlab.com/NebulousLabs/Sia/modules/renter/hostdb"
 	"gitlab.com/NebulousLabs/Sia/modules/renter/proto"
 	"gitlab.com/NebulousLabs/Sia/modules/renter/skynetblocklist"
@@ -86,6 +88,10 @@ type hostContractor interface {
 	// Contracts returns the staticContracts of the renter's hostContractor.
 	Contracts() []modules.RenterContract
 
+	// DrainContract marks a contract as being drained. A drained contract is
+	// neither good for upload nor good for renew.
+	DrainContract(id types.FileContractID) error
+
 	// ContractByPublicKey returns the contract associated with the host key.
 	ContractByPublicKey(types.SiaPublicKey) (modules.RenterContract, bool)
 
@@ -125,6 +131,11 @@ type hostContractor interface {
 	// insertion, deletion, and modification of sectors.
 	Editor(types.SiaPublicKey, <-chan struct{}) (contractor.Editor, error)
 
+	// FinalizeAppendProgram finalizes a write program, consisting of Append
+	// instructions for the given sector roots, which was executed on the host
+	// using the provided stream.
+	FinalizeAppendProgram(stream io.ReadWriter, host types.SiaPublicKey, roots []crypto.Hash, lastOutput modules.RPCExecuteProgramResponse, maxTransfer, storageCost, bandwidthCost types.Currency, blockHeight types.BlockHeight) error
+
 	// IsOffline reports whether the specified host is considered offline.
 	IsOffline(types.SiaPublicKey) bool
 
@@ -183,6 +194,7 @@ type cachedUtilities struct {
 type Renter struct {
 	// Skynet Management
 	staticSkynetBlocklist *skynetblocklist.SkynetBlocklist
+	staticSkynetCache     *skynetCache
 	staticSkynetPortals   *skynetportals.SkynetPortals
 
 	// Download management. The heap has a separate mutex because it is always
@@ -199,6 +211,27 @@ type Renter struct {
 	downloadHistory   map[modules.DownloadID]*download
 	downloadHistoryMu sync.Mutex
 
+	// Resumable downloads. Maps the uids of checkpointed downloads which
+	// haven't completed successfully to their destination.
+	resumableDownloads   map[modules.DownloadID]string
+	resumableDownloadsMu sync.Mutex
+
+	// Re-encoding of siafiles. Maps the uids of siafiles which are being
+	// re-encoded to the status of their re-encoding.
+	reencodes   map[siafile.SiafileUID]*reencodeStatus
+	reencodesMu sync.Mutex
+
+	// Draining of hosts. Maps the public keys of hosts which are being
+	// drained or were drained to the status of their drain.
+	drains   map[string]*drainStatus
+	drainsMu sync.Mutex
+
+	// Backup management. backupScheduleUpdate notifies the backup scheduler
+	// that the schedule was changed and snapshotsDeleted notifies the snapshot
+	// synchronization that backups were deleted.
+	backupScheduleUpdate chan struct{}
+	snapshotsDeleted     chan struct{}
+
 	// Upload management.
 	uploadHeap    uploadHeap
 	directoryHeap directoryHeap
@@ -226,9 +259,18 @@ type Renter struct {
 	// metrics across running the project multiple times.
 	staticProjectDownloadByRootManager *projectDownloadByRootManager
 
+	// staticRegistrySubscriptions manages the registry entries the renter's
+	// subscribers are interested in and deduplicates the updates the workers
+	// receive from their hosts.
+	staticRegistrySubscriptions *registrySubscriptionManager
+
 	// The renter's bandwidth ratelimit.
 	rl *ratelimit.RateLimit
 
+	// streamURLDownloadsChanged indicates that the bytes served by signed
+	// stream URLs changed since the renter's persistence was last saved.
+	streamURLDownloadsChanged bool
+
 	// Utilities.
 	cs                    modules.ConsensusSet
 	deps                  modules.Dependencies
@@ -594,10 +636,19 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	if err != nil {
 		return err
 	}
+
+	// Set the size of the skynet cache.
+	err = r.staticSkynetCache.managedSetMaxSize(s.SkynetCacheSize)
+	if err != nil {
+		return errors.AddContext(err, "unable to resize skynet cache")
+	}
+
 	// Save the changes.
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
+	r.persist.SkynetCacheSize = s.SkynetCacheSize
+	r.persist.RequireStreamSignature = s.RequireStreamSignature
 	err = r.saveSync()
 	r.mu.Unlock(id)
 	if err != nil {
@@ -791,15 +842,20 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 		return modules.RenterSettings{}, errors.AddContext(err, "error getting IPViolationsCheck:")
 	}
 	paused, endTime := r.uploadHeap.managedPauseStatus()
+	id := r.mu.RLock()
+	requireStreamSignature := r.persist.RequireStreamSignature
+	r.mu.RUnlock(id)
 	return modules.RenterSettings{
 		Allowance:        r.hostContractor.Allowance(),
 		IPViolationCheck: enabled,
 		MaxDownloadSpeed: download,
 		MaxUploadSpeed:   upload,
+		SkynetCacheSize:  r.staticSkynetCache.managedStats().MaxSize,
 		UploadsStatus: modules.UploadsStatus{
 			Paused:       paused,
 			PauseEndTime: endTime,
 		},
+		RequireStreamSignature: requireStreamSignature,
 	}, nil
 }
 
@@ -962,6 +1018,11 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 
 		bubbleUpdates:   make(map[string]bubbleStatus),
 		downloadHistory: make(map[modules.DownloadID]*download),
+		reencodes:       make(map[siafile.SiafileUID]*reencodeStatus),
+		drains:          make(map[string]*drainStatus),
+
+		backupScheduleUpdate: make(chan struct{}, 1),
+		snapshotsDeleted:     make(chan struct{}, 1),
 
 		staticProjectDownloadByRootManager: new(projectDownloadByRootManager),
 
@@ -1028,6 +1089,22 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	// Load the downloads which can be resumed.
+	err = r.managedLoadResumableDownloads()
+	if err != nil {
+		return nil, err
+	}
+
+	// Add SkynetCache now that the persisted cache size is known.
+	r.staticSkynetCache, err = newSkynetCache(filepath.Join(r.persistDir, skynetCacheDir), r.persist.SkynetCacheSize)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create skynet cache")
+	}
+
+	// Create the registry subscription manager before the workers since the
+	// workers' subscription loops depend on it.
+	r.staticRegistrySubscriptions = newRegistrySubscriptionManager(r)
+
 	// After persist is initialized, create the worker pool.
 	r.staticWorkerPool = r.newWorkerPool()
 
@@ -1047,6 +1124,14 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	r.managedUpdateRenterContractsAndUtilities()
 	go r.threadedUpdateRenterContractsAndUtilities()
 
+	// Persist the bytes served by signed stream URLs periodically and on
+	// shutdown.
+	go r.threadedPersistStreamURLDownloads()
+	err = r.tg.OnStop(r.managedPersistStreamURLDownloads)
+	if err != nil {
+		return nil, err
+	}
+
 	// Spin up background threads which are not depending on the renter being
 	// up-to-date with consensus.
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
@@ -1090,11 +1175,14 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
 		go r.threadedUploadAndRepair()
 		go r.threadedStuckFileLoop()
+		r.managedResumeDrains()
 	}
 	// Spin up the snapshot synchronization thread.
 	if !r.deps.Disrupt("DisableSnapshotSync") {
 		go r.threadedSynchronizeSnapshots()
 	}
+	// Spin up the backup scheduler.
+	go r.threadedScheduleBackups()
 	return nil
 }
 
@@ -0,0 +1,278 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// amzDateFormat is the format of the X-Amz-Date header.
+	amzDateFormat = "20060102T150405Z"
+
+	// maxClockSkew is the maximum difference between the time a request was
+	// signed and the time it is received.
+	maxClockSkew = 15 * time.Minute
+
+	// signV4Algorithm is the signing algorithm of AWS Signature Version 4.
+	signV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// streamingPayload is the payload hash of requests which sign every
+	// chunk of the body. Those requests are not supported.
+	streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+
+	// unsignedPayload is the payload hash of requests whose body isn't signed.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+type (
+	// authorization contains the fields of a Signature Version 4
+	// Authorization header.
+	authorization struct {
+		accessKey     string
+		date          string
+		region        string
+		service       string
+		signedHeaders []string
+		signature     []byte
+	}
+
+	// hashVerifier is an io.ReadCloser which hashes the data read from it
+	// and returns an error at the end of the data if its hash doesn't match
+	// the expected one.
+	hashVerifier struct {
+		io.ReadCloser
+		staticErr  error
+		staticHash hash.Hash
+		staticSum  []byte
+
+		// verified is set once the end of the data was reached and its hash
+		// matched the expected one.
+		verified bool
+	}
+)
+
+// newHashVerifier creates a new hashVerifier which returns err at the end of
+// the data if its hash doesn't match sum.
+func newHashVerifier(rc io.ReadCloser, h hash.Hash, sum []byte, err error) *hashVerifier {
+	return &hashVerifier{
+		ReadCloser: rc,
+		staticErr:  err,
+		staticHash: h,
+		staticSum:  sum,
+	}
+}
+
+// Read implements the io.Reader interface.
+func (hv *hashVerifier) Read(b []byte) (int, error) {
+	n, err := hv.ReadCloser.Read(b)
+	_, _ = hv.staticHash.Write(b[:n])
+	if err == io.EOF && !bytes.Equal(hv.staticHash.Sum(nil), hv.staticSum) {
+		return n, hv.staticErr
+	}
+	if err == io.EOF {
+		hv.verified = true
+	}
+	return n, err
+}
+
+// verify returns an error unless all the data was read and its hash matched
+// the expected one.
+func (hv *hashVerifier) verify() error {
+	if !hv.verified {
+		return hv.staticErr
+	}
+	return nil
+}
+
+// parseAuthorization parses a Signature Version 4 Authorization header.
+func parseAuthorization(header string) (authorization, error) {
+	var auth authorization
+	if !strings.HasPrefix(header, signV4Algorithm+" ") {
+		return auth, errUnsupportedAuthentication
+	}
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.TrimPrefix(header, signV4Algorithm), ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			return auth, errAuthorizationMalformed
+		}
+		fields[kv[0]] = kv[1]
+	}
+	// The credential has the format
+	// <access key>/<date>/<region>/<service>/aws4_request.
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[4] != "aws4_request" {
+		return auth, errAuthorizationMalformed
+	}
+	signature, err := hex.DecodeString(fields["Signature"])
+	if err != nil || len(signature) != sha256.Size || fields["SignedHeaders"] == "" {
+		return auth, errAuthorizationMalformed
+	}
+	auth.accessKey = credential[0]
+	auth.date = credential[1]
+	auth.region = credential[2]
+	auth.service = credential[3]
+	auth.signedHeaders = strings.Split(fields["SignedHeaders"], ";")
+	auth.signature = signature
+	return auth, nil
+}
+
+// authenticate checks that the request was signed with one of the server's
+// keys. If the payload of the request was signed, the request's body is
+// replaced with a reader which verifies it.
+func (srv *Server) authenticate(req *http.Request) error {
+	header := req.Header.Get("Authorization")
+	if header == "" {
+		return errAccessDenied
+	}
+	auth, err := parseAuthorization(header)
+	if err != nil {
+		return err
+	}
+	secretKey, exists := srv.staticKeys[auth.accessKey]
+	if !exists {
+		return errInvalidAccessKeyID
+	}
+	if auth.service != "s3" {
+		return errAuthorizationMalformed
+	}
+
+	// Check the time the request was signed.
+	amzDate := req.Header.Get("X-Amz-Date")
+	signTime, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil || amzDate[:8] != auth.date {
+		return errAccessDenied
+	}
+	if skew := time.Since(signTime); skew > maxClockSkew || skew < -maxClockSkew {
+		return errRequestTimeTooSkewed
+	}
+
+	// The host always needs to be signed.
+	hostSigned := false
+	for _, h := range auth.signedHeaders {
+		hostSigned = hostSigned || h == "host"
+	}
+	if !hostSigned {
+		return errAuthorizationMalformed
+	}
+
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		return errMissingContentSHA256
+	}
+	if payloadHash == streamingPayload {
+		return errNotImplemented
+	}
+
+	// Compute the signature and compare it to the provided one.
+	signature := signV4(req, auth, payloadHash, amzDate, secretKey)
+	if !hmac.Equal(signature, auth.signature) {
+		return errSignatureDoesNotMatch
+	}
+
+	// Verify the payload while the body is read.
+	if payloadHash != unsignedPayload {
+		sum, err := hex.DecodeString(payloadHash)
+		if err != nil || len(sum) != sha256.Size {
+			return errContentSHA256Mismatch
+		}
+		req.Body = newHashVerifier(req.Body, sha256.New(), sum, errContentSHA256Mismatch)
+	}
+	return nil
+}
+
+// signV4 computes the Signature Version 4 signature of a request.
+func signV4(req *http.Request, auth authorization, payloadHash, amzDate, secretKey string) []byte {
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req),
+		canonicalHeaders(req, auth.signedHeaders),
+		strings.Join(auth.signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := strings.Join([]string{auth.date, auth.region, auth.service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		signV4Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), auth.date)
+	key = hmacSHA256(key, auth.region)
+	key = hmacSHA256(key, auth.service)
+	key = hmacSHA256(key, "aws4_request")
+	return hmacSHA256(key, stringToSign)
+}
+
+// hmacSHA256 computes the HMAC-SHA256 of the data.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery returns the canonical query string of a request. The
+// parameters are sorted by name and value.
+func canonicalQuery(req *http.Request) string {
+	var params []string
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// canonicalHeaders returns the canonical headers of a request for the signed
+// headers. Every header is followed by a newline.
+func canonicalHeaders(req *http.Request, signedHeaders []string) string {
+	var sb strings.Builder
+	for _, name := range signedHeaders {
+		values := req.Header[http.CanonicalHeaderKey(name)]
+		switch {
+		case name == "host":
+			values = []string{req.Host}
+		case name == "content-length" && len(values) == 0 && req.ContentLength >= 0:
+			values = []string{strconv.FormatInt(req.ContentLength, 10)}
+		}
+		trimmed := make([]string, 0, len(values))
+		for _, value := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+		sb.WriteString(name + ":" + strings.Join(trimmed, ",") + "\n")
+	}
+	return sb.String()
+}
+
+// uriEncode encodes a string the way Signature Version 4 requires it. All
+// characters except for the unreserved ones are percent-encoded. Slashes are
+// only encoded if encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '.', b == '_', b == '~':
+			sb.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			sb.WriteByte(b)
+		default:
+			sb.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,416 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+const (
+	// maxPartNumber is the highest part number of a multipart upload.
+	maxPartNumber = 10000
+)
+
+var (
+	// maxPartSize is the maximum size of a single part of a multipart upload.
+	maxPartSize = build.Select(build.Var{
+		Dev:      int64(1 << 30), // 1 GiB
+		Standard: int64(5 << 30), // 5 GiB
+		Testing:  int64(512),
+	}).(int64)
+
+	// maxMultipartStorage is the maximum amount of disk space used by the
+	// parts of all unfinished multipart uploads.
+	maxMultipartStorage = build.Select(build.Var{
+		Dev:      int64(4 << 30),  // 4 GiB
+		Standard: int64(20 << 30), // 20 GiB
+		Testing:  int64(1 << 10),  // 1 KiB
+	}).(int64)
+
+	// multipartUploadExpiry is the age at which unfinished multipart uploads
+	// are aborted.
+	multipartUploadExpiry = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: 24 * time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// multipartExpiryCheckInterval is the interval at which the server checks
+	// for expired multipart uploads.
+	multipartExpiryCheckInterval = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: time.Hour,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// multipartUpload is an unfinished multipart upload. The parts are
+	// stored on disk until the upload is completed, aborted or expired.
+	multipartUpload struct {
+		staticBucket  string
+		staticCreated time.Time
+		staticDir     string
+		staticID      string
+		staticKey     string
+		staticSiaPath modules.SiaPath
+
+		// finishing is set once the upload is being completed or aborted.
+		finishing bool
+		parts     map[int]multipartPart
+		mu        sync.Mutex
+	}
+
+	// multipartPart is a part of a multipart upload which is stored on disk.
+	multipartPart struct {
+		etag string
+		size int64
+	}
+
+	// initiateMultipartUploadResult is the response of
+	// CreateMultipartUpload.
+	initiateMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Bucket   string
+		Key      string
+		UploadID string `xml:"UploadId"`
+	}
+
+	// completeMultipartUpload is the request body of
+	// CompleteMultipartUpload.
+	completeMultipartUpload struct {
+		Parts []completedPart `xml:"Part"`
+	}
+
+	// completedPart is a part within a completeMultipartUpload.
+	completedPart struct {
+		PartNumber int
+		ETag       string
+	}
+
+	// completeMultipartUploadResult is the response of
+	// CompleteMultipartUpload.
+	completeMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Location string
+		Bucket   string
+		Key      string
+		ETag     string
+	}
+)
+
+// partPath returns the path of a part on disk.
+func (mu *multipartUpload) partPath(partNumber int) string {
+	return filepath.Join(mu.staticDir, strconv.Itoa(partNumber))
+}
+
+// size returns the size of all the parts of the upload. The lock needs to be
+// held.
+func (mu *multipartUpload) size() int64 {
+	var size int64
+	for _, part := range mu.parts {
+		size += part.size
+	}
+	return size
+}
+
+// managedRemoveMultipartUpload removes a multipart upload which was marked as
+// finishing from the server and deletes its parts.
+func (srv *Server) managedRemoveMultipartUpload(upload *multipartUpload) error {
+	upload.mu.Lock()
+	size := upload.size()
+	upload.mu.Unlock()
+	srv.mu.Lock()
+	delete(srv.uploads, upload.staticID)
+	srv.multipartStorage -= size
+	srv.mu.Unlock()
+	if err := os.RemoveAll(upload.staticDir); err != nil {
+		return errors.AddContext(err, "unable to remove parts")
+	}
+	return nil
+}
+
+// managedExpireMultipartUploads aborts the multipart uploads which were
+// created more than multipartUploadExpiry before the provided time.
+func (srv *Server) managedExpireMultipartUploads(now time.Time) error {
+	srv.mu.Lock()
+	var uploads []*multipartUpload
+	for _, upload := range srv.uploads {
+		if now.Sub(upload.staticCreated) > multipartUploadExpiry {
+			uploads = append(uploads, upload)
+		}
+	}
+	srv.mu.Unlock()
+
+	var err error
+	for _, upload := range uploads {
+		upload.mu.Lock()
+		finishing := upload.finishing
+		upload.finishing = true
+		upload.mu.Unlock()
+		if finishing {
+			continue
+		}
+		err = errors.Compose(err, srv.managedRemoveMultipartUpload(upload))
+	}
+	return err
+}
+
+// threadedExpireMultipartUploads periodically aborts expired multipart
+// uploads until the server is closed.
+func (srv *Server) threadedExpireMultipartUploads() {
+	for {
+		select {
+		case <-srv.staticStopChan:
+			return
+		case <-time.After(multipartExpiryCheckInterval):
+		}
+		_ = srv.managedExpireMultipartUploads(time.Now())
+	}
+}
+
+// managedMultipartUpload returns the unfinished multipart upload with the given id.
+// The bucket and key need to match the ones the upload was created with.
+func (srv *Server) managedMultipartUpload(uploadID, bucket, key string) (*multipartUpload, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	upload, exists := srv.uploads[uploadID]
+	if !exists || upload.staticBucket != bucket || upload.staticKey != key {
+		return nil, errNoSuchUpload
+	}
+	return upload, nil
+}
+
+// createMultipartUpload handles CreateMultipartUpload requests.
+func (srv *Server) createMultipartUpload(w http.ResponseWriter, bucket, key string) error {
+	sp, err := objectSiaPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := srv.managedCheckBucket(bucket); err != nil {
+		return err
+	}
+	uploadID := hex.EncodeToString(fastrand.Bytes(16))
+	upload := &multipartUpload{
+		staticBucket:  bucket,
+		staticCreated: time.Now(),
+		staticDir:     filepath.Join(srv.staticMultipartDir, uploadID),
+		staticID:      uploadID,
+		staticKey:     key,
+		staticSiaPath: sp,
+
+		parts: make(map[int]multipartPart),
+	}
+	if err := os.Mkdir(upload.staticDir, modules.DefaultDirPerm); err != nil {
+		return errors.AddContext(err, "unable to create upload directory")
+	}
+	srv.mu.Lock()
+	srv.uploads[uploadID] = upload
+	srv.mu.Unlock()
+
+	writeXML(w, http.StatusOK, initiateMultipartUploadResult{
+		Xmlns:    xmlNamespace,
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: uploadID,
+	})
+	return nil
+}
+
+// uploadPart handles UploadPart requests. The part is written to a temporary
+// file first to not replace an existing part with an incomplete one. Parts
+// can't be larger than maxPartSize and the parts of all unfinished uploads
+// can't use more than maxMultipartStorage.
+func (srv *Server) uploadPart(w http.ResponseWriter, req *http.Request, bucket, key, uploadID, partNumberStr string) (err error) {
+	partNumber, err := strconv.Atoi(partNumberStr)
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		return errInvalidArgument
+	}
+	if req.ContentLength > maxPartSize {
+		return errEntityTooLarge
+	}
+	upload, err := srv.managedMultipartUpload(uploadID, bucket, key)
+	if err != nil {
+		return err
+	}
+	srv.mu.Lock()
+	available := maxMultipartStorage - srv.multipartStorage
+	srv.mu.Unlock()
+	limit := maxPartSize
+	if available < limit {
+		limit = available
+	}
+	body := io.Reader(req.Body)
+	if contentMD5 := req.Header.Get("Content-MD5"); contentMD5 != "" {
+		sum, err := base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil || len(sum) != md5.Size {
+			return errInvalidDigest
+		}
+		body = newHashVerifier(req.Body, md5.New(), sum, errBadDigest)
+	}
+
+	// Write the part to a temporary file.
+	f, err := ioutil.TempFile(upload.staticDir, "part")
+	if err != nil {
+		return errors.AddContext(err, "unable to create part file")
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, os.Remove(f.Name()))
+		}
+	}()
+	// Read at most one byte more than allowed to detect parts which are too
+	// large.
+	h := md5.New()
+	size, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(body, limit+1))
+	err = errors.Compose(err, f.Sync(), f.Close())
+	if err != nil {
+		return errors.AddContext(err, "unable to write part")
+	}
+	if size > maxPartSize {
+		return errEntityTooLarge
+	}
+	if size > limit {
+		return errMultipartStorageFull
+	}
+
+	// Replace the previous part with the same number.
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	if upload.finishing {
+		return errNoSuchUpload
+	}
+	srv.mu.Lock()
+	storage := srv.multipartStorage + size - upload.parts[partNumber].size
+	if storage > maxMultipartStorage {
+		srv.mu.Unlock()
+		return errMultipartStorageFull
+	}
+	srv.multipartStorage = storage
+	srv.mu.Unlock()
+	if err := os.Rename(f.Name(), upload.partPath(partNumber)); err != nil {
+		srv.mu.Lock()
+		srv.multipartStorage -= size - upload.parts[partNumber].size
+		srv.mu.Unlock()
+		return errors.AddContext(err, "unable to store part")
+	}
+	partETag := `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+	upload.parts[partNumber] = multipartPart{
+		etag: partETag,
+		size: size,
+	}
+	w.Header().Set("ETag", partETag)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// completeMultipartUpload handles CompleteMultipartUpload requests. The parts
+// are concatenated and streamed into the renter. The object is only replaced
+// once all the parts were uploaded.
+func (srv *Server) completeMultipartUpload(w http.ResponseWriter, req *http.Request, bucket, key, uploadID string) (err error) {
+	upload, err := srv.managedMultipartUpload(uploadID, bucket, key)
+	if err != nil {
+		return err
+	}
+	var cmu completeMultipartUpload
+	if err := xml.NewDecoder(req.Body).Decode(&cmu); err != nil || len(cmu.Parts) == 0 {
+		return errMalformedXML
+	}
+
+	// Check the parts and mark the upload as finishing.
+	upload.mu.Lock()
+	if upload.finishing {
+		upload.mu.Unlock()
+		return errNoSuchUpload
+	}
+	for i, part := range cmu.Parts {
+		if i > 0 && part.PartNumber <= cmu.Parts[i-1].PartNumber {
+			upload.mu.Unlock()
+			return errInvalidPartOrder
+		}
+		uploaded, exists := upload.parts[part.PartNumber]
+		if !exists || strings.Trim(uploaded.etag, `"`) != strings.Trim(part.ETag, `"`) {
+			upload.mu.Unlock()
+			return errInvalidPart
+		}
+	}
+	upload.finishing = true
+	upload.mu.Unlock()
+	defer func() {
+		if err != nil {
+			upload.mu.Lock()
+			upload.finishing = false
+			upload.mu.Unlock()
+		}
+	}()
+
+	// Open the parts and upload them as a single stream.
+	var readers []io.Reader
+	defer func() {
+		for _, r := range readers {
+			err = errors.Compose(err, r.(*os.File).Close())
+		}
+	}()
+	for _, part := range cmu.Parts {
+		f, err := os.Open(upload.partPath(part.PartNumber))
+		if err != nil {
+			return errors.AddContext(err, "unable to open part")
+		}
+		readers = append(readers, f)
+	}
+	fi, err := srv.managedUploadObject(upload.staticSiaPath, io.MultiReader(readers...))
+	if err != nil {
+		return err
+	}
+
+	// The upload is done. Remove its parts.
+	if err := srv.managedRemoveMultipartUpload(upload); err != nil {
+		return err
+	}
+	writeXML(w, http.StatusOK, completeMultipartUploadResult{
+		Xmlns:    xmlNamespace,
+		Location: "/" + bucket + "/" + key,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     etag(fi),
+	})
+	return nil
+}
+
+// abortMultipartUpload handles AbortMultipartUpload requests.
+func (srv *Server) abortMultipartUpload(w http.ResponseWriter, bucket, key, uploadID string) error {
+	upload, err := srv.managedMultipartUpload(uploadID, bucket, key)
+	if err != nil {
+		return err
+	}
+	upload.mu.Lock()
+	finishing := upload.finishing
+	upload.finishing = true
+	upload.mu.Unlock()
+	if finishing {
+		return errNoSuchUpload
+	}
+	if err := srv.managedRemoveMultipartUpload(upload); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,525 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+)
+
+const (
+	// maxListKeys is the maximum number of keys and common prefixes returned
+	// by a single ListObjectsV2 request.
+	maxListKeys = 1000
+
+	// timeFormat is the format of timestamps within XML responses.
+	timeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+type (
+	// listAllMyBucketsResult is the response of ListBuckets.
+	listAllMyBucketsResult struct {
+		XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Buckets []bucket `xml:"Buckets>Bucket"`
+	}
+
+	// bucket describes a bucket within a listAllMyBucketsResult.
+	bucket struct {
+		Name         string
+		CreationDate string
+	}
+
+	// listBucketResult is the response of ListObjectsV2.
+	listBucketResult struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Xmlns                 string   `xml:"xmlns,attr"`
+		Name                  string
+		Prefix                string
+		Delimiter             string `xml:",omitempty"`
+		MaxKeys               int
+		KeyCount              int
+		IsTruncated           bool
+		ContinuationToken     string `xml:",omitempty"`
+		NextContinuationToken string `xml:",omitempty"`
+		StartAfter            string `xml:",omitempty"`
+		EncodingType          string `xml:",omitempty"`
+		Contents              []object
+		CommonPrefixes        []commonPrefix
+	}
+
+	// object describes an object within a listBucketResult.
+	object struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         uint64
+		StorageClass string
+	}
+
+	// commonPrefix describes a common prefix within a listBucketResult.
+	commonPrefix struct {
+		Prefix string
+	}
+)
+
+// bucketSiaPath returns the siapath of a bucket.
+func bucketSiaPath(bucket string) (modules.SiaPath, error) {
+	if bucket == "" || strings.Contains(bucket, "/") {
+		return modules.SiaPath{}, errInvalidBucketName
+	}
+	sp, err := modules.UserFolder.Join(bucket)
+	if err != nil {
+		return modules.SiaPath{}, errInvalidBucketName
+	}
+	return sp, nil
+}
+
+// objectSiaPath returns the siapath of an object. Only keys which are valid
+// siapaths are accepted to make sure that listing a bucket returns the same
+// keys the objects were created with.
+func objectSiaPath(bucket, key string) (modules.SiaPath, error) {
+	bucketSP, err := bucketSiaPath(bucket)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	sp, err := bucketSP.Join(key)
+	if err != nil || sp.String() != bucketSP.String()+"/"+key {
+		return modules.SiaPath{}, errInvalidObjectName
+	}
+	return sp, nil
+}
+
+// etag returns the entity tag of a file. The content hash is used if the file
+// has one.
+func etag(fi modules.FileInfo) string {
+	if fi.ContentHash != (crypto.Hash{}) {
+		return `"` + hex.EncodeToString(fi.ContentHash[:]) + `"`
+	}
+	return fmt.Sprintf(`"%x-%x"`, fi.Filesize, fi.ModificationTime.UnixNano())
+}
+
+// contentType returns the content type of an object based on its extension.
+func contentType(key string) string {
+	if ctype := mime.TypeByExtension(path.Ext(key)); ctype != "" {
+		return ctype
+	}
+	return "application/octet-stream"
+}
+
+// managedCheckBucket returns errNoSuchBucket if the bucket doesn't exist.
+func (srv *Server) managedCheckBucket(bucket string) error {
+	sp, err := bucketSiaPath(bucket)
+	if err != nil {
+		return err
+	}
+	_, err = srv.staticRenter.DirList(sp)
+	if isNotExist(err) {
+		return errNoSuchBucket
+	}
+	return err
+}
+
+// managedNoSuchKey returns the error for a missing object. If the bucket is
+// missing too, errNoSuchBucket is returned.
+func (srv *Server) managedNoSuchKey(bucket string) error {
+	if err := srv.managedCheckBucket(bucket); err != nil {
+		return err
+	}
+	return errNoSuchKey
+}
+
+// managedUploadObject uploads the data read from r to a temporary siapath.
+// Once the upload finished and the provided verifiers verified the data, the
+// object at the siapath is replaced with the uploaded file. Otherwise the
+// uploaded file is deleted and the object is left untouched.
+func (srv *Server) managedUploadObject(sp modules.SiaPath, r io.Reader, verifiers ...*hashVerifier) (_ modules.FileInfo, err error) {
+	tmpSiaPath, err := modules.UploadsFolder.Join(hex.EncodeToString(fastrand.Bytes(16)))
+	if err != nil {
+		return modules.FileInfo{}, err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		deleteErr := srv.staticRenter.DeleteFile(tmpSiaPath)
+		if deleteErr != nil && !isNotExist(deleteErr) {
+			err = errors.Compose(err, deleteErr)
+		}
+	}()
+	up := modules.FileUploadParams{
+		SiaPath: tmpSiaPath,
+	}
+	if err := srv.staticRenter.UploadStreamFromReader(up, r); err != nil {
+		return modules.FileInfo{}, errors.AddContext(err, "unable to upload object")
+	}
+	for _, hv := range verifiers {
+		if err := hv.verify(); err != nil {
+			return modules.FileInfo{}, err
+		}
+	}
+	if err := srv.staticRenter.OverwriteFile(tmpSiaPath, sp); err != nil {
+		return modules.FileInfo{}, errors.AddContext(err, "unable to replace object")
+	}
+	fi, err := srv.staticRenter.File(sp)
+	if err != nil {
+		return modules.FileInfo{}, errors.AddContext(err, "unable to get uploaded object")
+	}
+	return fi, nil
+}
+
+// managedUploadBody replaces the object at the siapath with the data read from
+// the request and returns its entity tag. The object is only replaced if the
+// Content-MD5 and x-amz-content-sha256 digests of the request match the data.
+func (srv *Server) managedUploadBody(sp modules.SiaPath, req *http.Request) (string, error) {
+	body := req.Body
+	var verifiers []*hashVerifier
+	if hv, ok := body.(*hashVerifier); ok {
+		verifiers = append(verifiers, hv)
+	}
+	if contentMD5 := req.Header.Get("Content-MD5"); contentMD5 != "" {
+		sum, err := base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil || len(sum) != md5.Size {
+			return "", errInvalidDigest
+		}
+		hv := newHashVerifier(body, md5.New(), sum, errBadDigest)
+		verifiers = append(verifiers, hv)
+		body = hv
+	}
+	fi, err := srv.managedUploadObject(sp, body, verifiers...)
+	if err != nil {
+		return "", err
+	}
+	return etag(fi), nil
+}
+
+// listBuckets handles ListBuckets requests.
+func (srv *Server) listBuckets(w http.ResponseWriter) error {
+	dis, err := srv.staticRenter.DirList(modules.UserFolder)
+	if err != nil {
+		return errors.AddContext(err, "unable to list buckets")
+	}
+	result := listAllMyBucketsResult{Xmlns: xmlNamespace}
+	// Skip the first directory since it's the user folder itself.
+	for i := 1; i < len(dis); i++ {
+		result.Buckets = append(result.Buckets, bucket{
+			Name:         dis[i].Name(),
+			CreationDate: dis[i].ModTime().UTC().Format(timeFormat),
+		})
+	}
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// headBucket handles HeadBucket requests.
+func (srv *Server) headBucket(w http.ResponseWriter, bucket string) error {
+	if err := srv.managedCheckBucket(bucket); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// createBucket handles CreateBucket requests.
+func (srv *Server) createBucket(w http.ResponseWriter, bucket string) error {
+	sp, err := bucketSiaPath(bucket)
+	if err != nil {
+		return err
+	}
+	err = srv.staticRenter.CreateDir(sp, modules.DefaultDirPerm)
+	if errors.Contains(err, filesystem.ErrExists) {
+		return errBucketAlreadyOwnedByYou
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to create bucket")
+	}
+	w.Header().Set("Location", "/"+bucket)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// deleteBucket handles DeleteBucket requests. Only empty buckets can be
+// deleted.
+func (srv *Server) deleteBucket(w http.ResponseWriter, bucket string) error {
+	sp, err := bucketSiaPath(bucket)
+	if err != nil {
+		return err
+	}
+	dis, err := srv.staticRenter.DirList(sp)
+	if isNotExist(err) {
+		return errNoSuchBucket
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to list bucket")
+	}
+	var files int
+	var mu sync.Mutex
+	err = srv.staticRenter.FileList(sp, false, true, func(modules.FileInfo) {
+		mu.Lock()
+		files++
+		mu.Unlock()
+	})
+	if err != nil {
+		return errors.AddContext(err, "unable to list bucket")
+	}
+	if len(dis) > 1 || files > 0 {
+		return errBucketNotEmpty
+	}
+	if err := srv.staticRenter.DeleteDir(sp); err != nil {
+		return errors.AddContext(err, "unable to delete bucket")
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// putObject handles PutObject requests.
+func (srv *Server) putObject(w http.ResponseWriter, req *http.Request, bucket, key string) error {
+	if req.Header.Get("X-Amz-Copy-Source") != "" {
+		return errNotImplemented
+	}
+	sp, err := objectSiaPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := srv.managedCheckBucket(bucket); err != nil {
+		return err
+	}
+	objectETag, err := srv.managedUploadBody(sp, req)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", objectETag)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// getObject handles GetObject requests. Range requests are served by seeking
+// within the renter's stream.
+func (srv *Server) getObject(w http.ResponseWriter, req *http.Request, bucket, key string) error {
+	sp, err := objectSiaPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	fi, err := srv.staticRenter.File(sp)
+	if isNotExist(err) {
+		return srv.managedNoSuchKey(bucket)
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to get object")
+	}
+	_, stream, err := srv.staticRenter.Streamer(sp, false)
+	if err != nil {
+		return errors.AddContext(err, "unable to get stream for object")
+	}
+	defer stream.Close()
+	w.Header().Set("Content-Type", contentType(key))
+	w.Header().Set("ETag", etag(fi))
+	http.ServeContent(w, req, fi.Name(), fi.ModificationTime, stream)
+	return nil
+}
+
+// headObject handles HeadObject requests.
+func (srv *Server) headObject(w http.ResponseWriter, bucket, key string) error {
+	sp, err := objectSiaPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	fi, err := srv.staticRenter.File(sp)
+	if isNotExist(err) {
+		return srv.managedNoSuchKey(bucket)
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to get object")
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatUint(fi.Filesize, 10))
+	w.Header().Set("Content-Type", contentType(key))
+	w.Header().Set("ETag", etag(fi))
+	w.Header().Set("Last-Modified", fi.ModificationTime.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// deleteObject handles DeleteObject requests. Like S3, deleting an object
+// which doesn't exist succeeds.
+func (srv *Server) deleteObject(w http.ResponseWriter, bucket, key string) error {
+	sp, err := objectSiaPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	err = srv.staticRenter.DeleteFile(sp)
+	if err != nil && !isNotExist(err) {
+		return errors.AddContext(err, "unable to delete object")
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// listObjectsV2 handles ListObjectsV2 requests. Keys which contain the
+// delimiter after the prefix are rolled up into common prefixes.
+func (srv *Server) listObjectsV2(w http.ResponseWriter, bucket string, query url.Values) error {
+	bucketSP, err := bucketSiaPath(bucket)
+	if err != nil {
+		return err
+	}
+	if err := srv.managedCheckBucket(bucket); err != nil {
+		return err
+	}
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	encodingType := query.Get("encoding-type")
+	if encodingType != "" && encodingType != "url" {
+		return errInvalidArgument
+	}
+	maxKeys := maxListKeys
+	if mk := query.Get("max-keys"); mk != "" {
+		maxKeys, err = strconv.Atoi(mk)
+		if err != nil || maxKeys < 0 {
+			return errInvalidArgument
+		}
+		if maxKeys > maxListKeys {
+			maxKeys = maxListKeys
+		}
+	}
+	// The continuation token contains the last key or common prefix of the
+	// previous page. It takes precedence over StartAfter.
+	after, afterPrefix := query.Get("start-after"), false
+	token := query.Get("continuation-token")
+	if token != "" {
+		after, afterPrefix, err = decodeContinuationToken(token)
+		if err != nil {
+			return errInvalidArgument
+		}
+	}
+
+	// Only list the deepest folder which contains all the keys with the
+	// prefix.
+	dirSP := bucketSP
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		dirSP, err = bucketSP.Join(prefix[:i])
+		if err != nil {
+			dirSP = modules.SiaPath{}
+		}
+	}
+	var fis []modules.FileInfo
+	var mu sync.Mutex
+	if !dirSP.IsRoot() {
+		err = srv.staticRenter.FileList(dirSP, true, true, func(fi modules.FileInfo) {
+			mu.Lock()
+			fis = append(fis, fi)
+			mu.Unlock()
+		})
+		if err != nil && !isNotExist(err) {
+			return errors.AddContext(err, "unable to list objects")
+		}
+	}
+	var objects []object
+	for _, fi := range fis {
+		key := strings.TrimPrefix(fi.SiaPath.String(), bucketSP.String()+"/")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		objects = append(objects, object{
+			Key:          key,
+			LastModified: fi.ModificationTime.UTC().Format(timeFormat),
+			ETag:         etag(fi),
+			Size:         fi.Filesize,
+			StorageClass: "STANDARD",
+		})
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+
+	result := listBucketResult{
+		Xmlns:             xmlNamespace,
+		Name:              bucket,
+		Prefix:            prefix,
+		Delimiter:         delimiter,
+		MaxKeys:           maxKeys,
+		ContinuationToken: token,
+		StartAfter:        query.Get("start-after"),
+		EncodingType:      encodingType,
+	}
+	var last string
+	var lastIsPrefix bool
+	for i := 0; i < len(objects) && maxKeys > 0; i++ {
+		key := objects[i].Key
+		if key <= after || (afterPrefix && strings.HasPrefix(key, after)) {
+			continue
+		}
+		// Roll up keys which contain the delimiter after the prefix.
+		var cp string
+		if j := strings.Index(key[len(prefix):], delimiter); delimiter != "" && j >= 0 {
+			cp = key[:len(prefix)+j+len(delimiter)]
+		}
+		if cp != "" && lastIsPrefix && cp == last {
+			continue
+		}
+		if result.KeyCount == maxKeys {
+			result.IsTruncated = true
+			result.NextContinuationToken = encodeContinuationToken(last, lastIsPrefix)
+			break
+		}
+		if cp != "" {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: cp})
+			last, lastIsPrefix = cp, true
+		} else {
+			result.Contents = append(result.Contents, objects[i])
+			last, lastIsPrefix = key, false
+		}
+		result.KeyCount++
+	}
+
+	// Encode the keys if requested by the client.
+	if encodingType == "url" {
+		result.Prefix = uriEncode(result.Prefix, false)
+		result.Delimiter = uriEncode(result.Delimiter, false)
+		result.StartAfter = uriEncode(result.StartAfter, false)
+		for i := range result.Contents {
+			result.Contents[i].Key = uriEncode(result.Contents[i].Key, false)
+		}
+		for i := range result.CommonPrefixes {
+			result.CommonPrefixes[i].Prefix = uriEncode(result.CommonPrefixes[i].Prefix, false)
+		}
+	}
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// encodeContinuationToken encodes the last key or common prefix of a page
+// into a continuation token.
+func encodeContinuationToken(last string, isPrefix bool) string {
+	kind := "k"
+	if isPrefix {
+		kind = "p"
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(kind + last))
+}
+
+// decodeContinuationToken decodes a continuation token created by
+// encodeContinuationToken.
+func decodeContinuationToken(token string) (last string, isPrefix bool, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) < 2 || (b[0] != 'k' && b[0] != 'p') {
+		return "", false, errors.New("invalid continuation token")
+	}
+	return string(b[1:]), b[0] == 'p', nil
+}
@@ -0,0 +1,282 @@
+// Package s3 serves the renter's files using a subset of the Amazon S3 API.
+// Buckets are the folders of the user folder and objects are the siafiles
+// within them, so the object "photos/cat.jpg" in the bucket "backups" is
+// stored at the siapath "home/user/backups/photos/cat.jpg". Only path-style
+// requests are supported and every request needs to be signed with AWS
+// Signature Version 4 using one of the locally configured keys.
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+)
+
+const (
+	// multipartDir is the name of the directory within the persist directory
+	// which contains the parts of unfinished multipart uploads.
+	multipartDir = "s3multipart"
+
+	// xmlNamespace is the namespace of the S3 API's XML responses.
+	xmlNamespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+)
+
+var (
+	// errNoKeys is returned when a server is created without any keys.
+	errNoKeys = errors.New("the s3 server requires at least one key")
+)
+
+type (
+	// Server is an S3-compatible server which serves the renter's files.
+	Server struct {
+		staticHTTPServer   *http.Server
+		staticKeys         map[string]string
+		staticListener     net.Listener
+		staticMultipartDir string
+		staticRenter       modules.Renter
+		staticStopChan     chan struct{}
+
+		// uploads contains the unfinished multipart uploads by their id.
+		// multipartStorage is the size of their parts on disk.
+		uploads          map[string]*multipartUpload
+		multipartStorage int64
+
+		serveChan chan struct{}
+		serveErr  error
+		mu        sync.Mutex
+	}
+
+	// s3Error is an error which is returned to the client using an S3 error
+	// code.
+	s3Error struct {
+		code    string
+		message string
+		status  int
+	}
+
+	// errorResponse is the body of an error response.
+	errorResponse struct {
+		XMLName  xml.Name `xml:"Error"`
+		Code     string
+		Message  string
+		Resource string
+	}
+)
+
+// Errors which are returned to the client.
+var (
+	errAccessDenied              = &s3Error{"AccessDenied", "Access Denied", http.StatusForbidden}
+	errAuthorizationMalformed    = &s3Error{"AuthorizationHeaderMalformed", "The authorization header is malformed.", http.StatusBadRequest}
+	errBadDigest                 = &s3Error{"BadDigest", "The Content-MD5 you specified did not match what we received.", http.StatusBadRequest}
+	errBucketAlreadyOwnedByYou   = &s3Error{"BucketAlreadyOwnedByYou", "The bucket you tried to create already exists.", http.StatusConflict}
+	errBucketNotEmpty            = &s3Error{"BucketNotEmpty", "The bucket you tried to delete is not empty.", http.StatusConflict}
+	errContentSHA256Mismatch     = &s3Error{"XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.", http.StatusBadRequest}
+	errEntityTooLarge            = &s3Error{"EntityTooLarge", "Your proposed upload exceeds the maximum allowed size.", http.StatusBadRequest}
+	errInvalidAccessKeyID        = &s3Error{"InvalidAccessKeyId", "The access key Id you provided does not exist in our records.", http.StatusForbidden}
+	errInvalidArgument           = &s3Error{"InvalidArgument", "Invalid argument.", http.StatusBadRequest}
+	errInvalidBucketName         = &s3Error{"InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest}
+	errInvalidDigest             = &s3Error{"InvalidDigest", "The Content-MD5 you specified is not valid.", http.StatusBadRequest}
+	errInvalidObjectName         = &s3Error{"InvalidArgument", "The specified key is not a valid siapath.", http.StatusBadRequest}
+	errInvalidPart               = &s3Error{"InvalidPart", "One or more of the specified parts could not be found.", http.StatusBadRequest}
+	errInvalidPartOrder          = &s3Error{"InvalidPartOrder", "The list of parts was not in ascending order.", http.StatusBadRequest}
+	errMalformedXML              = &s3Error{"MalformedXML", "The XML you provided was not well-formed.", http.StatusBadRequest}
+	errMethodNotAllowed          = &s3Error{"MethodNotAllowed", "The specified method is not allowed against this resource.", http.StatusMethodNotAllowed}
+	errMissingContentSHA256      = &s3Error{"InvalidRequest", "Missing required header for this request: x-amz-content-sha256.", http.StatusBadRequest}
+	errMultipartStorageFull      = &s3Error{"SlowDown", "The storage for unfinished multipart uploads is full.", http.StatusServiceUnavailable}
+	errNoSuchBucket              = &s3Error{"NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound}
+	errNoSuchKey                 = &s3Error{"NoSuchKey", "The specified key does not exist.", http.StatusNotFound}
+	errNoSuchUpload              = &s3Error{"NoSuchUpload", "The specified multipart upload does not exist.", http.StatusNotFound}
+	errNotImplemented            = &s3Error{"NotImplemented", "A header or query parameter you provided implies functionality that is not implemented.", http.StatusNotImplemented}
+	errRequestTimeTooSkewed      = &s3Error{"RequestTimeTooSkewed", "The difference between the request time and the server's time is too large.", http.StatusForbidden}
+	errSignatureDoesNotMatch     = &s3Error{"SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden}
+	errUnsupportedAuthentication = &s3Error{"InvalidRequest", "Only AWS Signature Version 4 in the Authorization header is supported.", http.StatusBadRequest}
+
+	// clientErrors are errors which are caused by the request body and
+	// therefore might be wrapped by the renter.
+	clientErrors = []*s3Error{errBadDigest, errContentSHA256Mismatch}
+)
+
+// Error implements the error interface.
+func (e *s3Error) Error() string {
+	return e.code + ": " + e.message
+}
+
+// New creates a new S3 server which listens on the provided address and
+// serves the renter's files to clients which sign their requests with one of
+// the provided keys. The keys map access key ids to secret keys. The parts of
+// multipart uploads are stored within the persist directory until the upload
+// is completed, aborted or expired.
+func New(addr, persistDir string, keys map[string]string, r modules.Renter) (*Server, error) {
+	if len(keys) == 0 {
+		return nil, errNoKeys
+	}
+	// Multipart uploads don't survive a restart, so clear the parts of any
+	// previous uploads.
+	dir := filepath.Join(persistDir, multipartDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, errors.AddContext(err, "unable to clear multipart directory")
+	}
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		return nil, errors.AddContext(err, "unable to create multipart directory")
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create s3 listener")
+	}
+	srv := &Server{
+		staticKeys:         keys,
+		staticListener:     listener,
+		staticMultipartDir: dir,
+		staticRenter:       r,
+		staticStopChan:     make(chan struct{}),
+
+		uploads: make(map[string]*multipartUpload),
+
+		serveChan: make(chan struct{}),
+	}
+	srv.staticHTTPServer = &http.Server{
+		Handler: srv,
+
+		// Uploads and downloads of large objects can take a long time, so
+		// only the headers are subject to a short timeout.
+		ReadHeaderTimeout: time.Minute * 2,
+		IdleTimeout:       time.Minute * 5,
+	}
+	go func() {
+		srv.serveErr = srv.staticHTTPServer.Serve(listener)
+		close(srv.serveChan)
+	}()
+	go srv.threadedExpireMultipartUploads()
+	return srv, nil
+}
+
+// Addr returns the address the server is listening on.
+func (srv *Server) Addr() net.Addr {
+	return srv.staticListener.Addr()
+}
+
+// Close shuts the server down.
+func (srv *Server) Close() error {
+	close(srv.staticStopChan)
+	err := srv.staticHTTPServer.Shutdown(context.Background())
+	// Wait for Serve to return and capture its error.
+	<-srv.serveChan
+	if !errors.Contains(srv.serveErr, http.ErrServerClosed) {
+		err = errors.Compose(err, srv.serveErr)
+	}
+	return errors.AddContext(err, "error while closing s3 server")
+}
+
+// ServeHTTP implements the http.Handler interface. It authenticates the
+// request and routes it to the handler of the requested operation.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if err := srv.authenticate(req); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	bucket, key := splitPath(req.URL.Path)
+	query := req.URL.Query()
+	_, uploads := query["uploads"]
+	uploadID := query.Get("uploadId")
+
+	var err error
+	switch {
+	case bucket == "" && req.Method == http.MethodGet:
+		err = srv.listBuckets(w)
+	case bucket == "":
+		err = errMethodNotAllowed
+	case key == "" && req.Method == http.MethodGet && query.Get("list-type") == "2":
+		err = srv.listObjectsV2(w, bucket, query)
+	case key == "" && req.Method == http.MethodGet:
+		// Only ListObjectsV2 is supported.
+		err = errNotImplemented
+	case key == "" && req.Method == http.MethodHead:
+		err = srv.headBucket(w, bucket)
+	case key == "" && req.Method == http.MethodPut:
+		err = srv.createBucket(w, bucket)
+	case key == "" && req.Method == http.MethodDelete:
+		err = srv.deleteBucket(w, bucket)
+	case key == "":
+		err = errMethodNotAllowed
+	case req.Method == http.MethodPost && uploads:
+		err = srv.createMultipartUpload(w, bucket, key)
+	case req.Method == http.MethodPost && uploadID != "":
+		err = srv.completeMultipartUpload(w, req, bucket, key, uploadID)
+	case req.Method == http.MethodPut && uploadID != "":
+		err = srv.uploadPart(w, req, bucket, key, uploadID, query.Get("partNumber"))
+	case req.Method == http.MethodDelete && uploadID != "":
+		err = srv.abortMultipartUpload(w, bucket, key, uploadID)
+	case req.Method == http.MethodPut:
+		err = srv.putObject(w, req, bucket, key)
+	case req.Method == http.MethodGet:
+		err = srv.getObject(w, req, bucket, key)
+	case req.Method == http.MethodHead:
+		err = srv.headObject(w, bucket, key)
+	case req.Method == http.MethodDelete:
+		err = srv.deleteObject(w, bucket, key)
+	default:
+		err = errMethodNotAllowed
+	}
+	if err != nil {
+		writeError(w, req, err)
+	}
+}
+
+// splitPath splits the path of a path-style request into the bucket and the
+// key.
+func splitPath(path string) (bucket, key string) {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.Index(path, "/"); i >= 0 {
+		return path[:i], path[i+1:]
+	}
+	return path, ""
+}
+
+// isNotExist returns whether the error indicates that a file or directory
+// doesn't exist.
+func isNotExist(err error) bool {
+	return os.IsNotExist(err) || errors.Contains(err, filesystem.ErrNotExist)
+}
+
+// writeError writes an error response. Errors which aren't s3Errors are
+// reported as internal errors.
+func writeError(w http.ResponseWriter, req *http.Request, err error) {
+	s3Err, ok := err.(*s3Error)
+	for i := 0; !ok && i < len(clientErrors); i++ {
+		if errors.Contains(err, clientErrors[i]) {
+			s3Err, ok = clientErrors[i], true
+		}
+	}
+	if !ok {
+		s3Err = &s3Error{"InternalError", err.Error(), http.StatusInternalServerError}
+	}
+	// Responses to HEAD requests don't have a body.
+	if req.Method == http.MethodHead {
+		w.WriteHeader(s3Err.status)
+		return
+	}
+	writeXML(w, s3Err.status, errorResponse{
+		Code:     s3Err.code,
+		Message:  s3Err.message,
+		Resource: req.URL.Path,
+	})
+}
+
+// writeXML writes a response with an XML body.
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(v)
+}
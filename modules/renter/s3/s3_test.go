@@ -0,0 +1,486 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+)
+
+type (
+	// mockRenter is an in-memory implementation of the parts of the
+	// modules.Renter interface which are used by the s3 server.
+	mockRenter struct {
+		modules.Renter
+
+		dirs  map[modules.SiaPath]struct{}
+		files map[modules.SiaPath][]byte
+		mu    sync.Mutex
+	}
+
+	// mockStreamer is a modules.Streamer for the data of a mockRenter file.
+	mockStreamer struct {
+		*bytes.Reader
+	}
+)
+
+// newMockRenter creates a mockRenter which only contains the root and the
+// user folder.
+func newMockRenter() *mockRenter {
+	return &mockRenter{
+		dirs: map[modules.SiaPath]struct{}{
+			modules.RootSiaPath(): {},
+			modules.UserFolder:    {},
+		},
+		files: make(map[modules.SiaPath][]byte),
+	}
+}
+
+// Close implements the io.Closer interface.
+func (ms mockStreamer) Close() error { return nil }
+
+// parent returns the parent directory of a siapath.
+func parent(sp modules.SiaPath) modules.SiaPath {
+	dir, err := sp.Dir()
+	if err != nil {
+		panic(err)
+	}
+	return dir
+}
+
+// CreateDir implements the modules.Renter interface.
+func (mr *mockRenter) CreateDir(sp modules.SiaPath, mode os.FileMode) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.dirs[sp]; exists {
+		return filesystem.ErrExists
+	}
+	mr.dirs[sp] = struct{}{}
+	return nil
+}
+
+// DeleteDir implements the modules.Renter interface.
+func (mr *mockRenter) DeleteDir(sp modules.SiaPath) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.dirs[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	delete(mr.dirs, sp)
+	return nil
+}
+
+// DeleteFile implements the modules.Renter interface.
+func (mr *mockRenter) DeleteFile(sp modules.SiaPath) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.files[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	delete(mr.files, sp)
+	return nil
+}
+
+// DirList implements the modules.Renter interface.
+func (mr *mockRenter) DirList(sp modules.SiaPath) ([]modules.DirectoryInfo, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.dirs[sp]; !exists {
+		return nil, filesystem.ErrNotExist
+	}
+	dis := []modules.DirectoryInfo{{SiaPath: sp}}
+	for dir := range mr.dirs {
+		if !dir.IsRoot() && parent(dir).Equals(sp) {
+			dis = append(dis, modules.DirectoryInfo{SiaPath: dir})
+		}
+	}
+	return dis, nil
+}
+
+// File implements the modules.Renter interface.
+func (mr *mockRenter) File(sp modules.SiaPath) (modules.FileInfo, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	data, exists := mr.files[sp]
+	if !exists {
+		return modules.FileInfo{}, filesystem.ErrNotExist
+	}
+	return modules.FileInfo{SiaPath: sp, Filesize: uint64(len(data))}, nil
+}
+
+// FileList implements the modules.Renter interface.
+func (mr *mockRenter) FileList(sp modules.SiaPath, recursive, cached bool, flf modules.FileListFunc) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.dirs[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	for file, data := range mr.files {
+		inDir := parent(file).Equals(sp)
+		if recursive {
+			inDir = strings.HasPrefix(file.String(), sp.String()+"/")
+		}
+		if inDir {
+			flf(modules.FileInfo{SiaPath: file, Filesize: uint64(len(data))})
+		}
+	}
+	return nil
+}
+
+// Streamer implements the modules.Renter interface.
+func (mr *mockRenter) Streamer(sp modules.SiaPath, disableLocalFetch bool) (string, modules.Streamer, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	data, exists := mr.files[sp]
+	if !exists {
+		return "", nil, filesystem.ErrNotExist
+	}
+	return sp.Name(), mockStreamer{bytes.NewReader(data)}, nil
+}
+
+// OverwriteFile implements the modules.Renter interface. Like the renter, it
+// creates the missing parent directories of the destination.
+func (mr *mockRenter) OverwriteFile(srcPath, dstPath modules.SiaPath) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	data, exists := mr.files[srcPath]
+	if !exists {
+		return filesystem.ErrNotExist
+	}
+	delete(mr.files, srcPath)
+	mr.files[dstPath] = data
+	for dir := parent(dstPath); !dir.IsRoot(); dir = parent(dir) {
+		mr.dirs[dir] = struct{}{}
+	}
+	return nil
+}
+
+// UploadStreamFromReader implements the modules.Renter interface. Like the
+// renter, it creates the missing parent directories of the file and keeps the
+// data which was uploaded before reading from the reader failed.
+func (mr *mockRenter) UploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) error {
+	data, readErr := ioutil.ReadAll(reader)
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.files[up.SiaPath]; exists && !up.Force {
+		return filesystem.ErrExists
+	}
+	mr.files[up.SiaPath] = data
+	for dir := parent(up.SiaPath); !dir.IsRoot(); dir = parent(dir) {
+		mr.dirs[dir] = struct{}{}
+	}
+	return errors.AddContext(readErr, "unable to read data")
+}
+
+// numUploads returns the number of files within the uploads folder.
+func (mr *mockRenter) numUploads() int {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	var n int
+	for file := range mr.files {
+		if strings.HasPrefix(file.String(), modules.UploadsFolder.String()+"/") {
+			n++
+		}
+	}
+	return n
+}
+
+// s3Tester is a helper to send signed requests to a server.
+type s3Tester struct {
+	staticBaseURL string
+	staticT       *testing.T
+}
+
+// request sends a signed request and checks the status code of the response.
+func (st *s3Tester) request(method, target string, body []byte, header http.Header, status int) ([]byte, http.Header) {
+	t := st.staticT
+	t.Helper()
+	req, err := http.NewRequest(method, st.staticBaseURL+target, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	signRequest(req, body, "access", "secret", time.Now())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != status {
+		t.Fatalf("%v %v: expected status %v but got %v: %s", method, target, status, resp.StatusCode, respBody)
+	}
+	return respBody, resp.Header
+}
+
+// list sends a ListObjectsV2 request.
+func (st *s3Tester) list(bucket string, params url.Values) listBucketResult {
+	t := st.staticT
+	t.Helper()
+	params.Set("list-type", "2")
+	body, _ := st.request(http.MethodGet, "/"+bucket+"?"+params.Encode(), nil, nil, http.StatusOK)
+	var result listBucketResult
+	if err := xml.Unmarshal(body, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// keys returns the keys and common prefixes of a listBucketResult.
+func keys(result listBucketResult) (keys, prefixes []string) {
+	for _, obj := range result.Contents {
+		keys = append(keys, obj.Key)
+	}
+	for _, cp := range result.CommonPrefixes {
+		prefixes = append(prefixes, cp.Prefix)
+	}
+	return keys, prefixes
+}
+
+// TestServer tests the s3 server with a mocked renter.
+func TestServer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// A server without keys can't be created.
+	dir := build.TempDir("s3", t.Name())
+	mr := newMockRenter()
+	if _, err := New("localhost:0", dir, nil, mr); !errors.Contains(err, errNoKeys) {
+		t.Fatal("expected errNoKeys", err)
+	}
+	srv, err := New("localhost:0", dir, map[string]string{"access": "secret"}, mr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := srv.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	st := &s3Tester{
+		staticBaseURL: "http://" + srv.Addr().String(),
+		staticT:       t,
+	}
+
+	// Unsigned requests are rejected.
+	resp, err := http.Get(st.staticBaseURL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatal("expected forbidden but got", resp.StatusCode)
+	}
+
+	// Create a bucket.
+	st.request(http.MethodPut, "/bucket", nil, nil, http.StatusOK)
+	st.request(http.MethodPut, "/bucket", nil, nil, http.StatusConflict)
+	st.request(http.MethodHead, "/bucket", nil, nil, http.StatusOK)
+	st.request(http.MethodHead, "/missing", nil, nil, http.StatusNotFound)
+	body, _ := st.request(http.MethodGet, "/", nil, nil, http.StatusOK)
+	if !bytes.Contains(body, []byte("<Name>bucket</Name>")) {
+		t.Fatalf("bucket is missing from listing: %s", body)
+	}
+
+	// Upload some objects.
+	data := fastrand.Bytes(100)
+	for _, key := range []string{"dir/a.txt", "dir/b.txt", "c.txt", "dir/sub/d.txt"} {
+		st.request(http.MethodPut, "/bucket/"+key, data, nil, http.StatusOK)
+	}
+	st.request(http.MethodPut, "/missing/a.txt", data, nil, http.StatusNotFound)
+	st.request(http.MethodPut, "/bucket/dir/../e.txt", data, nil, http.StatusBadRequest)
+	sp, _ := modules.UserFolder.Join("bucket/dir/a.txt")
+	if !bytes.Equal(mr.files[sp], data) {
+		t.Fatal("uploaded data doesn't match")
+	}
+
+	// Uploads with an invalid Content-MD5 are rejected and don't replace the
+	// existing object.
+	sum := md5.Sum([]byte("other data"))
+	md5Header := http.Header{"Content-Md5": []string{base64.StdEncoding.EncodeToString(sum[:])}}
+	st.request(http.MethodPut, "/bucket/f.txt", data, md5Header, http.StatusBadRequest)
+	st.request(http.MethodPut, "/bucket/dir/a.txt", fastrand.Bytes(100), md5Header, http.StatusBadRequest)
+	if !bytes.Equal(mr.files[sp], data) {
+		t.Fatal("object was replaced by an upload with an invalid Content-MD5")
+	}
+
+	// Uploads with a body which doesn't match the signed payload hash are
+	// rejected as well.
+	req, err := http.NewRequest(http.MethodPut, st.staticBaseURL+"/bucket/dir/a.txt", bytes.NewReader(fastrand.Bytes(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signRequest(req, fastrand.Bytes(100), "access", "secret", time.Now())
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("expected bad request but got", resp.StatusCode)
+	}
+	if !bytes.Equal(mr.files[sp], data) {
+		t.Fatal("object was replaced by an upload with an invalid payload hash")
+	}
+	if n := mr.numUploads(); n != 0 {
+		t.Fatal("failed uploads weren't deleted", n)
+	}
+
+	// Download an object and a range of it.
+	if body, _ := st.request(http.MethodGet, "/bucket/dir/a.txt", nil, nil, http.StatusOK); !bytes.Equal(body, data) {
+		t.Fatal("downloaded data doesn't match")
+	}
+	rangeHeader := http.Header{"Range": []string{"bytes=10-19"}}
+	if body, _ := st.request(http.MethodGet, "/bucket/dir/a.txt", nil, rangeHeader, http.StatusPartialContent); !bytes.Equal(body, data[10:20]) {
+		t.Fatal("downloaded range doesn't match")
+	}
+	st.request(http.MethodGet, "/bucket/missing.txt", nil, nil, http.StatusNotFound)
+	if _, header := st.request(http.MethodHead, "/bucket/dir/a.txt", nil, nil, http.StatusOK); header.Get("Content-Length") != "100" {
+		t.Fatal("wrong content length", header.Get("Content-Length"))
+	}
+
+	// List the bucket with a delimiter.
+	result := st.list("bucket", url.Values{"delimiter": []string{"/"}})
+	objects, prefixes := keys(result)
+	if strings.Join(objects, ",") != "c.txt" || strings.Join(prefixes, ",") != "dir/" {
+		t.Fatal("unexpected listing", objects, prefixes)
+	}
+	result = st.list("bucket", url.Values{"prefix": []string{"dir/"}, "delimiter": []string{"/"}})
+	objects, prefixes = keys(result)
+	if strings.Join(objects, ",") != "dir/a.txt,dir/b.txt" || strings.Join(prefixes, ",") != "dir/sub/" {
+		t.Fatal("unexpected listing", objects, prefixes)
+	}
+
+	// List the bucket page by page.
+	var all []string
+	params := url.Values{"max-keys": []string{"1"}}
+	for i := 0; ; i++ {
+		result = st.list("bucket", params)
+		objects, _ = keys(result)
+		all = append(all, objects...)
+		if !result.IsTruncated {
+			break
+		}
+		if i > 4 {
+			t.Fatal("too many pages")
+		}
+		params.Set("continuation-token", result.NextContinuationToken)
+	}
+	if strings.Join(all, ",") != "c.txt,dir/a.txt,dir/b.txt,dir/sub/d.txt" {
+		t.Fatal("unexpected listing", all)
+	}
+
+	// Upload an object in multiple parts.
+	body, _ = st.request(http.MethodPost, "/bucket/big?uploads", nil, nil, http.StatusOK)
+	var initResult initiateMultipartUploadResult
+	if err := xml.Unmarshal(body, &initResult); err != nil {
+		t.Fatal(err)
+	}
+	uploadID := initResult.UploadID
+	part1, part2 := fastrand.Bytes(200), fastrand.Bytes(50)
+	_, header2 := st.request(http.MethodPut, "/bucket/big?partNumber=2&uploadId="+uploadID, part2, nil, http.StatusOK)
+	_, header1 := st.request(http.MethodPut, "/bucket/big?partNumber=1&uploadId="+uploadID, part1, nil, http.StatusOK)
+	st.request(http.MethodPut, "/bucket/big?partNumber=1&uploadId=unknown", part1, nil, http.StatusNotFound)
+	complete := func(parts ...completedPart) []byte {
+		b, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	wrongOrder := complete(completedPart{2, header2.Get("ETag")}, completedPart{1, header1.Get("ETag")})
+	st.request(http.MethodPost, "/bucket/big?uploadId="+uploadID, wrongOrder, nil, http.StatusBadRequest)
+	wrongETag := complete(completedPart{1, header2.Get("ETag")}, completedPart{2, header2.Get("ETag")})
+	st.request(http.MethodPost, "/bucket/big?uploadId="+uploadID, wrongETag, nil, http.StatusBadRequest)
+	parts := complete(completedPart{1, header1.Get("ETag")}, completedPart{2, header2.Get("ETag")})
+	st.request(http.MethodPost, "/bucket/big?uploadId="+uploadID, parts, nil, http.StatusOK)
+	if body, _ := st.request(http.MethodGet, "/bucket/big", nil, nil, http.StatusOK); !bytes.Equal(body, append(part1, part2...)) {
+		t.Fatal("multipart upload data doesn't match")
+	}
+	st.request(http.MethodPost, "/bucket/big?uploadId="+uploadID, parts, nil, http.StatusNotFound)
+
+	// Abort a multipart upload.
+	body, _ = st.request(http.MethodPost, "/bucket/aborted?uploads", nil, nil, http.StatusOK)
+	if err := xml.Unmarshal(body, &initResult); err != nil {
+		t.Fatal(err)
+	}
+	st.request(http.MethodPut, "/bucket/aborted?partNumber=1&uploadId="+initResult.UploadID, part1, nil, http.StatusOK)
+	st.request(http.MethodDelete, "/bucket/aborted?uploadId="+initResult.UploadID, nil, nil, http.StatusNoContent)
+	st.request(http.MethodPut, "/bucket/aborted?partNumber=2&uploadId="+initResult.UploadID, part2, nil, http.StatusNotFound)
+	if _, err := os.Stat(srv.staticMultipartDir + "/" + initResult.UploadID); !os.IsNotExist(err) {
+		t.Fatal("parts weren't removed", err)
+	}
+
+	// Parts which are too large are rejected.
+	body, _ = st.request(http.MethodPost, "/bucket/limited?uploads", nil, nil, http.StatusOK)
+	if err := xml.Unmarshal(body, &initResult); err != nil {
+		t.Fatal(err)
+	}
+	limitedURL := "/bucket/limited?uploadId=" + initResult.UploadID + "&partNumber="
+	st.request(http.MethodPut, limitedURL+"1", fastrand.Bytes(int(maxPartSize)+1), nil, http.StatusBadRequest)
+
+	// The parts of all unfinished uploads can't exceed the storage limit.
+	// Replacing a part only counts the difference in size.
+	partSize := int(maxMultipartStorage) / 3
+	st.request(http.MethodPut, limitedURL+"1", fastrand.Bytes(partSize), nil, http.StatusOK)
+	st.request(http.MethodPut, limitedURL+"2", fastrand.Bytes(partSize), nil, http.StatusOK)
+	st.request(http.MethodPut, limitedURL+"2", fastrand.Bytes(partSize), nil, http.StatusOK)
+	st.request(http.MethodPut, limitedURL+"3", fastrand.Bytes(partSize), nil, http.StatusOK)
+	st.request(http.MethodPut, limitedURL+"4", fastrand.Bytes(partSize), nil, http.StatusServiceUnavailable)
+
+	// Expired uploads are aborted and free their storage.
+	if err := srv.managedExpireMultipartUploads(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	st.request(http.MethodPut, limitedURL+"4", nil, nil, http.StatusOK)
+	if err := srv.managedExpireMultipartUploads(time.Now().Add(multipartUploadExpiry + time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	st.request(http.MethodPut, limitedURL+"4", nil, nil, http.StatusNotFound)
+	if _, err := os.Stat(srv.staticMultipartDir + "/" + initResult.UploadID); !os.IsNotExist(err) {
+		t.Fatal("parts of expired upload weren't removed", err)
+	}
+	srv.mu.Lock()
+	storage := srv.multipartStorage
+	srv.mu.Unlock()
+	if storage != 0 {
+		t.Fatal("storage of expired upload wasn't freed", storage)
+	}
+
+	// Delete the objects and the bucket.
+	st.request(http.MethodDelete, "/bucket", nil, nil, http.StatusConflict)
+	for _, key := range []string{"dir/a.txt", "dir/b.txt", "c.txt", "dir/sub/d.txt", "big", "missing"} {
+		st.request(http.MethodDelete, "/bucket/"+key, nil, nil, http.StatusNoContent)
+	}
+	for _, dir := range []string{"bucket/dir/sub", "bucket/dir"} {
+		sp, _ := modules.UserFolder.Join(dir)
+		if err := mr.DeleteDir(sp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	st.request(http.MethodDelete, "/bucket", nil, nil, http.StatusNoContent)
+	st.request(http.MethodHead, "/bucket", nil, nil, http.StatusNotFound)
+}
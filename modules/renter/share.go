@@ -0,0 +1,252 @@
+package renter
+
+// Siafiles can be shared with other renters by exporting them into a share.
+// A share contains a JSON header followed by a gzipped tarball of the raw
+// siafiles. Since a siafile contains the master key, the host keys and the
+// piece roots of the file, another renter can use it to download the data
+// directly from the hosts or to repair it onto its own hosts. The local path of
+// a siafile is removed when it is imported since it refers to the machine of
+// the renter which exported it. Shares can optionally be ASCII-armored to make
+// them easier to copy and paste.
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+const (
+	// shareArchiveVersion is the current version of the share format.
+	shareArchiveVersion = "1.0"
+
+	// shareArmorHeader and shareArmorFooter enclose the base64 encoded data
+	// of an ASCII-armored share.
+	shareArmorHeader = "-----BEGIN SIA SHARE-----"
+	shareArmorFooter = "-----END SIA SHARE-----"
+
+	// shareArmorLineLength is the maximum length of a line of base64 encoded
+	// data within an ASCII-armored share.
+	shareArmorLineLength = 64
+)
+
+var (
+	// errInvalidShare is returned when data which is not a valid share is
+	// imported.
+	errInvalidShare = errors.New("invalid share")
+
+	// errNoSharedFiles is returned when a share without any files should be
+	// created.
+	errNoSharedFiles = errors.New("no files to share")
+
+	// errSharePartialChunk is returned when a siafile with a partial chunk is
+	// shared. The partial chunk is stored in a separate siafile which can't
+	// be shared.
+	errSharePartialChunk = errors.New("files with a partial chunk can't be shared")
+)
+
+// shareArchiveHeader defines the structure of the share's JSON header.
+type shareArchiveHeader struct {
+	Version string `json:"version"`
+}
+
+// armorShare returns the ASCII-armored version of the provided share.
+func armorShare(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var buf bytes.Buffer
+	buf.WriteString(shareArmorHeader + "\n")
+	for len(encoded) > shareArmorLineLength {
+		buf.WriteString(encoded[:shareArmorLineLength] + "\n")
+		encoded = encoded[shareArmorLineLength:]
+	}
+	buf.WriteString(encoded + "\n")
+	buf.WriteString(shareArmorFooter + "\n")
+	return buf.Bytes()
+}
+
+// unarmorShare returns the raw share of an ASCII-armored share. If the
+// provided share is not armored, it is returned unchanged.
+func unarmorShare(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte(shareArmorHeader)) {
+		return data, nil
+	}
+	if !bytes.HasSuffix(trimmed, []byte(shareArmorFooter)) {
+		return nil, errors.AddContext(errInvalidShare, "missing armor footer")
+	}
+	body := trimmed[len(shareArmorHeader) : len(trimmed)-len(shareArmorFooter)]
+	body = bytes.Join(bytes.Fields(body), nil)
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(body)))
+	n, err := base64.StdEncoding.Decode(decoded, body)
+	if err != nil {
+		return nil, errors.Compose(errInvalidShare, err)
+	}
+	return decoded[:n], nil
+}
+
+// ExportSiaFile creates a share of the siafiles at the given siapaths which
+// can be imported by another renter using ImportSiaFile. If ascii is set, the
+// share is ASCII-armored.
+func (r *Renter) ExportSiaFile(siaPaths []modules.SiaPath, ascii bool) ([]byte, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	if len(siaPaths) == 0 {
+		return nil, errNoSharedFiles
+	}
+
+	// Write the header.
+	var buf bytes.Buffer
+	sh := shareArchiveHeader{
+		Version: shareArchiveVersion,
+	}
+	if err := json.NewEncoder(&buf).Encode(sh); err != nil {
+		return nil, err
+	}
+
+	// Add the siafiles to the archive. The siafiles are stored by name so
+	// the names need to be unique.
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	names := make(map[string]struct{})
+	for _, siaPath := range siaPaths {
+		name := siaPath.Name() + modules.SiaFileExtension
+		if _, exists := names[name]; exists {
+			err := fmt.Errorf("can't share multiple files named '%v'", siaPath.Name())
+			return nil, errors.Compose(err, tw.Close(), gzw.Close())
+		}
+		names[name] = struct{}{}
+		if err := r.managedTarSharedSiaFile(tw, siaPath, name); err != nil {
+			err = errors.AddContext(err, fmt.Sprintf("unable to share file '%v'", siaPath))
+			return nil, errors.Compose(err, tw.Close(), gzw.Close())
+		}
+	}
+	if err := errors.Compose(tw.Close(), gzw.Close()); err != nil {
+		return nil, err
+	}
+	if ascii {
+		return armorShare(buf.Bytes()), nil
+	}
+	return buf.Bytes(), nil
+}
+
+// managedTarSharedSiaFile adds the siafile at the given siapath to the
+// provided archive using the provided name.
+func (r *Renter) managedTarSharedSiaFile(tw *tar.Writer, siaPath modules.SiaPath, name string) (err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	if entry.HasPartialChunk() {
+		return errSharePartialChunk
+	}
+	// Get a reader to read from the siafile.
+	sr, err := entry.SnapshotReader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, sr.Close())
+	}()
+	fi, err := sr.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Name:    name,
+		Mode:    int64(entry.Mode()),
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, sr)
+	return err
+}
+
+// ImportSiaFile adds the siafiles of a share created by ExportSiaFile to the
+// dir at the given siapath. Both raw and ASCII-armored shares are accepted. If
+// a file with the same name already exists in the dir, a suffix is added to the
+// name of the imported file.
+func (r *Renter) ImportSiaFile(data []byte, dirSiaPath modules.SiaPath) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Remove the armor and check the header.
+	data, err = unarmorShare(data)
+	if err != nil {
+		return err
+	}
+	i := bytes.IndexByte(data, '\n')
+	if i == -1 {
+		return errors.AddContext(errInvalidShare, "missing header")
+	}
+	var sh shareArchiveHeader
+	if err := json.Unmarshal(data[:i], &sh); err != nil {
+		return errors.Compose(errInvalidShare, err)
+	}
+	if sh.Version != shareArchiveVersion {
+		return fmt.Errorf("unknown share version '%v'", sh.Version)
+	}
+
+	// Open the archive.
+	gzr, err := gzip.NewReader(bytes.NewReader(data[i+1:]))
+	if err != nil {
+		return errors.Compose(errInvalidShare, err)
+	}
+	defer func() {
+		err = errors.Compose(err, gzr.Close())
+	}()
+	tr := tar.NewReader(gzr)
+
+	// The metadata of the dir needs to be updated after adding the files.
+	dirsToUpdate := r.newUniqueRefreshPaths()
+	defer dirsToUpdate.callRefreshAll()
+	if err := dirsToUpdate.callAdd(dirSiaPath); err != nil {
+		return errors.AddContext(err, fmt.Sprintf("could not add directory %v to the list of directories to be updated", dirSiaPath))
+	}
+
+	// Add the files.
+	for {
+		header, err := tr.Next()
+		if errors.Contains(err, io.EOF) {
+			break
+		} else if err != nil {
+			return errors.AddContext(err, "could not get next entry in the share")
+		}
+		name := header.Name
+		if filepath.Ext(name) != modules.SiaFileExtension || filepath.Base(name) != name {
+			return errors.AddContext(errInvalidShare, fmt.Sprintf("unexpected entry '%v'", name))
+		}
+		siaPath, err := dirSiaPath.Join(strings.TrimSuffix(name, modules.SiaFileExtension))
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return errors.AddContext(err, "could not load the shared file in memory")
+		}
+		err = r.staticFileSystem.ImportSiaFileFromReader(bytes.NewReader(b), siaPath)
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("could not import file '%v'", siaPath))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package renter
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/persist"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestShareArmor tests that ASCII-armored shares can be unarmored and that
+// raw shares are not changed by unarmoring them.
+func TestShareArmor(t *testing.T) {
+	t.Parallel()
+
+	data := fastrand.Bytes(int(fastrand.Intn(1000)) + 1000)
+	armored := armorShare(data)
+	if !bytes.HasPrefix(armored, []byte(shareArmorHeader)) {
+		t.Fatal("armored share is missing the header")
+	}
+	unarmored, err := unarmorShare(armored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unarmored, data) {
+		t.Fatal("unarmored share doesn't match the original data")
+	}
+	unarmored, err = unarmorShare(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unarmored, data) {
+		t.Fatal("raw share was changed")
+	}
+	_, err = unarmorShare(armored[:len(armored)-len(shareArmorFooter)-1])
+	if !errors.Contains(err, errInvalidShare) {
+		t.Fatal("expected errInvalidShare", err)
+	}
+}
+
+// TestExportImportSiaFile tests exporting siafiles into a share and importing
+// them again.
+func TestExportImportSiaFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a file without a partial chunk which has a local path and a
+	// piece.
+	siaPath, rsc := testingFileParams()
+	err = r.staticFileSystem.NewSiaFile(siaPath, "", rsc, crypto.GenerateSiaKey(crypto.RandomCipherType()), 1000, persist.DefaultDiskPermissionsTest, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	if err := entry.AddPiece(hostKey, 0, 0, root); err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.SetLocalPath("TestPath"); err != nil {
+		t.Fatal(err)
+	}
+	masterKey := entry.MasterKey()
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sharing no files or files with the same name should fail.
+	if _, err := r.ExportSiaFile(nil, false); !errors.Contains(err, errNoSharedFiles) {
+		t.Fatal("expected errNoSharedFiles", err)
+	}
+	if _, err := r.ExportSiaFile([]modules.SiaPath{siaPath, siaPath}, false); err == nil {
+		t.Fatal("expected sharing files with the same name to fail")
+	}
+
+	// Export the file both raw and ASCII-armored and import the shares into
+	// different dirs.
+	raw, err := r.ExportSiaFile([]modules.SiaPath{siaPath}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	armored, err := r.ExportSiaFile([]modules.SiaPath{siaPath}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(armored, []byte(shareArmorHeader)) {
+		t.Fatal("share isn't armored")
+	}
+	for i, share := range [][]byte{raw, armored} {
+		dirSiaPath := modules.RandomSiaPath()
+		if err := r.ImportSiaFile(share, dirSiaPath); err != nil {
+			t.Fatal(i, err)
+		}
+		importedSiaPath, err := dirSiaPath.Join(siaPath.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		imported, err := r.staticFileSystem.OpenSiaFile(importedSiaPath)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if imported.LocalPath() != "" {
+			t.Error(i, "local path wasn't removed", imported.LocalPath())
+		}
+		if !bytes.Equal(imported.MasterKey().Key(), masterKey.Key()) {
+			t.Error(i, "master key doesn't match")
+		}
+		pieces, err := imported.Pieces(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pieces[0]) != 1 || !pieces[0][0].HostPubKey.Equals(hostKey) || pieces[0][0].MerkleRoot != root {
+			t.Error(i, "pieces don't match", pieces[0])
+		}
+		if err := imported.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Importing invalid data should fail.
+	if err := r.ImportSiaFile(fastrand.Bytes(100), modules.RandomSiaPath()); !errors.Contains(err, errInvalidShare) {
+		t.Fatal("expected errInvalidShare", err)
+	}
+}
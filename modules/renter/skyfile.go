@@ -33,6 +33,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"gitlab.com/NebulousLabs/Sia/fixtures"
@@ -71,6 +74,18 @@ var (
 	// ErrSkylinkBlocked is the error returned when a skylink is blocked
 	ErrSkylinkBlocked = errors.New("skylink is blocked")
 
+	// ErrSkylinkNotPinned is the error returned when trying to unpin a skylink
+	// that isn't stored in any of the renter's siafiles.
+	ErrSkylinkNotPinned = errors.New("skylink is not pinned")
+
+	// ErrUnpinSkylinkV2 is the error returned when trying to unpin a v2
+	// skylink. Only the v1 skylink it resolves to can be unpinned.
+	ErrUnpinSkylinkV2 = errors.New("v2 skylinks can't be unpinned, unpin the skylink they resolve to instead")
+
+	// ErrInvalidSkylinkV2Entry is the error returned when the registry entry
+	// a v2 skylink points to doesn't contain a valid skylink.
+	ErrInvalidSkylinkV2Entry = errors.New("registry entry of v2 skylink doesn't contain a valid skylink")
+
 	// ExtendedSuffix is the suffix that is added to a skyfile siapath if it is
 	// a large file upload
 	ExtendedSuffix = "-extended"
@@ -391,8 +406,9 @@ func (r *Renter) UpdateSkynetPortals(additions []modules.SkynetPortal, removals
 
 // managedUploadBaseSector will take the raw baseSector bytes and upload them,
 // returning the resulting merkle root, and the fileNode of the siafile that is
-// tracking the base sector.
-func (r *Renter) managedUploadBaseSector(lup modules.SkyfileUploadParameters, baseSector []byte, skylink modules.Skylink) (err error) {
+// tracking the base sector. Packed uploads can pass multiple skylinks since
+// every skyfile within the sector has its own skylink.
+func (r *Renter) managedUploadBaseSector(lup modules.SkyfileUploadParameters, baseSector []byte, skylinks ...modules.Skylink) (err error) {
 	fileUploadParams, err := fileUploadParamsFromLUP(lup)
 	if err != nil {
 		return errors.AddContext(err, "failed to create siafile upload parameters")
@@ -410,9 +426,14 @@ func (r *Renter) managedUploadBaseSector(lup modules.SkyfileUploadParameters, ba
 		err = errors.Compose(err, fileNode.Close())
 	}()
 
-	// Add the skylink to the Siafile.
-	err = fileNode.AddSkylink(skylink)
-	return errors.AddContext(err, "unable to add skylink to siafile")
+	// Add the skylinks to the Siafile.
+	for _, skylink := range skylinks {
+		err = fileNode.AddSkylink(skylink)
+		if err != nil {
+			return errors.AddContext(err, "unable to add skylink to siafile")
+		}
+	}
+	return nil
 }
 
 // managedUploadSkyfile uploads a file and returns the skylink and whether or
@@ -588,6 +609,10 @@ func (r *Renter) DownloadSkylink(link modules.Skylink, timeout time.Duration) (m
 		return modules.SkyfileMetadata{}, nil, err
 	}
 	defer r.tg.Done()
+	link, err := r.managedResolveSkylinkV2(link, timeout)
+	if err != nil {
+		return modules.SkyfileMetadata{}, nil, err
+	}
 	return r.managedDownloadSkylink(link, timeout)
 }
 
@@ -598,10 +623,53 @@ func (r *Renter) DownloadSkylinkBaseSector(link modules.Skylink, timeout time.Du
 		return nil, err
 	}
 	defer r.tg.Done()
+	link, err := r.managedResolveSkylinkV2(link, timeout)
+	if err != nil {
+		return nil, err
+	}
 	baseSector, err := r.managedDownloadBaseSector(link, timeout)
 	return StreamerFromSlice(baseSector), err
 }
 
+// ResolveSkylinkV2 resolves a v2 skylink to the v1 skylink it points to. If
+// the provided skylink is a v1 skylink, it is returned unchanged.
+func (r *Renter) ResolveSkylinkV2(link modules.Skylink, timeout time.Duration) (modules.Skylink, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.Skylink{}, err
+	}
+	defer r.tg.Done()
+	return r.managedResolveSkylinkV2(link, timeout)
+}
+
+// managedResolveSkylinkV2 follows a v2 skylink through the registry until it
+// reaches a v1 skylink. A v2 skylink may point to another v2 skylink, but only
+// up to modules.MaxSkylinkV2ResolvingDepth v2 skylinks are followed. Every
+// skylink along the way is checked against the blocklist.
+func (r *Renter) managedResolveSkylinkV2(link modules.Skylink, timeout time.Duration) (modules.Skylink, error) {
+	for depth := 0; link.IsSkylinkV2(); depth++ {
+		if depth >= modules.MaxSkylinkV2ResolvingDepth {
+			return modules.Skylink{}, modules.ErrSkylinkV2ResolvingDepthExceeded
+		}
+		if r.staticSkynetBlocklist.IsBlocked(link) {
+			return modules.Skylink{}, ErrSkylinkBlocked
+		}
+		srv, err := r.ReadRegistryRID(link.MerkleRoot(), timeout)
+		if err != nil {
+			return modules.Skylink{}, errors.AddContext(err, "failed to resolve v2 skylink")
+		}
+		var next modules.Skylink
+		if len(srv.Data) != modules.RawSkylinkSize {
+			return modules.Skylink{}, errors.AddContext(ErrInvalidSkylinkV2Entry, fmt.Sprintf("expected %v bytes of data but got %v", modules.RawSkylinkSize, len(srv.Data)))
+		}
+		err = next.LoadBytes(srv.Data)
+		if err != nil {
+			return modules.Skylink{}, errors.Compose(err, ErrInvalidSkylinkV2Entry)
+		}
+		link = next
+	}
+	return link, nil
+}
+
 // managedDownloadSkylink will take a link and turn it into the metadata and data of a
 // download.
 func (r *Renter) managedDownloadSkylink(link modules.Skylink, timeout time.Duration) (modules.SkyfileMetadata, modules.Streamer, error) {
@@ -676,8 +744,15 @@ func (r *Renter) managedDownloadBaseSector(link modules.Skylink, timeout time.Du
 		return nil, errors.AddContext(err, "unable to parse skylink")
 	}
 
+	// Check the skynet cache first.
+	id := link.DataSourceID()
+	baseSector, cached := r.staticSkynetCache.managedGet(id, skynetCacheBaseSectorIndex)
+	if cached && len(baseSector) >= modules.SkyfileLayoutSize {
+		return baseSector, nil
+	}
+
 	// Fetch the leading chunk.
-	baseSector, err := r.DownloadByRoot(link.MerkleRoot(), offset, fetchSize, timeout)
+	baseSector, err = r.DownloadByRoot(link.MerkleRoot(), offset, fetchSize, timeout)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to fetch base sector of skylink")
 	}
@@ -685,6 +760,12 @@ func (r *Renter) managedDownloadBaseSector(link modules.Skylink, timeout time.Du
 		return nil, errors.New("download did not fetch enough data, layout cannot be decoded")
 	}
 
+	// Add the base sector to the skynet cache.
+	err = r.staticSkynetCache.managedPut(id, skynetCacheBaseSectorIndex, baseSector)
+	if err != nil {
+		r.log.Printf("unable to add base sector of %v to skynet cache: %v", link, err)
+	}
+
 	// Return the baseSector
 	return baseSector, nil
 }
@@ -700,6 +781,13 @@ func (r *Renter) PinSkylink(skylink modules.Skylink, lup modules.SkyfileUploadPa
 	// Set sane defaults for unspecified values.
 	skyfileEstablishDefaults(&lup)
 
+	// A v2 skylink can change over time. Pin the content it currently points
+	// to.
+	skylink, err := r.managedResolveSkylinkV2(skylink, timeout)
+	if err != nil {
+		return err
+	}
+
 	// Fetch the leading chunk.
 	baseSector, err := r.DownloadByRoot(skylink.MerkleRoot(), 0, modules.SectorSize, timeout)
 	if err != nil {
@@ -797,6 +885,107 @@ func (r *Renter) PinSkylink(skylink modules.Skylink, lup modules.SkyfileUploadPa
 	return nil
 }
 
+// UnpinSkylink deletes all siafiles that store the given skylink, including
+// the extended siafiles of large skyfiles. Since a siafile can be shared by
+// multiple skylinks, e.g. when the skyfiles were packed into the same sector,
+// siafiles which store other skylinks as well are not deleted. Instead the
+// skylink is only removed from their metadata. The siapaths of the deleted and
+// the updated siafiles are returned.
+func (r *Renter) UnpinSkylink(skylink modules.Skylink) (deleted, updated []modules.SiaPath, err error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, nil, err
+	}
+	defer r.tg.Done()
+
+	// The siafiles only store v1 skylinks.
+	if skylink.IsSkylinkV2() {
+		return nil, nil, ErrUnpinSkylinkV2
+	}
+
+	// Find all siafiles that store the skylink and remember whether they are
+	// shared with other skylinks.
+	skylinkStr := skylink.String()
+	var mu sync.Mutex
+	siaPaths := make(map[modules.SiaPath]bool)
+	err = r.FileList(modules.RootSiaPath(), true, true, func(fi modules.FileInfo) {
+		var found, shared bool
+		for _, sl := range fi.Skylinks {
+			if sl == skylinkStr {
+				found = true
+			} else {
+				shared = true
+			}
+		}
+		if !found {
+			return
+		}
+		mu.Lock()
+		siaPaths[fi.SiaPath] = shared
+		mu.Unlock()
+	})
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to list siafiles")
+	}
+	if len(siaPaths) == 0 {
+		return nil, nil, ErrSkylinkNotPinned
+	}
+
+	// Add the extended siafiles of large skyfiles which are deleted.
+	for siaPath, shared := range siaPaths {
+		if shared || strings.HasSuffix(siaPath.String(), ExtendedSuffix) {
+			continue
+		}
+		extendedSiaPath, err := modules.NewSiaPath(siaPath.String() + ExtendedSuffix)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to create extended siapath")
+		}
+		if _, exists := siaPaths[extendedSiaPath]; !exists {
+			siaPaths[extendedSiaPath] = false
+		}
+	}
+	sorted := make([]modules.SiaPath, 0, len(siaPaths))
+	for siaPath := range siaPaths {
+		sorted = append(sorted, siaPath)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+
+	// Remove the skylink from the shared siafiles and delete the others.
+	// Extended siafiles which don't exist are ignored.
+	for _, siaPath := range sorted {
+		if siaPaths[siaPath] {
+			err := r.managedRemoveSkylink(siaPath, skylink)
+			if err != nil {
+				return deleted, updated, errors.AddContext(err, fmt.Sprintf("unable to remove skylink from siafile %v", siaPath))
+			}
+			updated = append(updated, siaPath)
+			continue
+		}
+		err := r.DeleteFile(siaPath)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return deleted, updated, errors.AddContext(err, fmt.Sprintf("unable to delete siafile %v", siaPath))
+		}
+		deleted = append(deleted, siaPath)
+	}
+	return deleted, updated, nil
+}
+
+// managedRemoveSkylink removes the skylink from the metadata of the siafile at
+// the given siapath.
+func (r *Renter) managedRemoveSkylink(siaPath modules.SiaPath, skylink modules.Skylink) (err error) {
+	fileNode, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open siafile")
+	}
+	defer func() {
+		err = errors.Compose(err, fileNode.Close())
+	}()
+	return fileNode.RemoveSkylink(skylink)
+}
+
 // UploadSkyfile will upload the provided data with the provided metadata,
 // returning a skylink which can be used by any viewnode to recover the full
 // original file and metadata. The skylink will be unique to the combination of
@@ -0,0 +1,131 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestUnpinSkylink probes UnpinSkylink.
+func TestUnpinSkylink(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a renter for the tests
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create two skylinks.
+	var root1, root2 crypto.Hash
+	fastrand.Read(root1[:])
+	fastrand.Read(root2[:])
+	skylink1, err := modules.NewSkylinkV1(root1, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skylink2, err := modules.NewSkylinkV1(root2, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a few siafiles that store the skylinks.
+	createFile := func(name string, skylinks ...modules.Skylink) modules.SiaPath {
+		siaPath, err := modules.SkynetFolder.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileNode, err := r.createRenterTestFile(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, skylink := range skylinks {
+			if err := fileNode.AddSkylink(skylink); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := fileNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return siaPath
+	}
+	a := createFile("a", skylink1)
+	aExtended := createFile("a"+ExtendedSuffix, skylink1)
+	b := createFile("b", skylink2)
+	c := createFile("dir/c", skylink1, skylink2)
+
+	// Unpin the first skylink. This should delete a and its extended file. c
+	// is shared with the second skylink which means that only the skylink is
+	// removed from it.
+	deleted, updated, err := r.UnpinSkylink(skylink1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []modules.SiaPath{a, aExtended}
+	if len(deleted) != len(expected) {
+		t.Fatalf("expected %v deleted files but got %v", len(expected), len(deleted))
+	}
+	for i := range expected {
+		if !deleted[i].Equals(expected[i]) {
+			t.Fatal("unexpected deleted file", deleted[i], expected[i])
+		}
+		if _, err := r.File(expected[i]); !errors.Contains(err, filesystem.ErrNotExist) {
+			t.Fatal("file wasn't deleted", expected[i], err)
+		}
+	}
+	if len(updated) != 1 || !updated[0].Equals(c) {
+		t.Fatal("unexpected updated files", updated)
+	}
+	fi, err := r.File(c)
+	if err != nil {
+		t.Fatal("shared file shouldn't have been deleted", err)
+	}
+	if len(fi.Skylinks) != 1 || fi.Skylinks[0] != skylink2.String() {
+		t.Fatal("unexpected skylinks", fi.Skylinks)
+	}
+	if _, err := r.File(b); err != nil {
+		t.Fatal("file shouldn't have been deleted", err)
+	}
+
+	// Unpinning the skylink again should fail.
+	_, _, err = r.UnpinSkylink(skylink1)
+	if !errors.Contains(err, ErrSkylinkNotPinned) {
+		t.Fatal("expected ErrSkylinkNotPinned", err)
+	}
+
+	// Unpin the second skylink. Now c isn't shared anymore and is deleted too.
+	deleted, updated, err = r.UnpinSkylink(skylink2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 2 || !deleted[0].Equals(b) || !deleted[1].Equals(c) || len(updated) != 0 {
+		t.Fatal("unexpected unpinned files", deleted, updated)
+	}
+
+	// Unpinning a v2 skylink should fail.
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	_, pk := crypto.GenerateKeyPair()
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	_, _, err = r.UnpinSkylink(modules.NewSkylinkV2(spk, tweak))
+	if !errors.Contains(err, ErrUnpinSkylinkV2) {
+		t.Fatal("expected ErrUnpinSkylinkV2", err)
+	}
+}
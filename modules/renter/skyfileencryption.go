@@ -58,8 +58,7 @@ func (r *Renter) decryptBaseSector(baseSector []byte) (skykey.Skykey, error) {
 		build.Critical("Expected layout to be marked as encrypted!")
 	}
 
-	// Get the nonce to be used for getting private-id skykeys, and for deriving the
-	// file-specific skykey.
+	// Get the nonce to be used for getting private-id skykeys.
 	nonce := make([]byte, chacha.XNonceSize)
 	copy(nonce[:], sl.KeyData[skykey.SkykeyIDLen:skykey.SkykeyIDLen+chacha.XNonceSize])
 
@@ -79,7 +78,7 @@ func (r *Renter) decryptBaseSector(baseSector []byte) (skykey.Skykey, error) {
 	}
 
 	// Derive the file-specific key.
-	fileSkykey, err := masterSkykey.SubkeyWithNonce(nonce)
+	fileSkykey, err := modules.DeriveFileSpecificSkykey(sl, masterSkykey)
 	if err != nil {
 		return skykey.Skykey{}, errors.AddContext(err, "Unable to derive file-specific subkey")
 	}
@@ -149,11 +148,13 @@ func encryptBaseSectorWithSkykey(baseSector []byte, plaintextLayout modules.Skyf
 	encryptedLayout.CipherType = baseSectorKey.CipherType()
 
 	// Add the key ID or the encrypted skyfile identifier, depending on the key
-	// type.
+	// type, followed by the nonce in plaintext.
 	switch sk.Type {
 	case skykey.TypePublicID:
 		keyID := sk.ID()
 		copy(encryptedLayout.KeyData[:skykey.SkykeyIDLen], keyID[:])
+		nonce := sk.Nonce()
+		copy(encryptedLayout.KeyData[skykey.SkykeyIDLen:skykey.SkykeyIDLen+len(nonce)], nonce[:])
 
 	case skykey.TypePrivateID:
 		encryptedIdentifier, err := sk.GenerateSkyfileEncryptionID()
@@ -161,16 +162,25 @@ func encryptBaseSectorWithSkykey(baseSector []byte, plaintextLayout modules.Skyf
 			return errors.AddContext(err, "Unable to generate encrypted skyfile ID")
 		}
 		copy(encryptedLayout.KeyData[:skykey.SkykeyIDLen], encryptedIdentifier[:])
+		nonce := sk.Nonce()
+		copy(encryptedLayout.KeyData[skykey.SkykeyIDLen:skykey.SkykeyIDLen+len(nonce)], nonce[:])
+
+	case skykey.TypeX25519Private, skykey.TypeX25519Public:
+		// The recipient's key ID and the ephemeral public key are stored in
+		// place of the nonce, which is derived from the ephemeral public key.
+		epk, err := sk.EphemeralPublicKey()
+		if err != nil {
+			return errors.AddContext(err, "Unable to get ephemeral public key")
+		}
+		keyID := sk.ID()
+		copy(encryptedLayout.KeyData[:skykey.SkykeyIDLen], keyID[:])
+		copy(encryptedLayout.KeyData[skykey.SkykeyIDLen:skykey.SkykeyIDLen+len(epk)], epk[:])
 
 	default:
 		build.Critical("No encryption implemented for this skykey type")
 		return errors.AddContext(errors.New("No encryption implemented for skykey type"), string(sk.Type))
 	}
 
-	// Add the nonce to the base sector, in plaintext.
-	nonce := sk.Nonce()
-	copy(encryptedLayout.KeyData[skykey.SkykeyIDLen:skykey.SkykeyIDLen+len(nonce)], nonce[:])
-
 	// Now re-copy the encrypted layout into the baseSector.
 	copy(baseSector[:modules.SkyfileLayoutSize], encryptedLayout.Encode())
 	return nil
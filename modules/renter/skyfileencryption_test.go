@@ -34,6 +34,7 @@ func TestSkyfileBaseSectorEncryption(t *testing.T) {
 
 	testBaseSectorEncryptionWithType(t, r, skykey.TypePublicID)
 	testBaseSectorEncryptionWithType(t, r, skykey.TypePrivateID)
+	testBaseSectorEncryptionWithType(t, r, skykey.TypeX25519Private)
 }
 
 // testBaseSectorEncryptionWithType tests base sector encryption and decryption
@@ -272,13 +273,17 @@ func testBaseSectorEncryptionWithType(t *testing.T, r *Renter, skykeyType skykey
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !bytes.Equal(fanoutKey2.Entropy[:], fanoutKeyEntropy[:]) {
+	fanoutCipherKey2, err := fanoutKey2.CipherKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fanoutCipherKey2.Key(), fanoutKeyEntropy[:]) {
 		t.Fatal("Expected fanout key returned from deriveFanoutKey to be same as manual derivation")
 	}
 }
 
 // TestBaseSectorKeyID checks that keyIDs are set correctly in base sectors
-// encrypted using TypePublicID and TypePrivateID skykeys.
+// encrypted using TypePublicID, TypePrivateID and TypeX25519Private skykeys.
 func TestBaseSectorKeyID(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -395,4 +400,37 @@ func TestBaseSectorKeyID(t *testing.T) {
 		t.Log(expectedEncID, keyID2)
 		t.Fatal("Expected to find the skyfile encryption ID")
 	}
+
+	// Create a TypeX25519Private skykey to check that the key ID and the
+	// ephemeral public key are set.
+	x25519KeyName := t.Name() + "-x25519-key"
+	x25519Key, err := r.CreateSkykey(x25519KeyName, skykey.TypeX25519Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsSkykey3, err := x25519Key.GenerateFileSpecificSubkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bsCopy3 := baseSectorCopy()
+	err = encryptBaseSectorWithSkykey(bsCopy3, ll, fsSkykey3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encLayout3 modules.SkyfileLayout
+	encLayout3.Decode(bsCopy3)
+
+	var keyID3 skykey.SkykeyID
+	copy(keyID3[:], encLayout3.KeyData[:skykey.SkykeyIDLen])
+	if keyID3 != x25519Key.ID() {
+		t.Log(keyID3, x25519Key.ID())
+		t.Fatal("Expected keyID to match skykey ID.")
+	}
+	epk, err := fsSkykey3.EphemeralPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encLayout3.KeyData[skykey.SkykeyIDLen:skykey.SkykeyIDLen+len(epk)], epk[:]) {
+		t.Fatal("Expected to find the ephemeral public key")
+	}
 }
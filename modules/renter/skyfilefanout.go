@@ -84,24 +84,34 @@ func (r *Renter) newFanoutStreamer(link modules.Skylink, sl modules.SkyfileLayou
 // decodeFanout will take the fanout bytes from a skyfile and decode them in to
 // the staticChunks filed of the fanoutStreamBufferDataSource.
 func (fs *fanoutStreamBufferDataSource) decodeFanout(fanoutBytes []byte) error {
-	// Decode piecesPerChunk, chunkRootsSize, and numChunks
-	piecesPerChunk, chunkRootsSize, numChunks, err := modules.DecodeFanout(fs.staticLayout, fanoutBytes)
+	chunks, err := decodeFanoutChunks(fs.staticLayout, fanoutBytes)
 	if err != nil {
 		return err
 	}
+	fs.staticChunks = chunks
+	return nil
+}
+
+// decodeFanoutChunks decodes the fanout bytes of a skyfile into the list of
+// piece roots of every chunk.
+func decodeFanoutChunks(sl modules.SkyfileLayout, fanoutBytes []byte) ([][]crypto.Hash, error) {
+	// Decode piecesPerChunk, chunkRootsSize, and numChunks
+	piecesPerChunk, chunkRootsSize, numChunks, err := modules.DecodeFanout(sl, fanoutBytes)
+	if err != nil {
+		return nil, err
+	}
 
-	// Decode the fanout data into the list of chunks for the
-	// fanoutStreamBufferDataSource.
-	fs.staticChunks = make([][]crypto.Hash, 0, numChunks)
+	// Decode the fanout data into the list of chunks.
+	chunks := make([][]crypto.Hash, 0, numChunks)
 	for i := uint64(0); i < numChunks; i++ {
 		chunk := make([]crypto.Hash, piecesPerChunk)
 		for j := uint64(0); j < piecesPerChunk; j++ {
 			fanoutOffset := (i * chunkRootsSize) + (j * crypto.HashSize)
 			copy(chunk[j][:], fanoutBytes[fanoutOffset:])
 		}
-		fs.staticChunks = append(fs.staticChunks, chunk)
+		chunks = append(chunks, chunk)
 	}
-	return nil
+	return chunks, nil
 }
 
 // skyfileEncodeFanout will create the serialized fanout for a fileNode. The
@@ -277,11 +287,28 @@ func (fs *fanoutStreamBufferDataSource) ReadAt(b []byte, offset int64) (int, err
 	// Determine which chunk contains the data.
 	chunkIndex := uint64(offset) / fs.staticChunkSize
 
+	// Check the skynet cache first. Only the chunks of unencrypted skyfiles
+	// are cached since the cache would otherwise store decrypted data on disk.
+	cache := fs.staticRenter.staticSkynetCache
+	cacheable := fs.staticMasterKey.Type() == crypto.TypePlain
+	if cacheable {
+		chunkData, cached := cache.managedGet(fs.staticStreamID, chunkIndex)
+		if cached {
+			return copy(b, chunkData), nil
+		}
+	}
+
 	// Perform a download to fetch the chunk.
 	chunkData, err := fs.managedFetchChunk(chunkIndex)
 	if err != nil {
 		return 0, errors.AddContext(err, "unable to fetch chunk in ReadAt call on fanout streamer")
 	}
+	if cacheable {
+		err = cache.managedPut(fs.staticStreamID, chunkIndex, chunkData)
+		if err != nil {
+			fs.staticRenter.log.Printf("unable to add chunk %v of %v to skynet cache: %v", chunkIndex, fs.staticStreamID, err)
+		}
+	}
 	n := copy(b, chunkData)
 	return n, nil
 }
@@ -0,0 +1,199 @@
+package renter
+
+// skyfilepack.go contains the logic for packed skyfile uploads. A packed upload
+// takes a batch of small files and turns every one of them into its own skyfile
+// without a fanout. Instead of uploading every skyfile to a sector of its own,
+// the skyfiles are packed into as few sectors as possible using
+// modules.PackSkyfiles. Every skyfile is then addressed by a v1 skylink which
+// encodes the offset and fetch size of the skyfile within the shared sector.
+
+import (
+	"fmt"
+	"strconv"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// errNoPackedFiles is returned when a packed upload doesn't contain any
+	// files.
+	errNoPackedFiles = errors.New("no files provided for packed upload")
+)
+
+// packedSkyfile is a single skyfile of a packed upload.
+type packedSkyfile struct {
+	// data contains the layout, metadata and file data of the skyfile.
+	data []byte
+
+	// packedSize is the number of bytes the skyfile occupies within its
+	// sector. It is the length of the data rounded up to the fetch size of the
+	// skylink.
+	packedSize uint64
+}
+
+// newPackedSkyfile creates the skyfile for a single file of a packed upload.
+func newPackedSkyfile(file modules.SkyfilePackedFile) (packedSkyfile, error) {
+	// Create and validate the metadata.
+	metadata := modules.SkyfileMetadata{
+		Filename: file.Filename,
+		Length:   uint64(len(file.Data)),
+		Mode:     file.Mode,
+	}
+	err := modules.ValidateSkyfileMetadata(metadata)
+	if err != nil {
+		return packedSkyfile{}, errors.Compose(ErrInvalidMetadata, err)
+	}
+	metadataBytes, err := modules.SkyfileMetadataBytes(metadata)
+	if err != nil {
+		return packedSkyfile{}, errors.AddContext(err, "unable to get skyfile metadata bytes")
+	}
+
+	// Make sure the skyfile fits within a single sector.
+	size := uint64(modules.SkyfileLayoutSize + len(metadataBytes) + len(file.Data))
+	if size > modules.SectorSize {
+		return packedSkyfile{}, modules.ErrSizeTooLarge
+	}
+
+	// Create the skyfile. There is no fanout since the whole file is
+	// contained within the base sector.
+	sl := modules.SkyfileLayout{
+		Version:      modules.SkyfileVersion,
+		Filesize:     uint64(len(file.Data)),
+		MetadataSize: uint64(len(metadataBytes)),
+		CipherType:   crypto.TypePlain,
+	}
+	data := make([]byte, 0, size)
+	data = append(data, sl.Encode()...)
+	data = append(data, metadataBytes...)
+	data = append(data, file.Data...)
+
+	packedSize, err := skylinkFetchSize(size)
+	if err != nil {
+		return packedSkyfile{}, errors.AddContext(err, "unable to determine fetch size")
+	}
+	return packedSkyfile{
+		data:       data,
+		packedSize: packedSize,
+	}, nil
+}
+
+// skylinkFetchSize returns the fetch size that a v1 skylink encodes for the
+// given length. Skylinks round the fetch size up, which means that the
+// returned fetch size is never smaller than the length.
+func skylinkFetchSize(length uint64) (uint64, error) {
+	sl, err := modules.NewSkylinkV1(crypto.Hash{}, 0, length)
+	if err != nil {
+		return 0, err
+	}
+	_, fetchSize, err := sl.OffsetAndFetchSize()
+	return fetchSize, err
+}
+
+// UploadPackedSkyfiles uploads a batch of small files as individual skyfiles.
+// The skyfiles are packed into as few sectors as possible and every sector is
+// uploaded to its own siafile within the directory at sup.SiaPath. The
+// siafiles are named after the merkle roots of their sectors. The returned
+// skylinks are in the same order as the files.
+func (r *Renter) UploadPackedSkyfiles(sup modules.SkyfileUploadParameters, files []modules.SkyfilePackedFile) (_ []modules.Skylink, err error) {
+	if len(files) == 0 {
+		return nil, errNoPackedFiles
+	}
+	// The skyfiles within a sector can't be encrypted since the skylinks
+	// would all need to share the same key.
+	if encryptionEnabled(sup) {
+		return nil, ErrEncryptionNotSupported
+	}
+
+	// Set reasonable default values for any sup fields that are blank.
+	err = skyfileEstablishDefaults(&sup)
+	if err != nil {
+		return nil, errors.AddContext(err, "skyfile upload parameters are incorrect")
+	}
+
+	// Create the skyfiles and pack them into sectors. The index of a file is
+	// used as its id.
+	skyfiles := make([]packedSkyfile, len(files))
+	sizes := make(map[string]uint64, len(files))
+	for i, file := range files {
+		skyfiles[i], err = newPackedSkyfile(file)
+		if err != nil {
+			return nil, errors.AddContext(err, fmt.Sprintf("invalid file '%v'", file.Filename))
+		}
+		sizes[strconv.Itoa(i)] = skyfiles[i].packedSize
+	}
+	placements, numSectors, err := modules.PackSkyfiles(sizes)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to pack files")
+	}
+
+	// Copy the skyfiles into their sectors.
+	sectors := make([][]byte, numSectors)
+	for i := range sectors {
+		sectors[i] = make([]byte, modules.SectorSize)
+	}
+	indices := make([]int, len(placements))
+	for i, fp := range placements {
+		indices[i], err = strconv.Atoi(fp.FileID)
+		if err != nil {
+			build.Critical("PackSkyfiles returned an unknown file id", fp.FileID)
+			return nil, errors.AddContext(err, "failed to parse file id")
+		}
+		copy(sectors[fp.SectorIndex][fp.SectorOffset:], skyfiles[indices[i]].data)
+	}
+
+	// Create the skylinks.
+	roots := make([]crypto.Hash, numSectors)
+	for i, sector := range sectors {
+		roots[i] = crypto.MerkleRoot(sector)
+	}
+	skylinks := make([]modules.Skylink, len(files))
+	sectorSkylinks := make([][]modules.Skylink, numSectors)
+	for i, fp := range placements {
+		skylink, err := modules.NewSkylinkV1(roots[fp.SectorIndex], fp.SectorOffset, uint64(len(skyfiles[indices[i]].data)))
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to build the skylink")
+		}
+		if r.staticSkynetBlocklist.IsBlocked(skylink) {
+			return nil, ErrSkylinkBlocked
+		}
+		skylinks[indices[i]] = skylink
+		sectorSkylinks[fp.SectorIndex] = append(sectorSkylinks[fp.SectorIndex], skylink)
+	}
+
+	// If this is a dry-run, we do not need to upload the sectors.
+	if sup.DryRun {
+		return skylinks, nil
+	}
+
+	// Clean up the uploaded siafiles if one of the uploads fails.
+	var uploaded []modules.SiaPath
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, siaPath := range uploaded {
+			if err := r.DeleteFile(siaPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+				r.log.Printf("error deleting siafile after packed upload error: %v", err)
+			}
+		}
+	}()
+
+	// Upload the sectors.
+	for i, sector := range sectors {
+		lup := sup
+		lup.SiaPath, err = sup.SiaPath.Join(roots[i].String())
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to create siapath for packed sector")
+		}
+		err = r.managedUploadBaseSector(lup, sector, sectorSkylinks[i]...)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to upload packed sector")
+		}
+		uploaded = append(uploaded, lup.SiaPath)
+	}
+	return skylinks, nil
+}
@@ -0,0 +1,150 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestNewPackedSkyfile is a unit test for newPackedSkyfile.
+func TestNewPackedSkyfile(t *testing.T) {
+	t.Parallel()
+
+	file := modules.SkyfilePackedFile{
+		Filename: "file",
+		Mode:     0640,
+		Data:     fastrand.Bytes(100),
+	}
+	psf, err := newPackedSkyfile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The packed size should be at least as large as the data and match the
+	// fetch size of a skylink.
+	if psf.packedSize < uint64(len(psf.data)) {
+		t.Fatal("packed size is smaller than the data", psf.packedSize, len(psf.data))
+	}
+	fetchSize, err := skylinkFetchSize(uint64(len(psf.data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if psf.packedSize != fetchSize {
+		t.Fatal("packed size doesn't match fetch size", psf.packedSize, fetchSize)
+	}
+
+	// The data should be a valid skyfile.
+	sl, fanout, md, payload, err := modules.ParseSkyfileMetadata(psf.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sl.Filesize != uint64(len(file.Data)) || len(fanout) != 0 {
+		t.Fatal("unexpected layout", sl)
+	}
+	if md.Filename != file.Filename || md.Mode != file.Mode || md.Length != uint64(len(file.Data)) {
+		t.Fatal("unexpected metadata", md)
+	}
+	if !bytes.Equal(payload, file.Data) {
+		t.Fatal("payload doesn't match data")
+	}
+
+	// Empty files and files that don't fit in a sector are not allowed.
+	file.Data = nil
+	if _, err := newPackedSkyfile(file); !errors.Contains(err, ErrInvalidMetadata) {
+		t.Fatal("expected ErrInvalidMetadata", err)
+	}
+	file.Data = fastrand.Bytes(int(modules.SectorSize))
+	if _, err := newPackedSkyfile(file); !errors.Contains(err, modules.ErrSizeTooLarge) {
+		t.Fatal("expected ErrSizeTooLarge", err)
+	}
+}
+
+// TestUploadPackedSkyfilesDryRun probes the skylinks created by
+// UploadPackedSkyfiles.
+func TestUploadPackedSkyfilesDryRun(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a renter for the tests
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	sup := modules.SkyfileUploadParameters{
+		SiaPath: modules.RandomSiaPath(),
+		DryRun:  true,
+	}
+
+	// An empty batch is not allowed.
+	_, err = r.UploadPackedSkyfiles(sup, nil)
+	if !errors.Contains(err, errNoPackedFiles) {
+		t.Fatal("expected errNoPackedFiles", err)
+	}
+
+	// Encryption is not supported.
+	encSup := sup
+	encSup.SkykeyName = "key"
+	files := []modules.SkyfilePackedFile{{Filename: "file", Data: fastrand.Bytes(10)}}
+	_, err = r.UploadPackedSkyfiles(encSup, files)
+	if !errors.Contains(err, ErrEncryptionNotSupported) {
+		t.Fatal("expected ErrEncryptionNotSupported", err)
+	}
+
+	// Pack a batch of files of different sizes.
+	files = nil
+	for i := 0; i < 10; i++ {
+		files = append(files, modules.SkyfilePackedFile{
+			Filename: fmt.Sprintf("file%v", i),
+			Data:     fastrand.Bytes(fastrand.Intn(int(modules.SectorSize)/4) + 1),
+		})
+	}
+	skylinks, err := r.UploadPackedSkyfiles(sup, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skylinks) != len(files) {
+		t.Fatalf("expected %v skylinks but got %v", len(files), len(skylinks))
+	}
+
+	// Every skylink should point to a unique, non-overlapping region within a
+	// sector.
+	type region struct {
+		offset, fetchSize uint64
+	}
+	regions := make(map[crypto.Hash][]region)
+	for _, skylink := range skylinks {
+		offset, fetchSize, err := skylink.OffsetAndFetchSize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if offset+fetchSize > modules.SectorSize {
+			t.Fatal("skylink exceeds sector", offset, fetchSize)
+		}
+		root := skylink.MerkleRoot()
+		for _, reg := range regions[root] {
+			if offset < reg.offset+reg.fetchSize && reg.offset < offset+fetchSize {
+				t.Fatal("skylinks overlap", reg, offset, fetchSize)
+			}
+		}
+		regions[root] = append(regions[root], region{offset, fetchSize})
+	}
+
+	// A dry run shouldn't create any siafiles.
+	if _, err := r.staticFileSystem.OpenSiaDir(sup.SiaPath); err == nil {
+		t.Fatal("dry run created a directory")
+	}
+}
@@ -0,0 +1,207 @@
+package renter
+
+// skyfiletemporary.go contains the logic for temporary skyfile uploads. A
+// temporary skyfile is a small skyfile which isn't tracked by a siafile or
+// covered by file contracts. Instead, its base sector is stored by a number of
+// hosts for a fixed number of blocks and paid for from the workers' ephemeral
+// accounts. Once the duration is over, the hosts delete the sector again.
+
+import (
+	"fmt"
+	"io"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// ErrTemporaryUploadTooLarge is returned when the skyfile of a temporary
+	// upload doesn't fit within a single sector.
+	ErrTemporaryUploadTooLarge = errors.New("temporary skyfiles need to fit within a single sector")
+
+	// errTemporaryUploadNoDuration is returned when a temporary upload is
+	// started without a duration.
+	errTemporaryUploadNoDuration = errors.New("temporary upload requires a duration")
+
+	// errTemporaryUploadNoHosts is returned when none of the hosts stored the
+	// base sector of a temporary upload.
+	errTemporaryUploadNoHosts = errors.New("no host stored the temporary skyfile")
+)
+
+// TemporaryUpload uploads a small skyfile which is stored by the hosts for the
+// given number of blocks without creating a siafile or adding its sector to a
+// file contract. The storage is paid for from the workers' ephemeral accounts.
+// The skyfile, including its metadata, needs to fit within a single sector.
+func (r *Renter) TemporaryUpload(sup modules.SkyfileUploadParameters, reader modules.SkyfileUploadReader, duration types.BlockHeight) (modules.Skylink, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.Skylink{}, err
+	}
+	defer r.tg.Done()
+
+	if duration == 0 {
+		return modules.Skylink{}, errTemporaryUploadNoDuration
+	}
+	// The skykey would need to be persisted with the skyfile which isn't
+	// possible without a siafile.
+	if encryptionEnabled(sup) {
+		return modules.Skylink{}, ErrEncryptionNotSupported
+	}
+
+	// Set reasonable default values for any sup fields that are blank.
+	err := skyfileEstablishDefaults(&sup)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "skyfile upload parameters are incorrect")
+	}
+
+	// Read the file. It needs to fit within a single sector.
+	buf := make([]byte, modules.SectorSize)
+	numBytes, err := io.ReadFull(reader, buf)
+	if err == nil {
+		return modules.Skylink{}, ErrTemporaryUploadTooLarge
+	} else if !errors.Contains(err, io.EOF) && !errors.Contains(err, io.ErrUnexpectedEOF) {
+		return modules.Skylink{}, errors.AddContext(err, "unable to read skyfile")
+	}
+	buf = buf[:numBytes]
+
+	// Get the skyfile metadata from the reader.
+	metadata, err := reader.SkyfileMetadata(r.tg.StopCtx())
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "unable to get skyfile metadata")
+	}
+	err = modules.ValidateSkyfileMetadata(metadata)
+	if err != nil {
+		return modules.Skylink{}, errors.Compose(ErrInvalidMetadata, err)
+	}
+	metadataBytes, err := modules.SkyfileMetadataBytes(metadata)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "unable to get skyfile metadata bytes")
+	}
+	if uint64(modules.SkyfileLayoutSize+len(metadataBytes)+numBytes) > modules.SectorSize {
+		return modules.Skylink{}, ErrTemporaryUploadTooLarge
+	}
+
+	// Create the base sector and the skylink.
+	sl := modules.SkyfileLayout{
+		Version:      modules.SkyfileVersion,
+		Filesize:     uint64(numBytes),
+		MetadataSize: uint64(len(metadataBytes)),
+		CipherType:   crypto.TypePlain,
+	}
+	baseSector, fetchSize := modules.BuildBaseSector(sl.Encode(), nil, metadataBytes, buf) // 'nil' because there is no fanout
+	skylink, err := modules.NewSkylinkV1(crypto.MerkleRoot(baseSector), 0, fetchSize)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "failed to build the skylink")
+	}
+	if r.staticSkynetBlocklist.IsBlocked(skylink) {
+		return modules.Skylink{}, ErrSkylinkBlocked
+	}
+
+	// If this is a dry-run, we do not need to store the base sector.
+	if sup.DryRun {
+		return skylink, nil
+	}
+
+	// Store the base sector on the hosts.
+	err = r.managedStoreSectorTemporarily(baseSector, duration, int(sup.BaseChunkRedundancy))
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "failed to store temporary skyfile")
+	}
+	return skylink, nil
+}
+
+// managedStoreSectorTemporarily stores a sector on up to 'redundancy' hosts
+// for the given duration. If a host fails to store the sector, the next
+// available worker is tried instead. An error is only returned if none of the
+// hosts stored the sector.
+func (r *Renter) managedStoreSectorTemporarily(sector []byte, duration types.BlockHeight, redundancy int) error {
+	// Get the full list of workers and create a channel to receive all of the
+	// results from the workers. The channel is buffered with one slot per
+	// worker, so that the workers do not have to block when returning the
+	// result of the job, even if this thread is not listening.
+	workers := r.staticWorkerPool.callWorkers()
+	staticResponseChan := make(chan *jobStoreSectorResponse, len(workers))
+
+	// Filter out hosts that don't support ephemeral storage.
+	numWorkers := 0
+	for _, worker := range workers {
+		cache := worker.staticCache()
+		if build.VersionCmp(cache.staticHostVersion, minEphemeralStorageVersion) < 0 {
+			continue
+		}
+
+		// Skip !goodForUpload workers.
+		if !cache.staticContractUtility.GoodForUpload {
+			continue
+		}
+
+		// check for price gouging
+		host, ok, err := r.hostDB.Host(worker.staticHostPubKey)
+		if !ok || err != nil {
+			continue
+		}
+		err = checkUploadGouging(cache.staticRenterAllowance, host.HostExternalSettings)
+		if err != nil {
+			r.log.Debugf("price gouging detected in worker %v, err: %v\n", worker.staticHostPubKeyStr, err)
+			continue
+		}
+		workers[numWorkers] = worker
+		numWorkers++
+	}
+	workers = workers[:numWorkers]
+
+	// launchNext adds a job to the next available worker. It returns false if
+	// there are no workers left.
+	launchNext := func() bool {
+		for len(workers) > 0 {
+			worker := workers[0]
+			workers = workers[1:]
+			// Use the renter's ctx to make sure the jobs can finish in the
+			// background.
+			jss := worker.newJobStoreSector(r.tg.StopCtx(), staticResponseChan, sector, duration)
+			if worker.staticJobStoreSectorQueue.callAdd(jss) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Launch the initial jobs.
+	pending := 0
+	for pending < redundancy && launchNext() {
+		pending++
+	}
+
+	// Wait for the responses. Every failed job is replaced by a job on the
+	// next available worker.
+	var errs error
+	successes := 0
+	for pending > 0 {
+		var resp *jobStoreSectorResponse
+		select {
+		case <-r.tg.StopChan():
+			return errors.New("renter shut down before the sector was stored")
+		case resp = <-staticResponseChan:
+		}
+		pending--
+
+		if resp.staticErr != nil {
+			errs = errors.Compose(errs, errors.AddContext(resp.staticErr, fmt.Sprintf("worker %v", resp.staticWorker.staticHostPubKeyStr)))
+			if launchNext() {
+				pending++
+			}
+			continue
+		}
+		successes++
+	}
+
+	if successes == 0 {
+		return errors.Compose(errTemporaryUploadNoHosts, errs)
+	}
+	if successes < redundancy {
+		r.log.Printf("WARN: temporary sector was only stored on %v out of %v hosts: %v", successes, redundancy, errs)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package renter
+
+// skylinkhealth.go contains the logic for reporting how well the data of a
+// skylink is available on the network. The base sector of the skyfile is
+// downloaded to learn the piece roots of the fanout, and then every worker is
+// asked whether its host stores any of these roots using HasSector jobs. This
+// works for any skylink, not only for skylinks that are pinned by the renter.
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// skylinkHealthMaxRootsPerJob is the maximum number of roots that are
+	// checked within a single HasSector job.
+	skylinkHealthMaxRootsPerJob = 100
+)
+
+// SkylinkHealth downloads the base sector of the skylink and queries all the
+// hosts of the renter for the sectors of the skyfile. If the timeout is reached
+// before all hosts have responded, the report is based on the responses
+// received so far.
+func (r *Renter) SkylinkHealth(link modules.Skylink, timeout time.Duration) (modules.SkylinkHealth, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.SkylinkHealth{}, err
+	}
+	defer r.tg.Done()
+
+	// Resolve the skylink.
+	link, err := r.managedResolveSkylinkV2(link, timeout)
+	if err != nil {
+		return modules.SkylinkHealth{}, errors.AddContext(err, "unable to resolve skylink")
+	}
+
+	// Create a context. If the timeout is greater than zero, have the context
+	// expire when the timeout triggers.
+	ctx := r.tg.StopCtx()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(r.tg.StopCtx(), timeout)
+		defer cancel()
+	}
+
+	// Download the base sector to get the layout and fanout.
+	baseSector, err := r.managedDownloadBaseSector(link, timeout)
+	if err != nil {
+		return modules.SkylinkHealth{}, errors.AddContext(err, "unable to download base sector")
+	}
+	if modules.IsEncryptedBaseSector(baseSector) {
+		_, err = r.decryptBaseSector(baseSector)
+		if err != nil {
+			return modules.SkylinkHealth{}, errors.AddContext(err, "unable to decrypt skyfile base sector")
+		}
+	}
+	layout, fanoutBytes, _, _, err := modules.ParseSkyfileMetadata(baseSector)
+	if err != nil {
+		return modules.SkylinkHealth{}, errors.AddContext(err, "error parsing skyfile metadata")
+	}
+	var chunks [][]crypto.Hash
+	if layout.FanoutSize > 0 {
+		chunks, err = decodeFanoutChunks(layout, fanoutBytes)
+		if err != nil {
+			return modules.SkylinkHealth{}, errors.AddContext(err, "unable to decode fanout")
+		}
+	}
+
+	// Collect the unique roots of the skyfile.
+	roots := []crypto.Hash{link.MerkleRoot()}
+	seen := map[crypto.Hash]struct{}{link.MerkleRoot(): {}}
+	for _, chunk := range chunks {
+		for _, root := range chunk {
+			if _, exists := seen[root]; exists {
+				continue
+			}
+			seen[root] = struct{}{}
+			roots = append(roots, root)
+		}
+	}
+
+	// Query the hosts.
+	hosts, queried, responded := r.managedHostsForSectors(ctx, roots)
+
+	// Build the report.
+	health := modules.SkylinkHealth{
+		BaseSector:     newSkylinkChunkHealth([]crypto.Hash{link.MerkleRoot()}, 1, hosts),
+		Chunks:         make([]modules.SkylinkChunkHealth, 0, len(chunks)),
+		HostsQueried:   queried,
+		HostsResponded: responded,
+	}
+	health.MinRedundancy = health.BaseSector.Redundancy
+	for _, chunk := range chunks {
+		ch := newSkylinkChunkHealth(chunk, uint64(layout.FanoutDataPieces), hosts)
+		if ch.Redundancy < health.MinRedundancy {
+			health.MinRedundancy = ch.Redundancy
+		}
+		health.Chunks = append(health.Chunks, ch)
+	}
+	return health, nil
+}
+
+// managedHostsForSectors asks the hosts of all workers whether they store the
+// given roots. It returns the hosts that store each root as well as the number
+// of hosts that were queried and the number of hosts that responded.
+func (r *Renter) managedHostsForSectors(ctx context.Context, roots []crypto.Hash) (_ map[crypto.Hash][]types.SiaPublicKey, queried, responded uint64) {
+	workers := r.staticWorkerPool.callWorkers()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	hosts := make(map[crypto.Hash][]types.SiaPublicKey)
+	queriedHosts := make(map[string]struct{})
+	respondedHosts := make(map[string]struct{})
+	for len(roots) > 0 {
+		n := len(roots)
+		if n > skylinkHealthMaxRootsPerJob {
+			n = skylinkHealthMaxRootsPerJob
+		}
+		batch := roots[:n]
+		roots = roots[n:]
+
+		// Add a job for the batch to every worker.
+		responseChan := make(chan *jobHasSectorResponse, len(workers))
+		numJobs := 0
+		for _, w := range workers {
+			jhs := w.newJobHasSector(ctx, responseChan, batch...)
+			if !w.staticJobHasSectorQueue.callAdd(jhs) {
+				continue
+			}
+			numJobs++
+			mu.Lock()
+			queriedHosts[w.staticHostPubKeyStr] = struct{}{}
+			mu.Unlock()
+		}
+
+		// Collect the responses.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numJobs; i++ {
+				var resp *jobHasSectorResponse
+				select {
+				case resp = <-responseChan:
+				case <-ctx.Done():
+					return
+				}
+				if resp.staticErr != nil || len(resp.staticAvailables) != len(batch) {
+					continue
+				}
+				w := resp.staticWorker
+				mu.Lock()
+				respondedHosts[w.staticHostPubKeyStr] = struct{}{}
+				for j, available := range resp.staticAvailables {
+					if available {
+						hosts[batch[j]] = append(hosts[batch[j]], w.staticHostPubKey)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return hosts, uint64(len(queriedHosts)), uint64(len(respondedHosts))
+}
+
+// newSkylinkChunkHealth creates the health of a chunk from the hosts that store
+// its pieces.
+func newSkylinkChunkHealth(roots []crypto.Hash, minPieces uint64, hosts map[crypto.Hash][]types.SiaPublicKey) modules.SkylinkChunkHealth {
+	ch := modules.SkylinkChunkHealth{
+		MinPieces: minPieces,
+		Pieces:    make([]modules.SkylinkPieceHealth, 0, len(roots)),
+	}
+	uniqueHosts := make(map[string]struct{})
+	for _, root := range roots {
+		pieceHosts := hosts[root]
+		if pieceHosts == nil {
+			pieceHosts = []types.SiaPublicKey{}
+		}
+		if len(pieceHosts) > 0 {
+			ch.AvailablePieces++
+		}
+		for _, host := range pieceHosts {
+			uniqueHosts[host.String()] = struct{}{}
+		}
+		ch.Pieces = append(ch.Pieces, modules.SkylinkPieceHealth{
+			Root:  root,
+			Hosts: pieceHosts,
+		})
+	}
+
+	// If a single piece is enough to recover the chunk, every host storing a
+	// piece adds to the redundancy.
+	if minPieces <= 1 {
+		ch.Redundancy = float64(len(uniqueHosts))
+	} else {
+		ch.Redundancy = float64(ch.AvailablePieces) / float64(minPieces)
+	}
+	return ch
+}
@@ -0,0 +1,66 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestNewSkylinkChunkHealth is a unit test for newSkylinkChunkHealth.
+func TestNewSkylinkChunkHealth(t *testing.T) {
+	t.Parallel()
+
+	// Create some roots and hosts.
+	roots := make([]crypto.Hash, 3)
+	for i := range roots {
+		fastrand.Read(roots[i][:])
+	}
+	hostKeys := make([]types.SiaPublicKey, 3)
+	for i := range hostKeys {
+		hostKeys[i] = types.SiaPublicKey{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(crypto.PublicKeySize),
+		}
+	}
+
+	// The first piece is stored on two hosts, the second piece on one host and
+	// the last piece on none.
+	hosts := map[crypto.Hash][]types.SiaPublicKey{
+		roots[0]: {hostKeys[0], hostKeys[1]},
+		roots[1]: {hostKeys[1]},
+	}
+
+	// If a single piece is enough to recover the chunk, the redundancy is the
+	// number of unique hosts storing any of the pieces.
+	ch := newSkylinkChunkHealth(roots, 1, hosts)
+	if ch.AvailablePieces != 2 || ch.MinPieces != 1 {
+		t.Fatal("unexpected pieces", ch.AvailablePieces, ch.MinPieces)
+	}
+	if ch.Redundancy != 2 {
+		t.Fatal("unexpected redundancy", ch.Redundancy)
+	}
+	if len(ch.Pieces) != len(roots) {
+		t.Fatal("unexpected number of pieces", len(ch.Pieces))
+	}
+	for i, piece := range ch.Pieces {
+		if piece.Root != roots[i] {
+			t.Fatal("wrong root", i)
+		}
+		if len(piece.Hosts) != len(hosts[roots[i]]) {
+			t.Fatal("wrong hosts", i, piece.Hosts)
+		}
+	}
+
+	// If more pieces are required, the redundancy is the number of available
+	// pieces divided by the required pieces.
+	ch = newSkylinkChunkHealth(roots, 2, hosts)
+	if ch.Redundancy != 1 {
+		t.Fatal("unexpected redundancy", ch.Redundancy)
+	}
+	ch = newSkylinkChunkHealth(roots, 3, hosts)
+	if ch.Redundancy >= 1 {
+		t.Fatal("chunk shouldn't be recoverable", ch.Redundancy)
+	}
+}
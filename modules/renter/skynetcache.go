@@ -0,0 +1,324 @@
+package renter
+
+// skynetcache.go contains a persistent, size-bounded on-disk cache for the data
+// that Skynet downloads from the network. Both the base sectors of skylinks and
+// the chunks of skyfile fanouts are cached, keyed by the DataSourceID of the
+// skylink and the index of the chunk.
+//
+// Every cached item is stored in its own file within the cache directory. The
+// file is named after the hash of the item's key and starts with the hash of
+// the item's data, which is verified whenever the item is read from disk. Since
+// the cache doesn't keep any other state on disk, it is recovered after a
+// restart by scanning the cache directory. The modification times of the files
+// are used to restore the order in which the items were used.
+
+import (
+	"container/list"
+	"encoding/hex"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+const (
+	// skynetCacheDir is the name of the directory within the renter's persist
+	// directory that contains the skynet cache.
+	skynetCacheDir = "skynetcache"
+
+	// skynetCacheTmpSuffix is the suffix of files that are being written to
+	// the cache. Leftover temporary files are removed on startup.
+	skynetCacheTmpSuffix = ".tmp"
+
+	// skynetCacheBaseSectorIndex is the chunk index used to cache the base
+	// sector of a skylink. It can't collide with the index of a fanout chunk
+	// since a skyfile can't have that many chunks.
+	skynetCacheBaseSectorIndex = math.MaxUint64
+)
+
+var (
+	// errSkynetCacheCorrupt is returned when the data of a cached item doesn't
+	// match its checksum.
+	errSkynetCacheCorrupt = errors.New("cached skynet data is corrupt")
+)
+
+type (
+	// skynetCache is a least recently used cache for skynet data that is
+	// persisted on disk.
+	skynetCache struct {
+		// entries maps the keys of all cached items to their elements within
+		// the lru list. The front of the list contains the most recently used
+		// item.
+		entries map[skynetCacheKey]*list.Element
+		lru     *list.List
+
+		// size is the number of bytes used by the cached items on disk and
+		// maxSize is the number of bytes the cache is allowed to use. A
+		// maxSize of 0 disables the cache.
+		size    uint64
+		maxSize uint64
+
+		hits   uint64
+		misses uint64
+
+		staticDir string
+		mu        sync.Mutex
+	}
+
+	// skynetCacheEntry is a single item of the skynet cache.
+	skynetCacheEntry struct {
+		staticKey  skynetCacheKey
+		staticSize uint64
+	}
+
+	// skynetCacheKey identifies a single item within the skynet cache.
+	skynetCacheKey crypto.Hash
+)
+
+// newSkynetCache creates a new skynet cache which stores its items in the
+// given directory. Any items that were cached before are loaded.
+func newSkynetCache(dir string, maxSize uint64) (*skynetCache, error) {
+	err := os.MkdirAll(dir, modules.DefaultDirPerm)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create skynet cache dir")
+	}
+	sc := &skynetCache{
+		entries:   make(map[skynetCacheKey]*list.Element),
+		lru:       list.New(),
+		maxSize:   maxSize,
+		staticDir: dir,
+	}
+
+	// Load the existing items.
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read skynet cache dir")
+	}
+	sort.Slice(fis, func(i, j int) bool {
+		return fis[i].ModTime().After(fis[j].ModTime())
+	})
+	for _, fi := range fis {
+		key, err := skynetCacheKeyFromFilename(fi.Name())
+		if err != nil || fi.IsDir() {
+			// Remove leftover temporary files and anything else that doesn't
+			// belong into the cache.
+			err = os.RemoveAll(filepath.Join(dir, fi.Name()))
+			if err != nil {
+				return nil, errors.AddContext(err, "unable to remove invalid skynet cache file")
+			}
+			continue
+		}
+		entry := &skynetCacheEntry{
+			staticKey:  key,
+			staticSize: uint64(fi.Size()),
+		}
+		sc.entries[key] = sc.lru.PushBack(entry)
+		sc.size += entry.staticSize
+	}
+
+	// The max size might have decreased since the last time.
+	err = sc.evict()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to evict items from skynet cache")
+	}
+	return sc, nil
+}
+
+// newSkynetCacheKey returns the cache key for the chunk with the given index of
+// the given data source.
+func newSkynetCacheKey(id modules.DataSourceID, chunkIndex uint64) skynetCacheKey {
+	return skynetCacheKey(crypto.HashAll(id, chunkIndex))
+}
+
+// skynetCacheKeyFromFilename parses a cache key from the name of a cache file.
+func skynetCacheKeyFromFilename(name string) (skynetCacheKey, error) {
+	var key skynetCacheKey
+	if strings.HasSuffix(name, skynetCacheTmpSuffix) {
+		return key, errors.New("file is a temporary file")
+	}
+	b, err := hex.DecodeString(name)
+	if err != nil {
+		return key, err
+	}
+	if len(b) != len(key) {
+		return key, errors.New("filename has wrong length")
+	}
+	copy(key[:], b)
+	return key, nil
+}
+
+// filename returns the name of the file that stores the item with the key.
+func (key skynetCacheKey) filename() string {
+	return hex.EncodeToString(key[:])
+}
+
+// path returns the path of the file that stores the item with the given key.
+func (sc *skynetCache) path(key skynetCacheKey) string {
+	return filepath.Join(sc.staticDir, key.filename())
+}
+
+// evict removes the least recently used items from the cache until it is
+// within its max size.
+func (sc *skynetCache) evict() error {
+	for sc.size > sc.maxSize {
+		elem := sc.lru.Back()
+		entry := elem.Value.(*skynetCacheEntry)
+		err := os.Remove(sc.path(entry.staticKey))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		sc.remove(elem)
+	}
+	return nil
+}
+
+// remove removes an element from the cache's bookkeeping.
+func (sc *skynetCache) remove(elem *list.Element) {
+	entry := sc.lru.Remove(elem).(*skynetCacheEntry)
+	delete(sc.entries, entry.staticKey)
+	sc.size -= entry.staticSize
+}
+
+// managedGet returns the cached data for the chunk with the given index of the
+// given data source. The bool indicates whether the data was found in the
+// cache.
+func (sc *skynetCache) managedGet(id modules.DataSourceID, chunkIndex uint64) ([]byte, bool) {
+	key := newSkynetCacheKey(id, chunkIndex)
+
+	sc.mu.Lock()
+	if sc.maxSize == 0 {
+		sc.mu.Unlock()
+		return nil, false
+	}
+	_, exists := sc.entries[key]
+	if !exists {
+		sc.misses++
+		sc.mu.Unlock()
+		return nil, false
+	}
+	sc.mu.Unlock()
+
+	// Read the item from disk. The item might have been evicted in the
+	// meantime, which counts as a miss.
+	data, err := sc.readItem(key)
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	elem, exists := sc.entries[key]
+	if err != nil {
+		sc.misses++
+		if exists && errors.Contains(err, errSkynetCacheCorrupt) {
+			_ = os.Remove(sc.path(key))
+			sc.remove(elem)
+		}
+		return nil, false
+	}
+	sc.hits++
+	if exists {
+		sc.lru.MoveToFront(elem)
+		now := time.Now()
+		_ = os.Chtimes(sc.path(key), now, now)
+	}
+	return data, true
+}
+
+// managedPut adds the chunk with the given index of the given data source to
+// the cache.
+func (sc *skynetCache) managedPut(id modules.DataSourceID, chunkIndex uint64, data []byte) error {
+	key := newSkynetCacheKey(id, chunkIndex)
+	size := uint64(crypto.HashSize + len(data))
+
+	sc.mu.Lock()
+	_, exists := sc.entries[key]
+	tooLarge := size > sc.maxSize
+	sc.mu.Unlock()
+	if exists || tooLarge {
+		return nil
+	}
+
+	// Write the item to a temporary file first. The name of the temporary
+	// file is randomized to allow for concurrent puts of the same item.
+	checksum := crypto.HashBytes(data)
+	tmpPath := sc.path(key) + "-" + hex.EncodeToString(fastrand.Bytes(8)) + skynetCacheTmpSuffix
+	err := ioutil.WriteFile(tmpPath, append(checksum[:], data...), modules.DefaultFilePerm)
+	if err != nil {
+		return errors.Compose(err, os.Remove(tmpPath))
+	}
+
+	// Move the item into place.
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if _, exists := sc.entries[key]; exists {
+		return os.Remove(tmpPath)
+	}
+	err = os.Rename(tmpPath, sc.path(key))
+	if err != nil {
+		return errors.Compose(err, os.Remove(tmpPath))
+	}
+	entry := &skynetCacheEntry{
+		staticKey:  key,
+		staticSize: size,
+	}
+	sc.entries[key] = sc.lru.PushFront(entry)
+	sc.size += size
+	return sc.evict()
+}
+
+// managedSetMaxSize updates the max size of the cache, evicting items if
+// necessary.
+func (sc *skynetCache) managedSetMaxSize(maxSize uint64) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.maxSize = maxSize
+	return sc.evict()
+}
+
+// managedStats returns the statistics of the cache.
+func (sc *skynetCache) managedStats() modules.SkynetCacheStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return modules.SkynetCacheStats{
+		Hits:     sc.hits,
+		Misses:   sc.misses,
+		NumItems: uint64(len(sc.entries)),
+		Size:     sc.size,
+		MaxSize:  sc.maxSize,
+	}
+}
+
+// readItem reads the item with the given key from disk and verifies its
+// checksum.
+func (sc *skynetCache) readItem(key skynetCacheKey) ([]byte, error) {
+	b, err := ioutil.ReadFile(sc.path(key))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < crypto.HashSize {
+		return nil, errSkynetCacheCorrupt
+	}
+	var checksum crypto.Hash
+	copy(checksum[:], b[:crypto.HashSize])
+	data := b[crypto.HashSize:]
+	if crypto.HashBytes(data) != checksum {
+		return nil, errSkynetCacheCorrupt
+	}
+	return data, nil
+}
+
+// SkynetCacheStats returns the statistics of the renter's skynet cache.
+func (r *Renter) SkynetCacheStats() (modules.SkynetCacheStats, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.SkynetCacheStats{}, err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetCache.managedStats(), nil
+}
@@ -0,0 +1,141 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// randomDataSourceID returns a random DataSourceID.
+func randomDataSourceID() modules.DataSourceID {
+	var id modules.DataSourceID
+	fastrand.Read(id[:])
+	return id
+}
+
+// TestSkynetCache is a unit test for the skynetCache.
+func TestSkynetCache(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a cache with room for 3 items.
+	itemSize := uint64(100)
+	entrySize := itemSize + crypto.HashSize
+	dir := build.TempDir("renter", t.Name())
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := newSkynetCache(dir, 3*entrySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A missing item should be a miss.
+	id := randomDataSourceID()
+	if _, cached := sc.managedGet(id, 0); cached {
+		t.Fatal("item shouldn't be cached")
+	}
+
+	// Add 3 items.
+	items := make([][]byte, 4)
+	for i := range items {
+		items[i] = fastrand.Bytes(int(itemSize))
+	}
+	for i := 0; i < 3; i++ {
+		if err := sc.managedPut(id, uint64(i), items[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		data, cached := sc.managedGet(id, uint64(i))
+		if !cached || !bytes.Equal(data, items[i]) {
+			t.Fatal("item wasn't cached correctly", i)
+		}
+	}
+	stats := sc.managedStats()
+	if stats.Hits != 3 || stats.Misses != 1 || stats.NumItems != 3 || stats.Size != 3*entrySize {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// Use item 0 again and add a fourth item. Item 1 is the least recently
+	// used item and should be evicted.
+	if _, cached := sc.managedGet(id, 0); !cached {
+		t.Fatal("item should be cached")
+	}
+	if err := sc.managedPut(id, 3, items[3]); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := sc.managedGet(id, 1); cached {
+		t.Fatal("item 1 should have been evicted")
+	}
+	if _, err := os.Stat(sc.path(newSkynetCacheKey(id, 1))); !os.IsNotExist(err) {
+		t.Fatal("evicted item wasn't removed from disk", err)
+	}
+
+	// Reload the cache. The items should still be there.
+	sc, err = newSkynetCache(dir, 3*entrySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{0, 2, 3} {
+		data, cached := sc.managedGet(id, uint64(i))
+		if !cached || !bytes.Equal(data, items[i]) {
+			t.Fatal("item wasn't persisted correctly", i)
+		}
+	}
+
+	// Corrupt an item on disk. It should be treated as a miss and removed.
+	path := sc.path(newSkynetCacheKey(id, 0))
+	if err := ioutil.WriteFile(path, fastrand.Bytes(int(entrySize)), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := sc.managedGet(id, 0); cached {
+		t.Fatal("corrupt item shouldn't be returned")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("corrupt item wasn't removed from disk", err)
+	}
+	if stats := sc.managedStats(); stats.NumItems != 2 || stats.Size != 2*entrySize {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// Leftover temporary files should be removed on startup.
+	tmpPath := filepath.Join(dir, "leftover"+skynetCacheTmpSuffix)
+	if err := ioutil.WriteFile(tmpPath, items[0], modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	sc, err = newSkynetCache(dir, 3*entrySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Fatal("temporary file wasn't removed", err)
+	}
+
+	// Disabling the cache should remove all items.
+	if err := sc.managedSetMaxSize(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.managedPut(id, 0, items[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := sc.managedGet(id, 0); cached {
+		t.Fatal("disabled cache shouldn't return items")
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 0 {
+		t.Fatal("disabled cache should be empty", len(fis))
+	}
+}
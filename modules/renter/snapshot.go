@@ -271,6 +271,10 @@ func (r *Renter) managedSnapshotExists(name string) bool {
 func (r *Renter) managedSaveSnapshot(meta modules.UploadedBackup) error {
 	id := r.mu.Lock()
 	defer r.mu.Unlock(id)
+	// Ignore snapshots which were deleted.
+	if _, deleted := r.deletedBackupUIDs()[meta.UID]; deleted {
+		return nil
+	}
 	// Check whether we've already saved this snapshot.
 	for i, ub := range r.persist.UploadedBackups {
 		if ub.UID == meta.UID {
@@ -306,6 +310,61 @@ func (r *Renter) managedSaveSnapshot(meta modules.UploadedBackup) error {
 	return nil
 }
 
+// managedDeleteBackup deletes an uploaded backup. The snapshot synchronization
+// removes the backup from the snapshot tables of the hosts and prevents it from
+// being restored from hosts which still store it.
+func (r *Renter) managedDeleteBackup(ub modules.UploadedBackup) error {
+	// Delete the backup's siafile in case it wasn't uploaded as a snapshot
+	// yet.
+	sp, err := modules.BackupFolder.Join(ub.Name)
+	if err != nil {
+		return err
+	}
+	err = r.staticFileSystem.DeleteFile(sp)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete the backup's siafile")
+	}
+
+	id := r.mu.Lock()
+	for i, b := range r.persist.UploadedBackups {
+		if b.UID == ub.UID {
+			r.persist.UploadedBackups = append(r.persist.UploadedBackups[:i], r.persist.UploadedBackups[i+1:]...)
+			break
+		}
+	}
+	// Remember the deletion and forget about the deletions which expired.
+	now := time.Now()
+	var deleted []deletedBackup
+	for _, db := range r.persist.DeletedBackups {
+		if now.Sub(db.Time) < deletedBackupExpiry {
+			deleted = append(deleted, db)
+		}
+	}
+	r.persist.DeletedBackups = append(deleted, deletedBackup{UID: ub.UID, Time: now})
+	err = r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "unable to persist deleted backup")
+	}
+
+	// Notify the snapshot synchronization.
+	select {
+	case r.snapshotsDeleted <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// deletedBackupUIDs returns the set of the UIDs of deleted backups. The caller
+// needs to hold the renter's lock.
+func (r *Renter) deletedBackupUIDs() map[[16]byte]struct{} {
+	deleted := make(map[[16]byte]struct{}, len(r.persist.DeletedBackups))
+	for _, db := range r.persist.DeletedBackups {
+		deleted[db.UID] = struct{}{}
+	}
+	return deleted
+}
+
 // managedDownloadSnapshotTable will fetch the snapshot table from the host.
 func (r *Renter) managedDownloadSnapshotTable(host *worker) ([]snapshotEntry, error) {
 	// Get the wallet seed.
@@ -539,15 +598,20 @@ func (r *Renter) threadedSynchronizeSnapshots() {
 		return
 	}
 	defer r.tg.Done()
-	// calcOverlap takes a host's entry table and the set of known snapshots,
-	// and calculates which snapshots the host is missing and which snapshots it
-	// has that we don't.
-	calcOverlap := func(entryTable []snapshotEntry, known map[[16]byte]struct{}) (unknown []modules.UploadedBackup, missing [][16]byte) {
+	// calcOverlap takes a host's entry table, the set of known snapshots and
+	// the set of deleted snapshots, and calculates which snapshots the host is
+	// missing, which snapshots it has that we don't and which snapshots it has
+	// that were deleted.
+	calcOverlap := func(entryTable []snapshotEntry, known, deleted map[[16]byte]struct{}) (unknown []modules.UploadedBackup, missing, obsolete [][16]byte) {
 		missingMap := make(map[[16]byte]struct{}, len(known))
 		for uid := range known {
 			missingMap[uid] = struct{}{}
 		}
 		for _, e := range entryTable {
+			if _, ok := deleted[e.UID]; ok {
+				obsolete = append(obsolete, e.UID)
+				continue
+			}
 			if _, ok := known[e.UID]; !ok {
 				unknown = append(unknown, modules.UploadedBackup{
 					Name:           string(bytes.TrimRight(e.Name[:], types.RuneToString(0))),
@@ -585,6 +649,14 @@ func (r *Renter) threadedSynchronizeSnapshots() {
 		}
 		r.staticWorkerPool.callUpdate()
 
+		// If backups were deleted, all hosts need to be synchronized again to
+		// remove them from their snapshot tables.
+		select {
+		case <-r.snapshotsDeleted:
+			syncedContracts = make(map[types.FileContractID]struct{})
+		default:
+		}
+
 		// First, process any snapshot siafiles that may have finished uploading.
 		root := modules.BackupFolder
 		var mu sync.Mutex
@@ -683,9 +755,11 @@ func (r *Renter) threadedSynchronizeSnapshots() {
 			r.log.Println("Could not get un-uploaded snapshots:", err)
 		}
 
-		// Build a set of the snapshots we already have.
+		// Build a set of the snapshots we already have and the snapshots
+		// which were deleted.
 		known := make(map[[16]byte]struct{})
 		id := r.mu.RLock()
+		deleted := r.deletedBackupUIDs()
 		for _, ub := range r.persist.UploadedBackups {
 			if ub.UploadProgress == 100 {
 				known[ub.UID] = struct{}{}
@@ -727,6 +801,8 @@ func (r *Renter) threadedSynchronizeSnapshots() {
 			}
 			select {
 			case <-time.After(snapshotSyncSleepDuration):
+			case <-r.snapshotsDeleted:
+				syncedContracts = make(map[types.FileContractID]struct{})
 			case <-r.tg.StopChan():
 				return
 			}
@@ -748,9 +824,18 @@ func (r *Renter) threadedSynchronizeSnapshots() {
 				return err
 			}
 
-			// Calculate which snapshots the host doesn't have, and which
-			// snapshots it does have that we haven't seen before.
-			unknown, missing := calcOverlap(entryTable, known)
+			// Calculate which snapshots the host doesn't have, which
+			// snapshots it does have that we haven't seen before and which
+			// snapshots it does have that were deleted.
+			unknown, missing, obsolete := calcOverlap(entryTable, known, deleted)
+
+			// Remove the deleted snapshots from the host.
+			if len(obsolete) != 0 {
+				if err := w.DeleteSnapshots(r.tg.StopCtx(), obsolete); err != nil {
+					return err
+				}
+				r.log.Printf("Deleted %v snapshots from host %v", len(obsolete), c.HostPublicKey)
+			}
 
 			// If *any* snapshots are new, mark all other hosts as not
 			// synchronized.
@@ -0,0 +1,218 @@
+package renter
+
+// Signed stream URLs grant access to a file or the files within a folder
+// through /renter/stream without the API password. The parameters of a URL are
+// authenticated with an HMAC using a random key which is generated when the
+// first URL is signed. URLs expire and can optionally be limited to a number
+// of downloads. Since a download can consist of any number of range requests,
+// downloads are counted in bytes: a URL limited to n downloads serves up to n
+// times the size of a file. The served bytes are charged after a request
+// finished, so requests which are served in parallel can exceed the limit by
+// the size of the ranges they request. The served bytes are tracked in memory
+// and persisted together with the key periodically and on shutdown until the
+// URL expires.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+)
+
+var (
+	// errStreamURLExpired is returned when a signed stream URL is used after
+	// it expired.
+	errStreamURLExpired = errors.New("stream url has expired")
+
+	// errStreamURLDownloadLimit is returned when a signed stream URL is used
+	// after all its downloads were used up.
+	errStreamURLDownloadLimit = errors.New("stream url has reached its download limit")
+
+	// errStreamURLInvalidSignature is returned when the signature of a stream
+	// URL doesn't match its parameters.
+	errStreamURLInvalidSignature = errors.New("invalid stream url signature")
+
+	// errStreamURLOutOfScope is returned when a signed stream URL is used for
+	// a file outside of the file or folder it was signed for.
+	errStreamURLOutOfScope = errors.New("stream url doesn't grant access to this file")
+)
+
+// streamURLDownloads tracks the number of bytes which were served for each
+// file by a signed stream URL with a download limit.
+type streamURLDownloads struct {
+	Served  map[string]uint64
+	Expires int64
+}
+
+// streamURLSignature computes the signature of the parameters of a stream URL.
+func streamURLSignature(key crypto.Hash, sig modules.StreamSignature) string {
+	mac := hmac.New(sha256.New, key[:])
+	_, _ = fmt.Fprintf(mac, "%v\n%v\n%v\n%v", sig.ID, sig.Scope.String(), sig.Expires, sig.MaxDownloads)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// streamURLInScope returns whether the siapath is the scope itself or within
+// the scope.
+func streamURLInScope(siaPath, scope modules.SiaPath) bool {
+	return scope.IsRoot() || siaPath.Equals(scope) || strings.HasPrefix(siaPath.String(), scope.String()+"/")
+}
+
+// SignStreamURL signs the parameters of a stream URL which grants access to
+// the file or folder at the siapath until it expires. A maxDownloads of 0
+// allows for an unlimited number of downloads.
+func (r *Renter) SignStreamURL(scope modules.SiaPath, expires time.Time, maxDownloads uint64) (modules.StreamSignature, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.StreamSignature{}, err
+	}
+	defer r.tg.Done()
+	if !expires.After(time.Now()) {
+		return modules.StreamSignature{}, errors.New("expiry needs to be in the future")
+	}
+
+	// Generate the key if this is the first signed URL.
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	if r.persist.StreamURLKey == (crypto.Hash{}) {
+		fastrand.Read(r.persist.StreamURLKey[:])
+		if err := r.saveSync(); err != nil {
+			r.persist.StreamURLKey = crypto.Hash{}
+			return modules.StreamSignature{}, errors.AddContext(err, "unable to persist stream url key")
+		}
+	}
+	sig := modules.StreamSignature{
+		Expires:      expires.Unix(),
+		ID:           hex.EncodeToString(fastrand.Bytes(16)),
+		MaxDownloads: maxDownloads,
+		Scope:        scope,
+	}
+	sig.Signature = streamURLSignature(r.persist.StreamURLKey, sig)
+	return sig, nil
+}
+
+// VerifyStreamURL checks that the signature grants access to the file at the
+// siapath. If the URL has a download limit, the limit must not have been
+// reached for the file yet.
+func (r *Renter) VerifyStreamURL(siaPath modules.SiaPath, sig modules.StreamSignature) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Get the size of the file to check the download limit. Missing files
+	// can't exceed the limit.
+	var size uint64
+	if sig.MaxDownloads > 0 {
+		entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return errors.AddContext(err, "unable to open file")
+		}
+		if err == nil {
+			size = entry.Size()
+			if err := entry.Close(); err != nil {
+				return errors.AddContext(err, "unable to close file")
+			}
+		}
+	}
+
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	key := r.persist.StreamURLKey
+	expected := streamURLSignature(key, sig)
+	if key == (crypto.Hash{}) || !hmac.Equal([]byte(expected), []byte(sig.Signature)) {
+		return errStreamURLInvalidSignature
+	}
+	now := time.Now().Unix()
+	if now > sig.Expires {
+		return errStreamURLExpired
+	}
+	if !streamURLInScope(siaPath, sig.Scope) {
+		return errStreamURLOutOfScope
+	}
+	if sig.MaxDownloads == 0 || size == 0 {
+		return nil
+	}
+	served := r.persist.StreamURLDownloads[sig.ID].Served[siaPath.String()]
+	if served/size >= sig.MaxDownloads {
+		return errStreamURLDownloadLimit
+	}
+	return nil
+}
+
+// ChargeStreamURL charges the bytes which were served for the file at the
+// siapath to the download limit of the signed URL. It should only be called
+// after VerifyStreamURL succeeded.
+func (r *Renter) ChargeStreamURL(siaPath modules.SiaPath, sig modules.StreamSignature, served uint64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if sig.MaxDownloads == 0 || served == 0 {
+		return nil
+	}
+
+	// Charge the bytes and forget about the downloads of expired URLs.
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	if r.persist.StreamURLDownloads == nil {
+		r.persist.StreamURLDownloads = make(map[string]streamURLDownloads)
+	}
+	downloads := r.persist.StreamURLDownloads[sig.ID]
+	if downloads.Served == nil {
+		downloads.Served = make(map[string]uint64)
+	}
+	downloads.Served[siaPath.String()] += served
+	downloads.Expires = sig.Expires
+	r.persist.StreamURLDownloads[sig.ID] = downloads
+	now := time.Now().Unix()
+	for urlID, d := range r.persist.StreamURLDownloads {
+		if now > d.Expires {
+			delete(r.persist.StreamURLDownloads, urlID)
+		}
+	}
+	r.streamURLDownloadsChanged = true
+	return nil
+}
+
+// managedPersistStreamURLDownloads saves the renter's persistence if the bytes
+// served by signed stream URLs changed since it was last saved.
+func (r *Renter) managedPersistStreamURLDownloads() error {
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	if !r.streamURLDownloadsChanged {
+		return nil
+	}
+	err := r.saveSync()
+	if err != nil {
+		return errors.AddContext(err, "unable to persist stream url downloads")
+	}
+	r.streamURLDownloadsChanged = false
+	return nil
+}
+
+// threadedPersistStreamURLDownloads periodically persists the bytes served by
+// signed stream URLs.
+func (r *Renter) threadedPersistStreamURLDownloads() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(streamURLDownloadsPersistInterval):
+		}
+		if err := r.managedPersistStreamURLDownloads(); err != nil {
+			r.log.Printf("WARN: %v", err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package renter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/persist"
+)
+
+// TestStreamURLInScope tests that streamURLInScope only grants access to the
+// scope itself and the files within it.
+func TestStreamURLInScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		siaPath string
+		scope   string
+		inScope bool
+	}{
+		{"foo/bar", "foo/bar", true},
+		{"foo/bar", "foo", true},
+		{"foo/bar/baz", "foo", true},
+		{"foo", "foo/bar", false},
+		{"foobar", "foo", false},
+		{"bar/foo", "foo", false},
+	}
+	for _, test := range tests {
+		siaPath, err := modules.NewSiaPath(test.siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scope, err := modules.NewSiaPath(test.scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if streamURLInScope(siaPath, scope) != test.inScope {
+			t.Errorf("streamURLInScope(%v, %v) should be %v", test.siaPath, test.scope, test.inScope)
+		}
+	}
+	if !streamURLInScope(modules.RandomSiaPath(), modules.RootSiaPath()) {
+		t.Fatal("root scope should grant access to every file")
+	}
+}
+
+// TestStreamURL tests signing and verifying stream URLs.
+func TestStreamURL(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// A URL can't expire in the past.
+	scope, err := modules.NewSiaPath("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SignStreamURL(scope, time.Now().Add(-time.Minute), 0); err == nil {
+		t.Fatal("expected error for expiry in the past")
+	}
+
+	// Sign a URL with a download limit for a folder.
+	sig, err := r.SignStreamURL(scope, time.Now().Add(time.Hour), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := scope.Join("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := modules.NewSiaPath("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.VerifyStreamURL(other, sig); !errors.Contains(err, errStreamURLOutOfScope) {
+		t.Fatal("expected errStreamURLOutOfScope", err)
+	}
+
+	// Tampering with the parameters invalidates the signature.
+	tampered := sig
+	tampered.Scope = other
+	if err := r.VerifyStreamURL(other, tampered); !errors.Contains(err, errStreamURLInvalidSignature) {
+		t.Fatal("expected errStreamURLInvalidSignature", err)
+	}
+	tampered = sig
+	tampered.MaxDownloads = 0
+	if err := r.VerifyStreamURL(file, tampered); !errors.Contains(err, errStreamURLInvalidSignature) {
+		t.Fatal("expected errStreamURLInvalidSignature", err)
+	}
+
+	// Create two files within the scope.
+	rsc, _ := modules.NewRSCode(1, 1)
+	entry, err := r.createRenterTestFileWithParams(file, rsc, crypto.RandomCipherType())
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := entry.Size()
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file2, err := scope.Join("baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err = r.createRenterTestFileWithParams(file2, rsc, crypto.RandomCipherType())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Downloads are counted in bytes, no matter how many requests they are
+	// split into. The URL allows for serving twice the size of the file.
+	for _, served := range []uint64{size - 1, 1, size / 2, 0, size/2 - 1} {
+		if err := r.VerifyStreamURL(file, sig); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.ChargeStreamURL(file, sig, served); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.VerifyStreamURL(file, sig); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ChargeStreamURL(file, sig, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.VerifyStreamURL(file, sig); !errors.Contains(err, errStreamURLDownloadLimit) {
+		t.Fatal("expected errStreamURLDownloadLimit", err)
+	}
+
+	// The limit applies to each file separately.
+	if err := r.VerifyStreamURL(file2, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// The served bytes aren't saved on every request but when the renter
+	// persists them.
+	if err := r.managedPersistStreamURLDownloads(); err != nil {
+		t.Fatal(err)
+	}
+	var p persistence
+	err = persist.LoadJSON(settingsMetadata, &p, filepath.Join(r.persistDir, PersistFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.StreamURLDownloads[sig.ID].Served[file.String()] != 2*size {
+		t.Fatal("served bytes weren't persisted", p.StreamURLDownloads)
+	}
+	id := r.mu.Lock()
+	changed := r.streamURLDownloadsChanged
+	r.mu.Unlock(id)
+	if changed {
+		t.Fatal("served bytes should be marked as persisted")
+	}
+
+	// The served bytes are persisted.
+	r, err = rt.reloadRenter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.VerifyStreamURL(file, sig); !errors.Contains(err, errStreamURLDownloadLimit) {
+		t.Fatal("expected errStreamURLDownloadLimit", err)
+	}
+
+	// URLs without a limit are never limited.
+	unlimited, err := r.SignStreamURL(scope, time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ChargeStreamURL(file, unlimited, 10*size); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.VerifyStreamURL(file, unlimited); err != nil {
+		t.Fatal(err)
+	}
+
+	// A URL with a valid signature can't be used after it expired.
+	id = r.mu.RLock()
+	key := r.persist.StreamURLKey
+	r.mu.RUnlock(id)
+	expired := modules.StreamSignature{
+		Expires: time.Now().Add(-time.Minute).Unix(),
+		ID:      "expired",
+		Scope:   scope,
+	}
+	expired.Signature = streamURLSignature(key, expired)
+	if err := r.VerifyStreamURL(file, expired); !errors.Contains(err, errStreamURLExpired) {
+		t.Fatal("expected errStreamURLExpired", err)
+	}
+}
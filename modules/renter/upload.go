@@ -49,15 +49,20 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	}
 	file.Close()
 
-	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
+	// Delete or version the existing file if overwrite flag is set.
 	if up.Force {
-		err := r.DeleteFile(up.SiaPath)
-		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
-			return errors.AddContext(err, "unable to delete existing file")
+		err := r.managedOverwriteFile(up.SiaPath)
+		if err != nil {
+			return err
 		}
 	}
 
-	// Fill in any missing upload params with sensible defaults.
+	// Fill in any missing upload params from the upload policy of the
+	// directory and then with sensible defaults.
+	err = r.managedApplyUploadPolicy(&up)
+	if err != nil {
+		return err
+	}
 	if up.ErasureCode == nil {
 		up.ErasureCode = modules.NewRSSubCodeDefault()
 	}
@@ -98,6 +103,9 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return errors.AddContext(err, "could not open the new sia file")
 	}
 
+	// Compute the content hash of the file in the background.
+	go r.threadedSetContentHashFromDisk(entry.Copy(), up.Source)
+
 	// No need to upload zero-byte files.
 	if sourceInfo.Size() == 0 {
 		return nil
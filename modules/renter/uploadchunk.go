@@ -48,6 +48,10 @@ type unfinishedUploadChunk struct {
 	stuck                  bool   // indicates if the chunk was marked as stuck during last repair
 	stuckRepair            bool   // indicates if the chunk was identified for repair by the stuck loop
 
+	// repairPriority is the repair priority of the chunk's siafile as set by
+	// the upload policy of its directory.
+	repairPriority modules.RepairPriority
+
 	// Static cached fields.
 	staticIndex    uint64
 	staticSiaPath  string
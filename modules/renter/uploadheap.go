@@ -76,18 +76,23 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 	//      than all other chunks. An example would be if the upload of a single
 	//      chunk is a blocking task.
 	//
-	//  2) File Recently Successful Chunks
+	//  2) Repair Priority
+	//    - These are chunks of siafiles in directories with a higher repair
+	//      priority in their upload policy. The priority only orders the
+	//      chunks in the heap, the directory heap doesn't consider it.
+	//
+	//  3) File Recently Successful Chunks
 	//    - These are stuck chunks that are from a file that recently had a
 	//      successful repair
 	//
-	//  3) Stuck Chunks
+	//  4) Stuck Chunks
 	//    - These are chunks added by the stuck loop
 	//
-	//  4) Remote Chunks
+	//  5) Remote Chunks
 	//    - These are chunks of a siafile that do not have a local file to repair
 	//    from
 	//
-	//  5) Worst Health Chunk
+	//  6) Worst Health Chunk
 	//    - The base priority of chunks in the heap is by the worst health
 
 	// Check for Priority chunks
@@ -101,6 +106,13 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 		return false
 	}
 
+	// Check for Repair Priority
+	//
+	// Prioritize the chunk with the higher repair priority.
+	if uch[i].repairPriority != uch[j].repairPriority {
+		return uch[i].repairPriority > uch[j].repairPriority
+	}
+
 	// Check for File Recently Successful Chunks
 	//
 	// If only chunk i's file was recently successful, return true to prioritize
@@ -683,6 +695,9 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 		pks[string(pk.Key)] = pk
 	}
 
+	// Determine the repair priority of the file's chunks.
+	repairPriority := r.managedRepairPriority(r.staticFileSystem.FileSiaPath(entry))
+
 	// Assemble the set of chunks.
 	newUnfinishedChunks := make([]*unfinishedUploadChunk, 0, len(chunkIndexes))
 	for _, index := range chunkIndexes {
@@ -697,6 +712,7 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 			r.log.Debugln("Error when building an unfinished chunk:", err)
 			continue
 		}
+		chunk.repairPriority = repairPriority
 		newUnfinishedChunks = append(newUnfinishedChunks, chunk)
 	}
 
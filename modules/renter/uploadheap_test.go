@@ -2,6 +2,7 @@ package renter
 
 import (
 	"bytes"
+	"container/heap"
 	"fmt"
 	"io"
 	"os"
@@ -1283,3 +1284,31 @@ func (rt *renterTester) managedBlockUntilBubblesComplete() {
 		rt.renter.bubbleUpdatesMu.Unlock()
 	}
 }
+
+// TestUploadHeapRepairPriority tests that chunks with a higher repair priority
+// are popped first, but that priority chunks still come before them.
+func TestUploadHeapRepairPriority(t *testing.T) {
+	t.Parallel()
+
+	var uch uploadChunkHeap
+	heap.Push(&uch, &unfinishedUploadChunk{health: 2, repairPriority: modules.RepairPriorityLow})
+	heap.Push(&uch, &unfinishedUploadChunk{health: 1, repairPriority: modules.RepairPriorityHigh})
+	heap.Push(&uch, &unfinishedUploadChunk{health: 1, repairPriority: modules.RepairPriorityLow, staticPriority: true})
+	heap.Push(&uch, &unfinishedUploadChunk{health: 3, repairPriority: modules.RepairPriorityNormal, stuck: true})
+
+	expected := []modules.RepairPriority{
+		modules.RepairPriorityLow, // priority chunk
+		modules.RepairPriorityHigh,
+		modules.RepairPriorityNormal,
+		modules.RepairPriorityLow,
+	}
+	for i, rp := range expected {
+		chunk := heap.Pop(&uch).(*unfinishedUploadChunk)
+		if chunk.repairPriority != rp {
+			t.Fatalf("chunk %v: expected repair priority %v but got %v", i, rp, chunk.repairPriority)
+		}
+	}
+	if uch.Len() != 0 {
+		t.Fatal("heap should be empty")
+	}
+}
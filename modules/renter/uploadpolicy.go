@@ -0,0 +1,137 @@
+package renter
+
+// Every directory can have an upload policy which specifies the erasure coding
+// settings, the cipher type and the repair priority of the siafiles uploaded
+// into the directory and its subdirectories. Settings which aren't set in a
+// directory's policy are inherited from the closest parent directory which
+// sets them. Uploads only use the policy for settings which weren't specified
+// by the caller.
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+)
+
+// managedUploadPolicy returns the effective upload policy of the dir at the
+// given siapath by merging the policies of the dir and all of its parents.
+// Directories which don't exist yet are skipped. If none of the dirs sets a
+// repair priority, RepairPriorityNormal is returned.
+func (r *Renter) managedUploadPolicy(dirSiaPath modules.SiaPath) (modules.UploadPolicy, error) {
+	var policy modules.UploadPolicy
+	for {
+		dirPolicy, err := r.managedDirUploadPolicy(dirSiaPath)
+		if err != nil {
+			return modules.UploadPolicy{}, err
+		}
+		if policy.DataPieces == 0 && dirPolicy.DataPieces != 0 {
+			policy.DataPieces = dirPolicy.DataPieces
+			policy.ParityPieces = dirPolicy.ParityPieces
+		}
+		if policy.CipherType == "" {
+			policy.CipherType = dirPolicy.CipherType
+		}
+		if policy.RepairPriority == modules.RepairPriorityInherit {
+			policy.RepairPriority = dirPolicy.RepairPriority
+		}
+		if dirSiaPath.IsRoot() {
+			break
+		}
+		dirSiaPath, err = dirSiaPath.Dir()
+		if err != nil {
+			return modules.UploadPolicy{}, err
+		}
+	}
+	if policy.RepairPriority == modules.RepairPriorityInherit {
+		policy.RepairPriority = modules.RepairPriorityNormal
+	}
+	return policy, nil
+}
+
+// managedDirUploadPolicy returns the upload policy which is set for the dir at
+// the given siapath. If the dir doesn't exist, an empty policy is returned.
+func (r *Renter) managedDirUploadPolicy(dirSiaPath modules.SiaPath) (_ modules.UploadPolicy, err error) {
+	dir, err := r.staticFileSystem.OpenSiaDir(dirSiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return modules.UploadPolicy{}, nil
+	}
+	if err != nil {
+		return modules.UploadPolicy{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return modules.UploadPolicy{}, err
+	}
+	return md.UploadPolicy, nil
+}
+
+// managedApplyUploadPolicy fills in the erasure code and cipher type of the
+// upload params from the upload policy of the siafile's directory if they
+// weren't specified.
+func (r *Renter) managedApplyUploadPolicy(up *modules.FileUploadParams) error {
+	if up.ErasureCode != nil && up.CipherType != crypto.TypeInvalid {
+		return nil // nothing to fill in
+	}
+	dirSiaPath, err := up.SiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	policy, err := r.managedUploadPolicy(dirSiaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to fetch upload policy")
+	}
+	if up.ErasureCode == nil && policy.DataPieces != 0 {
+		up.ErasureCode, err = modules.NewRSSubCode(policy.DataPieces, policy.ParityPieces, crypto.SegmentSize)
+		if err != nil {
+			return errors.AddContext(err, "invalid erasure code settings in upload policy")
+		}
+	}
+	if up.CipherType == crypto.TypeInvalid && policy.CipherType != "" {
+		err = up.CipherType.FromString(policy.CipherType)
+		if err != nil {
+			return errors.AddContext(err, "invalid cipher type in upload policy")
+		}
+	}
+	return nil
+}
+
+// managedRepairPriority returns the repair priority of the siafile at the given
+// siapath.
+func (r *Renter) managedRepairPriority(siaPath modules.SiaPath) modules.RepairPriority {
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		r.log.Debugln("WARN: unable to get dir of siafile:", err)
+		return modules.RepairPriorityNormal
+	}
+	policy, err := r.managedUploadPolicy(dirSiaPath)
+	if err != nil {
+		r.log.Debugln("WARN: unable to fetch upload policy:", err)
+		return modules.RepairPriorityNormal
+	}
+	return policy.RepairPriority
+}
+
+// SetDirUploadPolicy updates the upload policy of the dir at the given siapath.
+// The policy applies to uploads into the dir and its subdirs.
+func (r *Renter) SetDirUploadPolicy(siaPath modules.SiaPath, policy modules.UploadPolicy) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := policy.Validate(); err != nil {
+		return errors.AddContext(err, "invalid upload policy")
+	}
+	dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.UpdateUploadPolicy(policy)
+}
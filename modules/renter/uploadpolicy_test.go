@@ -0,0 +1,135 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/persist"
+)
+
+// TestUploadPolicyInheritance tests that the upload policies of directories
+// are inherited by their subdirectories and applied to uploads.
+func TestUploadPolicyInheritance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a dir and a subdir.
+	parent, err := modules.NewSiaPath("backups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := parent.Join("photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateDir(child, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without any policies, the repair priority should be normal and nothing
+	// else should be set.
+	policy, err := r.managedUploadPolicy(child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy != (modules.UploadPolicy{RepairPriority: modules.RepairPriorityNormal}) {
+		t.Fatal("unexpected default policy", policy)
+	}
+
+	// Invalid policies should be rejected.
+	invalid := []modules.UploadPolicy{
+		{DataPieces: 10},
+		{DataPieces: 0, ParityPieces: 10},
+		{CipherType: "foo"},
+		{RepairPriority: modules.RepairPriorityHigh + 1},
+	}
+	for _, p := range invalid {
+		if err := r.SetDirUploadPolicy(parent, p); err == nil {
+			t.Fatal("expected invalid policy to be rejected", p)
+		}
+	}
+
+	// Set a policy for the parent and override some of its fields in the
+	// child.
+	err = r.SetDirUploadPolicy(parent, modules.UploadPolicy{
+		DataPieces:     10,
+		ParityPieces:   30,
+		CipherType:     crypto.TypePlain.String(),
+		RepairPriority: modules.RepairPriorityLow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.SetDirUploadPolicy(child, modules.UploadPolicy{
+		RepairPriority: modules.RepairPriorityHigh,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err = r.managedUploadPolicy(child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := modules.UploadPolicy{
+		DataPieces:     10,
+		ParityPieces:   30,
+		CipherType:     crypto.TypePlain.String(),
+		RepairPriority: modules.RepairPriorityHigh,
+	}
+	if policy != expected {
+		t.Fatalf("expected policy %v but got %v", expected, policy)
+	}
+
+	// The policy should be applied to uploads into directories which don't
+	// exist yet.
+	fileSiaPath, err := child.Join("2020/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp := r.managedRepairPriority(fileSiaPath); rp != modules.RepairPriorityHigh {
+		t.Fatal("wrong repair priority", rp)
+	}
+	up := modules.FileUploadParams{SiaPath: fileSiaPath}
+	if err := r.managedApplyUploadPolicy(&up); err != nil {
+		t.Fatal(err)
+	}
+	if up.ErasureCode == nil || up.ErasureCode.MinPieces() != 10 || up.ErasureCode.NumPieces() != 40 {
+		t.Fatal("erasure code of policy wasn't applied", up.ErasureCode)
+	}
+	if up.CipherType != crypto.TypePlain {
+		t.Fatal("cipher type of policy wasn't applied", up.CipherType)
+	}
+
+	// Settings of the upload should take precedence over the policy.
+	ec := modules.NewRSSubCodeDefault()
+	up = modules.FileUploadParams{SiaPath: fileSiaPath, ErasureCode: ec, CipherType: crypto.TypeThreefish}
+	if err := r.managedApplyUploadPolicy(&up); err != nil {
+		t.Fatal(err)
+	}
+	if up.ErasureCode != ec || up.CipherType != crypto.TypeThreefish {
+		t.Fatal("upload settings were overwritten by the policy")
+	}
+
+	// The policy should be reflected in the directory info.
+	di, err := r.staticFileSystem.DirInfo(child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if di.UploadPolicy.RepairPriority != modules.RepairPriorityHigh || di.UploadPolicy.DataPieces != 0 {
+		t.Fatal("wrong upload policy in directory info", di.UploadPolicy)
+	}
+}
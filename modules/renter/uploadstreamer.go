@@ -138,6 +138,14 @@ func (r *Renter) UploadStreamFromReader(up modules.FileUploadParams, reader io.R
 // managedInitUploadStream verifies the upload parameters and prepares an empty
 // SiaFile for the upload.
 func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesystem.FileNode, error) {
+	// Fill in any missing upload params from the upload policy of the
+	// directory. Repairs use the settings of the existing file.
+	if !up.Repair {
+		err := r.managedApplyUploadPolicy(&up)
+		if err != nil {
+			return nil, err
+		}
+	}
 	siaPath, ec, force, repair, cipherType := up.SiaPath, up.ErasureCode, up.Force, up.Repair, up.CipherType
 	// Check if ec was set. If not use defaults.
 	var err error
@@ -153,10 +161,10 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 		return nil, errors.New("'force' and 'repair' can't both be set")
 	}
 
-	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
+	// Delete or version the existing file if overwrite flag is set.
 	if force {
-		err := r.DeleteFile(siaPath)
-		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		err := r.managedOverwriteFile(siaPath)
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -179,14 +187,22 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	}
 
 	// If there's a cipherKey defined already use that, otherwise generate a new
-	// key of the given cipherType.
+	// key of the given cipherType. If no cipher type has been set, the default
+	// renter type will be used.
 	cipherKey := up.CipherKey
 	if up.CipherKey == nil {
+		if cipherType == crypto.TypeInvalid {
+			cipherType = crypto.TypeDefaultRenter
+		}
 		cipherKey = crypto.GenerateSiaKey(cipherType)
 	}
 
 	// Create the Siafile and add to renter
-	err = r.staticFileSystem.NewSiaFile(siaPath, up.Source, up.ErasureCode, cipherKey, 0, defaultFilePerm, up.DisablePartialChunk)
+	mode := up.Mode
+	if mode == 0 {
+		mode = defaultFilePerm
+	}
+	err = r.staticFileSystem.NewSiaFile(siaPath, up.Source, up.ErasureCode, cipherKey, 0, mode, up.DisablePartialChunk)
 	if err != nil {
 		return nil, err
 	}
@@ -238,6 +254,9 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		return nil, fmt.Errorf("Need at least %v workers for upload but got only %v", minWorkers, availableWorkers)
 	}
 
+	// Hash the data while reading it from the input stream.
+	hashReader := newContentHashReader(reader)
+
 	// Read the chunks we want to upload one by one from the input stream using
 	// shards. A shard will signal completion after reading the input but
 	// before the upload is done.
@@ -266,7 +285,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		}
 
 		// Create a new shard set it to be the source reader of the chunk.
-		ss := NewStreamShard(reader, peek)
+		ss := NewStreamShard(hashReader, peek)
 		uuc.sourceReader = ss
 
 		// Check if the chunk needs any work or if we can skip it.
@@ -320,6 +339,14 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		}
 	}
 
+	// All the data was read from the input stream. Store its content hash
+	// unless the stream was used to repair an existing file.
+	if !up.Repair {
+		if err := fileNode.SetContentHash(hashReader.Sum()); err != nil {
+			return nil, errors.AddContext(err, "unable to set content hash")
+		}
+	}
+
 	// Wait for all chunks to become available.
 	start := time.Now()
 	for _, chunk := range chunks {
@@ -1,8 +1,11 @@
 package renter
 
 import (
+	"os"
 	"testing"
 	"time"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
 )
 
 // TestEstimateTimeUntilComplete is a unit test that probes
@@ -50,3 +53,43 @@ func TestEstimateTimeUntilComplete(t *testing.T) {
 		t.Fatal("unexpected", timeUntilComplete)
 	}
 }
+
+// TestInitUploadStreamMode tests that streamed uploads use the provided file
+// mode and fall back to the default file mode.
+func TestInitUploadStreamMode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	for _, mode := range []os.FileMode{0, 0640} {
+		up := modules.FileUploadParams{
+			SiaPath: modules.RandomSiaPath(),
+			Mode:    mode,
+		}
+		fileNode, err := rt.renter.managedInitUploadStream(up)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := mode
+		if expected == 0 {
+			expected = defaultFilePerm
+		}
+		if fileNode.Mode() != expected {
+			t.Errorf("expected mode %v but got %v", expected, fileNode.Mode())
+		}
+		if err := fileNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
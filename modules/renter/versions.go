@@ -0,0 +1,257 @@
+package renter
+
+// Versioning of siafiles can be enabled per directory. If enabled, siafiles
+// which are overwritten by an upload with the 'Force' flag are moved to a
+// hidden location within the VersionsFolder instead of being deleted. The
+// versions of a siafile are stored in a directory which mirrors the siafile's
+// siapath within the VersionsFolder and are named after the time at which they
+// were overwritten. Versions which exceed the directory's limits are pruned
+// whenever a siafile is overwritten or its versions are accessed.
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+)
+
+var (
+	// ErrVersionNotFound is returned when a version of a siafile doesn't
+	// exist.
+	ErrVersionNotFound = errors.New("file version not found")
+)
+
+// versionsSiaPath returns the siapath of the directory which contains the
+// versions of the siafile at the given siapath.
+func versionsSiaPath(siaPath modules.SiaPath) (modules.SiaPath, error) {
+	return modules.VersionsFolder.Join(siaPath.String())
+}
+
+// managedVersioning returns the versioning settings of the directory of the
+// siafile at the given siapath.
+func (r *Renter) managedVersioning(siaPath modules.SiaPath) (maxVersions, retentionDays uint64, err error) {
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return 0, 0, err
+	}
+	dir, err := r.staticFileSystem.OpenSiaDir(dirSiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return 0, 0, err
+	}
+	return md.MaxVersions, md.VersionRetentionDays, nil
+}
+
+// managedOverwriteFile prepares the siafile at the given siapath for being
+// overwritten. If versioning is enabled for the siafile's directory, the
+// siafile is kept as a version. Otherwise it is deleted.
+func (r *Renter) managedOverwriteFile(siaPath modules.SiaPath) error {
+	maxVersions, retentionDays, err := r.managedVersioning(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to fetch versioning settings")
+	}
+	if maxVersions == 0 && retentionDays == 0 {
+		err = r.DeleteFile(siaPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return errors.AddContext(err, "unable to delete existing file")
+		}
+		return nil
+	}
+	_, err = r.managedAddFileVersion(siaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return r.managedPruneFileVersions(siaPath, maxVersions, retentionDays)
+}
+
+// managedAddFileVersion moves the siafile at the given siapath to a new
+// version and returns the siapath of the version.
+func (r *Renter) managedAddFileVersion(siaPath modules.SiaPath) (modules.SiaPath, error) {
+	versionsDir, err := versionsSiaPath(siaPath)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	versionSiaPath, err := versionsDir.Join(strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	err = r.RenameFile(siaPath, versionSiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return modules.SiaPath{}, filesystem.ErrNotExist
+	}
+	if err != nil {
+		return modules.SiaPath{}, errors.AddContext(err, "unable to move siafile to versions")
+	}
+	return versionSiaPath, nil
+}
+
+// managedFileVersions returns the versions of the siafile at the given siapath
+// sorted from the most recent to the least recent version.
+func (r *Renter) managedFileVersions(siaPath modules.SiaPath) ([]modules.FileVersion, error) {
+	versionsDir, err := versionsSiaPath(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	var versions []modules.FileVersion
+	err = r.staticFileSystem.CachedList(versionsDir, false, func(fi modules.FileInfo) {
+		nanos, err := strconv.ParseInt(fi.Name(), 10, 64)
+		if err != nil {
+			return // not a version
+		}
+		mu.Lock()
+		versions = append(versions, modules.FileVersion{
+			ID:         fi.Name(),
+			Redundancy: fi.Redundancy,
+			SiaPath:    fi.SiaPath,
+			Size:       fi.Filesize,
+			Time:       time.Unix(0, nanos),
+		})
+		mu.Unlock()
+	}, func(modules.DirectoryInfo) {})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to list versions")
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Time.After(versions[j].Time)
+	})
+	return versions, nil
+}
+
+// managedPruneFileVersions deletes the versions of the siafile at the given
+// siapath which exceed the provided limits. A limit of 0 means that there is
+// no limit.
+func (r *Renter) managedPruneFileVersions(siaPath modules.SiaPath, maxVersions, retentionDays uint64) error {
+	versions, err := r.managedFileVersions(siaPath)
+	if err != nil {
+		return err
+	}
+	retention := time.Duration(retentionDays) * 24 * time.Hour
+	for i, version := range versions {
+		tooMany := maxVersions > 0 && uint64(i) >= maxVersions
+		tooOld := retentionDays > 0 && time.Since(version.Time) > retention
+		if !tooMany && !tooOld {
+			continue
+		}
+		err = r.DeleteFile(version.SiaPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return errors.AddContext(err, "unable to delete version")
+		}
+	}
+	return nil
+}
+
+// FileVersions returns the hidden versions of the siafile at the given siapath
+// which were kept when the siafile was overwritten. The versions are sorted
+// from the most recent to the least recent version.
+func (r *Renter) FileVersions(siaPath modules.SiaPath) ([]modules.FileVersion, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	maxVersions, retentionDays, err := r.managedVersioning(siaPath)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to fetch versioning settings")
+	}
+	err = r.managedPruneFileVersions(siaPath, maxVersions, retentionDays)
+	if err != nil {
+		return nil, err
+	}
+	return r.managedFileVersions(siaPath)
+}
+
+// RestoreFileVersion replaces the siafile at the given siapath with one of its
+// versions. The replaced siafile is kept as a version to allow for undoing the
+// restore. If the siafile doesn't exist anymore, the version is restored
+// without replacing anything.
+func (r *Renter) RestoreFileVersion(siaPath modules.SiaPath, id string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Make sure the version exists.
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return ErrVersionNotFound
+	}
+	versionsDir, err := versionsSiaPath(siaPath)
+	if err != nil {
+		return err
+	}
+	versionSiaPath, err := versionsDir.Join(id)
+	if err != nil {
+		return err
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(versionSiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return ErrVersionNotFound
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to open version")
+	}
+	if err := entry.Close(); err != nil {
+		return err
+	}
+
+	// Keep the current siafile as a version.
+	replacedSiaPath, err := r.managedAddFileVersion(siaPath)
+	replaced := err == nil
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return err
+	}
+
+	// Restore the version. If that fails, try to move the replaced siafile
+	// back.
+	err = r.RenameFile(versionSiaPath, siaPath)
+	if err != nil && replaced {
+		err = errors.Compose(err, r.RenameFile(replacedSiaPath, siaPath))
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to restore version")
+	}
+
+	// Prune the versions since the replaced siafile was added.
+	maxVersions, retentionDays, err := r.managedVersioning(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to fetch versioning settings")
+	}
+	return r.managedPruneFileVersions(siaPath, maxVersions, retentionDays)
+}
+
+// SetDirVersioning updates the versioning settings of the dir at the given
+// siapath. If both maxVersions and retentionDays are 0, versioning is
+// disabled. Existing versions are not affected by disabling versioning.
+func (r *Renter) SetDirVersioning(siaPath modules.SiaPath, maxVersions, retentionDays uint64) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.UpdateVersioning(maxVersions, retentionDays)
+}
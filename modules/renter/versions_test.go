@@ -0,0 +1,121 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestFileVersions tests keeping, pruning and restoring versions of
+// overwritten siafiles.
+func TestFileVersions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a file.
+	siaPath, rsc := testingFileParams()
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	createFile := func() {
+		entry, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.RandomCipherType())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createFile()
+
+	// Without versioning, overwriting the file deletes it.
+	if err := r.managedOverwriteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(siaPath); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("expected file to be deleted", err)
+	}
+	versions, err := r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 {
+		t.Fatal("expected no versions", len(versions))
+	}
+
+	// Enable versioning and overwrite the file a few times. Only the most
+	// recent versions should be kept.
+	if err := r.SetDirVersioning(dirSiaPath, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		createFile()
+		if err := r.managedOverwriteFile(siaPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatal("wrong number of versions", len(versions))
+	}
+	if !versions[0].Time.After(versions[1].Time) {
+		t.Fatal("versions aren't sorted from most to least recent")
+	}
+
+	// Restore the oldest version. Since the file doesn't exist, nothing should
+	// be kept as a version.
+	restored := versions[1].ID
+	if err := r.RestoreFileVersion(siaPath, restored); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].ID == restored {
+		t.Fatal("wrong versions after restore", versions)
+	}
+
+	// Restore the remaining version. The current file should be kept as a
+	// version.
+	if err := r.RestoreFileVersion(siaPath, versions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Fatal("expected the replaced file to be kept as a version", len(versions))
+	}
+
+	// Restoring a version which doesn't exist should fail.
+	if err := r.RestoreFileVersion(siaPath, restored); err != ErrVersionNotFound {
+		t.Fatal("expected ErrVersionNotFound", err)
+	}
+	if err := r.RestoreFileVersion(siaPath, "foo"); err != ErrVersionNotFound {
+		t.Fatal("expected ErrVersionNotFound", err)
+	}
+}
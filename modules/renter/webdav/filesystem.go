@@ -0,0 +1,438 @@
+package webdav
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"golang.org/x/net/webdav"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+)
+
+var (
+	// errIsDir is returned when a directory is read or written like a file.
+	errIsDir = errors.New("is a directory")
+
+	// errNotWritable is returned when writing to a file which wasn't opened
+	// for writing.
+	errNotWritable = errors.New("file wasn't opened for writing")
+
+	// errNotReadable is returned when reading from a file which was opened
+	// for writing.
+	errNotReadable = errors.New("file was opened for writing")
+
+	// errPartialWrite is returned when a file is opened for writing without
+	// truncating it. Siafiles can only be replaced as a whole.
+	errPartialWrite = errors.New("files can only be overwritten as a whole")
+
+	// errRootModification is returned when the root of the webdav filesystem
+	// is deleted or moved.
+	errRootModification = errors.New("the root can't be deleted or moved")
+)
+
+type (
+	// fileSystem implements webdav.FileSystem on top of the renter.
+	fileSystem struct {
+		staticRenter modules.Renter
+	}
+
+	// fileInfo wraps a modules.FileInfo to provide the content type of a file
+	// without downloading it.
+	fileInfo struct {
+		modules.FileInfo
+	}
+
+	// dir is a webdav.File for a directory.
+	dir struct {
+		staticFS      *fileSystem
+		staticInfo    os.FileInfo
+		staticSiaPath modules.SiaPath
+
+		// entries contains the entries which weren't returned by Readdir
+		// yet. They are fetched on the first call to Readdir.
+		entries []os.FileInfo
+		listed  bool
+	}
+
+	// downloadFile is a webdav.File which reads a file using the renter's
+	// streamer.
+	downloadFile struct {
+		modules.Streamer
+		staticInfo os.FileInfo
+	}
+
+	// uploadFile is a webdav.File which streams the data written to it into an
+	// upload to a temporary siapath. The upload only replaces the file at the
+	// target siapath once all the data was written successfully.
+	uploadFile struct {
+		staticBody       *requestBody
+		staticDone       chan struct{}
+		staticFS         *fileSystem
+		staticPipe       *io.PipeWriter
+		staticSiaPath    modules.SiaPath
+		staticTmpSiaPath modules.SiaPath
+
+		err      error
+		writeErr error
+		written  int64
+	}
+)
+
+// Ensure the types satisfy the required interfaces.
+var _ = (webdav.FileSystem)((*fileSystem)(nil))
+var _ = (webdav.ContentTyper)(fileInfo{})
+var _ = (webdav.File)((*dir)(nil))
+var _ = (webdav.File)((*downloadFile)(nil))
+var _ = (webdav.File)((*uploadFile)(nil))
+
+// isNotExist returns whether the error indicates that a file or directory
+// doesn't exist.
+func isNotExist(err error) bool {
+	return os.IsNotExist(err) || errors.Contains(err, filesystem.ErrNotExist)
+}
+
+// siaPath converts a webdav path into a siapath within the user folder.
+func siaPath(name string) (modules.SiaPath, error) {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return modules.UserFolder, nil
+	}
+	return modules.UserFolder.Join(name)
+}
+
+// ContentType implements the webdav.ContentTyper interface. The content type
+// is determined by the file's extension since sniffing it would require
+// downloading the file.
+func (fi fileInfo) ContentType(ctx context.Context) (string, error) {
+	if ctype := mime.TypeByExtension(path.Ext(fi.Name())); ctype != "" {
+		return ctype, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// stat returns information about the file or directory at the given siapath.
+func (fs *fileSystem) stat(sp modules.SiaPath) (os.FileInfo, error) {
+	fi, err := fs.staticRenter.File(sp)
+	if err == nil {
+		return fileInfo{fi}, nil
+	}
+	if !isNotExist(err) {
+		return nil, err
+	}
+	dis, err := fs.staticRenter.DirList(sp)
+	if isNotExist(err) || (err == nil && len(dis) == 0) {
+		return nil, os.ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dis[0], nil
+}
+
+// Mkdir implements the webdav.FileSystem interface. The requested permissions
+// are ignored in favor of the renter's defaults.
+func (fs *fileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	sp, err := siaPath(name)
+	if err != nil {
+		return err
+	}
+	err = fs.staticRenter.CreateDir(sp, modules.DefaultDirPerm)
+	if errors.Contains(err, filesystem.ErrExists) {
+		return os.ErrExist
+	}
+	return err
+}
+
+// OpenFile implements the webdav.FileSystem interface. Files which are opened
+// for writing are uploaded when they are closed.
+func (fs *fileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	sp, err := siaPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		if flag&os.O_TRUNC == 0 {
+			return nil, errPartialWrite
+		}
+		return fs.newUploadFile(ctx, sp)
+	}
+	info, err := fs.stat(sp)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &dir{
+			staticFS:      fs,
+			staticInfo:    info,
+			staticSiaPath: sp,
+		}, nil
+	}
+	_, stream, err := fs.staticRenter.Streamer(sp, false)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to get stream for file")
+	}
+	return &downloadFile{
+		Streamer:   stream,
+		staticInfo: info,
+	}, nil
+}
+
+// RemoveAll implements the webdav.FileSystem interface.
+func (fs *fileSystem) RemoveAll(ctx context.Context, name string) error {
+	sp, err := siaPath(name)
+	if err != nil {
+		return err
+	}
+	if sp.Equals(modules.UserFolder) {
+		return errRootModification
+	}
+	info, err := fs.stat(sp)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fs.staticRenter.DeleteDir(sp)
+	}
+	return fs.staticRenter.DeleteFile(sp)
+}
+
+// Rename implements the webdav.FileSystem interface.
+func (fs *fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	oldSiaPath, err := siaPath(oldName)
+	if err != nil {
+		return err
+	}
+	newSiaPath, err := siaPath(newName)
+	if err != nil {
+		return err
+	}
+	if oldSiaPath.Equals(modules.UserFolder) || newSiaPath.Equals(modules.UserFolder) {
+		return errRootModification
+	}
+	info, err := fs.stat(oldSiaPath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = fs.staticRenter.RenameDir(oldSiaPath, newSiaPath)
+	} else {
+		err = fs.staticRenter.RenameFile(oldSiaPath, newSiaPath)
+	}
+	if errors.Contains(err, filesystem.ErrExists) {
+		return os.ErrExist
+	}
+	return err
+}
+
+// Stat implements the webdav.FileSystem interface.
+func (fs *fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	sp, err := siaPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.stat(sp)
+}
+
+// Close implements the http.File interface.
+func (d *dir) Close() error {
+	return nil
+}
+
+// Read implements the http.File interface.
+func (d *dir) Read([]byte) (int, error) {
+	return 0, errIsDir
+}
+
+// Readdir implements the http.File interface.
+func (d *dir) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.listed {
+		entries, err := d.staticFS.list(d.staticSiaPath)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.listed = true
+	}
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(d.entries) {
+		count = len(d.entries)
+	}
+	entries := d.entries[:count]
+	d.entries = d.entries[count:]
+	return entries, nil
+}
+
+// Seek implements the http.File interface.
+func (d *dir) Seek(int64, int) (int64, error) {
+	return 0, errIsDir
+}
+
+// Stat implements the http.File interface.
+func (d *dir) Stat() (os.FileInfo, error) {
+	return d.staticInfo, nil
+}
+
+// Write implements the io.Writer interface.
+func (d *dir) Write([]byte) (int, error) {
+	return 0, errIsDir
+}
+
+// list returns the files and subdirectories of the directory at the given
+// siapath sorted by name.
+func (fs *fileSystem) list(sp modules.SiaPath) ([]os.FileInfo, error) {
+	dis, err := fs.staticRenter.DirList(sp)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to list directories")
+	}
+	var entries []os.FileInfo
+	// Skip the first directory since it's the directory itself.
+	for i := 1; i < len(dis); i++ {
+		entries = append(entries, dis[i])
+	}
+	var mu sync.Mutex
+	err = fs.staticRenter.FileList(sp, false, true, func(fi modules.FileInfo) {
+		mu.Lock()
+		entries = append(entries, fileInfo{fi})
+		mu.Unlock()
+	})
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to list files")
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// Readdir implements the http.File interface.
+func (df *downloadFile) Readdir(int) ([]os.FileInfo, error) {
+	return nil, os.ErrInvalid
+}
+
+// Stat implements the http.File interface.
+func (df *downloadFile) Stat() (os.FileInfo, error) {
+	return df.staticInfo, nil
+}
+
+// Write implements the io.Writer interface.
+func (df *downloadFile) Write([]byte) (int, error) {
+	return 0, errNotWritable
+}
+
+// newUploadFile creates a new uploadFile which replaces the file at the given
+// siapath with the data written to it.
+func (fs *fileSystem) newUploadFile(ctx context.Context, sp modules.SiaPath) (*uploadFile, error) {
+	tmpSiaPath, err := modules.UploadsFolder.Join(hex.EncodeToString(fastrand.Bytes(16)))
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	body, _ := ctx.Value(requestBodyKey{}).(*requestBody)
+	uf := &uploadFile{
+		staticBody:       body,
+		staticDone:       make(chan struct{}),
+		staticFS:         fs,
+		staticPipe:       pw,
+		staticSiaPath:    sp,
+		staticTmpSiaPath: tmpSiaPath,
+	}
+	go func() {
+		up := modules.FileUploadParams{
+			SiaPath: tmpSiaPath,
+		}
+		err := fs.staticRenter.UploadStreamFromReader(up, pr)
+		// Unblock the writer in case the upload stopped reading early.
+		pr.CloseWithError(err)
+		uf.err = err
+		close(uf.staticDone)
+	}()
+	return uf, nil
+}
+
+// Close implements the http.File interface. It waits for the upload to finish
+// and replaces the file at the target siapath with the uploaded file. If
+// writing the data failed, the upload is aborted and the target is left
+// untouched.
+func (uf *uploadFile) Close() error {
+	err := uf.writeErr
+	if err == nil && uf.staticBody != nil {
+		err = uf.staticBody.managedErr()
+	}
+	if err != nil {
+		uf.staticPipe.CloseWithError(err)
+	} else {
+		err = uf.staticPipe.Close()
+	}
+	<-uf.staticDone
+	err = errors.Compose(err, uf.err)
+	if err == nil {
+		err = uf.staticFS.staticRenter.OverwriteFile(uf.staticTmpSiaPath, uf.staticSiaPath)
+	}
+	if err == nil {
+		return nil
+	}
+	// Clean up the temporary file.
+	deleteErr := uf.staticFS.staticRenter.DeleteFile(uf.staticTmpSiaPath)
+	if deleteErr != nil && !isNotExist(deleteErr) {
+		err = errors.Compose(err, deleteErr)
+	}
+	return err
+}
+
+// Read implements the http.File interface.
+func (uf *uploadFile) Read([]byte) (int, error) {
+	return 0, errNotReadable
+}
+
+// Readdir implements the http.File interface.
+func (uf *uploadFile) Readdir(int) ([]os.FileInfo, error) {
+	return nil, os.ErrInvalid
+}
+
+// Seek implements the http.File interface.
+func (uf *uploadFile) Seek(int64, int) (int64, error) {
+	return 0, errNotReadable
+}
+
+// Stat implements the http.File interface. It returns the information of the
+// file as it will be once the upload finishes.
+func (uf *uploadFile) Stat() (os.FileInfo, error) {
+	return fileInfo{modules.FileInfo{
+		FileMode:         modules.DefaultFilePerm,
+		Filesize:         uint64(uf.written),
+		ModificationTime: time.Now(),
+		SiaPath:          uf.staticSiaPath,
+	}}, nil
+}
+
+// Write implements the io.Writer interface. A failed write aborts the upload
+// when the file is closed.
+func (uf *uploadFile) Write(b []byte) (int, error) {
+	if uf.writeErr != nil {
+		return 0, uf.writeErr
+	}
+	n, err := uf.staticPipe.Write(b)
+	uf.written += int64(n)
+	if err != nil {
+		uf.writeErr = err
+	}
+	return n, err
+}
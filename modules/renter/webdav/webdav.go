@@ -0,0 +1,175 @@
+// Package webdav serves the renter's filesystem over WebDAV. Paths are relative
+// to the user folder, like the siapaths of the renter API. Files are downloaded
+// using the renter's streamer which allows for range requests and uploaded by
+// streaming the request body into the renter. The server uses its own
+// credentials which are independent of the API password.
+package webdav
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/net/webdav"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errNoCredentials is returned when a server is created without a user
+	// or a password.
+	errNoCredentials = errors.New("the webdav server requires a user and a password")
+)
+
+type (
+	// Server is a WebDAV server which serves the renter's filesystem.
+	Server struct {
+		staticHTTPServer *http.Server
+		staticListener   net.Listener
+
+		serveChan chan struct{}
+		serveErr  error
+	}
+
+	// requestBody wraps the body of a PUT request to remember whether reading
+	// it failed. The webdav handler closes the uploaded file even if copying
+	// the body failed, so the upload checks it to avoid committing a
+	// truncated file.
+	requestBody struct {
+		io.ReadCloser
+
+		err error
+		mu  sync.Mutex
+	}
+
+	// requestBodyKey is the context key of the requestBody of a PUT request.
+	requestBodyKey struct{}
+)
+
+// Read implements the io.Reader interface.
+func (rb *requestBody) Read(b []byte) (int, error) {
+	n, err := rb.ReadCloser.Read(b)
+	if err != nil && err != io.EOF {
+		rb.mu.Lock()
+		rb.err = err
+		rb.mu.Unlock()
+	}
+	return n, err
+}
+
+// managedErr returns the error which occurred while reading the body.
+func (rb *requestBody) managedErr() error {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.err
+}
+
+// New creates a new WebDAV server which listens on the provided address and
+// serves the renter's filesystem to clients which authenticate with the
+// provided user and password using HTTP basic auth.
+func New(addr, user, password string, r modules.Renter) (*Server, error) {
+	if user == "" || password == "" {
+		return nil, errNoCredentials
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create webdav listener")
+	}
+	fs := &fileSystem{staticRenter: r}
+	handler := &webdav.Handler{
+		FileSystem: fs,
+		LockSystem: webdav.NewMemLS(),
+	}
+	srv := &Server{
+		staticHTTPServer: &http.Server{
+			Handler: requireCredentials(preparePut(handler, fs), user, password),
+
+			// Uploads and downloads of large files can take a long time, so
+			// only the headers are subject to a short timeout.
+			ReadHeaderTimeout: time.Minute * 2,
+			IdleTimeout:       time.Minute * 5,
+		},
+		staticListener: listener,
+		serveChan:      make(chan struct{}),
+	}
+	go func() {
+		srv.serveErr = srv.staticHTTPServer.Serve(listener)
+		close(srv.serveChan)
+	}()
+	return srv, nil
+}
+
+// Addr returns the address the server is listening on.
+func (srv *Server) Addr() net.Addr {
+	return srv.staticListener.Addr()
+}
+
+// Close shuts the server down.
+func (srv *Server) Close() error {
+	err := srv.staticHTTPServer.Shutdown(context.Background())
+	// Wait for Serve to return and capture its error.
+	<-srv.serveChan
+	if !errors.Contains(srv.serveErr, http.ErrServerClosed) {
+		err = errors.Compose(err, srv.serveErr)
+	}
+	return errors.AddContext(err, "error while closing webdav server")
+}
+
+// requireCredentials is middleware that requires a request to authenticate with
+// the provided user and password using HTTP basic auth.
+func requireCredentials(h http.Handler, user, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		u, p, ok := req.BasicAuth()
+		validUser := subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1
+		validPassword := subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+		if !ok || !validUser || !validPassword {
+			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaWebDAV\"")
+			http.Error(w, "WebDAV authentication failed.", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// preparePut is middleware that prepares PUT requests for the webdav handler.
+// Uploads into a missing collection fail with 409 Conflict as required by RFC
+// 4918 instead of creating the missing directories. The request body is
+// wrapped to detect truncated uploads.
+func preparePut(h http.Handler, fs *fileSystem) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPut {
+			h.ServeHTTP(w, req)
+			return
+		}
+		sp, err := siaPath(req.URL.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !sp.Equals(modules.UserFolder) {
+			parent, err := sp.Dir()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			info, err := fs.stat(parent)
+			if isNotExist(err) || (err == nil && !info.IsDir()) {
+				http.Error(w, "parent collection doesn't exist", http.StatusConflict)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		body := &requestBody{ReadCloser: req.Body}
+		req.Body = body
+		ctx := context.WithValue(req.Context(), requestBodyKey{}, body)
+		h.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
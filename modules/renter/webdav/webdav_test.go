@@ -0,0 +1,429 @@
+package webdav
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem"
+)
+
+type (
+	// mockRenter is an in-memory implementation of the parts of the
+	// modules.Renter interface which are used by the webdav server.
+	mockRenter struct {
+		modules.Renter
+
+		dirs      map[modules.SiaPath]struct{}
+		files     map[modules.SiaPath][]byte
+		uploadErr error
+		mu        sync.Mutex
+	}
+
+	// mockStreamer is a modules.Streamer for the data of a mockRenter file.
+	mockStreamer struct {
+		*bytes.Reader
+	}
+)
+
+// newMockRenter creates a mockRenter which only contains the root and the
+// user folder.
+func newMockRenter() *mockRenter {
+	return &mockRenter{
+		dirs: map[modules.SiaPath]struct{}{
+			modules.RootSiaPath(): {},
+			modules.UserFolder:    {},
+		},
+		files: make(map[modules.SiaPath][]byte),
+	}
+}
+
+// Close implements the io.Closer interface.
+func (ms mockStreamer) Close() error { return nil }
+
+// parent returns the parent directory of a siapath.
+func parent(sp modules.SiaPath) modules.SiaPath {
+	dir, err := sp.Dir()
+	if err != nil {
+		panic(err)
+	}
+	return dir
+}
+
+// fileInfo returns the FileInfo of a file. The lock needs to be held.
+func (mr *mockRenter) fileInfo(sp modules.SiaPath) modules.FileInfo {
+	return modules.FileInfo{
+		FileMode:         modules.DefaultFilePerm,
+		Filesize:         uint64(len(mr.files[sp])),
+		ModificationTime: time.Now(),
+		SiaPath:          sp,
+	}
+}
+
+// CreateDir implements the modules.Renter interface.
+func (mr *mockRenter) CreateDir(sp modules.SiaPath, mode os.FileMode) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.dirs[sp]; exists {
+		return filesystem.ErrExists
+	}
+	mr.dirs[sp] = struct{}{}
+	return nil
+}
+
+// DeleteDir implements the modules.Renter interface.
+func (mr *mockRenter) DeleteDir(sp modules.SiaPath) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.dirs[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	for dir := range mr.dirs {
+		if strings.HasPrefix(dir.String()+"/", sp.String()+"/") {
+			delete(mr.dirs, dir)
+		}
+	}
+	for file := range mr.files {
+		if strings.HasPrefix(file.String(), sp.String()+"/") {
+			delete(mr.files, file)
+		}
+	}
+	return nil
+}
+
+// DeleteFile implements the modules.Renter interface.
+func (mr *mockRenter) DeleteFile(sp modules.SiaPath) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.files[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	delete(mr.files, sp)
+	return nil
+}
+
+// DirList implements the modules.Renter interface.
+func (mr *mockRenter) DirList(sp modules.SiaPath) ([]modules.DirectoryInfo, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.dirs[sp]; !exists {
+		return nil, filesystem.ErrNotExist
+	}
+	dis := []modules.DirectoryInfo{{SiaPath: sp, DirMode: modules.DefaultDirPerm}}
+	for dir := range mr.dirs {
+		if !dir.IsRoot() && parent(dir).Equals(sp) {
+			dis = append(dis, modules.DirectoryInfo{SiaPath: dir, DirMode: modules.DefaultDirPerm})
+		}
+	}
+	return dis, nil
+}
+
+// File implements the modules.Renter interface.
+func (mr *mockRenter) File(sp modules.SiaPath) (modules.FileInfo, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.files[sp]; !exists {
+		return modules.FileInfo{}, filesystem.ErrNotExist
+	}
+	return mr.fileInfo(sp), nil
+}
+
+// FileList implements the modules.Renter interface.
+func (mr *mockRenter) FileList(sp modules.SiaPath, recursive, cached bool, flf modules.FileListFunc) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	for file := range mr.files {
+		if parent(file).Equals(sp) {
+			flf(mr.fileInfo(file))
+		}
+	}
+	return nil
+}
+
+// RenameDir implements the modules.Renter interface.
+func (mr *mockRenter) RenameDir(oldPath, newPath modules.SiaPath) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.dirs[newPath]; exists {
+		return filesystem.ErrExists
+	}
+	for dir := range mr.dirs {
+		if rebased, err := dir.Rebase(oldPath, newPath); err == nil {
+			delete(mr.dirs, dir)
+			mr.dirs[rebased] = struct{}{}
+		}
+	}
+	for file, data := range mr.files {
+		if rebased, err := file.Rebase(oldPath, newPath); err == nil {
+			delete(mr.files, file)
+			mr.files[rebased] = data
+		}
+	}
+	return nil
+}
+
+// RenameFile implements the modules.Renter interface.
+func (mr *mockRenter) RenameFile(oldPath, newPath modules.SiaPath) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	data, exists := mr.files[oldPath]
+	if !exists {
+		return filesystem.ErrNotExist
+	}
+	if _, exists := mr.files[newPath]; exists {
+		return filesystem.ErrExists
+	}
+	delete(mr.files, oldPath)
+	mr.files[newPath] = data
+	return nil
+}
+
+// OverwriteFile implements the modules.Renter interface.
+func (mr *mockRenter) OverwriteFile(srcPath, dstPath modules.SiaPath) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	data, exists := mr.files[srcPath]
+	if !exists {
+		return filesystem.ErrNotExist
+	}
+	delete(mr.files, srcPath)
+	mr.files[dstPath] = data
+	return nil
+}
+
+// Streamer implements the modules.Renter interface.
+func (mr *mockRenter) Streamer(sp modules.SiaPath, disableLocalFetch bool) (string, modules.Streamer, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	data, exists := mr.files[sp]
+	if !exists {
+		return "", nil, filesystem.ErrNotExist
+	}
+	return sp.Name(), mockStreamer{bytes.NewReader(data)}, nil
+}
+
+// UploadStreamFromReader implements the modules.Renter interface.
+func (mr *mockRenter) UploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) error {
+	mr.mu.Lock()
+	uploadErr := mr.uploadErr
+	mr.mu.Unlock()
+	if uploadErr != nil {
+		return uploadErr
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	if _, exists := mr.files[up.SiaPath]; exists && !up.Force {
+		return filesystem.ErrExists
+	}
+	mr.files[up.SiaPath] = data
+	return nil
+}
+
+// TestSiaPath tests the conversion of webdav paths to siapaths.
+func TestSiaPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		result string
+	}{
+		{"", "home/user"},
+		{"/", "home/user"},
+		{"/foo", "home/user/foo"},
+		{"/foo/bar/", "home/user/foo/bar"},
+		{"foo//bar", "home/user/foo/bar"},
+		{"/../../foo", "home/user/foo"},
+	}
+	for _, test := range tests {
+		sp, err := siaPath(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sp.String() != test.result {
+			t.Errorf("%q: expected %v but got %v", test.name, test.result, sp)
+		}
+	}
+}
+
+// TestServer tests the webdav server with a mocked renter.
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	// A server without credentials can't be created.
+	mr := newMockRenter()
+	if _, err := New("localhost:0", "", "", mr); !errors.Contains(err, errNoCredentials) {
+		t.Fatal("expected errNoCredentials", err)
+	}
+	srv, err := New("localhost:0", "user", "password", mr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := srv.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	baseURL := "http://" + srv.Addr().String()
+
+	// request is a helper to send an authenticated request and check its
+	// status code.
+	request := func(method, path string, body []byte, header http.Header, status int) []byte {
+		t.Helper()
+		req, err := http.NewRequest(method, baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.SetBasicAuth("user", "password")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		respBody, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != status {
+			t.Fatalf("%v %v: expected status %v but got %v: %s", method, path, status, resp.StatusCode, respBody)
+		}
+		return respBody
+	}
+
+	// Requests without valid credentials are rejected.
+	resp, err := http.Get(baseURL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("expected unauthorized but got", resp.StatusCode)
+	}
+
+	// Create a directory.
+	request("MKCOL", "/docs", nil, nil, http.StatusCreated)
+	request("MKCOL", "/docs", nil, nil, http.StatusMethodNotAllowed)
+	docs, _ := modules.UserFolder.Join("docs")
+	if _, exists := mr.dirs[docs]; !exists {
+		t.Fatal("directory wasn't created")
+	}
+
+	// Upload a file.
+	data := fastrand.Bytes(100)
+	request("PUT", "/docs/file.txt", data, nil, http.StatusCreated)
+	file, _ := docs.Join("file.txt")
+	if !bytes.Equal(mr.files[file], data) {
+		t.Fatal("uploaded data doesn't match")
+	}
+
+	// Uploading into a missing directory fails without creating it.
+	request("PUT", "/missing/file.txt", data, nil, http.StatusConflict)
+	request("PUT", "/docs/file.txt/file.txt", data, nil, http.StatusConflict)
+	missing, _ := modules.UserFolder.Join("missing")
+	if _, exists := mr.dirs[missing]; exists {
+		t.Fatal("missing directory was created")
+	}
+
+	// A truncated upload shouldn't replace the file.
+	conn, err := net.Dial("tcp", srv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte("user:password"))
+	_, err = fmt.Fprintf(conn, "PUT /docs/file.txt HTTP/1.1\r\nHost: localhost\r\nAuthorization: Basic %v\r\nContent-Length: 200\r\n\r\n%s", auth, fastrand.Bytes(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.(*net.TCPConn).CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusCreated {
+		t.Fatal("truncated upload shouldn't succeed")
+	}
+	if !bytes.Equal(mr.files[file], data) || len(mr.files) != 1 {
+		t.Fatal("truncated upload modified the files", len(mr.files))
+	}
+
+	// A failed upload shouldn't replace the file either.
+	mr.mu.Lock()
+	mr.uploadErr = errors.New("upload failed")
+	mr.mu.Unlock()
+	request("PUT", "/docs/file.txt", fastrand.Bytes(100), nil, http.StatusMethodNotAllowed)
+	mr.mu.Lock()
+	mr.uploadErr = nil
+	mr.mu.Unlock()
+	if !bytes.Equal(mr.files[file], data) || len(mr.files) != 1 {
+		t.Fatal("failed upload modified the files", len(mr.files))
+	}
+
+	// Overwriting the file replaces its data.
+	data = fastrand.Bytes(100)
+	request("PUT", "/docs/file.txt", data, nil, http.StatusCreated)
+	if !bytes.Equal(mr.files[file], data) || len(mr.files) != 1 {
+		t.Fatal("overwritten data doesn't match")
+	}
+
+	// Download the whole file and a range of it.
+	if body := request("GET", "/docs/file.txt", nil, nil, http.StatusOK); !bytes.Equal(body, data) {
+		t.Fatal("downloaded data doesn't match")
+	}
+	rangeHeader := http.Header{"Range": []string{"bytes=10-19"}}
+	if body := request("GET", "/docs/file.txt", nil, rangeHeader, http.StatusPartialContent); !bytes.Equal(body, data[10:20]) {
+		t.Fatal("downloaded range doesn't match")
+	}
+
+	// List the directory.
+	depthHeader := http.Header{"Depth": []string{"1"}}
+	body := request("PROPFIND", "/docs", nil, depthHeader, http.StatusMultiStatus)
+	if !bytes.Contains(body, []byte("/docs/file.txt")) {
+		t.Fatalf("file is missing from listing: %s", body)
+	}
+
+	// Move the file.
+	moveHeader := http.Header{"Destination": []string{baseURL + "/docs/moved.txt"}}
+	request("MOVE", "/docs/file.txt", nil, moveHeader, http.StatusCreated)
+	moved, _ := docs.Join("moved.txt")
+	if _, exists := mr.files[file]; exists {
+		t.Fatal("file wasn't moved")
+	}
+	if !bytes.Equal(mr.files[moved], data) {
+		t.Fatal("moved data doesn't match")
+	}
+
+	// Delete the directory.
+	request("DELETE", "/docs", nil, nil, http.StatusNoContent)
+	if len(mr.files) != 0 || len(mr.dirs) != 2 {
+		t.Fatal("directory wasn't deleted", mr.files, mr.dirs)
+	}
+
+	// The root can't be deleted.
+	request("DELETE", "/", nil, nil, http.StatusMethodNotAllowed)
+}
@@ -31,6 +31,18 @@ const (
 	// host to support the registry.
 	minRegistryVersion = "1.5.1"
 
+	// minRegistryEIDVersion defines the minimum version that is required for
+	// a host to support looking up registry entries by their entry ID.
+	minRegistryEIDVersion = "1.5.5"
+
+	// minUploadAppendVersion defines the minimum version that is required for
+	// a host to support uploading sectors using Append programs.
+	minUploadAppendVersion = "1.5.4"
+
+	// minEphemeralStorageVersion defines the minimum version that is required
+	// for a host to support storing sectors without a file contract.
+	minEphemeralStorageVersion = "1.5.4"
+
 	// registryCacheSize is the cache size used by a single worker for the
 	// registry cache.
 	registryCacheSize = 1 << 20 // 1 MiB
@@ -88,9 +100,12 @@ type (
 		staticJobHasSectorQueue        *jobHasSectorQueue
 		staticJobReadQueue             *jobReadQueue
 		staticJobReadRegistryQueue     *jobReadRegistryQueue
+		staticJobReadRegistryEIDQueue  *jobReadRegistryEIDQueue
 		staticJobRenewQueue            *jobRenewQueue
+		staticJobStoreSectorQueue      *jobStoreSectorQueue
 		staticJobUpdateRegistryQueue   *jobUpdateRegistryQueue
 		staticJobUploadSnapshotQueue   *jobUploadSnapshotQueue
+		staticJobDeleteSnapshotsQueue  *jobDeleteSnapshotsQueue
 
 		// Upload variables.
 		unprocessedChunks         []*unfinishedUploadChunk // Yet unprocessed work items.
@@ -120,6 +135,10 @@ type (
 		// registry entries.
 		staticRegistryCache *registryRevisionCache
 
+		// staticSubscriptionInfo contains the state of the worker's registry
+		// subscription session with its host.
+		staticSubscriptionInfo *subscriptionInfos
+
 		// Utilities.
 		killChan chan struct{} // Worker will shut down if a signal is sent down this channel.
 		mu       sync.Mutex
@@ -214,8 +233,12 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 	w.initJobRenewQueue()
 	w.initJobDownloadSnapshotQueue()
 	w.initJobReadRegistryQueue()
+	w.initJobReadRegistryEIDQueue()
+	w.initJobStoreSectorQueue()
 	w.initJobUpdateRegistryQueue()
 	w.initJobUploadSnapshotQueue()
+	w.initJobDeleteSnapshotsQueue()
+	w.initSubscriptionInfos()
 
 	// Get the worker cache set up before returning the worker. This prevents a
 	// race condition in some tests.
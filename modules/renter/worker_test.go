@@ -193,6 +193,8 @@ func TestManagedAsyncReady(t *testing.T) {
 	w.initJobHasSectorQueue()
 	w.initJobReadQueue()
 	w.initJobUpdateRegistryQueue()
+	w.initJobReadRegistryEIDQueue()
+	w.initJobStoreSectorQueue()
 
 	timeInFuture := time.Now().Add(time.Hour)
 	timeInPast := time.Now().Add(-time.Hour)
@@ -0,0 +1,237 @@
+package renter
+
+import (
+	"context"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/contractor"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/proto"
+	"gitlab.com/NebulousLabs/encoding"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+type (
+	// jobDeleteSnapshots is a job for the worker to remove snapshots from the
+	// snapshot table of its respective host.
+	jobDeleteSnapshots struct {
+		staticUIDs [][16]byte
+
+		staticResponseChan chan *jobDeleteSnapshotsResponse
+
+		*jobGeneric
+	}
+
+	// jobDeleteSnapshotsQueue contains the set of snapshot deletions that need
+	// to be performed.
+	jobDeleteSnapshotsQueue struct {
+		*jobGenericQueue
+	}
+
+	// jobDeleteSnapshotsResponse contains the response to a delete snapshots
+	// job.
+	jobDeleteSnapshotsResponse struct {
+		staticErr error
+	}
+)
+
+// filterSnapshotEntries returns the entries of the snapshot table which are not
+// part of the provided set of UIDs.
+func filterSnapshotEntries(entryTable []snapshotEntry, uids map[[16]byte]struct{}) []snapshotEntry {
+	filtered := entryTable[:0]
+	for _, entry := range entryTable {
+		if _, ok := uids[entry.UID]; !ok {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// callDiscard will discard this job, sending an error down the response
+// channel.
+func (j *jobDeleteSnapshots) callDiscard(err error) {
+	resp := &jobDeleteSnapshotsResponse{
+		staticErr: errors.Extend(err, ErrJobDiscarded),
+	}
+	w := j.staticQueue.staticWorker()
+	errLaunch := w.renter.tg.Launch(func() {
+		select {
+		case j.staticResponseChan <- resp:
+		case <-j.staticCtx.Done():
+		case <-w.renter.tg.StopChan():
+		}
+	})
+	if errLaunch != nil {
+		w.renter.log.Print("callDiscard: launch failed", err)
+	}
+}
+
+// callExecute will perform a delete snapshots job for the worker.
+func (j *jobDeleteSnapshots) callExecute() {
+	w := j.staticQueue.staticWorker()
+
+	// Defer a function to send the result down a channel.
+	var err error
+	defer func() {
+		// Return the error to the caller, error may be nil.
+		resp := &jobDeleteSnapshotsResponse{
+			staticErr: err,
+		}
+		errLaunch := w.renter.tg.Launch(func() {
+			select {
+			case j.staticResponseChan <- resp:
+			case <-j.staticCtx.Done():
+			case <-w.renter.tg.StopChan():
+			}
+		})
+		if errLaunch != nil {
+			w.renter.log.Print("callExecute: launch failed", err)
+		}
+
+		// Report a failure to the queue if this job had an error.
+		if err != nil {
+			j.staticQueue.callReportFailure(err)
+		} else {
+			j.staticQueue.callReportSuccess()
+		}
+	}()
+
+	// Grab a session to replace the snapshot table.
+	var sess contractor.Session
+	sess, err = w.renter.hostContractor.Session(w.staticHostPubKey, w.renter.tg.StopChan())
+	if err != nil {
+		w.renter.log.Debugln("unable to grab a session to perform a delete snapshots job:", err)
+		err = errors.AddContext(err, "unable to get host session")
+		return
+	}
+	defer func() {
+		closeErr := sess.Close()
+		if closeErr != nil {
+			w.renter.log.Println("error while closing session:", closeErr)
+		}
+		err = errors.Compose(err, closeErr)
+	}()
+
+	// Replacing the snapshot table uploads a sector, so the same gouging
+	// checks as for uploading a snapshot apply.
+	allowance := w.renter.hostContractor.Allowance()
+	hostSettings := sess.HostSettings()
+	err = checkUploadSnapshotGouging(allowance, hostSettings)
+	if err != nil {
+		err = errors.AddContext(err, "snapshot deletion blocked because potential price gouging was detected")
+		return
+	}
+
+	// Delete the snapshots from the host.
+	err = w.renter.managedDeleteSnapshotsHost(j.staticUIDs, sess, w)
+	if err != nil {
+		w.renter.log.Debugln("deleting snapshots from a host failed:", err)
+		err = errors.AddContext(err, "deleting snapshots from a host failed")
+		return
+	}
+}
+
+// callExpectedBandwidth returns the amount of bandwidth this job is expected to
+// consume.
+func (j *jobDeleteSnapshots) callExpectedBandwidth() (ul, dl uint64) {
+	// Estimate 50kb in overhead for upload and download, and then 4 MiB
+	// necessary to download and upload the snapshot table each.
+	return 50e3 + 1<<22, 50e3 + 1<<22
+}
+
+// initJobDeleteSnapshotsQueue will initialize the delete snapshots job queue
+// for the worker.
+func (w *worker) initJobDeleteSnapshotsQueue() {
+	if w.staticJobDeleteSnapshotsQueue != nil {
+		w.renter.log.Critical("should not be double initializng the delete snapshots queue")
+		return
+	}
+
+	w.staticJobDeleteSnapshotsQueue = &jobDeleteSnapshotsQueue{
+		jobGenericQueue: newJobGenericQueue(w),
+	}
+}
+
+// managedDeleteSnapshotsHost removes the snapshots with the provided UIDs from
+// the snapshot table of a single host. The sectors of the snapshots remain in
+// the contract just like the sectors of snapshots which are evicted from a
+// full table.
+//
+// NOTE: Dropping the sectors would require swapping them to the end of the
+// contract and trimming them, but the local merkle roots of a contract are
+// only updated for appended sectors. The sectors are therefore kept until the
+// contract expires instead of risking a mismatch between the renter's and the
+// host's merkle roots.
+func (r *Renter) managedDeleteSnapshotsHost(uids [][16]byte, host contractor.Session, w *worker) error {
+	// Get the wallet seed.
+	ws, _, err := r.w.PrimarySeed()
+	if err != nil {
+		return errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := proto.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	// Derive the secret and wipe it afterwards.
+	secret := crypto.HashAll(rs, snapshotKeySpecifier)
+	defer fastrand.Read(secret[:])
+
+	// download the snapshot table
+	entryTable, err := r.managedDownloadSnapshotTable(w)
+	if errors.Contains(err, errEmptyContract) {
+		return nil // host doesn't have a table
+	} else if err != nil {
+		return errors.AddContext(err, "could not download the snapshot table")
+	}
+
+	// remove the entries, there is nothing to do if the host doesn't have any
+	// of them
+	toDelete := make(map[[16]byte]struct{}, len(uids))
+	for _, uid := range uids {
+		toDelete[uid] = struct{}{}
+	}
+	numEntries := len(entryTable)
+	entryTable = filterSnapshotEntries(entryTable, toDelete)
+	if len(entryTable) == numEntries {
+		return nil
+	}
+
+	// encode and encrypt the table
+	c, _ := crypto.NewSiaKey(crypto.TypeThreefish, secret[:])
+	newTable := make([]byte, modules.SectorSize)
+	copy(newTable[:16], snapshotTableSpecifier[:])
+	copy(newTable[16:], encoding.Marshal(entryTable))
+	tableSector := c.EncryptBytes(newTable)
+
+	// swap the new entry table into index 0 and delete the old one
+	if _, err := host.Replace(tableSector, 0, true); err != nil {
+		return errors.AddContext(err, "could not perform sector replace for the snapshot table")
+	}
+	return nil
+}
+
+// DeleteSnapshots is a helper method to run a DeleteSnapshots job on a worker.
+func (w *worker) DeleteSnapshots(ctx context.Context, uids [][16]byte) error {
+	deleteSnapshotsRespChan := make(chan *jobDeleteSnapshotsResponse)
+	jds := &jobDeleteSnapshots{
+		staticUIDs:         uids,
+		staticResponseChan: deleteSnapshotsRespChan,
+
+		jobGeneric: newJobGeneric(ctx, w.staticJobDeleteSnapshotsQueue, nil),
+	}
+
+	// Add the job to the queue.
+	if !w.staticJobDeleteSnapshotsQueue.callAdd(jds) {
+		return errors.New("worker unavailable")
+	}
+
+	// Wait for the response.
+	var resp *jobDeleteSnapshotsResponse
+	select {
+	case <-ctx.Done():
+		return errors.New("DeleteSnapshots interrupted")
+	case resp = <-deleteSnapshotsRespChan:
+	}
+	return resp.staticErr
+}
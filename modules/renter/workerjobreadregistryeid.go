@@ -0,0 +1,214 @@
+package renter
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+type (
+	// jobReadRegistryEID contains information about a ReadRegistryEID query.
+	// Unlike a regular ReadRegistry job, it looks up an entry by its ID
+	// without knowing the entry's public key and tweak.
+	jobReadRegistryEID struct {
+		staticRegistryEntryID crypto.Hash
+
+		staticResponseChan chan *jobReadRegistryEIDResponse // Channel to send a response down
+
+		*jobGeneric
+	}
+
+	// jobReadRegistryEIDQueue is a list of ReadRegistryEID jobs that have been
+	// assigned to the worker.
+	jobReadRegistryEIDQueue struct {
+		// These variables contain an exponential weighted average of the
+		// worker's recent performance for jobReadRegistryEIDQueue.
+		weightedJobTime float64
+
+		*jobGenericQueue
+	}
+
+	// jobReadRegistryEIDResponse contains the result of a ReadRegistryEID
+	// query.
+	jobReadRegistryEIDResponse struct {
+		staticSignedRegistryValue *modules.SignedRegistryValue
+		staticSiaPublicKey        types.SiaPublicKey
+		staticErr                 error
+	}
+)
+
+// lookupRegistryEID looks up a registry entry on the host by its entry ID and
+// verifies its signature.
+func lookupRegistryEID(w *worker, rid crypto.Hash) (types.SiaPublicKey, *modules.SignedRegistryValue, error) {
+	// Create the program.
+	pt := w.staticPriceTable().staticPriceTable
+	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since ReadRegistryEID doesn't depend on it.
+	pb.AddReadRegistryEIDInstruction(rid)
+	program, programData := pb.Program()
+	cost, _, _ := pb.Cost(true)
+
+	// take into account bandwidth costs
+	ulBandwidth, dlBandwidth := readRegistryJobExpectedBandwidth()
+	bandwidthCost := modules.MDMBandwidthCost(pt, ulBandwidth, dlBandwidth)
+	cost = cost.Add(bandwidthCost)
+
+	// Execute the program and parse the responses.
+	var responses []programResponse
+	responses, _, err := w.managedExecuteProgram(program, programData, types.FileContractID{}, cost)
+	if err != nil {
+		return types.SiaPublicKey{}, nil, errors.AddContext(err, "Unable to execute program")
+	}
+	for _, resp := range responses {
+		if resp.Error != nil {
+			return types.SiaPublicKey{}, nil, errors.AddContext(resp.Error, "Output error")
+		}
+		break
+	}
+	if len(responses) != len(program) {
+		return types.SiaPublicKey{}, nil, errors.New("received invalid number of responses but no error")
+	}
+
+	// Check if entry was found.
+	resp := responses[0]
+	if resp.OutputLength == 0 {
+		return types.SiaPublicKey{}, nil, nil
+	}
+
+	// Parse response.
+	spk, rv, err := modules.ParseReadRegistryEIDResponse(resp.Output)
+	if err != nil {
+		return types.SiaPublicKey{}, nil, errors.AddContext(err, "failed to parse signed revision response")
+	}
+
+	// Make sure the host returned the entry we asked for.
+	if modules.DeriveRegistryEntryID(spk, rv.Tweak) != rid {
+		return types.SiaPublicKey{}, nil, errors.New("host returned an entry with the wrong entry id")
+	}
+
+	// Verify signature.
+	if rv.Verify(spk.ToPublicKey()) != nil {
+		return types.SiaPublicKey{}, nil, errors.New("failed to verify returned registry value's signature")
+	}
+	return spk, &rv, nil
+}
+
+// newJobReadRegistryEID is a helper method to create a new ReadRegistryEID
+// job.
+func (w *worker) newJobReadRegistryEID(ctx context.Context, responseChan chan *jobReadRegistryEIDResponse, rid crypto.Hash) *jobReadRegistryEID {
+	return &jobReadRegistryEID{
+		staticRegistryEntryID: rid,
+		staticResponseChan:    responseChan,
+		jobGeneric:            newJobGeneric(ctx, w.staticJobReadRegistryEIDQueue, nil),
+	}
+}
+
+// callDiscard will discard a job, sending the provided error.
+func (j *jobReadRegistryEID) callDiscard(err error) {
+	w := j.staticQueue.staticWorker()
+	errLaunch := w.renter.tg.Launch(func() {
+		response := &jobReadRegistryEIDResponse{
+			staticErr: errors.Extend(err, ErrJobDiscarded),
+		}
+		select {
+		case j.staticResponseChan <- response:
+		case <-j.staticCtx.Done():
+		case <-w.renter.tg.StopChan():
+		}
+	})
+	if errLaunch != nil {
+		w.renter.log.Debugln("callDiscard: launch failed", err)
+	}
+}
+
+// callExecute will run the ReadRegistryEID job.
+func (j *jobReadRegistryEID) callExecute() {
+	start := time.Now()
+	w := j.staticQueue.staticWorker()
+
+	// Prepare a method to send a response asynchronously.
+	sendResponse := func(spk types.SiaPublicKey, srv *modules.SignedRegistryValue, err error) {
+		errLaunch := w.renter.tg.Launch(func() {
+			response := &jobReadRegistryEIDResponse{
+				staticSignedRegistryValue: srv,
+				staticSiaPublicKey:        spk,
+				staticErr:                 err,
+			}
+			select {
+			case j.staticResponseChan <- response:
+			case <-j.staticCtx.Done():
+			case <-w.renter.tg.StopChan():
+			}
+		})
+		if errLaunch != nil {
+			w.renter.log.Debugln("callExececute: launch failed", err)
+		}
+	}
+
+	// Read the value.
+	spk, srv, err := lookupRegistryEID(w, j.staticRegistryEntryID)
+	if err != nil {
+		sendResponse(types.SiaPublicKey{}, nil, err)
+		j.staticQueue.callReportFailure(err)
+		return
+	}
+
+	// Check if we have a cached version of the looked up entry. If the new entry
+	// has a higher revision number we update it. If it has a lower one we know that
+	// the host should be punished for losing it or trying to cheat us.
+	if srv != nil {
+		cachedRevision, cached := w.staticRegistryCache.Get(spk, srv.Tweak)
+		if cached && cachedRevision > srv.Revision {
+			sendResponse(types.SiaPublicKey{}, nil, errHostLowerRevisionThanCache)
+			j.staticQueue.callReportFailure(errHostLowerRevisionThanCache)
+			w.staticRegistryCache.Set(spk, *srv, true) // adjust the cache
+			return
+		} else if !cached || srv.Revision > cachedRevision {
+			w.staticRegistryCache.Set(spk, *srv, false) // adjust the cache
+		}
+	}
+
+	// Success.
+	jobTime := time.Since(start)
+
+	// Send the response and report success.
+	sendResponse(spk, srv, nil)
+	j.staticQueue.callReportSuccess()
+
+	// Update the performance stats on the queue.
+	jq := j.staticQueue.(*jobReadRegistryEIDQueue)
+	jq.mu.Lock()
+	jq.weightedJobTime = expMovingAvg(jq.weightedJobTime, float64(jobTime), jobReadRegistryPerformanceDecay)
+	jq.mu.Unlock()
+}
+
+// callExpectedBandwidth returns the bandwidth that is expected to be consumed
+// by the job.
+func (j *jobReadRegistryEID) callExpectedBandwidth() (ul, dl uint64) {
+	return readRegistryJobExpectedBandwidth()
+}
+
+// staticSupportsRegistryEID returns whether the worker's host is recent enough
+// to support looking up registry entries by their entry ID.
+func (w *worker) staticSupportsRegistryEID() bool {
+	return build.VersionCmp(w.staticCache().staticHostVersion, minRegistryEIDVersion) >= 0
+}
+
+// initJobReadRegistryEIDQueue will init the queue for the ReadRegistryEID
+// jobs.
+func (w *worker) initJobReadRegistryEIDQueue() {
+	// Sanity check that there is no existing job queue.
+	if w.staticJobReadRegistryEIDQueue != nil {
+		w.renter.log.Critical("incorret call on initJobReadRegistryEIDQueue")
+		return
+	}
+
+	w.staticJobReadRegistryEIDQueue = &jobReadRegistryEIDQueue{
+		jobGenericQueue: newJobGenericQueue(w),
+	}
+}
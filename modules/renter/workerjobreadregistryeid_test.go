@@ -0,0 +1,30 @@
+package renter
+
+import (
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+// TestSupportsRegistryEID makes sure that only hosts which ship the
+// ReadRegistryEID RPC are used to look up registry entries by their ID.
+func TestSupportsRegistryEID(t *testing.T) {
+	tests := []struct {
+		version   string
+		supported bool
+	}{
+		{"1.5.1", false},
+		{"1.5.4", false},
+		{minRegistryEIDVersion, true},
+		{"1.5.6", true},
+	}
+	w := new(worker)
+	for _, test := range tests {
+		atomic.StorePointer(&w.atomicCache, unsafe.Pointer(&workerCache{
+			staticHostVersion: test.version,
+		}))
+		if w.staticSupportsRegistryEID() != test.supported {
+			t.Errorf("host version %v: expected support to be %v", test.version, test.supported)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package renter
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// jobStoreSectorPerformanceDecay defines how much the average performance
+	// is decayed each time a new datapoint is added. The jobs use an
+	// exponential weighted average.
+	jobStoreSectorPerformanceDecay = 0.9
+)
+
+// errStoreSectorWrongRoot is returned if the host returns a different root
+// than the one of the stored sector.
+var errStoreSectorWrongRoot = errors.New("host returned wrong root for stored sector")
+
+type (
+	// jobStoreSector contains information about a StoreSector query.
+	jobStoreSector struct {
+		staticData     []byte
+		staticDuration types.BlockHeight
+		staticRoot     crypto.Hash
+
+		staticResponseChan chan *jobStoreSectorResponse // Channel to send a response down
+
+		*jobGeneric
+	}
+
+	// jobStoreSectorQueue is a list of StoreSector jobs that have been
+	// assigned to the worker.
+	jobStoreSectorQueue struct {
+		// These variables contain an exponential weighted average of the
+		// worker's recent performance for jobStoreSectorQueue.
+		weightedJobTime float64
+
+		*jobGenericQueue
+	}
+
+	// jobStoreSectorResponse contains the result of a StoreSector query.
+	jobStoreSectorResponse struct {
+		staticErr    error
+		staticWorker *worker
+	}
+)
+
+// newJobStoreSector is a helper method to create a new StoreSector job.
+func (w *worker) newJobStoreSector(ctx context.Context, responseChan chan *jobStoreSectorResponse, data []byte, duration types.BlockHeight) *jobStoreSector {
+	return &jobStoreSector{
+		staticData:         data,
+		staticDuration:     duration,
+		staticRoot:         crypto.MerkleRoot(data),
+		staticResponseChan: responseChan,
+		jobGeneric:         newJobGeneric(ctx, w.staticJobStoreSectorQueue, nil),
+	}
+}
+
+// callDiscard will discard a job, sending the provided error.
+func (j *jobStoreSector) callDiscard(err error) {
+	w := j.staticQueue.staticWorker()
+	errLaunch := w.renter.tg.Launch(func() {
+		response := &jobStoreSectorResponse{
+			staticErr:    errors.Extend(err, ErrJobDiscarded),
+			staticWorker: w,
+		}
+		select {
+		case j.staticResponseChan <- response:
+		case <-j.staticCtx.Done():
+		case <-w.renter.tg.StopChan():
+		}
+	})
+	if errLaunch != nil {
+		w.renter.log.Debugln("callDiscard: launch failed", err)
+	}
+}
+
+// callExecute will run the StoreSector job.
+func (j *jobStoreSector) callExecute() {
+	start := time.Now()
+	w := j.staticQueue.staticWorker()
+
+	// Prepare a method to send a response asynchronously.
+	sendResponse := func(err error) {
+		errLaunch := w.renter.tg.Launch(func() {
+			response := &jobStoreSectorResponse{
+				staticErr:    err,
+				staticWorker: w,
+			}
+			select {
+			case j.staticResponseChan <- response:
+			case <-j.staticCtx.Done():
+			case <-w.renter.tg.StopChan():
+			}
+		})
+		if errLaunch != nil {
+			w.renter.log.Debugln("callExececute: launch failed", err)
+		}
+	}
+
+	// Store the sector.
+	err := j.managedStoreSector()
+	if err != nil {
+		sendResponse(err)
+		j.staticQueue.callReportFailure(err)
+		return
+	}
+	jobTime := time.Since(start)
+
+	// Send the response and report success.
+	sendResponse(nil)
+	j.staticQueue.callReportSuccess()
+
+	// Update the performance stats on the queue.
+	jq := j.staticQueue.(*jobStoreSectorQueue)
+	jq.mu.Lock()
+	jq.weightedJobTime = expMovingAvg(jq.weightedJobTime, float64(jobTime), jobStoreSectorPerformanceDecay)
+	jq.mu.Unlock()
+}
+
+// callExpectedBandwidth returns the bandwidth that is expected to be consumed
+// by the job.
+func (j *jobStoreSector) callExpectedBandwidth() (ul, dl uint64) {
+	return storeSectorJobExpectedBandwidth()
+}
+
+// managedStoreSector stores the job's sector on the host for the job's
+// duration. The sector is paid for from the worker's ephemeral account.
+func (j *jobStoreSector) managedStoreSector() error {
+	w := j.staticQueue.staticWorker()
+	// Check the duration against the host's limit.
+	pt := w.staticPriceTable().staticPriceTable
+	if j.staticDuration > pt.EphemeralStorageMaxDuration {
+		return errors.New("duration exceeds the host's max ephemeral storage duration")
+	}
+
+	// Create the program.
+	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since StoreSector doesn't depend on it.
+	err := pb.AddStoreSectorInstruction(j.staticData, j.staticDuration)
+	if err != nil {
+		return errors.AddContext(err, "Unable to add StoreSector instruction")
+	}
+	program, programData := pb.Program()
+	cost, _, _ := pb.Cost(true)
+
+	// take into account bandwidth costs
+	ulBandwidth, dlBandwidth := j.callExpectedBandwidth()
+	bandwidthCost := modules.MDMBandwidthCost(pt, ulBandwidth, dlBandwidth)
+	cost = cost.Add(bandwidthCost)
+
+	// Execute the program and parse the responses.
+	responses, _, err := w.managedExecuteProgram(program, programData, types.FileContractID{}, cost)
+	if err != nil {
+		return errors.AddContext(err, "Unable to execute program")
+	}
+	if len(responses) != len(program) {
+		return errors.New("received invalid number of responses but no error")
+	}
+	resp := responses[0]
+	if resp.Error != nil {
+		return errors.AddContext(resp.Error, "Output error")
+	}
+
+	// Verify the returned root.
+	var root crypto.Hash
+	if len(resp.Output) != len(root) {
+		return errStoreSectorWrongRoot
+	}
+	copy(root[:], resp.Output)
+	if root != j.staticRoot {
+		return errStoreSectorWrongRoot
+	}
+	return nil
+}
+
+// initJobStoreSectorQueue will init the queue for the StoreSector jobs.
+func (w *worker) initJobStoreSectorQueue() {
+	// Sanity check that there is no existing job queue.
+	if w.staticJobStoreSectorQueue != nil {
+		w.renter.log.Critical("incorret call on initJobStoreSectorQueue")
+		return
+	}
+
+	w.staticJobStoreSectorQueue = &jobStoreSectorQueue{
+		jobGenericQueue: newJobGenericQueue(w),
+	}
+}
+
+// StoreSector is a helper method to run a StoreSector job on a worker.
+func (w *worker) StoreSector(ctx context.Context, data []byte, duration types.BlockHeight) error {
+	storeSectorRespChan := make(chan *jobStoreSectorResponse)
+	jss := w.newJobStoreSector(ctx, storeSectorRespChan, data, duration)
+
+	// Add the job to the queue.
+	if !w.staticJobStoreSectorQueue.callAdd(jss) {
+		return errors.New("worker unavailable")
+	}
+
+	// Wait for the response.
+	var resp *jobStoreSectorResponse
+	select {
+	case <-ctx.Done():
+		return errors.New("StoreSector interrupted")
+	case resp = <-storeSectorRespChan:
+	}
+	return resp.staticErr
+}
+
+// storeSectorJobExpectedBandwidth is a helper function that returns the
+// expected bandwidth consumption of a StoreSector job. This helper function
+// enables getting at the expected bandwidth without having to instantiate a
+// job.
+func storeSectorJobExpectedBandwidth() (ul, dl uint64) {
+	ul = modules.SectorSize + 2*ethernetMTU // the sector plus the program
+	dl = ethernetMTU                        // the returned root
+	return
+}
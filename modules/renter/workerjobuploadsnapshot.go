@@ -268,9 +268,12 @@ func (r *Renter) managedUploadSnapshotHost(meta modules.UploadedBackup, dotSia [
 	shouldOverwrite := len(entryTable) != 0 // only overwrite if the sector already contained an entryTable
 	entryTable = append(entryTable, entry)
 
+	// drop the entries of deleted snapshots
+	id := r.mu.Lock()
+	entryTable = filterSnapshotEntries(entryTable, r.deletedBackupUIDs())
+
 	// if entryTable is too large to fit in a sector, repeatedly remove the
 	// oldest entry until it fits
-	id := r.mu.Lock()
 	sort.Slice(r.persist.UploadedBackups, func(i, j int) bool {
 		return r.persist.UploadedBackups[i].CreationDate > r.persist.UploadedBackups[j].CreationDate
 	})
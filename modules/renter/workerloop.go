@@ -129,6 +129,11 @@ func (w *worker) externTryLaunchSerialJob() {
 		w.externLaunchSerialJob(job.callExecute)
 		return
 	}
+	job = w.staticJobDeleteSnapshotsQueue.callNext()
+	if job != nil {
+		w.externLaunchSerialJob(job.callExecute)
+		return
+	}
 	job = w.staticJobUploadSnapshotQueue.callNext()
 	if job != nil {
 		w.externLaunchSerialJob(job.callExecute)
@@ -258,6 +263,20 @@ func (w *worker) externTryLaunchAsyncJob() bool {
 			return true
 		}
 	}
+	if w.staticSupportsRegistryEID() {
+		job = w.staticJobReadRegistryEIDQueue.callNext()
+		if job != nil {
+			w.externLaunchAsyncJob(job)
+			return true
+		}
+	}
+	if build.VersionCmp(cache.staticHostVersion, minEphemeralStorageVersion) >= 0 {
+		job = w.staticJobStoreSectorQueue.callNext()
+		if job != nil {
+			w.externLaunchAsyncJob(job)
+			return true
+		}
+	}
 	job = w.staticJobReadQueue.callNext()
 	if job != nil {
 		w.externLaunchAsyncJob(job)
@@ -290,6 +309,8 @@ func (w *worker) managedBlockUntilReady() bool {
 func (w *worker) managedDiscardAsyncJobs(err error) {
 	w.staticJobHasSectorQueue.callDiscardAll(err)
 	w.staticJobUpdateRegistryQueue.callDiscardAll(err)
+	w.staticJobReadRegistryEIDQueue.callDiscardAll(err)
+	w.staticJobStoreSectorQueue.callDiscardAll(err)
 	w.staticJobReadQueue.callDiscardAll(err)
 }
 
@@ -309,9 +330,12 @@ func (w *worker) threadedWorkLoop() {
 	defer w.managedKillDownloading()
 	defer w.staticJobHasSectorQueue.callKill()
 	defer w.staticJobUpdateRegistryQueue.callKill()
+	defer w.staticJobReadRegistryEIDQueue.callKill()
+	defer w.staticJobStoreSectorQueue.callKill()
 	defer w.staticJobReadQueue.callKill()
 	defer w.staticJobDownloadSnapshotQueue.callKill()
 	defer w.staticJobUploadSnapshotQueue.callKill()
+	defer w.staticJobDeleteSnapshotsQueue.callKill()
 
 	if build.VersionCmp(w.staticCache().staticHostVersion, minAsyncVersion) >= 0 {
 		// Ensure the renter's revision number of the underlying file contract
@@ -341,6 +365,13 @@ func (w *worker) threadedWorkLoop() {
 		}
 	}
 
+	// Launch the registry subscription loop. It runs independently of the
+	// work loop since it keeps a long-lived session open with the host.
+	err := w.renter.tg.Launch(w.threadedSubscriptionLoop)
+	if err != nil {
+		return
+	}
+
 	// The worker will continuously perform jobs in a loop.
 	for {
 		// There are certain conditions under which the worker should either
@@ -122,12 +122,20 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 	}
 
 	// read the responses.
-	responses = make([]programResponse, 0, len(epr.Program))
-	for i := 0; i < len(epr.Program); i++ {
+	responses, err = readProgramResponses(stream, len(epr.Program))
+	return
+}
+
+// readProgramResponses reads the responses of a program with the given number
+// of instructions from the stream. It stops reading after the first response
+// that contains an error.
+func readProgramResponses(stream io.Reader, numInstructions int) ([]programResponse, error) {
+	responses := make([]programResponse, 0, numInstructions)
+	for i := 0; i < numInstructions; i++ {
 		var response programResponse
-		err = modules.RPCRead(stream, &response)
+		err := modules.RPCRead(stream, &response)
 		if err != nil {
-			return
+			return responses, err
 		}
 
 		// Read the output data.
@@ -135,7 +143,7 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 		response.Output = make([]byte, outputLen)
 		_, err = io.ReadFull(stream, response.Output)
 		if err != nil {
-			return
+			return responses, err
 		}
 
 		// We received a valid response. Append it.
@@ -146,7 +154,7 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 			break
 		}
 	}
-	return
+	return responses, nil
 }
 
 // staticNewStream returns a new stream to the worker's host
@@ -0,0 +1,509 @@
+package renter
+
+// workersubscription.go contains the worker's side of the registry
+// subscription protocol. Every worker runs a subscription loop which opens a
+// long-lived RPCRegistrySubscription session with its host whenever the
+// renter's registrySubscriptionManager tracks at least one entry. Within the
+// session, the worker keeps the set of entries it is subscribed to in sync with
+// the manager, extends the session before it expires, prepays notifications
+// and forwards all verified notifications to the manager.
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// minSubscriptionVersion defines the minimum version that is required for
+	// a host to support registry subscriptions.
+	minSubscriptionVersion = "1.5.4"
+
+	// maxSubscriptionRequestBatch is the maximum number of entries the worker
+	// subscribes to or unsubscribes from within a single request. It matches
+	// the host's limit.
+	maxSubscriptionRequestBatch = 10000
+
+	// minSubscriptionNotificationsLeft is the number of prepaid notifications
+	// below which the worker will prepay more notifications.
+	minSubscriptionNotificationsLeft = modules.InitialNumNotifications / 2
+)
+
+var (
+	// subscriptionCooldown is the amount of time a worker waits before
+	// trying to open a new subscription session after the previous one
+	// failed.
+	subscriptionCooldown = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// subscriptionExtensionWindow is the amount of time before the end of the
+	// subscription period at which the worker extends the subscription.
+	subscriptionExtensionWindow = modules.SubscriptionPeriod / 2
+)
+
+type (
+	// subscriptionInfos contains the state of the worker's subscription
+	// session with its host.
+	subscriptionInfos struct {
+		// subscriptions contains the entries that the worker is subscribed to
+		// on the host within the current session.
+		subscriptions map[crypto.Hash]*workerSubscription
+
+		// notificationsLeft is the worker's estimate of the number of
+		// prepaid notifications left within the current session.
+		notificationsLeft uint64
+
+		staticWakeChan chan struct{}
+		mu             sync.Mutex
+	}
+
+	// workerSubscription is a single entry that the worker is subscribed to.
+	workerSubscription struct {
+		staticRequest modules.RPCRegistrySubscriptionRequest
+
+		// latestRevision is the revision number of the latest value received
+		// for the entry. It is only valid if 'received' is true.
+		latestRevision uint64
+		received       bool
+	}
+)
+
+// initSubscriptionInfos initializes the worker's subscription state.
+func (w *worker) initSubscriptionInfos() {
+	w.staticSubscriptionInfo = &subscriptionInfos{
+		subscriptions:  make(map[crypto.Hash]*workerSubscription),
+		staticWakeChan: make(chan struct{}, 1),
+	}
+}
+
+// staticWake wakes the worker's subscription loop.
+func (si *subscriptionInfos) staticWake() {
+	select {
+	case si.staticWakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// managedReset clears the subscriptions and sets the prepaid notifications at
+// the beginning and end of a session.
+func (si *subscriptionInfos) managedReset(notificationsLeft uint64) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	si.subscriptions = make(map[crypto.Hash]*workerSubscription)
+	si.notificationsLeft = notificationsLeft
+}
+
+// managedDiff compares the worker's subscriptions to the given desired set of
+// subscriptions and returns the entries to subscribe to and to unsubscribe
+// from.
+func (si *subscriptionInfos) managedDiff(desired map[crypto.Hash]modules.RPCRegistrySubscriptionRequest) (toSubscribe, toUnsubscribe map[crypto.Hash]modules.RPCRegistrySubscriptionRequest) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	toSubscribe = make(map[crypto.Hash]modules.RPCRegistrySubscriptionRequest)
+	toUnsubscribe = make(map[crypto.Hash]modules.RPCRegistrySubscriptionRequest)
+	for eid, req := range desired {
+		if _, exists := si.subscriptions[eid]; !exists {
+			toSubscribe[eid] = req
+		}
+	}
+	for eid, sub := range si.subscriptions {
+		if _, exists := desired[eid]; !exists {
+			toUnsubscribe[eid] = sub.staticRequest
+		}
+	}
+	return
+}
+
+// managedHandleNotification verifies a registry value received from the host
+// and returns the public key of the entry it belongs to. The returned bool is
+// false if the value doesn't belong to any of the worker's subscriptions or if
+// it is outdated.
+func (si *subscriptionInfos) managedHandleNotification(srv modules.SignedRegistryValue) (types.SiaPublicKey, bool) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	// Every notification uses up a prepaid notification on the host.
+	if si.notificationsLeft > 0 {
+		si.notificationsLeft--
+	}
+
+	// Notifications only contain the tweak of the entry so we need to find
+	// the subscription with a public key that the value was signed with.
+	for _, sub := range si.subscriptions {
+		if sub.staticRequest.Tweak != srv.Tweak {
+			continue
+		}
+		if srv.Verify(sub.staticRequest.PubKey.ToPublicKey()) != nil {
+			continue
+		}
+		if sub.received && srv.Revision <= sub.latestRevision {
+			return types.SiaPublicKey{}, false
+		}
+		sub.latestRevision = srv.Revision
+		sub.received = true
+		return sub.staticRequest.PubKey, true
+	}
+	return types.SiaPublicKey{}, false
+}
+
+// managedNotificationsLeft returns the estimated number of prepaid
+// notifications left.
+func (si *subscriptionInfos) managedNotificationsLeft() uint64 {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	return si.notificationsLeft
+}
+
+// threadedSubscriptionLoop is a perpetual loop run by the worker that opens a
+// subscription session with the host whenever the renter is subscribed to at
+// least one registry entry.
+func (w *worker) threadedSubscriptionLoop() {
+	// Only hosts that support subscriptions are of interest.
+	if build.VersionCmp(w.staticCache().staticHostVersion, minSubscriptionVersion) < 0 {
+		return
+	}
+
+	rsm := w.renter.staticRegistrySubscriptions
+	for {
+		// Wait until there is something to subscribe to.
+		for rsm.callNumSubscriptions() == 0 {
+			select {
+			case <-w.staticSubscriptionInfo.staticWakeChan:
+			case <-w.killChan:
+				return
+			case <-w.renter.tg.StopChan():
+				return
+			}
+		}
+
+		// Run a session. If it fails, wait for a cooldown before trying
+		// again.
+		err := w.managedSubscriptionSession()
+		if w.staticKilled() {
+			return
+		}
+		if err == nil {
+			continue
+		}
+		w.renter.log.Debugf("worker %v: subscription session failed: %v", w.staticHostPubKeyStr, err)
+		select {
+		case <-time.After(subscriptionCooldown):
+		case <-w.killChan:
+			return
+		case <-w.renter.tg.StopChan():
+			return
+		}
+	}
+}
+
+// managedSubscriptionSession opens a subscription session with the host and
+// keeps it alive for as long as the renter is subscribed to at least one
+// entry.
+func (w *worker) managedSubscriptionSession() (err error) {
+	// The session can't be paid for without a valid price table.
+	if !w.staticPriceTable().staticValid() {
+		return errors.New("price table is not valid")
+	}
+	pt := w.staticPriceTable().staticPriceTable
+
+	// Create a stream.
+	stream, err := w.staticNewStream()
+	if err != nil {
+		return errors.AddContext(err, "unable to create a new stream")
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			w.renter.log.Println("ERROR: failed to close stream", err)
+		}
+	}()
+
+	// The host controls the lifetime of the session using the subscription
+	// period so the default deadline of the stream is removed.
+	err = stream.SetDeadline(time.Time{})
+	if err != nil {
+		return errors.AddContext(err, "failed to reset stream deadline")
+	}
+
+	// Begin the session.
+	cost := pt.SubscriptionBaseCost.Add(modules.SubscriptionNotificationsCost(&pt, modules.InitialNumNotifications))
+	err = w.managedBeginSubscription(stream, pt, cost)
+	if err != nil {
+		return errors.AddContext(err, "failed to begin subscription")
+	}
+	deadline := time.Now().Add(modules.SubscriptionPeriod)
+	w.staticSubscriptionInfo.managedReset(modules.InitialNumNotifications)
+	defer w.staticSubscriptionInfo.managedReset(0)
+
+	// Start listening for notifications.
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- w.managedListenForNotifications(stream)
+	}()
+
+	extendTimer := time.NewTimer(time.Until(deadline) - subscriptionExtensionWindow)
+	defer extendTimer.Stop()
+	for {
+		// Sync the subscriptions with the manager. If there is nothing left
+		// to subscribe to, the session is closed which causes the host to
+		// refund the unused notifications.
+		desired := w.renter.staticRegistrySubscriptions.callSubscriptions()
+		if len(desired) == 0 {
+			return nil
+		}
+		toSubscribe, toUnsubscribe := w.staticSubscriptionInfo.managedDiff(desired)
+		if len(toUnsubscribe) > 0 {
+			err = w.managedUnsubscribe(stream, toUnsubscribe)
+			if err != nil {
+				return errors.AddContext(err, "failed to unsubscribe")
+			}
+		}
+		if len(toSubscribe) > 0 {
+			err = w.managedSubscribe(stream, toSubscribe)
+			if err != nil {
+				return errors.AddContext(err, "failed to subscribe")
+			}
+		}
+
+		// Prepay notifications if necessary.
+		if w.staticSubscriptionInfo.managedNotificationsLeft() < minSubscriptionNotificationsLeft {
+			err = w.managedPrepayNotifications(stream, modules.InitialNumNotifications)
+			if err != nil {
+				return errors.AddContext(err, "failed to prepay notifications")
+			}
+		}
+
+		select {
+		case <-w.staticSubscriptionInfo.staticWakeChan:
+		case <-extendTimer.C:
+			err = w.managedExtendSubscription(stream)
+			if err != nil {
+				return errors.AddContext(err, "failed to extend subscription")
+			}
+			deadline = deadline.Add(modules.SubscriptionPeriod)
+			extendTimer.Reset(time.Until(deadline) - subscriptionExtensionWindow)
+		case err = <-errChan:
+			return errors.AddContext(err, "failed to receive notification")
+		case <-w.killChan:
+			return nil
+		case <-w.renter.tg.StopChan():
+			return nil
+		}
+	}
+}
+
+// managedBeginSubscription starts the RPCRegistrySubscription on the stream
+// and pays for the initial notifications.
+func (w *worker) managedBeginSubscription(stream io.Writer, pt modules.RPCPriceTable, cost types.Currency) (err error) {
+	// track the withdrawal
+	w.staticAccount.managedTrackWithdrawal(cost)
+	defer func() {
+		w.staticAccount.managedCommitWithdrawal(cost, err == nil)
+	}()
+
+	// prepare a buffer so we can optimize our writes
+	buffer := bytes.NewBuffer(nil)
+
+	// write the specifier
+	err = modules.RPCWrite(buffer, modules.RPCRegistrySubscription)
+	if err != nil {
+		return err
+	}
+
+	// send price table uid
+	err = modules.RPCWrite(buffer, pt.UID)
+	if err != nil {
+		return err
+	}
+
+	// provide payment
+	err = w.staticAccount.ProvidePayment(buffer, w.staticHostPubKey, modules.RPCRegistrySubscription, cost, w.staticAccount.staticID, w.staticCache().staticBlockHeight)
+	if err != nil {
+		return err
+	}
+
+	// write contents of the buffer to the stream
+	_, err = buffer.WriteTo(stream)
+	return err
+}
+
+// managedWriteSubscriptionRequest writes a request of the given type to the
+// stream, pays for it and appends the provided objects.
+func (w *worker) managedWriteSubscriptionRequest(stream io.Writer, requestType uint8, pt modules.RPCPriceTable, cost types.Currency, objs ...interface{}) (err error) {
+	// track the withdrawal
+	w.staticAccount.managedTrackWithdrawal(cost)
+	defer func() {
+		w.staticAccount.managedCommitWithdrawal(cost, err == nil)
+	}()
+
+	// prepare a buffer so we can optimize our writes
+	buffer := bytes.NewBuffer(nil)
+
+	// write the request type
+	err = modules.RPCWrite(buffer, requestType)
+	if err != nil {
+		return err
+	}
+
+	// send price table uid
+	err = modules.RPCWrite(buffer, pt.UID)
+	if err != nil {
+		return err
+	}
+
+	// provide payment
+	err = w.staticAccount.ProvidePayment(buffer, w.staticHostPubKey, modules.RPCRegistrySubscription, cost, w.staticAccount.staticID, w.staticCache().staticBlockHeight)
+	if err != nil {
+		return err
+	}
+
+	// write the request's payload
+	for _, obj := range objs {
+		err = modules.RPCWrite(buffer, obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	// write contents of the buffer to the stream
+	_, err = buffer.WriteTo(stream)
+	return err
+}
+
+// managedSubscribe subscribes to the given entries on the host.
+func (w *worker) managedSubscribe(stream io.Writer, requests map[crypto.Hash]modules.RPCRegistrySubscriptionRequest) error {
+	for _, batch := range batchSubscriptionRequests(requests) {
+		// Add the subscriptions before sending the request since the host
+		// will respond with the initial values right away.
+		si := w.staticSubscriptionInfo
+		si.mu.Lock()
+		for eid, req := range batch {
+			si.subscriptions[eid] = &workerSubscription{staticRequest: req}
+		}
+		si.mu.Unlock()
+
+		pt := w.staticPriceTable().staticPriceTable
+		n := uint64(len(batch))
+		cost := pt.SubscriptionBaseCost.Mul64(n)
+		cost = cost.Add(modules.SubscriptionMemoryCost(&pt, n))
+		cost = cost.Add(modules.SubscriptionNotificationsCost(&pt, n))
+		err := w.managedWriteSubscriptionRequest(stream, modules.SubscriptionRequestSubscribe, pt, cost, batchRequestObjects(batch)...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// managedUnsubscribe unsubscribes from the given entries on the host.
+func (w *worker) managedUnsubscribe(stream io.Writer, requests map[crypto.Hash]modules.RPCRegistrySubscriptionRequest) error {
+	for _, batch := range batchSubscriptionRequests(requests) {
+		pt := w.staticPriceTable().staticPriceTable
+		cost := pt.SubscriptionBaseCost.Mul64(uint64(len(batch)))
+		err := w.managedWriteSubscriptionRequest(stream, modules.SubscriptionRequestUnsubscribe, pt, cost, batchRequestObjects(batch)...)
+		if err != nil {
+			return err
+		}
+
+		// Remove the subscriptions after the request was sent. Notifications
+		// for these entries that are still in flight are ignored.
+		si := w.staticSubscriptionInfo
+		si.mu.Lock()
+		for eid := range batch {
+			delete(si.subscriptions, eid)
+		}
+		si.mu.Unlock()
+	}
+	return nil
+}
+
+// managedExtendSubscription extends the subscription session by another
+// subscription period.
+func (w *worker) managedExtendSubscription(stream io.Writer) error {
+	si := w.staticSubscriptionInfo
+	si.mu.Lock()
+	numSubs := uint64(len(si.subscriptions))
+	si.mu.Unlock()
+
+	pt := w.staticPriceTable().staticPriceTable
+	cost := pt.SubscriptionBaseCost.Add(modules.SubscriptionMemoryCost(&pt, numSubs))
+	return w.managedWriteSubscriptionRequest(stream, modules.SubscriptionRequestExtend, pt, cost)
+}
+
+// managedPrepayNotifications pays the host for numNotifications more
+// notifications.
+func (w *worker) managedPrepayNotifications(stream io.Writer, numNotifications uint64) error {
+	pt := w.staticPriceTable().staticPriceTable
+	cost := pt.SubscriptionBaseCost.Add(modules.SubscriptionNotificationsCost(&pt, numNotifications))
+	err := w.managedWriteSubscriptionRequest(stream, modules.SubscriptionRequestPrepay, pt, cost, numNotifications)
+	if err != nil {
+		return err
+	}
+	si := w.staticSubscriptionInfo
+	si.mu.Lock()
+	si.notificationsLeft += numNotifications
+	si.mu.Unlock()
+	return nil
+}
+
+// managedListenForNotifications reads notifications from the stream until
+// reading fails and forwards the valid ones to the renter's subscription
+// manager.
+func (w *worker) managedListenForNotifications(stream io.Reader) error {
+	for {
+		var notification modules.RPCRegistrySubscriptionNotification
+		err := modules.RPCRead(stream, &notification)
+		if err != nil {
+			return err
+		}
+		if notification.Type != modules.SubscriptionResponseRegistryValue {
+			return errors.New("received notification of unknown type")
+		}
+		srv := notification.Entry
+		spk, ok := w.staticSubscriptionInfo.managedHandleNotification(srv)
+		if !ok {
+			continue
+		}
+		w.staticRegistryCache.Set(spk, srv, false)
+		w.renter.staticRegistrySubscriptions.managedNotify(spk, srv)
+	}
+}
+
+// batchSubscriptionRequests splits the requests into batches of at most
+// maxSubscriptionRequestBatch requests.
+func batchSubscriptionRequests(requests map[crypto.Hash]modules.RPCRegistrySubscriptionRequest) []map[crypto.Hash]modules.RPCRegistrySubscriptionRequest {
+	var batches []map[crypto.Hash]modules.RPCRegistrySubscriptionRequest
+	batch := make(map[crypto.Hash]modules.RPCRegistrySubscriptionRequest)
+	for eid, req := range requests {
+		if len(batch) == maxSubscriptionRequestBatch {
+			batches = append(batches, batch)
+			batch = make(map[crypto.Hash]modules.RPCRegistrySubscriptionRequest)
+		}
+		batch[eid] = req
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// batchRequestObjects turns a batch of requests into the objects that need to
+// be written to the stream. That's the number of requests followed by the
+// requests themselves.
+func batchRequestObjects(batch map[crypto.Hash]modules.RPCRegistrySubscriptionRequest) []interface{} {
+	objs := make([]interface{}, 0, len(batch)+1)
+	objs = append(objs, uint64(len(batch)))
+	for _, req := range batch {
+		objs = append(objs, req)
+	}
+	return objs
+}
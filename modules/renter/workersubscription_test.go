@@ -0,0 +1,101 @@
+package renter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestWorkerSubscription tests that a worker subscribes to the renter's
+// registry subscriptions on its host and forwards the notifications.
+func TestWorkerSubscription(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a registry value and set it on the host.
+	sk, pk := crypto.GenerateKeyPair()
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	rv := modules.NewRegistryValue(tweak, fastrand.Bytes(modules.RegistryDataSize), fastrand.Uint64n(1000)).Sign(sk)
+	err = wt.UpdateRegistry(context.Background(), spk, rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Subscribe to the entry.
+	notifications := make(chan modules.SignedRegistryValue, 10)
+	sub, err := wt.rt.renter.NewRegistrySubscriber(func(_ types.SiaPublicKey, srv modules.SignedRegistryValue) {
+		notifications <- srv
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sub.Subscribe(spk, tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Helper to wait for a notification.
+	expectNotification := func(expected modules.SignedRegistryValue) {
+		t.Helper()
+		select {
+		case srv := <-notifications:
+			if !reflect.DeepEqual(srv, expected) {
+				t.Fatal("wrong notification", srv, expected)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("no notification received")
+		}
+	}
+
+	// The initial value should be received.
+	expectNotification(rv)
+
+	// Update the entry. The update should be received.
+	rv.Revision++
+	rv = rv.Sign(sk)
+	err = wt.UpdateRegistry(context.Background(), spk, rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectNotification(rv)
+
+	// Close the subscriber. The worker should end the session.
+	sub.Close()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		si := wt.staticSubscriptionInfo
+		si.mu.Lock()
+		defer si.mu.Unlock()
+		if len(si.subscriptions) != 0 {
+			return fmt.Errorf("expected %v subscriptions but got %v", 0, len(si.subscriptions))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
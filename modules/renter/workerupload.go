@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/modules/renter/filesystem/siafile"
 	"gitlab.com/NebulousLabs/errors"
@@ -151,14 +152,17 @@ func (w *worker) managedHasUploadJob() bool {
 	return len(w.unprocessedChunks) > 0
 }
 
-// managedPerformUploadChunkJob will perform some upload work.
-func (w *worker) managedPerformUploadChunkJob() {
+// managedNextUploadPiece pops the next chunk from the worker's queue and
+// selects a piece of that chunk for the worker to upload. If a piece was
+// selected, the chunk's cancelWG was incremented and the caller is responsible
+// for calling Done on it once the upload is over.
+func (w *worker) managedNextUploadPiece() (*unfinishedUploadChunk, uint64) {
 	// Fetch any available chunk for uploading. If no chunk is found, return
-	// false.
+	// nil.
 	w.mu.Lock()
 	if len(w.unprocessedChunks) == 0 {
 		w.mu.Unlock()
-		return
+		return nil, 0
 	}
 	nextChunk := w.unprocessedChunks[0]
 	w.unprocessedChunks = w.unprocessedChunks[1:]
@@ -171,22 +175,38 @@ func (w *worker) managedPerformUploadChunkJob() {
 		// If the chunk was canceled then we drop the chunk. This will decrement the
 		// chunk's remainingWorkers and perform any clean up work necessary
 		w.managedDropChunk(nextChunk)
-		return
+		return nil, 0
 	}
-	// Add this worker to the chunk's cancelWG for the duration of this method.
+	// Add this worker to the chunk's cancelWG for the duration of the upload.
 	nextChunk.cancelWG.Add(1)
-	defer nextChunk.cancelWG.Done()
 	nextChunk.cancelMU.Unlock()
 
-	// Check if this particular chunk is necessary. If not, return 'true'
-	// because there may be more chunks in the queue.
+	// Check if this particular chunk is necessary.
 	uc, pieceIndex := w.managedProcessUploadChunk(nextChunk)
 	if uc == nil {
 		nextChunk.mu.Lock()
 		nextChunk.chunkFailedProcessTimes = append(nextChunk.chunkFailedProcessTimes, time.Now())
 		nextChunk.mu.Unlock()
+		nextChunk.cancelWG.Done()
+		return nil, 0
+	}
+	return uc, pieceIndex
+}
+
+// managedPerformUploadChunkJob will perform some upload work.
+func (w *worker) managedPerformUploadChunkJob() {
+	uc, pieceIndex := w.managedNextUploadPiece()
+	if uc == nil {
 		return
 	}
+
+	// Use the MDM to upload the piece if the host supports it.
+	if w.staticSupportsUploadAppend() {
+		w.managedPerformUploadAppendJob(uploadPiece{staticChunk: uc, staticPieceIndex: pieceIndex})
+		return
+	}
+	defer uc.cancelWG.Done()
+
 	// Open an editing connection to the host.
 	e, err := w.renter.hostContractor.Editor(w.staticHostPubKey, w.renter.tg.StopChan())
 	if err != nil {
@@ -225,8 +245,15 @@ func (w *worker) managedPerformUploadChunkJob() {
 	w.uploadConsecutiveFailures = 0
 	w.mu.Unlock()
 
+	// Register the uploaded piece.
+	w.managedUploadSucceeded(uc, pieceIndex, root)
+}
+
+// managedUploadSucceeded adds an uploaded piece to the chunk's file and
+// updates the state of the chunk accordingly.
+func (w *worker) managedUploadSucceeded(uc *unfinishedUploadChunk, pieceIndex uint64, root crypto.Hash) {
 	// Add piece to renterFile
-	err = uc.fileEntry.AddPiece(w.staticHostPubKey, uc.staticIndex, pieceIndex, root)
+	err := uc.fileEntry.AddPiece(w.staticHostPubKey, uc.staticIndex, pieceIndex, root)
 	if err != nil {
 		failureErr := fmt.Errorf("Worker failed to add new piece to SiaFile: %v", err)
 		w.renter.log.Debugln(failureErr)
@@ -325,6 +352,13 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 // managedUploadFailed is called if a worker failed to upload part of an unfinished
 // chunk.
 func (w *worker) managedUploadFailed(uc *unfinishedUploadChunk, pieceIndex uint64, failureErr error) {
+	w.managedUploadPiecesFailed([]uploadPiece{{staticChunk: uc, staticPieceIndex: pieceIndex}}, failureErr)
+}
+
+// managedUploadPiecesFailed is called if a worker failed to upload one or more
+// pieces of unfinished chunks at once. The failure only counts once towards the
+// worker's cooldown.
+func (w *worker) managedUploadPiecesFailed(pieces []uploadPiece, failureErr error) {
 	// Mark the failure in the worker if the gateway says we are online. It's
 	// not the worker's fault if we are offline.
 	if w.renter.g.Online() && !(strings.Contains(failureErr.Error(), siafile.ErrDeleted.Error()) || errors.Contains(failureErr, siafile.ErrDeleted)) {
@@ -334,19 +368,25 @@ func (w *worker) managedUploadFailed(uc *unfinishedUploadChunk, pieceIndex uint6
 		w.uploadConsecutiveFailures++
 		failures := w.uploadConsecutiveFailures
 		w.mu.Unlock()
-		w.renter.repairLog.Debugf("Worker upload failed. Worker: %v, Consecutive Failures: %v, Chunk: %v of %s, Error: %v", w.staticHostPubKey, failures, uc.staticIndex, uc.staticSiaPath, failureErr)
+		for _, piece := range pieces {
+			uc := piece.staticChunk
+			w.renter.repairLog.Debugf("Worker upload failed. Worker: %v, Consecutive Failures: %v, Chunk: %v of %s, Error: %v", w.staticHostPubKey, failures, uc.staticIndex, uc.staticSiaPath, failureErr)
+		}
 	}
 
-	// Unregister the piece from the chunk and hunt for a replacement.
-	uc.mu.Lock()
-	uc.piecesRegistered--
-	uc.pieceUsage[pieceIndex] = false
-	uc.chunkFailedProcessTimes = append(uc.chunkFailedProcessTimes, time.Now())
-	uc.mu.Unlock()
+	for _, piece := range pieces {
+		// Unregister the piece from the chunk and hunt for a replacement.
+		uc := piece.staticChunk
+		uc.mu.Lock()
+		uc.piecesRegistered--
+		uc.pieceUsage[piece.staticPieceIndex] = false
+		uc.chunkFailedProcessTimes = append(uc.chunkFailedProcessTimes, time.Now())
+		uc.mu.Unlock()
 
-	// Notify the standby workers of the chunk
-	uc.managedNotifyStandbyWorkers()
-	w.renter.managedCleanUpUploadChunk(uc)
+		// Notify the standby workers of the chunk
+		uc.managedNotifyStandbyWorkers()
+		w.renter.managedCleanUpUploadChunk(uc)
+	}
 
 	// Because the worker is now on cooldown, drop all remaining chunks.
 	w.managedDropUploadChunks()
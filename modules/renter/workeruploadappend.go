@@ -0,0 +1,265 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// maxUploadAppendSectors is the maximum number of sectors a worker appends
+	// to its host within a single program. Batching sectors saves a round trip
+	// and a contract revision per sector.
+	maxUploadAppendSectors = build.Select(build.Var{
+		Standard: 4,
+		Dev:      4,
+		Testing:  2,
+	}).(int)
+)
+
+// uploadPiece is a piece of an unfinished upload chunk which a worker
+// registered for uploading.
+type uploadPiece struct {
+	staticChunk      *unfinishedUploadChunk
+	staticPieceIndex uint64
+}
+
+// checkUploadAppendGouging looks at the current renter allowance and the
+// worker's price table and determines whether appending sectors to the host
+// should be halted due to price gouging.
+func checkUploadAppendGouging(allowance modules.Allowance, pt modules.RPCPriceTable) error {
+	// Check whether the storage price is too high.
+	if !allowance.MaxStoragePrice.IsZero() && allowance.MaxStoragePrice.Cmp(pt.WriteStoreCost) < 0 {
+		return fmt.Errorf("storage price of host is %v, which is above the maximum allowed by the allowance: %v", pt.WriteStoreCost, allowance.MaxStoragePrice)
+	}
+	// Check whether the upload bandwidth price is too high.
+	if !allowance.MaxUploadBandwidthPrice.IsZero() && allowance.MaxUploadBandwidthPrice.Cmp(pt.UploadBandwidthCost) < 0 {
+		return fmt.Errorf("upload bandwidth price of host is %v, which is above the maximum allowed by the allowance: %v", pt.UploadBandwidthCost, allowance.MaxUploadBandwidthPrice)
+	}
+
+	// If there is no allowance, general price gouging checks have to be
+	// disabled, because there is no baseline for understanding what might count
+	// as price gouging.
+	if allowance.Funds.IsZero() {
+		return nil
+	}
+
+	// Check that the combined prices make sense in the context of the overall
+	// allowance. This is the same check as in checkUploadGouging, but based on
+	// the cost of appending a single sector for the whole period.
+	appendCost, _ := modules.MDMAppendCost(&pt, allowance.Period)
+	programCost := modules.MDMInitCost(&pt, modules.SectorSize, 1).Add(appendCost)
+	ulbw, dlbw := uploadAppendJobExpectedBandwidth(1)
+	singleUploadCost := programCost.Add(modules.MDMBandwidthCost(pt, ulbw, dlbw))
+	fullCostPerByte := singleUploadCost.Div64(modules.SectorSize)
+	allowanceStorageCost := fullCostPerByte.Mul64(allowance.ExpectedStorage)
+	reducedCost := allowanceStorageCost.Div64(uploadGougingFractionDenom)
+	if reducedCost.Cmp(allowance.Funds) > 0 {
+		return fmt.Errorf("combined upload pricing of host yields %v, which is more than the renter is willing to pay for storage: %v - price gouging protection enabled", reducedCost, allowance.Funds)
+	}
+	return nil
+}
+
+// uploadAppendJobExpectedBandwidth is a helper function that returns the
+// expected bandwidth consumption of appending the given number of sectors.
+func uploadAppendJobExpectedBandwidth(numSectors int) (ul, dl uint64) {
+	// Every sector is split up into frames which adds a small overhead. On top
+	// of that the renter sends a frame for the request, the payment, the
+	// program and the final revision. The host responds with a frame for the
+	// payment, the cancellation token, every instruction and the signature of
+	// the final revision.
+	ul = uint64(float64(uint64(numSectors)*modules.SectorSize)*1.01) + 4*ethernetMTU
+	dl = uint64(3+numSectors) * ethernetMTU
+	return
+}
+
+// staticSupportsUploadAppend returns true if the worker's host supports
+// uploading sectors using Append programs.
+func (w *worker) staticSupportsUploadAppend() bool {
+	return build.VersionCmp(w.staticCache().staticHostVersion, minUploadAppendVersion) >= 0
+}
+
+// managedPerformUploadAppendJob uploads the given piece, together with more
+// pieces from the worker's queue, to the worker's host using a single Append
+// program. The caller is expected to have called managedNextUploadPiece to
+// obtain the first piece.
+func (w *worker) managedPerformUploadAppendJob(first uploadPiece) {
+	// Fill up the batch with more pieces from the queue.
+	pieces := []uploadPiece{first}
+	for len(pieces) < maxUploadAppendSectors && w.managedHasUploadJob() {
+		uc, pieceIndex := w.managedNextUploadPiece()
+		if uc == nil {
+			continue
+		}
+		pieces = append(pieces, uploadPiece{staticChunk: uc, staticPieceIndex: pieceIndex})
+	}
+	defer func() {
+		for _, piece := range pieces {
+			piece.staticChunk.cancelWG.Done()
+		}
+	}()
+
+	// Before performing the upload, check for price gouging.
+	pt := w.staticPriceTable().staticPriceTable
+	err := checkUploadAppendGouging(w.staticCache().staticRenterAllowance, pt)
+	if err != nil && !w.renter.deps.Disrupt("DisableUploadGouging") {
+		failureErr := errors.AddContext(err, "worker uploader is not being used because price gouging was detected")
+		w.renter.log.Debugln(failureErr)
+		w.managedUploadPiecesFailed(pieces, failureErr)
+		return
+	}
+
+	// Perform the upload, and update the failure stats based on the success of
+	// the upload attempt.
+	sectors := make([][]byte, 0, len(pieces))
+	for _, piece := range pieces {
+		piece.staticChunk.mu.Lock()
+		sectors = append(sectors, piece.staticChunk.physicalChunkData[piece.staticPieceIndex])
+		piece.staticChunk.mu.Unlock()
+	}
+	roots, err := w.managedAppendSectors(sectors)
+	if err != nil {
+		failureErr := fmt.Errorf("Worker failed to upload via the MDM: %v", err)
+		w.renter.log.Debugln(failureErr)
+		w.managedUploadPiecesFailed(pieces, failureErr)
+		return
+	}
+	w.mu.Lock()
+	w.uploadConsecutiveFailures = 0
+	w.mu.Unlock()
+
+	// Register the uploaded pieces.
+	for i, piece := range pieces {
+		w.managedUploadSucceeded(piece.staticChunk, piece.staticPieceIndex, roots[i])
+	}
+}
+
+// managedAppendSectors appends the given sectors to the worker's contract using
+// a single Append program. The program is paid for using the worker's
+// ephemeral account if it has a sufficient balance and using the contract
+// otherwise. Afterwards the contract is updated using the final revision of the
+// program. Returns the merkle roots of the sectors.
+func (w *worker) managedAppendSectors(sectors [][]byte) (_ []crypto.Hash, err error) {
+	// Fetch the contract.
+	contract, exists := w.renter.hostContractor.ContractByPublicKey(w.staticHostPubKey)
+	if !exists || len(contract.Transaction.FileContractRevisions) == 0 {
+		return nil, errors.New("worker has no contract with its host")
+	}
+	rev := contract.Transaction.FileContractRevisions[0]
+
+	// Create the program. The host charges for storing the data until the
+	// proof deadline of the contract.
+	pt := w.staticPriceTable().staticPriceTable
+	if rev.NewWindowEnd <= pt.HostBlockHeight {
+		return nil, errors.New("contract has already expired")
+	}
+	pb := modules.NewProgramBuilder(&pt, rev.NewWindowEnd-pt.HostBlockHeight)
+	roots := make([]crypto.Hash, 0, len(sectors))
+	for _, sector := range sectors {
+		err = pb.AddAppendInstruction(sector, false)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to add append instruction")
+		}
+		roots = append(roots, crypto.MerkleRoot(sector))
+	}
+	program, programData := pb.Program()
+	programCost, storageCost, collateral := pb.Cost(true)
+
+	// Take into account bandwidth costs.
+	ulBandwidth, dlBandwidth := uploadAppendJobExpectedBandwidth(len(sectors))
+	bandwidthCost := modules.MDMBandwidthCost(pt, ulBandwidth, dlBandwidth)
+	cost := programCost.Add(bandwidthCost)
+
+	// Create a new stream.
+	stream, err := w.staticNewStream()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create a new stream")
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			w.renter.log.Println("ERROR: failed to close stream", err)
+		}
+	}()
+
+	// Write the specifier and the price table uid. Paying by contract reads
+	// from the stream, so the buffer has to be flushed before the payment.
+	buffer := bytes.NewBuffer(nil)
+	err = modules.RPCWrite(buffer, modules.RPCExecuteProgram)
+	if err != nil {
+		return nil, err
+	}
+	err = modules.RPCWrite(buffer, pt.UID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = buffer.WriteTo(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	// Provide payment, preferably from the ephemeral account.
+	bh := w.staticCache().staticBlockHeight
+	if w.staticAccount.managedAvailableBalance().Cmp(cost) >= 0 {
+		w.staticAccount.managedTrackWithdrawal(cost)
+		defer func() {
+			w.staticAccount.managedCommitWithdrawal(cost, err == nil)
+		}()
+		err = w.staticAccount.ProvidePayment(stream, w.staticHostPubKey, modules.RPCExecuteProgram, cost, w.staticAccount.staticID, bh)
+	} else {
+		err = w.renter.hostContractor.ProvidePayment(stream, w.staticHostPubKey, modules.RPCExecuteProgram, cost, w.staticAccount.staticID, bh)
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to provide payment")
+	}
+
+	// Send the program and its data.
+	epr := modules.RPCExecuteProgramRequest{
+		FileContractID:    contract.ID,
+		Program:           program,
+		ProgramDataLength: uint64(len(programData)),
+	}
+	err = modules.RPCWrite(buffer, epr)
+	if err != nil {
+		return nil, err
+	}
+	_, err = buffer.Write(programData)
+	if err != nil {
+		return nil, err
+	}
+	_, err = buffer.WriteTo(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the cancellation token and the responses.
+	var ct modules.MDMCancellationToken
+	err = modules.RPCRead(stream, &ct)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := readProgramResponses(stream, len(program))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read program responses")
+	}
+	for _, resp := range responses {
+		if resp.Error != nil {
+			return nil, errors.AddContext(resp.Error, "output error")
+		}
+	}
+	if len(responses) != len(program) {
+		return nil, errors.New("received invalid number of responses but no error")
+	}
+
+	// Finalize the program by revising the contract.
+	lastOutput := responses[len(responses)-1].RPCExecuteProgramResponse
+	maxTransfer := collateral.Add(storageCost)
+	err = w.renter.hostContractor.FinalizeAppendProgram(stream, w.staticHostPubKey, roots, lastOutput, maxTransfer, storageCost, cost.Sub(storageCost), pt.HostBlockHeight)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to finalize program")
+	}
+	return roots, nil
+}
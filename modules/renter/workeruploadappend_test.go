@@ -0,0 +1,76 @@
+package renter
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestCheckUploadAppendGouging checks that the append upload price gouging
+// checker is correctly detecting price gouging from a host.
+func TestCheckUploadAppendGouging(t *testing.T) {
+	t.Parallel()
+
+	pt := newDefaultPriceTable()
+	pt.WriteBaseCost = types.NewCurrency64(1)
+	pt.WriteLengthCost = types.NewCurrency64(1)
+	pt.WriteStoreCost = types.SiacoinPrecision.Div64(modules.SectorSize)
+
+	// Without an allowance only the max prices are checked, which aren't set.
+	err := checkUploadAppendGouging(modules.Allowance{}, pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A storage price above the max storage price is gouging.
+	allowance := modules.Allowance{MaxStoragePrice: pt.WriteStoreCost.Sub64(1)}
+	err = checkUploadAppendGouging(allowance, pt)
+	if err == nil || !strings.Contains(err.Error(), "storage price") {
+		t.Fatal("expected storage price gouging", err)
+	}
+
+	// An upload bandwidth price above the max price is gouging.
+	allowance = modules.Allowance{MaxUploadBandwidthPrice: pt.UploadBandwidthCost.Sub64(1)}
+	err = checkUploadAppendGouging(allowance, pt)
+	if err == nil || !strings.Contains(err.Error(), "upload bandwidth price") {
+		t.Fatal("expected upload bandwidth price gouging", err)
+	}
+
+	// Storing a single sector for a single block costs at least one siacoin.
+	// Expecting to store a sector with an allowance that can't cover the
+	// reduced cost is gouging.
+	allowance = modules.Allowance{
+		Funds:           types.SiacoinPrecision.Div64(uploadGougingFractionDenom),
+		Period:          1,
+		ExpectedStorage: modules.SectorSize,
+	}
+	err = checkUploadAppendGouging(allowance, pt)
+	if err == nil || !strings.Contains(err.Error(), "combined upload pricing") {
+		t.Fatal("expected combined price gouging", err)
+	}
+
+	// Increasing the funds resolves the gouging.
+	allowance.Funds = types.SiacoinPrecision
+	err = checkUploadAppendGouging(allowance, pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestUploadAppendJobExpectedBandwidth is a unit test for
+// uploadAppendJobExpectedBandwidth.
+func TestUploadAppendJobExpectedBandwidth(t *testing.T) {
+	t.Parallel()
+
+	for numSectors := 1; numSectors <= maxUploadAppendSectors; numSectors++ {
+		ul, dl := uploadAppendJobExpectedBandwidth(numSectors)
+		if ul < uint64(numSectors)*modules.SectorSize {
+			t.Fatal("expected upload bandwidth to cover the sectors", numSectors, ul)
+		}
+		if dl < uint64(numSectors)*ethernetMTU {
+			t.Fatal("expected download bandwidth to cover a response per sector", numSectors, dl)
+		}
+	}
+}
@@ -160,6 +160,14 @@ type RPCPriceTable struct {
 	// Registry related fields.
 	RegistryEntriesLeft  uint64 `json:"registryentriesleft"`
 	RegistryEntriesTotal uint64 `json:"registryentriestotal"`
+
+	// EphemeralStorageCost is the cost per byte per block of storing a sector
+	// which is paid for from an ephemeral account instead of a file contract.
+	EphemeralStorageCost types.Currency `json:"ephemeralstoragecost"`
+
+	// EphemeralStorageMaxDuration is the maximum number of blocks the host is
+	// willing to store an ephemeral sector for.
+	EphemeralStorageMaxDuration types.BlockHeight `json:"ephemeralstoragemaxduration"`
 }
 
 var (
@@ -19,6 +19,17 @@ type (
 		WriteBPS           int64  `json:"writebps"`
 		PacketSize         uint64 `json:"packetsize"`
 
+		// WebDAV related fields. The WebDAV server is only started if an
+		// address is set.
+		WebDAVAddr     string `json:"webdavaddr"`
+		WebDAVUser     string `json:"webdavuser"`
+		WebDAVPassword string `json:"webdavpassword"`
+
+		// S3 related fields. The S3 server is only started if an address is
+		// set. S3Keys maps access key ids to secret keys.
+		S3Addr string            `json:"s3addr"`
+		S3Keys map[string]string `json:"s3keys"`
+
 		// path of config on disk.
 		path string
 		mu   sync.Mutex
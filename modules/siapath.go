@@ -53,15 +53,29 @@ var (
 	// accessible data.
 	HomeFolder = NewGlobalSiaPath("/home")
 
+	// ReencodeFolder is the Sia folder where siafiles are temporarily stored
+	// while they are re-encoded with new erasure coding parameters.
+	ReencodeFolder = NewGlobalSiaPath("/var/reencode")
+
 	// SkynetFolder is the Sia folder where all of the skyfiles are stored by
 	// default.
 	SkynetFolder = NewGlobalSiaPath("/var/skynet")
 
+	// UploadsFolder is the Sia folder where siafiles are temporarily stored
+	// while they are uploaded before they replace the siafile at their
+	// target siapath.
+	UploadsFolder = NewGlobalSiaPath("/var/uploads")
+
 	// UserFolder is the Sia folder that is used to store the renter's siafiles.
 	UserFolder = NewGlobalSiaPath("/home/user")
 
-	// VarFolder is the Sia folder that contains the skynet folder.
+	// VarFolder is the Sia folder that contains the skynet, versions, reencode
+	// and uploads folders.
 	VarFolder = NewGlobalSiaPath("/var")
+
+	// VersionsFolder is the Sia folder where the hidden versions of
+	// overwritten siafiles are stored.
+	VersionsFolder = NewGlobalSiaPath("/var/versions")
 )
 
 type (
@@ -0,0 +1,385 @@
+package modules
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// sniffLen is the number of bytes used to detect the content type of a
+	// file within an archive if it can't be determined by its extension.
+	sniffLen = 512
+)
+
+var (
+	// ErrDuplicateArchiveFile is returned when an archive contains the same
+	// file more than once.
+	ErrDuplicateArchiveFile = errors.New("archive contains duplicate file")
+
+	// ErrUnsupportedArchiveFormat is returned when trying to expand an archive
+	// of a format that is not supported.
+	ErrUnsupportedArchiveFormat = errors.New("unsupported archive format")
+
+	// ErrZipArchiveTooLarge is returned when trying to expand a zip archive
+	// which exceeds MaxZipArchiveSize.
+	ErrZipArchiveTooLarge = errors.New("zip archive is too large")
+
+	// MaxZipArchiveSize is the maximum size of a zip archive that can be
+	// expanded. The zip format stores its directory at the end of the
+	// archive, which means zip archives have to be held in memory entirely.
+	MaxZipArchiveSize = build.Select(build.Var{
+		Dev:      int64(1 << 26), // 64 MiB
+		Standard: int64(1 << 28), // 256 MiB
+		Testing:  int64(1 << 16), // 64 KiB
+	}).(int64)
+)
+
+type (
+	// archiveFile is a regular file within an archive.
+	archiveFile struct {
+		name   string
+		mode   os.FileMode
+		reader io.Reader
+	}
+
+	// archiveIterator returns the next regular file of an archive every time
+	// it is called. io.EOF is returned when there are no more files.
+	archiveIterator func() (*archiveFile, error)
+
+	// skyfileArchiveReader is a helper struct that implements the
+	// SkyfileUploadReader interface for an archive upload. Every regular file
+	// of the archive becomes a subfile of the skyfile.
+	//
+	// NOTE: reading from this object is not threadsafe and thus should not be
+	// done from more than one thread if you want the reads to be deterministic.
+	skyfileArchiveReader struct {
+		next    archiveIterator
+		readBuf []byte
+
+		fanoutReader *skyfileArchiveReader
+
+		currLen   uint64
+		currOff   uint64
+		currFile  *archiveFile
+		currSniff []byte
+
+		// compression is the content encoding used to compress every file
+		// before it is uploaded. If it is set, the data of the current file is
+		// read through currCompressed.
+		compression    string
+		currCompressed *compressedReader
+
+		metadata      SkyfileMetadata
+		metadataAvail chan struct{}
+	}
+)
+
+// NewSkyfileArchiveReader wraps the given reader, which contains an archive of
+// the given format, and returns a SkyfileUploadReader. By reading from this
+// reader until an EOF is reached, the archive is expanded and the
+// SkyfileMetadata is constructed incrementally every time a new file is read.
+//
+// NOTE: tar archives are decoded while they are streamed. The zip format
+// stores its directory at the end of the archive, which means zip archives are
+// read into memory entirely before they are expanded. Zip archives larger than
+// MaxZipArchiveSize are rejected.
+func NewSkyfileArchiveReader(reader io.Reader, format SkyfileFormat, sup SkyfileUploadParameters) (SkyfileUploadReader, error) {
+	var next, fanoutNext archiveIterator
+	switch format {
+	case SkyfileFormatTar, SkyfileFormatTarGz:
+		// Split the reader using a TeeReader so that the upload is not
+		// blocking the creation of the fanout bytes.
+		var buf bytes.Buffer
+		tr := io.TeeReader(reader, &buf)
+		gz := format == SkyfileFormatTarGz
+		next = newTarIterator(tr, gz)
+		fanoutNext = newTarIterator(&buf, gz)
+	case SkyfileFormatZip:
+		data, err := ioutil.ReadAll(io.LimitReader(reader, MaxZipArchiveSize+1))
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to read zip archive")
+		}
+		if int64(len(data)) > MaxZipArchiveSize {
+			return nil, ErrZipArchiveTooLarge
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to open zip archive")
+		}
+		next = newZipIterator(zr)
+		fanoutNext = newZipIterator(zr)
+	default:
+		return nil, errors.AddContext(ErrUnsupportedArchiveFormat, string(format))
+	}
+	sr := newSkyfileArchiveReader(next, sup)
+	sr.fanoutReader = newSkyfileArchiveReader(fanoutNext, sup)
+	return sr, nil
+}
+
+// newSkyfileArchiveReader returns a skyfileArchiveReader for the files
+// returned by the given iterator.
+func newSkyfileArchiveReader(next archiveIterator, sup SkyfileUploadParameters) *skyfileArchiveReader {
+	return &skyfileArchiveReader{
+		next: next,
+		metadata: SkyfileMetadata{
+			Filename:           sup.Filename,
+			Mode:               sup.Mode,
+			DefaultPath:        sup.DefaultPath,
+			DisableDefaultPath: sup.DisableDefaultPath,
+			TryFiles:           sup.TryFiles,
+			ErrorPages:         sup.ErrorPages,
+			Subfiles:           make(SkyfileSubfiles),
+		},
+		compression:   sup.Compression,
+		metadataAvail: make(chan struct{}),
+	}
+}
+
+// newTarIterator returns an archiveIterator for the tar archive read from r.
+// If gz is true, the archive is expected to be gzip compressed. The readers are
+// created lazily since the fanout reader's data is only available after the
+// upload finished.
+func newTarIterator(r io.Reader, gz bool) archiveIterator {
+	var tr *tar.Reader
+	return func() (*archiveFile, error) {
+		if tr == nil {
+			if gz {
+				gzr, err := gzip.NewReader(r)
+				if err != nil {
+					return nil, errors.AddContext(err, "unable to open gzip stream")
+				}
+				r = gzr
+			}
+			tr = tar.NewReader(r)
+		}
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				return nil, err
+			}
+			// Only regular files become subfiles.
+			if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+				continue
+			}
+			return &archiveFile{
+				name:   cleanArchivePath(header.Name),
+				mode:   os.FileMode(header.Mode).Perm(),
+				reader: tr,
+			}, nil
+		}
+	}
+}
+
+// newZipIterator returns an archiveIterator for the given zip archive.
+func newZipIterator(zr *zip.Reader) archiveIterator {
+	var i int
+	var curr io.Closer
+	return func() (*archiveFile, error) {
+		if curr != nil {
+			err := curr.Close()
+			curr = nil
+			if err != nil {
+				return nil, errors.AddContext(err, "unable to close zip file")
+			}
+		}
+		for ; i < len(zr.File); i++ {
+			f := zr.File[i]
+			// Only regular files become subfiles.
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, errors.AddContext(err, "unable to open zip file")
+			}
+			curr = rc
+			i++
+			return &archiveFile{
+				name:   cleanArchivePath(f.Name),
+				mode:   f.Mode().Perm(),
+				reader: rc,
+			}, nil
+		}
+		return nil, io.EOF
+	}
+}
+
+// cleanArchivePath turns the path of a file within an archive into the path of
+// a subfile. Archives frequently prefix their paths with './' or '/'.
+func cleanArchivePath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// AddReadBuffer adds the given bytes to the read buffer. The next reads will
+// read from this buffer until it is entirely consumed, after which we continue
+// reading from the underlying reader.
+func (sr *skyfileArchiveReader) AddReadBuffer(b []byte) {
+	sr.readBuf = append(sr.readBuf, b...)
+}
+
+// FanoutReader returns the reader to be used for generating the encoded fanout.
+func (sr *skyfileArchiveReader) FanoutReader() io.Reader {
+	return sr.fanoutReader
+}
+
+// SkyfileMetadata returns the SkyfileMetadata associated with this reader.
+func (sr *skyfileArchiveReader) SkyfileMetadata(ctx context.Context) (SkyfileMetadata, error) {
+	// Wait for the metadata to become available, that will be the case when
+	// the reader returned an EOF, or until the context is cancelled.
+	select {
+	case <-ctx.Done():
+		return SkyfileMetadata{}, errors.AddContext(ErrSkyfileMetadataUnavailable, "context cancelled")
+	case <-sr.metadataAvail:
+	}
+
+	// Check whether we found any files
+	if len(sr.metadata.Subfiles) == 0 {
+		return SkyfileMetadata{}, errors.New("could not find any files in archive")
+	}
+
+	// Use the filename of the first subfile if it's not passed as query
+	// string parameter and there's only one subfile.
+	if sr.metadata.Filename == "" && len(sr.metadata.Subfiles) == 1 {
+		for _, sf := range sr.metadata.Subfiles {
+			sr.metadata.Filename = sf.Filename
+			break
+		}
+	}
+
+	// Set the total length as the sum of the lengths of every subfile
+	if sr.metadata.Length == 0 {
+		for _, sf := range sr.metadata.Subfiles {
+			sr.metadata.Length += sf.Len
+		}
+	}
+
+	return sr.metadata, nil
+}
+
+// Read implements the io.Reader part of the interface and reads the contents
+// of the files within the archive. While the data is being read, the metadata
+// is being constructed.
+func (sr *skyfileArchiveReader) Read(p []byte) (n int, err error) {
+	if len(sr.readBuf) > 0 {
+		n = copy(p, sr.readBuf)
+		sr.readBuf = sr.readBuf[n:]
+	}
+
+	// check if we've already read until EOF, that will be the case if
+	// `metadataAvail` is closed.
+	select {
+	case <-sr.metadataAvail:
+		return n, io.EOF
+	default:
+	}
+
+	for n < len(p) && err == nil {
+		// only read the next file if the current file is not set
+		if sr.currFile == nil {
+			sr.currFile, err = sr.next()
+			if err != nil {
+				// signal the metadata is ready on any error, not only EOF
+				close(sr.metadataAvail)
+				break
+			}
+			sr.currOff += sr.currLen
+			sr.currLen = 0
+			sr.currSniff = sr.currSniff[:0]
+
+			// compress the file if necessary
+			sr.currCompressed = nil
+			if sr.compression != "" {
+				sr.currCompressed = newCompressedReader(sr.currFile.reader)
+			}
+		}
+
+		// read data from the file
+		var nn int
+		if sr.currCompressed != nil {
+			nn, err = sr.currCompressed.Read(p[n:])
+		} else {
+			nn, err = sr.currFile.reader.Read(p[n:])
+		}
+		if sr.currCompressed == nil && len(sr.currSniff) < sniffLen {
+			sniff := p[n : n+nn]
+			if len(sniff) > sniffLen-len(sr.currSniff) {
+				sniff = sniff[:sniffLen-len(sr.currSniff)]
+			}
+			sr.currSniff = append(sr.currSniff, sniff...)
+		}
+		n += nn
+
+		// update the length
+		sr.currLen += uint64(nn)
+
+		// ignore the EOF to continue reading from the next file if
+		// necessary
+		if err == io.EOF {
+			// create the metadata for the current subfile before resetting
+			// the current file
+			err = sr.createSubfileFromCurrFile()
+			if err != nil {
+				break
+			}
+			sr.currFile = nil
+		}
+	}
+
+	return
+}
+
+// createSubfileFromCurrFile adds a subfile for the current file.
+func (sr *skyfileArchiveReader) createSubfileFromCurrFile() error {
+	// sanity check the reader has a current file set
+	if sr.currFile == nil {
+		build.Critical("createSubfileFromCurrFile called when currFile is nil")
+		return errors.New("could not create metadata for subfile")
+	}
+
+	filename := sr.currFile.name
+	if filename == "" {
+		return ErrEmptyFilename
+	}
+	if _, exists := sr.metadata.Subfiles[filename]; exists {
+		return errors.AddContext(ErrDuplicateArchiveFile, filename)
+	}
+
+	// determine the content type by the extension of the file, fall back to
+	// sniffing the content if that fails
+	sniff := sr.currSniff
+	if sr.currCompressed != nil {
+		sniff = sr.currCompressed.sniff
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" && len(sniff) > 0 {
+		contentType = http.DetectContentType(sniff)
+	}
+
+	subfile := SkyfileSubfileMetadata{
+		FileMode:    sr.currFile.mode,
+		Filename:    filename,
+		ContentType: contentType,
+		Offset:      sr.currOff,
+		Len:         sr.currLen,
+	}
+	if sr.currCompressed != nil {
+		subfile.ContentEncoding = sr.compression
+		subfile.UncompressedLen = sr.currCompressed.n
+	}
+	sr.metadata.Subfiles[filename] = subfile
+	return nil
+}
@@ -0,0 +1,207 @@
+package modules
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// testArchiveFile is a file that is added to the archives in the tests.
+type testArchiveFile struct {
+	name string
+	mode os.FileMode
+	data []byte
+}
+
+// TestSkyfileArchiveReader verifies the functionality of the
+// SkyfileArchiveReader.
+func TestSkyfileArchiveReader(t *testing.T) {
+	t.Run("Tar", func(t *testing.T) { testSkyfileArchiveReader(t, SkyfileFormatTar) })
+	t.Run("TarGz", func(t *testing.T) { testSkyfileArchiveReader(t, SkyfileFormatTarGz) })
+	t.Run("Zip", func(t *testing.T) { testSkyfileArchiveReader(t, SkyfileFormatZip) })
+	t.Run("Duplicate", testSkyfileArchiveReaderDuplicate)
+	t.Run("ZipTooLarge", testSkyfileArchiveReaderZipTooLarge)
+}
+
+// testSkyfileArchiveReader verifies that an archive of the given format is
+// expanded into a multi-file skyfile.
+func testSkyfileArchiveReader(t *testing.T, format SkyfileFormat) {
+	t.Parallel()
+
+	files := []testArchiveFile{
+		{name: "./index.html", mode: 0644, data: []byte("<html></html>")},
+		{name: "/dir/file", mode: 0600, data: fastrand.Bytes(1000)},
+		{name: "dir/empty.txt", mode: 0640, data: nil},
+	}
+	archive := createTestArchive(t, format, files)
+
+	sup := SkyfileUploadParameters{Filename: "site", DefaultPath: "/index.html"}
+	reader, err := NewSkyfileArchiveReader(bytes.NewReader(archive), format, sup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the data and compare it to the concatenated files.
+	var expected []byte
+	for _, file := range files {
+		expected = append(expected, file.data...)
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatal("unexpected data")
+	}
+
+	// The fanout reader should return the same data.
+	fanoutData, err := ioutil.ReadAll(reader.FanoutReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fanoutData, expected) {
+		t.Fatal("unexpected fanout data")
+	}
+
+	// Verify the metadata.
+	md, err := reader.SkyfileMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Length != uint64(len(expected)) || md.DefaultPath != sup.DefaultPath {
+		t.Fatal("unexpected metadata", md)
+	}
+	if len(md.Subfiles) != len(files) {
+		t.Fatalf("expected %v subfiles but got %v", len(files), len(md.Subfiles))
+	}
+	var offset uint64
+	for _, file := range files {
+		name := cleanArchivePath(file.name)
+		sf, exists := md.Subfiles[name]
+		if !exists {
+			t.Fatal("missing subfile", name)
+		}
+		if sf.Filename != name || sf.FileMode != file.mode || sf.Offset != offset || sf.Len != uint64(len(file.data)) {
+			t.Fatal("unexpected subfile", sf)
+		}
+		offset += sf.Len
+	}
+	if ct := md.Subfiles["index.html"].ContentType; ct != "text/html; charset=utf-8" {
+		t.Fatal("unexpected content type", ct)
+	}
+	if ct := md.Subfiles["dir/file"].ContentType; ct == "" {
+		t.Fatal("content type wasn't sniffed")
+	}
+	if err := ValidateSkyfileMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testSkyfileArchiveReaderDuplicate verifies that archives containing the same
+// file twice are rejected.
+func testSkyfileArchiveReaderDuplicate(t *testing.T) {
+	t.Parallel()
+
+	files := []testArchiveFile{
+		{name: "file", mode: 0644, data: fastrand.Bytes(10)},
+		{name: "./file", mode: 0644, data: fastrand.Bytes(10)},
+	}
+	archive := createTestArchive(t, SkyfileFormatTar, files)
+	reader, err := NewSkyfileArchiveReader(bytes.NewReader(archive), SkyfileFormatTar, SkyfileUploadParameters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(reader)
+	if !errors.Contains(err, ErrDuplicateArchiveFile) {
+		t.Fatal("expected ErrDuplicateArchiveFile", err)
+	}
+}
+
+// testSkyfileArchiveReaderZipTooLarge verifies that zip archives which exceed
+// MaxZipArchiveSize are rejected.
+func testSkyfileArchiveReaderZipTooLarge(t *testing.T) {
+	t.Parallel()
+
+	files := []testArchiveFile{
+		{name: "file", mode: 0644, data: fastrand.Bytes(int(MaxZipArchiveSize))},
+	}
+	archive := createTestArchive(t, SkyfileFormatZip, files)
+	_, err := NewSkyfileArchiveReader(bytes.NewReader(archive), SkyfileFormatZip, SkyfileUploadParameters{})
+	if !errors.Contains(err, ErrZipArchiveTooLarge) {
+		t.Fatal("expected ErrZipArchiveTooLarge", err)
+	}
+}
+
+// createTestArchive creates an archive of the given format which contains the
+// given files. A directory entry is added to make sure only regular files are
+// expanded.
+func createTestArchive(t *testing.T, format SkyfileFormat, files []testArchiveFile) []byte {
+	var buf bytes.Buffer
+	switch format {
+	case SkyfileFormatTar, SkyfileFormatTarGz:
+		var tw *tar.Writer
+		var gzw *gzip.Writer
+		if format == SkyfileFormatTarGz {
+			gzw = gzip.NewWriter(&buf)
+			tw = tar.NewWriter(gzw)
+		} else {
+			tw = tar.NewWriter(&buf)
+		}
+		err := tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			err = tw.WriteHeader(&tar.Header{
+				Name:     file.name,
+				Typeflag: tar.TypeReg,
+				Mode:     int64(file.mode),
+				Size:     int64(len(file.data)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write(file.data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if gzw != nil {
+			if err := gzw.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	case SkyfileFormatZip:
+		zw := zip.NewWriter(&buf)
+		if _, err := zw.Create("dir/"); err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			header := &zip.FileHeader{Name: file.name, Method: zip.Deflate}
+			header.SetMode(file.mode)
+			w, err := zw.CreateHeader(header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(file.data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatal("unsupported format", format)
+	}
+	return buf.Bytes()
+}
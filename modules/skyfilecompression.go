@@ -0,0 +1,112 @@
+package modules
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// compressionChunkSize is the number of uncompressed bytes that are read
+	// from the underlying reader at once by the compressedReader.
+	compressionChunkSize = 1 << 16
+)
+
+var (
+	// ErrUnsupportedCompression is returned when a skyfile is uploaded or
+	// downloaded using a compression that is not supported.
+	ErrUnsupportedCompression = errors.New("unsupported compression")
+)
+
+// compressedReader is a reader that compresses the data of the underlying
+// reader while it is being read.
+//
+// NOTE: the output of the compressedReader only depends on the data of the
+// underlying reader. That allows for compressing the data of a skyfile twice,
+// once for the upload and once for the fanout, and getting the same result.
+type compressedReader struct {
+	src   io.Reader
+	buf   bytes.Buffer
+	gzw   *gzip.Writer
+	chunk []byte
+	done  bool
+
+	// n is the number of uncompressed bytes that were read from src and sniff
+	// contains the first uncompressed bytes, which are used to detect the
+	// content type of the data.
+	n     uint64
+	sniff []byte
+}
+
+// ValidateCompression returns an error if the given compression is not
+// supported. An empty compression is valid and means that the data is not
+// compressed.
+func ValidateCompression(compression string) error {
+	if compression != "" && compression != SkyfileContentEncodingGzip {
+		return errors.AddContext(ErrUnsupportedCompression, compression)
+	}
+	return nil
+}
+
+// compressedContentType returns the content type of the data of a compressed
+// subfile. The content type is determined by the extension of the file and
+// falls back to sniffing the uncompressed data. Unlike uncompressed files we
+// can't leave it to the download handler to sniff the content type, since the
+// data of a compressed file might be served as is.
+func compressedContentType(filename string, sniff []byte) string {
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = http.DetectContentType(sniff)
+	}
+	return contentType
+}
+
+// newCompressedReader returns a reader which compresses the data read from src
+// using gzip, which is the only supported compression.
+func newCompressedReader(src io.Reader) *compressedReader {
+	cr := &compressedReader{
+		src:   src,
+		chunk: make([]byte, compressionChunkSize),
+	}
+	// The header doesn't contain a modification time or name which keeps the
+	// output deterministic.
+	cr.gzw = gzip.NewWriter(&cr.buf)
+	return cr
+}
+
+// Read implements the io.Reader interface.
+func (cr *compressedReader) Read(p []byte) (int, error) {
+	for cr.buf.Len() == 0 && !cr.done {
+		n, err := cr.src.Read(cr.chunk)
+		if n > 0 {
+			if len(cr.sniff) < sniffLen {
+				sniff := cr.chunk[:n]
+				if len(sniff) > sniffLen-len(cr.sniff) {
+					sniff = sniff[:sniffLen-len(cr.sniff)]
+				}
+				cr.sniff = append(cr.sniff, sniff...)
+			}
+			cr.n += uint64(n)
+			if _, werr := cr.gzw.Write(cr.chunk[:n]); werr != nil {
+				return 0, errors.AddContext(werr, "unable to compress data")
+			}
+		}
+		if err == io.EOF {
+			if cerr := cr.gzw.Close(); cerr != nil {
+				return 0, errors.AddContext(cerr, "unable to finish compression")
+			}
+			cr.done = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	if cr.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return cr.buf.Read(p)
+}
@@ -0,0 +1,192 @@
+package modules
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestSkyfileCompression verifies that the SkyfileUploadReaders compress the
+// data of the subfiles if a compression is set.
+func TestSkyfileCompression(t *testing.T) {
+	t.Run("Reader", testSkyfileCompressionReader)
+	t.Run("MultipartReader", testSkyfileCompressionMultipartReader)
+	t.Run("ArchiveReader", testSkyfileCompressionArchiveReader)
+}
+
+// testSkyfileCompressionReader verifies the compression of a regular upload.
+func testSkyfileCompressionReader(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("compressible "), 10e3)
+	sup := SkyfileUploadParameters{
+		Filename:    "file.txt",
+		Mode:        DefaultFilePerm,
+		Compression: SkyfileContentEncodingGzip,
+	}
+	reader := NewSkyfileReader(bytes.NewReader(data), sup)
+	compressed := readSkyfileUploadReader(t, reader)
+
+	md, err := reader.SkyfileMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSkyfileMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+	if md.IsDirectory() || md.Length != uint64(len(compressed)) {
+		t.Fatal("unexpected metadata", md)
+	}
+	sf, exists := md.Subfiles[sup.Filename]
+	if !exists {
+		t.Fatal("compressed upload should have a subfile")
+	}
+	expected := SkyfileSubfileMetadata{
+		FileMode:        sup.Mode,
+		Filename:        sup.Filename,
+		ContentType:     "text/plain; charset=utf-8",
+		ContentEncoding: SkyfileContentEncodingGzip,
+		Len:             uint64(len(compressed)),
+		UncompressedLen: uint64(len(data)),
+	}
+	if sf != expected {
+		t.Fatal("unexpected subfile", sf)
+	}
+	if encoding, size := md.ContentEncoding(); encoding != SkyfileContentEncodingGzip || size != uint64(len(data)) {
+		t.Fatal("unexpected content encoding", encoding, size)
+	}
+	verifyDecompressed(t, compressed, data)
+}
+
+// testSkyfileCompressionMultipartReader verifies the compression of a
+// multipart upload.
+func testSkyfileCompressionMultipartReader(t *testing.T) {
+	t.Parallel()
+
+	files := map[string][]byte{
+		"file1": fastrand.Bytes(100),
+		"file2": bytes.Repeat([]byte{1}, 1000),
+	}
+
+	// create a multipart body
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	var offset uint64
+	for _, name := range []string{"file1", "file2"} {
+		if _, err := AddMultipartFile(writer, files[name], "files[]", name, 0600, &offset); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sup := SkyfileUploadParameters{
+		Filename:    t.Name(),
+		Compression: SkyfileContentEncodingGzip,
+	}
+	var buf bytes.Buffer
+	reader := NewSkyfileMultipartReader(
+		multipart.NewReader(io.TeeReader(body, &buf), writer.Boundary()),
+		newFanoutReader(multipart.NewReader(&buf, writer.Boundary()), sup),
+		sup,
+	)
+	compressed := readSkyfileUploadReader(t, reader)
+
+	md, err := reader.SkyfileMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSkyfileMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+	if !md.IsEncoded() || md.Length != uint64(len(compressed)) {
+		t.Fatal("unexpected metadata", md)
+	}
+	for name, data := range files {
+		sf := md.Subfiles[name]
+		if sf.ContentEncoding != SkyfileContentEncodingGzip || sf.UncompressedLen != uint64(len(data)) {
+			t.Fatal("unexpected subfile", sf)
+		}
+		verifyDecompressed(t, compressed[sf.Offset:sf.Offset+sf.Len], data)
+	}
+}
+
+// testSkyfileCompressionArchiveReader verifies the compression of an archive
+// upload.
+func testSkyfileCompressionArchiveReader(t *testing.T) {
+	t.Parallel()
+
+	files := []testArchiveFile{
+		{name: "index.html", mode: 0644, data: []byte("<html></html>")},
+		{name: "dir/file", mode: 0600, data: []byte("some text")},
+	}
+	archive := createTestArchive(t, SkyfileFormatTar, files)
+	sup := SkyfileUploadParameters{
+		Filename:    "site",
+		Compression: SkyfileContentEncodingGzip,
+	}
+	reader, err := NewSkyfileArchiveReader(bytes.NewReader(archive), SkyfileFormatTar, sup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed := readSkyfileUploadReader(t, reader)
+
+	md, err := reader.SkyfileMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSkyfileMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		sf := md.Subfiles[file.name]
+		if sf.ContentEncoding != SkyfileContentEncodingGzip || sf.UncompressedLen != uint64(len(file.data)) {
+			t.Fatal("unexpected subfile", sf)
+		}
+		verifyDecompressed(t, compressed[sf.Offset:sf.Offset+sf.Len], file.data)
+	}
+	// the content type should be sniffed from the uncompressed data
+	if ct := md.Subfiles["dir/file"].ContentType; ct != "text/plain; charset=utf-8" {
+		t.Fatal("unexpected content type", ct)
+	}
+}
+
+// readSkyfileUploadReader reads all data from the given reader and its fanout
+// reader, verifies they are equal and returns the data.
+func readSkyfileUploadReader(t *testing.T, reader SkyfileUploadReader) []byte {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fanoutData, err := ioutil.ReadAll(reader.FanoutReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, fanoutData) {
+		t.Fatal("fanout data doesn't match the uploaded data")
+	}
+	return data
+}
+
+// verifyDecompressed verifies that the compressed data decompresses to the
+// expected data.
+func verifyDecompressed(t *testing.T, compressed, expected []byte) {
+	gzr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(gzr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatal("decompressed data doesn't match")
+	}
+}
@@ -53,6 +53,12 @@ type (
 		currOff  uint64
 		currPart *multipart.Part
 
+		// compression is the content encoding used to compress every part
+		// before it is uploaded. If it is set, the data of the current part is
+		// read through currCompressed.
+		compression    string
+		currCompressed *compressedReader
+
 		metadata      SkyfileMetadata
 		metadataAvail chan struct{}
 	}
@@ -70,6 +76,12 @@ type (
 
 		currLen uint64
 
+		// compression is the content encoding used to compress the data
+		// before it is uploaded. If it is set, compressed is the underlying
+		// reader.
+		compression string
+		compressed  *compressedReader
+
 		metadata      SkyfileMetadata
 		metadataAvail chan struct{}
 	}
@@ -84,7 +96,7 @@ func NewSkyfileReader(reader io.Reader, sup SkyfileUploadParameters) SkyfileUplo
 	tr := io.TeeReader(reader, &buf)
 
 	// Define the skyfileReader
-	return &skyfileReader{
+	sr := &skyfileReader{
 		reader:       tr,
 		fanoutReader: &buf,
 		metadata: SkyfileMetadata{
@@ -93,6 +105,16 @@ func NewSkyfileReader(reader io.Reader, sup SkyfileUploadParameters) SkyfileUplo
 		},
 		metadataAvail: make(chan struct{}),
 	}
+
+	// If the data is compressed, both the data and the fanout are read through
+	// a compressedReader.
+	if sup.Compression != "" {
+		sr.compression = sup.Compression
+		sr.compressed = newCompressedReader(tr)
+		sr.reader = sr.compressed
+		sr.fanoutReader = newCompressedReader(&buf)
+	}
+	return sr
 }
 
 // AddReadBuffer adds the given bytes to the read buffer. The next reads will
@@ -151,10 +173,32 @@ func (sr *skyfileReader) Read(p []byte) (n int, err error) {
 	if errors.Contains(err, io.EOF) {
 		close(sr.metadataAvail)
 		sr.metadata.Length = sr.currLen
+		if sr.compressed != nil {
+			sr.createCompressedSubfile()
+		}
 	}
 	return
 }
 
+// createCompressedSubfile adds a single subfile for the compressed data to the
+// metadata. The content encoding is recorded per subfile, which is why a
+// compressed skyfile always has a subfile, even if it wasn't uploaded as a
+// multipart upload. Since the subfile has the same name as the skyfile, the
+// skyfile is not considered a directory.
+func (sr *skyfileReader) createCompressedSubfile() {
+	filename := sr.metadata.Filename
+	sr.metadata.Subfiles = SkyfileSubfiles{
+		filename: SkyfileSubfileMetadata{
+			FileMode:        sr.metadata.Mode,
+			Filename:        filename,
+			ContentType:     compressedContentType(filename, sr.compressed.sniff),
+			ContentEncoding: sr.compression,
+			Len:             sr.currLen,
+			UncompressedLen: sr.compressed.n,
+		},
+	}
+}
+
 // NewSkyfileMultipartReader wraps the given reader and returns
 // a SkyfileUploadReader. By reading from this reader until an EOF is reached,
 // the SkyfileMetadata will be constructed incrementally every time a new Part
@@ -172,8 +216,11 @@ func NewSkyfileMultipartReader(reader *multipart.Reader, fanoutReader *skyfileMu
 			Mode:               sup.Mode,
 			DefaultPath:        sup.DefaultPath,
 			DisableDefaultPath: sup.DisableDefaultPath,
+			TryFiles:           sup.TryFiles,
+			ErrorPages:         sup.ErrorPages,
 			Subfiles:           make(SkyfileSubfiles),
 		},
+		compression:   sup.Compression,
 		metadataAvail: make(chan struct{}),
 	}
 }
@@ -188,8 +235,11 @@ func newFanoutReader(reader *multipart.Reader, sup SkyfileUploadParameters) *sky
 			Mode:               sup.Mode,
 			DefaultPath:        sup.DefaultPath,
 			DisableDefaultPath: sup.DisableDefaultPath,
+			TryFiles:           sup.TryFiles,
+			ErrorPages:         sup.ErrorPages,
 			Subfiles:           make(SkyfileSubfiles),
 		},
+		compression:   sup.Compression,
 		metadataAvail: make(chan struct{}),
 	}
 }
@@ -312,11 +362,21 @@ func (sr *skyfileMultipartReader) Read(p []byte) (n int, err error) {
 				err = ErrIllegalFormName
 				break
 			}
+
+			// compress the part if necessary
+			sr.currCompressed = nil
+			if sr.compression != "" {
+				sr.currCompressed = newCompressedReader(sr.currPart)
+			}
 		}
 
 		// read data from the part
 		var nn int
-		nn, err = sr.currPart.Read(p[n:])
+		if sr.currCompressed != nil {
+			nn, err = sr.currCompressed.Read(p[n:])
+		} else {
+			nn, err = sr.currPart.Read(p[n:])
+		}
 		n += nn
 
 		// update the length
@@ -359,13 +419,21 @@ func (sr *skyfileMultipartReader) createSubfileFromCurrPart() error {
 		return ErrEmptyFilename
 	}
 
-	sr.metadata.Subfiles[filename] = SkyfileSubfileMetadata{
+	subfile := SkyfileSubfileMetadata{
 		FileMode:    mode,
 		Filename:    filename,
 		ContentType: sr.currPart.Header.Get("Content-Type"),
 		Offset:      sr.currOff,
 		Len:         sr.currLen,
 	}
+	if sr.currCompressed != nil {
+		if subfile.ContentType == "" {
+			subfile.ContentType = compressedContentType(filename, sr.currCompressed.sniff)
+		}
+		subfile.ContentEncoding = sr.compression
+		subfile.UncompressedLen = sr.currCompressed.n
+	}
+	sr.metadata.Subfiles[filename] = subfile
 	return nil
 }
 
@@ -13,6 +13,7 @@ import (
 
 	"gitlab.com/NebulousLabs/Sia/build"
 	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/types"
 
 	"gitlab.com/NebulousLabs/errors"
 )
@@ -33,14 +34,25 @@ const (
 	// encoded using base64.
 	base64EncodedSkylinkSize = 46
 
-	// rawSkylinkSize is the raw size of the data that gets put into a link.
-	rawSkylinkSize = 34
+	// RawSkylinkSize is the raw size of the data that gets put into a link.
+	RawSkylinkSize = 34
+
+	// MaxSkylinkV2ResolvingDepth is the maximum number of v2 skylinks that
+	// are resolved in a row when following a v2 skylink to its v1 skylink.
+	// This prevents v2 skylinks that point to themselves or other v2 skylinks
+	// from causing endless lookups.
+	MaxSkylinkV2ResolvingDepth = 5
 )
 
 var (
 	// ErrSkylinkIncorrectSize is returned when a string could not be decoded
 	// into a Skylink due to it having an incorrect size.
 	ErrSkylinkIncorrectSize = errors.New("skylink has incorrect size")
+
+	// ErrSkylinkV2ResolvingDepthExceeded is returned when resolving a v2
+	// skylink requires following more than MaxSkylinkV2ResolvingDepth v2
+	// skylinks.
+	ErrSkylinkV2ResolvingDepthExceeded = errors.New("exceeded maximum depth for resolving v2 skylinks")
 )
 
 type (
@@ -51,6 +63,10 @@ type (
 	// The first two bits of the bitfield (values 1 and 2 in decimal) determine
 	// the version of the skylink. The skylink version determines how the
 	// remaining bits are used. Not all values of the bitfield are legal.
+	//
+	// For a v1 skylink the MerkleRoot is the root of the sector that contains
+	// the file. For a v2 skylink it is the ID of a registry entry which in
+	// turn contains the skylink to resolve to.
 	Skylink struct {
 		bitfield   uint16
 		merkleRoot crypto.Hash
@@ -73,6 +89,33 @@ func NewSkylinkV1(merkleRoot crypto.Hash, offset, length uint64) (Skylink, error
 	return sl, nil
 }
 
+// NewSkylinkV2 returns a v2 Skylink object with the version set to 2. Instead
+// of a merkle root, a v2 Skylink contains the ID of the registry entry that is
+// identified by the provided public key and tweak. The data of that registry
+// entry is expected to be the raw bytes of the skylink the v2 skylink
+// resolves to.
+func NewSkylinkV2(spk types.SiaPublicKey, tweak crypto.Hash) Skylink {
+	return Skylink{
+		bitfield:   1, // only the version bits are set, semantically version 2
+		merkleRoot: DeriveRegistryEntryID(spk, tweak),
+	}
+}
+
+// validateBitfield validates the bitfield of a skylink of any supported
+// version.
+func validateBitfield(bitfield uint16) error {
+	// A v2 skylink only uses the version bits. All other bits are reserved and
+	// need to be 0.
+	if bitfield&3 == 1 {
+		if bitfield != 1 {
+			return errors.New("v2 skylink has non-zero reserved bits")
+		}
+		return nil
+	}
+	_, _, err := validateAndParseV1Bitfield(bitfield)
+	return err
+}
+
 // validateAndParseV1Bitfield is a helper method which validates that a bitfield
 // is valid and also parses the offset and fetch size from the bitfield. These
 // two actions are performed at once because performing full validation requires
@@ -142,7 +185,7 @@ func (sl *Skylink) Bitfield() uint16 {
 
 // Bytes returns the raw bytes representation of a Skylink
 func (sl *Skylink) Bytes() []byte {
-	raw := make([]byte, rawSkylinkSize)
+	raw := make([]byte, RawSkylinkSize)
 	binary.LittleEndian.PutUint16(raw, sl.bitfield)
 	copy(raw[2:], sl.merkleRoot[:])
 	return raw
@@ -154,6 +197,16 @@ func (sl Skylink) DataSourceID() DataSourceID {
 	return DataSourceID(crypto.HashObject(sl.String()))
 }
 
+// IsSkylinkV1 returns true if the skylink is a v1 skylink.
+func (sl Skylink) IsSkylinkV1() bool {
+	return sl.Version() == 1
+}
+
+// IsSkylinkV2 returns true if the skylink is a v2 skylink.
+func (sl Skylink) IsSkylinkV2() bool {
+	return sl.Version() == 2
+}
+
 // LoadString converts from a string and loads the result into sl.
 func (sl *Skylink) LoadString(s string) error {
 	// Trim any parameters that may exist after a question mark. Eventually, it
@@ -182,7 +235,8 @@ func (sl *Skylink) LoadString(s string) error {
 	return sl.LoadBytes(raw)
 }
 
-// MerkleRoot returns the merkle root of the Skylink.
+// MerkleRoot returns the merkle root of the Skylink. For a v2 Skylink this is
+// the ID of the registry entry the Skylink points to.
 func (sl Skylink) MerkleRoot() crypto.Hash {
 	return sl.merkleRoot
 }
@@ -313,7 +367,7 @@ func (sl Skylink) Version() uint16 {
 // LoadBytes loads the given raw data onto the skylink.
 func (sl *Skylink) LoadBytes(data []byte) error {
 	// Sanity check the size of the given data
-	if len(data) != rawSkylinkSize {
+	if len(data) != RawSkylinkSize {
 		build.Critical("raw skylink data has the incorrect size")
 		return errors.New("failed to load skylink data")
 	}
@@ -322,7 +376,7 @@ func (sl *Skylink) LoadBytes(data []byte) error {
 	// Skylink so that the Skylink remains unchanged if there is any error
 	// parsing the string.
 	bitfield := binary.LittleEndian.Uint16(data)
-	_, _, err := validateAndParseV1Bitfield(bitfield)
+	err := validateBitfield(bitfield)
 	if err != nil {
 		return errors.AddContext(err, "skylink failed verification")
 	}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/types"
 	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/NebulousLabs/fastrand"
@@ -204,7 +205,7 @@ func TestSkylink(t *testing.T) {
 
 	// Try loading a base32 encoded string with invalid bitfield
 	var slInvalidBitfield Skylink
-	slInvalidBitfield.bitfield = 1
+	slInvalidBitfield.bitfield = 3
 	b32BadBitfield := slInvalidBitfield.Base32EncodedString()
 	err = slMaxB32Decoded.LoadString(b32BadBitfield)
 	if err == nil {
@@ -287,6 +288,57 @@ func TestSkylink(t *testing.T) {
 	}
 }
 
+// TestSkylinkV2 tests creating and loading v2 skylinks.
+func TestSkylinkV2(t *testing.T) {
+	// Create a v2 skylink.
+	_, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	sl := NewSkylinkV2(spk, tweak)
+
+	// Check the version and the merkle root.
+	if !sl.IsSkylinkV2() || sl.IsSkylinkV1() {
+		t.Fatal("wrong version", sl.Version())
+	}
+	if sl.MerkleRoot() != DeriveRegistryEntryID(spk, tweak) {
+		t.Fatal("wrong entry id")
+	}
+
+	// A v2 skylink doesn't have an offset and fetch size.
+	_, _, err := sl.OffsetAndFetchSize()
+	if err == nil {
+		t.Fatal("v2 skylink shouldn't have offset and fetch size")
+	}
+
+	// Encode and decode it.
+	var sl2 Skylink
+	err = sl2.LoadString(sl.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sl2 != sl {
+		t.Fatal("encoding and decoding is not symmetric")
+	}
+	err = sl2.LoadString(sl.Base32EncodedString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sl2 != sl {
+		t.Fatal("base32 encoding and decoding is not symmetric")
+	}
+
+	// Setting any of the reserved bits should result in an invalid skylink.
+	for i := uint(2); i < 16; i++ {
+		slBad := sl
+		slBad.bitfield |= 1 << i
+		err = sl2.LoadString(slBad.String())
+		if err == nil {
+			t.Fatal("expected error for reserved bit", i)
+		}
+	}
+}
+
 // TestSkylinkAutoExamples performs a brute force test over lots of values for
 // the skylink bitfield to ensure correctness.
 func TestSkylinkAutoExamples(t *testing.T) {
@@ -5,6 +5,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -52,6 +53,17 @@ var (
 	SkyfileFormatZip = SkyfileFormat("zip")
 )
 
+const (
+	// SkyfileContentEncodingGzip is the content encoding of subfiles that were
+	// compressed using gzip before they were uploaded. It matches the HTTP
+	// content coding of the same name.
+	SkyfileContentEncodingGzip = "gzip"
+
+	// SkyfileTryFilesURI is the placeholder in the TryFiles of a skyfile which
+	// is replaced by the requested path.
+	SkyfileTryFilesURI = "$uri"
+)
+
 type (
 	// SkyfileSubfiles contains the subfiles of a skyfile, indexed by their
 	// filename.
@@ -89,6 +101,12 @@ type (
 		// Mode indicates the file permissions of the skyfile.
 		Mode os.FileMode
 
+		// Compression is the content encoding used to compress every subfile
+		// before it is uploaded. If left empty, the subfiles are uploaded
+		// uncompressed. The only supported compression is
+		// SkyfileContentEncodingGzip.
+		Compression string
+
 		// DefaultPath indicates what content to serve if the user has not
 		// specified a path and the user is not trying to download the Skylink
 		// as an archive. If left empty, it will be interpreted as "index.html"
@@ -100,6 +118,17 @@ type (
 		// content will be automatically served for the skyfile.
 		DisableDefaultPath bool
 
+		// TryFiles is an ordered list of paths that are tried when the
+		// requested path doesn't match a file of the skyfile. Every occurrence
+		// of SkyfileTryFilesURI is replaced by the requested path. The first
+		// path that matches a file is served. TryFiles can't be combined with
+		// DefaultPath or DisableDefaultPath.
+		TryFiles []string
+
+		// ErrorPages maps HTTP status codes to the files that are served
+		// whenever a download of the skyfile results in that status code.
+		ErrorPages map[int]string
+
 		// Reader supplies the file data for the skyfile.
 		Reader io.Reader
 
@@ -116,6 +145,20 @@ type (
 		FileSpecificSkykey skykey.Skykey
 	}
 
+	// SkyfilePackedFile is a single small file of a packed skyfile upload.
+	// Every packed file ends up as its own skyfile with its own skylink, but
+	// multiple packed files can share the same sector on the network.
+	SkyfilePackedFile struct {
+		// Filename is the filename of the skyfile.
+		Filename string
+
+		// Mode indicates the file permissions of the skyfile.
+		Mode os.FileMode
+
+		// Data is the file data of the skyfile.
+		Data []byte
+	}
+
 	// SkyfileMultipartUploadParameters defines the parameters specific to
 	// multipart uploads. See SkyfileUploadParameters for a detailed description
 	// of the fields.
@@ -138,6 +181,14 @@ type (
 		// content will be automatically served for the skyfile.
 		DisableDefaultPath bool
 
+		// TryFiles is an ordered list of paths that are tried when the
+		// requested path doesn't match a file of the skyfile.
+		TryFiles []string
+
+		// ErrorPages maps HTTP status codes to the files that are served
+		// whenever a download of the skyfile results in that status code.
+		ErrorPages map[int]string
+
 		// ContentType indicates the media  of the data supplied by the reader.
 		ContentType string
 	}
@@ -164,6 +215,66 @@ type (
 		Subfiles           SkyfileSubfiles `json:"subfiles,omitempty"`
 		DefaultPath        string          `json:"defaultpath,omitempty"`
 		DisableDefaultPath bool            `json:"disabledefaultpath,omitempty"`
+		TryFiles           []string        `json:"tryfiles,omitempty"`
+		ErrorPages         map[int]string  `json:"errorpages,omitempty"`
+	}
+
+	// SkylinkHealth describes how well the data of a skylink is available on
+	// the network.
+	SkylinkHealth struct {
+		// BaseSector is the health of the base sector of the skyfile.
+		BaseSector SkylinkChunkHealth `json:"basesector"`
+
+		// Chunks contains the health of every chunk of the fanout. It is
+		// empty for skyfiles which fit within their base sector.
+		Chunks []SkylinkChunkHealth `json:"chunks"`
+
+		// MinRedundancy is the lowest redundancy of the base sector and all
+		// the chunks. A redundancy below 1 means that the skyfile can't be
+		// fully retrieved.
+		MinRedundancy float64 `json:"minredundancy"`
+
+		// HostsQueried is the number of hosts that were asked for the
+		// sectors and HostsResponded is the number of hosts that answered.
+		HostsQueried   uint64 `json:"hostsqueried"`
+		HostsResponded uint64 `json:"hostsresponded"`
+	}
+
+	// SkylinkChunkHealth describes how well a single chunk of a skyfile is
+	// available on the network.
+	SkylinkChunkHealth struct {
+		// AvailablePieces is the number of pieces of the chunk that are stored
+		// on at least one host.
+		AvailablePieces uint64 `json:"availablepieces"`
+
+		// MinPieces is the number of pieces required to recover the chunk.
+		MinPieces uint64 `json:"minpieces"`
+
+		// Redundancy is the redundancy of the chunk. If a single piece is
+		// enough to recover the chunk, it's the number of hosts storing any
+		// of the pieces. Otherwise it's the number of available pieces divided
+		// by the number of pieces required to recover the chunk.
+		Redundancy float64 `json:"redundancy"`
+
+		// Pieces contains the hosts storing each piece of the chunk.
+		Pieces []SkylinkPieceHealth `json:"pieces"`
+	}
+
+	// SkylinkPieceHealth contains the hosts that store a single piece of a
+	// skyfile.
+	SkylinkPieceHealth struct {
+		Root  crypto.Hash          `json:"root"`
+		Hosts []types.SiaPublicKey `json:"hosts"`
+	}
+
+	// SkynetCacheStats contains statistics about the renter's on-disk cache
+	// for skynet base sectors and fanout chunks.
+	SkynetCacheStats struct {
+		Hits     uint64 `json:"hits"`
+		Misses   uint64 `json:"misses"`
+		NumItems uint64 `json:"numitems"`
+		Size     uint64 `json:"size"`    // bytes used on disk
+		MaxSize  uint64 `json:"maxsize"` // bytes allowed on disk, 0 disables the cache
 	}
 
 	// SkynetPortal contains information identifying a Skynet portal.
@@ -221,6 +332,31 @@ func (sm SkyfileMetadata) ForPath(path string) (SkyfileMetadata, bool, uint64, u
 	return metadata, isFile, offset, metadata.size()
 }
 
+// TryFile returns the path of the first entry of TryFiles which matches a file
+// of the skyfile for the given request path. The request path replaces every
+// occurrence of SkyfileTryFilesURI in the entries.
+func (sm SkyfileMetadata) TryFile(requestPath string) (string, bool) {
+	requestPath = EnsurePrefix(requestPath, "/")
+	for _, tf := range sm.TryFiles {
+		tryPath := strings.Replace(tf, SkyfileTryFilesURI, requestPath, -1)
+		tryPath = path.Clean(EnsurePrefix(tryPath, "/"))
+		if _, exists := sm.Subfiles[strings.TrimPrefix(tryPath, "/")]; exists {
+			return tryPath, true
+		}
+	}
+	return "", false
+}
+
+// ErrorPage returns the path of the file which should be served for the given
+// HTTP status code.
+func (sm SkyfileMetadata) ErrorPage(status int) (string, bool) {
+	errorPage, exists := sm.ErrorPages[status]
+	if !exists {
+		return "", false
+	}
+	return EnsurePrefix(errorPage, "/"), true
+}
+
 // ContentType returns the Content Type of the data. We only return a
 // content-type if it has exactly one subfile. As that is the only case where we
 // can be sure of it.
@@ -233,6 +369,28 @@ func (sm SkyfileMetadata) ContentType() string {
 	return ""
 }
 
+// ContentEncoding returns the content encoding of the data and the length of
+// the data once it's decoded. Like the content type, we only return a content
+// encoding if there is exactly one subfile.
+func (sm SkyfileMetadata) ContentEncoding() (string, uint64) {
+	if len(sm.Subfiles) == 1 {
+		for _, sf := range sm.Subfiles {
+			return sf.ContentEncoding, sf.UncompressedLen
+		}
+	}
+	return "", 0
+}
+
+// IsEncoded returns true if any of the subfiles has a content encoding.
+func (sm SkyfileMetadata) IsEncoded() bool {
+	for _, sf := range sm.Subfiles {
+		if sf.ContentEncoding != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // IsDirectory returns true if the SkyfileMetadata represents a directory.
 func (sm SkyfileMetadata) IsDirectory() bool {
 	if len(sm.Subfiles) > 1 {
@@ -350,12 +508,18 @@ func (sl *SkyfileLayout) Encode() []byte {
 // written and its length. Its filename can potentially include a '/' character
 // as nested files and directories are allowed within a single Skyfile, but it
 // is not allowed to contain ./, ../, be empty, or start with a forward slash.
+//
+// If the subfile was compressed before it was uploaded, ContentEncoding holds
+// the encoding of the data. In that case Len is the length of the encoded data
+// and UncompressedLen is the length of the data once it's decoded.
 type SkyfileSubfileMetadata struct {
-	FileMode    os.FileMode `json:"mode,omitempty,siamismatch"` // different json name for compat reasons
-	Filename    string      `json:"filename,omitempty"`
-	ContentType string      `json:"contenttype,omitempty"`
-	Offset      uint64      `json:"offset,omitempty"`
-	Len         uint64      `json:"len,omitempty"`
+	FileMode        os.FileMode `json:"mode,omitempty,siamismatch"` // different json name for compat reasons
+	Filename        string      `json:"filename,omitempty"`
+	ContentType     string      `json:"contenttype,omitempty"`
+	ContentEncoding string      `json:"contentencoding,omitempty"`
+	Offset          uint64      `json:"offset,omitempty"`
+	Len             uint64      `json:"len,omitempty"`
+	UncompressedLen uint64      `json:"uncompressedlen,omitempty"`
 }
 
 // IsDir implements the os.FileInfo interface for SkyfileSubfileMetadata.
@@ -258,3 +258,84 @@ func TestSkyfileMetadata_IsDirectory(t *testing.T) {
 		}
 	}
 }
+
+// TestSkyfileMetadata_TryFile is a table test for the TryFile method.
+func TestSkyfileMetadata_TryFile(t *testing.T) {
+	meta := SkyfileMetadata{
+		Filename: "app",
+		Subfiles: SkyfileSubfiles{
+			"index.html":       SkyfileSubfileMetadata{Filename: "index.html"},
+			"about/index.html": SkyfileSubfileMetadata{Filename: "about/index.html"},
+			"img/logo.png":     SkyfileSubfileMetadata{Filename: "img/logo.png"},
+		},
+		TryFiles: []string{SkyfileTryFilesURI, SkyfileTryFilesURI + "/index.html", "/index.html"},
+	}
+
+	tests := []struct {
+		name         string
+		meta         SkyfileMetadata
+		path         string
+		expectedPath string
+		expectedOK   bool
+	}{
+		{
+			name:         "exact file",
+			meta:         meta,
+			path:         "/img/logo.png",
+			expectedPath: "/img/logo.png",
+			expectedOK:   true,
+		},
+		{
+			name:         "directory index",
+			meta:         meta,
+			path:         "/about",
+			expectedPath: "/about/index.html",
+			expectedOK:   true,
+		},
+		{
+			name:         "directory index trailing slash",
+			meta:         meta,
+			path:         "/about/",
+			expectedPath: "/about/index.html",
+			expectedOK:   true,
+		},
+		{
+			name:         "fallback",
+			meta:         meta,
+			path:         "/some/client/route",
+			expectedPath: "/index.html",
+			expectedOK:   true,
+		},
+		{
+			name:         "root",
+			meta:         meta,
+			path:         "/",
+			expectedPath: "/index.html",
+			expectedOK:   true,
+		},
+		{
+			name:         "no try files",
+			meta:         SkyfileMetadata{Subfiles: meta.Subfiles},
+			path:         "/index.html",
+			expectedPath: "",
+			expectedOK:   false,
+		},
+		{
+			name: "no match",
+			meta: SkyfileMetadata{
+				Subfiles: meta.Subfiles,
+				TryFiles: []string{SkyfileTryFilesURI},
+			},
+			path:         "/missing",
+			expectedPath: "",
+			expectedOK:   false,
+		},
+	}
+
+	for _, test := range tests {
+		path, ok := test.meta.TryFile(test.path)
+		if path != test.expectedPath || ok != test.expectedOK {
+			t.Fatalf("'%s' failed: expected '%s' '%t', got '%s' '%t'", test.name, test.expectedPath, test.expectedOK, path, ok)
+		}
+	}
+}
@@ -24,6 +24,14 @@ var (
 	// ErrInvalidDefaultPath is returned when the specified default path is not
 	// valid, e.g. the file it points to does not exist.
 	ErrInvalidDefaultPath = errors.New("invalid default path provided")
+
+	// ErrInvalidTryFiles is returned when the specified try files are not
+	// valid, e.g. a file they point to does not exist.
+	ErrInvalidTryFiles = errors.New("invalid try files provided")
+
+	// ErrInvalidErrorPages is returned when the specified error pages are not
+	// valid, e.g. a file they point to does not exist.
+	ErrInvalidErrorPages = errors.New("invalid error pages provided")
 )
 
 // AddMultipartFile is a helper function to add a file to multipart form-data.
@@ -106,6 +114,23 @@ func DecodeFanout(sl SkyfileLayout, fanoutBytes []byte) (piecesPerChunk, chunkRo
 	return
 }
 
+// DeriveFileSpecificSkykey derives the file-specific skykey of the skyfile
+// stored using this layout from the master skykey that was used to encrypt it.
+func DeriveFileSpecificSkykey(sl SkyfileLayout, masterSkykey skykey.Skykey) (skykey.Skykey, error) {
+	// X25519 skykeys derive the file-specific key from the ephemeral public key
+	// of the uploader, which is stored after the key ID.
+	if masterSkykey.Type == skykey.TypeX25519Private || masterSkykey.Type == skykey.TypeX25519Public {
+		var epk crypto.X25519PublicKey
+		copy(epk[:], sl.KeyData[skykey.SkykeyIDLen:skykey.SkykeyIDLen+len(epk)])
+		return masterSkykey.X25519Subkey(epk)
+	}
+
+	// All other skykeys use the nonce which is stored after the key ID.
+	nonce := make([]byte, chacha.XNonceSize)
+	copy(nonce[:], sl.KeyData[skykey.SkykeyIDLen:skykey.SkykeyIDLen+chacha.XNonceSize])
+	return masterSkykey.SubkeyWithNonce(nonce)
+}
+
 // DecryptBaseSector attempts to decrypt the baseSector. If it has the necessary
 // Skykey, it will decrypt the baseSector in-place.It returns the file-specific
 // skykey to be used for decrypting the rest of the associated skyfile.
@@ -124,17 +149,8 @@ func DecryptBaseSector(baseSector []byte, sk skykey.Skykey) (skykey.Skykey, erro
 		build.Critical("Expected layout to be marked as encrypted!")
 	}
 
-	// Get the nonce to be used for getting private-id skykeys, and for deriving the
-	// file-specific skykey.
-	nonce := make([]byte, chacha.XNonceSize)
-	copy(nonce[:], sl.KeyData[skykey.SkykeyIDLen:skykey.SkykeyIDLen+chacha.XNonceSize])
-
-	// Grab the key ID from the layout.
-	var keyID skykey.SkykeyID
-	copy(keyID[:], sl.KeyData[:skykey.SkykeyIDLen])
-
 	// Derive the file-specific key.
-	fileSkykey, err := sk.SubkeyWithNonce(nonce)
+	fileSkykey, err := DeriveFileSpecificSkykey(sl, sk)
 	if err != nil {
 		return skykey.Skykey{}, errors.AddContext(err, "Unable to derive file-specific subkey")
 	}
@@ -302,6 +318,10 @@ func ValidateSkyfileMetadata(metadata SkyfileMetadata) error {
 			if err != nil {
 				return errors.AddContext(err, fmt.Sprintf("invalid filename provided for subfile '%v'", filename))
 			}
+			err = ValidateCompression(md.ContentEncoding)
+			if err != nil {
+				return errors.AddContext(err, fmt.Sprintf("invalid content encoding for subfile '%v'", filename))
+			}
 
 			// note that we do not check the length property of a subfile as it
 			// is possible a user might have uploaded an empty part
@@ -313,6 +333,11 @@ func ValidateSkyfileMetadata(metadata SkyfileMetadata) error {
 		return errors.New("'Length' property not set on metadata")
 	}
 
+	// try files replace the default path, so they can't be combined
+	if len(metadata.TryFiles) > 0 && (metadata.DefaultPath != "" || metadata.DisableDefaultPath) {
+		return errors.AddContext(ErrInvalidTryFiles, "try files can't be combined with a default path")
+	}
+
 	// validate default path (only if default path was not explicitly disabled)
 	if !metadata.DisableDefaultPath {
 		metadata.DefaultPath, err = validateDefaultPath(metadata.DefaultPath, metadata.Subfiles)
@@ -321,6 +346,18 @@ func ValidateSkyfileMetadata(metadata SkyfileMetadata) error {
 		}
 	}
 
+	// validate try files
+	err = validateTryFiles(metadata.TryFiles, metadata.Subfiles)
+	if err != nil {
+		return errors.Compose(ErrInvalidTryFiles, err)
+	}
+
+	// validate error pages
+	err = validateErrorPages(metadata.ErrorPages, metadata.Subfiles)
+	if err != nil {
+		return errors.Compose(ErrInvalidErrorPages, err)
+	}
+
 	return nil
 }
 
@@ -383,3 +420,36 @@ func validateDefaultPath(defaultPath string, subfiles SkyfileSubfiles) (string,
 
 	return defaultPath, nil
 }
+
+// validateTryFiles ensures the given try files make sense in relation to the
+// subfiles being uploaded. Entries without the SkyfileTryFilesURI placeholder
+// always resolve to the same path, so they have to point to an existing file.
+func validateTryFiles(tryFiles []string, subfiles SkyfileSubfiles) error {
+	for _, tf := range tryFiles {
+		if strings.TrimSpace(tf) == "" {
+			return errors.New("try files can't contain an empty path")
+		}
+		if strings.Contains(tf, SkyfileTryFilesURI) {
+			continue
+		}
+		if _, found := subfiles[strings.TrimPrefix(tf, "/")]; !found {
+			return fmt.Errorf("no such path: %s", EnsurePrefix(tf, "/"))
+		}
+	}
+	return nil
+}
+
+// validateErrorPages ensures the given error pages map HTTP error status codes
+// to subfiles being uploaded. Error pages are only served for files that can't
+// be found, so 404 is the only supported status code.
+func validateErrorPages(errorPages map[int]string, subfiles SkyfileSubfiles) error {
+	for status, errorPage := range errorPages {
+		if status != http.StatusNotFound {
+			return fmt.Errorf("invalid status code %v, error pages can only be set for status code %v", status, http.StatusNotFound)
+		}
+		if _, found := subfiles[strings.TrimPrefix(errorPage, "/")]; !found {
+			return fmt.Errorf("no such path for status code %v: %s", status, EnsurePrefix(errorPage, "/"))
+		}
+	}
+	return nil
+}
@@ -184,6 +184,60 @@ func testValidateSkyfileMetadata(t *testing.T) {
 	if err != nil {
 		t.Fatal("unexpected outcome")
 	}
+
+	// verify valid try files and error pages
+	valid := metadata
+	valid.TryFiles = []string{SkyfileTryFilesURI, "/validkey"}
+	valid.ErrorPages = map[int]string{404: "/validkey"}
+	err = ValidateSkyfileMetadata(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// verify try files can't be combined with a default path
+	invalid = valid
+	invalid.DefaultPath = "/validkey"
+	err = ValidateSkyfileMetadata(invalid)
+	if !errors.Contains(err, ErrInvalidTryFiles) {
+		t.Fatal("unexpected outcome")
+	}
+	invalid = valid
+	invalid.DisableDefaultPath = true
+	err = ValidateSkyfileMetadata(invalid)
+	if !errors.Contains(err, ErrInvalidTryFiles) {
+		t.Fatal("unexpected outcome")
+	}
+
+	// verify invalid try files
+	invalid = valid
+	invalid.TryFiles = []string{SkyfileTryFilesURI, "/missing"}
+	err = ValidateSkyfileMetadata(invalid)
+	if !errors.Contains(err, ErrInvalidTryFiles) {
+		t.Fatal("unexpected outcome")
+	}
+	invalid.TryFiles = []string{" "}
+	err = ValidateSkyfileMetadata(invalid)
+	if !errors.Contains(err, ErrInvalidTryFiles) {
+		t.Fatal("unexpected outcome")
+	}
+
+	// verify invalid error pages
+	invalid = valid
+	invalid.ErrorPages = map[int]string{404: "/missing"}
+	err = ValidateSkyfileMetadata(invalid)
+	if !errors.Contains(err, ErrInvalidErrorPages) {
+		t.Fatal("unexpected outcome")
+	}
+	invalid.ErrorPages = map[int]string{200: "/validkey"}
+	err = ValidateSkyfileMetadata(invalid)
+	if !errors.Contains(err, ErrInvalidErrorPages) {
+		t.Fatal("unexpected outcome")
+	}
+	invalid.ErrorPages = map[int]string{500: "/validkey"}
+	err = ValidateSkyfileMetadata(invalid)
+	if !errors.Contains(err, ErrInvalidErrorPages) {
+		t.Fatal("unexpected outcome")
+	}
 }
 
 // testEnsurePrefix ensures EnsurePrefix is properly adding prefixes.
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/types"
 )
 
 const (
@@ -58,6 +59,17 @@ type (
 		// is expected to only store the data once.
 		AddSector(sectorRoot crypto.Hash, sectorData []byte) error
 
+		// AddEphemeralSector adds a sector to the storage manager which isn't
+		// covered by a file contract. The sector will be removed by
+		// RemoveExpiredEphemeralSectors once the expiry height is reached. If
+		// the sector is already stored as an ephemeral sector, its expiry is
+		// extended.
+		AddEphemeralSector(sectorRoot crypto.Hash, sectorData []byte, expiry types.BlockHeight) error
+
+		// EphemeralSectorExpiry returns the expiry height of an ephemeral
+		// sector and whether it exists.
+		EphemeralSectorExpiry(sectorRoot crypto.Hash) (types.BlockHeight, bool)
+
 		// HasSector indicates whether the contract manager stores a sector with
 		// a given root or not.
 		HasSector(crypto.Hash) bool
@@ -104,6 +116,10 @@ type (
 		// necessary when clearing out an entire contract from the host.
 		RemoveSectorBatch(sectorRoots []crypto.Hash) error
 
+		// RemoveExpiredEphemeralSectors removes all ephemeral sectors which
+		// expire at or before the provided height.
+		RemoveExpiredEphemeralSectors(height types.BlockHeight) error
+
 		// RemoveStorageFolder will remove a storage folder from the manager.
 		// All storage on the folder will be moved to other storage folders,
 		// meaning that no data will be lost. If the manager is unable to save
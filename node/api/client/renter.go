@@ -1,9 +1,11 @@
 package client
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"math"
+	"net/http"
 	"net/url"
 	"os"
 	"strconv"
@@ -177,6 +179,22 @@ func (c *Client) RenterContractCancelPost(id types.FileContractID) (err error) {
 	return
 }
 
+// RenterDrainGet uses the /renter/drain endpoint to get the progress of the
+// hosts which are being drained.
+func (c *Client) RenterDrainGet() (rdg api.RenterDrainGET, err error) {
+	err = c.get("/renter/drain", &rdg)
+	return
+}
+
+// RenterDrainPost uses the /renter/drain endpoint to drain the host with the
+// given public key.
+func (c *Client) RenterDrainPost(hostKey types.SiaPublicKey) (err error) {
+	values := url.Values{}
+	values.Set("hostpubkey", hostKey.String())
+	err = c.post("/renter/drain", values.Encode(), nil)
+	return
+}
+
 // RenterAllContractsGet requests the /renter/contracts resource with all
 // options set to true
 func (c *Client) RenterAllContractsGet() (rc api.RenterContracts, err error) {
@@ -263,6 +281,13 @@ func (c *Client) RenterCancelDownloadPost(id modules.DownloadID) (err error) {
 	return
 }
 
+// RenterResumeDownloadPost resumes the download with the given id
+// asynchronously.
+func (c *Client) RenterResumeDownloadPost(id modules.DownloadID) (err error) {
+	err = c.post(fmt.Sprintf("/renter/download/resume/%s", id), "", nil)
+	return
+}
+
 // RenterFileDeleteRootPost uses the /renter/delete endpoint to delete a file.
 // It passes the `root=true` flag to indicate an absolute path.
 func (c *Client) RenterFileDeleteRootPost(siaPath modules.SiaPath) (err error) {
@@ -327,6 +352,24 @@ func (c *Client) RenterCreateBackupPost(name string) (err error) {
 	return
 }
 
+// RenterBackupScheduleGet returns the renter's backup schedule.
+func (c *Client) RenterBackupScheduleGet() (bss modules.BackupScheduleStatus, err error) {
+	err = c.get("/renter/backups/schedule", &bss)
+	return
+}
+
+// RenterBackupSchedulePost replaces the renter's backup schedule.
+func (c *Client) RenterBackupSchedulePost(bs modules.BackupSchedule) (err error) {
+	values := url.Values{}
+	values.Set("schedule", bs.Schedule)
+	values.Set("nametemplate", bs.NameTemplate)
+	values.Set("keepdaily", fmt.Sprint(bs.KeepDaily))
+	values.Set("keeplast", fmt.Sprint(bs.KeepLast))
+	values.Set("keepweekly", fmt.Sprint(bs.KeepWeekly))
+	err = c.post("/renter/backups/schedule", values.Encode(), nil)
+	return
+}
+
 // RenterRecoverBackupPost downloads and restores the specified backup.
 func (c *Client) RenterRecoverBackupPost(name string) (err error) {
 	values := url.Values{}
@@ -372,6 +415,23 @@ func (c *Client) RenterDownloadFullGet(siaPath modules.SiaPath, destination stri
 	return modules.DownloadID(h.Get("ID")), nil
 }
 
+// RenterDownloadVerifiedGet uses the /renter/download endpoint to download a
+// full file and verify it against the file's content hash.
+func (c *Client) RenterDownloadVerifiedGet(siaPath modules.SiaPath, destination string, async, root bool) (modules.DownloadID, error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("httpresp", fmt.Sprint(false))
+	values.Set("async", fmt.Sprint(async))
+	values.Set("root", fmt.Sprint(root))
+	values.Set("verifycontenthash", fmt.Sprint(true))
+	h, _, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
+	if err != nil {
+		return "", err
+	}
+	return modules.DownloadID(h.Get("ID")), nil
+}
+
 // RenterClearAllDownloadsPost requests the /renter/downloads/clear resource
 // with no parameters
 func (c *Client) RenterClearAllDownloadsPost() (err error) {
@@ -452,6 +512,23 @@ func (c *Client) RenterFileGet(siaPath modules.SiaPath) (rf api.RenterFile, err
 	return
 }
 
+// RenterVersionsGet requests the /renter/versions/:siapath resource.
+func (c *Client) RenterVersionsGet(siaPath modules.SiaPath) (rfv api.RenterFileVersions, err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.get("/renter/versions/"+sp, &rfv)
+	return
+}
+
+// RenterVersionsRestorePost uses the /renter/versions/:siapath endpoint to
+// restore a version of a file.
+func (c *Client) RenterVersionsRestorePost(siaPath modules.SiaPath, version string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("version", version)
+	err = c.post("/renter/versions/"+sp, values.Encode(), nil)
+	return
+}
+
 // RenterFilesGet requests the /renter/files resource.
 func (c *Client) RenterFilesGet(cached bool) (rf api.RenterFiles, err error) {
 	err = c.get("/renter/files?cached="+fmt.Sprint(cached), &rf)
@@ -505,6 +582,15 @@ func (c *Client) RenterRateLimitPost(readBPS, writeBPS int64) (err error) {
 	return
 }
 
+// RenterSkynetCacheSizePost uses the /renter endpoint to change the max size
+// of the renter's skynet cache.
+func (c *Client) RenterSkynetCacheSizePost(size uint64) (err error) {
+	values := url.Values{}
+	values.Set("skynetcachesize", strconv.FormatUint(size, 10))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterRenamePost uses the /renter/rename/:siapath endpoint to rename a file.
 func (c *Client) RenterRenamePost(siaPathOld, siaPathNew modules.SiaPath, root bool) (err error) {
 	spo := escapeSiaPath(siaPathOld)
@@ -555,6 +641,39 @@ func (c *Client) RenterStreamPartialGet(siaPath modules.SiaPath, start, end uint
 	return
 }
 
+// RenterSignedStreamGet downloads data from a signed stream URL.
+func (c *Client) RenterSignedStreamGet(streamURL string) (resp []byte, err error) {
+	_, resp, err = c.getRawResponse(streamURL)
+	return
+}
+
+// RenterSignedStreamPartialGet downloads the bytes in the range [start, end)
+// from a signed stream URL.
+func (c *Client) RenterSignedStreamPartialGet(streamURL string, start, end uint64) (resp []byte, err error) {
+	return c.getRawPartialResponse(streamURL, start, end)
+}
+
+// RenterStreamURLPost uses the /renter/streamurl endpoint to create a signed
+// stream URL for a file or folder. A maxDownloads of 0 allows for an unlimited
+// number of downloads.
+func (c *Client) RenterStreamURLPost(siaPath modules.SiaPath, expiry time.Duration, maxDownloads uint64) (rsup api.RenterStreamURLPOST, err error) {
+	values := url.Values{}
+	values.Set("expiry", fmt.Sprint(uint64(expiry.Seconds())))
+	values.Set("maxdownloads", fmt.Sprint(maxDownloads))
+	sp := escapeSiaPath(siaPath)
+	err = c.post(fmt.Sprintf("/renter/streamurl/%s", sp), values.Encode(), &rsup)
+	return
+}
+
+// RenterRequireStreamSignaturePost uses the /renter endpoint to set whether
+// /renter/stream requires a signed URL or the API password.
+func (c *Client) RenterRequireStreamSignaturePost(require bool) (err error) {
+	values := url.Values{}
+	values.Set("requirestreamsignature", fmt.Sprint(require))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterSetRepairPathPost uses the /renter/tracking endpoint to set the repair
 // path of a file to a new location. The file at newPath must exists.
 func (c *Client) RenterSetRepairPathPost(siaPath modules.SiaPath, newPath string) (err error) {
@@ -576,6 +695,51 @@ func (c *Client) RenterSetFileStuckPost(siaPath modules.SiaPath, root, stuck boo
 	return
 }
 
+// RenterChangeRedundancyPost re-encodes the siafile at siaPath with the
+// provided erasure coding parameters.
+func (c *Client) RenterChangeRedundancyPost(siaPath modules.SiaPath, dataPieces, parityPieces uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	err = c.post(fmt.Sprintf("/renter/file/%v", sp), values.Encode(), nil)
+	return
+}
+
+// renterShareQuery returns the query for exporting the siafiles at the
+// provided siapaths using the /renter/share endpoint.
+func renterShareQuery(siaPaths []modules.SiaPath, ascii bool) string {
+	values := url.Values{}
+	for _, siaPath := range siaPaths {
+		values.Add("siapath", siaPath.String())
+	}
+	values.Set("ascii", fmt.Sprint(ascii))
+	return values.Encode()
+}
+
+// RenterShareGet uses the /renter/share endpoint to export the siafiles at
+// the provided siapaths into a share.
+func (c *Client) RenterShareGet(siaPaths []modules.SiaPath) ([]byte, error) {
+	_, data, err := c.getRawResponse("/renter/share?" + renterShareQuery(siaPaths, false))
+	return data, err
+}
+
+// RenterShareASCIIGet uses the /renter/share endpoint to export the siafiles
+// at the provided siapaths into an ASCII-armored share.
+func (c *Client) RenterShareASCIIGet(siaPaths []modules.SiaPath) (rsa api.RenterShareASCII, err error) {
+	err = c.get("/renter/share?"+renterShareQuery(siaPaths, true), &rsa)
+	return
+}
+
+// RenterSharePost uses the /renter/share endpoint to import the siafiles of a
+// share into the dir at the provided siapath.
+func (c *Client) RenterSharePost(dirSiaPath modules.SiaPath, data []byte) (err error) {
+	sp := escapeSiaPath(dirSiaPath)
+	headers := http.Header{"Content-Type": []string{"application/octet-stream"}}
+	_, _, err = c.postRawResponseWithHeaders(fmt.Sprintf("/renter/share/%s", sp), bytes.NewReader(data), headers)
+	return
+}
+
 // RenterUploadPost uses the /renter/upload endpoint to upload a file
 func (c *Client) RenterUploadPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64) (err error) {
 	return c.RenterUploadForcePost(path, siaPath, dataPieces, parityPieces, false)
@@ -669,6 +833,32 @@ func (c *Client) RenterDirRenamePost(siaPath, newSiaPath modules.SiaPath) (err e
 	return
 }
 
+// RenterDirSetVersioningPost uses the /renter/dir/ endpoint to update the
+// versioning settings of a directory.
+func (c *Client) RenterDirSetVersioningPost(siaPath modules.SiaPath, maxVersions, retentionDays uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setversioning")
+	values.Set("maxversions", fmt.Sprint(maxVersions))
+	values.Set("versionretentiondays", fmt.Sprint(retentionDays))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterDirSetUploadPolicyPost uses the /renter/dir/ endpoint to update the
+// upload policy of a directory.
+func (c *Client) RenterDirSetUploadPolicyPost(siaPath modules.SiaPath, policy modules.UploadPolicy) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setuploadpolicy")
+	values.Set("datapieces", strconv.Itoa(policy.DataPieces))
+	values.Set("paritypieces", strconv.Itoa(policy.ParityPieces))
+	values.Set("ciphertype", policy.CipherType)
+	values.Set("repairpriority", policy.RepairPriority.String())
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterDirRootGet uses the /renter/dir/ endpoint to query a directory,
 // starting from the root path.
 func (c *Client) RenterDirRootGet(siaPath modules.SiaPath) (rd api.RenterDirectory, err error) {
@@ -1,14 +1,18 @@
 package client
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"gitlab.com/NebulousLabs/Sia/crypto"
@@ -48,6 +52,13 @@ func (uc *UnsafeClient) SkynetSkylinkGetWithETag(skylink string, eTag string) (*
 	return uc.GetWithHeaders(skylinkQueryWithValues(skylink, url.Values{}), http.Header{"If-None-Match": []string{eTag}})
 }
 
+// SkynetSkylinkGetWithHeaders uses the /skynet/skylink endpoint to download a
+// skylink file using the given request headers. This function is unsafe as it
+// returns the raw response.
+func (uc *UnsafeClient) SkynetSkylinkGetWithHeaders(skylink string, headers http.Header) (*http.Response, error) {
+	return uc.GetWithHeaders(skylinkQueryWithValues(skylink, url.Values{}), headers)
+}
+
 // SkynetSkyfilePostRawResponse uses the /skynet/skyfile endpoint to upload a
 // skyfile.  This function is unsafe as it returns the raw response alongside
 // the http headers.
@@ -65,6 +76,9 @@ func (uc *UnsafeClient) SkynetSkyfilePostRawResponse(params modules.SkyfileUploa
 	values.Set("basechunkredundancy", redundancyStr)
 	rootStr := fmt.Sprintf("%t", params.Root)
 	values.Set("root", rootStr)
+	if params.Compression != "" {
+		values.Set("compression", params.Compression)
+	}
 
 	// Encode SkykeyName or SkykeyID.
 	if params.SkykeyName != "" {
@@ -228,6 +242,14 @@ func (c *Client) SkynetSkylinkReaderGet(skylink string) (io.ReadCloser, error) {
 	return reader, errors.AddContext(err, "unable to fetch skylink data")
 }
 
+// SkynetSkylinkReaderGetWithHeader uses the /skynet/skylink endpoint to fetch
+// a reader of the file data as well as the response headers.
+func (c *Client) SkynetSkylinkReaderGetWithHeader(skylink string) (http.Header, io.ReadCloser, error) {
+	getQuery := fmt.Sprintf("/skynet/skylink/%s", skylink)
+	header, reader, err := c.getReaderResponse(getQuery)
+	return header, reader, errors.AddContext(err, "unable to fetch skylink data")
+}
+
 // SkynetSkylinkConcatReaderGet uses the /skynet/skylink endpoint to fetch a
 // reader of the file data with the 'concat' format specified.
 func (c *Client) SkynetSkylinkConcatReaderGet(skylink string) (io.ReadCloser, error) {
@@ -291,6 +313,30 @@ func (c *Client) SkynetSkylinkPinPostWithTimeout(skylink string, params modules.
 	return nil
 }
 
+// SkynetSkylinkHealthGet uses the /skynet/health endpoint to get a report on
+// how well the data of the given skylink is available on the network.
+func (c *Client) SkynetSkylinkHealthGet(skylink string) (health modules.SkylinkHealth, err error) {
+	err = c.get(fmt.Sprintf("/skynet/health/%s", skylink), &health)
+	return
+}
+
+// SkynetSkylinkUnpinPost uses the /skynet/unpin endpoint to delete all
+// siafiles that store the given skylink. The siapaths of the deleted siafiles
+// and of the shared siafiles the skylink was removed from are returned.
+func (c *Client) SkynetSkylinkUnpinPost(skylink string) (api.SkynetSkylinkUnpinPOST, error) {
+	query := fmt.Sprintf("/skynet/unpin/%s", skylink)
+	_, resp, err := c.postRawResponse(query, nil)
+	if err != nil {
+		return api.SkynetSkylinkUnpinPOST{}, errors.AddContext(err, "post call to "+query+" failed")
+	}
+	var ssup api.SkynetSkylinkUnpinPOST
+	err = json.Unmarshal(resp, &ssup)
+	if err != nil {
+		return api.SkynetSkylinkUnpinPOST{}, errors.AddContext(err, "unable to parse the unpin response")
+	}
+	return ssup, nil
+}
+
 // SkynetSkyfilePost uses the /skynet/skyfile endpoint to upload a skyfile.  The
 // resulting skylink is returned along with an error.
 func (c *Client) SkynetSkyfilePost(params modules.SkyfileUploadParameters) (string, api.SkynetSkyfileHandlerPOST, error) {
@@ -307,6 +353,9 @@ func (c *Client) SkynetSkyfilePost(params modules.SkyfileUploadParameters) (stri
 	values.Set("basechunkredundancy", redundancyStr)
 	rootStr := fmt.Sprintf("%t", params.Root)
 	values.Set("root", rootStr)
+	if params.Compression != "" {
+		values.Set("compression", params.Compression)
+	}
 
 	// Encode SkykeyName or SkykeyID.
 	if params.SkykeyName != "" {
@@ -371,6 +420,37 @@ func (c *Client) SkynetSkyfilePostDisableForce(params modules.SkyfileUploadParam
 	return rshp.Skylink, rshp, err
 }
 
+// SkynetSkyfileTemporaryPost uses the /skynet/skyfile endpoint to upload a
+// temporary skyfile which is stored by the hosts for the given duration. The
+// resulting skylink is returned along with an error.
+func (c *Client) SkynetSkyfileTemporaryPost(params modules.SkyfileUploadParameters, duration types.BlockHeight) (string, api.SkynetSkyfileHandlerPOST, error) {
+	// Set the url values.
+	values := url.Values{}
+	values.Set("filename", params.Filename)
+	dryRunStr := fmt.Sprintf("%t", params.DryRun)
+	values.Set("dryrun", dryRunStr)
+	modeStr := fmt.Sprintf("%o", params.Mode)
+	values.Set("mode", modeStr)
+	redundancyStr := fmt.Sprintf("%v", params.BaseChunkRedundancy)
+	values.Set("basechunkredundancy", redundancyStr)
+	values.Set("temporaryduration", fmt.Sprint(duration))
+
+	// Make the call to upload the file.
+	query := fmt.Sprintf("/skynet/skyfile/%s?%s", params.SiaPath.String(), values.Encode())
+	_, resp, err := c.postRawResponse(query, params.Reader)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, errors.AddContext(err, "post call to "+query+" failed")
+	}
+
+	// Parse the response to get the skylink.
+	var rshp api.SkynetSkyfileHandlerPOST
+	err = json.Unmarshal(resp, &rshp)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, errors.AddContext(err, "unable to parse the skylink upload response")
+	}
+	return rshp.Skylink, rshp, err
+}
+
 // SkynetSkyfileMultiPartPost uses the /skynet/skyfile endpoint to upload a
 // skyfile using multipart form data.  The resulting skylink is returned along
 // with an error.
@@ -397,6 +477,10 @@ func (c *Client) SkynetSkyfileMultiPartEncryptedPost(params modules.SkyfileMulti
 	if skykeyID != (skykey.SkykeyID{}) {
 		values.Set("skykeyid", skykeyID.ToString())
 	}
+	err := setRoutingValues(values, params.TryFiles, params.ErrorPages)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, err
+	}
 
 	// Make the call to upload the file.
 	query := fmt.Sprintf("/skynet/skyfile/%s?%s", params.SiaPath.String(), values.Encode())
@@ -416,6 +500,115 @@ func (c *Client) SkynetSkyfileMultiPartEncryptedPost(params modules.SkyfileMulti
 	return rshp.Skylink, rshp, err
 }
 
+// SkynetSkyfileArchivePost uses the /skynet/skyfile endpoint to upload an
+// archive of the given format which is expanded into a multi-file skyfile. The
+// resulting skylink is returned along with an error.
+func (c *Client) SkynetSkyfileArchivePost(params modules.SkyfileUploadParameters, format modules.SkyfileFormat) (string, api.SkynetSkyfileHandlerPOST, error) {
+	// Set the url values.
+	values := url.Values{}
+	values.Set("filename", params.Filename)
+	values.Set("format", string(format))
+	values.Set("disabledefaultpath", strconv.FormatBool(params.DisableDefaultPath))
+	values.Set("defaultpath", params.DefaultPath)
+	dryRunStr := fmt.Sprintf("%t", params.DryRun)
+	values.Set("dryrun", dryRunStr)
+	forceStr := fmt.Sprintf("%t", params.Force)
+	values.Set("force", forceStr)
+	redundancyStr := fmt.Sprintf("%v", params.BaseChunkRedundancy)
+	values.Set("basechunkredundancy", redundancyStr)
+	rootStr := fmt.Sprintf("%t", params.Root)
+	values.Set("root", rootStr)
+	if params.Compression != "" {
+		values.Set("compression", params.Compression)
+	}
+	err := setRoutingValues(values, params.TryFiles, params.ErrorPages)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, err
+	}
+
+	// Make the call to upload the archive.
+	query := fmt.Sprintf("/skynet/skyfile/%s?%s", params.SiaPath.String(), values.Encode())
+	headers := http.Header{"Content-Type": []string{"application/octet-stream"}}
+	_, resp, err := c.postRawResponseWithHeaders(query, params.Reader, headers)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, errors.AddContext(err, "post call to "+query+" failed")
+	}
+
+	// Parse the response to get the skylink.
+	var rshp api.SkynetSkyfileHandlerPOST
+	err = json.Unmarshal(resp, &rshp)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, errors.AddContext(err, "unable to parse the skylink upload response")
+	}
+	return rshp.Skylink, rshp, err
+}
+
+// setRoutingValues sets the 'tryfiles' and 'errorpages' url values if they are
+// not empty.
+func setRoutingValues(values url.Values, tryFiles []string, errorPages map[int]string) error {
+	if len(tryFiles) > 0 {
+		tryFilesStr, err := json.Marshal(tryFiles)
+		if err != nil {
+			return errors.AddContext(err, "unable to marshal try files")
+		}
+		values.Set("tryfiles", string(tryFilesStr))
+	}
+	if len(errorPages) > 0 {
+		errorPagesStr, err := json.Marshal(errorPages)
+		if err != nil {
+			return errors.AddContext(err, "unable to marshal error pages")
+		}
+		values.Set("errorpages", string(errorPagesStr))
+	}
+	return nil
+}
+
+// SkynetPackPost uses the /skynet/pack endpoint to upload a batch of small
+// files which are packed into as few sectors as possible. The resulting
+// skylinks are returned in the same order as the files.
+func (c *Client) SkynetPackPost(sup modules.SkyfileUploadParameters, files []modules.SkyfilePackedFile) (api.SkynetPackHandlerPOST, error) {
+	// Set the url values.
+	values := url.Values{}
+	forceStr := fmt.Sprintf("%t", sup.Force)
+	values.Set("force", forceStr)
+	redundancyStr := fmt.Sprintf("%v", sup.BaseChunkRedundancy)
+	values.Set("basechunkredundancy", redundancyStr)
+	rootStr := fmt.Sprintf("%t", sup.Root)
+	values.Set("root", rootStr)
+	dryRunStr := fmt.Sprintf("%t", sup.DryRun)
+	values.Set("dryrun", dryRunStr)
+
+	// Create the multipart form.
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for _, file := range files {
+		_, err := modules.AddMultipartFile(writer, file.Data, "files[]", file.Filename, uint64(file.Mode), nil)
+		if err != nil {
+			return api.SkynetPackHandlerPOST{}, errors.AddContext(err, "unable to add file to multipart form")
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		return api.SkynetPackHandlerPOST{}, errors.AddContext(err, "unable to close multipart writer")
+	}
+
+	// Make the call to upload the files.
+	query := fmt.Sprintf("/skynet/pack/%s?%s", sup.SiaPath.String(), values.Encode())
+	headers := http.Header{"Content-Type": []string{writer.FormDataContentType()}}
+	_, resp, err := c.postRawResponseWithHeaders(query, body, headers)
+	if err != nil {
+		return api.SkynetPackHandlerPOST{}, errors.AddContext(err, "post call to "+query+" failed")
+	}
+
+	// Parse the response to get the skylinks.
+	var rsphp api.SkynetPackHandlerPOST
+	err = json.Unmarshal(resp, &rsphp)
+	if err != nil {
+		return api.SkynetPackHandlerPOST{}, errors.AddContext(err, "unable to parse the packed upload response")
+	}
+	return rsphp, nil
+}
+
 // SkynetConvertSiafileToSkyfilePost uses the /skynet/skyfile endpoint to
 // convert an existing siafile to a skyfile. The input SiaPath 'convert' is the
 // siapath of the siafile that should be converted. The siapath provided inside
@@ -557,6 +750,43 @@ func (c *Client) SkykeyGetByID(id skykey.SkykeyID) (skykey.Skykey, error) {
 	return sk, nil
 }
 
+// SkykeyGetPublicHalfByName requests the /skynet/skykey Get endpoint using the
+// key name and returns the public half of the X25519 skykey.
+func (c *Client) SkykeyGetPublicHalfByName(name string) (skykey.Skykey, error) {
+	values := url.Values{}
+	values.Set("name", name)
+	return c.skykeyGetPublicHalf(values)
+}
+
+// SkykeyGetPublicHalfByID requests the /skynet/skykey Get endpoint using the
+// key ID and returns the public half of the X25519 skykey.
+func (c *Client) SkykeyGetPublicHalfByID(id skykey.SkykeyID) (skykey.Skykey, error) {
+	values := url.Values{}
+	values.Set("id", id.ToString())
+	return c.skykeyGetPublicHalf(values)
+}
+
+// skykeyGetPublicHalf requests the /skynet/skykey Get endpoint with the
+// publickey flag set and the given values.
+func (c *Client) skykeyGetPublicHalf(values url.Values) (skykey.Skykey, error) {
+	values.Set("publickey", "true")
+	getQuery := fmt.Sprintf("/skynet/skykey?%s", values.Encode())
+
+	var skykeyGet api.SkykeyGET
+	err := c.get(getQuery, &skykeyGet)
+	if err != nil {
+		return skykey.Skykey{}, err
+	}
+
+	var sk skykey.Skykey
+	err = sk.FromString(skykeyGet.Skykey)
+	if err != nil {
+		return skykey.Skykey{}, err
+	}
+
+	return sk, nil
+}
+
 // SkykeyDeleteByIDPost requests the /skynet/deleteskykey POST endpoint using the key ID.
 func (c *Client) SkykeyDeleteByIDPost(id skykey.SkykeyID) error {
 	values := url.Values{}
@@ -669,6 +899,80 @@ func (c *Client) RegistryReadWithTimeout(spk types.SiaPublicKey, dataKey crypto.
 	return modules.NewSignedRegistryValue(dataKey, data, rhg.Revision, sig), nil
 }
 
+// RegistrySubscription is a stream of registry notifications returned by the
+// /skynet/registry/subscription [GET] endpoint.
+type RegistrySubscription struct {
+	staticBody   io.ReadCloser
+	staticReader *bufio.Reader
+}
+
+// RegistrySubscribe queries the /skynet/registry/subscription [GET] endpoint
+// to subscribe to the entries with the given public keys and data keys.
+func (c *Client) RegistrySubscribe(spks []types.SiaPublicKey, dataKeys []crypto.Hash) (*RegistrySubscription, error) {
+	// Set the values.
+	values := url.Values{}
+	for _, spk := range spks {
+		values.Add("publickey", spk.String())
+	}
+	for _, dataKey := range dataKeys {
+		values.Add("datakey", dataKey.String())
+	}
+
+	// Send request.
+	_, body, err := c.getReaderResponse(fmt.Sprintf("/skynet/registry/subscription?%v", values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	return &RegistrySubscription{
+		staticBody:   body,
+		staticReader: bufio.NewReader(body),
+	}, nil
+}
+
+// Next blocks until the next notification is received and returns the public
+// key of the updated entry together with the new value.
+func (rs *RegistrySubscription) Next() (types.SiaPublicKey, modules.SignedRegistryValue, error) {
+	for {
+		line, err := rs.staticReader.ReadString('\n')
+		if err != nil {
+			return types.SiaPublicKey{}, modules.SignedRegistryValue{}, errors.AddContext(err, "failed to read event")
+		}
+		// Ignore everything but data lines. That includes keepalive
+		// comments and the empty lines separating events.
+		line = strings.TrimSuffix(line, "\n")
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var rsn api.RegistrySubscriptionNotification
+		err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &rsn)
+		if err != nil {
+			return types.SiaPublicKey{}, modules.SignedRegistryValue{}, errors.AddContext(err, "failed to decode notification")
+		}
+
+		// Decode data.
+		data, err := hex.DecodeString(rsn.Data)
+		if err != nil {
+			return types.SiaPublicKey{}, modules.SignedRegistryValue{}, errors.AddContext(err, "failed to decode data")
+		}
+		// Decode signature.
+		var sig crypto.Signature
+		sigBytes, err := hex.DecodeString(rsn.Signature)
+		if err != nil {
+			return types.SiaPublicKey{}, modules.SignedRegistryValue{}, errors.AddContext(err, "failed to decode signature")
+		}
+		if len(sigBytes) != len(sig) {
+			return types.SiaPublicKey{}, modules.SignedRegistryValue{}, fmt.Errorf("unexpected signature length %v != %v", len(sigBytes), len(sig))
+		}
+		copy(sig[:], sigBytes)
+		return rsn.PublicKey, modules.NewSignedRegistryValue(rsn.DataKey, data, rsn.Revision, sig), nil
+	}
+}
+
+// Close closes the subscription.
+func (rs *RegistrySubscription) Close() error {
+	return rs.staticBody.Close()
+}
+
 // RegistryUpdate queries the /skynet/registry [POST] endpoint.
 func (c *Client) RegistryUpdate(spk types.SiaPublicKey, dataKey crypto.Hash, revision uint64, sig crypto.Signature, skylink modules.Skylink) error {
 	req := api.RegistryHandlerRequestPOST{
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// decodingStreamer is a helper struct that wraps a modules.Streamer which
+// returns compressed data, and decodes that data on the fly. Since the size of
+// the decoded data is known up front, the decodingStreamer can be seeked like
+// any other streamer, which allows for serving range requests.
+//
+// Compressed data can't be seeked into, so whenever the offset is moved
+// backwards, the decoder is reset to the start of the compressed data. When the
+// offset is moved forwards, the decoded data in between is discarded.
+//
+// Note that the decodingStreamer is not thread safe, if you call Seek and Read
+// on it from different threads, you are going to have unexpected behavior.
+type decodingStreamer struct {
+	stream modules.Streamer
+	size   uint64
+	off    uint64
+
+	decoder    io.Reader
+	decoderOff uint64
+
+	staticNewDecoder func(io.Reader) (io.Reader, error)
+}
+
+// NewDecodingStreamer wraps the given modules.Streamer, which returns data
+// compressed with the given content encoding, and returns a modules.Streamer
+// that returns the decoded data. The size is the size of the decoded data.
+func NewDecodingStreamer(s modules.Streamer, contentEncoding string, size uint64) (modules.Streamer, error) {
+	if contentEncoding != modules.SkyfileContentEncodingGzip {
+		return nil, errors.AddContext(modules.ErrUnsupportedCompression, contentEncoding)
+	}
+	return &decodingStreamer{
+		stream: s,
+		size:   size,
+		staticNewDecoder: func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+	}, nil
+}
+
+// NewSubfilesDecodingStreamer wraps the given modules.Streamer, which returns
+// the data of the given subfiles in order of their offsets, and returns a
+// modules.Streamer that returns the concatenated decoded data of the subfiles.
+func NewSubfilesDecodingStreamer(s modules.Streamer, subfiles modules.SkyfileSubfiles) modules.Streamer {
+	var files []modules.SkyfileSubfileMetadata
+	var size uint64
+	for _, file := range subfiles {
+		files = append(files, file)
+		if file.ContentEncoding != "" {
+			size += file.UncompressedLen
+		} else {
+			size += file.Len
+		}
+	}
+	return &decodingStreamer{
+		stream: s,
+		size:   size,
+		staticNewDecoder: func(r io.Reader) (io.Reader, error) {
+			decoded, _ := decodeSubfiles(r, files)
+			return decoded, nil
+		},
+	}
+}
+
+// Read implements the io.Reader interface
+func (ds *decodingStreamer) Read(p []byte) (n int, err error) {
+	if ds.off >= ds.size {
+		return 0, io.EOF
+	}
+	if max := ds.size - ds.off; uint64(len(p)) > max {
+		p = p[0:max]
+	}
+
+	// Reset the decoder if it's not created yet or if it's past the offset.
+	if ds.decoder == nil || ds.decoderOff > ds.off {
+		_, err = ds.stream.Seek(0, io.SeekStart)
+		if err != nil {
+			return 0, errors.AddContext(err, "unable to seek to the start of the compressed data")
+		}
+		ds.decoder, err = ds.staticNewDecoder(ds.stream)
+		if err != nil {
+			return 0, errors.AddContext(err, "unable to create decoder")
+		}
+		ds.decoderOff = 0
+	}
+
+	// Discard the data in front of the offset.
+	if ds.decoderOff < ds.off {
+		var discarded int64
+		discarded, err = io.CopyN(ioutil.Discard, ds.decoder, int64(ds.off-ds.decoderOff))
+		ds.decoderOff += uint64(discarded)
+		if err != nil {
+			return 0, errors.AddContext(err, "unable to skip decoded data")
+		}
+	}
+
+	n, err = ds.decoder.Read(p)
+	ds.off += uint64(n)
+	ds.decoderOff += uint64(n)
+	if err == io.EOF && ds.off < ds.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
+
+// Seek implements the io.Seeker interface
+func (ds *decodingStreamer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += int64(ds.off)
+	case io.SeekEnd:
+		offset += int64(ds.size)
+	default:
+		return 0, errors.New("invalid value for 'whence' in call to seek")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("invalid offset")
+	}
+
+	ds.off = uint64(offset)
+	return offset, nil
+}
+
+// Close implements the io.Closer interface
+func (ds *decodingStreamer) Close() error {
+	return ds.stream.Close()
+}
+
+// decodeSubfiles takes a reader that returns the data of the given subfiles in
+// order of their offsets, and returns a reader that returns the decoded data
+// of these subfiles. The returned metadata describes the decoded subfiles.
+func decodeSubfiles(src io.Reader, files []modules.SkyfileSubfileMetadata) (io.Reader, []modules.SkyfileSubfileMetadata) {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Offset < files[j].Offset
+	})
+
+	var readers []io.Reader
+	var decoded []modules.SkyfileSubfileMetadata
+	var offset uint64
+	for _, file := range files {
+		readers = append(readers, &subfileDecoder{
+			src:      io.LimitReader(src, int64(file.Len)),
+			encoding: file.ContentEncoding,
+		})
+		if file.ContentEncoding != "" {
+			file.Len = file.UncompressedLen
+			file.ContentEncoding = ""
+			file.UncompressedLen = 0
+		}
+		file.Offset = offset
+		offset += file.Len
+		decoded = append(decoded, file)
+	}
+	return io.MultiReader(readers...), decoded
+}
+
+// subfileDecoder is a reader that decodes the data of a single subfile. The
+// decoder is created lazily since the subfiles are read one after another from
+// the same underlying reader.
+type subfileDecoder struct {
+	src      io.Reader
+	encoding string
+	decoder  io.Reader
+}
+
+// Read implements the io.Reader interface
+func (sd *subfileDecoder) Read(p []byte) (int, error) {
+	if sd.encoding == "" {
+		return sd.src.Read(p)
+	}
+	if sd.decoder == nil {
+		if sd.encoding != modules.SkyfileContentEncodingGzip {
+			return 0, errors.AddContext(modules.ErrUnsupportedCompression, sd.encoding)
+		}
+		gzr, err := gzip.NewReader(sd.src)
+		if err != nil {
+			return 0, errors.AddContext(err, "unable to create decoder")
+		}
+		sd.decoder = gzr
+	}
+	n, err := sd.decoder.Read(p)
+	if err == io.EOF {
+		// Make sure the next subfile starts at the right offset.
+		_, derr := io.Copy(ioutil.Discard, sd.src)
+		if derr != nil {
+			return n, derr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestDecodingStreamer verifies the decoding streamer properly returns the
+// decoded data at any offset.
+func TestDecodingStreamer(t *testing.T) {
+	data := fastrand.Bytes(10e3)
+	compressed := gzipBytes(t, data)
+
+	// an unsupported encoding should be rejected
+	_, err := NewDecodingStreamer(streamerFromSlice(compressed), "br", uint64(len(data)))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// read all data
+	streamer, err := NewDecodingStreamer(streamerFromSlice(compressed), modules.SkyfileContentEncodingGzip, uint64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allData, err := ioutil.ReadAll(streamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(allData, data) {
+		t.Fatal("Expected streamer to return all data")
+	}
+
+	// the size is reported by seeking to the end
+	size, err := streamer.Seek(0, io.SeekEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(data)) {
+		t.Fatal("unexpected size", size)
+	}
+
+	// seek backwards and forwards and read at random offsets
+	for i := 0; i < 10; i++ {
+		off := fastrand.Intn(len(data))
+		_, err = streamer.Seek(int64(off), io.SeekStart)
+		if err != nil {
+			t.Fatal(err)
+		}
+		length := fastrand.Intn(len(data)-off) + 1
+		buf := make([]byte, length)
+		_, err = io.ReadFull(streamer, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, data[off:off+length]) {
+			t.Fatal("unexpected data at offset", off)
+		}
+	}
+
+	// the streamer should not return data beyond the size
+	_, err = streamer.Seek(int64(len(data)), io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := streamer.Read(make([]byte, 10))
+	if err != io.EOF || n != 0 {
+		t.Fatal("expected EOF", n, err)
+	}
+
+	// a size that is larger than the decoded data should result in an error
+	streamer, err = NewDecodingStreamer(streamerFromSlice(compressed), modules.SkyfileContentEncodingGzip, uint64(len(data))+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(streamer)
+	if err != io.ErrUnexpectedEOF {
+		t.Fatal("expected ErrUnexpectedEOF", err)
+	}
+}
+
+// TestDecodeSubfiles verifies that decodeSubfiles decodes a mix of compressed
+// and uncompressed subfiles.
+func TestDecodeSubfiles(t *testing.T) {
+	data1 := fastrand.Bytes(100)
+	data2 := fastrand.Bytes(200)
+	data3 := fastrand.Bytes(300)
+	compressed1 := gzipBytes(t, data1)
+	compressed3 := gzipBytes(t, data3)
+
+	files := []modules.SkyfileSubfileMetadata{
+		{
+			Filename:        "file3",
+			ContentEncoding: modules.SkyfileContentEncodingGzip,
+			Offset:          uint64(len(compressed1) + len(data2)),
+			Len:             uint64(len(compressed3)),
+			UncompressedLen: uint64(len(data3)),
+		},
+		{
+			Filename:        "file1",
+			ContentEncoding: modules.SkyfileContentEncodingGzip,
+			Len:             uint64(len(compressed1)),
+			UncompressedLen: uint64(len(data1)),
+		},
+		{
+			Filename: "file2",
+			Offset:   uint64(len(compressed1)),
+			Len:      uint64(len(data2)),
+		},
+	}
+	src := bytes.NewReader(append(append(compressed1, data2...), compressed3...))
+	decoded, decodedFiles := decodeSubfiles(src, files)
+
+	allData, err := ioutil.ReadAll(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append(append(data1, data2...), data3...)
+	if !bytes.Equal(allData, expected) {
+		t.Fatal("unexpected decoded data")
+	}
+
+	// the returned metadata should describe the decoded files
+	var offset uint64
+	for i, data := range [][]byte{data1, data2, data3} {
+		file := decodedFiles[i]
+		if file.Offset != offset || file.Len != uint64(len(data)) || file.ContentEncoding != "" || file.UncompressedLen != 0 {
+			t.Fatal("unexpected decoded file", file)
+		}
+		offset += file.Len
+	}
+}
+
+// TestSubfilesDecodingStreamer verifies that the subfiles decoding streamer
+// returns the decoded data of the subfiles at any offset and that it can be
+// used to serve range requests.
+func TestSubfilesDecodingStreamer(t *testing.T) {
+	data1 := fastrand.Bytes(1000)
+	data2 := fastrand.Bytes(2000)
+	compressed1 := gzipBytes(t, data1)
+	subfiles := modules.SkyfileSubfiles{
+		"file1": {
+			Filename:        "file1",
+			ContentEncoding: modules.SkyfileContentEncodingGzip,
+			Len:             uint64(len(compressed1)),
+			UncompressedLen: uint64(len(data1)),
+		},
+		"file2": {
+			Filename: "file2",
+			Offset:   uint64(len(compressed1)),
+			Len:      uint64(len(data2)),
+		},
+	}
+	data := append(append([]byte{}, data1...), data2...)
+	newStreamer := func() modules.Streamer {
+		return NewSubfilesDecodingStreamer(streamerFromSlice(append(compressed1, data2...)), subfiles)
+	}
+
+	// the size is reported by seeking to the end
+	streamer := newStreamer()
+	size, err := streamer.Seek(0, io.SeekEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(data)) {
+		t.Fatal("unexpected size", size)
+	}
+
+	// seek backwards and forwards and read at random offsets
+	for i := 0; i < 10; i++ {
+		off := fastrand.Intn(len(data))
+		_, err = streamer.Seek(int64(off), io.SeekStart)
+		if err != nil {
+			t.Fatal(err)
+		}
+		length := fastrand.Intn(len(data)-off) + 1
+		buf := make([]byte, length)
+		_, err = io.ReadFull(streamer, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, data[off:off+length]) {
+			t.Fatal("unexpected data at offset", off)
+		}
+	}
+
+	// a range request spanning both subfiles should be served partially
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=900-1099")
+	rec := httptest.NewRecorder()
+	http.ServeContent(rec, req, "", time.Time{}, newStreamer())
+	if rec.Code != http.StatusPartialContent {
+		t.Fatal("unexpected status", rec.Code)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), data[900:1100]) {
+		t.Fatal("unexpected data")
+	}
+	if rec.Header().Get("Content-Type") == "" {
+		t.Fatal("content type should be sniffed")
+	}
+}
+
+// gzipBytes returns the given data compressed using gzip.
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	if _, err := gzw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -65,6 +65,10 @@ var (
 		Testing:  types.BlockHeight(1),
 	}).(types.BlockHeight)
 
+	// defaultStreamURLExpiry is the time after which a signed stream URL
+	// expires if no expiry is specified.
+	defaultStreamURLExpiry = 24 * time.Hour
+
 	// errNeedBothDataAndParityPieces is the error returned when only one of the
 	// erasure coding parameters is set
 	errNeedBothDataAndParityPieces = errors.New("must provide both the datapieces parameter and the paritypieces parameter if specifying erasure coding parameters")
@@ -163,11 +167,21 @@ type (
 		Downloads []DownloadInfo `json:"downloads"`
 	}
 
+	// RenterDrainGET lists the progress of the hosts which are being drained.
+	RenterDrainGET struct {
+		Drains []modules.HostDrainStatus `json:"drains"`
+	}
+
 	// RenterFile lists the file queried.
 	RenterFile struct {
 		File modules.FileInfo `json:"file"`
 	}
 
+	// RenterFileVersions lists the versions of the file queried.
+	RenterFileVersions struct {
+		Versions []modules.FileVersion `json:"versions"`
+	}
+
 	// RenterFiles lists the files known to the renter.
 	RenterFiles struct {
 		Files []modules.FileInfo `json:"files"`
@@ -200,6 +214,12 @@ type (
 		ASCIIsia string `json:"asciisia"`
 	}
 
+	// RenterStreamURLPOST contains a signed stream URL.
+	RenterStreamURLPOST struct {
+		StreamSignature modules.StreamSignature `json:"streamsignature"`
+		URL             string                  `json:"url"`
+	}
+
 	// RenterUploadedBackup describes an uploaded backup.
 	RenterUploadedBackup struct {
 		Name           string          `json:"name"`
@@ -234,17 +254,19 @@ type (
 
 	// DownloadInfo contains all client-facing information of a file.
 	DownloadInfo struct {
-		Destination     string          `json:"destination"`     // The destination of the download.
-		DestinationType string          `json:"destinationtype"` // Can be "file", "memory buffer", or "http stream".
-		Filesize        uint64          `json:"filesize"`        // DEPRECATED. Same as 'Length'.
-		Length          uint64          `json:"length"`          // The length requested for the download.
-		Offset          uint64          `json:"offset"`          // The offset within the siafile requested for the download.
-		SiaPath         modules.SiaPath `json:"siapath"`         // The siapath of the file used for the download.
+		Destination     string             `json:"destination"`     // The destination of the download.
+		DestinationType string             `json:"destinationtype"` // Can be "file", "memory buffer", or "http stream".
+		Filesize        uint64             `json:"filesize"`        // DEPRECATED. Same as 'Length'.
+		Length          uint64             `json:"length"`          // The length requested for the download.
+		Offset          uint64             `json:"offset"`          // The offset within the siafile requested for the download.
+		SiaPath         modules.SiaPath    `json:"siapath"`         // The siapath of the file used for the download.
+		UID             modules.DownloadID `json:"uid"`             // The unique identifier of the download.
 
 		Completed            bool      `json:"completed"`            // Whether or not the download has completed.
 		EndTime              time.Time `json:"endtime"`              // The time when the download fully completed.
 		Error                string    `json:"error"`                // Will be the empty string unless there was an error.
 		Received             uint64    `json:"received"`             // Amount of data confirmed and decoded.
+		Resumable            bool      `json:"resumable"`            // Whether or not the download can be resumed.
 		StartTime            time.Time `json:"starttime"`            // The time when the download was started.
 		StartTimeUnix        int64     `json:"starttimeunix"`        // The time when the download was started in unix format.
 		TotalDataTransferred uint64    `json:"totaldatatransferred"` // The total amount of data transferred, including negotiation, overdrive etc.
@@ -411,6 +433,49 @@ func (api *API) renterBackupsCreateHandlerPOST(w http.ResponseWriter, req *http.
 	WriteSuccess(w)
 }
 
+// renterBackupsScheduleHandlerGET handles the API calls to
+// /renter/backups/schedule
+func (api *API) renterBackupsScheduleHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	bss, err := api.renter.BackupSchedule()
+	if err != nil {
+		WriteError(w, Error{"failed to get backup schedule: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, bss)
+}
+
+// renterBackupsScheduleHandlerPOST handles the API calls to
+// /renter/backups/schedule
+func (api *API) renterBackupsScheduleHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	bs := modules.BackupSchedule{
+		Schedule:     req.FormValue("schedule"),
+		NameTemplate: req.FormValue("nametemplate"),
+	}
+	retention := []struct {
+		param string
+		value *uint64
+	}{
+		{"keepdaily", &bs.KeepDaily},
+		{"keeplast", &bs.KeepLast},
+		{"keepweekly", &bs.KeepWeekly},
+	}
+	for _, r := range retention {
+		if v := req.FormValue(r.param); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				WriteError(w, Error{fmt.Sprintf("unable to parse %v: %v", r.param, err)}, http.StatusBadRequest)
+				return
+			}
+			*r.value = n
+		}
+	}
+	if err := api.renter.SetBackupSchedule(bs); err != nil {
+		WriteError(w, Error{"failed to set backup schedule: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterBackupsRestoreHandlerGET handles the API calls to /renter/backups/restore
 func (api *API) renterBackupsRestoreHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Check that a name was specified.
@@ -883,6 +948,25 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		}
 		settings.MaxUploadSpeed = uploadSpeed
 	}
+	// Scan the skynet cache size. (optional parameter)
+	if s := req.FormValue("skynetcachesize"); s != "" {
+		var cacheSize uint64
+		if _, err := fmt.Sscan(s, &cacheSize); err != nil {
+			WriteError(w, Error{"unable to parse skynetcachesize: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.SkynetCacheSize = cacheSize
+	}
+
+	// Scan the requirestreamsignature flag.
+	if rss := req.FormValue("requirestreamsignature"); rss != "" {
+		var requireStreamSignature bool
+		if _, err := fmt.Sscan(rss, &requireStreamSignature); err != nil {
+			WriteError(w, Error{"unable to parse requirestreamsignature: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.RequireStreamSignature = requireStreamSignature
+	}
 
 	// Scan the checkforipviolation flag.
 	if ipc := req.FormValue("checkforipviolation"); ipc != "" {
@@ -959,6 +1043,29 @@ func (api *API) renterContractCancelHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// renterDrainHandlerGET handles the API call to request the progress of the
+// hosts which are being drained.
+func (api *API) renterDrainHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterDrainGET{
+		Drains: api.renter.DrainStatus(),
+	})
+}
+
+// renterDrainHandlerPOST handles the API call to drain a host.
+func (api *API) renterDrainHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var hostKey types.SiaPublicKey
+	if err := hostKey.LoadString(req.FormValue("hostpubkey")); err != nil {
+		WriteError(w, Error{"unable to parse hostpubkey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err := api.renter.DrainHost(hostKey)
+	if err != nil {
+		WriteError(w, Error{"unable to drain host: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterContractsHandler handles the API call to request the Renter's
 // contracts. Active and renewed contracts are returned by default
 //
@@ -1224,11 +1331,13 @@ func (api *API) renterDownloadsHandler(w http.ResponseWriter, req *http.Request,
 			Length:          di.Length,
 			Offset:          di.Offset,
 			SiaPath:         di.SiaPath,
+			UID:             di.UID,
 
 			Completed:            di.Completed,
 			EndTime:              di.EndTime,
 			Error:                di.Error,
 			Received:             di.Received,
+			Resumable:            di.Resumable,
 			StartTime:            di.StartTime,
 			StartTimeUnix:        di.StartTimeUnix,
 			TotalDataTransferred: di.TotalDataTransferred,
@@ -1260,11 +1369,13 @@ func (api *API) renterDownloadByUIDHandlerGET(w http.ResponseWriter, _ *http.Req
 		Length:          di.Length,
 		Offset:          di.Offset,
 		SiaPath:         di.SiaPath,
+		UID:             di.UID,
 
 		Completed:            di.Completed,
 		EndTime:              di.EndTime,
 		Error:                di.Error,
 		Received:             di.Received,
+		Resumable:            di.Resumable,
 		StartTime:            di.StartTime,
 		StartTimeUnix:        di.StartTimeUnix,
 		TotalDataTransferred: di.TotalDataTransferred,
@@ -1450,6 +1561,169 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 	})
 }
 
+// renterVersionsHandlerGET handles GET requests to the
+// /renter/versions/:siapath API endpoint.
+func (api *API) renterVersionsHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Fetch the versions.
+	versions, err := api.renter.FileVersions(siaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to fetch versions: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if versions == nil {
+		versions = []modules.FileVersion{}
+	}
+	WriteJSON(w, RenterFileVersions{
+		Versions: versions,
+	})
+}
+
+// renterVersionsHandlerPOST handles POST requests to the
+// /renter/versions/:siapath API endpoint.
+func (api *API) renterVersionsHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	version := req.FormValue("version")
+	if version == "" {
+		WriteError(w, Error{"version not specified"}, http.StatusBadRequest)
+		return
+	}
+
+	// Restore the version.
+	err = api.renter.RestoreFileVersion(siaPath, version)
+	if errors.Contains(err, renter.ErrVersionNotFound) {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to restore version: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterShareHandlerGET handles GET requests to the /renter/share API
+// endpoint.
+func (api *API) renterShareHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var ascii bool
+	if asciiStr := req.FormValue("ascii"); asciiStr != "" {
+		ascii, err = strconv.ParseBool(asciiStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'ascii' arg: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var siaPaths []modules.SiaPath
+	for _, siaPathStr := range req.Form["siapath"] {
+		siaPath, err := modules.NewSiaPath(siaPathStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse siapath: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if !root {
+			siaPath, err = rebaseInputSiaPath(siaPath)
+			if err != nil {
+				WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		siaPaths = append(siaPaths, siaPath)
+	}
+	if len(siaPaths) == 0 {
+		WriteError(w, Error{"no siapath specified"}, http.StatusBadRequest)
+		return
+	}
+
+	// Export the files.
+	data, err := api.renter.ExportSiaFile(siaPaths, ascii)
+	if err != nil {
+		WriteError(w, Error{"failed to export files: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if ascii {
+		WriteJSON(w, RenterShareASCII{
+			ASCIIsia: string(data),
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(data)
+}
+
+// renterShareHandlerPOST handles POST requests to the /renter/share/:siapath
+// API endpoint.
+func (api *API) renterShareHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Read the share before parsing the form to avoid the body being parsed
+	// as a form.
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		WriteError(w, Error{"failed to read share: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse siapath: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Import the files.
+	if err := api.renter.ImportSiaFile(data, siaPath); err != nil {
+		WriteError(w, Error{"failed to import files: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterFileHandler handles POST requests to the /renter/file/:siapath API endpoint.
 func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	newTrackingPath := req.FormValue("trackingpath")
@@ -1490,6 +1764,18 @@ func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request,
 			return
 		}
 	}
+	// Handle changing the redundancy of a file.
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if ec != nil {
+		if err := api.renter.ChangeRedundancy(siaPath, ec); err != nil {
+			WriteError(w, Error{"failed to change file redundancy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	WriteSuccess(w)
 }
 
@@ -1712,6 +1998,36 @@ func (api *API) renterDownloadHandler(w http.ResponseWriter, req *http.Request,
 	}
 }
 
+// renterResumeDownloadHandler handles the API call to resume a download
+// asynchronously.
+func (api *API) renterResumeDownloadHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	id := modules.DownloadID(ps.ByName("uid"))
+	start, cancel, err := api.renter.ResumeDownload(id, func(_ error) error {
+		api.downloadMu.Lock()
+		delete(api.downloads, id)
+		api.downloadMu.Unlock()
+		return nil
+	})
+	if errors.Contains(err, renter.ErrDownloadNotResumable) {
+		WriteError(w, Error{"failed to resume download: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to resume download: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	// Add download to API's map for cancellation.
+	api.downloadMu.Lock()
+	api.downloads[id] = cancel
+	api.downloadMu.Unlock()
+	// Start download.
+	if err := start(); err != nil {
+		WriteError(w, Error{"download failed: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterDownloadAsyncHandler handles the API call to download a file asynchronously.
 func (api *API) renterDownloadAsyncHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	req.ParseForm()
@@ -1744,6 +2060,10 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 	// disk if available.
 	disablelocalfetchparam := req.FormValue("disablelocalfetch")
 
+	// verifycontenthashparam determines whether the downloaded data is
+	// verified against the content hash of the file.
+	verifycontenthashparam := req.FormValue("verifycontenthash")
+
 	// Parse the offset and length parameters.
 	var offset, length uint64
 	if len(offsetparam) > 0 {
@@ -1798,6 +2118,12 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		}
 	}
 
+	// Parse the verifycontenthash parameter.
+	verifyContentHash, err := scanBool(verifycontenthashparam)
+	if err != nil {
+		return modules.RenterDownloadParameters{}, errors.AddContext(err, "error parsing the verifycontenthash flag")
+	}
+
 	dp := modules.RenterDownloadParameters{
 		Destination:      destination,
 		DisableDiskFetch: disableLocalFetch,
@@ -1805,6 +2131,8 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		Length:           length,
 		Offset:           offset,
 		SiaPath:          siaPath,
+
+		VerifyContentHash: verifyContentHash,
 	}
 	if httpresp {
 		dp.Httpwriter = w
@@ -1833,6 +2161,31 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 			return
 		}
 	}
+	// Verify the signature of signed URLs. If the renter requires signatures,
+	// unsigned requests need to provide the API password instead.
+	sig, signed, err := parseStreamSignature(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if signed {
+		err = api.renter.VerifyStreamURL(siaPath, sig)
+		if err != nil {
+			WriteError(w, Error{"invalid stream url: " + err.Error()}, http.StatusForbidden)
+			return
+		}
+	} else if !api.hasPassword(req) {
+		settings, err := api.renter.Settings()
+		if err != nil {
+			WriteError(w, Error{"unable to get renter settings: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		if settings.RequireStreamSignature {
+			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
+			WriteError(w, Error{"streaming requires a signed url or the API password"}, http.StatusUnauthorized)
+			return
+		}
+	}
 	disablelocalfetchparam := req.FormValue("disablelocalfetch")
 	var disableLocalFetch bool
 	if disablelocalfetchparam != "" {
@@ -1852,7 +2205,150 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 	defer func() {
 		_ = streamer.Close()
 	}()
-	http.ServeContent(w, req, fileName, time.Time{}, streamer)
+	if !signed {
+		http.ServeContent(w, req, fileName, time.Time{}, streamer)
+		return
+	}
+	// Charge the bytes which were served to the signed URL's download limit.
+	// The response was already written, so the renter logs a failure to
+	// persist the charge.
+	cw := &countingResponseWriter{ResponseWriter: w}
+	http.ServeContent(cw, req, fileName, time.Time{}, streamer)
+	_ = api.renter.ChargeStreamURL(siaPath, sig, cw.served)
+}
+
+// renterStreamURLHandlerPOST handles the API call to create a signed URL for
+// /renter/stream which grants access to a file or folder without the API
+// password.
+func (api *API) renterStreamURLHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	inputSiaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := scanBool(req.FormValue("root"))
+	if err != nil {
+		err = errors.AddContext(err, "error parsing the root flag")
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath := inputSiaPath
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	expiry := defaultStreamURLExpiry
+	if e := req.FormValue("expiry"); e != "" {
+		seconds, err := strconv.ParseUint(e, 10, 64)
+		if err != nil || seconds == 0 {
+			WriteError(w, Error{"unable to parse expiry, it needs to be a positive number of seconds"}, http.StatusBadRequest)
+			return
+		}
+		expiry = time.Duration(seconds) * time.Second
+	}
+	var maxDownloads uint64
+	if md := req.FormValue("maxdownloads"); md != "" {
+		maxDownloads, err = strconv.ParseUint(md, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxdownloads: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	sig, err := api.renter.SignStreamURL(siaPath, time.Now().Add(expiry), maxDownloads)
+	if err != nil {
+		WriteError(w, Error{"unable to sign stream url: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	values := streamSignatureValues(sig)
+	if root {
+		values.Set("root", "true")
+	}
+	streamURL := url.URL{
+		Path:     "/renter/stream/" + inputSiaPath.String(),
+		RawQuery: values.Encode(),
+	}
+	WriteJSON(w, RenterStreamURLPOST{
+		StreamSignature: sig,
+		URL:             streamURL.String(),
+	})
+}
+
+// hasPassword returns whether the request authenticates with the API password.
+// Requests never authenticate if the API doesn't have a password.
+func (api *API) hasPassword(req *http.Request) bool {
+	_, pass, ok := req.BasicAuth()
+	return ok && api.requiredPassword != "" && pass == api.requiredPassword
+}
+
+// countingResponseWriter is a http.ResponseWriter which counts the bytes of
+// the body of successful responses. The bodies of error responses aren't
+// counted.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	served uint64
+	status int
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (cw *countingResponseWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (cw *countingResponseWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	n, err := cw.ResponseWriter.Write(b)
+	if cw.status == http.StatusOK || cw.status == http.StatusPartialContent {
+		cw.served += uint64(n)
+	}
+	return n, err
+}
+
+// parseStreamSignature parses the signature of a signed stream URL from the
+// query string. The returned bool indicates whether the URL is signed.
+func parseStreamSignature(req *http.Request) (sig modules.StreamSignature, signed bool, err error) {
+	sig.Signature = req.FormValue("signature")
+	if sig.Signature == "" {
+		return modules.StreamSignature{}, false, nil
+	}
+	sig.ID = req.FormValue("id")
+	sig.Expires, err = strconv.ParseInt(req.FormValue("expires"), 10, 64)
+	if err != nil {
+		return modules.StreamSignature{}, false, errors.AddContext(err, "unable to parse expires")
+	}
+	if md := req.FormValue("maxdownloads"); md != "" {
+		sig.MaxDownloads, err = strconv.ParseUint(md, 10, 64)
+		if err != nil {
+			return modules.StreamSignature{}, false, errors.AddContext(err, "unable to parse maxdownloads")
+		}
+	}
+	if scope := req.FormValue("scope"); scope != "" {
+		sig.Scope, err = modules.NewSiaPath(scope)
+		if err != nil {
+			return modules.StreamSignature{}, false, errors.AddContext(err, "unable to parse scope")
+		}
+	}
+	return sig, true, nil
+}
+
+// streamSignatureValues returns the query string values of a signed stream
+// URL.
+func streamSignatureValues(sig modules.StreamSignature) url.Values {
+	values := url.Values{}
+	values.Set("expires", strconv.FormatInt(sig.Expires, 10))
+	values.Set("id", sig.ID)
+	values.Set("maxdownloads", strconv.FormatUint(sig.MaxDownloads, 10))
+	values.Set("scope", sig.Scope.String())
+	values.Set("signature", sig.Signature)
+	return values
 }
 
 // renterUploadHandler handles the API call to upload a file.
@@ -1899,8 +2395,8 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		Force:               force,
 		DisablePartialChunk: true, // TODO: remove this
 
-		// NOTE: can make this an optional param.
-		CipherType: crypto.TypeDefaultRenter,
+		// NOTE: the cipher type is left blank to use the upload policy of the
+		// directory or the renter's default.
 	})
 	if err != nil {
 		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
@@ -2029,8 +2525,8 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		Force:       force,
 		Repair:      repair,
 
-		// NOTE: can make this an optional param.
-		CipherType: crypto.TypeDefaultRenter,
+		// NOTE: the cipher type is left blank to use the upload policy of the
+		// directory or the renter's default.
 	}
 	err = api.renter.UploadStreamFromReader(up, req.Body)
 	if err != nil {
@@ -2201,6 +2697,64 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 		WriteSuccess(w)
 		return
 	}
+	if action == "setversioning" {
+		var maxVersions, retentionDays uint64
+		if mv := req.FormValue("maxversions"); mv != "" {
+			maxVersions, err = strconv.ParseUint(mv, 10, 64)
+			if err != nil {
+				WriteError(w, Error{"failed to parse maxversions: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if rd := req.FormValue("versionretentiondays"); rd != "" {
+			retentionDays, err = strconv.ParseUint(rd, 10, 64)
+			if err != nil {
+				WriteError(w, Error{"failed to parse versionretentiondays: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		err = api.renter.SetDirVersioning(siaPath, maxVersions, retentionDays)
+		if err != nil {
+			WriteError(w, Error{"failed to set versioning: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+	if action == "setuploadpolicy" {
+		var policy modules.UploadPolicy
+		if dp := req.FormValue("datapieces"); dp != "" {
+			policy.DataPieces, err = strconv.Atoi(dp)
+			if err != nil {
+				WriteError(w, Error{"failed to parse datapieces: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if pp := req.FormValue("paritypieces"); pp != "" {
+			policy.ParityPieces, err = strconv.Atoi(pp)
+			if err != nil {
+				WriteError(w, Error{"failed to parse paritypieces: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		policy.CipherType = req.FormValue("ciphertype")
+		err = policy.RepairPriority.FromString(req.FormValue("repairpriority"))
+		if err != nil {
+			WriteError(w, Error{"failed to parse repairpriority: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err = policy.Validate(); err != nil {
+			WriteError(w, Error{"invalid upload policy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.SetDirUploadPolicy(siaPath, policy)
+		if err != nil {
+			WriteError(w, Error{"failed to set upload policy: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
 
 	// Report that no calls were made
 	WriteError(w, Error{"no calls were made, please check your submission and try again"}, http.StatusInternalServerError)
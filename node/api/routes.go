@@ -120,6 +120,8 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/backups", RequirePassword(api.renterBackupsHandlerGET, requiredPassword))
 		router.POST("/renter/backups/create", RequirePassword(api.renterBackupsCreateHandlerPOST, requiredPassword))
 		router.POST("/renter/backups/restore", RequirePassword(api.renterBackupsRestoreHandlerGET, requiredPassword))
+		router.GET("/renter/backups/schedule", RequirePassword(api.renterBackupsScheduleHandlerGET, requiredPassword))
+		router.POST("/renter/backups/schedule", RequirePassword(api.renterBackupsScheduleHandlerPOST, requiredPassword))
 		router.POST("/renter/clean", RequirePassword(api.renterCleanHandlerPOST, requiredPassword))
 		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
 		router.GET("/renter/contracts", api.renterContractsHandler)
@@ -128,9 +130,15 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/downloadinfo/*uid", api.renterDownloadByUIDHandlerGET)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
+		router.GET("/renter/drain", api.renterDrainHandlerGET)
+		router.POST("/renter/drain", RequirePassword(api.renterDrainHandlerPOST, requiredPassword))
 		router.GET("/renter/files", api.renterFilesHandler)
 		router.GET("/renter/file/*siapath", api.renterFileHandlerGET)
 		router.POST("/renter/file/*siapath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
+		router.GET("/renter/versions/*siapath", api.renterVersionsHandlerGET)
+		router.POST("/renter/versions/*siapath", RequirePassword(api.renterVersionsHandlerPOST, requiredPassword))
+		router.GET("/renter/share", RequirePassword(api.renterShareHandlerGET, requiredPassword))
+		router.POST("/renter/share/*siapath", RequirePassword(api.renterShareHandlerPOST, requiredPassword))
 		router.GET("/renter/prices", api.renterPricesHandler)
 		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
@@ -141,9 +149,11 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter/delete/*siapath", RequirePassword(api.renterDeleteHandler, requiredPassword))
 		router.GET("/renter/download/*siapath", RequirePassword(api.renterDownloadHandler, requiredPassword))
 		router.POST("/renter/download/cancel", RequirePassword(api.renterCancelDownloadHandler, requiredPassword))
+		router.POST("/renter/download/resume/:uid", RequirePassword(api.renterResumeDownloadHandler, requiredPassword))
 		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
 		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
+		router.POST("/renter/streamurl/*siapath", RequirePassword(api.renterStreamURLHandlerPOST, requiredPassword))
 		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
 		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
@@ -154,17 +164,21 @@ func (api *API) buildHTTPRoutes() {
 
 		// Skynet endpoints
 		router.GET("/skynet/basesector/*skylink", api.skynetBaseSectorHandlerGET)
+		router.GET("/skynet/health/:skylink", api.skynetSkylinkHealthHandlerGET)
 		router.GET("/skynet/blocklist", api.skynetBlocklistHandlerGET)
 		router.POST("/skynet/blocklist", RequirePassword(api.skynetBlocklistHandlerPOST, requiredPassword))
 		router.POST("/skynet/pin/:skylink", RequirePassword(api.skynetSkylinkPinHandlerPOST, requiredPassword))
+		router.POST("/skynet/unpin/:skylink", RequirePassword(api.skynetSkylinkUnpinHandlerPOST, requiredPassword))
 		router.GET("/skynet/portals", api.skynetPortalsHandlerGET)
 		router.POST("/skynet/portals", RequirePassword(api.skynetPortalsHandlerPOST, requiredPassword))
 		router.GET("/skynet/root", api.skynetRootHandlerGET)
 		router.GET("/skynet/skylink/*skylink", api.skynetSkylinkHandlerGET)
 		router.HEAD("/skynet/skylink/*skylink", api.skynetSkylinkHandlerGET)
 		router.POST("/skynet/skyfile/*siapath", RequirePassword(api.skynetSkyfileHandlerPOST, requiredPassword))
+		router.POST("/skynet/pack/*siapath", RequirePassword(api.skynetPackHandlerPOST, requiredPassword))
 		router.POST("/skynet/registry", RequirePassword(api.registryHandlerPOST, requiredPassword))
 		router.GET("/skynet/registry", api.registryHandlerGET)
+		router.GET("/skynet/registry/subscription", api.registrySubscriptionHandlerGET)
 		router.GET("/skynet/stats", api.skynetStatsHandlerGET)
 		router.GET("/skynet/skykey", RequirePassword(api.skykeyHandlerGET, requiredPassword))
 		router.POST("/skynet/addskykey", RequirePassword(api.skykeyAddKeyHandlerPOST, requiredPassword))
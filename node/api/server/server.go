@@ -20,6 +20,8 @@ import (
 	"gitlab.com/NebulousLabs/Sia/build"
 	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/s3"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/webdav"
 	"gitlab.com/NebulousLabs/Sia/node"
 	"gitlab.com/NebulousLabs/Sia/node/api"
 	"gitlab.com/NebulousLabs/Sia/types"
@@ -33,6 +35,8 @@ type Server struct {
 	listener          net.Listener
 	node              *node.Node
 	requiredUserAgent string
+	s3                *s3.Server
+	webdav            *webdav.Server
 	Dir               string
 
 	serveChan chan struct{}
@@ -67,6 +71,13 @@ func (srv *Server) Close() error {
 	if !errors.Contains(srv.serveErr, http.ErrServerClosed) {
 		err = errors.Compose(err, srv.serveErr)
 	}
+	// Stop the S3 and WebDAV servers before the renter shuts down.
+	if srv.s3 != nil {
+		err = errors.Compose(err, srv.s3.Close())
+	}
+	if srv.webdav != nil {
+		err = errors.Compose(err, srv.webdav.Close())
+	}
 	// Shutdown modules.
 	if srv.node != nil {
 		err = errors.Compose(err, srv.node.Close())
@@ -237,6 +248,26 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 		// Server wasn't shut down. Add node and replace modules.
 		srv.node = n
 		api.SetModules(n.ConsensusSet, n.Explorer, n.FeeManager, n.Gateway, n.Host, n.Miner, n.Renter, n.TransactionPool, n.Wallet)
+
+		// Start the WebDAV server if it is enabled in the config.
+		if cfg.WebDAVAddr != "" && n.Renter != nil {
+			srv.webdav, err = webdav.New(cfg.WebDAVAddr, cfg.WebDAVUser, cfg.WebDAVPassword, n.Renter)
+			if err != nil {
+				return nil, errors.AddContext(err, "unable to start the webdav server")
+			}
+		}
+
+		// Start the S3 server if it is enabled in the config.
+		if cfg.S3Addr != "" && n.Renter != nil {
+			persistDir := filepath.Join(nodeParams.Dir, modules.RenterDir)
+			srv.s3, err = s3.New(cfg.S3Addr, persistDir, cfg.S3Keys, n.Renter)
+			if err != nil {
+				if srv.webdav != nil {
+					err = errors.Compose(err, srv.webdav.Close())
+				}
+				return nil, errors.AddContext(err, "unable to start the s3 server")
+			}
+		}
 		return srv, nil
 	}()
 	if err != nil {
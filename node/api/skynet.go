@@ -2,6 +2,7 @@ package api
 
 import (
 	"compress/gzip"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -44,6 +45,17 @@ const (
 	MaxSkynetRequestTimeout = 15 * 60 // in seconds
 )
 
+var (
+	// registrySubscriptionKeepAliveInterval is the interval at which the
+	// /skynet/registry/subscription endpoint sends a comment to the client to
+	// keep the connection alive while no notifications are sent.
+	registrySubscriptionKeepAliveInterval = build.Select(build.Var{
+		Dev:      30 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
 type (
 	// SkynetSkyfileHandlerPOST is the response that the api returns after the
 	// /skynet/ POST endpoint has been used.
@@ -53,6 +65,28 @@ type (
 		Bitfield   uint16      `json:"bitfield"`
 	}
 
+	// SkynetSkylinkUnpinPOST is the response that the api returns after the
+	// /skynet/unpin/ POST endpoint has been used.
+	SkynetSkylinkUnpinPOST struct {
+		SiaPaths        []modules.SiaPath `json:"siapaths"`
+		UpdatedSiaPaths []modules.SiaPath `json:"updatedsiapaths"`
+	}
+
+	// SkynetPackHandlerPOST is the response that the api returns after the
+	// /skynet/pack/ POST endpoint has been used.
+	SkynetPackHandlerPOST struct {
+		Skyfiles []SkynetPackedSkyfile `json:"skyfiles"`
+	}
+
+	// SkynetPackedSkyfile contains the skylink of a single file of a packed
+	// upload.
+	SkynetPackedSkyfile struct {
+		Filename   string      `json:"filename"`
+		Skylink    string      `json:"skylink"`
+		MerkleRoot crypto.Hash `json:"merkleroot"`
+		Bitfield   uint16      `json:"bitfield"`
+	}
+
 	// SkynetBlocklistGET contains the information queried for the
 	// /skynet/blocklist GET endpoint
 	//
@@ -91,7 +125,8 @@ type (
 	// SkynetStatsGET contains the information queried for the /skynet/stats
 	// GET endpoint
 	SkynetStatsGET struct {
-		PerformanceStats SkynetPerformanceStats `json:"performancestats"`
+		CacheStats       modules.SkynetCacheStats `json:"cachestats"`
+		PerformanceStats SkynetPerformanceStats   `json:"performancestats"`
 
 		Uptime      int64         `json:"uptime"`
 		UploadStats SkynetStats   `json:"uploadstats"`
@@ -131,6 +166,17 @@ type (
 		Signature string `json:"signature"`
 	}
 
+	// RegistrySubscriptionNotification is the format of the events sent by
+	// the /skynet/registry/subscription endpoint whenever a subscribed to
+	// entry is updated.
+	RegistrySubscriptionNotification struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		DataKey   crypto.Hash        `json:"datakey"`
+		Data      string             `json:"data"`
+		Revision  uint64             `json:"revision"`
+		Signature string             `json:"signature"`
+	}
+
 	// RegistryHandlerRequestPOST is the expected format of the json request for
 	// /skynet/registry [POST].
 	RegistryHandlerRequestPOST struct {
@@ -570,8 +616,20 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		timeout = time.Duration(timeoutInt) * time.Second
 	}
 
+	// Resolve the skylink. If it is a v2 skylink, this will return the v1
+	// skylink it points to. All further operations use the resolved skylink.
+	resolvedSkylink, err := api.renter.ResolveSkylinkV2(skylink, timeout)
+	if errors.Contains(err, renter.ErrRegistryEntryNotFound) {
+		WriteError(w, Error{fmt.Sprintf("failed to resolve skylink: %v", err)}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to resolve skylink: %v", err)}, http.StatusInternalServerError)
+		return
+	}
+
 	// Fetch the skyfile's metadata and a streamer to download the file
-	metadata, streamer, err := api.renter.DownloadSkylink(skylink, timeout)
+	metadata, streamer, err := api.renter.DownloadSkylink(resolvedSkylink, timeout)
 	if errors.Contains(err, renter.ErrRootNotFound) {
 		WriteError(w, Error{fmt.Sprintf("failed to fetch skylink: %v", err)}, http.StatusNotFound)
 		return
@@ -593,8 +651,12 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		WriteError(w, Error{"invalid defaultpath state - both defaultpath and disabledefaultpath are set, please specify a format"}, http.StatusBadRequest)
 		return
 	}
+	if len(metadata.TryFiles) > 0 && (metadata.DefaultPath != "" || metadata.DisableDefaultPath) && format == modules.SkyfileFormatNotSpecified {
+		WriteError(w, Error{"invalid tryfiles state - tryfiles can't be combined with defaultpath or disabledefaultpath, please specify a format"}, http.StatusBadRequest)
+		return
+	}
 	defaultPath := metadata.DefaultPath
-	if metadata.DefaultPath == "" && !metadata.DisableDefaultPath {
+	if metadata.DefaultPath == "" && !metadata.DisableDefaultPath && len(metadata.TryFiles) == 0 {
 		if len(metadata.Subfiles) == 1 {
 			// If `defaultpath` and `disabledefaultpath` are not set and the
 			// skyfile has a single subfile we automatically default to it.
@@ -615,6 +677,8 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 
 	var isSubfile bool
 	responseContentType := metadata.ContentType()
+	var contentEncoding string
+	var decodedSize uint64
 
 	// Serve the contents of the file at the default path if one is set. Note
 	// that we return the metadata for the entire Skylink when we serve the
@@ -665,13 +729,46 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		}
 		isSubfile = isFile
 		responseContentType = metaForPath.ContentType()
+		contentEncoding, decodedSize = metaForPath.ContentEncoding()
+	}
+
+	// If the skyfile defines try files and the path doesn't match a file, the
+	// first try file that matches a file is served instead. The try files
+	// replace the default path, so they also apply to the root path.
+	servePath := path
+	if len(metadata.TryFiles) > 0 && format == modules.SkyfileFormatNotSpecified {
+		_, isFile, _, _ := metadata.ForPath(path)
+		tryPath, found := metadata.TryFile(path)
+		if !isFile && found {
+			// Skapps served at the root path need the trailing slash to
+			// work with relative paths, see the default path.
+			isSkapp := strings.HasSuffix(tryPath, ".html") || strings.HasSuffix(tryPath, ".htm")
+			if path == "/" && isSkapp && !attachment && req.Method == http.MethodGet && !strings.HasSuffix(skylinkStringNoQuery, "/") {
+				location := skylinkStringNoQuery + "/"
+				if req.URL.RawQuery != "" {
+					location += "?" + req.URL.RawQuery
+				}
+				w.Header().Set("Location", location)
+				w.WriteHeader(http.StatusTemporaryRedirect)
+				return
+			}
+			servePath = tryPath
+		}
 	}
 
 	// Serve the contents of the skyfile at path if one is set
-	if path != "/" {
-		metadataForPath, file, offset, size := metadata.ForPath(path)
+	if servePath != "/" {
+		metadataForPath, file, offset, size := metadata.ForPath(servePath)
 		if len(metadataForPath.Subfiles) == 0 {
-			WriteError(w, Error{fmt.Sprintf("failed to download contents for path: %v", path)}, http.StatusNotFound)
+			// Serve the skyfile's error page if it has one.
+			served, err := serveErrorPage(w, req, streamer, metadata, http.StatusNotFound)
+			if err != nil {
+				WriteError(w, Error{fmt.Sprintf("failed to serve error page: %v", err)}, http.StatusInternalServerError)
+				return
+			}
+			if !served {
+				WriteError(w, Error{fmt.Sprintf("failed to download contents for path: %v", path)}, http.StatusNotFound)
+			}
 			return
 		}
 		streamer, err = NewLimitStreamer(streamer, offset, size)
@@ -682,6 +779,9 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 
 		metadata = metadataForPath
 		isSubfile = file
+		if file {
+			contentEncoding, decodedSize = metadataForPath.ContentEncoding()
+		}
 	}
 
 	// If we are serving more than one file, and the format is not
@@ -709,7 +809,7 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		skynetPerformanceStatsMu.Lock()
 		defer skynetPerformanceStatsMu.Unlock()
 
-		_, fetchSize, err := skylink.OffsetAndFetchSize()
+		_, fetchSize, err := resolvedSkylink.OffsetAndFetchSize()
 		if err != nil {
 			return
 		}
@@ -728,11 +828,27 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		skynetPerformanceStats.DownloadLarge.AddRequest(time.Since(startTime))
 	}()
 
-	// Set the Skylink response header
+	// Set the Skylink response headers. For v1 skylinks the resolved skylink
+	// is the requested one.
 	w.Header().Set("Skynet-Skylink", skylink.String())
+	w.Header().Set("Skynet-Resolved-Skylink", resolvedSkylink.String())
 
-	// Set the ETag response header
-	eTag := buildETag(skylink, req.Method, path, format)
+	// Compressed files are served as is if the client accepts the encoding,
+	// otherwise they are decoded on the fly. Archives always contain the
+	// decoded files.
+	var passthrough bool
+	if contentEncoding != "" && !format.IsArchive() {
+		w.Header().Add("Vary", "Accept-Encoding")
+		passthrough = acceptsEncoding(req, contentEncoding)
+	}
+
+	// Set the ETag response header. The ETag is built from the resolved
+	// skylink since the content behind a v2 skylink can change. The encoded
+	// and decoded representations of a file need a different ETag.
+	eTag := buildETag(resolvedSkylink, req.Method, path, format)
+	if passthrough {
+		eTag += "-" + contentEncoding
+	}
 	w.Header().Set("ETag", fmt.Sprintf("\"%v\"", eTag))
 
 	// Set an appropriate Content-Disposition header
@@ -789,6 +905,20 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		w.Header().Set("Content-Type", responseContentType)
 	}
 
+	// Serve compressed files either encoded or decoded. Concatenated subfiles
+	// are decoded one after another.
+	if contentEncoding != "" && passthrough {
+		w.Header().Set("Content-Encoding", contentEncoding)
+	} else if contentEncoding != "" {
+		streamer, err = NewDecodingStreamer(streamer, contentEncoding, decodedSize)
+		if err != nil {
+			WriteError(w, Error{fmt.Sprintf("failed to decode skyfile: %v", err)}, http.StatusInternalServerError)
+			return
+		}
+	} else if !isSubfile && metadata.IsEncoded() {
+		streamer = NewSubfilesDecodingStreamer(streamer, metadata.Subfiles)
+	}
+
 	http.ServeContent(w, req, metadata.Filename, time.Time{}, streamer)
 }
 
@@ -901,6 +1031,69 @@ func (api *API) skynetSkylinkPinHandlerPOST(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// skynetSkylinkHealthHandlerGET responds with a report on how well the data
+// of a skylink is available on the network.
+func (api *API) skynetSkylinkHealthHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	strLink := ps.ByName("skylink")
+	var skylink modules.Skylink
+	err := skylink.LoadString(strLink)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+	timeout, err := parseTimeout(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	health, err := api.renter.SkylinkHealth(skylink, timeout)
+	if errors.Contains(err, renter.ErrRootNotFound) {
+		WriteError(w, Error{fmt.Sprintf("failed to fetch skylink: %v", err)}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to get skylink health: %v", err)}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, health)
+}
+
+// skynetSkylinkUnpinHandlerPOST deletes all siafiles that store the skylink,
+// removes the skylink from siafiles shared with other skylinks and responds
+// with their siapaths.
+func (api *API) skynetSkylinkUnpinHandlerPOST(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	strLink := ps.ByName("skylink")
+	var skylink modules.Skylink
+	err := skylink.LoadString(strLink)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	deleted, updated, err := api.renter.UnpinSkylink(skylink)
+	if errors.Contains(err, renter.ErrSkylinkNotPinned) {
+		WriteError(w, Error{fmt.Sprintf("Failed to unpin skylink: %v", err)}, http.StatusNotFound)
+		return
+	} else if errors.Contains(err, renter.ErrUnpinSkylinkV2) {
+		WriteError(w, Error{fmt.Sprintf("Failed to unpin skylink: %v", err)}, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		WriteError(w, Error{fmt.Sprintf("Failed to unpin skylink: %v", err)}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, SkynetSkylinkUnpinPOST{
+		SiaPaths:        deleted,
+		UpdatedSiaPaths: updated,
+	})
+}
+
 // skynetSkyfileHandlerPOST is a dual purpose endpoint. If the 'convertpath'
 // field is set, this endpoint will create a skyfile using an existing siafile.
 // The original siafile and the skyfile will both need to be kept in order for
@@ -929,10 +1122,17 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 		Filename: params.filename,
 		Mode:     params.mode,
 
+		// Set the compression
+		Compression: params.compression,
+
 		// Set the default path params
 		DefaultPath:        params.defaultPath,
 		DisableDefaultPath: params.disableDefaultPath,
 
+		// Set the routing params
+		TryFiles:   params.tryFiles,
+		ErrorPages: params.errorPages,
+
 		// Set encryption key details
 		SkykeyName: params.skyKeyName,
 		SkykeyID:   params.skyKeyID,
@@ -942,11 +1142,37 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 	var reader modules.SkyfileUploadReader
 	if isMultipartRequest(headers.mediaType) {
 		reader, err = modules.NewSkyfileMultipartReaderFromRequest(req, sup)
+		if err != nil {
+			WriteError(w, Error{fmt.Sprintf("unable to create multipart reader: %v", err)}, http.StatusBadRequest)
+			return
+		}
+	} else if params.format.IsArchive() {
+		reader, err = modules.NewSkyfileArchiveReader(req.Body, params.format, sup)
+		if err != nil {
+			WriteError(w, Error{fmt.Sprintf("unable to create archive reader: %v", err)}, http.StatusBadRequest)
+			return
+		}
 	} else {
 		reader = modules.NewSkyfileReader(req.Body, sup)
 	}
-	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("unable to create multipart reader: %v", err)}, http.StatusBadRequest)
+
+	// Check whether this is a temporary upload. Temporary skyfiles are stored
+	// by the hosts for a fixed number of blocks without a siafile.
+	if params.temporaryDuration > 0 {
+		skylink, err := api.renter.TemporaryUpload(sup, reader, params.temporaryDuration)
+		if err != nil {
+			WriteError(w, Error{fmt.Sprintf("failed to upload temporary file to Skynet: %v", err)}, http.StatusBadRequest)
+			return
+		}
+
+		// Set the Skylink response header
+		w.Header().Set("Skynet-Skylink", skylink.String())
+
+		WriteJSON(w, SkynetSkyfileHandlerPOST{
+			Skylink:    skylink.String(),
+			MerkleRoot: skylink.MerkleRoot(),
+			Bitfield:   skylink.Bitfield(),
+		})
 		return
 	}
 
@@ -1017,6 +1243,82 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 	})
 }
 
+// skynetPackHandlerPOST takes a batch of small files from a multipart form,
+// packs them into as few sectors as possible and uploads the sectors. Every
+// file can be accessed using its own skylink.
+func (api *API) skynetPackHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// parse the request headers and parameters
+	headers, params, err := parseUploadHeadersAndRequestParameters(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// validate the parameters, every file gets its own metadata so only the
+	// parameters that apply to the upload as a whole are allowed
+	if !isMultipartRequest(headers.mediaType) {
+		WriteError(w, Error{"packed uploads require a multipart form"}, http.StatusBadRequest)
+		return
+	}
+	if params.convertPath != "" {
+		WriteError(w, Error{"'convertpath' is not supported for packed uploads"}, http.StatusBadRequest)
+		return
+	}
+	if params.compression != "" {
+		WriteError(w, Error{"'compression' is not supported for packed uploads"}, http.StatusBadRequest)
+		return
+	}
+	if params.filename != "" || params.mode != 0 {
+		WriteError(w, Error{"'filename' and 'mode' are set per file for packed uploads"}, http.StatusBadRequest)
+		return
+	}
+	if params.defaultPath != "" || params.disableDefaultPath {
+		WriteError(w, Error{"'defaultpath' and 'disabledefaultpath' are not supported for packed uploads"}, http.StatusBadRequest)
+		return
+	}
+	if len(params.tryFiles) > 0 || len(params.errorPages) > 0 {
+		WriteError(w, Error{"'tryfiles' and 'errorpages' are not supported for packed uploads"}, http.StatusBadRequest)
+		return
+	}
+	if params.skyKeyName != "" || params.skyKeyID != (skykey.SkykeyID{}) {
+		WriteError(w, Error{"encryption is not supported for packed uploads"}, http.StatusBadRequest)
+		return
+	}
+
+	// read the files
+	files, err := parsePackedFiles(req)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("unable to parse files: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// upload the files
+	sup := modules.SkyfileUploadParameters{
+		BaseChunkRedundancy: params.baseChunkRedundancy,
+		DryRun:              params.dryRun,
+		Force:               params.force,
+		SiaPath:             params.siaPath,
+	}
+	skylinks, err := api.renter.UploadPackedSkyfiles(sup, files)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to upload packed files to Skynet: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	resp := SkynetPackHandlerPOST{
+		Skyfiles: make([]SkynetPackedSkyfile, 0, len(skylinks)),
+	}
+	for i, skylink := range skylinks {
+		resp.Skyfiles = append(resp.Skyfiles, SkynetPackedSkyfile{
+			Filename:   files[i].Filename,
+			Skylink:    skylink.String(),
+			MerkleRoot: skylink.MerkleRoot(),
+			Bitfield:   skylink.Bitfield(),
+		})
+	}
+	WriteJSON(w, resp)
+}
+
 // skynetStatsHandlerGET responds with a JSON with statistical data about
 // skynet, e.g. number of files uploaded, total size, etc.
 func (api *API) skynetStatsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -1050,10 +1352,18 @@ func (api *API) skynetStatsHandlerGET(w http.ResponseWriter, _ *http.Request, _
 	perfStats := skynetPerformanceStats.Copy()
 	skynetPerformanceStatsMu.Unlock()
 
+	// Grab the stats of the skynet cache.
+	cacheStats, err := api.renter.SkynetCacheStats()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to get the skynet cache stats: %v", err)}, http.StatusInternalServerError)
+		return
+	}
+
 	// Grab the siad uptime
 	uptime := time.Since(api.StartTime()).Seconds()
 
 	WriteJSON(w, SkynetStatsGET{
+		CacheStats:       cacheStats,
 		PerformanceStats: perfStats,
 
 		Uptime:      int64(uptime),
@@ -1066,7 +1376,7 @@ func (api *API) skynetStatsHandlerGET(w http.ResponseWriter, _ *http.Request, _
 }
 
 // skykeyHandlerGET handles the API call to get a Skykey and its ID using its
-// name or ID.
+// name or ID. For X25519 skykeys the public half can be requested instead.
 func (api *API) skykeyHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Parse Skykey id and name.
 	name := req.FormValue("name")
@@ -1099,6 +1409,22 @@ func (api *API) skykeyHandlerGET(w http.ResponseWriter, req *http.Request, _ htt
 		return
 	}
 
+	// Return the public half of the skykey if requested.
+	if publicKeyStr := req.FormValue("publickey"); publicKeyStr != "" {
+		publicKey, err := strconv.ParseBool(publicKeyStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'publickey' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if publicKey {
+			sk, err = sk.PublicHalf()
+			if err != nil {
+				WriteError(w, Error{"failed to get public half of skykey: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
 	skString, err := sk.ToString()
 	if err != nil {
 		WriteError(w, Error{"failed to decode skykey: " + err.Error()}, http.StatusInternalServerError)
@@ -1327,3 +1653,110 @@ func (api *API) registryHandlerGET(w http.ResponseWriter, req *http.Request, _ h
 		Signature: hex.EncodeToString(srv.Signature[:]),
 	})
 }
+
+// registrySubscriptionHandlerGET handles the GET calls to
+// /skynet/registry/subscription. It subscribes to the requested entries and
+// streams updates to the client as server-sent events until the client closes
+// the connection.
+func (api *API) registrySubscriptionHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the entries. Every publickey needs to be accompanied by a datakey.
+	query := req.URL.Query()
+	pubKeyStrs := query["publickey"]
+	dataKeyStrs := query["datakey"]
+	if len(pubKeyStrs) == 0 {
+		WriteError(w, Error{"at least one 'publickey' and 'datakey' param is required"}, http.StatusBadRequest)
+		return
+	}
+	if len(pubKeyStrs) != len(dataKeyStrs) {
+		WriteError(w, Error{fmt.Sprintf("number of 'publickey' params doesn't match number of 'datakey' params: %v != %v", len(pubKeyStrs), len(dataKeyStrs))}, http.StatusBadRequest)
+		return
+	}
+	spks := make([]types.SiaPublicKey, len(pubKeyStrs))
+	dataKeys := make([]crypto.Hash, len(dataKeyStrs))
+	for i := range pubKeyStrs {
+		err := spks[i].LoadString(pubKeyStrs[i])
+		if err != nil {
+			WriteError(w, Error{"Unable to parse publickey param: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = dataKeys[i].LoadString(dataKeyStrs[i])
+		if err != nil {
+			WriteError(w, Error{"Unable to decode dataKey param: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The response is streamed so the writer needs to support flushing.
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, Error{"streaming is not supported by the response writer"}, http.StatusInternalServerError)
+		return
+	}
+
+	// Create the subscriber. The notify function blocks until the
+	// notification was handed over or the request is done. The context is
+	// cancelled before the subscriber is closed to make sure closing the
+	// subscriber never blocks on a pending notification.
+	ctx, cancel := context.WithCancel(req.Context())
+	notifications := make(chan RegistrySubscriptionNotification)
+	sub, err := api.renter.NewRegistrySubscriber(func(spk types.SiaPublicKey, srv modules.SignedRegistryValue) {
+		select {
+		case notifications <- RegistrySubscriptionNotification{
+			PublicKey: spk,
+			DataKey:   srv.Tweak,
+			Data:      hex.EncodeToString(srv.Data),
+			Revision:  srv.Revision,
+			Signature: hex.EncodeToString(srv.Signature[:]),
+		}:
+		case <-ctx.Done():
+		}
+	})
+	if err != nil {
+		cancel()
+		WriteError(w, Error{"Unable to create registry subscriber: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	defer sub.Close()
+	defer cancel()
+
+	// Subscribe to the entries.
+	for i := range spks {
+		err = sub.Subscribe(spks[i], dataKeys[i])
+		if err != nil {
+			WriteError(w, Error{"Unable to subscribe to entry: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Start the event stream.
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(registrySubscriptionKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case notification := <-notifications:
+			b, err := json.Marshal(notification)
+			if err != nil {
+				build.Critical("failed to marshal registry notification", err)
+				return
+			}
+			_, err = fmt.Fprintf(w, "data: %s\n\n", b)
+			if err != nil {
+				return
+			}
+		case <-ticker.C:
+			_, err = io.WriteString(w, ": keepalive\n\n")
+			if err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
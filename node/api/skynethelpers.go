@@ -3,8 +3,10 @@ package api
 import (
 	"archive/tar"
 	"archive/zip"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime"
 	"net/http"
 	"net/url"
@@ -19,6 +21,7 @@ import (
 	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/skykey"
+	"gitlab.com/NebulousLabs/Sia/types"
 	"gitlab.com/NebulousLabs/errors"
 )
 
@@ -27,17 +30,22 @@ type (
 	// string parameters on upload
 	skyfileUploadParams struct {
 		baseChunkRedundancy uint8
+		compression         string
 		defaultPath         string
 		convertPath         string
 		disableDefaultPath  bool
 		dryRun              bool
+		errorPages          map[int]string
 		filename            string
 		force               bool
+		format              modules.SkyfileFormat
 		mode                os.FileMode
 		root                bool
 		siaPath             modules.SiaPath
 		skyKeyID            skykey.SkykeyID
 		skyKeyName          string
+		temporaryDuration   types.BlockHeight
+		tryFiles            []string
 	}
 
 	// skyfileUploadHeaders is a helper struct that contains all of the request
@@ -64,6 +72,76 @@ func isMultipartRequest(mediaType string) bool {
 	return strings.HasPrefix(mediaType, "multipart/form-data")
 }
 
+// archiveFormatFromMediaType is a helper method that returns the archive format
+// that matches the given media type. If the media type is not an archive,
+// modules.SkyfileFormatNotSpecified is returned.
+func archiveFormatFromMediaType(mediaType string) modules.SkyfileFormat {
+	switch mediaType {
+	case "application/x-tar":
+		return modules.SkyfileFormatTar
+	case "application/gzip", "application/x-gzip", "application/x-gtar":
+		return modules.SkyfileFormatTarGz
+	case "application/zip", "application/x-zip-compressed":
+		return modules.SkyfileFormatZip
+	default:
+		return modules.SkyfileFormatNotSpecified
+	}
+}
+
+// parsePackedFiles is a helper function that reads the files of a packed
+// upload from the multipart form of the given request. Every part is expected
+// to be a file that fits within a single sector.
+func parsePackedFiles(req *http.Request) ([]modules.SkyfilePackedFile, error) {
+	mpr, err := req.MultipartReader()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create multipart reader")
+	}
+	var files []modules.SkyfilePackedFile
+	for {
+		part, err := mpr.NextPart()
+		if errors.Contains(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to read multipart form")
+		}
+
+		// Only parts with a legal form name are considered files.
+		formName := part.FormName()
+		if formName != "file" && formName != "files[]" {
+			continue
+		}
+		filename := part.FileName()
+		if filename == "" {
+			return nil, modules.ErrEmptyFilename
+		}
+
+		// parse the mode from the part header
+		var mode os.FileMode
+		if modeStr := part.Header.Get("Mode"); modeStr != "" {
+			_, err := fmt.Sscanf(modeStr, "%o", &mode)
+			if err != nil {
+				return nil, errors.AddContext(err, fmt.Sprintf("unable to parse mode of file '%v'", filename))
+			}
+		}
+
+		// read the file data, making sure it fits in a sector
+		data, err := ioutil.ReadAll(io.LimitReader(part, int64(modules.SectorSize)+1))
+		if err != nil {
+			return nil, errors.AddContext(err, fmt.Sprintf("unable to read file '%v'", filename))
+		}
+		if uint64(len(data)) > modules.SectorSize {
+			return nil, errors.AddContext(modules.ErrSizeTooLarge, fmt.Sprintf("file '%v' is too large to be packed", filename))
+		}
+		files = append(files, modules.SkyfilePackedFile{
+			Filename: filename,
+			Mode:     mode,
+			Data:     data,
+		})
+	}
+	return files, nil
+}
+
 // parseSkylinkURL splits a raw skylink URL into its components - a skylink, a
 // string representation of the skylink with the query parameters stripped, and
 // a path. The input skylink URL should not have been URL-decoded. The path is
@@ -145,6 +223,12 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'compression' query parameter
+	compression := strings.ToLower(queryForm.Get("compression"))
+	if err := modules.ValidateCompression(compression); err != nil {
+		return nil, nil, errors.AddContext(err, "unable to parse 'compression' parameter, allowed values are: 'gzip'")
+	}
+
 	// parse 'convertpath' query parameter
 	convertPath := queryForm.Get("convertpath")
 
@@ -174,6 +258,19 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'errorpages' query parameter
+	var errorPages map[int]string
+	errorPagesStr := queryForm.Get("errorpages")
+	if errorPagesStr != "" {
+		err = json.Unmarshal([]byte(errorPagesStr), &errorPages)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'errorpages' parameter")
+		}
+		for status, errorPage := range errorPages {
+			errorPages[status] = modules.EnsurePrefix(errorPage, "/")
+		}
+	}
+
 	// parse 'filename' query parameter
 	filename := queryForm.Get("filename")
 
@@ -187,6 +284,15 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'format' query parameter, if it's not set the archive format is
+	// derived from the 'Content-Type' header
+	format := modules.SkyfileFormat(strings.ToLower(queryForm.Get("format")))
+	if format == modules.SkyfileFormatNotSpecified {
+		format = archiveFormatFromMediaType(mediaType)
+	} else if !format.IsArchive() {
+		return nil, nil, errors.New("unable to parse 'format' parameter, allowed values are: 'tar', 'targz' and 'zip'")
+	}
+
 	// parse 'mode' query parameter
 	modeStr := queryForm.Get("mode")
 	var mode os.FileMode
@@ -232,6 +338,26 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'temporaryduration' query parameter
+	var temporaryDuration types.BlockHeight
+	temporaryDurationStr := queryForm.Get("temporaryduration")
+	if temporaryDurationStr != "" {
+		_, err = fmt.Sscan(temporaryDurationStr, &temporaryDuration)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'temporaryduration' parameter")
+		}
+	}
+
+	// parse 'tryfiles' query parameter
+	var tryFiles []string
+	tryFilesStr := queryForm.Get("tryfiles")
+	if tryFilesStr != "" {
+		err = json.Unmarshal([]byte(tryFilesStr), &tryFiles)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'tryfiles' parameter")
+		}
+	}
+
 	// validate parameter combos
 
 	// verify force is not set if disable force header was set
@@ -249,9 +375,26 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		return nil, nil, errors.AddContext(modules.ErrInvalidDefaultPath, "DefaultPath and DisableDefaultPath are mutually exclusive and cannot be set together")
 	}
 
-	// verify default path params are not set if it's not a multipart upload
-	if !isMultipartRequest(mediaType) && (disableDefaultPath || defaultPath != "") {
-		return nil, nil, errors.New("DefaultPath and DisableDefaultPath can only be set on multipart uploads")
+	// verify tryfiles are not combined with defaultpath or disabledefaultpath
+	if len(tryFiles) > 0 && (disableDefaultPath || defaultPath != "") {
+		return nil, nil, errors.AddContext(modules.ErrInvalidTryFiles, "TryFiles can't be combined with DefaultPath or DisableDefaultPath")
+	}
+
+	// verify an archive format is not set on a multipart upload
+	if isMultipartRequest(mediaType) && format.IsArchive() {
+		return nil, nil, errors.New("'format' can not be set on multipart uploads")
+	}
+
+	// verify default path params are not set if it's not a multipart or
+	// archive upload
+	if !isMultipartRequest(mediaType) && !format.IsArchive() && (disableDefaultPath || defaultPath != "") {
+		return nil, nil, errors.New("DefaultPath and DisableDefaultPath can only be set on multipart and archive uploads")
+	}
+
+	// verify tryfiles and errorpages are not set if it's not a multipart or
+	// archive upload
+	if !isMultipartRequest(mediaType) && !format.IsArchive() && (len(tryFiles) > 0 || len(errorPages) > 0) {
+		return nil, nil, errors.New("TryFiles and ErrorPages can only be set on multipart and archive uploads")
 	}
 
 	// verify convertpath and filename are not combined
@@ -259,6 +402,21 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		return nil, nil, errors.New("cannot set both a 'convertpath' and a 'filename'")
 	}
 
+	// verify convertpath and an archive format are not combined
+	if convertPath != "" && format.IsArchive() {
+		return nil, nil, errors.New("cannot set both a 'convertpath' and an archive 'format'")
+	}
+
+	// verify convertpath and compression are not combined
+	if convertPath != "" && compression != "" {
+		return nil, nil, errors.New("cannot set both a 'convertpath' and a 'compression'")
+	}
+
+	// verify temporaryduration and convertpath are not combined
+	if temporaryDuration > 0 && convertPath != "" {
+		return nil, nil, errors.New("cannot set both a 'temporaryduration' and a 'convertpath'")
+	}
+
 	// verify skykeyname and skykeyid are not combined
 	if skykeyName != "" && skykeyIDStr != "" {
 		return nil, nil, errors.New("cannot set both a 'skykeyname' and 'skykeyid'")
@@ -271,17 +429,22 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 	}
 	params := &skyfileUploadParams{
 		baseChunkRedundancy: baseChunkRedundancy,
+		compression:         compression,
 		convertPath:         convertPath,
 		defaultPath:         defaultPath,
 		disableDefaultPath:  disableDefaultPath,
 		dryRun:              dryRun,
+		errorPages:          errorPages,
 		filename:            filename,
 		force:               force,
+		format:              format,
 		mode:                mode,
 		root:                root,
 		siaPath:             siaPath,
 		skyKeyID:            skykeyID,
 		skyKeyName:          skykeyName,
+		temporaryDuration:   temporaryDuration,
+		tryFiles:            tryFiles,
 	}
 	return headers, params, nil
 }
@@ -327,9 +490,82 @@ func serveArchive(dst io.Writer, src io.ReadSeeker, md modules.SkyfileMetadata,
 			Len:      length,
 		})
 	}
+	// Compressed subfiles are decoded before they are added to the archive.
+	if md.IsEncoded() {
+		var decoded io.Reader
+		decoded, files = decodeSubfiles(src, files)
+		return archiveFunc(dst, decoded, files)
+	}
 	return archiveFunc(dst, src, files)
 }
 
+// serveErrorPage serves the error page the skyfile defines for the given HTTP
+// status code, using the given streamer of the whole skyfile. It returns false
+// if the skyfile doesn't define an error page for the status code.
+func serveErrorPage(w http.ResponseWriter, req *http.Request, streamer modules.Streamer, md modules.SkyfileMetadata, status int) (bool, error) {
+	errorPage, exists := md.ErrorPage(status)
+	if !exists {
+		return false, nil
+	}
+	metaForPage, isFile, offset, size := md.ForPath(errorPage)
+	if !isFile {
+		return false, nil
+	}
+	pageStreamer, err := NewLimitStreamer(streamer, offset, size)
+	if err != nil {
+		return false, errors.AddContext(err, "could not create limit streamer")
+	}
+
+	// Error pages are always served decoded.
+	contentEncoding, decodedSize := metaForPage.ContentEncoding()
+	if contentEncoding != "" {
+		pageStreamer, err = NewDecodingStreamer(pageStreamer, contentEncoding, decodedSize)
+		if err != nil {
+			return false, errors.AddContext(err, "could not create decoding streamer")
+		}
+		size = decodedSize
+	}
+
+	if contentType := metaForPage.ContentType(); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Content-Length", fmt.Sprint(size))
+	w.WriteHeader(status)
+	if req.Method == http.MethodHead {
+		return true, nil
+	}
+	_, err = io.CopyN(w, pageStreamer, int64(size))
+	return true, err
+}
+
+// acceptsEncoding returns true if the Accept-Encoding header of the request
+// allows for the response to be encoded using the given content encoding.
+func acceptsEncoding(req *http.Request, contentEncoding string) bool {
+	for _, header := range req.Header["Accept-Encoding"] {
+		for _, coding := range strings.Split(header, ",") {
+			// Split off the quality value, a quality of 0 means the encoding
+			// is not acceptable.
+			params := strings.Split(coding, ";")
+			name := strings.ToLower(strings.TrimSpace(params[0]))
+			if name != contentEncoding && name != "*" {
+				continue
+			}
+			acceptable := true
+			for _, param := range params[1:] {
+				param = strings.ReplaceAll(param, " ", "")
+				if strings.HasPrefix(param, "q=") {
+					q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+					acceptable = err == nil && q > 0
+				}
+			}
+			if acceptable {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // serveTar is an archiveFunc that implements serving the files from src to dst
 // as a tar.
 func serveTar(dst io.Writer, src io.Reader, files []modules.SkyfileSubfileMetadata) error {
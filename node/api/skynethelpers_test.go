@@ -13,6 +13,7 @@ import (
 	"gitlab.com/NebulousLabs/Sia/build"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/skykey"
+	"gitlab.com/NebulousLabs/errors"
 )
 
 // TestSkynetHelpers is a convenience function that wraps all of the Skynet
@@ -22,6 +23,7 @@ func TestSkynetHelpers(t *testing.T) {
 	t.Run("BuildETag", testBuildETag)
 	t.Run("ParseSkylinkURL", testParseSkylinkURL)
 	t.Run("ParseUploadRequestParameters", testParseUploadRequestParameters)
+	t.Run("AcceptsEncoding", testAcceptsEncoding)
 }
 
 // testBuildETag verifies the functionality of the buildETag helper function
@@ -280,6 +282,28 @@ func testParseUploadRequestParameters(t *testing.T) {
 		t.Fatal("Unexpected")
 	}
 
+	// verify 'compression'
+	req = buildRequest(url.Values{"compression": []string{"GZIP"}}, http.Header{})
+	_, params = parseRequest(req, defaultParams)
+	if params.compression != modules.SkyfileContentEncodingGzip {
+		t.Fatal("Unexpected")
+	}
+
+	// verify 'compression' - unsupported compression
+	octetStreamStr := []string{"application/octet-stream"}
+	req = buildRequest(url.Values{"compression": []string{"br"}}, http.Header{"Content-Type": octetStreamStr})
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	if !errors.Contains(err, modules.ErrUnsupportedCompression) {
+		t.Fatal("Unexpected", err)
+	}
+
+	// verify 'compression' - combo with 'convertpath'
+	req = buildRequest(url.Values{"compression": []string{"gzip"}, "convertpath": []string{"/foo/bar"}}, http.Header{"Content-Type": octetStreamStr})
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	if err == nil || !strings.Contains(err.Error(), "cannot set both a 'convertpath' and a 'compression'") {
+		t.Fatal("Unexpected", err)
+	}
+
 	// verify 'convertpath'
 	req = buildRequest(url.Values{"convertpath": []string{"/foo/bar"}}, http.Header{})
 	_, params = parseRequest(req, defaultParams)
@@ -357,6 +381,46 @@ func testParseUploadRequestParameters(t *testing.T) {
 		t.Fatal("Unexpected")
 	}
 
+	// verify 'format'
+	req = buildRequest(url.Values{"format": []string{"TarGz"}}, http.Header{})
+	_, params = parseRequest(req, defaultParams)
+	if params.format != modules.SkyfileFormatTarGz {
+		t.Fatal("Unexpected")
+	}
+
+	// verify 'format' - derived from 'Content-Type'
+	req = buildRequest(url.Values{}, http.Header{"Content-Type": []string{"application/x-tar"}})
+	_, params = parseRequest(req, defaultParams)
+	if params.format != modules.SkyfileFormatTar {
+		t.Fatal("Unexpected")
+	}
+	req = buildRequest(url.Values{}, http.Header{"Content-Type": []string{"text/html"}})
+	_, params = parseRequest(req, defaultParams)
+	if params.format != modules.SkyfileFormatNotSpecified {
+		t.Fatal("Unexpected")
+	}
+
+	// verify 'format' - non-archive format
+	req = buildRequest(url.Values{"format": []string{string(modules.SkyfileFormatConcat)}}, http.Header{})
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	if err == nil {
+		t.Fatal("Unexpected")
+	}
+
+	// verify 'format' - combo with a multipart request
+	req = buildRequest(url.Values{"format": []string{"zip"}}, http.Header{"Content-Type": contentTypeStr})
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	if err == nil {
+		t.Fatal("Unexpected")
+	}
+
+	// verify 'format' - combo with 'defaultpath'
+	req = buildRequest(url.Values{"format": []string{"zip"}, "defaultpath": []string{"/index.html"}}, http.Header{})
+	_, params = parseRequest(req, defaultParams)
+	if params.defaultPath != "/index.html" {
+		t.Fatal("Unexpected")
+	}
+
 	// verify 'mode'
 	req = buildRequest(url.Values{"mode": []string{fmt.Sprintf("%o", os.FileMode(0644))}}, http.Header{})
 	_, params = parseRequest(req, defaultParams)
@@ -418,3 +482,36 @@ func testParseUploadRequestParameters(t *testing.T) {
 		t.Fatal("Unexpected")
 	}
 }
+
+// testAcceptsEncoding verifies the functionality of the acceptsEncoding helper
+// function
+func testAcceptsEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header   string
+		accepted bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip;q=1.0, *;q=0.5", true},
+		{"br;q=1.0, gzip; q=0.8", true},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"identity", false},
+		{"br, deflate", false},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest("GET", "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.header != "" {
+			req.Header.Set("Accept-Encoding", test.header)
+		}
+		if acceptsEncoding(req, modules.SkyfileContentEncodingGzip) != test.accepted {
+			t.Fatalf("unexpected result for header '%v'", test.header)
+		}
+	}
+}
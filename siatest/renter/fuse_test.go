@@ -732,9 +732,10 @@ func TestFuse(t *testing.T) {
 		t.Fatal("should not be able to make a directory in a read-only fuse system")
 	}
 
-	// TODO: When read-write fuse is supported, switch over to read-write mode
-	// here and begin testing the write features. Extend the concurrency test to
-	// probe write features as well, probably by adding more phases.
+	// The write features are tested in TestFuseWrite.
+	//
+	// TODO: Extend the concurrency test to probe write features as well,
+	// probably by adding more phases.
 
 	// Inode check. Mount the root siafile to a special inode mountpoint then
 	// open several files and directoriesk. Grab their inodes. Keep the folder
@@ -1163,3 +1164,171 @@ func TestFuse(t *testing.T) {
 		err = r.RenterFuseUnmount(unmount)
 	}
 }
+
+// TestFuseWrite tests writing to a fuse filesystem which isn't mounted as
+// read-only.
+func TestFuseWrite(t *testing.T) {
+	if !build.VLONG {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Miners:  1,
+		Renters: 1,
+	}
+	testDir := fuseTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+
+	// Create a dir with an upload policy which works with the available hosts
+	// and mount it.
+	dirSiaPath := modules.RandomSiaPath()
+	err = r.RenterDirCreatePost(dirSiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterDirSetUploadPolicyPost(dirSiaPath, modules.UploadPolicy{DataPieces: 1, ParityPieces: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mountpoint := filepath.Join(testDir, "mount")
+	err = os.MkdirAll(mountpoint, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterFuseMount(mountpoint, dirSiaPath, modules.MountOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checkFile checks that the file at the given path within the mounted
+	// dir has the expected content both in fuse and in the renter.
+	checkFile := func(name string, expected []byte) {
+		t.Helper()
+		siaPath, err := dirSiaPath.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rf, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rf.File.Filesize != uint64(len(expected)) {
+			t.Fatalf("expected filesize %v but was %v", len(expected), rf.File.Filesize)
+		}
+		err = build.Retry(100, 100*time.Millisecond, func() error {
+			data, err := ioutil.ReadFile(filepath.Join(mountpoint, name))
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(data, expected) {
+				return errors.New("data mismatch")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Create a file.
+	data := fastrand.Bytes(int(100 + fastrand.Intn(1000)))
+	err = ioutil.WriteFile(filepath.Join(mountpoint, "file"), data, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile("file", data)
+
+	// Append to the file.
+	f, err := os.OpenFile(filepath.Join(mountpoint, "file"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appended := fastrand.Bytes(100)
+	if _, err := f.Write(appended); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, appended...)
+	checkFile("file", data)
+
+	// Truncate the file.
+	err = os.Truncate(filepath.Join(mountpoint, "file"), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = data[:50]
+	checkFile("file", data)
+
+	// Create a dir and move the file into it.
+	err = os.Mkdir(filepath.Join(mountpoint, "dir"), persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(filepath.Join(mountpoint, "file"), filepath.Join(mountpoint, "dir", "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile("dir/file", data)
+	siaPath, err := dirSiaPath.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterFileGet(siaPath); err == nil {
+		t.Fatal("file should have been moved")
+	}
+
+	// A non-empty dir can't be removed.
+	err = os.Remove(filepath.Join(mountpoint, "dir"))
+	if err == nil {
+		t.Fatal("should not be able to remove a non-empty dir")
+	}
+
+	// Delete the file and the dir.
+	err = os.Remove(filepath.Join(mountpoint, "dir", "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Remove(filepath.Join(mountpoint, "dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd, err := r.RenterDirGet(dirSiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rd.Files) != 0 || len(rd.Directories) != 1 {
+		t.Fatal("dir should be empty", len(rd.Files), len(rd.Directories))
+	}
+
+	// Files can't be created in a read-only mount.
+	err = r.RenterFuseUnmount(mountpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterFuseMount(mountpoint, dirSiaPath, modules.MountOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Create(filepath.Join(mountpoint, "file"))
+	if err == nil {
+		t.Fatal("should not be able to create a file in a read only fuse system")
+	}
+	err = r.RenterFuseUnmount(mountpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
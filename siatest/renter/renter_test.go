@@ -280,6 +280,7 @@ func TestRenterFour(t *testing.T) {
 		{Name: "TestPauseAndResumeRepairAndUploads", Test: testPauseAndResumeRepairAndUploads},
 		{Name: "TestDownloadServedFromDisk", Test: testDownloadServedFromDisk},
 		{Name: "TestDirMode", Test: testDirMode},
+		{Name: "TestSignedStreamURL", Test: testSignedStreamURL},
 		{Name: "TestEscapeSiaPath", Test: testEscapeSiaPath}, // Runs last because it uploads many files
 	}
 
@@ -4792,6 +4793,101 @@ func testDirMode(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testSignedStreamURL tests that files can be streamed using signed stream URLs
+// without the API password.
+func testSignedStreamURL(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	renter := tg.Renters()[0]
+	// Upload file, creating a piece for each host in the group
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	fileSize := fastrand.Intn(int(modules.SectorSize)) + siatest.Fuzz() + 2
+	lf, rf, err := renter.UploadNewFileBlocking(fileSize, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal("Failed to upload a file for testing: ", err)
+	}
+
+	// Create a client without the API password.
+	c := client.New(client.Options{
+		Address:   renter.Address,
+		UserAgent: renter.UserAgent,
+	})
+
+	// Create a URL which can be used for a single download.
+	rsup, err := renter.RenterStreamURLPost(rf.SiaPath(), time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.RenterSignedStreamGet(rsup.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lf.Equal(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.RenterSignedStreamGet(rsup.URL); err == nil {
+		t.Fatal("expected download limit to be enforced")
+	}
+
+	// Splitting a download into range requests doesn't bypass the limit and
+	// failed requests aren't charged.
+	rsup, err = renter.RenterStreamURLPost(rf.SiaPath(), time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := uint64(fileSize)
+	if _, err := c.RenterSignedStreamPartialGet(rsup.URL, size+1, size+2); err == nil {
+		t.Fatal("expected unsatisfiable range to fail")
+	}
+	tail, err := c.RenterSignedStreamPartialGet(rsup.URL, 1, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := c.RenterSignedStreamPartialGet(rsup.URL, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lf.Equal(append(head, tail...)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.RenterSignedStreamPartialGet(rsup.URL, 1, size); err == nil {
+		t.Fatal("expected download limit to be enforced for range requests")
+	}
+
+	// A URL for another file doesn't grant access to the uploaded file.
+	rsup, err = renter.RenterStreamURLPost(modules.RandomSiaPath(), time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(rsup.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Path = "/renter/stream/" + rf.SiaPath().String()
+	if _, err := c.RenterSignedStreamGet(u.String()); err == nil {
+		t.Fatal("expected stream outside of the url's scope to fail")
+	}
+
+	// Unsigned streams require the API password once signatures are required.
+	if _, err := c.RenterStreamGet(rf.SiaPath(), false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := renter.RenterRequireStreamSignaturePost(true); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := renter.RenterRequireStreamSignaturePost(false); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if _, err := c.RenterStreamGet(rf.SiaPath(), false, false); err == nil {
+		t.Fatal("expected unsigned stream without password to fail")
+	}
+	if _, err := renter.RenterStreamGet(rf.SiaPath(), false, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestWorkerStatus probes the WorkerPoolStatus
 func TestWorkerStatus(t *testing.T) {
 	if testing.Short() {
@@ -81,6 +81,20 @@ func TestSkynetSkylinkHandlerGET(t *testing.T) {
 			Skylink:       "3BBcCO73xMbehYaK7bjDGCtW0GwOL6Swl-lNY52Pb_APzA",
 			ExpectedError: "both defaultpath and disabledefaultpath are set",
 		},
+		{
+			// DefaultPathTryFiles ensures that we return an error if a file
+			// has both defaultPath and tryFiles set.
+			Name:          "DefaultPathTryFiles",
+			Skylink:       "4DDcCO73xMbehYaK7bjDGCtW0GwOL6Swl-lNY52Pb_APzA",
+			ExpectedError: "tryfiles can't be combined with defaultpath",
+		},
+		{
+			// DefaultPathTryFilesSubPath ensures that the error is also
+			// returned for paths within such a file.
+			Name:          "DefaultPathTryFilesSubPath",
+			Skylink:       "4DDcCO73xMbehYaK7bjDGCtW0GwOL6Swl-lNY52Pb_APzA/missing",
+			ExpectedError: "tryfiles can't be combined with defaultpath",
+		},
 		{
 			// NonRootDefaultPath ensures that we return an error if a file has
 			// a non-root defaultPath.
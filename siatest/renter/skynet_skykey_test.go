@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/node"
 	"gitlab.com/NebulousLabs/Sia/node/api"
 	"gitlab.com/NebulousLabs/Sia/node/api/client"
 	"gitlab.com/NebulousLabs/Sia/persist"
@@ -39,6 +40,9 @@ func TestSkykey(t *testing.T) {
 		{Name: "EncryptionTypePublicID", Test: testSkynetEncryptionWithType(skykey.TypePublicID)},
 		{Name: "LargeFilePrivateID", Test: testSkynetEncryptionLargeFileWithType(skykey.TypePrivateID)},
 		{Name: "LargeFilePublicID", Test: testSkynetEncryptionLargeFileWithType(skykey.TypePublicID)},
+		{Name: "EncryptionTypeX25519", Test: testSkynetEncryptionWithType(skykey.TypeX25519Private)},
+		{Name: "LargeFileX25519", Test: testSkynetEncryptionLargeFileWithType(skykey.TypeX25519Private)},
+		{Name: "X25519PublicHalf", Test: testSkynetEncryptionX25519PublicHalf},
 		{Name: "UnsafeClient", Test: testUnsafeClient},
 	}
 
@@ -706,3 +710,113 @@ func testSkynetEncryptionLargeFile(t *testing.T, tg *siatest.TestGroup, skykeyTy
 		t.Fatal("skylink mismatch")
 	}
 }
+
+// testSkynetEncryptionX25519PublicHalf tests that a renter which only knows the
+// public half of an X25519 skykey can upload skyfiles which only the holder of
+// the private half can download.
+func testSkynetEncryptionX25519PublicHalf(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	keyName := "x25519-public-half-test-key"
+
+	// Create the private key and export its public half.
+	sk, err := r.SkykeyCreateKeyPost(keyName, skykey.TypeX25519Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := r.SkykeyGetPublicHalfByName(keyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk.Type != skykey.TypeX25519Public {
+		t.Fatal("unexpected type", pk.Type)
+	}
+	if pk.ID() != sk.ID() || pk.Name != keyName {
+		t.Fatal("public half should have the same ID and name as the private half")
+	}
+	pk2, err := r.SkykeyGetPublicHalfByID(sk.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkStr, err := pk.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk2Str, err := pk2.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkStr != pk2Str {
+		t.Fatal("Expected same public half by name and ID")
+	}
+
+	// Other skykeys don't have a public half.
+	otherName := "x25519-public-half-test-other-key"
+	_, err = r.SkykeyCreateKeyPost(otherName, skykey.TypePrivateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.SkykeyGetPublicHalfByName(otherName)
+	if err == nil {
+		t.Fatal("Expected error when getting the public half of a private-id skykey")
+	}
+
+	// Public skykeys can't be created.
+	_, err = r.SkykeyCreateKeyPost("x25519-public-create", skykey.TypeX25519Public)
+	if err == nil {
+		t.Fatal("Expected error when creating a public X25519 skykey")
+	}
+
+	// Add a new renter which only knows the public half.
+	nodes, err := tg.AddNodes(node.RenterTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploader := nodes[0]
+	defer func() {
+		if err := tg.RemoveNode(uploader); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = uploader.SkykeyAddKeyPost(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload a skyfile using the public half.
+	data := fastrand.Bytes(100 + siatest.Fuzz())
+	filename := "testEncryptX25519PublicHalf"
+	uploadSiaPath, err := modules.NewSiaPath(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sup := modules.SkyfileUploadParameters{
+		SiaPath:             uploadSiaPath,
+		BaseChunkRedundancy: 2,
+		Filename:            filename,
+		Mode:                0640,
+		Reader:              bytes.NewReader(data),
+		SkykeyName:          keyName,
+	}
+	skylink, _, err := uploader.SkynetSkyfilePost(sup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The uploader can't decrypt the skyfile.
+	_, _, err = uploader.SkynetSkylinkGet(skylink)
+	if err == nil {
+		t.Fatal("Expected error when downloading with the public half only")
+	}
+
+	// The holder of the private half can.
+	fetchedData, metadata, err := r.SkynetSkylinkGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fetchedData, data) {
+		t.Fatal("upload and download doesn't match")
+	}
+	if metadata.Filename != filename {
+		t.Fatal("bad filename")
+	}
+}
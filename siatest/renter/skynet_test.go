@@ -85,6 +85,14 @@ func TestSkynet(t *testing.T) {
 		{Name: "DownloadByRoot", Test: testSkynetDownloadByRootNoEncryption},
 		{Name: "DownloadByRootEncrypted", Test: testSkynetDownloadByRootEncrypted},
 		{Name: "FanoutRegression", Test: testSkynetFanoutRegression},
+		{Name: "SkylinkV2", Test: testSkynetSkylinkV2},
+		{Name: "PackedUpload", Test: testSkynetPackedUpload},
+		{Name: "TemporaryUpload", Test: testSkynetTemporaryUpload},
+		{Name: "UnpinSkylink", Test: testSkynetUnpinSkylink},
+		{Name: "SkylinkHealth", Test: testSkynetSkylinkHealth},
+		{Name: "ArchiveUpload", Test: testSkynetArchiveUpload},
+		{Name: "Compression", Test: testSkynetCompression},
+		{Name: "TryFilesErrorPages", Test: testSkynetTryFilesErrorPages},
 	}
 
 	// Run tests
@@ -3814,3 +3822,771 @@ func TestSkynetCleanupOnError(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 }
+
+// testSkynetSkylinkV2 tests downloading skyfiles using v2 skylinks which are
+// resolved through the registry.
+func testSkynetSkylinkV2(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload two skyfiles.
+	sl1, _, _, err := r.UploadNewSkyfileBlocking(t.Name()+"1", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl2, _, _, err := r.UploadNewSkyfileBlocking(t.Name()+"2", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var skylink1, skylink2 modules.Skylink
+	if err := skylink1.LoadString(sl1); err != nil {
+		t.Fatal(err)
+	}
+	if err := skylink2.LoadString(sl2); err != nil {
+		t.Fatal(err)
+	}
+	data1, _, err := r.SkynetSkylinkGet(sl1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data2, _, err := r.SkynetSkylinkGet(sl2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the v2 skylink.
+	sk, pk := crypto.GenerateKeyPair()
+	var dataKey crypto.Hash
+	fastrand.Read(dataKey[:])
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	skylinkV2 := modules.NewSkylinkV2(spk, dataKey)
+	if !skylinkV2.IsSkylinkV2() {
+		t.Fatal("expected v2 skylink")
+	}
+
+	// Downloading the v2 skylink should fail before the entry exists.
+	_, _, err = r.SkynetSkylinkGet(skylinkV2.String())
+	if err == nil {
+		t.Fatal("expected download of unset v2 skylink to fail")
+	}
+
+	// Point the v2 skylink at the first skyfile.
+	srv := modules.NewRegistryValue(dataKey, skylink1.Bytes(), 0).Sign(sk)
+	err = r.RegistryUpdate(spk, dataKey, srv.Revision, srv.Signature, skylink1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _, err := r.SkynetSkylinkGet(skylinkV2.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, data1) {
+		t.Fatal("wrong data")
+	}
+	_, header, err := r.SkynetSkylinkHead(skylinkV2.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Skynet-Resolved-Skylink") != sl1 {
+		t.Fatalf("expected resolved skylink %v but got %v", sl1, header.Get("Skynet-Resolved-Skylink"))
+	}
+
+	// Update the entry to point at the second skyfile.
+	srv = modules.NewRegistryValue(dataKey, skylink2.Bytes(), 1).Sign(sk)
+	err = r.RegistryUpdate(spk, dataKey, srv.Revision, srv.Signature, skylink2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _, err = r.SkynetSkylinkGet(skylinkV2.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, data2) {
+		t.Fatal("wrong data")
+	}
+
+	// Point the v2 skylink at itself. Resolving it should fail.
+	srv = modules.NewRegistryValue(dataKey, skylinkV2.Bytes(), 2).Sign(sk)
+	err = r.RegistryUpdate(spk, dataKey, srv.Revision, srv.Signature, skylinkV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = r.SkynetSkylinkGet(skylinkV2.String())
+	if err == nil || !strings.Contains(err.Error(), modules.ErrSkylinkV2ResolvingDepthExceeded.Error()) {
+		t.Fatal("expected resolving depth error", err)
+	}
+}
+
+// TestRegistrySubscription tests subscribing to registry entries using the
+// /skynet/registry/subscription endpoint.
+func TestRegistrySubscription(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testDir := renterTestDir(t.Name())
+
+	// Create a testgroup.
+	groupParams := siatest.GroupParams{
+		Hosts:   renter.MinUpdateRegistrySuccesses,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+
+	// Create some random skylinks to use.
+	skylink1, err := modules.NewSkylinkV1(crypto.HashBytes(fastrand.Bytes(100)), 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skylink2, err := modules.NewSkylinkV1(crypto.HashBytes(fastrand.Bytes(100)), 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create signed registry values.
+	sk, pk := crypto.GenerateKeyPair()
+	var dataKey crypto.Hash
+	fastrand.Read(dataKey[:])
+	srv1 := modules.NewRegistryValue(dataKey, skylink1.Bytes(), 0).Sign(sk)
+	srv2 := modules.NewRegistryValue(dataKey, skylink2.Bytes(), 1).Sign(sk)
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+
+	// Set the first value.
+	err = r.RegistryUpdate(spk, dataKey, srv1.Revision, srv1.Signature, skylink1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Subscribe to the entry.
+	sub, err := r.RegistrySubscribe([]types.SiaPublicKey{spk}, []crypto.Hash{dataKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := sub.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Helper to receive the next notification with a timeout.
+	type notification struct {
+		spk types.SiaPublicKey
+		srv modules.SignedRegistryValue
+		err error
+	}
+	notifications := make(chan notification, 100)
+	go func() {
+		for {
+			spk, srv, err := sub.Next()
+			notifications <- notification{spk, srv, err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	expectNotification := func(expected modules.SignedRegistryValue) {
+		t.Helper()
+		select {
+		case n := <-notifications:
+			if n.err != nil {
+				t.Fatal(n.err)
+			}
+			if !n.spk.Equals(spk) {
+				t.Fatal("wrong public key")
+			}
+			if !reflect.DeepEqual(n.srv, expected) {
+				t.Log(n.srv)
+				t.Log(expected)
+				t.Fatal("srvs don't match")
+			}
+		case <-time.After(time.Minute):
+			t.Fatal("no notification received")
+		}
+	}
+
+	// The initial value should be received once even though the renter is
+	// subscribed on multiple hosts.
+	expectNotification(srv1)
+
+	// Update the entry. The update should be received once as well.
+	err = r.RegistryUpdate(spk, dataKey, srv2.Revision, srv2.Signature, skylink2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectNotification(srv2)
+	select {
+	case n := <-notifications:
+		t.Fatal("unexpected notification", n)
+	case <-time.After(time.Second):
+	}
+
+	// Subscribing with a mismatched number of keys should fail.
+	_, err = r.RegistrySubscribe([]types.SiaPublicKey{spk}, nil)
+	if err == nil {
+		t.Fatal("expected subscription to fail")
+	}
+}
+
+// testSkynetPackedUpload tests uploading a batch of small files using the
+// /skynet/pack endpoint.
+func testSkynetPackedUpload(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Create a few small files.
+	var files []modules.SkyfilePackedFile
+	for i := 0; i < 3; i++ {
+		files = append(files, modules.SkyfilePackedFile{
+			Filename: fmt.Sprintf("file%v", i),
+			Mode:     0640,
+			Data:     fastrand.Bytes(100 * (i + 1)),
+		})
+	}
+
+	// Upload the files.
+	siaPath, err := modules.NewSiaPath(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sup := modules.SkyfileUploadParameters{
+		SiaPath: siaPath,
+	}
+	resp, err := r.SkynetPackPost(sup, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Skyfiles) != len(files) {
+		t.Fatalf("expected %v skyfiles but got %v", len(files), len(resp.Skyfiles))
+	}
+
+	// Every file should be downloadable using its own skylink.
+	for i, sf := range resp.Skyfiles {
+		if sf.Filename != files[i].Filename {
+			t.Fatal("wrong filename", sf.Filename, files[i].Filename)
+		}
+		data, md, err := r.SkynetSkylinkGet(sf.Skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, files[i].Data) {
+			t.Fatal("wrong data")
+		}
+		if md.Filename != files[i].Filename || md.Mode != files[i].Mode {
+			t.Fatal("wrong metadata", md)
+		}
+	}
+
+	// The packed sectors should be stored within the directory at the siapath.
+	dirSiaPath, err := modules.SkynetFolder.Join(siaPath.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := r.RenterDirRootGet(dirSiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dir.Files) == 0 {
+		t.Fatal("expected packed sectors to be stored in the directory")
+	}
+
+	// Uploading the same files again without force should fail since the
+	// sectors already exist.
+	_, err = r.SkynetPackPost(sup, files)
+	if err == nil {
+		t.Fatal("expected upload without force to fail")
+	}
+
+	// Uploading an empty batch should fail.
+	_, err = r.SkynetPackPost(sup, nil)
+	if err == nil {
+		t.Fatal("expected upload of empty batch to fail")
+	}
+}
+
+// testSkynetTemporaryUpload tests uploading a skyfile which is stored by the
+// hosts for a fixed number of blocks without a siafile.
+func testSkynetTemporaryUpload(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a small file temporarily.
+	siaPath, err := modules.NewSiaPath(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(100)
+	sup := modules.SkyfileUploadParameters{
+		SiaPath:  siaPath,
+		Filename: "temporary",
+		Mode:     0640,
+		Reader:   bytes.NewReader(data),
+	}
+	skylink, _, err := r.SkynetSkyfileTemporaryPost(sup, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file should be downloadable.
+	downloaded, md, err := r.SkynetSkylinkGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("wrong data")
+	}
+	if md.Filename != sup.Filename {
+		t.Fatal("wrong metadata", md)
+	}
+
+	// There shouldn't be a siafile for the temporary upload.
+	skynetSiaPath, err := modules.SkynetFolder.Join(siaPath.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterFileRootGet(skynetSiaPath)
+	if err == nil {
+		t.Fatal("temporary upload shouldn't create a siafile")
+	}
+
+	// A file which doesn't fit within a single sector can't be uploaded
+	// temporarily.
+	sup.Reader = bytes.NewReader(fastrand.Bytes(int(modules.SectorSize)))
+	_, _, err = r.SkynetSkyfileTemporaryPost(sup, 5)
+	if err == nil || !strings.Contains(err.Error(), renter.ErrTemporaryUploadTooLarge.Error()) {
+		t.Fatal("expected upload of large file to fail", err)
+	}
+}
+
+// testSkynetUnpinSkylink tests unpinning a skyfile by its skylink using the
+// /skynet/unpin endpoint.
+func testSkynetUnpinSkylink(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a small and a large skyfile.
+	small, sup, _, err := r.UploadNewSkyfileBlocking(t.Name()+"small", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallSiaPath, err := modules.SkynetFolder.Join(sup.SiaPath.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	large, sup, _, err := r.UploadNewSkyfileBlocking(t.Name()+"large", 2*modules.SectorSize, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	largeSiaPath, err := modules.SkynetFolder.Join(sup.SiaPath.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	largeExtendedSiaPath, err := modules.NewSiaPath(largeSiaPath.String() + renter.ExtendedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unpin the small skyfile.
+	ssup, err := r.SkynetSkylinkUnpinPost(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ssup.SiaPaths) != 1 || !ssup.SiaPaths[0].Equals(smallSiaPath) || len(ssup.UpdatedSiaPaths) != 0 {
+		t.Fatal("unexpected siapaths", ssup.SiaPaths, ssup.UpdatedSiaPaths)
+	}
+	if _, err := r.RenterFileRootGet(smallSiaPath); err == nil {
+		t.Fatal("expected small skyfile to be deleted")
+	}
+
+	// Unpin the large skyfile. This should delete the extended siafile too.
+	ssup, err = r.SkynetSkylinkUnpinPost(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ssup.SiaPaths) != 2 || !ssup.SiaPaths[0].Equals(largeSiaPath) || !ssup.SiaPaths[1].Equals(largeExtendedSiaPath) {
+		t.Fatal("unexpected siapaths", ssup.SiaPaths)
+	}
+	for _, siaPath := range ssup.SiaPaths {
+		if _, err := r.RenterFileRootGet(siaPath); err == nil {
+			t.Fatal("expected large skyfile to be deleted", siaPath)
+		}
+	}
+
+	// Unpinning the skylink again should fail.
+	_, err = r.SkynetSkylinkUnpinPost(small)
+	if err == nil || !strings.Contains(err.Error(), renter.ErrSkylinkNotPinned.Error()) {
+		t.Fatal("expected ErrSkylinkNotPinned", err)
+	}
+}
+
+// testSkynetSkylinkHealth tests the /skynet/health endpoint.
+func testSkynetSkylinkHealth(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a small and a large skyfile.
+	small, _, _, err := r.UploadNewSkyfileBlocking(t.Name()+"small", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	large, _, _, err := r.UploadNewSkyfileBlocking(t.Name()+"large", 2*modules.SectorSize, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The small skyfile only consists of its base sector.
+	health, err := r.SkynetSkylinkHealthGet(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.HostsQueried == 0 || health.HostsResponded == 0 {
+		t.Fatal("no hosts were queried", health.HostsQueried, health.HostsResponded)
+	}
+	if len(health.Chunks) != 0 {
+		t.Fatal("small skyfile shouldn't have chunks", len(health.Chunks))
+	}
+	if health.BaseSector.Redundancy < 1 || health.MinRedundancy != health.BaseSector.Redundancy {
+		t.Fatal("unexpected redundancy", health.BaseSector.Redundancy, health.MinRedundancy)
+	}
+
+	// The large skyfile has a fanout.
+	health, err = r.SkynetSkylinkHealthGet(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(health.Chunks) == 0 {
+		t.Fatal("large skyfile should have chunks")
+	}
+	if health.MinRedundancy < 1 {
+		t.Fatal("unexpected redundancy", health.MinRedundancy)
+	}
+	for _, chunk := range health.Chunks {
+		if chunk.AvailablePieces == 0 || len(chunk.Pieces) == 0 {
+			t.Fatal("chunk isn't available", chunk)
+		}
+	}
+
+	// An unknown skylink should fail.
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	unknown, err := modules.NewSkylinkV1(root, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.SkynetSkylinkHealthGet(unknown.String())
+	if err == nil {
+		t.Fatal("expected health of unknown skylink to fail")
+	}
+}
+
+// testSkynetArchiveUpload verifies that uploading a tar.gz archive results in
+// a multi-file skyfile.
+func testSkynetArchiveUpload(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Create a tar.gz archive with a few files.
+	files := map[string][]byte{
+		"index.html":   []byte("<html><body>index</body></html>"),
+		"dir/file.bin": fastrand.Bytes(100),
+	}
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, name := range []string{"index.html", "dir/file.bin"} {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     "./" + name,
+			Typeflag: tar.TypeReg,
+			Mode:     0640,
+			Size:     int64(len(files[name])),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := errors.Compose(tw.Close(), gzw.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload the archive.
+	siaPath, err := modules.NewSiaPath(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sup := modules.SkyfileUploadParameters{
+		SiaPath:  siaPath,
+		Filename: "site",
+		Reader:   &buf,
+	}
+	skylink, _, err := r.SkynetSkyfileArchivePost(sup, modules.SkyfileFormatTarGz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Download the files by their path.
+	for name, data := range files {
+		downloaded, md, err := r.SkynetSkylinkGet(skylink + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, data) {
+			t.Fatal("downloaded data doesn't match", name)
+		}
+		sf, exists := md.Subfiles[name]
+		if !exists || sf.FileMode != 0640 {
+			t.Fatal("unexpected subfile metadata", name, md.Subfiles)
+		}
+	}
+
+	// The root should serve the index.html by default.
+	downloaded, _, err := r.SkynetSkylinkGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, files["index.html"]) {
+		t.Fatal("root didn't serve the default path")
+	}
+}
+
+// testSkynetCompression verifies that compressed skyfiles are served either
+// encoded or decoded depending on the Accept-Encoding header of the request.
+func testSkynetCompression(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	uc := client.NewUnsafeClient(r.Client)
+
+	// Upload a compressed file.
+	data := bytes.Repeat([]byte("compressible data "), 1e4)
+	siaPath, err := modules.NewSiaPath(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sup := modules.SkyfileUploadParameters{
+		SiaPath:     siaPath,
+		Filename:    "file.txt",
+		Compression: modules.SkyfileContentEncodingGzip,
+		Reader:      bytes.NewReader(data),
+	}
+	skylink, _, err := r.SkynetSkyfilePost(sup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The regular client transparently decodes the file.
+	downloaded, md, err := r.SkynetSkylinkGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("downloaded data doesn't match")
+	}
+	if encoding, size := md.ContentEncoding(); encoding != modules.SkyfileContentEncodingGzip || size != uint64(len(data)) {
+		t.Fatal("unexpected content encoding", encoding, size)
+	}
+	if md.Length >= uint64(len(data)) {
+		t.Fatal("file wasn't compressed", md.Length)
+	}
+
+	// download is a helper that downloads the skylink with the given headers.
+	download := func(headers http.Header) (*http.Response, []byte) {
+		resp, err := uc.SkynetSkylinkGetWithHeaders(skylink, headers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, body
+	}
+
+	// A client that accepts gzip receives the encoded file.
+	resp, body := download(http.Header{"Accept-Encoding": []string{"gzip"}})
+	if resp.Header.Get("Content-Encoding") != modules.SkyfileContentEncodingGzip {
+		t.Fatal("expected encoded response", resp.Header)
+	}
+	if resp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Fatal("unexpected Vary header", resp.Header.Get("Vary"))
+	}
+	if uint64(len(body)) != md.Length {
+		t.Fatal("unexpected body length", len(body), md.Length)
+	}
+	gzr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ioutil.ReadAll(gzr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Fatal("decoded data doesn't match")
+	}
+	encodedETag := resp.Header.Get("ETag")
+
+	// A client that doesn't accept gzip receives the decoded file.
+	resp, body = download(http.Header{"Accept-Encoding": []string{"identity"}})
+	if resp.Header.Get("Content-Encoding") != "" {
+		t.Fatal("expected decoded response", resp.Header)
+	}
+	if !bytes.Equal(body, data) {
+		t.Fatal("decoded data doesn't match")
+	}
+	if resp.Header.Get("ETag") == encodedETag {
+		t.Fatal("encoded and decoded responses should have different ETags")
+	}
+
+	// Range requests on the decoded file are served from the decoded data.
+	resp, body = download(http.Header{
+		"Accept-Encoding": []string{"identity"},
+		"Range":           []string{"bytes=100-199"},
+	})
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatal("unexpected status code", resp.StatusCode)
+	}
+	if !bytes.Equal(body, data[100:200]) {
+		t.Fatal("range data doesn't match")
+	}
+
+	// Archives contain the decoded file.
+	_, reader, err := r.SkynetSkylinkTarReaderGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(reader)
+	header, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Name != "file.txt" || header.Size != int64(len(data)) {
+		t.Fatal("unexpected tar header", header.Name, header.Size)
+	}
+	tarData, err := ioutil.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tarData, data) {
+		t.Fatal("tar data doesn't match")
+	}
+}
+
+// testSkynetTryFilesErrorPages verifies that the try files and error pages of
+// a skyfile are honored when downloading it.
+func testSkynetTryFilesErrorPages(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	uc := client.NewUnsafeClient(r.Client)
+
+	// upload is a helper that uploads a multipart skyfile with the given try
+	// files and error pages.
+	files := []siatest.TestFile{
+		{Name: "index.html", Data: []byte("index")},
+		{Name: "404.html", Data: []byte("not found")},
+		{Name: "about/index.html", Data: []byte("about")},
+	}
+	upload := func(name string, tryFiles []string, errorPages map[int]string) string {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		var offset uint64
+		for _, tf := range files {
+			_, err := modules.AddMultipartFile(writer, tf.Data, "files[]", tf.Name, modules.DefaultFilePerm, &offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		siaPath, err := modules.NewSiaPath(t.Name() + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		skylink, _, err := r.SkynetSkyfileMultiPartPost(modules.SkyfileMultipartUploadParameters{
+			SiaPath:             siaPath,
+			BaseChunkRedundancy: 2,
+			Reader:              bytes.NewReader(body.Bytes()),
+			ContentType:         writer.FormDataContentType(),
+			Filename:            name,
+			TryFiles:            tryFiles,
+			ErrorPages:          errorPages,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return skylink
+	}
+
+	// download is a helper that downloads the given skylink and returns the
+	// status code and the body of the response.
+	download := func(skylink string) (int, []byte) {
+		resp, err := uc.SkynetSkylinkGetWithHeaders(skylink, http.Header{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, body
+	}
+
+	// Upload a skyfile with try files.
+	tryFiles := []string{modules.SkyfileTryFilesURI, modules.SkyfileTryFilesURI + "/index.html", "/index.html"}
+	skylink := upload("tryfiles", tryFiles, nil)
+	tests := []struct {
+		path string
+		data string
+	}{
+		{path: "/", data: "index"},
+		{path: "/404.html", data: "not found"},
+		{path: "/about", data: "about"},
+		{path: "/about/", data: "about"},
+		{path: "/some/client/route", data: "index"},
+	}
+	for _, test := range tests {
+		status, body := download(skylink + test.path)
+		if status != http.StatusOK {
+			t.Fatalf("unexpected status for '%v': %v", test.path, status)
+		}
+		if string(body) != test.data {
+			t.Fatalf("unexpected data for '%v': '%v' != '%v'", test.path, string(body), test.data)
+		}
+	}
+
+	// Upload a skyfile with an error page.
+	skylink = upload("errorpages", nil, map[int]string{http.StatusNotFound: "/404.html"})
+	status, body := download(skylink + "/index.html")
+	if status != http.StatusOK || string(body) != "index" {
+		t.Fatal("unexpected response", status, string(body))
+	}
+	status, body = download(skylink + "/missing")
+	if status != http.StatusNotFound {
+		t.Fatal("unexpected status", status)
+	}
+	if string(body) != "not found" {
+		t.Fatalf("unexpected data '%v'", string(body))
+	}
+}
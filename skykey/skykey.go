@@ -7,6 +7,7 @@ import (
 	"net/url"
 
 	"github.com/aead/chacha20/chacha"
+	"golang.org/x/crypto/curve25519"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
@@ -33,6 +34,17 @@ const (
 	// It must be at most MaxKeyNameLen plus the max entropy size for any
 	// cipher-type.
 	maxEntropyLen = 256
+
+	// x25519KeyLen is the length of the entropy of a master skykey of type
+	// TypeX25519Private or TypeX25519Public. It is either the secret or the
+	// public half of the X25519 key pair.
+	x25519KeyLen = 32
+
+	// x25519FileKeyLen is the length of the entropy of a file-specific skykey
+	// of type TypeX25519Private or TypeX25519Public. It consists of the shared
+	// secret, the nonce, the ID of the recipient's skykey and the ephemeral
+	// public key of the uploader.
+	x25519FileKeyLen = chacha.KeySize + chacha.XNonceSize + SkykeyIDLen + x25519KeyLen
 )
 
 // Define SkykeyTypes. Constants stated explicitly (instead of
@@ -51,6 +63,18 @@ const (
 	// successfully decrypted with the correct skykey.
 	TypePrivateID = SkykeyType(0x02)
 
+	// TypeX25519Private is the private half of an X25519 key pair. Skyfiles
+	// encrypted for the matching TypeX25519Public skykey can only be decrypted
+	// by the holder of this skykey. Files are encrypted using XChaCha20 with a
+	// key derived from the shared secret of the recipient's key pair and an
+	// ephemeral key pair generated by the uploader.
+	TypeX25519Private = SkykeyType(0x03)
+
+	// TypeX25519Public is the public half of an X25519 key pair. It can be
+	// shared with others to allow them to upload skyfiles that only the holder
+	// of the private half can decrypt. It can't be used to decrypt skyfiles.
+	TypeX25519Public = SkykeyType(0x04)
+
 	// typeDeletedSkykey is used internally to mark a key as deleted in the
 	// skykey manager. It is different from TypeInvalid because TypeInvalid can
 	// be used to catch other kinds of errors, i.e. accidentally using a
@@ -64,6 +88,7 @@ var (
 	SkykeySpecifier               = types.NewSpecifier("Skykey")
 	skyfileEncryptionIDSpecifier  = types.NewSpecifier("SkyfileEncID")
 	skyfileEncryptionIDDerivation = types.NewSpecifier("SFEncIDDerivPath")
+	x25519NonceDerivation         = types.NewSpecifier("X25519NonceDeriv")
 
 	errUnsupportedSkykeyType            = errors.New("Unsupported Skykey type")
 	errUnmarshalDataErr                 = errors.New("Unable to unmarshal Skykey data")
@@ -106,6 +131,10 @@ func (t SkykeyType) ToString() string {
 		return "public-id"
 	case TypePrivateID:
 		return "private-id"
+	case TypeX25519Private:
+		return "x25519-private"
+	case TypeX25519Public:
+		return "x25519-public"
 	default:
 		return "invalid"
 	}
@@ -118,6 +147,10 @@ func (t *SkykeyType) FromString(s string) error {
 		*t = TypePublicID
 	case "private-id":
 		*t = TypePrivateID
+	case "x25519-private":
+		*t = TypeX25519Private
+	case "x25519-public":
+		*t = TypeX25519Public
 	default:
 		return ErrInvalidSkykeyType
 	}
@@ -202,13 +235,18 @@ func (sk *Skykey) unmarshalAndConvertFromOldFormat(r io.Reader) error {
 // CipherType returns the crypto.CipherType used by this Skykey.
 func (t SkykeyType) CipherType() crypto.CipherType {
 	switch t {
-	case TypePublicID, TypePrivateID:
+	case TypePublicID, TypePrivateID, TypeX25519Private, TypeX25519Public:
 		return crypto.TypeXChaCha20
 	default:
 		return crypto.TypeInvalid
 	}
 }
 
+// isX25519 returns true if the type is one of the halves of an X25519 key pair.
+func (t SkykeyType) isX25519() bool {
+	return t == TypeX25519Private || t == TypeX25519Public
+}
+
 // CipherType returns the crypto.CipherType used by this Skykey.
 func (sk *Skykey) CipherType() crypto.CipherType {
 	return sk.Type.CipherType()
@@ -224,6 +262,8 @@ func (sk *Skykey) unmarshalDataOnly(r io.Reader) error {
 	switch sk.Type {
 	case TypePublicID, TypePrivateID:
 		entropyLen = chacha.KeySize + chacha.XNonceSize
+	case TypeX25519Private, TypeX25519Public:
+		entropyLen = x25519KeyLen
 	case TypeInvalid:
 		return errCannotMarshalTypeInvalidSkykey
 	case typeDeletedSkykey:
@@ -272,6 +312,8 @@ func (sk Skykey) marshalDataOnly(w io.Writer) error {
 	switch sk.Type {
 	case TypePublicID, TypePrivateID:
 		entropyLen = chacha.KeySize + chacha.XNonceSize
+	case TypeX25519Private, TypeX25519Public:
+		entropyLen = x25519KeyLen
 	case TypeInvalid:
 		return errCannotMarshalTypeInvalidSkykey
 	default:
@@ -369,6 +411,21 @@ func (sk Skykey) ID() (keyID SkykeyID) {
 	case TypePublicID, TypePrivateID:
 		entropy = sk.Entropy[:chacha.KeySize]
 
+	// Both halves of an X25519 key pair share the ID of the public half.
+	// File-specific keys contain the ID of the recipient's skykey.
+	case TypeX25519Private, TypeX25519Public:
+		if len(sk.Entropy) == x25519FileKeyLen {
+			copy(keyID[:], sk.Entropy[chacha.KeySize+chacha.XNonceSize:])
+			return keyID
+		}
+		pk, err := sk.x25519PublicKey()
+		if err != nil {
+			build.Critical("Computing ID with invalid X25519 skykey: ", err)
+		}
+		h := crypto.HashAll(SkykeySpecifier, TypeX25519Public, pk)
+		copy(keyID[:], h[:SkykeyIDLen])
+		return keyID
+
 	default:
 		build.Critical("Computing ID with skykey of unknown type: ", sk.Type)
 	}
@@ -378,6 +435,38 @@ func (sk Skykey) ID() (keyID SkykeyID) {
 	return keyID
 }
 
+// PublicHalf returns the TypeX25519Public skykey that belongs to this skykey.
+// It can be shared with others to allow them to encrypt skyfiles for the owner
+// of this skykey. Only X25519 skykeys have a public half.
+func (sk Skykey) PublicHalf() (Skykey, error) {
+	if !sk.Type.isX25519() || len(sk.Entropy) != x25519KeyLen {
+		return Skykey{}, errSkykeyTypeDoesNotSupportFunction
+	}
+	pk, err := sk.x25519PublicKey()
+	if err != nil {
+		return Skykey{}, err
+	}
+	return Skykey{sk.Name, TypeX25519Public, pk[:]}, nil
+}
+
+// x25519PublicKey returns the public key of a master X25519 skykey.
+func (sk Skykey) x25519PublicKey() (pk crypto.X25519PublicKey, err error) {
+	if len(sk.Entropy) != x25519KeyLen {
+		return pk, errInvalidEntropyLength
+	}
+	switch sk.Type {
+	case TypeX25519Private:
+		var xsk crypto.X25519SecretKey
+		copy(xsk[:], sk.Entropy)
+		curve25519.ScalarBaseMult((*[32]byte)(&pk), (*[32]byte)(&xsk))
+	case TypeX25519Public:
+		copy(pk[:], sk.Entropy)
+	default:
+		return pk, errSkykeyTypeDoesNotSupportFunction
+	}
+	return pk, nil
+}
+
 // ToString encodes the SkykeyID as a base64 string.
 func (id SkykeyID) ToString() string {
 	return base64.URLEncoding.EncodeToString(id[:])
@@ -412,12 +501,64 @@ func (sk *Skykey) equalData(otherKey Skykey) bool {
 // given nonce, so this method is used to generate keys with new nonces when a
 // new file is uploaded.
 func (sk *Skykey) GenerateFileSpecificSubkey() (Skykey, error) {
+	// X25519 skykeys derive the file-specific key from a new ephemeral key
+	// pair instead of using a random nonce.
+	if sk.Type.isX25519() {
+		pk, err := sk.x25519PublicKey()
+		if err != nil {
+			return Skykey{}, err
+		}
+		esk, epk := crypto.GenerateX25519KeyPair()
+		return sk.x25519FileKey(crypto.DeriveSharedSecret(esk, pk), epk), nil
+	}
+
 	// Generate a new random nonce.
 	nonce := make([]byte, chacha.XNonceSize)
 	fastrand.Read(nonce[:])
 	return sk.SubkeyWithNonce(nonce)
 }
 
+// X25519Subkey returns the file-specific skykey of a skyfile which was
+// encrypted for this skykey using the given ephemeral public key. Only the
+// private half of an X25519 key pair can derive the file-specific key.
+func (sk *Skykey) X25519Subkey(epk crypto.X25519PublicKey) (Skykey, error) {
+	if sk.Type != TypeX25519Private {
+		return Skykey{}, errSkykeyTypeDoesNotSupportFunction
+	}
+	if len(sk.Entropy) != x25519KeyLen {
+		return Skykey{}, errInvalidEntropyLength
+	}
+	var xsk crypto.X25519SecretKey
+	copy(xsk[:], sk.Entropy)
+	return sk.x25519FileKey(crypto.DeriveSharedSecret(xsk, epk), epk), nil
+}
+
+// x25519FileKey creates the file-specific skykey for the given shared secret
+// and ephemeral public key. The nonce is derived from the ephemeral public key
+// which is unique for every file.
+func (sk *Skykey) x25519FileKey(secret [32]byte, epk crypto.X25519PublicKey) Skykey {
+	nonce := crypto.HashAll(x25519NonceDerivation, epk)
+	id := sk.ID()
+
+	entropy := make([]byte, 0, x25519FileKeyLen)
+	entropy = append(entropy, secret[:]...)
+	entropy = append(entropy, nonce[:chacha.XNonceSize]...)
+	entropy = append(entropy, id[:]...)
+	entropy = append(entropy, epk[:]...)
+	return Skykey{sk.Name, sk.Type, entropy}
+}
+
+// EphemeralPublicKey returns the ephemeral public key of a file-specific X25519
+// skykey. It is stored in the layout of the skyfile to allow the recipient to
+// derive the file-specific key.
+func (sk *Skykey) EphemeralPublicKey() (epk crypto.X25519PublicKey, err error) {
+	if !sk.Type.isX25519() || len(sk.Entropy) != x25519FileKeyLen {
+		return epk, errSkykeyTypeDoesNotSupportFunction
+	}
+	copy(epk[:], sk.Entropy[x25519FileKeyLen-x25519KeyLen:])
+	return epk, nil
+}
+
 // DeriveSubkey is used to create Skykeys with the same key, but with a
 // different nonce. This is used to create file-specific keys, and separate keys
 // for Skyfile baseSector uploads and fanout uploads.
@@ -436,13 +577,25 @@ func (sk *Skykey) SubkeyWithNonce(nonce []byte) (Skykey, error) {
 	if len(nonce) != chacha.XNonceSize {
 		return Skykey{}, errors.New("Incorrect nonce size")
 	}
+	// The entropy of a master X25519 skykey is not a XChaCha20 key, subkeys
+	// can only be derived from its file-specific keys.
+	entropyLen := chacha.KeySize + chacha.XNonceSize
+	if sk.Type.isX25519() {
+		if len(sk.Entropy) != x25519FileKeyLen {
+			return Skykey{}, errSkykeyTypeDoesNotSupportFunction
+		}
+		entropyLen = x25519FileKeyLen
+	}
 
-	entropy := make([]byte, chacha.KeySize+chacha.XNonceSize)
+	// Subkeys of X25519 skykeys keep the recipient's key ID and the ephemeral
+	// public key which follow the nonce.
+	entropy := make([]byte, entropyLen)
 	copy(entropy[:chacha.KeySize], sk.Entropy[:chacha.KeySize])
 	copy(entropy[chacha.KeySize:], nonce[:])
+	copy(entropy[chacha.KeySize+chacha.XNonceSize:], sk.Entropy[chacha.KeySize+chacha.XNonceSize:])
 
 	// Sanity check that we can actually make a CipherKey with this.
-	_, err := crypto.NewSiaKey(sk.CipherType(), entropy)
+	_, err := crypto.NewSiaKey(sk.CipherType(), entropy[:chacha.KeySize+chacha.XNonceSize])
 	if err != nil {
 		return Skykey{}, errors.AddContext(err, "error creating new skykey subkey")
 	}
@@ -522,7 +675,13 @@ func (sk *Skykey) MatchesSkyfileEncryptionID(encryptionID, nonce []byte) (bool,
 
 // CipherKey returns the crypto.CipherKey equivalent of this Skykey.
 func (sk *Skykey) CipherKey() (crypto.CipherKey, error) {
-	return crypto.NewSiaKey(sk.CipherType(), sk.Entropy)
+	entropy := sk.Entropy
+	// File-specific X25519 skykeys contain additional data after the key and
+	// nonce.
+	if sk.Type.isX25519() && len(entropy) > chacha.KeySize+chacha.XNonceSize {
+		entropy = entropy[:chacha.KeySize+chacha.XNonceSize]
+	}
+	return crypto.NewSiaKey(sk.CipherType(), entropy)
 }
 
 // Nonce returns the nonce of this Skykey.
@@ -544,6 +703,12 @@ func (sk *Skykey) IsValid() error {
 			return errInvalidEntropyLength
 		}
 
+	case TypeX25519Private, TypeX25519Public:
+		if len(sk.Entropy) != x25519KeyLen {
+			return errInvalidEntropyLength
+		}
+		return nil
+
 	default:
 		return errUnsupportedSkykeyType
 	}
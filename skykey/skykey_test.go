@@ -1017,3 +1017,144 @@ func TestSkykeyDeleteCompat(t *testing.T) {
 		t.Fatal("keys don't match")
 	}
 }
+
+// TestSkykeyX25519 tests the creation of X25519 skykeys, the export of their
+// public halves and the derivation of file-specific keys.
+func TestSkykeyX25519(t *testing.T) {
+	// Create a key manager.
+	persistDir := build.TempDir("skykey", t.Name())
+	keyMan, err := NewSkykeyManager(persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Public halves can't be created.
+	_, err = keyMan.CreateKey("public", TypeX25519Public)
+	if !errors.Contains(err, errCannotCreatePublicHalf) {
+		t.Fatal("Expected errCannotCreatePublicHalf", err)
+	}
+
+	sk, err := keyMan.CreateKey("x25519", TypeX25519Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sk.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The public half should have the same name and ID and survive a round trip
+	// through its string representation.
+	pk, err := sk.PublicHalf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pk.Type != TypeX25519Public || pk.Name != sk.Name || pk.ID() != sk.ID() {
+		t.Fatal("unexpected public half", pk)
+	}
+	if bytes.Equal(pk.Entropy, sk.Entropy) {
+		t.Fatal("public half shouldn't contain the secret key")
+	}
+	pkStr, err := pk.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decodedPK Skykey
+	if err := decodedPK.FromString(pkStr); err != nil {
+		t.Fatal(err)
+	}
+	if !decodedPK.equals(pk) {
+		t.Fatal("public half doesn't match after round trip")
+	}
+	pk2, err := pk.PublicHalf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk2.equals(pk) {
+		t.Fatal("public half of a public half should be the same key")
+	}
+
+	// The public half can't be added next to the private half since they share
+	// an ID.
+	pk.Name = "public"
+	err = keyMan.AddKey(pk)
+	if !errors.Contains(err, ErrSkykeyWithIDAlreadyExists) {
+		t.Fatal("Expected ErrSkykeyWithIDAlreadyExists", err)
+	}
+
+	// Other types don't have a public half.
+	otherKey, err := keyMan.CreateKey("private-id", TypePrivateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = otherKey.PublicHalf()
+	if !errors.Contains(err, errSkykeyTypeDoesNotSupportFunction) {
+		t.Fatal("Expected errSkykeyTypeDoesNotSupportFunction", err)
+	}
+
+	// Master X25519 keys can't be used as XChaCha20 keys.
+	_, err = sk.SubkeyWithNonce(fastrand.Bytes(chacha.XNonceSize))
+	if !errors.Contains(err, errSkykeyTypeDoesNotSupportFunction) {
+		t.Fatal("Expected errSkykeyTypeDoesNotSupportFunction", err)
+	}
+
+	// Generate file-specific keys using the public half. The private half
+	// should derive the same keys from the ephemeral public keys.
+	fsKey1, err := pk.GenerateFileSpecificSubkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsKey2, err := pk.GenerateFileSpecificSubkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(fsKey1.Entropy, fsKey2.Entropy) {
+		t.Fatal("Expected different file-specific keys")
+	}
+	for _, fsKey := range []Skykey{fsKey1, fsKey2} {
+		if fsKey.ID() != sk.ID() {
+			t.Fatal("Expected file-specific key to have the ID of the master key")
+		}
+		if _, err := fsKey.CipherKey(); err != nil {
+			t.Fatal(err)
+		}
+		epk, err := fsKey.EphemeralPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		privFSKey, err := sk.X25519Subkey(epk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(privFSKey.Entropy, fsKey.Entropy) {
+			t.Fatal("Expected private half to derive the same file-specific key")
+		}
+
+		// Derived subkeys keep the ID.
+		dk, err := fsKey.DeriveSubkey([]byte("derivation"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dk.ID() != sk.ID() || bytes.Equal(dk.Nonce(), fsKey.Nonce()) {
+			t.Fatal("unexpected derived subkey", dk)
+		}
+
+		// The public half can't derive the file-specific key.
+		_, err = pk.X25519Subkey(epk)
+		if !errors.Contains(err, errSkykeyTypeDoesNotSupportFunction) {
+			t.Fatal("Expected errSkykeyTypeDoesNotSupportFunction", err)
+		}
+	}
+
+	// The key should be loaded from a fresh persist.
+	freshKeyMan, err := NewSkykeyManager(persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadedKey, err := freshKeyMan.KeyByName(sk.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loadedKey.equals(sk) {
+		t.Fatal("keys don't match")
+	}
+}
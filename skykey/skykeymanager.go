@@ -63,6 +63,10 @@ var (
 
 	// errSkykeyNameToolong indicates that the name is too long.
 	errSkykeyNameToolong = errors.New("Skykey name exceeds max length")
+
+	// errCannotCreatePublicHalf indicates that a public X25519 skykey can't be
+	// created without its private half.
+	errCannotCreatePublicHalf = errors.New("Public X25519 skykeys can't be created, create a private key and export its public half instead")
 )
 
 // SkykeyManager manages the creation and handling of new skykeys which can be
@@ -221,9 +225,19 @@ func (sm *SkykeyManager) CreateKey(name string, skykeyType SkykeyType) (Skykey,
 		return Skykey{}, ErrSkykeyWithNameAlreadyExists
 	}
 
-	// Generate the new key.
-	cipherKey := crypto.GenerateSiaKey(skykeyType.CipherType())
-	skykey := Skykey{name, skykeyType, cipherKey.Key()}
+	// Generate the new key. X25519 skykeys only store the secret half of the
+	// key pair, the public half can be derived from it.
+	var skykey Skykey
+	switch skykeyType {
+	case TypeX25519Private:
+		xsk, _ := crypto.GenerateX25519KeyPair()
+		skykey = Skykey{name, skykeyType, xsk[:]}
+	case TypeX25519Public:
+		return Skykey{}, errCannotCreatePublicHalf
+	default:
+		cipherKey := crypto.GenerateSiaKey(skykeyType.CipherType())
+		skykey = Skykey{name, skykeyType, cipherKey.Key()}
+	}
 
 	err := sm.saveKey(skykey)
 	if err != nil {
@@ -310,7 +324,7 @@ func (sm *SkykeyManager) Skykeys() []Skykey {
 // skykeys with the given type.
 func (sm *SkykeyManager) SupportsSkykeyType(skykeyType SkykeyType) bool {
 	switch skykeyType {
-	case TypePublicID, TypePrivateID:
+	case TypePublicID, TypePrivateID, TypeX25519Private, TypeX25519Public:
 		return true
 	default:
 		return false